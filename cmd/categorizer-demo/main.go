@@ -28,6 +28,9 @@ func main() {
 	}
 
 	fmt.Printf("Loaded %d patterns\n", cat.PatternCount())
+	for _, warning := range cat.LoadWarnings() {
+		fmt.Printf("  Warning: %s\n", warning)
+	}
 	fmt.Println()
 
 	// Test transactions
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// categorize suggests categories for the placeholder postings of a ledger, or of an
+// import file written for it, and applies those at or above a confidence threshold,
+// returning the exit status: with --dry-run nothing is written, and with --interactive
+// each suggestion is confirmed first
+func categorize(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	dryRun := flags.Bool("dry-run", false, "print the suggestions without writing them")
+	interactive := flags.Bool("interactive", false, "ask before applying each suggestion")
+	minConfidence := flags.Float64("min-confidence", cfg.Categorization.AutoThreshold, "lowest confidence to apply, 0.0-1.0; defaults to categorization.auto_threshold")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if *minConfidence < 0 || *minConfidence > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --min-confidence must be between 0.0 and 1.0, got %g\n", *minConfidence)
+		return 2
+	}
+	cfg, path, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	if cfg.UI.ReadOnly && !*dryRun {
+		fmt.Fprintln(os.Stderr, "Error: read-only mode: use --dry-run to see the suggestions")
+		return 2
+	}
+	if *interactive && jsonOutput {
+		fmt.Fprintln(os.Stderr, "Error: --interactive asks on the terminal, so it can't be used with --output json")
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		return 2
+	}
+
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	for _, warning := range cat.LoadWarnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if cfg.Categorization.SimilarityEnabled {
+		cat.TrainSimilarity(append(ledgerHistory(cfg, path), transactions...))
+	}
+	candidates, err := cat.Candidates(transactions, *minConfidence)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	result := categorizeJSON{header: newHeader("categorize"), File: absPath(path), MinConfidence: *minConfidence, Suggestions: []suggestionJSON{}}
+	if len(candidates) == 0 {
+		if jsonOutput {
+			if err := writeJSON(result); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 2
+			}
+			return 0
+		}
+		fmt.Printf("No suggestions at or above %.0f%% confidence\n", *minConfidence*100)
+		return 0
+	}
+
+	writer := beancount.NewWriter(file)
+	writer.SetBackup(backup.FromConfig(cfg.Backup).Hook())
+	in := bufio.NewReader(os.Stdin)
+	var edits []beancount.Edit
+	var audit []categorizer.AuditEntry
+	applyAll := !*interactive
+	for _, candidate := range candidates {
+		tx := candidate.Transaction
+		payee := tx.Payee
+		if payee == "" {
+			payee = tx.Narration
+		}
+		if jsonOutput {
+			result.Suggestions = append(result.Suggestions, suggestionJSON{
+				File:       absPath(tx.FilePath),
+				Line:       tx.Postings[candidate.PostingIndex].LineNumber,
+				Date:       tx.Date.Format("2006-01-02"),
+				Payee:      payee,
+				Account:    tx.Postings[candidate.PostingIndex].Account,
+				Category:   candidate.Suggestion.Category,
+				Confidence: candidate.Suggestion.Confidence,
+				Source:     string(candidate.Suggestion.Source),
+			})
+		} else {
+			fmt.Printf("%s:%d: %s %s: %s -> %s (%.0f%%, %s)\n",
+				displayPath(tx.FilePath), tx.Postings[candidate.PostingIndex].LineNumber,
+				tx.Date.Format("2006-01-02"), payee, tx.Postings[candidate.PostingIndex].Account,
+				candidate.Suggestion.Category, candidate.Suggestion.Confidence*100, candidate.Suggestion.Source)
+		}
+		if *dryRun {
+			continue
+		}
+		if !applyAll {
+			fmt.Print("Apply? [y]es, [n]o, [a]ll, [q]uit: ")
+			answer, err := in.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if err != nil && answer == "" {
+				// Input ran out: stop asking and keep what was accepted
+				fmt.Println()
+				break
+			}
+			if answer == "q" || answer == "quit" {
+				break
+			}
+			if answer == "a" || answer == "all" {
+				applyAll = true
+			} else if answer != "y" && answer != "yes" {
+				continue
+			}
+		}
+		edit, err := writer.SetPostingAccount(tx, candidate.PostingIndex, candidate.Suggestion.Category)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s:%d: %v\n", displayPath(tx.FilePath), tx.LineNumber, err)
+			continue
+		}
+		edits = append(edits, edit)
+		audit = append(audit, categorizer.NewAuditEntry(candidate, categorizer.AuditApplied))
+		if jsonOutput {
+			result.Suggestions[len(result.Suggestions)-1].Applied = true
+		}
+	}
+
+	if *dryRun && jsonOutput {
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	if *dryRun {
+		fmt.Printf("%d suggestions at or above %.0f%% confidence; nothing written\n", len(candidates), *minConfidence*100)
+		return 0
+	}
+	if err := writer.ApplyAll(edits); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+		return 2
+	}
+	// The ledger is written; a failing audit log only warrants a warning
+	if cfg.Categorization.AuditLog != "" {
+		if err := categorizer.NewAuditLog(cfg.Categorization.AuditLog).Record(audit...); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if jsonOutput {
+		result.Applied = len(edits)
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	fmt.Printf("Categorized %d of %d transactions\n", len(edits), len(candidates))
+	return 0
+}
+
+// ledgerHistory returns the transactions of the default ledger when path is another
+// file, such as an import, so similarity can learn from what is already categorized
+func ledgerHistory(cfg *config.Config, path string) []*beancount.Transaction {
+	ledger := cfg.Files.DefaultLedger
+	if ledger == "" || absPath(ledger) == absPath(path) {
+		return nil
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		return nil
+	}
+	return transactions
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/stats"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// check reports the ledger's problems without starting the TUI, one per line as
+// file:line: kind: message, and returns the exit status: 0 when there are none, 1
+// when there are some and 2 when the ledger can't be read
+func check(cfg *config.Config, ledger string) int {
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	problems, err := file.Check()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking file: %v\n", err)
+		return 2
+	}
+	// Patterns filing transactions under accounts the ledger doesn't open are warned
+	// about with the open directive that would add the account; they don't fail the check
+	var warnings []categorizer.CategoryWarning
+	if cat, err := categorizer.New(cfg); err == nil {
+		warnings = cat.SetKnownAccounts(file.DeclaredAccounts())
+	}
+	if jsonOutput {
+		result := checkJSON{header: newHeader("check"), Ledger: absPath(ledger), Problems: newProblems(problems), PatternWarnings: newPatternWarnings(warnings)}
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	} else {
+		for _, problem := range problems {
+			fmt.Printf("%s:%d: %s: %s\n", displayPath(problem.FilePath), problem.LineNumber, problem.Kind, problem.Message)
+		}
+		for _, warning := range warnings {
+			fmt.Printf("Warning: %s; to add the account: %s\n", warning, warning.OpenDirective)
+		}
+		fmt.Printf("%s: %s\n", displayPath(ledger), problemsSummary(len(problems)))
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// ledgerStats prints the ledger's measures: transactions per year and account, how
+// many need a category or don't balance, the largest, the dates spanned and the
+// commodities used, returning the exit status
+func ledgerStats(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	top := flags.Int("top", 5, "how many of the largest transactions to list")
+	accounts := flags.Int("accounts", 10, "how many of the busiest accounts to list; 0 lists all")
+	currency := flags.String("currency", cfg.Amounts.Currency, "currency to size transactions in with the ledger's prices; \"\" compares each commodity as it is")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	s, err := stats.Compute(file, *currency, *top)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if jsonOutput {
+		if err := writeJSON(newStats(ledger, s)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Transactions\t%d\n", s.Transactions)
+	if s.Transactions > 0 {
+		fmt.Fprintf(w, "Dates\t%s to %s\n", s.First.Format("2006-01-02"), s.Last.Format("2006-01-02"))
+	}
+	fmt.Fprintf(w, "Postings per transaction\t%.2f\n", s.AveragePostings())
+	fmt.Fprintf(w, "Uncategorized\t%d (%.1f%%)\n", s.Uncategorized, s.UncategorizedShare())
+	fmt.Fprintf(w, "Unbalanced\t%d\n", s.Unbalanced)
+	fmt.Fprintf(w, "Commodities\t%s\n", strings.Join(s.Commodities, ", "))
+	w.Flush()
+
+	if len(s.Years) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "YEAR\tTRANSACTIONS")
+		for _, c := range s.Years {
+			fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Transactions)
+		}
+		w.Flush()
+	}
+	if len(s.Accounts) > 0 {
+		fmt.Println()
+		listed := s.Accounts
+		if *accounts > 0 && len(listed) > *accounts {
+			listed = listed[:*accounts]
+		}
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ACCOUNT\tTRANSACTIONS")
+		for _, c := range listed {
+			fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Transactions)
+		}
+		w.Flush()
+		if len(listed) < len(s.Accounts) {
+			fmt.Printf("(%d more accounts)\n", len(s.Accounts)-len(listed))
+		}
+	}
+	if len(s.Largest) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DATE\tPAYEE\tNARRATION\tAMOUNT")
+		for _, large := range s.Largest {
+			tx := large.Transaction
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s %s\n", tx.Date.Format("2006-01-02"), tx.Payee, tx.Narration,
+				large.Amount.Number.StringFixed(2), large.Amount.Commodity)
+		}
+		w.Flush()
+	}
+	return 0
+}
+
+// problemsSummary counts a ledger's problems
+func problemsSummary(n int) string {
+	switch n {
+	case 0:
+		return "no problems"
+	case 1:
+		return "1 problem"
+	default:
+		return fmt.Sprintf("%d problems", n)
+	}
+}
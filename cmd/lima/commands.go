@@ -0,0 +1,615 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/cli"
+	"github.com/mmichie/lima/internal/crypt"
+	"github.com/mmichie/lima/internal/forecast"
+	"github.com/mmichie/lima/internal/importer"
+	"github.com/mmichie/lima/internal/query"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/reports"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// command is a subcommand and the function running it, which defines its flags on
+// flags before parsing args with them
+type command struct {
+	cli.Command
+	run func(cfg *config.Config, flags *flag.FlagSet, args []string) int
+}
+
+// ledgerFile completes a ledger argument
+var ledgerFile = []cli.Completion{{Files: true}}
+
+// commands returns lima's subcommands, in the order usage lists them; configPath and
+// profile are those lima paths reports
+func commands(configPath, profile string) []command {
+	return []command{
+		{cli.Command{
+			Name:       "paths",
+			Synopses:   []string{"[ledger]"},
+			Summary:    "Print where lima reads and writes its files.",
+			Positional: ledgerFile,
+		}, func(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+			return paths(cfg, flags, args, configPath, profile)
+		}},
+		{cli.Command{
+			Name:       "check",
+			Synopses:   []string{"[ledger]"},
+			Summary:    "Report the ledger's problems; the status is 1 when there are some and 2 when the ledger can't be read.",
+			Positional: ledgerFile,
+		}, checkLedger},
+		{cli.Command{
+			Name:       "stats",
+			Synopses:   []string{"[--top N] [--accounts N] [--currency C] [ledger]"},
+			Summary:    "Print how many transactions the ledger has and where, and how healthy they are.",
+			Values:     map[string]cli.Completion{"currency": {Dynamic: ledgerCommodities}},
+			Positional: ledgerFile,
+		}, ledgerStats},
+		{cli.Command{
+			Name:       "fmt",
+			Synopses:   []string{"[--check] [--diff] [--sort] [ledger]"},
+			Summary:    "Reformat the ledger and its includes.",
+			Positional: ledgerFile,
+		}, format},
+		{cli.Command{
+			Name:     "split",
+			Synopses: []string{"--by-year [--dir DIR] [--diff] [ledger]"},
+			Summary:  "Move the ledger's entries into one include file per year.",
+			Help: "The main file keeps its options and includes, and includes the year files, such as\n" +
+				"2024.beancount in --dir, from where the entries were. lima join puts them back.",
+			Values:     map[string]cli.Completion{"dir": {Files: true}},
+			Positional: ledgerFile,
+		}, splitLedger},
+		{cli.Command{
+			Name:       "join",
+			Synopses:   []string{"[--diff] [ledger]"},
+			Summary:    "Put the entries of the year files lima split wrote back into the ledger.",
+			Positional: ledgerFile,
+		}, joinLedger},
+		{cli.Command{
+			Name:     "query",
+			Synopses: []string{"[--format table|csv|json] QUERY [ledger]"},
+			Summary:  "Run a BQL-style query over the ledger's postings.",
+			Help: "Example: lima query \"SELECT account, sum(amount) WHERE year = 2024 GROUP BY account\"\n\n" +
+				"Columns: " + strings.Join(query.ColumnNames(), ", "),
+			Values:     map[string]cli.Completion{"format": {Words: export.Encodings}},
+			Positional: []cli.Completion{{}, {Files: true}},
+		}, runQuery},
+		{cli.Command{
+			Name:       "categorize",
+			Synopses:   []string{"[--dry-run] [--interactive] [--min-confidence N] [file]"},
+			Summary:    "Suggest categories for postings to a placeholder account, and apply the confident ones.",
+			Positional: ledgerFile,
+		}, categorize},
+		{cli.Command{
+			Name:     "payees merge",
+			Synopses: []string{"[--cluster N] [--name NAME] [--diff] [--write] [ledger]"},
+			Summary:  "List payees that look like one merchant spelled differently, and merge them under one name.",
+			Help: "Without --write the clusters are listed with the name each would be merged as; --cluster\n" +
+				"picks one of them by its number, and --name merges it under another name.",
+			Positional: ledgerFile,
+		}, mergePayees},
+		{cli.Command{
+			Name:     "import",
+			Synopses: []string{"[--importer NAME] [--format FORMAT] [--stage] [--min-confidence N] STATEMENT [ledger]"},
+			Summary:  "Read a bank statement, categorizing its transactions and skipping those already in the ledger.",
+			Values: map[string]cli.Completion{
+				"importer": {Dynamic: importerNames},
+				"format":   {Words: importer.Formats},
+				"account":  {Dynamic: ledgerAccounts},
+				"currency": {Dynamic: ledgerCommodities},
+			},
+			Positional: []cli.Completion{{Files: true}, {Files: true}},
+		}, importStatement},
+		{cli.Command{
+			Name:       "sync",
+			Synopses:   []string{"[--days N] [--stage] [--min-confidence N] [ledger]", "--claim SETUP_TOKEN"},
+			Summary:    "Pull new transactions from the bank aggregator.",
+			Positional: ledgerFile,
+		}, syncBank},
+		{cli.Command{
+			Name:       "prices fetch",
+			Synopses:   []string{"[--dry-run] [--refresh] [--currency C] [ledger]"},
+			Summary:    "Fetch the latest prices of the ledger's commodities into the prices file.",
+			Values:     map[string]cli.Completion{"currency": {Dynamic: ledgerCommodities}},
+			Positional: ledgerFile,
+		}, fetchPrices},
+		{cli.Command{
+			Name:     "export",
+			Synopses: []string{"[--format beancount|fava] [--query QUERY] [--output FILE] [ledger]"},
+			Summary:  "Export the ledger, or the transactions a query selects.",
+			Values: map[string]cli.Completion{
+				"format": {Words: exportFormats},
+				"output": {Files: true},
+			},
+			Positional: ledgerFile,
+		}, exportLedger},
+		{cli.Command{
+			Name:       "serve",
+			Synopses:   []string{"[--address HOST:PORT] [ledger]"},
+			Summary:    "Serve the ledger over a REST API.",
+			Positional: ledgerFile,
+		}, serveAPI},
+		{cli.Command{
+			Name:       "grpc",
+			Synopses:   []string{"[--address HOST:PORT] [ledger]"},
+			Summary:    "Offer the categorizer as a gRPC service.",
+			Positional: ledgerFile,
+		}, grpcCategorizer},
+		{cli.Command{
+			Name:       "mcp",
+			Synopses:   []string{"[ledger]"},
+			Summary:    "Answer an assistant's questions about the ledger over MCP.",
+			Help:       "Run by an assistant's MCP client, which speaks JSON-RPC on standard input and output.",
+			Positional: ledgerFile,
+		}, serveMCP},
+		{cli.Command{
+			Name:       "restore",
+			Synopses:   []string{"[--backup NAME] [ledger]"},
+			Summary:    "List the backups taken before lima wrote to the ledger, or put one back.",
+			Values:     map[string]cli.Completion{"backup": {Dynamic: backupNames}},
+			Positional: ledgerFile,
+		}, restore},
+		{cli.Command{
+			Name:     "watch",
+			Synopses: []string{"[--inbox DIR] [--interval D] [--no-categorize] [--min-confidence N] [ledger]"},
+			Summary:  "Keep checking and categorizing the ledger, importing statements dropped in an inbox.",
+			Values: map[string]cli.Completion{
+				"inbox":    {Dirs: true},
+				"currency": {Dynamic: ledgerCommodities},
+			},
+			Positional: ledgerFile,
+		}, watchLedger},
+		{cli.Command{
+			Name:     "report",
+			Synopses: []string{"REPORT [--period P] [--currency C] [--format table|csv|json] [ledger]"},
+			Summary:  "Print one of the Reports view's reports.",
+			Help:     "Reports: " + strings.Join(reports.Kinds, ", "),
+			Values: map[string]cli.Completion{
+				"currency": {Dynamic: ledgerCommodities},
+				"format":   {Words: export.Encodings},
+			},
+			Positional: []cli.Completion{{Words: reports.Kinds}, {Files: true}},
+		}, report},
+		{cli.Command{
+			Name:     "forecast",
+			Synopses: []string{"[--months N] [--history N] [--model average|linear] [--account A] [--currency C] [--format table|csv|json] [ledger]"},
+			Summary:  "Project account balances months ahead and flag months they may go negative.",
+			Help: "Recurring charges are expected on their dates from the accounts that paid them; other\n" +
+				"flows follow the average or linear trend of the months before.",
+			Values: map[string]cli.Completion{
+				"model":    {Words: forecast.Models},
+				"account":  {Dynamic: ledgerAccounts},
+				"currency": {Dynamic: ledgerCommodities},
+				"format":   {Words: export.Encodings},
+			},
+			Positional: ledgerFile,
+		}, forecastBalances},
+		{cli.Command{
+			Name:     "trips",
+			Synopses: []string{"[--tag-prefix P] [--event TYPE] [--currency C] [--format table|csv|json] [ledger]"},
+			Summary:  "Total the spending of each trip or event, by category and per day.",
+			Help: "A trip is the transactions tagged #trip-NAME, or the days an event \"location\" directive's\n" +
+				"value holds until the next one.",
+			Values: map[string]cli.Completion{
+				"currency": {Dynamic: ledgerCommodities},
+				"format":   {Words: export.Encodings},
+			},
+			Positional: ledgerFile,
+		}, tripSpending},
+		{cli.Command{
+			Name:     "portfolio",
+			Synopses: []string{"[--account A] [--date YYYY-MM-DD] [--format table|csv|json] [ledger]"},
+			Summary:  "Report investment holdings with their cost basis, gains and time-weighted return.",
+			Help: "Commodities held at cost, such as 10 VTI {200 USD}, are kept as lots sold oldest first and\n" +
+				"valued at the latest price, from price directives or those purchases and sales imply.",
+			Values: map[string]cli.Completion{
+				"account": {Dynamic: ledgerAccounts},
+				"format":  {Words: export.Encodings},
+			},
+			Positional: ledgerFile,
+		}, portfolioPerformance},
+		{cli.Command{
+			Name:     "shared",
+			Synopses: []string{"[--detail] [--currency C] [--format table|csv|json] [ledger]"},
+			Summary:  "Work out who owes whom for expenses split with other people.",
+			Help: "Transactions tagged #shared, or with shared_with, paid_by or share: 50% metadata, are\n" +
+				"split; settle: NAME metadata marks a payment between you and NAME.",
+			Values: map[string]cli.Completion{
+				"currency": {Dynamic: ledgerCommodities},
+				"format":   {Words: export.Encodings},
+			},
+			Positional: ledgerFile,
+		}, sharedBalances},
+		{cli.Command{
+			Name:     "tax",
+			Synopses: []string{"[--year Y] [--detail] [--currency C] [--format table|csv|json] [ledger]"},
+			Summary:  "Total the configured tax groups by tax year, for an accountant.",
+			Help: "Groups and the day tax years start are set under tax: in the config. --detail lists\n" +
+				"each posting counted.",
+			Values: map[string]cli.Completion{
+				"currency": {Dynamic: ledgerCommodities},
+				"format":   {Words: export.Encodings},
+			},
+			Positional: ledgerFile,
+		}, taxSummary},
+		{cli.Command{
+			Name:     "completion",
+			Synopses: []string{"bash|zsh|fish"},
+			Summary:  "Print the shell completion script for a shell.",
+			Help: "Load it in bash with: source <(lima completion bash)\n" +
+				"In zsh, write it to a directory in $fpath as _lima.\n" +
+				"In fish, write it to ~/.config/fish/completions/lima.fish.",
+			Positional: []cli.Completion{{Words: cli.Shells}},
+		}, nil},
+		{cli.Command{
+			Name:     "man",
+			Synopses: []string{"[--dir DIR]"},
+			Summary:  "Print the man page, or write every page to a directory.",
+			Help:     "Read it with: lima man | man -l -",
+			Values:   map[string]cli.Completion{"dir": {Dirs: true}},
+		}, nil},
+		{cli.Command{Name: "__complete", Hidden: true}, nil},
+	}
+}
+
+// program describes lima's command line with cfg's defaults, running each command
+// with -h so its flags are defined
+func program(cfg *config.Config, commands []command) cli.Program {
+	lima := cli.Program{
+		Name:     "lima",
+		Synopsis: "[ledger | [user@]host:path]",
+		Summary:  "a terminal UI for Beancount ledgers",
+		Help: "Without a command lima opens the ledger, the configured default ledger when none is given, " +
+			"in its terminal UI. A ledger given as [user@]host:path is fetched over SFTP.\n\n" +
+			"The commands work without the UI, for scripts and servers; with --output json they print " +
+			"their results as JSON.",
+		Flags: flag.CommandLine,
+		Values: map[string]cli.Completion{
+			"profile":  {Dynamic: profileNames},
+			"config":   {Files: true},
+			"patterns": {Files: true},
+			"view":     {Words: config.ViewNames},
+			"theme":    {Words: config.ThemeNames},
+			"output":   {Words: outputFormats},
+		},
+		Positional: ledgerFile,
+		Environment: [][2]string{
+			{config.ProfileEnv, "The configuration profile to use when --profile is not given."},
+			{"NO_COLOR", "Render without colors when set."},
+			{"XDG_CONFIG_HOME", "Where the lima directory holding the configuration is."},
+			{"XDG_DATA_HOME", "Where the lima directory holding patterns, budgets, importers and the audit log is."},
+			{"XDG_CACHE_HOME", "Where the lima directory holding recent files and session state is."},
+		},
+	}
+	for _, c := range commands {
+		flags := newFlags(c.Command)
+		flags.SetOutput(io.Discard)
+		if c.run != nil {
+			c.run(cfg, flags, []string{"-h"})
+		} else {
+			defineFlags(c.Name, flags)
+		}
+		c.Command.Flags = flags
+		lima.Commands = append(lima.Commands, c.Command)
+	}
+	return lima
+}
+
+// newFlags returns a command's flag set, its usage the command's own
+func newFlags(c cli.Command) *flag.FlagSet {
+	flags := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), c.Usage("lima"))
+		defined := false
+		flags.VisitAll(func(*flag.Flag) { defined = true })
+		if defined {
+			fmt.Fprint(flags.Output(), "\nFlags:\n")
+			flags.PrintDefaults()
+		}
+	}
+	return flags
+}
+
+// defineFlags defines the flags of the commands lima itself is the subject of
+func defineFlags(name string, flags *flag.FlagSet) {
+	if name == "man" {
+		flags.String("dir", "", "directory to write lima.1 and a page per command to, rather than printing lima.1")
+	}
+}
+
+// parseStatus returns the exit status after a command's flags could not be parsed:
+// asked for with -h, the usage is all that was wanted
+func parseStatus(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return 2
+}
+
+// runCommand runs the command args name, returning false when they name none; a name
+// cut short, such as lima prices, prints the usage of the command it starts
+func runCommand(cfg *config.Config, commands []command, args []string) (int, bool) {
+	lima := program(cfg, commands)
+	found, rest, ok := lima.Find(args)
+	if !ok {
+		for _, c := range lima.Commands {
+			if words := strings.Fields(c.Name); len(args) > 0 && len(words) > 1 && words[0] == args[0] {
+				c.Flags.SetOutput(os.Stderr)
+				c.Flags.Usage()
+				return 2, true
+			}
+		}
+		return 0, false
+	}
+	flags := newFlags(found)
+	switch found.Name {
+	case "completion":
+		return completion(lima, flags, rest), true
+	case "man":
+		return manPages(program(config.DefaultConfig(), commands), flags, rest), true
+	case "__complete":
+		completionConfig = cfg
+		for _, candidate := range lima.Complete(rest) {
+			fmt.Println(candidate)
+		}
+		return 0, true
+	}
+	i := slices.IndexFunc(commands, func(c command) bool { return c.Name == found.Name })
+	return commands[i].run(cfg, flags, rest), true
+}
+
+// paths prints where lima reads and writes its files, for the ledger given
+func paths(cfg *config.Config, flags *flag.FlagSet, args []string, configPath, profile string) int {
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	ledger := cfg.Files.DefaultLedger
+	if flags.NArg() > 0 {
+		ledger = flags.Arg(0)
+	}
+	printPaths(cfg, configPath, profile, ledger)
+	return 0
+}
+
+// printPaths lists where lima reads and writes its files, as resolved for this user
+// with the config file at configPath, a profile and a ledger's project file
+func printPaths(cfg *config.Config, configPath, profile, ledger string) {
+	if jsonOutput {
+		result := pathsJSON{
+			header:    newHeader("paths"),
+			ConfigDir: config.ConfigDir(),
+			DataDir:   config.DataDir(),
+			CacheDir:  config.CacheDir(),
+			Profile:   profile,
+			Locations: []pathJSON{},
+		}
+		cfg, project, err := cfg.ForLedger(ledger)
+		result.ProjectFile = project
+		if err != nil {
+			result.ProjectError = err.Error()
+		}
+		for _, location := range cfg.Locations(configPath) {
+			result.Locations = append(result.Locations, pathJSON{Name: location.Name, Path: location.Path})
+		}
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Config directory:\t%s\n", config.ConfigDir())
+	fmt.Fprintf(w, "Data directory:\t%s\n", config.DataDir())
+	fmt.Fprintf(w, "Cache directory:\t%s\n", config.CacheDir())
+	fmt.Fprintln(w)
+
+	if profile != "" {
+		fmt.Fprintf(w, "Profile:\t%s\n", profile)
+	}
+	cfg, project, err := cfg.ForLedger(ledger)
+	if err != nil {
+		fmt.Fprintf(w, "Project file:\t%s (ignored: %v)\n", project, err)
+	} else if project != "" {
+		fmt.Fprintf(w, "Project file:\t%s\n", project)
+	}
+	for _, location := range cfg.Locations(configPath) {
+		path := location.Path
+		if path == "" {
+			path = "(not set)"
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", location.Name, path)
+	}
+	w.Flush()
+}
+
+// checkLedger checks the ledger given, or the default one
+func checkLedger(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	return check(cfg, ledger)
+}
+
+// completion prints the completion script for a shell
+func completion(lima cli.Program, flags *flag.FlagSet, args []string) int {
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	script, err := lima.Script(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	fmt.Print(script)
+	return 0
+}
+
+// manPages prints lima's man page, or with --dir writes every page there
+func manPages(lima cli.Program, flags *flag.FlagSet, args []string) int {
+	defineFlags("man", flags)
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+	pages := lima.ManPages(time.Now())
+	dir := flags.Lookup("dir").Value.String()
+	if dir == "" {
+		fmt.Print(string(pages["lima.1"]))
+		return 0
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	names := make([]string, 0, len(pages))
+	for name := range pages {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pages[name], 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if !jsonOutput {
+			fmt.Println("Wrote " + path)
+		}
+	}
+	return 0
+}
+
+// Dynamic completions, which read the configuration and the ledger being typed
+
+// completionConfig is the configuration dynamic completions read, set by __complete
+var completionConfig *config.Config
+
+// profileNames completes the configuration's profiles
+func profileNames([]string) []string {
+	return completionConfig.ProfileNames()
+}
+
+// completionLedger returns the ledger among the arguments typed, the last that looks
+// like one, or the default ledger
+func completionLedger(args []string) string {
+	for i := len(args) - 1; i >= 0; i-- {
+		switch strings.ToLower(filepath.Ext(args[i])) {
+		case ".beancount", ".bean", ".age", ".gpg", ".asc":
+			return args[i]
+		}
+	}
+	return completionConfig.Files.DefaultLedger
+}
+
+// openCompletionLedger opens the ledger among the arguments, or returns nil
+func openCompletionLedger(args []string) *beancount.File {
+	ledger := completionLedger(args)
+	if ledger == "" {
+		return nil
+	}
+	// Completing never asks for a passphrase: a ledger that needs one is skipped
+	store := beancount.NewStore(crypt.New(completionConfig.Encryption))
+	file, err := store.Open(config.ExpandHome(ledger))
+	if err != nil {
+		return nil
+	}
+	return file
+}
+
+// ledgerAccounts completes the ledger's account names
+func ledgerAccounts(args []string) []string {
+	file := openCompletionLedger(args)
+	if file == nil {
+		return nil
+	}
+	defer file.Close()
+	accounts := slices.Clone(file.DeclaredAccounts())
+	slices.Sort(accounts)
+	return accounts
+}
+
+// ledgerCommodities completes the ledger's commodities, the configured currency first
+func ledgerCommodities(args []string) []string {
+	var commodities []string
+	if currency := completionConfig.Amounts.Currency; currency != "" {
+		commodities = append(commodities, currency)
+	}
+	if file := openCompletionLedger(args); file != nil {
+		defer file.Close()
+		found := slices.Clone(file.GetCommodities())
+		slices.Sort(found)
+		commodities = append(commodities, found...)
+	}
+	return commodities
+}
+
+// importerNames completes the importers of the importers file and the registered ones
+func importerNames([]string) []string {
+	importers, err := importer.ReadFile(completionConfig.Files.ImportersFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	names := importer.Names(importers.Importers)
+	if plugins, err := importers.Plugins(); err == nil {
+		for _, plugin := range plugins {
+			names = append(names, plugin.Name())
+		}
+	}
+	return names
+}
+
+// backupNames completes the names of the ledger's backups
+func backupNames(args []string) []string {
+	ledger := completionLedger(args)
+	if ledger == "" {
+		return nil
+	}
+	cfg, _, _ := completionConfig.ForLedger(ledger)
+	if cfg.Backup.Dir == "" {
+		return nil
+	}
+	store := backup.New(config.ExpandHome(cfg.Backup.Dir), max(cfg.Backup.Keep, 1))
+	backups, err := ledgerBackups(cfg, store, ledger)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, b := range backups {
+		names = append(names, b.Name)
+	}
+	return names
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/fava"
+	"github.com/mmichie/lima/internal/query"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// exportFormats are the forms lima export writes
+var exportFormats = []string{"beancount", "fava"}
+
+// exportLedger writes the ledger, includes flattened into one file, as beancount or as
+// the JSON fava serves, or only what a query selects, returning the exit status
+func exportLedger(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	outputFormat := flags.String("format", "beancount", "output format: "+strings.Join(exportFormats, ", "))
+	queryText := flags.String("query", "", "export only what a query selects: with beancount the transactions its WHERE matches, with fava its result table")
+	output := flags.String("output", "", "file to write; defaults to standard output")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	if !slices.Contains(exportFormats, *outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: use %s\n", *outputFormat, strings.Join(exportFormats, ", "))
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	data, err := exportData(file, *outputFormat, *queryText)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	result := exportJSON{header: newHeader("export"), Format: *outputFormat}
+	if *output == "" {
+		if !jsonOutput {
+			os.Stdout.Write(data)
+			return 0
+		}
+		if *outputFormat == "fava" {
+			result.Fava = data
+		} else {
+			content := string(data)
+			result.Content = &content
+		}
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	path := config.ExpandHome(*output)
+	if abs, err := filepath.Abs(path); err == nil && abs == file.Path() {
+		fmt.Fprintln(os.Stderr, "Error: --output is the ledger itself")
+		return 2
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+		return 2
+	}
+	fmt.Fprintf(os.Stderr, "Exported %s to %s\n", displayPath(ledger), displayPath(path))
+	if jsonOutput {
+		result.File = absPath(path)
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	return 0
+}
+
+// exportData renders a ledger, or the part of it a query selects, in an export format
+func exportData(file *beancount.File, format, text string) ([]byte, error) {
+	var directives []beancount.Directive
+	var options []string
+	switch {
+	case text != "" && format == "fava":
+		result, err := query.Run(file, text)
+		if err != nil {
+			return nil, err
+		}
+		return fava.Query(result)
+	case text != "":
+		q, err := query.Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		all, err := file.AllTransactions()
+		if err != nil {
+			return nil, err
+		}
+		var transactions []*beancount.Transaction
+		for _, i := range file.TransactionsFrom(time.Time{}) {
+			transactions = append(transactions, all[i])
+		}
+		matched, err := q.Transactions(transactions)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range matched {
+			directives = append(directives, tx)
+		}
+	default:
+		var err error
+		if directives, err = file.Directives(); err != nil {
+			return nil, err
+		}
+		if options, err = file.Options(); err != nil {
+			return nil, err
+		}
+	}
+
+	if format == "fava" {
+		return fava.Entries(directives)
+	}
+	lines, err := beancount.FormatDirectives(directives)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) > 0 {
+		lines = append(append(options, ""), lines...)
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
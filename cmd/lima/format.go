@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// format reformats the ledger and its includes with the writer and returns the exit
+// status: with --check or --diff nothing is written, and --check fails when a file
+// would change
+func format(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	checkOnly := flags.Bool("check", false, "list the files that would change and fail if there are any")
+	diff := flags.Bool("diff", false, "print the changes as a unified diff instead of writing them")
+	sortByDate := flags.Bool("sort", false, "order dated entries by date between comments and undated lines")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	writer := beancount.NewWriter(file)
+	writer.SetReadOnly(cfg.UI.ReadOnly)
+	writer.SetBackup(backup.FromConfig(cfg.Backup).Hook())
+	edits, err := writer.Format(beancount.FormatOptions{SortByDate: *sortByDate})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error formatting file: %v\n", err)
+		return 2
+	}
+
+	// JSON reports the changes once they are written, or would be
+	result := formatJSON{header: newHeader("fmt"), Ledger: absPath(ledger), Files: []formattedFileJSON{}}
+	for _, edit := range edits {
+		result.Files = append(result.Files, formattedFileJSON{File: absPath(edit.FilePath), Diff: edit.UnifiedDiff()})
+	}
+	report := func() {
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}
+	for _, edit := range edits {
+		shown := edit
+		shown.FilePath = displayPath(edit.FilePath)
+		switch {
+		case jsonOutput:
+		case *diff:
+			fmt.Print(shown.UnifiedDiff())
+		case *checkOnly:
+			fmt.Println(shown.FilePath)
+		}
+	}
+	if jsonOutput && (*checkOnly || *diff) {
+		report()
+	}
+	if *checkOnly {
+		if len(edits) > 0 {
+			return 1
+		}
+		return 0
+	}
+	if *diff {
+		return 0
+	}
+
+	if err := writer.ApplyAll(edits); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+		return 2
+	}
+	if jsonOutput {
+		result.Written = true
+		report()
+		return 0
+	}
+	for _, edit := range edits {
+		fmt.Printf("Formatted %s\n", displayPath(edit.FilePath))
+	}
+	return 0
+}
+
+// splitLedger moves the dated entries of the ledger's main file into one include file
+// per year and returns the exit status; with --diff nothing is written
+func splitLedger(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	byYear := flags.Bool("by-year", false, "split the entries by year")
+	dir := flags.String("dir", ".", "directory of the year files, relative to the ledger")
+	diff := flags.Bool("diff", false, "print the changes as a unified diff instead of writing them")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if !*byYear {
+		fmt.Fprintln(os.Stderr, "Error: lima split needs --by-year")
+		return 2
+	}
+	return rewriteYears(cfg, flags, "split", *diff, func(writer *beancount.Writer) ([]beancount.Edit, []string, error) {
+		edits, err := writer.SplitByYear(*dir)
+		var years []string
+		for _, edit := range edits[min(1, len(edits)):] {
+			years = append(years, edit.FilePath)
+		}
+		return edits, years, err
+	})
+}
+
+// joinLedger puts the entries of the year files lima split wrote back into the ledger
+// and removes those files, returning the exit status; with --diff nothing is written
+func joinLedger(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	diff := flags.Bool("diff", false, "print the changes as a unified diff instead of writing them")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	return rewriteYears(cfg, flags, "join", *diff, func(writer *beancount.Writer) ([]beancount.Edit, []string, error) {
+		return writer.JoinYears()
+	})
+}
+
+// rewriteYears applies the edits of lima split or lima join to the ledger, or with diff
+// prints them; the year files of a join are backed up and removed once it is written
+func rewriteYears(cfg *config.Config, flags *flag.FlagSet, command string, diff bool, build func(*beancount.Writer) ([]beancount.Edit, []string, error)) int {
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	store := backup.FromConfig(cfg.Backup)
+	writer := beancount.NewWriter(file)
+	writer.SetReadOnly(cfg.UI.ReadOnly)
+	writer.SetBackup(store.Hook())
+	edits, years, err := build(writer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	result := splitJSON{header: newHeader(command), Ledger: absPath(ledger), Years: []string{}}
+	for _, year := range years {
+		result.Years = append(result.Years, absPath(year))
+	}
+	if diff {
+		result.Files = []formattedFileJSON{}
+		for _, edit := range edits {
+			result.Files = append(result.Files, formattedFileJSON{File: absPath(edit.FilePath), Diff: edit.UnifiedDiff()})
+			if !jsonOutput {
+				edit.FilePath = displayPath(edit.FilePath)
+				fmt.Print(edit.UnifiedDiff())
+			}
+		}
+	} else {
+		if err := writer.ApplyAll(edits); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+			return 2
+		}
+		if command == "join" {
+			for _, year := range years {
+				if err := store.Save(year); err != nil {
+					fmt.Fprintf(os.Stderr, "Error backing up %s: %v\n", displayPath(year), err)
+					return 2
+				}
+				if err := os.Remove(year); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					return 2
+				}
+				// The directory split made goes once it is empty
+				os.Remove(filepath.Dir(year))
+			}
+		}
+		result.Written = true
+	}
+
+	if jsonOutput {
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	if diff {
+		return 0
+	}
+	for _, year := range years {
+		if command == "join" {
+			fmt.Printf("Joined %s\n", displayPath(year))
+		} else {
+			fmt.Printf("Wrote %s\n", displayPath(year))
+		}
+	}
+	return 0
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/internal/banksync"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/importer"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// importStatement reads a bank statement with a configured importer, or a QIF file or
+// ledger-cli journal, leaves out transactions already in the ledger and categorizes
+// the rest, then prints them as beancount or with --stage appends them to the ledger,
+// returning the exit status
+func importStatement(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	name := flags.String("importer", "", "importer to read the statement with: a column mapping or external importer in the importers file, or a registered one; by default one that detects the statement, or the only column mapping for CSV")
+	format := flags.String("format", "", "format of the statement: "+strings.Join(importer.Formats, ", ")+"; by default from its extension (.qif, or .ledger, .journal and .hledger)")
+	account := flags.String("account", "", "account a QIF file's transactions are for when it has no !Account headers")
+	currency := flags.String("currency", cfg.Amounts.Currency, "commodity of QIF amounts and of $ in journals; defaults to amounts.currency")
+	dayFirst := flags.Bool("day-first", false, "QIF dates are day/month/year rather than month/day/year")
+	stage := flags.Bool("stage", false, "append the new transactions to the ledger, flagged ! for the review queue")
+	minConfidence := flags.Float64("min-confidence", cfg.Categorization.AutoThreshold, "lowest confidence to apply a category, 0.0-1.0; defaults to categorization.auto_threshold")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() == 0 || flags.NArg() > 2 {
+		flags.Usage()
+		return 2
+	}
+	if *minConfidence < 0 || *minConfidence > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --min-confidence must be between 0.0 and 1.0, got %g\n", *minConfidence)
+		return 2
+	}
+	if *format != "" && !slices.Contains(importer.Formats, *format) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: use %s\n", *format, strings.Join(importer.Formats, ", "))
+		return 2
+	}
+	if *account != "" && !beancount.IsValidAccount(*account) {
+		fmt.Fprintf(os.Stderr, "Error: invalid account %q\n", *account)
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 1)
+	if !ok {
+		return 2
+	}
+	if *stage && cfg.UI.ReadOnly {
+		fmt.Fprintln(os.Stderr, "Error: read-only mode: leave out --stage to print the transactions")
+		return 2
+	}
+
+	entries, err := readStatement(cfg, flags.Arg(0), statementOptions{
+		importer: *name,
+		format:   *format,
+		qif:      importer.QIFConfig{Account: *account, Currency: *currency, DayFirst: *dayFirst},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+	entries, err = processEntries(cfg, file, entries, importer.Options{MinConfidence: *minConfidence, Stage: *stage})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", displayPath(flags.Arg(0)), err)
+		return 2
+	}
+	result := newImport("import", ledger, entries, *stage)
+	result.Statement = absPath(flags.Arg(0))
+	return outputEntries(cfg, entries, file, ledger, *stage, "", result)
+}
+
+// statementOptions say how readStatement reads a statement
+type statementOptions struct {
+	importer string             // Importer to read it with; "" for one that detects it
+	format   string             // One of importer.Formats; "" for the plugins, then the extension
+	qif      importer.QIFConfig // How QIF files are read; its currency also applies to journals
+}
+
+// readStatement reads the entries of a bank statement with the importer options name,
+// or one that detects it, or by its format
+func readStatement(cfg *config.Config, path string, options statementOptions) ([]importer.Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	importers, fileErr := importer.ReadFile(cfg.Files.ImportersFile)
+	if fileErr != nil && !errors.Is(fileErr, fs.ErrNotExist) {
+		return nil, fileErr
+	}
+	plugins, err := importers.Plugins()
+	if err != nil {
+		return nil, err
+	}
+
+	// A named importer reads the statement whatever its format; without a name or
+	// format the first plugin that detects it does
+	var reader importer.Reader
+	if options.importer != "" {
+		if mapping, ok := importers.Importers[options.importer]; ok {
+			reader = mapping
+		} else if plugin, ok := importer.Find(plugins, options.importer); ok {
+			reader = importer.ReaderOf(plugin)
+		} else {
+			names := importer.Names(importers.Importers)
+			for _, plugin := range plugins {
+				names = append(names, plugin.Name())
+			}
+			slices.Sort(names)
+			return nil, fmt.Errorf("unknown importer %q: choose one of %s", options.importer, strings.Join(names, ", "))
+		}
+	} else if options.format == "" {
+		if plugin := importer.Detect(plugins, path, data); plugin != nil {
+			reader = importer.ReaderOf(plugin)
+		}
+	}
+	if reader == nil {
+		format := options.format
+		if format == "" {
+			format = importer.FormatOf(path)
+		}
+		switch format {
+		case "qif":
+			if options.qif.Currency == "" {
+				return nil, errors.New("give the commodity of the QIF file's amounts with --currency or amounts.currency")
+			}
+			reader = options.qif
+		case "ledger":
+			reader = importer.LedgerConfig{Currency: options.qif.Currency}
+		default:
+			if fileErr != nil {
+				return nil, fileErr
+			}
+			names := importer.Names(importers.Importers)
+			if len(names) != 1 {
+				return nil, fmt.Errorf("choose an importer with --importer: %s", strings.Join(names, ", "))
+			}
+			reader = importers.Importers[names[0]]
+		}
+	}
+	entries, err := reader.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", displayPath(path), err)
+	}
+	return entries, nil
+}
+
+// processEntries leaves out the imported entries already in the ledger and
+// categorizes the rest
+func processEntries(cfg *config.Config, file *beancount.File, entries []importer.Entry, options importer.Options) ([]importer.Entry, error) {
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		return nil, err
+	}
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Categorization.SimilarityEnabled {
+		cat.TrainSimilarity(transactions)
+	}
+	return importer.Process(entries, transactions, cat, options)
+}
+
+// outputEntries prints imported entries as beancount, or with stage appends them to
+// the ledger, summarizing them with note appended, and returns the exit status; with
+// --output json it prints result instead
+func outputEntries(cfg *config.Config, entries []importer.Entry, file *beancount.File, ledger string, stage bool, note string, result importJSON) int {
+	if stage {
+		if err := stageEntries(cfg, file, entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+			return 2
+		}
+	}
+	if jsonOutput {
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+
+	summary := entriesSummary(entries) + note
+	if !stage {
+		text, err := importer.Text(entries)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		fmt.Print(text)
+		fmt.Fprintln(os.Stderr, summary)
+		return 0
+	}
+	fmt.Printf("%s; staged in %s for review\n", summary, displayPath(ledger))
+	return 0
+}
+
+// entriesSummary counts imported entries: new, categorized and already in the ledger
+func entriesSummary(entries []importer.Entry) string {
+	var added, duplicates, categorized int
+	for _, entry := range entries {
+		switch {
+		case entry.Duplicate != nil:
+			duplicates++
+		case entry.Suggestion != nil:
+			categorized++
+			added++
+		default:
+			added++
+		}
+	}
+	return fmt.Sprintf("%d new transactions, %d categorized, %d already in the ledger", added, categorized, duplicates)
+}
+
+// stageEntries appends the new imported entries to the ledger for review
+func stageEntries(cfg *config.Config, file *beancount.File, entries []importer.Entry) error {
+	writer := beancount.NewWriter(file)
+	writer.SetBackup(backup.FromConfig(cfg.Backup).Hook())
+	edits, err := importer.Stage(writer, entries)
+	if err != nil {
+		return err
+	}
+	return writer.ApplyAll(edits)
+}
+
+// syncBank pulls the last days of transactions from the configured aggregator, leaves
+// out those already in the ledger and categorizes the rest, then prints them as
+// beancount or with --stage appends them to the ledger, returning the exit status;
+// --claim exchanges a SimpleFIN setup token for an access URL instead
+func syncBank(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	claim := flags.String("claim", "", "SimpleFIN setup token to exchange for the access URL sync.access_url_env should hold")
+	days := flags.Int("days", cfg.Sync.Days, "how many days back to fetch; defaults to sync.days")
+	stage := flags.Bool("stage", false, "append the new transactions to the ledger, flagged ! for the review queue")
+	minConfidence := flags.Float64("min-confidence", cfg.Categorization.AutoThreshold, "lowest confidence to apply a category, 0.0-1.0; defaults to categorization.auto_threshold")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if *claim != "" {
+		client := &http.Client{Timeout: time.Duration(max(cfg.Sync.TimeoutSeconds, 1)) * time.Second}
+		accessURL, err := banksync.Claim(context.Background(), *claim, client)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if jsonOutput {
+			writeJSON(claimJSON{header: newHeader("sync"), AccessURL: accessURL})
+		} else {
+			fmt.Println(accessURL)
+		}
+		fmt.Fprintf(os.Stderr, "Keep the access URL secret: set %s to it, e.g. in your shell profile\n", cfg.Sync.AccessURLEnv)
+		return 0
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	if *days < 1 {
+		fmt.Fprintf(os.Stderr, "Error: --days must be at least 1, got %d\n", *days)
+		return 2
+	}
+	if *minConfidence < 0 || *minConfidence > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --min-confidence must be between 0.0 and 1.0, got %g\n", *minConfidence)
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	if *stage && cfg.UI.ReadOnly {
+		fmt.Fprintln(os.Stderr, "Error: read-only mode: leave out --stage to print the transactions")
+		return 2
+	}
+	provider, err := banksync.New(cfg.Sync)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		return 2
+	}
+	settings := cfg.Sync
+	settings.Days = *days
+	result, err := banksync.Sync(context.Background(), provider, settings, transactions, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	for _, account := range result.Unmapped {
+		fmt.Fprintf(os.Stderr, "Skipped account %s (%s): map it to a beancount account under sync.accounts\n", account.ID, account.Name)
+	}
+
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if cfg.Categorization.SimilarityEnabled {
+		cat.TrainSimilarity(transactions)
+	}
+	entries, err := importer.Process(result.Entries, transactions, cat, importer.Options{MinConfidence: *minConfidence, Stage: *stage})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	output := newImport("sync", ledger, entries, *stage)
+	output.SyncedBefore, output.Pending = &result.Synced, &result.Pending
+	for _, account := range result.Unmapped {
+		output.Unmapped = append(output.Unmapped, unmappedJSON{ID: account.ID, Name: account.Name})
+	}
+	return outputEntries(cfg, entries, file, ledger, *stage, fmt.Sprintf(", %d synced before, %d pending", result.Synced, result.Pending), output)
+}
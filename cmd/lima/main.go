@@ -1,27 +1,106 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/crypt"
+	"github.com/mmichie/lima/internal/remote"
 	"github.com/mmichie/lima/internal/ui"
+	"github.com/mmichie/lima/internal/ui/fileopen"
+	"github.com/mmichie/lima/internal/ui/loading"
+	"github.com/mmichie/lima/internal/ui/setup"
+	"github.com/mmichie/lima/internal/ui/theme"
 	"github.com/mmichie/lima/pkg/config"
 )
 
+// version is the release, set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 func main() {
+	profile := flag.String("profile", os.Getenv(config.ProfileEnv), "configuration profile to use; defaults to $"+config.ProfileEnv)
+	configPath := flag.String("config", config.DefaultConfigPath(), "configuration file to read")
+	var overrides config.Overrides
+	flag.StringVar(&overrides.PatternsFile, "patterns", "", "patterns file to use instead of the configured one")
+	flag.StringVar(&overrides.View, "view", "", "view to open: "+strings.Join(config.ViewNames, ", "))
+	flag.StringVar(&overrides.Theme, "theme", "", "theme to use: "+strings.Join(config.ThemeNames, ", "))
+	flag.BoolVar(&overrides.ReadOnly, "readonly", false, "never write to the ledger")
+	flag.BoolVar(&overrides.WriteBack, "write-back", false, "write changes to a remote ledger back to the server")
+	flag.BoolVar(&overrides.NoColor, "no-color", os.Getenv("NO_COLOR") != "", "render without colors; defaults to true when $NO_COLOR is set")
+	output := flag.String("output", "text", "how subcommands print their results: "+strings.Join(outputFormats, ", ")+"; json follows a stable schema")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	flag.Usage = func() {
+		lima := program(config.DefaultConfig(), commands(*configPath, *profile))
+		fmt.Fprintf(flag.CommandLine.Output(), "%s\nFlags:\n", lima.Usage())
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+
+	if !slices.Contains(outputFormats, *output) {
+		fmt.Fprintf(os.Stderr, "Error: unknown output %q: use %s\n", *output, strings.Join(outputFormats, ", "))
+		os.Exit(2)
+	}
+	jsonOutput = *output == "json"
+	if *showVersion {
+		if jsonOutput {
+			writeJSON(versionJSON{header: newHeader("version"), Lima: version})
+			return
+		}
+		fmt.Println("lima " + version)
+		return
+	}
+	if overrides.PatternsFile != "" {
+		overrides.PatternsFile = absPath(overrides.PatternsFile)
+	}
+
+	// Move files out of ~/.config/lima into the XDG directories before reading them;
+	// lima mcp speaks its protocol on standard output, JSON output is only JSON and
+	// completions, scripts and man pages are read by programs, so they hear of it on
+	// stderr
+	notes := os.Stdout
+	if jsonOutput || len(args) > 0 && slices.Contains([]string{"mcp", "__complete", "completion", "man"}, args[0]) {
+		notes = os.Stderr
+	}
+	moves, err := config.Migrate()
+	for _, move := range moves {
+		fmt.Fprintf(notes, "Moved %s to %s\n", move.From, move.To)
+	}
+	if err != nil {
+		fmt.Fprintf(notes, "Warning: migrating files: %v\n", err)
+	}
+
 	// Load configuration
-	cfg, err := config.LoadDefault()
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		fmt.Printf("Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	cfg, err = cfg.Profile(*profile)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err = cfg.Override(overrides)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	if status, ok := runCommand(cfg, commands(*configPath, *profile), args); ok {
+		os.Exit(status)
+	}
 
 	// Check for file argument or use config default
 	var filename string
-	if len(os.Args) > 1 {
-		filename = os.Args[1]
+	if len(args) > 0 {
+		filename = args[0]
 	} else if cfg.Files.DefaultLedger != "" {
 		filename = cfg.Files.DefaultLedger
 	} else {
@@ -29,21 +108,165 @@ func main() {
 		filename = "testdata/sample.beancount"
 	}
 
-	// Open the Beancount file
-	file, err := beancount.Open(filename)
-	if err != nil {
-		fmt.Printf("Error opening file: %v\n", err)
-		os.Exit(1)
+	// Ledger files ending in .age, .gpg or .asc are only ever decrypted in memory
+	cipher := crypt.New(cfg.Encryption)
+	cipher.SetPrompt(crypt.AskTerminal)
+	store := beancount.NewStore(cipher)
+
+	// A ledger on another machine is fetched into a mirror, opened in its place; it is
+	// read-only unless changes are written back to the server
+	var mirror *remote.Mirror
+	if location, ok := remote.Parse(filename); ok {
+		fmt.Printf("Fetching %s...\n", location)
+		mirror, err = remote.Fetch(location, remote.CacheDir(config.CacheDir(), location), store)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		filename = mirror.Path()
+		if !cfg.Remote.WriteBack {
+			readOnly := cfg.Overrides()
+			readOnly.ReadOnly = true
+			if cfg, err = cfg.Override(readOnly); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(2)
+			}
+		}
+	}
+	// The mirror goes when lima does
+	exit := func(code int) {
+		if mirror != nil {
+			mirror.Close()
+		}
+		os.Exit(code)
+	}
+
+	// Build the index behind a loading screen, then hand over to the TUI, resuming
+	// the last session on this ledger
+	theme.Configure(cfg.Theme)
+	var file *beancount.File
+	open := func(cfg *config.Config, filename string) tea.Model {
+		return loading.New(filename, store, func(opened *beancount.File) tea.Model {
+			file = opened
+			model := ui.New(opened, cfg).WatchConfig(*configPath, *profile).RestoreSession()
+			if mirror != nil && filename == mirror.Path() {
+				return model.AfterWrite(mirror.Put)
+			}
+			// Offer the ledger under File > Open next time; the history is a convenience
+			_ = fileopen.Remember(cfg.Files.RecentFiles, filename)
+			return model
+		})
 	}
-	defer file.Close()
 
-	// Create the TUI with config
-	m := ui.New(file, cfg)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	// On the first run the setup wizard writes a config, then opens the ledger chosen
+	var start tea.Model
+	if _, err := os.Stat(*configPath); os.IsNotExist(err) {
+		var ledger string
+		if len(args) > 0 {
+			ledger = args[0]
+		}
+		start = setup.New(*configPath, ledger, func(written *config.Config) tea.Model {
+			// The options given still apply to the config just written
+			cfg, err := written.Override(overrides)
+			if err != nil {
+				cfg = written
+			}
+			return open(cfg, cfg.Files.DefaultLedger)
+		})
+	} else {
+		start = open(cfg, filename)
+	}
+	// Ask for the passphrase before the UI takes over the terminal; encrypted files
+	// opened from the UI need theirs in the environment
+	if err := cipher.Unlock(filename); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		exit(1)
+	}
+	cipher.SetPrompt(nil)
+	p := tea.NewProgram(start, tea.WithAltScreen())
 
 	// Run the program
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
 		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+
+	switch final := final.(type) {
+	case setup.Model:
+		fmt.Println("Setup cancelled; no config was written")
+	case loading.Model:
+		// Quit while loading, or the ledger could not be opened
+		if err := final.Err(); err != nil {
+			fmt.Printf("Error opening file: %v\n", err)
+			exit(1)
+		}
+	case ui.Model:
+		// Another ledger may have been opened; the session saved is the one shown last
+		if err := final.SaveSession(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		// The mirror goes on exit, so what is still to be written back can't wait
+		if err := final.FinishWriteBack(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+	if file != nil {
+		file.Close()
+	}
+	exit(0)
+}
+
+// ledgerStore returns the store a command reads and writes its ledger through,
+// decrypting encrypted files as cfg says and asking on the terminal for a passphrase
+// the environment doesn't hold
+func ledgerStore(cfg *config.Config) *beancount.Store {
+	cipher := crypt.New(cfg.Encryption)
+	cipher.SetPrompt(crypt.AskTerminal)
+	return beancount.NewStore(cipher)
+}
+
+// resolveLedger returns the ledger a command works on, the positional argument at i
+// or else the default ledger, with the configuration for it: the ledger's project file
+// applied over cfg. ok is false, after saying why, when no ledger is given or configured.
+func resolveLedger(cfg *config.Config, positional []string, i int) (*config.Config, string, bool) {
+	ledger := cfg.Files.DefaultLedger
+	if len(positional) > i {
+		ledger = positional[i]
+	}
+	if ledger == "" {
+		fmt.Fprintln(os.Stderr, "Error: no ledger given and no default ledger configured")
+		return cfg, "", false
+	}
+	cfg, _, err := cfg.ForLedger(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return cfg, ledger, true
+}
+
+// absPath makes a path given on the command line absolute, expanding ~
+func absPath(path string) string {
+	path = config.ExpandHome(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// displayPath shortens a path to one relative to the working directory when it is
+// inside it
+func displayPath(path string) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(dir, abs); err == nil && filepath.IsLocal(rel) {
+		return rel
 	}
+	return path
 }
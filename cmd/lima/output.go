@@ -0,0 +1,481 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/importer"
+	"github.com/mmichie/lima/internal/stats"
+)
+
+// Results of the subcommands as --output json writes them. Each command prints one
+// object naming the command and the schema version; lima watch prints one per line.
+// Within a version fields are only ever added, never renamed, removed or retyped, so
+// scripts can rely on the ones below. Paths are absolute, dates are YYYY-MM-DD and
+// amounts are decimal strings, so no precision is lost.
+
+// schemaVersion is the version of the schemas below
+const schemaVersion = 1
+
+// outputFormats are the forms --output writes results in
+var outputFormats = []string{"text", "json"}
+
+// jsonOutput is set by --output json
+var jsonOutput bool
+
+// header starts every result
+type header struct {
+	Command string `json:"command"`
+	Version int    `json:"version"`
+}
+
+// newHeader returns the header of a command's result
+func newHeader(command string) header {
+	return header{Command: command, Version: schemaVersion}
+}
+
+// writeJSON prints a result on standard output
+func writeJSON(result any) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(append(data, '\n'))
+	return err
+}
+
+// writeJSONLine prints a result on one line of standard output, for streams of them
+func writeJSONLine(result any) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(append(data, '\n'))
+	return err
+}
+
+// versionJSON is the result of lima --version
+type versionJSON struct {
+	header
+	Lima string `json:"lima"`
+}
+
+// problemJSON is a problem lima check found
+type problemJSON struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// patternWarningJSON is a pattern whose category is not an account in the ledger
+type patternWarningJSON struct {
+	Pattern       string `json:"pattern"`
+	Category      string `json:"category"`
+	Suggestion    string `json:"suggestion,omitempty"`
+	OpenDirective string `json:"open_directive"`
+}
+
+// checkJSON is the result of lima check
+type checkJSON struct {
+	header
+	Ledger          string               `json:"ledger"`
+	Problems        []problemJSON        `json:"problems"`
+	PatternWarnings []patternWarningJSON `json:"pattern_warnings"`
+}
+
+// newPatternWarnings returns pattern category warnings in their JSON form
+func newPatternWarnings(warnings []categorizer.CategoryWarning) []patternWarningJSON {
+	result := []patternWarningJSON{}
+	for _, warning := range warnings {
+		result = append(result, patternWarningJSON{
+			Pattern:       warning.PatternID,
+			Category:      warning.Category,
+			Suggestion:    warning.Suggestion,
+			OpenDirective: warning.OpenDirective,
+		})
+	}
+	return result
+}
+
+// newProblems returns problems in their JSON form
+func newProblems(problems []beancount.Problem) []problemJSON {
+	result := []problemJSON{}
+	for _, problem := range problems {
+		result = append(result, problemJSON{
+			File:    absPath(problem.FilePath),
+			Line:    problem.LineNumber,
+			Kind:    string(problem.Kind),
+			Message: problem.Message,
+		})
+	}
+	return result
+}
+
+// formattedFileJSON is a file lima fmt changes, with the change as a unified diff
+type formattedFileJSON struct {
+	File string `json:"file"`
+	Diff string `json:"diff"`
+}
+
+// formatJSON is the result of lima fmt; written is false with --check and --diff
+type formatJSON struct {
+	header
+	Ledger  string              `json:"ledger"`
+	Files   []formattedFileJSON `json:"files"`
+	Written bool                `json:"written"`
+}
+
+// splitJSON is the result of lima split and lima join: the year files written, or
+// joined and removed, and with --diff the changes, in which case written is false
+type splitJSON struct {
+	header
+	Ledger  string              `json:"ledger"`
+	Years   []string            `json:"years"`
+	Files   []formattedFileJSON `json:"files,omitempty"`
+	Written bool                `json:"written"`
+}
+
+// tableJSON is the result of lima query and lima report: the columns, and rows of
+// cells as the table shows them
+type tableJSON struct {
+	header
+	Report  string     `json:"report,omitempty"`
+	From    string     `json:"from,omitempty"` // First day of the report's period, "" for all time
+	To      string     `json:"to,omitempty"`   // First day after it
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// newTable returns a table's columns and rows in its JSON form
+func newTable(command string, columns []string, rows [][]string) tableJSON {
+	if rows == nil {
+		rows = [][]string{}
+	}
+	return tableJSON{header: newHeader(command), Columns: columns, Rows: rows}
+}
+
+// suggestionJSON is a category lima categorize suggests for a placeholder posting
+type suggestionJSON struct {
+	File       string  `json:"file"`
+	Line       int     `json:"line"` // The posting's line
+	Date       string  `json:"date"`
+	Payee      string  `json:"payee"`
+	Account    string  `json:"account"` // The placeholder account replaced
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+	Applied    bool    `json:"applied"`
+}
+
+// categorizeJSON is the result of lima categorize
+type categorizeJSON struct {
+	header
+	File          string           `json:"file"`
+	MinConfidence float64          `json:"min_confidence"`
+	Suggestions   []suggestionJSON `json:"suggestions"`
+	Applied       int              `json:"applied"`
+}
+
+// payeeVariantJSON is one spelling of a payee and how many transactions use it
+type payeeVariantJSON struct {
+	Name         string `json:"name"`
+	Transactions int    `json:"transactions"`
+}
+
+// payeeClusterJSON is a merchant's spellings and the name they are merged as; number
+// is what --cluster selects it by
+type payeeClusterJSON struct {
+	Number   int                `json:"number"`
+	Name     string             `json:"name"`
+	Variants []payeeVariantJSON `json:"variants"`
+	Renamed  int                `json:"renamed"`
+	Diff     string             `json:"diff,omitempty"`
+}
+
+// payeesMergeJSON is the result of lima payees merge; written is false unless --write
+type payeesMergeJSON struct {
+	header
+	Ledger   string             `json:"ledger"`
+	Clusters []payeeClusterJSON `json:"clusters"`
+	Written  bool               `json:"written"`
+}
+
+// postingJSON is a posting of an imported transaction; number and currency are ""
+// when the posting is auto-balanced
+type postingJSON struct {
+	Account  string `json:"account"`
+	Number   string `json:"number"`
+	Currency string `json:"currency"`
+}
+
+// locationJSON is where a transaction is in the ledger
+type locationJSON struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// entryJSON is a transaction read from a statement or pulled by lima sync
+type entryJSON struct {
+	Line       int           `json:"line"` // The statement's line, 0 for synced ones
+	Date       string        `json:"date"`
+	Flag       string        `json:"flag"`
+	Payee      string        `json:"payee"`
+	Narration  string        `json:"narration"`
+	Postings   []postingJSON `json:"postings"`
+	Category   string        `json:"category"`   // "" when left for review
+	Confidence float64       `json:"confidence"` // Of the category, 0 when there is none
+	Duplicate  *locationJSON `json:"duplicate"`  // The ledger transaction it repeats, null for a new one
+}
+
+// unmappedJSON is an aggregator account lima sync skipped
+type unmappedJSON struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// claimJSON is the result of lima sync --claim
+type claimJSON struct {
+	header
+	AccessURL string `json:"access_url"`
+}
+
+// importJSON is the result of lima import and lima sync
+type importJSON struct {
+	header
+	Ledger      string      `json:"ledger"`
+	Statement   string      `json:"statement,omitempty"`
+	Entries     []entryJSON `json:"entries"`
+	New         int         `json:"new"`
+	Categorized int         `json:"categorized"`
+	Duplicates  int         `json:"duplicates"`
+	Staged      bool        `json:"staged"`
+
+	// Only lima sync gives these
+	SyncedBefore *int           `json:"synced_before,omitempty"`
+	Pending      *int           `json:"pending,omitempty"`
+	Unmapped     []unmappedJSON `json:"unmapped,omitempty"`
+}
+
+// newImport returns imported entries in their JSON form, with their counts
+func newImport(command, ledger string, entries []importer.Entry, staged bool) importJSON {
+	result := importJSON{header: newHeader(command), Ledger: absPath(ledger), Entries: []entryJSON{}, Staged: staged}
+	for _, entry := range entries {
+		tx := entry.Transaction
+		item := entryJSON{
+			Line:      entry.Line,
+			Date:      tx.Date.Format("2006-01-02"),
+			Flag:      tx.Flag,
+			Payee:     tx.Payee,
+			Narration: tx.Narration,
+			Postings:  []postingJSON{},
+		}
+		for _, posting := range tx.Postings {
+			p := postingJSON{Account: posting.Account}
+			if posting.Amount != nil {
+				p.Number = posting.Amount.Number.String()
+				p.Currency = posting.Amount.Commodity
+			}
+			item.Postings = append(item.Postings, p)
+		}
+		switch {
+		case entry.Duplicate != nil:
+			item.Duplicate = &locationJSON{File: absPath(entry.Duplicate.FilePath), Line: entry.Duplicate.LineNumber}
+			result.Duplicates++
+		case entry.Suggestion != nil:
+			item.Category = entry.Suggestion.Category
+			item.Confidence = entry.Suggestion.Confidence
+			result.Categorized++
+			result.New++
+		default:
+			result.New++
+		}
+		result.Entries = append(result.Entries, item)
+	}
+	return result
+}
+
+// countJSON is how many transactions fall in a year or post to an account
+type countJSON struct {
+	Name         string `json:"name"`
+	Transactions int    `json:"transactions"`
+}
+
+// largeJSON is one of the largest transactions and its size, the total of its
+// positive postings
+type largeJSON struct {
+	Date      string       `json:"date"`
+	Payee     string       `json:"payee"`
+	Narration string       `json:"narration"`
+	Number    string       `json:"number"`
+	Currency  string       `json:"currency"`
+	Location  locationJSON `json:"location"`
+}
+
+// statsJSON is the result of lima stats; first and last are "" without transactions
+type statsJSON struct {
+	header
+	Ledger               string      `json:"ledger"`
+	Transactions         int         `json:"transactions"`
+	Postings             int         `json:"postings"`
+	AveragePostings      float64     `json:"average_postings"`
+	Uncategorized        int         `json:"uncategorized"`
+	UncategorizedPercent float64     `json:"uncategorized_percent"`
+	Unbalanced           int         `json:"unbalanced"`
+	First                string      `json:"first"`
+	Last                 string      `json:"last"`
+	Years                []countJSON `json:"years"`
+	Accounts             []countJSON `json:"accounts"`
+	Commodities          []string    `json:"commodities"`
+	Largest              []largeJSON `json:"largest"`
+}
+
+// newStats converts the measures of a ledger to their JSON form
+func newStats(ledger string, s stats.Stats) statsJSON {
+	result := statsJSON{
+		header:               newHeader("stats"),
+		Ledger:               absPath(ledger),
+		Transactions:         s.Transactions,
+		Postings:             s.Postings,
+		AveragePostings:      s.AveragePostings(),
+		Uncategorized:        s.Uncategorized,
+		UncategorizedPercent: s.UncategorizedShare(),
+		Unbalanced:           s.Unbalanced,
+		Years:                []countJSON{},
+		Accounts:             []countJSON{},
+		Commodities:          append([]string{}, s.Commodities...),
+		Largest:              []largeJSON{},
+	}
+	if s.Transactions > 0 {
+		result.First = s.First.Format("2006-01-02")
+		result.Last = s.Last.Format("2006-01-02")
+	}
+	for _, c := range s.Years {
+		result.Years = append(result.Years, countJSON{Name: c.Name, Transactions: c.Transactions})
+	}
+	for _, c := range s.Accounts {
+		result.Accounts = append(result.Accounts, countJSON{Name: c.Name, Transactions: c.Transactions})
+	}
+	for _, large := range s.Largest {
+		tx := large.Transaction
+		result.Largest = append(result.Largest, largeJSON{
+			Date:      tx.Date.Format("2006-01-02"),
+			Payee:     tx.Payee,
+			Narration: tx.Narration,
+			Number:    large.Amount.Number.String(),
+			Currency:  large.Amount.Commodity,
+			Location:  locationJSON{File: absPath(tx.FilePath), Line: tx.LineNumber},
+		})
+	}
+	return result
+}
+
+// priceJSON is a price directive lima prices fetch found
+type priceJSON struct {
+	Date      string `json:"date"`
+	Commodity string `json:"commodity"`
+	Number    string `json:"number"`
+	Currency  string `json:"currency"`
+}
+
+// failureJSON is a commodity lima prices fetch could not quote
+type failureJSON struct {
+	Commodity string `json:"commodity"`
+	Error     string `json:"error"`
+}
+
+// pricesJSON is the result of lima prices fetch; file is "" when nothing was appended
+type pricesJSON struct {
+	header
+	Prices    []priceJSON   `json:"prices"`
+	Cached    int           `json:"cached"`
+	Known     int           `json:"known"`
+	Unsourced []string      `json:"unsourced"`
+	Failed    []failureJSON `json:"failed"`
+	File      string        `json:"file"`
+}
+
+// exportJSON is the result of lima export: the export itself, or with --output the
+// file it was written to; fava exports are JSON themselves and so kept as they are
+type exportJSON struct {
+	header
+	Format  string          `json:"format"`
+	File    string          `json:"file,omitempty"`
+	Content *string         `json:"content,omitempty"`
+	Fava    json.RawMessage `json:"fava,omitempty"`
+}
+
+// backupJSON is a backup lima restore lists or restored
+type backupJSON struct {
+	Name string `json:"name"`
+	File string `json:"file"` // The file it copies
+	Time string `json:"time"` // When it was taken, in RFC 3339
+	Size int64  `json:"size"`
+}
+
+// newBackup returns a backup in its JSON form
+func newBackup(b backup.Backup) backupJSON {
+	return backupJSON{Name: b.Name, File: absPath(b.Of), Time: b.Time.Format(time.RFC3339Nano), Size: b.Size}
+}
+
+// backupsJSON is the result of lima restore: the backups, newest first
+type backupsJSON struct {
+	header
+	Backups []backupJSON `json:"backups"`
+}
+
+// restoredJSON is the result of lima restore --backup
+type restoredJSON struct {
+	header
+	Restored backupJSON `json:"restored"`
+}
+
+// pathJSON is one of the files lima paths lists; path is "" when unset
+type pathJSON struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// pathsJSON is the result of lima paths
+type pathsJSON struct {
+	header
+	ConfigDir    string     `json:"config_dir"`
+	DataDir      string     `json:"data_dir"`
+	CacheDir     string     `json:"cache_dir"`
+	Profile      string     `json:"profile"`
+	ProjectFile  string     `json:"project_file"`
+	ProjectError string     `json:"project_error,omitempty"` // Why the project file was ignored
+	Locations    []pathJSON `json:"locations"`
+}
+
+// Events lima watch reports
+const (
+	watchStarted       = "started"
+	watchLedgerChanged = "ledger_changed"
+	watchStatement     = "statement"
+)
+
+// watchJSON is an event of lima watch, one per line
+type watchJSON struct {
+	header
+	Time  string `json:"time"` // In RFC 3339
+	Event string `json:"event"`
+	Path  string `json:"path"` // The ledger, the file of it that changed, or the statement
+
+	// The ledger's problems after the event, and the postings categorized; with
+	// read-only, suggested counts those that would have been
+	Problems    int `json:"problems"`
+	Categorized int `json:"categorized"`
+	Suggested   int `json:"suggested"`
+
+	// For statements: what was imported, and where the statement was moved
+	Import  *importJSON `json:"import,omitempty"`
+	MovedTo string      `json:"moved_to,omitempty"`
+
+	// Errors met along the way; the event is still reported
+	Errors []string `json:"errors"`
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/payees"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// mergePayees lists the payees that look like one merchant spelled differently, each
+// cluster with the name its spellings would be merged as, and with --write renames
+// them, returning the exit status; --cluster picks one cluster and --name overrides
+// its name
+func mergePayees(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	number := flags.Int("cluster", 0, "merge only the cluster with this number, as listed")
+	name := flags.String("name", "", "name to merge the cluster as, instead of the proposed one (needs --cluster)")
+	diff := flags.Bool("diff", false, "print the changes as a unified diff")
+	write := flags.Bool("write", false, "rename the payees in the ledger")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if *name != "" && *number == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --name needs --cluster")
+		return 2
+	}
+	if strings.ContainsAny(*name, "\"\n") {
+		fmt.Fprintln(os.Stderr, "Error: a payee can't contain quotes")
+		return 2
+	}
+
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading transactions: %v\n", err)
+		return 2
+	}
+
+	clusters := payees.Find(transactions)
+	numbers := make([]int, len(clusters))
+	for i := range clusters {
+		numbers[i] = i + 1
+	}
+	if *number != 0 {
+		if *number < 1 || *number > len(clusters) {
+			fmt.Fprintf(os.Stderr, "Error: no cluster %d; there are %d\n", *number, len(clusters))
+			return 2
+		}
+		clusters = clusters[*number-1 : *number]
+		numbers = numbers[*number-1 : *number]
+		if *name != "" {
+			clusters[0].Name = strings.TrimSpace(*name)
+		}
+	}
+
+	writer := beancount.NewWriter(file)
+	writer.SetReadOnly(cfg.UI.ReadOnly)
+	writer.SetBackup(backup.FromConfig(cfg.Backup).Hook())
+	result := payeesMergeJSON{header: newHeader("payees merge"), Ledger: absPath(ledger), Clusters: []payeeClusterJSON{}}
+	var edits []beancount.Edit
+	for i, c := range clusters {
+		clusterEdits, err := payees.Edits(writer, c.Variants, c.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error renaming %s: %v\n", c.Name, err)
+			return 2
+		}
+		edits = append(edits, clusterEdits...)
+
+		shown := payeeClusterJSON{Number: numbers[i], Name: c.Name, Renamed: c.Renamed()}
+		for _, v := range c.Variants {
+			shown.Variants = append(shown.Variants, payeeVariantJSON{Name: v.Name, Transactions: len(v.Transactions)})
+		}
+		var patch strings.Builder
+		for _, edit := range clusterEdits {
+			edit.FilePath = displayPath(edit.FilePath)
+			patch.WriteString(edit.UnifiedDiff())
+		}
+		if *diff {
+			shown.Diff = patch.String()
+		}
+		result.Clusters = append(result.Clusters, shown)
+
+		if jsonOutput {
+			continue
+		}
+		fmt.Printf("%d. %s (%d transactions, %d to rename)\n", shown.Number, c.Name, c.Count(), shown.Renamed)
+		for _, v := range shown.Variants {
+			fmt.Printf("     %-40s %d\n", v.Name, v.Transactions)
+		}
+		if *diff {
+			fmt.Print(shown.Diff)
+		}
+	}
+
+	if *write && len(edits) > 0 {
+		if err := writer.ApplyAll(edits); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+			return 2
+		}
+		result.Written = true
+	}
+	if jsonOutput {
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	switch {
+	case len(clusters) == 0:
+		fmt.Println("No payees look like the same merchant spelled differently")
+	case *write:
+		fmt.Printf("Renamed %d transactions' payees in %s\n", len(edits), displayPath(ledger))
+	}
+	return 0
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/prices"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// fetchPrices quotes the latest price of each commodity held in the ledger from its
+// configured source and appends the new price directives to files.prices_file, or
+// with --dry-run only prints them, returning the exit status
+func fetchPrices(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	dryRun := flags.Bool("dry-run", false, "print the price directives without appending them")
+	refresh := flags.Bool("refresh", false, "fetch every quote, ignoring cached ones")
+	currency := flags.String("currency", cfg.Prices.Currency, "currency to quote prices in where the source allows; defaults to prices.currency")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	if !beancount.IsValidCommodity(*currency) {
+		fmt.Fprintf(os.Stderr, "Error: invalid currency %q\n", *currency)
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	pricesFile := config.ExpandHome(cfg.Files.PricesFile)
+	if !*dryRun {
+		if cfg.UI.ReadOnly {
+			fmt.Fprintln(os.Stderr, "Error: read-only mode: use --dry-run to see the prices")
+			return 2
+		}
+		if pricesFile == "" {
+			fmt.Fprintln(os.Stderr, "Error: set files.prices_file to the file price directives go in, or use --dry-run")
+			return 2
+		}
+	}
+
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		return 2
+	}
+	known := file.GetPriceDirectives()
+	// The prices file may not be included in the ledger yet
+	if pricesFile != "" {
+		if existing, err := file.Store().Open(pricesFile); err == nil {
+			known = append(known, existing.GetPriceDirectives()...)
+			existing.Close()
+		}
+	}
+
+	cache, err := prices.LoadCache(config.ExpandHome(cfg.Files.PriceCache))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		cache, _ = prices.LoadCache("")
+	}
+	settings := cfg.Prices
+	settings.Currency = *currency
+	if *refresh {
+		settings.CacheMinutes = 0
+	}
+	client := &http.Client{Timeout: time.Duration(settings.TimeoutSeconds) * time.Second}
+	sources := prices.NewSources(client, settings.RequestsPerMinute)
+	result := prices.Fetch(context.Background(), sources, settings, prices.Held(transactions, *currency), known, cache, time.Now())
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	var lines []string
+	for _, price := range result.Prices {
+		line, err := beancount.FormatPrice(price)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		lines = append(lines, line)
+	}
+	for _, failure := range result.Failed {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", failure.Commodity, failure.Err)
+	}
+	if len(result.Unsourced) > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %s: give them a source under prices.sources\n", strings.Join(result.Unsourced, ", "))
+	}
+	summary := fmt.Sprintf("%d new prices, %d from the cache, %d already in the ledger", len(lines), result.Cached, result.Known)
+	output := pricesJSON{
+		header:    newHeader("prices"),
+		Prices:    []priceJSON{},
+		Cached:    result.Cached,
+		Known:     result.Known,
+		Unsourced: append([]string{}, result.Unsourced...),
+		Failed:    []failureJSON{},
+	}
+	for _, price := range result.Prices {
+		output.Prices = append(output.Prices, priceJSON{
+			Date:      price.Date.Format("2006-01-02"),
+			Commodity: price.Commodity,
+			Number:    price.Amount.Number.String(),
+			Currency:  price.Amount.Commodity,
+		})
+	}
+	for _, failure := range result.Failed {
+		output.Failed = append(output.Failed, failureJSON{Commodity: failure.Commodity, Error: failure.Err.Error()})
+	}
+
+	switch {
+	case jsonOutput && (*dryRun || len(lines) == 0):
+		if err := writeJSON(output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	case *dryRun || len(lines) == 0:
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+		fmt.Fprintln(os.Stderr, summary)
+	default:
+		if err := backup.FromConfig(cfg.Backup).Save(pricesFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if err := appendLines(file.Store(), pricesFile, lines); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+			return 2
+		}
+		if !jsonOutput {
+			fmt.Printf("%s; appended to %s\n", summary, displayPath(pricesFile))
+			break
+		}
+		output.File = absPath(pricesFile)
+		if err := writeJSON(output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	if len(result.Failed) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// appendLines adds lines at the end of a file, creating it if need be
+// The file is rewritten whole, so an encrypted one is encrypted again.
+func appendLines(store *beancount.Store, path string, lines []string) error {
+	existing, err := store.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	text := bytes.Clone(existing)
+	if len(text) > 0 && !bytes.HasSuffix(text, []byte("\n")) {
+		text = append(text, '\n')
+	}
+	for _, line := range lines {
+		text = append(text, line+"\n"...)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return store.WriteFile(path, text)
+}
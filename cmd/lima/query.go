@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mmichie/lima/internal/query"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// runQuery runs a query over the ledger's postings and prints the result as an aligned
+// table, CSV or JSON, returning the exit status: 2 when the query or ledger is bad
+func runQuery(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	outputFormat := flags.String("format", "table", "output format: "+strings.Join(export.Encodings, ", "))
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	if !slices.Contains(export.Encodings, *outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: use %s\n", *outputFormat, strings.Join(export.Encodings, ", "))
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 1)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	result, err := query.Run(file, flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if jsonOutput {
+		if err := writeJSON(newTable("query", result.Columns, result.Strings())); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	data, err := export.Encode(export.Table{Name: "results", Columns: result.Columns, Rows: result.Strings()}, *outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	os.Stdout.Write(data)
+	return 0
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/forecast"
+	"github.com/mmichie/lima/internal/portfolio"
+	"github.com/mmichie/lima/internal/shared"
+	"github.com/mmichie/lima/internal/tax"
+	"github.com/mmichie/lima/internal/trips"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/reports"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// report prints one of the built-in reports for a period as an aligned table, CSV or
+// JSON, returning the exit status: 2 when the arguments or ledger are bad
+func report(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	periodText := flags.String("period", "this month", "period to report: all, this/last month, quarter or year, YYYY, YYYY-Qn or YYYY-MM")
+	currency := flags.String("currency", cfg.Amounts.Currency, "currency to convert amounts into with the ledger's prices; \"\" keeps each commodity")
+	outputFormat := flags.String("format", "table", "output format: "+strings.Join(export.Encodings, ", "))
+	// Flags may come before or after the report name and ledger
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return parseStatus(err)
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if len(positional) == 0 || len(positional) > 2 {
+		flags.Usage()
+		return 2
+	}
+	period, err := beancount.ParsePeriod(*periodText, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if !slices.Contains(export.Encodings, *outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: use %s\n", *outputFormat, strings.Join(export.Encodings, ", "))
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, positional, 1)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	table, err := reports.Run(file, positional[0], period, *currency)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if jsonOutput {
+		result := newTable("report", table.Columns, table.Rows)
+		result.Report = positional[0]
+		if !period.IsAll() {
+			result.From = period.Start.Format("2006-01-02")
+			result.To = period.End().Format("2006-01-02")
+		}
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	data, err := export.Encode(table, *outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	os.Stdout.Write(data)
+	return 0
+}
+
+// forecastBalances projects the balances of the ledger's accounts months ahead as an
+// aligned table, CSV or JSON, one month to a row, returning the exit status; the table
+// is followed by the accounts that may go negative
+func forecastBalances(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	defaults := forecast.DefaultOptions
+	months := flags.Int("months", defaults.Months, "months to project, the current one first")
+	history := flags.Int("history", defaults.History, "full months before the current one to follow the trend of")
+	model := flags.String("model", string(defaults.Model), "trend of the flows besides recurring charges: "+strings.Join(forecast.Models, ", "))
+	account := flags.String("account", defaults.Account, "project this account and those under it")
+	currency := flags.String("currency", cfg.Amounts.Currency, "currency to convert amounts into with the ledger's prices; \"\" keeps each commodity")
+	outputFormat := flags.String("format", "table", "output format: "+strings.Join(export.Encodings, ", "))
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if !slices.Contains(export.Encodings, *outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: use %s\n", *outputFormat, strings.Join(export.Encodings, ", "))
+		return 2
+	}
+
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	options := forecast.Options{Months: *months, History: *history, Model: forecast.Model(*model), Account: *account}
+	table, err := reports.Forecast(file, *currency, options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if jsonOutput {
+		if err := writeJSON(newTable("forecast", table.Columns, table.Rows)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	data, err := export.Encode(table, *outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	os.Stdout.Write(data)
+	if *outputFormat != "table" {
+		return 0
+	}
+
+	// The first month each account may go negative
+	var warnings []string
+	warned := make(map[string]bool)
+	for _, row := range table.Rows {
+		account, month, risk := row[0]+" "+row[1], row[2], row[len(row)-1]
+		if risk != "" && !warned[account] {
+			warned[account] = true
+			warnings = append(warnings, fmt.Sprintf("%s %s in %s", account, risk, month))
+		}
+	}
+	if len(warnings) > 0 {
+		fmt.Printf("\n%s\n", strings.Join(warnings, "\n"))
+	}
+	return 0
+}
+
+// tripSpending totals the spending of tagged trips and event spans, with each trip's
+// categories, as an aligned table, CSV or JSON, returning the exit status
+func tripSpending(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	defaults := trips.DefaultOptions
+	tagPrefix := flags.String("tag-prefix", defaults.TagPrefix, "tags starting with this mark trips; \"\" takes no tags")
+	event := flags.String("event", defaults.Event, "type of the event directives whose values are trips; \"\" takes no events")
+	currency := flags.String("currency", cfg.Amounts.Currency, "currency to convert amounts into with the ledger's prices; \"\" keeps each commodity")
+	outputFormat := flags.String("format", "table", "output format: "+strings.Join(export.Encodings, ", "))
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if !slices.Contains(export.Encodings, *outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: use %s\n", *outputFormat, strings.Join(export.Encodings, ", "))
+		return 2
+	}
+	if *tagPrefix == "" && *event == "" {
+		fmt.Fprintln(os.Stderr, "Error: --tag-prefix and --event can't both be empty")
+		return 2
+	}
+
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	table, err := reports.Trips(file, *currency, trips.Options{TagPrefix: strings.TrimPrefix(*tagPrefix, "#"), Event: *event})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if jsonOutput {
+		if err := writeJSON(newTable("trips", table.Columns, table.Rows)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	data, err := export.Encode(table, *outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	os.Stdout.Write(data)
+	return 0
+}
+
+// portfolioPerformance reports the holdings of investment accounts with their cost
+// basis, value, unrealized gain and time-weighted return, each account's totals first,
+// as an aligned table, CSV or JSON, returning the exit status
+func portfolioPerformance(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	account := flags.String("account", "Assets", "report the holdings of this account and those under it")
+	on := flags.String("date", "", "value the holdings on this date, YYYY-MM-DD; by default today")
+	outputFormat := flags.String("format", "table", "output format: "+strings.Join(export.Encodings, ", "))
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if !slices.Contains(export.Encodings, *outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: use %s\n", *outputFormat, strings.Join(export.Encodings, ", "))
+		return 2
+	}
+	date := time.Now()
+	if *on != "" {
+		parsed, err := time.Parse("2006-01-02", *on)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid date %q: use YYYY-MM-DD\n", *on)
+			return 2
+		}
+		date = parsed
+	}
+
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	report := portfolio.Compute(transactions, file.GetPriceDirectives(), []string{*account}, date)
+
+	percent := func(fraction decimal.Decimal) string { return fraction.Mul(decimal.NewFromInt(100)).Round(2).String() }
+	table := export.Table{
+		Name:    "portfolio",
+		Columns: []string{"account", "commodity", "units", "cost", "value", "gain", "gain %", "return %", "since", "currency"},
+	}
+	for _, a := range report.Accounts {
+		gain := decimal.Zero
+		if !a.Cost.IsZero() {
+			gain = a.Gain().Div(a.Cost)
+		}
+		table.Rows = append(table.Rows, []string{a.Name, "total", "", a.Cost.String(), a.Value.String(), a.Gain().String(),
+			percent(gain), percent(a.Return), a.Since.Format("2006-01-02"), a.Currency})
+		for _, h := range report.Holdings {
+			if h.Account != a.Name || h.Currency != a.Currency {
+				continue
+			}
+			gain := decimal.Zero
+			if !h.Cost.IsZero() {
+				gain = h.Gain().Div(h.Cost)
+			}
+			value := h.Value.String()
+			if !h.Priced {
+				value += " (cost)"
+			}
+			table.Rows = append(table.Rows, []string{h.Account, h.Commodity, h.Units.String(), h.Cost.String(), value, h.Gain().String(),
+				percent(gain), percent(h.Return), h.Since.Format("2006-01-02"), h.Currency})
+		}
+	}
+	if jsonOutput {
+		if err := writeJSON(newTable("portfolio", table.Columns, table.Rows)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	data, err := export.Encode(table, *outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	os.Stdout.Write(data)
+	return 0
+}
+
+// sharedBalances works out who owes whom for shared expenses, or lists what each
+// shared transaction leaves owing, as an aligned table, CSV or JSON, returning the exit
+// status
+func sharedBalances(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	detail := flags.Bool("detail", false, "list what each shared transaction leaves owing instead of the balances")
+	currency := flags.String("currency", cfg.Amounts.Currency, "currency to convert amounts into with the ledger's prices; \"\" keeps each commodity")
+	outputFormat := flags.String("format", "table", "output format: "+strings.Join(export.Encodings, ", "))
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if !slices.Contains(export.Encodings, *outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: use %s\n", *outputFormat, strings.Join(export.Encodings, ", "))
+		return 2
+	}
+
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *currency != "" {
+		transactions = beancount.NewPriceDB(file.GetPriceDirectives()).ConvertTransactions(transactions, *currency)
+	}
+	report, err := shared.Summarize(transactions, cfg.Shared)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	table := export.Table{Name: "shared", Columns: []string{"owes", "to", "amount", "commodity"}}
+	for _, balance := range report.Balances {
+		table.Rows = append(table.Rows, []string{balance.From, balance.To, balance.Amount.Number.String(), balance.Amount.Commodity})
+	}
+	if *detail {
+		table = export.Table{Name: "shared", Columns: []string{"date", "payee", "narration", "kind", "owes", "to", "amount", "commodity"}}
+		for _, item := range report.Items {
+			tx := item.Transaction
+			kind := "expense"
+			if item.Settlement {
+				kind = "settlement"
+			}
+			table.Rows = append(table.Rows, []string{tx.Date.Format("2006-01-02"), tx.Payee, tx.Narration, kind,
+				item.From, item.To, item.Amount.Number.String(), item.Amount.Commodity})
+		}
+	}
+	if jsonOutput {
+		if err := writeJSON(newTable("shared", table.Columns, table.Rows)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	data, err := export.Encode(table, *outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	os.Stdout.Write(data)
+	return 0
+}
+
+// taxSummary totals the configured tax groups by tax year, or with --detail lists the
+// postings they count, as an aligned table, CSV or JSON, returning the exit status
+func taxSummary(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	year := flags.String("year", "", "report one tax year, e.g. 2024 or 2024-25; by default every year")
+	detail := flags.Bool("detail", false, "list each posting counted instead of the totals")
+	currency := flags.String("currency", cfg.Amounts.Currency, "currency to convert amounts into with the ledger's prices; \"\" keeps each commodity")
+	outputFormat := flags.String("format", "table", "output format: "+strings.Join(export.Encodings, ", "))
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+	if !slices.Contains(export.Encodings, *outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q: use %s\n", *outputFormat, strings.Join(export.Encodings, ", "))
+		return 2
+	}
+
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *currency != "" {
+		transactions = beancount.NewPriceDB(file.GetPriceDirectives()).ConvertTransactions(transactions, *currency)
+	}
+	summary, err := tax.Summarize(transactions, cfg.Tax)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *year != "" {
+		summary = summary.Year(*year)
+	}
+
+	table := export.Table{Name: "tax", Columns: []string{"year", "group", "transactions", "amount", "commodity"}}
+	for _, total := range summary.Totals {
+		table.Rows = append(table.Rows, []string{total.Year, total.Group, strconv.Itoa(total.Transactions), total.Amount.String(), total.Commodity})
+	}
+	if *detail {
+		table = export.Table{Name: "tax", Columns: []string{"year", "group", "date", "payee", "narration", "account", "amount", "commodity"}}
+		for _, item := range summary.Items {
+			tx := item.Transaction
+			table.Rows = append(table.Rows, []string{item.Year, item.Group, tx.Date.Format("2006-01-02"), tx.Payee, tx.Narration,
+				item.Account, item.Amount.Number.String(), item.Amount.Commodity})
+		}
+	}
+	if jsonOutput {
+		if err := writeJSON(newTable("tax", table.Columns, table.Rows)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	data, err := export.Encode(table, *outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	os.Stdout.Write(data)
+	return 0
+}
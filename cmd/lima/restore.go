@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// restore lists the backups of the ledger's files, newest first, or with --backup puts
+// one back, and returns the exit status
+func restore(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	name := flags.String("backup", "", "backup to restore, by the name the list shows")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	if cfg.Backup.Dir == "" {
+		fmt.Fprintln(os.Stderr, "Error: set backup.dir to the directory backups are kept in")
+		return 2
+	}
+	store := backup.New(config.ExpandHome(cfg.Backup.Dir), max(cfg.Backup.Keep, 1))
+
+	backups, err := ledgerBackups(cfg, store, ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *name == "" && jsonOutput {
+		result := backupsJSON{header: newHeader("restore"), Backups: []backupJSON{}}
+		for _, b := range backups {
+			result.Backups = append(result.Backups, newBackup(b))
+		}
+		if err := writeJSON(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	if *name == "" {
+		if len(backups) == 0 {
+			fmt.Printf("No backups of %s in %s\n", displayPath(ledger), store.Dir())
+			return 0
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BACKUP\tFILE\tTAKEN\tSIZE")
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", b.Name, displayPath(b.Of), b.Time.Format("2006-01-02 15:04:05"), b.Size)
+		}
+		w.Flush()
+		return 0
+	}
+
+	if cfg.UI.ReadOnly {
+		fmt.Fprintln(os.Stderr, "Error: read-only mode: nothing can be restored")
+		return 2
+	}
+	i := slices.IndexFunc(backups, func(b backup.Backup) bool { return b.Name == *name })
+	if i < 0 {
+		fmt.Fprintf(os.Stderr, "Error: no backup %q of %s: run lima restore to list them\n", *name, displayPath(ledger))
+		return 2
+	}
+	if err := store.Restore(backups[i]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if jsonOutput {
+		if err := writeJSON(restoredJSON{header: newHeader("restore"), Restored: newBackup(backups[i])}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	fmt.Printf("Restored %s from %s; its replaced contents were backed up first\n", displayPath(backups[i].Of), backups[i].Name)
+	return 0
+}
+
+// ledgerBackups returns the backups of the ledger's files and the prices file, newest
+// first
+func ledgerBackups(cfg *config.Config, store *backup.Store, ledger string) ([]backup.Backup, error) {
+	// The ledger may be what needs restoring, so one that won't open still has its
+	// own backups listed
+	files := []string{absPath(ledger)}
+	if file, err := ledgerStore(cfg).Open(ledger); err == nil {
+		files = file.Files()
+		file.Close()
+	}
+	if prices := config.ExpandHome(cfg.Files.PricesFile); prices != "" && !slices.Contains(files, absPath(prices)) {
+		files = append(files, absPath(prices))
+	}
+	var backups []backup.Backup
+	for _, path := range files {
+		found, err := store.List(path)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, found...)
+	}
+	slices.SortStableFunc(backups, func(a, b backup.Backup) int { return b.Time.Compare(a.Time) })
+	return backups, nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/grpc"
+	"github.com/mmichie/lima/internal/mcp"
+	"github.com/mmichie/lima/internal/server"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// serveAPI answers lima's JSON API over HTTP until interrupted, for requests bearing
+// the token in the environment variable serve.token_env, returning the exit status
+func serveAPI(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	address := flags.String("address", cfg.Serve.Address, "host:port to listen on; defaults to serve.address")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	token := os.Getenv(cfg.Serve.TokenEnv)
+	if token == "" {
+		fmt.Fprintf(os.Stderr, "Error: set %s to the token API requests must bear\n", cfg.Serve.TokenEnv)
+		return 2
+	}
+
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	for _, warning := range cat.LoadWarnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	handler, err := server.New(file, cat, token, cfg.Amounts.Currency)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	scheme := "http"
+	if cfg.Serve.CertFile != "" {
+		scheme = "https"
+	}
+	fmt.Fprintf(os.Stderr, "Serving %s on %s://%s/api/\n", displayPath(ledger), scheme, *address)
+	return listen(&http.Server{Addr: *address, Handler: handler, ReadHeaderTimeout: 10 * time.Second}, cfg.Serve)
+}
+
+// grpcCategorizer serves the categorizer as a gRPC service over TLS until interrupted,
+// training it on the ledger's history when there is one, returning the exit status
+func grpcCategorizer(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	address := flags.String("address", cfg.Serve.GRPCAddress, "host:port to listen on; defaults to serve.grpc_address")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	ledger := cfg.Files.DefaultLedger
+	if flags.NArg() > 0 {
+		ledger = flags.Arg(0)
+	}
+	if ledger != "" {
+		var err error
+		if cfg, _, err = cfg.ForLedger(ledger); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	if cfg.Serve.CertFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: set serve.cert_file and serve.key_file: gRPC runs over HTTP/2, which needs TLS")
+		return 2
+	}
+	token := os.Getenv(cfg.Serve.TokenEnv)
+	if token == "" {
+		fmt.Fprintf(os.Stderr, "Error: set %s to the token calls must bear\n", cfg.Serve.TokenEnv)
+		return 2
+	}
+
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	for _, warning := range cat.LoadWarnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if ledger != "" {
+		file, err := ledgerStore(cfg).Open(ledger)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+			return 2
+		}
+		transactions, err := file.AllTransactions()
+		if err != nil {
+			file.Close()
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			return 2
+		}
+		cat.SetKnownAccounts(file.DeclaredAccounts())
+		cat.TrainSimilarity(transactions)
+		file.Close()
+	}
+	handler, err := grpc.New(cat, token, cfg.Amounts.Currency)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	fmt.Fprintf(os.Stderr, "Serving %s on %s\n", grpc.ServiceName, *address)
+	return listen(&http.Server{Addr: *address, Handler: handler, ReadHeaderTimeout: 10 * time.Second}, cfg.Serve)
+}
+
+// listen runs a server, over TLS when a certificate is configured, until it fails or
+// lima is interrupted, returning the exit status
+func listen(srv *http.Server, settings config.ServeConfig) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errs := make(chan error, 1)
+	go func() {
+		if settings.CertFile != "" {
+			errs <- srv.ListenAndServeTLS(config.ExpandHome(settings.CertFile), config.ExpandHome(settings.KeyFile))
+			return
+		}
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return 0
+}
+
+// serveMCP answers Model Context Protocol requests on standard input and output, so
+// an AI assistant can query the ledger and suggest categories, returning the exit
+// status when the assistant hangs up
+func serveMCP(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	for _, warning := range cat.LoadWarnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	assistant, err := mcp.New(file, cat, cfg.Amounts.Currency, version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if err := assistant.Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	return 0
+}
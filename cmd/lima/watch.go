@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/importer"
+	"github.com/mmichie/lima/internal/watch"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// watchLedger checks the ledger whenever it changes and categorizes what the
+// categorizer is confident of, and imports the statements left in the inbox, staged
+// for review, printing a line per event until interrupted; it returns the exit status
+func watchLedger(cfg *config.Config, flags *flag.FlagSet, args []string) int {
+	inbox := flags.String("inbox", cfg.Watch.Inbox, "directory to import statements from; imported ones move to its imported directory, unreadable ones to failed; defaults to watch.inbox")
+	interval := flags.Duration("interval", time.Duration(cfg.Watch.IntervalSeconds)*time.Second, "how often to look for changes; defaults to watch.interval_seconds")
+	currency := flags.String("currency", cfg.Amounts.Currency, "commodity of QIF amounts and of $ in journals; defaults to amounts.currency")
+	noCategorize := flags.Bool("no-categorize", false, "check the ledger without categorizing it")
+	minConfidence := flags.Float64("min-confidence", cfg.Categorization.AutoThreshold, "lowest confidence to apply a category, 0.0-1.0; defaults to categorization.auto_threshold")
+	if err := flags.Parse(args); err != nil {
+		return parseStatus(err)
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+	if *interval <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: --interval must be positive, got %s\n", *interval)
+		return 2
+	}
+	if *minConfidence < 0 || *minConfidence > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --min-confidence must be between 0.0 and 1.0, got %g\n", *minConfidence)
+		return 2
+	}
+	cfg, ledger, ok := resolveLedger(cfg, flags.Args(), 0)
+	if !ok {
+		return 2
+	}
+	if *inbox != "" {
+		if cfg.UI.ReadOnly {
+			fmt.Fprintln(os.Stderr, "Error: read-only mode: statements can't be imported; leave out --inbox")
+			return 2
+		}
+		*inbox = absPath(*inbox)
+		if info, err := os.Stat(*inbox); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Error: inbox %s is not a directory\n", displayPath(*inbox))
+			return 2
+		}
+	}
+	file, err := ledgerStore(cfg).Open(ledger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+
+	// inspect checks the ledger and categorizes it into an event
+	inspect := func(event *watchJSON) {
+		problems, err := file.Check()
+		if err != nil {
+			event.Errors = append(event.Errors, fmt.Sprintf("check failed: %v", err))
+			return
+		}
+		event.Problems = len(problems)
+		if *noCategorize {
+			return
+		}
+		event.Categorized, event.Suggested, err = applySuggestions(cfg, file, *minConfidence)
+		if err != nil {
+			event.Errors = append(event.Errors, fmt.Sprintf("categorizing failed: %v", err))
+		}
+	}
+	// importFile stages a statement's new transactions into an event and moves it out
+	// of the inbox, to failed when it can't be read
+	importFile := func(event *watchJSON) {
+		destination := "imported"
+		entries, err := readStatement(cfg, event.Path, statementOptions{qif: importer.QIFConfig{Currency: *currency}})
+		if err == nil {
+			entries, err = processEntries(cfg, file, entries, importer.Options{MinConfidence: *minConfidence, Stage: true})
+		}
+		if err == nil {
+			err = stageEntries(cfg, file, entries)
+		}
+		if err != nil {
+			destination = "failed"
+			event.Errors = append(event.Errors, fmt.Sprintf("import failed: %v", err))
+		} else {
+			result := newImport("import", ledger, entries, true)
+			result.Statement = event.Path
+			event.Import = &result
+		}
+		// A statement of the same name imported before keeps its place
+		dir := filepath.Join(filepath.Dir(event.Path), destination)
+		var moved string
+		err = os.MkdirAll(dir, 0755)
+		if err == nil {
+			moved = freePath(dir, filepath.Base(event.Path))
+			err = os.Rename(event.Path, moved)
+		}
+		if err != nil {
+			// Left in the inbox, the statement is imported again only once it changes
+			event.Errors = append(event.Errors, fmt.Sprintf("not moved out of the inbox: %v", err))
+			return
+		}
+		event.MovedTo = moved
+	}
+	// report prints an event as a line of text or JSON
+	where := displayPath(ledger)
+	if *inbox != "" {
+		where += " and " + displayPath(*inbox)
+	}
+	report := func(event watchJSON) {
+		if jsonOutput {
+			if err := writeJSONLine(event); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			return
+		}
+		var what string
+		switch event.Event {
+		case watchStarted:
+			what = fmt.Sprintf("Watching %s every %s", where, *interval)
+		case watchLedgerChanged:
+			what = displayPath(event.Path) + " changed"
+		case watchStatement:
+			what = displayPath(event.Path)
+		}
+		parts := []string{}
+		if event.Import != nil {
+			parts = append(parts, fmt.Sprintf("%d new transactions, %d categorized, %d already in the ledger; staged for review",
+				event.Import.New, event.Import.Categorized, event.Import.Duplicates))
+		}
+		if event.MovedTo != "" {
+			parts = append(parts, "moved to "+displayPath(event.MovedTo))
+		}
+		parts = append(parts, problemsSummary(event.Problems))
+		switch {
+		case cfg.UI.ReadOnly && event.Suggested > 0:
+			parts = append(parts, fmt.Sprintf("%d suggestions at or above %.0f%% confidence, not written in read-only mode", event.Suggested, *minConfidence*100))
+		case event.Categorized > 0:
+			parts = append(parts, fmt.Sprintf("categorized %d transactions", event.Categorized))
+		}
+		parts = append(parts, event.Errors...)
+		when, _ := time.Parse(time.RFC3339, event.Time)
+		fmt.Printf("%s %s: %s\n", when.Format("2006-01-02 15:04:05"), what, strings.Join(parts, "; "))
+	}
+	newEvent := func(kind, path string) watchJSON {
+		return watchJSON{header: newHeader("watch"), Time: time.Now().Format(time.RFC3339), Event: kind, Path: path, Errors: []string{}}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	watcher := watch.New(file.Files, *inbox)
+	started := newEvent(watchStarted, file.Path())
+	inspect(&started)
+	report(started)
+	watcher.Sync()
+	watcher.Run(ctx, *interval, func(e watch.Event) {
+		var event watchJSON
+		switch e.Kind {
+		case watch.LedgerChanged:
+			event = newEvent(watchLedgerChanged, e.Path)
+			if err := file.Reload(); err != nil {
+				event.Errors = append(event.Errors, fmt.Sprintf("failed to read ledger: %v", err))
+				break
+			}
+			inspect(&event)
+		case watch.NewStatement:
+			event = newEvent(watchStatement, e.Path)
+			importFile(&event)
+			inspect(&event)
+		}
+		report(event)
+		// What lima wrote itself is not a change to report
+		watcher.Sync()
+	})
+	return 0
+}
+
+// applySuggestions categorizes the ledger's placeholder postings the categorizer has
+// a suggestion for at or above minConfidence, recording them in the audit log; it
+// returns how many it applied and how many there were, and applies none read-only
+func applySuggestions(cfg *config.Config, file *beancount.File, minConfidence float64) (applied, suggested int, err error) {
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		return 0, 0, err
+	}
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		return 0, 0, err
+	}
+	if cfg.Categorization.SimilarityEnabled {
+		cat.TrainSimilarity(transactions)
+	}
+	candidates, err := cat.Candidates(transactions, minConfidence)
+	if err != nil || len(candidates) == 0 || cfg.UI.ReadOnly {
+		return 0, len(candidates), err
+	}
+
+	writer := beancount.NewWriter(file)
+	writer.SetBackup(backup.FromConfig(cfg.Backup).Hook())
+	var edits []beancount.Edit
+	var audit []categorizer.AuditEntry
+	for _, candidate := range candidates {
+		edit, err := writer.SetPostingAccount(candidate.Transaction, candidate.PostingIndex, candidate.Suggestion.Category)
+		if err != nil {
+			continue
+		}
+		edits = append(edits, edit)
+		audit = append(audit, categorizer.NewAuditEntry(candidate, categorizer.AuditApplied))
+	}
+	if err := writer.ApplyAll(edits); err != nil {
+		return 0, len(candidates), err
+	}
+	if cfg.Categorization.AuditLog != "" {
+		if err := categorizer.NewAuditLog(cfg.Categorization.AuditLog).Record(audit...); err != nil {
+			return len(edits), len(candidates), err
+		}
+	}
+	return len(edits), len(candidates), nil
+}
+
+// freePath returns the path in dir of a file named name, or when one of that name is
+// there already, of one numbered before the extension, e.g. statement-2.csv
+func freePath(dir, name string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	path := filepath.Join(dir, name)
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(path); err != nil {
+			return path
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, n, ext))
+	}
+}
@@ -0,0 +1,130 @@
+// Package anomaly flags unusual transactions
+//
+// A transaction's charge is what it spent in the commodity of its first expense
+// posting. Three things are unusual: a charge several times the mean of the payee's
+// earlier charges, a payee's first charge when it is large, and the same charge to a
+// payee twice within a few days. Each is judged only against the transactions before
+// it, so an alert doesn't change as the ledger grows.
+package anomaly
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// Kind is what makes a transaction unusual
+type Kind string
+
+const (
+	Unusual     Kind = "unusual amount"
+	NewMerchant Kind = "new merchant"
+	Duplicate   Kind = "possible duplicate"
+)
+
+// Alert is a transaction flagged as unusual
+type Alert struct {
+	Kind        Kind
+	Transaction *beancount.Transaction
+	Amount      beancount.Amount       // The charge
+	Mean        decimal.Decimal        // For Unusual, the mean of the payee's earlier charges
+	Original    *beancount.Transaction // For Duplicate, the earlier charge it repeats
+}
+
+// Ratio returns how many times the payee's mean an unusual charge is
+func (a Alert) Ratio() decimal.Decimal {
+	if !a.Mean.IsPositive() {
+		return decimal.Zero
+	}
+	return a.Amount.Number.Div(a.Mean)
+}
+
+// charge is an earlier transaction's charge to a payee
+type charge struct {
+	tx     *beancount.Transaction
+	amount decimal.Decimal
+}
+
+// Detect flags the unusual transactions, the most recent first
+func Detect(transactions []*beancount.Transaction, cfg config.AnomaliesConfig) []Alert {
+	sorted := slices.Clone(transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	factor := decimal.NewFromFloat(cfg.MeanFactor)
+	threshold := decimal.NewFromFloat(cfg.NewMerchantAmount)
+	window := time.Duration(cfg.DuplicateDays) * 24 * time.Hour
+	seen := make(map[string]bool)           // Payees charged before, in any commodity
+	history := make(map[[2]string][]charge) // Earlier charges by payee and commodity
+	var alerts []Alert
+	for _, tx := range sorted {
+		payee := strings.ToLower(strings.TrimSpace(tx.Payee))
+		amount, ok := spent(tx)
+		if payee == "" || !ok {
+			continue
+		}
+		k := [2]string{payee, amount.Commodity}
+		earlier := history[k]
+
+		if len(earlier) >= cfg.MinHistory {
+			total := decimal.Zero
+			for _, c := range earlier {
+				total = total.Add(c.amount)
+			}
+			mean := total.Div(decimal.NewFromInt(int64(len(earlier))))
+			if amount.Number.GreaterThan(mean.Mul(factor)) {
+				alerts = append(alerts, Alert{Kind: Unusual, Transaction: tx, Amount: amount, Mean: mean})
+			}
+		}
+		if !seen[payee] && cfg.NewMerchantAmount > 0 && !amount.Number.LessThan(threshold) {
+			alerts = append(alerts, Alert{Kind: NewMerchant, Transaction: tx, Amount: amount})
+		}
+		if cfg.DuplicateDays > 0 {
+			for i := len(earlier) - 1; i >= 0 && tx.Date.Sub(earlier[i].tx.Date) <= window; i-- {
+				if earlier[i].amount.Equal(amount.Number) {
+					alerts = append(alerts, Alert{Kind: Duplicate, Transaction: tx, Amount: amount, Original: earlier[i].tx})
+					break
+				}
+			}
+		}
+
+		seen[payee] = true
+		history[k] = append(earlier, charge{tx: tx, amount: amount.Number})
+	}
+
+	slices.Reverse(alerts)
+	return alerts
+}
+
+// Since keeps the alerts on or after a date
+func Since(alerts []Alert, date time.Time) []Alert {
+	var kept []Alert
+	for _, alert := range alerts {
+		if !alert.Transaction.Date.Before(date) {
+			kept = append(kept, alert)
+		}
+	}
+	return kept
+}
+
+// spent returns what a transaction spent in the commodity of its first expense
+// posting, or false when it spent nothing
+func spent(tx *beancount.Transaction) (beancount.Amount, bool) {
+	var total beancount.Amount
+	for _, posting := range tx.ResolvedPostings() {
+		if posting.Amount == nil || posting.Account != "Expenses" && !strings.HasPrefix(posting.Account, "Expenses:") {
+			continue
+		}
+		if total.Commodity == "" {
+			total.Commodity = posting.Amount.Commodity
+		}
+		if posting.Amount.Commodity == total.Commodity {
+			total.Number = total.Number.Add(posting.Amount.Number)
+		}
+	}
+	return total, total.Number.IsPositive()
+}
@@ -0,0 +1,64 @@
+package anomaly
+
+import (
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+func TestDetect(t *testing.T) {
+	transactions := []*beancount.Transaction{
+		beancounttest.Tx("2024-01-05", "Grocer", "Expenses:Shopping", "50"),
+		beancounttest.Tx("2024-01-12", "Grocer", "Expenses:Shopping", "40"),
+		beancounttest.Tx("2024-01-19", "Grocer", "Expenses:Shopping", "60"),
+		beancounttest.Tx("2024-01-26", "grocer", "Expenses:Shopping", "200"), // Four times the mean of 50
+		beancounttest.Tx("2024-02-01", "Jeweler", "Expenses:Shopping", "900"),
+		beancounttest.Tx("2024-02-03", "Cafe", "Expenses:Shopping", "4.50"),
+		beancounttest.Tx("2024-02-05", "Cafe", "Expenses:Shopping", "4.50"),
+		beancounttest.Tx("2024-02-15", "Cafe", "Expenses:Shopping", "4.50"), // Too long after the last to look repeated
+		{Date: beancounttest.Date("2024-02-20"), Payee: "Refund", Postings: []beancount.Posting{
+			{Account: "Expenses:Shopping", Amount: &beancount.Amount{Number: decimal.NewFromInt(-900), Commodity: "USD"}},
+			{Account: "Assets:Checking"},
+		}},
+	}
+	alerts := Detect(transactions, config.DefaultConfig().Anomalies)
+
+	want := []struct {
+		kind  Kind
+		payee string
+	}{
+		{Duplicate, "Cafe"},
+		{NewMerchant, "Jeweler"},
+		{Unusual, "grocer"},
+	}
+	if len(alerts) != len(want) {
+		t.Fatalf("got alerts %+v", alerts)
+	}
+	for i, alert := range alerts {
+		if alert.Kind != want[i].kind || alert.Transaction.Payee != want[i].payee {
+			t.Errorf("alert %d: got %s for %s, want %s for %s", i, alert.Kind, alert.Transaction.Payee, want[i].kind, want[i].payee)
+		}
+	}
+	if alerts[0].Original != transactions[5] {
+		t.Errorf("expected the duplicate to point at the first charge, got %+v", alerts[0].Original)
+	}
+	if !alerts[2].Mean.Equal(decimal.NewFromInt(50)) || !alerts[2].Ratio().Equal(decimal.NewFromInt(4)) {
+		t.Errorf("unexpected mean %s", alerts[2].Mean)
+	}
+
+	if recent := Since(alerts, beancounttest.Date("2024-02-01")); len(recent) != 2 {
+		t.Errorf("expected February's alerts, got %+v", recent)
+	}
+}
+
+func TestDetectOff(t *testing.T) {
+	cfg := config.DefaultConfig().Anomalies
+	cfg.NewMerchantAmount, cfg.DuplicateDays = 0, 0
+	transactions := []*beancount.Transaction{beancounttest.Tx("2024-02-01", "Jeweler", "Expenses:Shopping", "900"), beancounttest.Tx("2024-02-01", "Jeweler", "Expenses:Shopping", "900")}
+	if alerts := Detect(transactions, cfg); len(alerts) != 0 {
+		t.Errorf("expected no alerts, got %+v", alerts)
+	}
+}
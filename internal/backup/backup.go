@@ -0,0 +1,196 @@
+// Package backup keeps timestamped copies of ledger files, taken before lima writes
+// to them, and restores them. Each file's copies live in a directory of their own
+// under the backup directory, named after the file and a hash of its absolute path,
+// so files with the same name in different places never mix.
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// stampLayout is the time format of a backup's name, which sorts in time order
+const stampLayout = "20060102-150405.000"
+
+// Backup is one copy of a file
+type Backup struct {
+	Name string    // The copy's file name, e.g. main.beancount.20260102-150405.000
+	Path string    // Where the copy is kept
+	Of   string    // The absolute path of the file it copies
+	Time time.Time // When it was taken
+	Size int64
+}
+
+// Store keeps the backups in a directory
+type Store struct {
+	dir  string
+	keep int
+	now  func() time.Time
+}
+
+// New returns a store keeping the keep most recent backups of each file in dir
+func New(dir string, keep int) *Store {
+	return &Store{dir: dir, keep: keep, now: time.Now}
+}
+
+// FromConfig returns the store the configuration describes, or nil when backups are
+// disabled
+func FromConfig(cfg config.BackupConfig) *Store {
+	if !cfg.Enabled {
+		return nil
+	}
+	return New(config.ExpandHome(cfg.Dir), cfg.Keep)
+}
+
+// Hook returns the function a beancount.Writer calls before rewriting a file; a nil
+// store returns nil, which writes without backups
+func (s *Store) Hook() func(path string) error {
+	if s == nil {
+		return nil
+	}
+	return s.Save
+}
+
+// Dir returns the directory the backups are kept in
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// fileDir returns the directory the backups of the file at an absolute path are kept in
+func (s *Store) fileDir(abs string) string {
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(s.dir, filepath.Base(abs)+"-"+hex.EncodeToString(sum[:4]))
+}
+
+// Save copies the file at path into the store, then prunes its oldest backups
+// A file that doesn't exist yet has nothing to lose and is skipped, as is every file
+// by a nil store.
+func (s *Store) Save(path string) error {
+	if s == nil {
+		return nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	src, err := os.Open(abs)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	defer src.Close()
+
+	dir := s.fileDir(abs)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	// Two backups within a millisecond take the next free stamp
+	var dst *os.File
+	for stamp := s.now(); ; stamp = stamp.Add(time.Millisecond) {
+		name := filepath.Base(abs) + "." + stamp.Format(stampLayout)
+		dst, err = os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if !errors.Is(err, fs.ErrExist) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return s.prune(abs)
+}
+
+// prune removes the backups of a file beyond the number kept
+func (s *Store) prune(abs string) error {
+	backups, err := s.list(abs)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups[min(s.keep, len(backups)):] {
+		if err := os.Remove(b.Path); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// List returns the backups of the file at path, newest first
+func (s *Store) List(path string) ([]Backup, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	return s.list(abs)
+}
+
+func (s *Store) list(abs string) ([]Backup, error) {
+	dir := s.fileDir(abs)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backups: %w", err)
+	}
+
+	prefix := filepath.Base(abs) + "."
+	var backups []Backup
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		taken, err := time.ParseInLocation(stampLayout, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			Name: entry.Name(),
+			Path: filepath.Join(dir, entry.Name()),
+			Of:   abs,
+			Time: taken,
+			Size: info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// Restore puts a backup back in place of the file it copies, first backing up the
+// file as it is now so the restore can itself be undone
+func (s *Store) Restore(b Backup) error {
+	data, err := os.ReadFile(b.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	if err := s.Save(b.Of); err != nil {
+		return err
+	}
+	return beancount.ReplaceFile(b.Of, data)
+}
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	ledger := filepath.Join(dir, "main.beancount")
+	store := New(filepath.Join(dir, "backups"), 2)
+	stamp := time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local)
+	store.now = func() time.Time { return stamp }
+
+	// Nothing to back up before the file exists
+	if err := store.Save(ledger); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, content := range []string{"one\n", "two\n", "three\n"} {
+		if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Save(ledger); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	backups, err := store.List(ledger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups kept, got %d", len(backups))
+	}
+	// Backups in the same millisecond take the next one
+	if backups[0].Name != "main.beancount.20260102-150405.002" || !backups[0].Time.Equal(stamp.Add(2*time.Millisecond)) {
+		t.Errorf("unexpected newest backup %+v", backups[0])
+	}
+	data, _ := os.ReadFile(backups[1].Path)
+	if string(data) != "two\n" || backups[1].Size != 4 || backups[1].Of != ledger {
+		t.Errorf("unexpected oldest backup %+v holding %q", backups[1], data)
+	}
+
+	// A file of the same name elsewhere has backups of its own
+	other := filepath.Join(dir, "sub", "main.beancount")
+	if others, err := store.List(other); err != nil || len(others) != 0 {
+		t.Errorf("expected no backups of %s, got %v (%v)", other, others, err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+	ledger := filepath.Join(dir, "main.beancount")
+	store := New(filepath.Join(dir, "backups"), 5)
+	if err := os.WriteFile(ledger, []byte("before\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ledger); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(ledger, []byte("after\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := store.List(ledger)
+	if err := store.Restore(backups[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(ledger)
+	if string(data) != "before\n" {
+		t.Errorf("expected the backup restored, got %q", data)
+	}
+	if info, _ := os.Stat(ledger); info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions kept, got %v", info.Mode().Perm())
+	}
+
+	// The file as it was before restoring is backed up too
+	backups, _ = store.List(ledger)
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %d", len(backups))
+	}
+	data, _ = os.ReadFile(backups[0].Path)
+	if string(data) != "after\n" {
+		t.Errorf("expected the replaced contents backed up, got %q", data)
+	}
+}
@@ -0,0 +1,160 @@
+// Package banksync pulls transactions from a bank aggregator, SimpleFIN Bridge or
+// Plaid, and turns those of the accounts mapped in the config into import entries,
+// to be checked for duplicates, categorized and staged like an imported statement.
+// Syncing is opt-in: nothing is fetched unless sync.enabled is set.
+package banksync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/importer"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// IDKey is the metadata key synced transactions carry the aggregator's ID under, so
+// the next sync leaves them out
+const IDKey = "sync_id"
+
+// Account is an account at the aggregator
+type Account struct {
+	ID       string
+	Name     string
+	Currency string
+}
+
+// Transaction is a transaction as the aggregator reports it
+type Transaction struct {
+	ID        string
+	Account   string // Aggregator account ID
+	Date      time.Time
+	Amount    decimal.Decimal // From the account's side: negative for money going out
+	Currency  string
+	Payee     string
+	Narration string
+	Pending   bool
+}
+
+// Provider is a bank aggregator
+type Provider interface {
+	// Fetch returns the accounts and the transactions posted between start and end
+	Fetch(ctx context.Context, start, end time.Time) ([]Account, []Transaction, error)
+}
+
+// New creates the configured provider, with credentials from the environment
+func New(cfg config.SyncConfig) (Provider, error) {
+	if !cfg.Enabled {
+		return nil, errors.New("bank sync is off: set sync.enabled to use it")
+	}
+	client := &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second}
+	switch cfg.Provider {
+	case "simplefin":
+		accessURL := os.Getenv(cfg.AccessURLEnv)
+		if accessURL == "" {
+			return nil, fmt.Errorf("set %s to the SimpleFIN access URL, as lima sync --claim prints it", cfg.AccessURLEnv)
+		}
+		return NewSimpleFIN(accessURL, client)
+	case "plaid":
+		credentials := map[string]string{}
+		for _, env := range []string{cfg.ClientIDEnv, cfg.SecretEnv, cfg.AccessTokenEnv} {
+			if credentials[env] = os.Getenv(env); credentials[env] == "" {
+				return nil, fmt.Errorf("set %s to the Plaid credential it names", env)
+			}
+		}
+		return NewPlaid(cfg.Endpoint, credentials[cfg.ClientIDEnv], credentials[cfg.SecretEnv], credentials[cfg.AccessTokenEnv], client), nil
+	}
+	return nil, fmt.Errorf("unknown sync provider %q", cfg.Provider)
+}
+
+// Result is what a sync found
+type Result struct {
+	// Entries are the new transactions of mapped accounts, oldest first
+	Entries []importer.Entry
+
+	// Synced counts the transactions already in the ledger from an earlier sync, and
+	// Pending those not yet posted, which are left for a later one
+	Synced  int
+	Pending int
+
+	// Unmapped are the accounts with transactions but no beancount account in
+	// sync.accounts
+	Unmapped []Account
+}
+
+// Sync fetches the transactions of the last cfg.Days days and turns those of mapped
+// accounts into entries, leaving out pending ones and those an earlier sync added
+func Sync(ctx context.Context, provider Provider, cfg config.SyncConfig, ledger []*beancount.Transaction, now time.Time) (Result, error) {
+	var result Result
+	for id, account := range cfg.Accounts {
+		if !beancount.IsValidAccount(account) {
+			return result, fmt.Errorf("sync.accounts: invalid account %q for %s", account, id)
+		}
+	}
+
+	accounts, transactions, err := provider.Fetch(ctx, now.AddDate(0, 0, -cfg.Days), now)
+	if err != nil {
+		return result, err
+	}
+	names := make(map[string]Account, len(accounts))
+	for _, account := range accounts {
+		names[account.ID] = account
+	}
+	synced := make(map[string]bool)
+	for _, tx := range ledger {
+		if id := tx.Metadata[IDKey]; id != "" {
+			synced[id] = true
+		}
+	}
+
+	unmapped := make(map[string]bool)
+	for _, tx := range transactions {
+		account, ok := cfg.Accounts[tx.Account]
+		switch {
+		case tx.Pending:
+			result.Pending++
+			continue
+		case !ok:
+			if !unmapped[tx.Account] {
+				unmapped[tx.Account] = true
+				unknown := names[tx.Account]
+				unknown.ID = tx.Account
+				result.Unmapped = append(result.Unmapped, unknown)
+			}
+			continue
+		case synced[tx.ID]:
+			result.Synced++
+			continue
+		}
+
+		record := importer.Record{
+			Date:      tx.Date.Format("2006-01-02"),
+			Amount:    tx.Amount.String(),
+			Currency:  tx.Currency,
+			Account:   account,
+			Payee:     tx.Payee,
+			Narration: tx.Narration,
+			Metadata:  map[string]string{IDKey: tx.ID},
+		}
+		if record.Payee == "" && record.Narration == "" {
+			record.Narration = "Transaction " + tx.ID
+		}
+		built, err := record.Transaction()
+		if err != nil {
+			return result, fmt.Errorf("transaction %s of %s: %w", tx.ID, account, err)
+		}
+		result.Entries = append(result.Entries, importer.Entry{Transaction: built})
+	}
+
+	sort.SliceStable(result.Entries, func(i, j int) bool {
+		return result.Entries[i].Transaction.Date.Before(result.Entries[j].Transaction.Date)
+	})
+	sort.Slice(result.Unmapped, func(i, j int) bool { return result.Unmapped[i].ID < result.Unmapped[j].ID })
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package banksync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// plaidPageSize is how many transactions a Plaid request asks for, its maximum
+const plaidPageSize = 500
+
+// Plaid reads the accounts of one Plaid item, e.g. in the sandbox
+// https://plaid.com/docs/api/products/transactions/#transactionsget
+type Plaid struct {
+	endpoint    string
+	clientID    string
+	secret      string
+	accessToken string
+	client      *http.Client
+}
+
+// NewPlaid creates a provider for a Plaid item
+func NewPlaid(endpoint, clientID, secret, accessToken string, client *http.Client) *Plaid {
+	return &Plaid{
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		clientID:    clientID,
+		secret:      secret,
+		accessToken: accessToken,
+		client:      client,
+	}
+}
+
+// plaidRequest is the body of a /transactions/get request
+type plaidRequest struct {
+	ClientID    string `json:"client_id"`
+	Secret      string `json:"secret"`
+	AccessToken string `json:"access_token"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	Options     struct {
+		Count  int `json:"count"`
+		Offset int `json:"offset"`
+	} `json:"options"`
+}
+
+// plaidResponse is the answer to a /transactions/get request
+type plaidResponse struct {
+	Accounts []struct {
+		AccountID string `json:"account_id"`
+		Name      string `json:"name"`
+		Balances  struct {
+			ISOCurrencyCode        string `json:"iso_currency_code"`
+			UnofficialCurrencyCode string `json:"unofficial_currency_code"`
+		} `json:"balances"`
+	} `json:"accounts"`
+	Transactions []struct {
+		TransactionID          string      `json:"transaction_id"`
+		AccountID              string      `json:"account_id"`
+		Amount                 json.Number `json:"amount"`
+		ISOCurrencyCode        string      `json:"iso_currency_code"`
+		UnofficialCurrencyCode string      `json:"unofficial_currency_code"`
+		Date                   string      `json:"date"`
+		Name                   string      `json:"name"`
+		MerchantName           string      `json:"merchant_name"`
+		Pending                bool        `json:"pending"`
+	} `json:"transactions"`
+	TotalTransactions int `json:"total_transactions"`
+}
+
+// plaidError is the body of a failed Plaid request
+type plaidError struct {
+	ErrorCode    string `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// Fetch returns the item's accounts and the transactions dated between start and end,
+// a page at a time
+// Plaid amounts are positive for money going out, so they are negated.
+func (p *Plaid) Fetch(ctx context.Context, start, end time.Time) ([]Account, []Transaction, error) {
+	var accounts []Account
+	var transactions []Transaction
+	for offset := 0; ; {
+		page, err := p.page(ctx, start, end, offset)
+		if err != nil {
+			return nil, nil, err
+		}
+		if offset == 0 {
+			for _, account := range page.Accounts {
+				currency := account.Balances.ISOCurrencyCode
+				if currency == "" {
+					currency = account.Balances.UnofficialCurrencyCode
+				}
+				accounts = append(accounts, Account{ID: account.AccountID, Name: account.Name, Currency: currency})
+			}
+		}
+		for _, tx := range page.Transactions {
+			amount, err := decimal.NewFromString(tx.Amount.String())
+			if err != nil {
+				return nil, nil, fmt.Errorf("plaid transaction %s: invalid amount %q", tx.TransactionID, tx.Amount)
+			}
+			date, err := time.Parse("2006-01-02", tx.Date)
+			if err != nil {
+				return nil, nil, fmt.Errorf("plaid transaction %s: invalid date %q", tx.TransactionID, tx.Date)
+			}
+			currency := tx.ISOCurrencyCode
+			if currency == "" {
+				currency = tx.UnofficialCurrencyCode
+			}
+			payee, narration := tx.MerchantName, tx.Name
+			if payee == "" {
+				payee, narration = tx.Name, ""
+			}
+			transactions = append(transactions, Transaction{
+				ID:        tx.TransactionID,
+				Account:   tx.AccountID,
+				Date:      date,
+				Amount:    amount.Neg(),
+				Currency:  currency,
+				Payee:     payee,
+				Narration: narration,
+				Pending:   tx.Pending,
+			})
+		}
+		offset += len(page.Transactions)
+		if len(page.Transactions) == 0 || offset >= page.TotalTransactions {
+			return accounts, transactions, nil
+		}
+	}
+}
+
+// page requests the transactions from an offset
+func (p *Plaid) page(ctx context.Context, start, end time.Time, offset int) (*plaidResponse, error) {
+	request := plaidRequest{
+		ClientID:    p.clientID,
+		Secret:      p.secret,
+		AccessToken: p.accessToken,
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+	}
+	request.Options.Count = plaidPageSize
+	request.Options.Offset = offset
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/transactions/get", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("plaid request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		var failure plaidError
+		if json.Unmarshal(snippet, &failure) == nil && failure.ErrorCode != "" {
+			return nil, fmt.Errorf("plaid request failed: %s: %s", failure.ErrorCode, failure.ErrorMessage)
+		}
+		return nil, fmt.Errorf("plaid request failed: %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	var page plaidResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode plaid response: %w", err)
+	}
+	return &page, nil
+}
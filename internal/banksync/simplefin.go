@@ -0,0 +1,158 @@
+package banksync
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SimpleFIN reads accounts from a SimpleFIN Bridge server
+// https://www.simplefin.org/protocol.html
+type SimpleFIN struct {
+	// base is the access URL without its credentials, which go in every request's
+	// basic auth so errors never show them
+	base     *url.URL
+	user     string
+	password string
+	client   *http.Client
+}
+
+// NewSimpleFIN creates a provider for a SimpleFIN access URL
+func NewSimpleFIN(accessURL string, client *http.Client) (*SimpleFIN, error) {
+	base, err := url.Parse(strings.TrimSpace(accessURL))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, errors.New("invalid SimpleFIN access URL")
+	}
+	s := &SimpleFIN{client: client}
+	if base.User != nil {
+		s.user = base.User.Username()
+		s.password, _ = base.User.Password()
+		base.User = nil
+	}
+	s.base = base
+	return s, nil
+}
+
+// Claim exchanges a SimpleFIN setup token, which can be used once, for the access URL
+// the bridge issues
+func Claim(ctx context.Context, setupToken string, client *http.Client) (string, error) {
+	claimURL, err := base64.StdEncoding.DecodeString(strings.TrimSpace(setupToken))
+	if err != nil {
+		return "", errors.New("invalid SimpleFIN setup token: it should be base64")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, string(claimURL), nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid SimpleFIN setup token: %w", err)
+	}
+	req.Header.Set("Content-Length", "0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("simplefin claim failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("simplefin claim failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("simplefin claim failed: %s: %s (setup tokens can only be claimed once)", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// simplefinAccounts is the answer of a SimpleFIN /accounts request
+type simplefinAccounts struct {
+	Errors   []string `json:"errors"`
+	Accounts []struct {
+		ID           string `json:"id"`
+		Name         string `json:"name"`
+		Currency     string `json:"currency"`
+		Transactions []struct {
+			ID          string `json:"id"`
+			Posted      int64  `json:"posted"`
+			Amount      string `json:"amount"`
+			Description string `json:"description"`
+			Payee       string `json:"payee"`
+			Memo        string `json:"memo"`
+			Pending     bool   `json:"pending"`
+		} `json:"transactions"`
+	} `json:"accounts"`
+}
+
+// Fetch returns the accounts and the transactions posted between start and end
+// Transactions with no posted date yet are pending.
+func (s *SimpleFIN) Fetch(ctx context.Context, start, end time.Time) ([]Account, []Transaction, error) {
+	u := *s.base
+	u.Path = strings.TrimRight(u.Path, "/") + "/accounts"
+	u.RawQuery = url.Values{
+		"start-date": {strconv.FormatInt(start.Unix(), 10)},
+		"end-date":   {strconv.FormatInt(end.Unix(), 10)},
+		"pending":    {"1"},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if s.user != "" {
+		req.SetBasicAuth(s.user, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("simplefin request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if resp.StatusCode == http.StatusForbidden {
+			return nil, nil, fmt.Errorf("simplefin refused the access URL (%s): claim a new one", resp.Status)
+		}
+		return nil, nil, fmt.Errorf("simplefin request failed: %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	var answer simplefinAccounts
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode simplefin response: %w", err)
+	}
+	if len(answer.Errors) > 0 {
+		return nil, nil, fmt.Errorf("simplefin: %s", strings.Join(answer.Errors, "; "))
+	}
+
+	var accounts []Account
+	var transactions []Transaction
+	for _, account := range answer.Accounts {
+		accounts = append(accounts, Account{ID: account.ID, Name: account.Name, Currency: account.Currency})
+		for _, tx := range account.Transactions {
+			amount, err := decimal.NewFromString(tx.Amount)
+			if err != nil {
+				return nil, nil, fmt.Errorf("simplefin transaction %s: invalid amount %q", tx.ID, tx.Amount)
+			}
+			payee, narration := tx.Payee, tx.Description
+			if payee == "" {
+				payee, narration = tx.Description, tx.Memo
+			}
+			transactions = append(transactions, Transaction{
+				ID:        tx.ID,
+				Account:   account.ID,
+				Date:      time.Unix(tx.Posted, 0).UTC(),
+				Amount:    amount,
+				Currency:  account.Currency,
+				Payee:     payee,
+				Narration: narration,
+				Pending:   tx.Pending || tx.Posted == 0,
+			})
+		}
+	}
+	return accounts, transactions, nil
+}
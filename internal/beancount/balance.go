@@ -0,0 +1,102 @@
+package beancount
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// Weight returns the amount a posting contributes to its transaction's balance
+// Postings held at cost or converted at a price weigh in the cost or price commodity
+func (p Posting) Weight() *Amount {
+	if p.Amount == nil {
+		return nil
+	}
+	switch {
+	case p.Cost != nil:
+		return &Amount{Number: p.Amount.Number.Mul(p.Cost.Number), Commodity: p.Cost.Commodity}
+	case p.Price != nil:
+		return &Amount{Number: p.Amount.Number.Mul(p.Price.Number), Commodity: p.Price.Commodity}
+	}
+	return p.Amount
+}
+
+// ResolvedPostings returns the postings with an auto-balanced posting filled in
+// The elided posting receives the residual of the others, one posting per commodity;
+// it is dropped when the others already balance
+func (t *Transaction) ResolvedPostings() []Posting {
+	elided := -1
+	residual := make(map[string]decimal.Decimal)
+	for i, posting := range t.Postings {
+		weight := posting.Weight()
+		if weight == nil {
+			elided = i
+			continue
+		}
+		residual[weight.Commodity] = residual[weight.Commodity].Add(weight.Number)
+	}
+	if elided < 0 {
+		return t.Postings
+	}
+
+	commodities := make([]string, 0, len(residual))
+	for commodity, number := range residual {
+		if !number.IsZero() {
+			commodities = append(commodities, commodity)
+		}
+	}
+	sort.Strings(commodities)
+
+	postings := make([]Posting, 0, len(t.Postings)+len(commodities))
+	for i, posting := range t.Postings {
+		if i != elided {
+			postings = append(postings, posting)
+			continue
+		}
+		for _, commodity := range commodities {
+			filled := posting
+			filled.Amount = &Amount{Number: residual[commodity].Neg(), Commodity: commodity}
+			postings = append(postings, filled)
+		}
+	}
+	return postings
+}
+
+// AccountBalances sums postings into per-account, per-commodity balances
+func AccountBalances(transactions []*Transaction) map[string]map[string]decimal.Decimal {
+	balances := make(map[string]map[string]decimal.Decimal)
+	for _, tx := range transactions {
+		for _, posting := range tx.ResolvedPostings() {
+			if posting.Amount == nil {
+				continue
+			}
+			balance, ok := balances[posting.Account]
+			if !ok {
+				balance = make(map[string]decimal.Decimal)
+				balances[posting.Account] = balance
+			}
+			commodity := posting.Amount.Commodity
+			balance[commodity] = balance[commodity].Add(posting.Amount.Number)
+		}
+	}
+	return balances
+}
+
+// RollUpBalances adds each account's balance to all of its parent accounts
+// The result has an entry for every account and ancestor, e.g. "Expenses" and "Expenses:Food"
+func RollUpBalances(balances map[string]map[string]decimal.Decimal) map[string]map[string]decimal.Decimal {
+	rolled := make(map[string]map[string]decimal.Decimal)
+	for account, balance := range balances {
+		for _, name := range AccountAncestry(account) {
+			total, ok := rolled[name]
+			if !ok {
+				total = make(map[string]decimal.Decimal)
+				rolled[name] = total
+			}
+			for commodity, number := range balance {
+				total[commodity] = total[commodity].Add(number)
+			}
+		}
+	}
+	return rolled
+}
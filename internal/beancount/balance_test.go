@@ -0,0 +1,111 @@
+package beancount
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAccountBalances(t *testing.T) {
+	usd := func(n string) *Amount {
+		return &Amount{Number: decimal.RequireFromString(n), Commodity: "USD"}
+	}
+	transactions := []*Transaction{
+		{Postings: []Posting{
+			{Account: "Assets:Checking", Amount: usd("-5.50")},
+			{Account: "Expenses:Food:Coffee", Amount: usd("5.50")},
+		}},
+		{Postings: []Posting{
+			{Account: "Expenses:Food:Groceries", Amount: usd("20.00")},
+			{Account: "Assets:Checking"},
+		}},
+		{Postings: []Posting{
+			{Account: "Assets:Brokerage", Amount: &Amount{Number: decimal.RequireFromString("2"), Commodity: "VTI"},
+				Price: usd("100.00")},
+			{Account: "Assets:Checking"},
+		}},
+	}
+
+	balances := AccountBalances(transactions)
+
+	tests := []struct {
+		account   string
+		commodity string
+		want      string
+	}{
+		{"Assets:Checking", "USD", "-225.50"},
+		{"Expenses:Food:Coffee", "USD", "5.50"},
+		{"Expenses:Food:Groceries", "USD", "20.00"},
+		{"Assets:Brokerage", "VTI", "2"},
+	}
+	for _, tt := range tests {
+		got := balances[tt.account][tt.commodity]
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("%s %s: got %s, want %s", tt.account, tt.commodity, got, tt.want)
+		}
+	}
+	if _, ok := balances["Assets:Brokerage"]["USD"]; ok {
+		t.Errorf("priced posting should hold units, not the price commodity")
+	}
+}
+
+func TestResolvedPostingsBalanced(t *testing.T) {
+	tx := &Transaction{Postings: []Posting{
+		{Account: "Assets:Checking", Amount: &Amount{Number: decimal.NewFromInt(-1), Commodity: "USD"}},
+		{Account: "Expenses:Misc", Amount: &Amount{Number: decimal.NewFromInt(1), Commodity: "USD"}},
+		{Account: "Equity:Rounding"},
+	}}
+	if got := len(tx.ResolvedPostings()); got != 2 {
+		t.Errorf("expected balanced elided posting to be dropped, got %d postings", got)
+	}
+}
+
+func TestRollUpBalances(t *testing.T) {
+	usd := func(n string) map[string]decimal.Decimal {
+		return map[string]decimal.Decimal{"USD": decimal.RequireFromString(n)}
+	}
+	rolled := RollUpBalances(map[string]map[string]decimal.Decimal{
+		"Expenses:Food:Coffee":    usd("5.50"),
+		"Expenses:Food:Groceries": usd("20.00"),
+		"Expenses:Rent":           usd("1000.00"),
+	})
+
+	tests := map[string]string{
+		"Expenses":             "1025.50",
+		"Expenses:Food":        "25.50",
+		"Expenses:Food:Coffee": "5.50",
+		"Expenses:Rent":        "1000.00",
+	}
+	for account, want := range tests {
+		if got := rolled[account]["USD"]; !got.Equal(decimal.RequireFromString(want)) {
+			t.Errorf("%s: got %s, want %s", account, got, want)
+		}
+	}
+}
+
+func TestBuildAccountTree(t *testing.T) {
+	roots := BuildAccountTree([]string{
+		"Expenses:Food:Coffee",
+		"Assets:Bank:Savings",
+		"Assets:Bank:Checking",
+		"Custom:Thing",
+		"Income:Salary",
+	})
+
+	var names []string
+	for _, root := range roots {
+		names = append(names, root.Name)
+	}
+	if got := strings.Join(names, ","); got != "Assets,Income,Expenses,Custom" {
+		t.Errorf("unexpected root order: %s", got)
+	}
+
+	bank := roots[0].Children[0]
+	if bank.Account != "Assets:Bank" || bank.Depth != 1 || len(bank.Children) != 2 {
+		t.Fatalf("unexpected bank node: %+v", bank)
+	}
+	if bank.Children[0].Name != "Checking" || bank.Children[1].Account != "Assets:Bank:Savings" {
+		t.Errorf("expected sorted children, got %s, %s", bank.Children[0].Name, bank.Children[1].Account)
+	}
+}
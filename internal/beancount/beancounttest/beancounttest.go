@@ -0,0 +1,35 @@
+// Package beancounttest builds ledger values for the tests of packages that read them
+package beancounttest
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// Date parses a YYYY-MM-DD date; a malformed one gives the zero time
+func Date(s string) time.Time {
+	d, _ := time.Parse("2006-01-02", s)
+	return d
+}
+
+// Tx returns a transaction on day, YYYY-MM-DD, from payee, posting amount to account
+// and balancing it with Assets:Checking
+// amount is a number, in USD unless a commodity follows it: "-12.50" or "30 EUR".
+func Tx(day, payee, account, amount string, tags ...string) *beancount.Transaction {
+	number, commodity, found := strings.Cut(amount, " ")
+	if !found {
+		commodity = "USD"
+	}
+	return &beancount.Transaction{
+		Date:  Date(day),
+		Payee: payee,
+		Tags:  tags,
+		Postings: []beancount.Posting{
+			{Account: account, Amount: &beancount.Amount{Number: decimal.RequireFromString(number), Commodity: commodity}},
+			{Account: "Assets:Checking"},
+		},
+	}
+}
@@ -0,0 +1,383 @@
+package beancount
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ProblemKind classifies a problem found by Check
+type ProblemKind string
+
+const (
+	ProblemSyntax     ProblemKind = "syntax"     // A line that can't be read
+	ProblemValidation ProblemKind = "validation" // A readable entry that breaks a ledger rule
+	ProblemUnbalanced ProblemKind = "unbalanced" // A transaction whose postings don't sum to zero
+	ProblemBalance    ProblemKind = "balance"    // A balance assertion that doesn't hold
+)
+
+// Problem is something wrong with the ledger, at a line of one of its files
+type Problem struct {
+	Kind       ProblemKind
+	FilePath   string
+	LineNumber int
+	Line       string // The offending line as written
+	Message    string
+
+	// Transaction is the index of the transaction involved, -1 when none is
+	Transaction int
+}
+
+// Directive keywords that may follow a date, besides transaction flags
+var datedKeywords = map[string]bool{
+	"open": true, "close": true, "balance": true, "pad": true, "note": true, "document": true,
+	"event": true, "query": true, "custom": true, "price": true, "commodity": true,
+}
+
+// Keywords that start an undated line
+var undatedKeywords = map[string]bool{
+	"option": true, "plugin": true, "include": true,
+	"pushtag": true, "poptag": true, "pushmeta": true, "popmeta": true,
+}
+
+// datedLineRegex splits a dated line into its date and the word after it
+var datedLineRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+(\S+)`)
+
+// Check reads the ledger and its includes for problems: lines that can't be read,
+// transactions that don't balance or have more than one posting without an amount,
+// postings to accounts not open at the time and balance assertions that don't hold.
+// Accounts are only checked when the ledger declares them with open directives.
+// Problems come in file and line order.
+func (f *File) Check() ([]Problem, error) {
+	transactionAt := make(map[string]int, len(f.index.transactions))
+	for i, txIndex := range f.index.transactions {
+		transactionAt[lineKey(txIndex.FilePath, txIndex.LineNumber)] = i
+	}
+
+	var problems []Problem
+	lines := make(map[string][]string, len(f.index.files))
+	for _, path := range f.index.files {
+		fileLines, err := f.store.readLedgerLines(path)
+		if err != nil {
+			return nil, err
+		}
+		lines[path] = fileLines
+		problems = append(problems, checkSyntax(path, fileLines, transactionAt)...)
+	}
+
+	transactions, err := f.AllTransactions()
+	if err != nil {
+		return nil, err
+	}
+	opened := make(map[string]time.Time, len(f.index.opens))
+	for _, open := range f.index.opens {
+		if first, ok := opened[open.Account]; !ok || open.Date.Before(first) {
+			opened[open.Account] = open.Date
+		}
+	}
+	closed := make(map[string]time.Time, len(f.index.closes))
+	for _, c := range f.index.closes {
+		closed[c.Account] = c.Date
+	}
+
+	for i, tx := range transactions {
+		txIndex := f.index.transactions[i]
+		fileLines := lines[txIndex.FilePath]
+		problem := func(kind ProblemKind, lineNumber int, format string, args ...any) Problem {
+			return Problem{
+				Kind:        kind,
+				FilePath:    txIndex.FilePath,
+				LineNumber:  lineNumber,
+				Line:        lineAt(fileLines, lineNumber),
+				Message:     fmt.Sprintf(format, args...),
+				Transaction: i,
+			}
+		}
+
+		elided := 0
+		for _, posting := range tx.Postings {
+			if posting.Amount == nil {
+				elided++
+			}
+		}
+		if elided > 1 {
+			problems = append(problems, problem(ProblemValidation, tx.LineNumber,
+				"%d postings without an amount; only one can be inferred", elided))
+		}
+		if elided == 0 && balanceReadable(tx, fileLines) {
+			if residual := unbalanced(tx); residual != "" {
+				problems = append(problems, problem(ProblemUnbalanced, tx.LineNumber,
+					"Transaction does not balance: %s left over", residual))
+			}
+		}
+
+		if len(opened) == 0 {
+			continue
+		}
+		for _, posting := range tx.Postings {
+			openDate, ok := opened[posting.Account]
+			switch {
+			case !ok:
+				problems = append(problems, problem(ProblemValidation, posting.LineNumber,
+					"Account %s is never opened", posting.Account))
+			case tx.Date.Before(openDate):
+				problems = append(problems, problem(ProblemValidation, posting.LineNumber,
+					"Account %s is not open until %s", posting.Account, openDate.Format("2006-01-02")))
+			}
+			if closeDate, ok := closed[posting.Account]; ok && tx.Date.After(closeDate) {
+				problems = append(problems, problem(ProblemValidation, posting.LineNumber,
+					"Account %s was closed on %s", posting.Account, closeDate.Format("2006-01-02")))
+			}
+		}
+	}
+
+	problems = append(problems, f.checkBalances(transactions, lines)...)
+
+	order := make(map[string]int, len(f.index.files))
+	for i, path := range f.index.files {
+		order[path] = i
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if a.FilePath != b.FilePath {
+			return order[a.FilePath] < order[b.FilePath]
+		}
+		return a.LineNumber < b.LineNumber
+	})
+	return problems, nil
+}
+
+// checkSyntax reports the lines of one file that can't be read
+// transactionAt maps the start of each indexed transaction to its index.
+func checkSyntax(path string, lines []string, transactionAt map[string]int) []Problem {
+	var problems []Problem
+	report := func(lineNumber int, transaction int, format string, args ...any) {
+		problems = append(problems, Problem{
+			Kind:        ProblemSyntax,
+			FilePath:    path,
+			LineNumber:  lineNumber,
+			Line:        lines[lineNumber-1],
+			Message:     fmt.Sprintf(format, args...),
+			Transaction: transaction,
+		})
+	}
+
+	transaction := -1 // The transaction whose postings follow, if any
+	inEntry := false  // Whether indented lines belong to a directive
+	for i, line := range lines {
+		lineNumber := i + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+
+		if line[0] == ' ' || line[0] == '\t' {
+			switch {
+			case !inEntry:
+				report(lineNumber, -1, "Indented line outside any entry")
+			case metadataRegex.MatchString(line):
+			case transaction < 0:
+				report(lineNumber, -1, "Unrecognized line in directive")
+			case postingRegex.MatchString(line):
+				rest := strings.TrimSpace(postingRegex.FindStringSubmatch(line)[2])
+				if rest != "" && !strings.HasPrefix(rest, ";") {
+					if _, _, err := parseAmount(rest); err != nil {
+						report(lineNumber, transaction, "Invalid posting amount")
+					}
+				}
+			default:
+				report(lineNumber, transaction, "Unrecognized line in transaction")
+			}
+			continue
+		}
+
+		transaction, inEntry = -1, false
+		if strings.ContainsAny(line[:1], ";#*%") {
+			continue // Comments and org-mode headings
+		}
+		matches := datedLineRegex.FindStringSubmatch(line)
+		if matches == nil {
+			word, _, _ := strings.Cut(trimmed, " ")
+			if !undatedKeywords[word] {
+				report(lineNumber, -1, "Unrecognized line")
+			}
+			continue
+		}
+
+		if _, err := time.Parse("2006-01-02", matches[1]); err != nil {
+			report(lineNumber, -1, "Invalid date %s", matches[1])
+			continue
+		}
+		inEntry = true
+		if index, ok := transactionAt[lineKey(path, lineNumber)]; ok {
+			transaction = index
+			continue
+		}
+		switch word := matches[2]; {
+		case datedKeywords[word]:
+		case word == "txn" || len(word) == 1:
+			report(lineNumber, -1, "Transaction header not recognized; expected * or ! and a quoted narration")
+		default:
+			report(lineNumber, -1, "Unknown directive %q", word)
+		}
+	}
+	return problems
+}
+
+// checkBalances reports the balance assertions that don't hold; an assertion checks
+// an account's balance, sub-accounts included, at the start of its date
+// Without an explicit tolerance an assertion tolerates half a unit in the last decimal
+// place of its amount, and nothing for a whole amount.
+func (f *File) checkBalances(transactions []*Transaction, lines map[string][]string) []Problem {
+	type assertion struct {
+		Balance
+		path string
+	}
+	var assertions []assertion
+	for _, path := range f.index.files {
+		for i, line := range lines[path] {
+			if balance := parseBalanceLine(line, i+1); balance != nil {
+				assertions = append(assertions, assertion{*balance, path})
+			}
+		}
+	}
+	sort.SliceStable(assertions, func(i, j int) bool {
+		return assertions[i].Date.Before(assertions[j].Date)
+	})
+
+	// Walk the transactions in date order, checking each assertion once everything
+	// dated before it is counted
+	var problems []Problem
+	balances := make(map[string]map[string]decimal.Decimal)
+	next := 0
+	for _, a := range assertions {
+		for ; next < len(f.index.byDate); next++ {
+			tx := transactions[f.index.byDate[next]]
+			if !tx.Date.Before(a.Date) {
+				break
+			}
+			for _, posting := range tx.ResolvedPostings() {
+				if posting.Amount == nil {
+					continue
+				}
+				balance, ok := balances[posting.Account]
+				if !ok {
+					balance = make(map[string]decimal.Decimal)
+					balances[posting.Account] = balance
+				}
+				balance[posting.Amount.Commodity] = balance[posting.Amount.Commodity].Add(posting.Amount.Number)
+			}
+		}
+
+		var total decimal.Decimal
+		for account, balance := range balances {
+			if AccountUnder(account, a.Account) {
+				total = total.Add(balance[a.Amount.Commodity])
+			}
+		}
+		places := max(0, -a.Amount.Number.Exponent())
+		tolerance := decimal.Zero
+		switch {
+		case a.Tolerance != nil:
+			tolerance = *a.Tolerance
+		case places > 0:
+			tolerance = decimal.New(5, -places-1)
+		}
+		if difference := total.Sub(a.Amount.Number); difference.Abs().GreaterThan(tolerance) {
+			problems = append(problems, Problem{
+				Kind:       ProblemBalance,
+				FilePath:   a.path,
+				LineNumber: a.LineNumber,
+				Line:       lineAt(lines[a.path], a.LineNumber),
+				Message: fmt.Sprintf("Balance assertion failed: %s is %s %s, not %s %s",
+					a.Account, total.StringFixed(max(places, -total.Exponent())), a.Amount.Commodity,
+					a.Amount.Number.StringFixed(places), a.Amount.Commodity),
+				Transaction: -1,
+			})
+		}
+	}
+	return problems
+}
+
+// balanceReadable reports whether every posting's weight was read, so the
+// transaction can be checked for balance: a cost that couldn't be read, such as
+// one left for the booking to fill in, would make it look unbalanced
+func balanceReadable(tx *Transaction, lines []string) bool {
+	for _, posting := range tx.Postings {
+		if posting.Cost == nil && strings.Contains(lineAt(lines, posting.LineNumber), "{") {
+			return false
+		}
+	}
+	return true
+}
+
+// unbalanced returns what is left over per commodity when a transaction's posting
+// weights don't sum to zero, or "" when they do
+// Each commodity tolerates half a unit in the last decimal place of its least precise
+// amount with decimals.
+func unbalanced(tx *Transaction) string {
+	residual := make(map[string]decimal.Decimal)
+	places := make(map[string]int32)
+	for _, posting := range tx.Postings {
+		weight := posting.Weight()
+		residual[weight.Commodity] = residual[weight.Commodity].Add(weight.Number)
+		if posting.Amount.Commodity != weight.Commodity || posting.Amount.Number.Exponent() >= 0 {
+			continue
+		}
+		if digits, ok := places[weight.Commodity]; !ok || -posting.Amount.Number.Exponent() < digits {
+			places[weight.Commodity] = -posting.Amount.Number.Exponent()
+		}
+	}
+
+	var left []string
+	for commodity, number := range residual {
+		digits, ok := places[commodity]
+		if !ok {
+			digits = 2 // Only whole or converted amounts
+		}
+		tolerance := decimal.New(5, -digits-1)
+		if number.Abs().GreaterThan(tolerance) {
+			left = append(left, number.StringFixed(digits)+" "+commodity)
+		}
+	}
+	sort.Strings(left)
+	return strings.Join(left, ", ")
+}
+
+// readLedgerLines reads a ledger file's lines
+func (s *Store) readLedgerLines(path string) ([]string, error) {
+	file, err := s.open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning file %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+// lineAt returns the 1-based line of lines, or "" when out of range
+func lineAt(lines []string, lineNumber int) string {
+	if lineNumber < 1 || lineNumber > len(lines) {
+		return ""
+	}
+	return lines[lineNumber-1]
+}
+
+// lineKey identifies a line of a file
+func lineKey(path string, lineNumber int) string {
+	return fmt.Sprintf("%s:%d", path, lineNumber)
+}
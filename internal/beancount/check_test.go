@@ -0,0 +1,205 @@
+package beancount
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParsePostingCostAndPrice(t *testing.T) {
+	tests := []struct {
+		line      string
+		cost      string
+		price     string
+		commodity string
+	}{
+		{"  Assets:Brokerage  10 VTI {100.00 USD}", "100.00", "", "USD"},
+		{"  Assets:Brokerage  10 VTI {{1000.00 USD}}", "100", "", "USD"},
+		{"  Assets:Brokerage  -10 VTI {100.00 USD, 2024-01-02} @ 120.00 USD", "100.00", "120.00", "USD"},
+		{"  Assets:Cash  -4 EUR @@ 5.00 USD", "", "1.25", "USD"},
+		{"  Assets:Cash  -4 EUR @ 1.25 USD", "", "1.25", "USD"},
+		{"  Assets:Brokerage  -10 VTI {}", "", "", ""},
+	}
+	for _, tt := range tests {
+		posting, err := parsePosting(tt.line, 1)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.line, err)
+		}
+		check := func(what string, got *Amount, want string) {
+			switch {
+			case want == "" && got != nil:
+				t.Errorf("%q: unexpected %s %s %s", tt.line, what, got.Number, got.Commodity)
+			case want != "" && got == nil:
+				t.Errorf("%q: no %s, want %s", tt.line, what, want)
+			case want != "" && (!got.Number.Equal(decimal.RequireFromString(want)) || got.Commodity != tt.commodity):
+				t.Errorf("%q: %s %s %s, want %s %s", tt.line, what, got.Number, got.Commodity, want, tt.commodity)
+			}
+		}
+		check("cost", posting.Cost, tt.cost)
+		check("price", posting.Price, tt.price)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	included := `2024-02-01 * "Shop" "Unbalanced"
+  Expenses:Food  10.00 USD
+  Assets:Checking  -9.00 USD
+`
+	content := `option "title" "Test"
+include "more.beancount"
+
+2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food
+2024-01-01 open Assets:Brokerage
+2024-03-01 close Expenses:Food
+
+2024-01-05 * "Grocer" "Balanced"
+  Expenses:Food  10.004 USD
+  Assets:Checking  -10.00 USD
+
+2024-01-06 * "Broker" "Held at cost"
+  Assets:Brokerage  2 VTI {100.00 USD}
+  Assets:Checking  -200.00 USD
+
+2024-01-07 * "Exchange" "Total price"
+  Assets:Checking  -3 EUR @@ 10.00 USD
+  Expenses:Food  10.00 USD
+
+2024-01-08 * "Cafe" "Two elided"
+  Expenses:Food
+  Assets:Checking
+
+2024-01-09 * "Gym" "Not opened"
+  Expenses:Fitness  30.00 USD
+  Assets:Checking
+
+2024-04-01 * "Grocer" "After close"
+  Expenses:Food  5.00 USD
+  Assets:Checking
+
+2024-13-01 open Assets:Savings
+2024-01-10 txn "Flagless"
+2024-01-11 bogus Assets:Checking
+Stray text
+  Assets:Checking  ten USD
+`
+	if err := os.WriteFile(filepath.Join(dir, "more.beancount"), []byte(included), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "main.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	problems, err := f.Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	type found struct {
+		kind ProblemKind
+		file string
+		line int
+		tx   int
+	}
+	var got []found
+	for _, p := range problems {
+		got = append(got, found{p.Kind, filepath.Base(p.FilePath), p.LineNumber, p.Transaction})
+	}
+	expected := []found{
+		{ProblemValidation, "main.beancount", 21, 4},
+		{ProblemValidation, "main.beancount", 26, 5},
+		{ProblemValidation, "main.beancount", 30, 6},
+		{ProblemSyntax, "main.beancount", 33, -1},
+		{ProblemSyntax, "main.beancount", 34, -1},
+		{ProblemSyntax, "main.beancount", 35, -1},
+		{ProblemSyntax, "main.beancount", 36, -1},
+		{ProblemSyntax, "main.beancount", 37, -1},
+		{ProblemUnbalanced, "more.beancount", 1, 0},
+	}
+	if len(got) != len(expected) {
+		for _, p := range problems {
+			t.Logf("%s:%d %s: %s", filepath.Base(p.FilePath), p.LineNumber, p.Kind, p.Message)
+		}
+		t.Fatalf("got %d problems, want %d", len(got), len(expected))
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("problem %d: got %+v, want %+v (%s)", i, got[i], expected[i], problems[i].Message)
+		}
+	}
+
+	if p := problems[8]; !strings.Contains(p.Message, "1.00 USD") || !strings.HasPrefix(p.Line, "2024-02-01") {
+		t.Errorf("unbalanced problem should report the residual and its line, got %q at %q", p.Message, p.Line)
+	}
+	if p := problems[7]; p.Message != "Indented line outside any entry" {
+		t.Errorf("unexpected message for the stray posting: %q", p.Message)
+	}
+}
+
+func TestCheckBalances(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Assets:Savings:Emergency
+2024-01-01 open Expenses:Food
+
+2024-01-02 * "Employer" "Pay"
+  Assets:Checking  1000.00 USD
+  Assets:Savings:Emergency  500 USD
+  Expenses:Food  -1500.00 USD
+
+2024-01-05 * "Grocer" "Groceries"
+  Expenses:Food  40.004 USD
+  Assets:Checking
+
+2024-01-05 balance Assets:Checking  1000.00 USD
+2024-01-06 balance Assets:Checking  959.996 USD
+2024-01-06 balance Assets:Checking  960 USD
+2024-01-06 balance Assets:Checking  960 ~ 0.01 USD
+2024-01-06 balance Assets:Savings  500 USD ; parents include their sub-accounts
+2024-01-06 balance Assets:Checking  900.00 USD
+2024-01-06 balance Assets:Savings  0 EUR
+`
+	path := filepath.Join(t.TempDir(), "main.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	problems, err := f.Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	var lines []int
+	for _, p := range problems {
+		if p.Kind != ProblemBalance {
+			t.Errorf("unexpected problem at line %d: %s", p.LineNumber, p.Message)
+			continue
+		}
+		lines = append(lines, p.LineNumber)
+	}
+	// A whole amount tolerates nothing
+	if want := []int{16, 19}; !slices.Equal(lines, want) {
+		for _, p := range problems {
+			t.Logf("%d: %s", p.LineNumber, p.Message)
+		}
+		t.Fatalf("failed assertions at lines %v, want %v", lines, want)
+	}
+	if msg := problems[1].Message; msg != "Balance assertion failed: Assets:Checking is 959.996 USD, not 900.00 USD" {
+		t.Errorf("unexpected message: %q", msg)
+	}
+}
@@ -0,0 +1,239 @@
+package beancount
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Cipher decrypts and encrypts ledger files, such as those encrypted with age or GPG
+type Cipher interface {
+	// Encrypted reports whether the file at path is one the cipher handles
+	Encrypted(path string) bool
+	// Decrypt returns the plaintext of the file at path
+	Decrypt(path string, ciphertext []byte) ([]byte, error)
+	// Encrypt returns what to write to the file at path to store plaintext
+	Encrypt(path string, plaintext []byte) ([]byte, error)
+}
+
+// Store reads and writes the files of a ledger, through a cipher for encrypted ones
+// The plaintext of an encrypted file is only ever kept in memory; without a cipher,
+// files are read and written as they are. A ledger, its writer and the ledgers opened
+// from it share a store.
+type Store struct {
+	cipher Cipher
+
+	mu sync.Mutex
+	// plaintexts holds the decrypted contents of encrypted files, by absolute path, so
+	// a file is only decrypted again once it changes on disk
+	plaintexts map[string]plaintext
+	// deferred keeps the plaintext written to encrypted files in pending until Flush
+	deferred bool
+	pending  map[string][]byte
+
+	// flushing is held while pending plaintexts are encrypted and written
+	flushing sync.Mutex
+}
+
+// plaintext is the decrypted contents of a file, with the size and modification time
+// of the ciphertext it came from
+type plaintext struct {
+	data    []byte
+	size    int64
+	modTime time.Time
+}
+
+// NewStore returns a store reading and writing files through the cipher, which keeps
+// the plaintext of encrypted files in memory only; nil reads and writes them as they are
+func NewStore(c Cipher) *Store {
+	return &Store{
+		cipher:     c,
+		plaintexts: make(map[string]plaintext),
+		pending:    make(map[string][]byte),
+	}
+}
+
+// DeferEncryption sets whether writing an encrypted file only keeps its new plaintext,
+// for Flush to encrypt and write later, such as off a UI's thread; reading the file
+// returns what was written in the meantime
+func (s *Store) DeferEncryption(deferred bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deferred = deferred
+}
+
+// encrypted reports whether a file is read and written through the cipher
+func (s *Store) encrypted(path string) bool {
+	return s != nil && s.cipher != nil && s.cipher.Encrypted(path)
+}
+
+// ReadFile returns the contents of a ledger file, decrypted when it is encrypted
+func (s *Store) ReadFile(path string) ([]byte, error) {
+	if !s.encrypted(path) {
+		return os.ReadFile(path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if data, ok := s.pending[abs]; ok {
+		return data, nil
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := s.plaintexts[abs]; ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.data, nil
+	}
+	ciphertext, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.cipher.Decrypt(abs, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	s.plaintexts[abs] = plaintext{data: data, size: info.Size(), modTime: info.ModTime()}
+	return data, nil
+}
+
+// WriteFile atomically replaces a ledger file's contents, preserving its permissions
+// An encrypted file is encrypted again, so its plaintext never reaches the disk.
+func (s *Store) WriteFile(path string, data []byte) error {
+	if !s.encrypted(path) {
+		return ReplaceFile(path, data)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	deferred := s.deferred
+	if deferred {
+		s.pending[abs] = data
+	}
+	s.mu.Unlock()
+	if deferred {
+		return nil
+	}
+	return s.writeEncrypted(abs, data)
+}
+
+// Pending reports whether plaintext written while encryption is deferred is still to
+// be encrypted and written
+func (s *Store) Pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending) > 0
+}
+
+// Flush encrypts and writes the plaintext kept back while encryption is deferred
+// A file that fails stays pending for the next Flush.
+func (s *Store) Flush() error {
+	s.flushing.Lock()
+	defer s.flushing.Unlock()
+	s.mu.Lock()
+	pending := maps.Clone(s.pending)
+	s.mu.Unlock()
+
+	var errs []error
+	for _, abs := range slices.Sorted(maps.Keys(pending)) {
+		data := pending[abs]
+		if err := s.writeEncrypted(abs, data); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// Written again meanwhile, it waits for the next Flush
+		s.mu.Lock()
+		if bytes.Equal(s.pending[abs], data) {
+			delete(s.pending, abs)
+		}
+		s.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// writeEncrypted encrypts plaintext to the file at an absolute path, remembering it
+// so what was just written needn't be decrypted again
+func (s *Store) writeEncrypted(abs string, data []byte) error {
+	content, err := s.cipher.Encrypt(abs, data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", abs, err)
+	}
+	if err := ReplaceFile(abs, content); err != nil {
+		return err
+	}
+	if info, err := os.Stat(abs); err == nil {
+		s.mu.Lock()
+		s.plaintexts[abs] = plaintext{data: data, size: info.Size(), modTime: info.ModTime()}
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// ReplaceFile atomically replaces a file's contents, preserving its permissions
+func ReplaceFile(path string, content []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op after a successful rename
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// ledgerFile is a ledger file open for reading
+type ledgerFile interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+// open opens a ledger file for reading; an encrypted one is read from its plaintext
+// in memory
+func (s *Store) open(path string) (ledgerFile, error) {
+	if !s.encrypted(path) {
+		return os.Open(path)
+	}
+	data, err := s.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return memoryFile{bytes.NewReader(data)}, nil
+}
+
+// memoryFile is a ledger file read from memory
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error { return nil }
@@ -0,0 +1,133 @@
+package beancount
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testCipher "encrypts" .enc files as base64 behind a marker, counting decryptions
+type testCipher struct {
+	decrypted int
+}
+
+func (c *testCipher) Encrypted(path string) bool {
+	return strings.HasSuffix(path, ".enc")
+}
+
+func (c *testCipher) Decrypt(path string, ciphertext []byte) ([]byte, error) {
+	c.decrypted++
+	encoded, ok := bytes.CutPrefix(ciphertext, []byte("ENC:"))
+	if !ok {
+		return nil, errors.New("not encrypted")
+	}
+	return base64.StdEncoding.DecodeString(string(encoded))
+}
+
+func (c *testCipher) Encrypt(path string, plaintext []byte) ([]byte, error) {
+	return []byte("ENC:" + base64.StdEncoding.EncodeToString(plaintext)), nil
+}
+
+func TestEncryptedLedger(t *testing.T) {
+	c := &testCipher{}
+	store := NewStore(c)
+
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.beancount")
+	included := filepath.Join(dir, "2025.beancount.enc")
+	if err := os.WriteFile(main, []byte("include \"2025.beancount.enc\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, _ := c.Encrypt(included, []byte(`2025-01-01 * "Cafe" "Coffee"
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized  5.00 USD
+`))
+	if err := os.WriteFile(included, ciphertext, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := store.Open(main)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+	tx, err := f.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to read transaction: %v", err)
+	}
+	if tx.Payee != "Cafe" || tx.FilePath != included {
+		t.Fatalf("unexpected transaction %+v", tx)
+	}
+
+	w := NewWriter(f)
+	edit, err := w.SetPostingAccount(tx, 1, "Expenses:Food:Coffee")
+	if err != nil {
+		t.Fatalf("failed to build edit: %v", err)
+	}
+	if err := w.Apply(edit); err != nil {
+		t.Fatalf("failed to apply edit: %v", err)
+	}
+
+	data, _ := os.ReadFile(included)
+	if bytes.Contains(data, []byte("Expenses")) || !bytes.HasPrefix(data, []byte("ENC:")) {
+		t.Errorf("expected only ciphertext on disk, got:\n%s", data)
+	}
+	if info, _ := os.Stat(included); info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions kept, got %v", info.Mode().Perm())
+	}
+	plain, err := store.ReadFile(included)
+	if err != nil || !bytes.Contains(plain, []byte("Expenses:Food:Coffee")) {
+		t.Errorf("expected the edit in the plaintext, got %q (%v)", plain, err)
+	}
+	// The reload after writing reuses the plaintext just encrypted
+	if c.decrypted != 1 {
+		t.Errorf("expected one decryption, got %d", c.decrypted)
+	}
+	if accounts := f.GetAccounts(); !strings.Contains(strings.Join(accounts, " "), "Expenses:Food:Coffee") {
+		t.Errorf("expected the reloaded index to see the edit, got %v", accounts)
+	}
+}
+
+func TestDeferEncryption(t *testing.T) {
+	c := &testCipher{}
+	store := NewStore(c)
+	store.DeferEncryption(true)
+
+	path := filepath.Join(t.TempDir(), "main.beancount.enc")
+	ciphertext, _ := c.Encrypt(path, []byte("2025-01-01 open Assets:Checking\n"))
+	if err := os.WriteFile(path, ciphertext, 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := store.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	// The new plaintext is read back at once, but only reaches the disk on Flush
+	edit := Edit{FilePath: path, StartLine: 2, NewLines: []string{"2025-01-01 open Assets:Savings"}}
+	if err := NewWriter(f).Apply(edit); err != nil {
+		t.Fatalf("failed to apply edit: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, ciphertext) {
+		t.Errorf("expected the file left until the flush, got %q", data)
+	}
+	if !strings.Contains(strings.Join(f.GetAccounts(), " "), "Assets:Savings") || !store.Pending() {
+		t.Errorf("expected the reloaded index to see the pending edit, got %v", f.GetAccounts())
+	}
+
+	if err := store.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+	if store.Pending() {
+		t.Error("expected nothing pending after the flush")
+	}
+	data, _ := os.ReadFile(path)
+	if plain, err := c.Decrypt(path, data); err != nil || !bytes.Contains(plain, []byte("Assets:Savings")) {
+		t.Errorf("expected the edit encrypted to disk, got %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,188 @@
+package beancount
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change of a diff
+const diffContext = 3
+
+// diffOp is a line of a diff: kept, removed from the old lines or added from the new
+type diffOp struct {
+	kind     byte // ' ', '-' or '+'
+	old, new int  // Indexes of the line in the old and new lines; -1 when absent
+}
+
+// UnifiedDiff renders the edit as a unified diff of its file, as diff -u would show it
+// It is "" when the edit changes nothing.
+func (e Edit) UnifiedDiff() string {
+	ops := diffLines(e.OldLines, e.NewLines)
+	var b strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk: changes closer than twice
+		// the context share one
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContext {
+				break
+			}
+		}
+		from, to := max(start, first-diffContext), min(len(ops), last+diffContext+1)
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", e.FilePath, e.FilePath)
+		}
+		// Lines before the hunk on each side, then lines in it
+		oldStart, newStart := sideCounts(ops[:from])
+		oldCount, newCount := sideCounts(ops[from:to])
+		offset := e.StartLine
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldStart+offset, oldCount), hunkRange(newStart+offset, newCount))
+		for _, op := range ops[from:to] {
+			line := ""
+			if op.kind == '+' {
+				line = e.NewLines[op.new]
+			} else {
+				line = e.OldLines[op.old]
+			}
+			b.WriteByte(op.kind)
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+		start = to
+	}
+	return b.String()
+}
+
+// sideCounts returns how many of the ops are old lines and how many are new ones
+func sideCounts(ops []diffOp) (old, new int) {
+	for _, op := range ops {
+		if op.kind != '+' {
+			old++
+		}
+		if op.kind != '-' {
+			new++
+		}
+	}
+	return old, new
+}
+
+// hunkRange renders the start and length of one side of a hunk
+// An empty side starts at the line before it, as diff -u writes it.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// diffLines lines up old and new lines with a patience diff: lines that appear once
+// in both anchor the alignment, and the stretches between anchors are diffed the same way
+// It stays fast on large files, where most lines are unique.
+func diffLines(old, new []string) []diffOp {
+	var ops []diffOp
+	var diff func(oldFrom, oldTo, newFrom, newTo int)
+	diff = func(oldFrom, oldTo, newFrom, newTo int) {
+		// Common lines at the start and end need no anchors
+		for oldFrom < oldTo && newFrom < newTo && old[oldFrom] == new[newFrom] {
+			ops = append(ops, diffOp{' ', oldFrom, newFrom})
+			oldFrom++
+			newFrom++
+		}
+		var tail []diffOp
+		for oldFrom < oldTo && newFrom < newTo && old[oldTo-1] == new[newTo-1] {
+			oldTo--
+			newTo--
+			tail = append(tail, diffOp{' ', oldTo, newTo})
+		}
+		defer func() {
+			for i := len(tail) - 1; i >= 0; i-- {
+				ops = append(ops, tail[i])
+			}
+		}()
+
+		anchors := uniqueCommon(old[oldFrom:oldTo], new[newFrom:newTo])
+		if len(anchors) == 0 {
+			for i := oldFrom; i < oldTo; i++ {
+				ops = append(ops, diffOp{'-', i, -1})
+			}
+			for i := newFrom; i < newTo; i++ {
+				ops = append(ops, diffOp{'+', -1, i})
+			}
+			return
+		}
+		oldBase, newBase := oldFrom, newFrom
+		for _, anchor := range anchors {
+			oldAt, newAt := oldBase+anchor[0], newBase+anchor[1]
+			diff(oldFrom, oldAt, newFrom, newAt)
+			ops = append(ops, diffOp{' ', oldAt, newAt})
+			oldFrom, newFrom = oldAt+1, newAt+1
+		}
+		diff(oldFrom, oldTo, newFrom, newTo)
+	}
+	diff(0, len(old), 0, len(new))
+	return ops
+}
+
+// uniqueCommon returns the pairs of indexes of lines that appear exactly once in both
+// old and new, keeping the longest run of them in the same order in both
+func uniqueCommon(old, new []string) [][2]int {
+	count := make(map[string]int)
+	for _, line := range old {
+		count[line]++
+	}
+	inNew := make(map[string]int)
+	for i, line := range new {
+		if count[line] != 1 {
+			continue
+		}
+		if _, ok := inNew[line]; ok {
+			inNew[line] = -1
+			continue
+		}
+		inNew[line] = i
+	}
+	var pairs [][2]int
+	for i, line := range old {
+		if j, ok := inNew[line]; ok && j >= 0 && count[line] == 1 {
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+
+	// Longest increasing run of new indexes by patience sorting
+	var piles []int                 // Index in pairs of the top of each pile
+	prev := make([]int, len(pairs)) // Top of the previous pile when each pair was placed
+	for k, pair := range pairs {
+		pile := sort.Search(len(piles), func(p int) bool { return pairs[piles[p]][1] >= pair[1] })
+		prev[k] = -1
+		if pile > 0 {
+			prev[k] = piles[pile-1]
+		}
+		if pile == len(piles) {
+			piles = append(piles, k)
+		} else {
+			piles[pile] = k
+		}
+	}
+	if len(piles) == 0 {
+		return nil
+	}
+	run := make([][2]int, len(piles))
+	for k, i := piles[len(piles)-1], len(piles)-1; k >= 0; k, i = prev[k], i-1 {
+		run[i] = pairs[k]
+	}
+	return run
+}
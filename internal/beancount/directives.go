@@ -0,0 +1,196 @@
+package beancount
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Regular expressions for non-transaction directives
+var (
+	// Open directive: DATE open ACCOUNT [COMMODITY,...] ["BOOKING"]
+	openRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+open\s+([A-Z][A-Za-z0-9:_-]*)\s*([A-Z0-9._',\s-]*)`)
+
+	// Close directive: DATE close ACCOUNT
+	closeRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+close\s+([A-Z][A-Za-z0-9:_-]*)`)
+
+	// Price directive: DATE price COMMODITY NUMBER CURRENCY
+	priceRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+price\s+([A-Z][A-Z0-9._'-]{0,22}[A-Z0-9])\s+(.*)$`)
+
+	// Balance directive: DATE balance ACCOUNT NUMBER [~ TOLERANCE] COMMODITY
+	balanceRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+balance\s+([A-Z][A-Za-z0-9:_-]*)\s+([^~;]*?)\s*(?:~\s*(\S+)\s+)?([A-Z][A-Z0-9._'-]*)\s*(?:;.*)?$`)
+
+	// Event directive: DATE event "TYPE" "VALUE"
+	eventRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+event\s+"([^"]*)"\s+"([^"]*)"\s*(?:;.*)?$`)
+
+	// Custom directive: DATE custom "TYPE" VALUE...
+	customRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+custom\s+"([^"]*)"(.*)$`)
+)
+
+// parseOpenLine parses an open directive, returning nil if the line is not one
+func parseOpenLine(line string, lineNumber int) *OpenAccount {
+	matches := openRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", matches[1])
+	if err != nil {
+		return nil
+	}
+
+	var commodities []string
+	for _, c := range strings.Split(matches[3], ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			commodities = append(commodities, c)
+		}
+	}
+
+	return &OpenAccount{
+		Date:        date,
+		Account:     matches[2],
+		Commodities: commodities,
+		LineNumber:  lineNumber,
+	}
+}
+
+// parseCloseLine parses a close directive, returning nil if the line is not one
+func parseCloseLine(line string, lineNumber int) *CloseAccount {
+	matches := closeRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", matches[1])
+	if err != nil {
+		return nil
+	}
+
+	return &CloseAccount{
+		Date:       date,
+		Account:    matches[2],
+		LineNumber: lineNumber,
+	}
+}
+
+// parsePriceLine parses a price directive, returning nil if the line is not one
+func parsePriceLine(line string, lineNumber int) *Price {
+	matches := priceRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", matches[1])
+	if err != nil {
+		return nil
+	}
+	amount, _, err := parseAmount(strings.TrimSpace(matches[3]))
+	if err != nil {
+		return nil
+	}
+
+	return &Price{
+		Date:       date,
+		Commodity:  matches[2],
+		Amount:     *amount,
+		LineNumber: lineNumber,
+	}
+}
+
+// parseBalanceLine parses a balance assertion, returning nil if the line is not one
+func parseBalanceLine(line string, lineNumber int) *Balance {
+	matches := balanceRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", matches[1])
+	if err != nil {
+		return nil
+	}
+	amount, _, err := parseAmount(matches[3] + " " + matches[5])
+	if err != nil {
+		return nil
+	}
+	balance := &Balance{
+		Date:       date,
+		Account:    matches[2],
+		Amount:     *amount,
+		LineNumber: lineNumber,
+	}
+	if matches[4] != "" {
+		tolerance, err := decimal.NewFromString(matches[4])
+		if err != nil {
+			return nil
+		}
+		balance.Tolerance = &tolerance
+	}
+	return balance
+}
+
+// parseEventLine parses an event directive, returning nil if the line is not one
+func parseEventLine(line string, lineNumber int) *Event {
+	matches := eventRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", matches[1])
+	if err != nil {
+		return nil
+	}
+
+	return &Event{
+		Date:       date,
+		Type:       matches[2],
+		Value:      matches[3],
+		LineNumber: lineNumber,
+	}
+}
+
+// parseCustomLine parses a custom directive, returning nil if the line is not one
+// Values are split on whitespace, except inside double quotes; a trailing ; comment is dropped
+func parseCustomLine(line string, lineNumber int) *Custom {
+	matches := customRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil
+	}
+
+	date, err := time.Parse("2006-01-02", matches[1])
+	if err != nil {
+		return nil
+	}
+
+	custom := &Custom{
+		Date:       date,
+		Type:       matches[2],
+		LineNumber: lineNumber,
+	}
+
+	rest := matches[3]
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" || rest[0] == ';' {
+			break
+		}
+		if rest[0] == '"' {
+			value, tail, found := strings.Cut(rest[1:], `"`)
+			if !found {
+				return nil
+			}
+			custom.Values = append(custom.Values, value)
+			rest = tail
+			continue
+		}
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		custom.Values = append(custom.Values, rest[:end])
+		rest = rest[end:]
+	}
+
+	return custom
+}
@@ -0,0 +1,134 @@
+package beancount
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseOpenLine(t *testing.T) {
+	open := parseOpenLine("2025-01-01 open Assets:Checking USD,EUR", 3)
+	if open == nil {
+		t.Fatal("expected open directive, got nil")
+	}
+	if open.Account != "Assets:Checking" {
+		t.Errorf("expected account 'Assets:Checking', got '%s'", open.Account)
+	}
+	if len(open.Commodities) != 2 || open.Commodities[0] != "USD" || open.Commodities[1] != "EUR" {
+		t.Errorf("unexpected commodities: %v", open.Commodities)
+	}
+	if open.LineNumber != 3 {
+		t.Errorf("expected line 3, got %d", open.LineNumber)
+	}
+
+	if parseOpenLine(`2025-01-01 * "Payee" "Narration"`, 1) != nil {
+		t.Error("expected nil for transaction line")
+	}
+}
+
+func TestParseCloseLine(t *testing.T) {
+	closeDirective := parseCloseLine("2025-06-30 close Assets:OldBank", 7)
+	if closeDirective == nil {
+		t.Fatal("expected close directive, got nil")
+	}
+	if closeDirective.Account != "Assets:OldBank" {
+		t.Errorf("expected account 'Assets:OldBank', got '%s'", closeDirective.Account)
+	}
+}
+
+func TestParseCustomLine(t *testing.T) {
+	custom := parseCustomLine(`2024-01-01 custom "budget" Expenses:Food "monthly" 400.00 USD ; groceries`, 5)
+	if custom == nil {
+		t.Fatal("expected custom directive, got nil")
+	}
+	if custom.Type != "budget" || custom.LineNumber != 5 {
+		t.Errorf("unexpected custom directive: %+v", custom)
+	}
+	want := []string{"Expenses:Food", "monthly", "400.00", "USD"}
+	if strings.Join(custom.Values, "|") != strings.Join(want, "|") {
+		t.Errorf("expected values %v, got %v", want, custom.Values)
+	}
+
+	if parseCustomLine(`2024-01-01 custom "budget" "unterminated`, 1) != nil {
+		t.Error("expected nil for unterminated string")
+	}
+	if parseCustomLine("2024-01-01 open Assets:Checking", 1) != nil {
+		t.Error("expected nil for open directive")
+	}
+}
+
+func TestParseEventLine(t *testing.T) {
+	event := parseEventLine(`2024-03-01 event "location" "Tokyo, Japan" ; landed`, 9)
+	if event == nil {
+		t.Fatal("expected event directive, got nil")
+	}
+	if event.Type != "location" || event.Value != "Tokyo, Japan" || event.LineNumber != 9 || event.Date.Format("2006-01-02") != "2024-03-01" {
+		t.Errorf("unexpected event directive: %+v", event)
+	}
+
+	if parseEventLine(`2024-03-01 event "location"`, 1) != nil {
+		t.Error("expected nil for an event without a value")
+	}
+	if parseEventLine(`2024-03-01 custom "location" "Tokyo"`, 1) != nil {
+		t.Error("expected nil for custom directive")
+	}
+}
+
+func TestDeclaredAccounts(t *testing.T) {
+	content := `2025-01-01 open Assets:Checking USD
+2025-01-01 open Expenses:Food
+
+2025-01-02 * "Store" "Groceries"
+  Assets:Checking  -10.00 USD
+  Expenses:Food  10.00 USD
+  Expenses:Undeclared  0.00 USD
+
+2025-02-01 close Assets:Checking
+`
+
+	tmpFile, err := createTempFile(content)
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	f, err := Open(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	declared := f.DeclaredAccounts()
+	if len(declared) != 2 {
+		t.Fatalf("expected 2 declared accounts, got %v", declared)
+	}
+	if len(f.GetCloseDirectives()) != 1 {
+		t.Errorf("expected 1 close directive, got %d", len(f.GetCloseDirectives()))
+	}
+	if f.TransactionCount() != 1 {
+		t.Errorf("expected 1 transaction, got %d", f.TransactionCount())
+	}
+}
+
+func TestDeclaredAccountsFallback(t *testing.T) {
+	content := `2025-01-02 * "Store" "Groceries"
+  Assets:Checking  -10.00 USD
+  Expenses:Food  10.00 USD
+`
+
+	tmpFile, err := createTempFile(content)
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	f, err := Open(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	if len(f.DeclaredAccounts()) != 2 {
+		t.Errorf("expected fallback to referenced accounts, got %v", f.DeclaredAccounts())
+	}
+}
@@ -0,0 +1,207 @@
+package beancount
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Balances returns the balance assertions of the ledger and its includes, in file order
+func (f *File) Balances() ([]Balance, error) {
+	var balances []Balance
+	for _, path := range f.index.files {
+		lines, err := f.store.readLedgerLines(path)
+		if err != nil {
+			return nil, err
+		}
+		for i, line := range lines {
+			if balance := parseBalanceLine(line, i+1); balance != nil {
+				balances = append(balances, *balance)
+			}
+		}
+	}
+	return balances, nil
+}
+
+// Events returns the event directives of the ledger and its includes, in file order
+func (f *File) Events() ([]Event, error) {
+	var events []Event
+	for _, path := range f.index.files {
+		lines, err := f.store.readLedgerLines(path)
+		if err != nil {
+			return nil, err
+		}
+		for i, line := range lines {
+			if event := parseEventLine(line, i+1); event != nil {
+				events = append(events, *event)
+			}
+		}
+	}
+	return events, nil
+}
+
+// Options returns the option and plugin lines of the ledger and its includes as
+// written, e.g. option "operating_currency" "USD"
+func (f *File) Options() ([]string, error) {
+	var options []string
+	for _, path := range f.index.files {
+		lines, err := f.store.readLedgerLines(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			if strings.HasPrefix(line, "option ") || strings.HasPrefix(line, "plugin ") {
+				options = append(options, strings.TrimSpace(line))
+			}
+		}
+	}
+	return options, nil
+}
+
+// Directives returns every directive lima reads from the ledger and its includes:
+// opens, closes, balance assertions, prices, custom directives and transactions, in
+// the order beancount sorts them
+func (f *File) Directives() ([]Directive, error) {
+	transactions, err := f.AllTransactions()
+	if err != nil {
+		return nil, err
+	}
+	balances, err := f.Balances()
+	if err != nil {
+		return nil, err
+	}
+
+	var directives []Directive
+	for _, open := range f.index.opens {
+		directives = append(directives, open)
+	}
+	for _, balance := range balances {
+		directives = append(directives, balance)
+	}
+	for _, i := range f.index.byDate {
+		directives = append(directives, transactions[i])
+	}
+	for _, price := range f.index.prices {
+		directives = append(directives, price)
+	}
+	for _, custom := range f.index.customs {
+		directives = append(directives, custom)
+	}
+	for _, c := range f.index.closes {
+		directives = append(directives, c)
+	}
+	SortDirectives(directives)
+	return directives, nil
+}
+
+// SortDirectives orders directives by date as beancount does: on the same day opens
+// come first, then balance assertions, which hold at the start of the day, and closes
+// last; others keep their order
+func SortDirectives(directives []Directive) {
+	rank := func(d Directive) int {
+		switch d.GetType() {
+		case DirectiveTypeOpen:
+			return -2
+		case DirectiveTypeBalance:
+			return -1
+		case DirectiveTypeClose:
+			return 2
+		}
+		return 0
+	}
+	sort.SliceStable(directives, func(i, j int) bool {
+		a, b := directives[i], directives[j]
+		if !a.GetDate().Equal(b.GetDate()) {
+			return a.GetDate().Before(b.GetDate())
+		}
+		return rank(a) < rank(b)
+	})
+}
+
+// FormatDirectives renders directives as one beancount file, a blank line between
+// entries
+func FormatDirectives(directives []Directive) ([]string, error) {
+	var lines []string
+	for i, directive := range directives {
+		entry, err := formatDirective(directive)
+		if err != nil {
+			return nil, fmt.Errorf("%s directive of %s: %w", directive.GetType(), directive.GetDate().Format("2006-01-02"), err)
+		}
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, entry...)
+	}
+	return lines, nil
+}
+
+// formatDirective renders a directive as ledger lines
+func formatDirective(directive Directive) ([]string, error) {
+	var line string
+	var metadata map[string]string
+	switch d := directive.(type) {
+	case *Transaction:
+		return FormatTransaction(d)
+	case OpenAccount:
+		line = dated(d.Date, "open", d.Account)
+		if len(d.Commodities) > 0 {
+			line += " " + strings.Join(d.Commodities, ",")
+		}
+		metadata = d.Metadata
+	case CloseAccount:
+		line, metadata = dated(d.Date, "close", d.Account), d.Metadata
+	case Balance:
+		amount := exactNumber(d.Amount)
+		if d.Tolerance != nil {
+			amount = strings.Replace(amount, " ", " ~ "+d.Tolerance.String()+" ", 1)
+		}
+		line, metadata = dated(d.Date, "balance", d.Account+" "+amount), d.Metadata
+	case Price:
+		formatted, err := FormatPrice(d)
+		if err != nil {
+			return nil, err
+		}
+		line, metadata = formatted, d.Metadata
+	case Custom:
+		values := make([]string, len(d.Values))
+		for i, value := range d.Values {
+			values[i] = customValue(value)
+		}
+		line = dated(d.Date, "custom", strings.TrimSpace(fmt.Sprintf("%q %s", d.Type, strings.Join(values, " "))))
+		metadata = d.Metadata
+	default:
+		return nil, fmt.Errorf("unsupported directive")
+	}
+	return append([]string{line}, formatMetadata(metadata, "  ")...), nil
+}
+
+// dated renders a directive's first line
+func dated(date time.Time, keyword, rest string) string {
+	return date.Format("2006-01-02") + " " + keyword + " " + rest
+}
+
+// exactNumber renders an amount with the decimal places it was written with, which
+// set a balance assertion's tolerance
+func exactNumber(amount Amount) string {
+	return amount.Number.StringFixed(max(0, -amount.Number.Exponent())) + " " + amount.Commodity
+}
+
+// customNumberRegex matches a number in a custom directive
+var customNumberRegex = regexp.MustCompile(`^-?\d[\d,]*(\.\d*)?$`)
+
+// customValue renders a custom directive's value as written: accounts, numbers,
+// commodities, dates and booleans bare, anything else as a string
+func customValue(value string) string {
+	if IsValidAccount(value) || IsValidCommodity(value) || value == "TRUE" || value == "FALSE" {
+		return value
+	}
+	if customNumberRegex.MatchString(value) {
+		return value
+	}
+	if _, err := time.Parse("2006-01-02", value); err == nil {
+		return value
+	}
+	return fmt.Sprintf("%q", value)
+}
@@ -0,0 +1,109 @@
+package beancount
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatDirectives(t *testing.T) {
+	dir := t.TempDir()
+	included := `2024-01-10 * "Broker" "Buy"
+  Assets:Brokerage  2 VTI {100.00 USD}
+  Assets:Checking
+`
+	content := `option "title" "Test"
+include "more.beancount"
+
+2024-02-01 close Assets:Brokerage
+2024-01-01 open Assets:Checking USD
+2024-01-01 open Assets:Brokerage
+2024-01-01 open Expenses:Food
+
+2024-01-05 * "Grocer" "Groceries" #food
+  source: "bank"
+  Expenses:Food  10 USD
+  Assets:Checking
+
+2024-01-05 balance Assets:Checking 0 USD
+2024-01-31 balance Assets:Checking -210.00 ~ 0.01 USD
+2024-01-31 price VTI 110.50 USD
+2024-01-01 custom "budget" Expenses:Food "monthly" 400.00 USD
+`
+	if err := os.WriteFile(filepath.Join(dir, "more.beancount"), []byte(included), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "main.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	options, err := f.Options()
+	if err != nil || len(options) != 1 || options[0] != `option "title" "Test"` {
+		t.Errorf("expected the title option, got %v (%v)", options, err)
+	}
+	directives, err := f.Directives()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines, err := FormatDirectives(directives)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var firsts []string
+	for i, line := range lines {
+		if line != "" && (i == 0 || lines[i-1] == "") {
+			firsts = append(firsts, line)
+		}
+	}
+	want := []string{
+		"2024-01-01 open Assets:Checking USD",
+		"2024-01-01 open Assets:Brokerage",
+		"2024-01-01 open Expenses:Food",
+		`2024-01-01 custom "budget" Expenses:Food "monthly" 400.00 USD`,
+		"2024-01-05 balance Assets:Checking 0 USD",
+		`2024-01-05 * "Grocer" "Groceries" #food`,
+		`2024-01-10 * "Broker" "Buy"`,
+		"2024-01-31 balance Assets:Checking -210.00 ~ 0.01 USD",
+		"2024-01-31 price VTI 110.50 USD",
+		"2024-02-01 close Assets:Brokerage",
+	}
+	if strings.Join(firsts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected entries:\n%s\nwant:\n%s", strings.Join(firsts, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The export reads back to the same ledger, includes and all, without problems
+	exported := filepath.Join(dir, "exported.beancount")
+	if err := os.WriteFile(exported, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := Open(exported)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer g.Close()
+	again, err := g.Directives()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	relines, err := FormatDirectives(again)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(relines, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("export changed when read back:\n%s\nwas:\n%s", strings.Join(relines, "\n"), strings.Join(lines, "\n"))
+	}
+	problems, err := g.Check()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("unexpected problems in the export: %+v", problems)
+	}
+}
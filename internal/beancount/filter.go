@@ -0,0 +1,297 @@
+package beancount
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// Filter selects transactions using a small query language
+// Every term must match (terms are ANDed):
+//
+//	coffee            payee or narration contains "coffee" (case-insensitive)
+//	"blue bottle"     quoted phrase
+//	payee:starbucks   payee contains
+//	narration:latte   narration contains
+//	account:food      any posting account contains
+//	tag:travel        transaction has the tag (also #travel); tag:* has any tag
+//	link:invoice-42   transaction has the link (also ^invoice-42)
+//	amount>50         largest posting amount compared with >, >=, <, <= or =
+//	date:2024-03      date within a year, month or day; ranges use 2024-01..2024-03
+//	-term             negates any term
+type Filter struct {
+	Query string
+	terms []filterTerm
+}
+
+// filterTerm is one parsed condition of a filter
+type filterTerm struct {
+	negate bool
+	match  func(tx *Transaction) bool
+}
+
+// ParseFilter parses a filter query; an empty query matches every transaction
+func ParseFilter(query string) (*Filter, error) {
+	f := &Filter{Query: query}
+	for _, token := range tokenizeFilter(query) {
+		term, err := parseFilterTerm(token)
+		if err != nil {
+			return nil, err
+		}
+		f.terms = append(f.terms, term)
+	}
+	return f, nil
+}
+
+// Empty reports whether the filter has no terms
+func (f *Filter) Empty() bool {
+	return f == nil || len(f.terms) == 0
+}
+
+// Match reports whether a transaction satisfies every term
+func (f *Filter) Match(tx *Transaction) bool {
+	if f == nil {
+		return true
+	}
+	for _, term := range f.terms {
+		if term.match(tx) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenizeFilter splits a query on whitespace, keeping quoted phrases together
+func tokenizeFilter(query string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// parseFilterTerm parses a single token into a term
+func parseFilterTerm(token string) (filterTerm, error) {
+	var term filterTerm
+	if strings.HasPrefix(token, "-") && len(token) > 1 {
+		term.negate = true
+		token = token[1:]
+	}
+
+	if strings.HasPrefix(token, "#") && len(token) > 1 {
+		term.match = tagMatcher(token[1:])
+		return term, nil
+	}
+	if strings.HasPrefix(token, "^") && len(token) > 1 {
+		term.match = linkMatcher(token[1:])
+		return term, nil
+	}
+
+	if rest := strings.ToLower(token); strings.HasPrefix(rest, "amount") && len(rest) > len("amount") && strings.ContainsRune("<>=:", rune(rest[len("amount")])) {
+		match, err := amountMatcher(token[len("amount"):])
+		if err != nil {
+			return term, err
+		}
+		term.match = match
+		return term, nil
+	}
+
+	field, value, found := strings.Cut(token, ":")
+	if !found || value == "" {
+		term.match = textMatcher(token)
+		return term, nil
+	}
+
+	switch strings.ToLower(field) {
+	case "payee":
+		needle := strings.ToLower(value)
+		term.match = func(tx *Transaction) bool {
+			return strings.Contains(strings.ToLower(tx.Payee), needle)
+		}
+	case "narration":
+		needle := strings.ToLower(value)
+		term.match = func(tx *Transaction) bool {
+			return strings.Contains(strings.ToLower(tx.Narration), needle)
+		}
+	case "account":
+		needle := strings.ToLower(value)
+		term.match = func(tx *Transaction) bool {
+			for _, posting := range tx.Postings {
+				if strings.Contains(strings.ToLower(posting.Account), needle) {
+					return true
+				}
+			}
+			return false
+		}
+	case "tag":
+		term.match = tagMatcher(value)
+	case "link":
+		term.match = linkMatcher(value)
+	case "date":
+		start, end, err := ParseDateRange(value)
+		if err != nil {
+			return term, err
+		}
+		term.match = func(tx *Transaction) bool {
+			return !tx.Date.Before(start) && tx.Date.Before(end)
+		}
+	default:
+		// Not a known field (e.g., "Expenses:Food"), so search text instead
+		term.match = textMatcher(token)
+	}
+	return term, nil
+}
+
+// textMatcher matches a case-insensitive substring of the payee or narration
+func textMatcher(text string) func(tx *Transaction) bool {
+	needle := strings.ToLower(text)
+	return func(tx *Transaction) bool {
+		return strings.Contains(strings.ToLower(tx.Payee), needle) ||
+			strings.Contains(strings.ToLower(tx.Narration), needle)
+	}
+}
+
+// tagMatcher matches transactions carrying a tag
+func tagMatcher(tag string) func(tx *Transaction) bool {
+	tag = strings.TrimPrefix(tag, "#")
+	if tag == "*" {
+		return func(tx *Transaction) bool {
+			return len(tx.Tags) > 0
+		}
+	}
+	return func(tx *Transaction) bool {
+		for _, t := range tx.Tags {
+			if strings.EqualFold(t, tag) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// linkMatcher matches transactions carrying a link
+func linkMatcher(link string) func(tx *Transaction) bool {
+	link = strings.TrimPrefix(link, "^")
+	return func(tx *Transaction) bool {
+		for _, l := range tx.Links {
+			if strings.EqualFold(l, link) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// amountMatcher compares the transaction's largest absolute posting amount
+// expr is the operator and number following "amount", e.g. ">=100"
+func amountMatcher(expr string) (func(tx *Transaction) bool, error) {
+	var op string
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", ":"} {
+		if strings.HasPrefix(expr, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("invalid amount filter: amount%s (use amount>N, amount<=N, ...)", expr)
+	}
+
+	value, err := decimal.NewFromString(strings.TrimSpace(expr[len(op):]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount in filter: %s", expr[len(op):])
+	}
+
+	return func(tx *Transaction) bool {
+		amount := LargestAmount(tx)
+		switch op {
+		case ">":
+			return amount.GreaterThan(value)
+		case ">=":
+			return amount.GreaterThanOrEqual(value)
+		case "<":
+			return amount.LessThan(value)
+		case "<=":
+			return amount.LessThanOrEqual(value)
+		default:
+			return amount.Equal(value)
+		}
+	}, nil
+}
+
+// LargestAmount returns the largest absolute posting amount of a transaction
+func LargestAmount(tx *Transaction) decimal.Decimal {
+	largest := decimal.Zero
+	for _, posting := range tx.Postings {
+		if posting.Amount == nil {
+			continue
+		}
+		if abs := posting.Amount.Number.Abs(); abs.GreaterThan(largest) {
+			largest = abs
+		}
+	}
+	return largest
+}
+
+// ParseDateRange parses a year, month or day ("2024", "2024-03", "2024-03-15"),
+// or a range of them ("2024-01..2024-03"), into a half-open interval [start, end)
+func ParseDateRange(s string) (time.Time, time.Time, error) {
+	from, to, isRange := strings.Cut(s, "..")
+	if !isRange {
+		to = from
+	}
+
+	start, _, err := parsePeriod(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	_, end, err := parsePeriod(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("empty date range: %s", s)
+	}
+	return start, end, nil
+}
+
+// parsePeriod parses a year, month or day into its [start, end) interval
+func parsePeriod(s string) (time.Time, time.Time, error) {
+	layouts := []struct {
+		layout string
+		years  int
+		months int
+		days   int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	}
+	for _, l := range layouts {
+		if len(s) != len(l.layout) {
+			continue
+		}
+		if start, err := time.Parse(l.layout, s); err == nil {
+			return start, start.AddDate(l.years, l.months, l.days), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid date: %s (use YYYY, YYYY-MM or YYYY-MM-DD)", s)
+}
@@ -0,0 +1,93 @@
+package beancount
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func filterTestTransactions() []*Transaction {
+	tx := func(date, payee, narration, account, amount string, tags ...string) *Transaction {
+		d, _ := time.Parse("2006-01-02", date)
+		return &Transaction{
+			Date:      d,
+			Payee:     payee,
+			Narration: narration,
+			Tags:      tags,
+			Postings: []Posting{
+				{Account: "Assets:Checking", Amount: &Amount{Number: decimal.RequireFromString("-" + amount), Commodity: "USD"}},
+				{Account: account, Amount: &Amount{Number: decimal.RequireFromString(amount), Commodity: "USD"}},
+			},
+		}
+	}
+	transactions := []*Transaction{
+		tx("2024-03-02", "Blue Bottle", "Latte", "Expenses:Food:Coffee", "5.50"),
+		tx("2024-03-20", "Whole Foods", "Groceries", "Expenses:Food:Groceries", "82.10"),
+		tx("2024-04-01", "Delta", "Flight to NYC", "Expenses:Travel:Air", "420.00", "travel"),
+		tx("2023-12-24", "Blue Bottle", "Beans", "Expenses:Food:Coffee", "18.00", "gift"),
+	}
+	transactions[0].Links = []string{"receipt-7"}
+	transactions[1].Links = []string{"receipt-7", "invoice-42"}
+	return transactions
+}
+
+func TestFilter(t *testing.T) {
+	transactions := filterTestTransactions()
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 4},
+		{"blue", 2},
+		{"\"blue bottle\"", 2},
+		{"payee:delta", 1},
+		{"narration:latte", 1},
+		{"account:food", 3},
+		{"account:food -account:coffee", 1},
+		{"tag:travel", 1},
+		{"#gift", 1},
+		{"tag:*", 2},
+		{"-tag:*", 2},
+		{"tag:travel tag:gift", 0},
+		{"link:receipt-7", 2},
+		{"^invoice-42", 1},
+		{"-link:receipt-7", 2},
+		{"amount>50", 2},
+		{"amount<=18", 2},
+		{"amount=5.50", 1},
+		{"date:2024-03", 2},
+		{"date:2024", 3},
+		{"date:2024-03-20", 1},
+		{"date:2023-12..2024-03", 3},
+		{"blue date:2024", 1},
+		{"amounts", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			f, err := ParseFilter(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := 0
+			for _, tx := range transactions {
+				if f.Match(tx) {
+					got++
+				}
+			}
+			if got != tt.want {
+				t.Errorf("expected %d matches, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, query := range []string{"amount>abc", "date:2024-13", "date:2024-03..2024-01"} {
+		if _, err := ParseFilter(query); err == nil {
+			t.Errorf("expected error for %q", query)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package beancount
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// FormatOptions chooses the optional rewrites of Format
+type FormatOptions struct {
+	// SortByDate orders dated entries by date, keeping entries of the same date in
+	// file order. Comments and undated lines such as options and includes stay where
+	// they are; entries only move between them.
+	SortByDate bool
+}
+
+// Format builds the edits that reformat the ledger and its includes, one for each file
+// that changes: postings are indented by two spaces and their metadata by four, amounts
+// are aligned in one column per file and trailing whitespace is removed
+func (w *Writer) Format(opts FormatOptions) ([]Edit, error) {
+	var edits []Edit
+	for _, path := range w.file.index.files {
+		lines, err := w.file.store.readLines(path)
+		if err != nil {
+			return nil, err
+		}
+		formatted := formatLines(lines, opts)
+		if !slices.Equal(lines, formatted) {
+			edits = append(edits, Edit{FilePath: path, StartLine: 1, OldLines: lines, NewLines: formatted})
+		}
+	}
+	return edits, nil
+}
+
+// formattedPosting is a posting line split for alignment
+type formattedPosting struct {
+	line    int    // Index of the line
+	account string // Account, or the whole line after the indent when unreadable
+	number  string // Number as written, "" without an amount
+	rest    string // Commodity and anything after it, or after the account without a number
+}
+
+// formatLines reformats the lines of one file
+func formatLines(lines []string, opts FormatOptions) []string {
+	result := make([]string, len(lines))
+	var postings []formattedPosting
+
+	inEntry := false       // Whether indented lines belong to a dated entry
+	inTransaction := false // Whether that entry is a transaction
+	postingWidth := -1     // Indent of the posting above, -1 before the first
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		result[i] = line
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		width := len(line) - len(trimmed)
+		if width == 0 {
+			inEntry = datedLineRegex.MatchString(line)
+			inTransaction = inEntry && transactionHeaderRegex.MatchString(line)
+			postingWidth = -1
+			continue
+		}
+		if !inEntry {
+			continue
+		}
+
+		switch {
+		case inTransaction && postingRegex.MatchString(line):
+			match := postingRegex.FindStringSubmatch(line)
+			posting := formattedPosting{line: i, account: match[1], rest: match[2]}
+			if amount := amountRegex.FindStringSubmatch(match[2]); amount != nil {
+				posting.number = amount[1]
+				posting.rest = amount[2] + match[2][len(amount[0]):]
+			}
+			postings = append(postings, posting)
+			postingWidth = width
+
+		case metadataRegex.MatchString(line) || strings.HasPrefix(trimmed, ";"):
+			// Metadata and comments indented past a posting belong to it
+			if postingWidth >= 0 && width > postingWidth {
+				result[i] = "    " + trimmed
+			} else {
+				result[i] = "  " + trimmed
+			}
+		}
+	}
+
+	accountWidth := postingAmountColumn
+	numberWidth := 0
+	for _, posting := range postings {
+		if posting.number != "" {
+			accountWidth = max(accountWidth, len(posting.account))
+			numberWidth = max(numberWidth, len(posting.number))
+		}
+	}
+	numberEnd := 2 + accountWidth + 2 + numberWidth
+	for _, posting := range postings {
+		line := "  " + posting.account
+		switch {
+		case posting.number != "":
+			line += strings.Repeat(" ", numberEnd-len(line)-len(posting.number)) + posting.number + " " + posting.rest
+		case posting.rest != "":
+			line += "  " + posting.rest
+		}
+		result[posting.line] = line
+	}
+
+	// Blank lines at the end of the file go
+	for len(result) > 0 && result[len(result)-1] == "" {
+		result = result[:len(result)-1]
+	}
+	if opts.SortByDate {
+		result = sortEntries(result)
+	}
+	return result
+}
+
+// sortEntries orders each run of dated entries by date
+// A run is broken by any other line that isn't blank. A transaction or other
+// multi-line entry is set apart by one blank line; one-line entries, such as opens
+// and prices, take the blank lines that followed them along when they move.
+func sortEntries(lines []string) []string {
+	result := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		if !datedLineRegex.MatchString(lines[i]) {
+			result = append(result, lines[i])
+			i++
+			continue
+		}
+
+		// The last entry of the run has no gap of its own; the lines after it stay.
+		// Only gaps between one-line entries are kept: those next to a multi-line
+		// entry are put back wherever one ends up.
+		type entry struct {
+			lines []string
+			gap   int
+		}
+		var entries []entry
+		for {
+			end := entryEnd(lines, i)
+			next := end
+			for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+				next++
+			}
+			if next == len(lines) || !datedLineRegex.MatchString(lines[next]) {
+				entries = append(entries, entry{lines: lines[i:end]})
+				i = end
+				break
+			}
+			e := entry{lines: lines[i:end]}
+			if end-i == 1 && entryEnd(lines, next)-next == 1 {
+				e.gap = next - end
+			}
+			entries = append(entries, e)
+			i = next
+		}
+
+		sort.SliceStable(entries, func(a, b int) bool {
+			return datedLineRegex.FindStringSubmatch(entries[a].lines[0])[1] < datedLineRegex.FindStringSubmatch(entries[b].lines[0])[1]
+		})
+		for k, e := range entries {
+			result = append(result, e.lines...)
+			if k == len(entries)-1 {
+				break
+			}
+			gap := e.gap
+			if len(e.lines) > 1 || len(entries[k+1].lines) > 1 {
+				gap = 1
+			}
+			result = append(result, make([]string, gap)...)
+		}
+	}
+	return result
+}
+
+// entryEnd returns the index just after the entry starting at start: its first line and
+// every indented line after it, with blank lines among them
+func entryEnd(lines []string, start int) int {
+	end := start + 1
+	for i := start + 1; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
@@ -0,0 +1,253 @@
+package beancount
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriterFormat(t *testing.T) {
+	dir := t.TempDir()
+	content := "option \"title\" \"Test\"   \n" +
+		"include \"more.beancount\"\n" +
+		"\n" +
+		"2024-02-01 * \"Grocer\" \"Groceries\"\n" +
+		"    note: \"weekly\"\n" +
+		"\tExpenses:Food 40.00 USD ; food\n" +
+		"        receipt: \"r1.pdf\"\n" +
+		"   Assets:Checking\n" +
+		"\n" +
+		"2024-01-15 * \"Broker\" \"Buy\"\n" +
+		"  Assets:Brokerage   2 VTI {100.00 USD}\n" +
+		"  Assets:Checking  -200.00 USD\n" +
+		"2024-01-10 price VTI 99.00 USD\n" +
+		"\n" +
+		"; Comments keep entries from moving past them\n" +
+		"2024-01-01 open Assets:Checking\n" +
+		"\n\n"
+	column := strings.Repeat(" ", 40)
+	included := "2024-03-01 * \"Cafe\" \"Latte\"\n" +
+		"  Expenses:Food" + column[len("Expenses:Food"):] + "   5.00 USD\n" +
+		"  Assets:Checking" + column[len("Assets:Checking"):] + "  -5.00 USD\n"
+	if err := os.WriteFile(filepath.Join(dir, "more.beancount"), []byte(included), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "main.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+	before, err := f.AllTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWriter(f)
+	edits, err := w.Format(FormatOptions{SortByDate: true})
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if len(edits) != 1 || edits[0].FilePath != path {
+		t.Fatalf("expected only the main file reformatted, got %d edits", len(edits))
+	}
+	expected := []string{
+		`option "title" "Test"`,
+		`include "more.beancount"`,
+		"",
+		"2024-01-10 price VTI 99.00 USD",
+		"",
+		`2024-01-15 * "Broker" "Buy"`,
+		"  Assets:Brokerage" + column[len("Assets:Brokerage"):] + "        2 VTI {100.00 USD}",
+		"  Assets:Checking" + column[len("Assets:Checking"):] + "  -200.00 USD",
+		"",
+		`2024-02-01 * "Grocer" "Groceries"`,
+		`  note: "weekly"`,
+		"  Expenses:Food" + column[len("Expenses:Food"):] + "    40.00 USD ; food",
+		`    receipt: "r1.pdf"`,
+		"  Assets:Checking",
+		"",
+		"; Comments keep entries from moving past them",
+		"2024-01-01 open Assets:Checking",
+	}
+	if got := edits[0].NewLines; strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected formatting:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	// Formatting changes the text, never the entries
+	if err := w.ApplyAll(edits); err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	after, err := f.AllTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before) {
+		t.Fatalf("expected %d transactions, got %d", len(before), len(after))
+	}
+	for _, tx := range before {
+		found := false
+		for _, other := range after {
+			if other.Narration == tx.Narration && len(other.Postings) == len(tx.Postings) &&
+				amountsEqual(other.Postings[0].Amount, tx.Postings[0].Amount) && other.Postings[0].Account == tx.Postings[0].Account {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("transaction %q changed by formatting", tx.Narration)
+		}
+	}
+
+	// Formatted files are left alone
+	if edits, err := w.Format(FormatOptions{SortByDate: true}); err != nil || len(edits) != 0 {
+		t.Errorf("expected formatting to be stable, got %d edits, %v", len(edits), err)
+	}
+}
+
+func TestSortEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			// A transaction moving into a block of opens is set apart from them
+			name: "transaction into opens",
+			lines: []string{
+				"2024-01-01 open Assets:Checking",
+				"2024-03-01 open Expenses:Rent",
+				"",
+				`2024-02-01 * "Grocer"`,
+				"  Expenses:Food  40.00 USD",
+				"  Assets:Checking",
+			},
+			want: []string{
+				"2024-01-01 open Assets:Checking",
+				"",
+				`2024-02-01 * "Grocer"`,
+				"  Expenses:Food  40.00 USD",
+				"  Assets:Checking",
+				"",
+				"2024-03-01 open Expenses:Rent",
+			},
+		},
+		{
+			// Opens moving ahead of a transaction close up, with no blank line among them
+			name: "opens out of a transaction",
+			lines: []string{
+				`2024-02-01 * "Grocer"`,
+				"  Expenses:Food  40.00 USD",
+				"  Assets:Checking",
+				"",
+				"2024-01-01 open Assets:Checking",
+				"2024-01-02 open Expenses:Food",
+			},
+			want: []string{
+				"2024-01-01 open Assets:Checking",
+				"2024-01-02 open Expenses:Food",
+				"",
+				`2024-02-01 * "Grocer"`,
+				"  Expenses:Food  40.00 USD",
+				"  Assets:Checking",
+			},
+		},
+		{
+			// Gaps move with the entry they follow
+			name: "gaps move with entries",
+			lines: []string{
+				"2024-01-02 price VTI 100 USD",
+				"",
+				"2024-01-03 price VTI 101 USD",
+				"2024-01-01 price VTI 99 USD",
+				"; end of prices",
+			},
+			want: []string{
+				"2024-01-01 price VTI 99 USD",
+				"2024-01-02 price VTI 100 USD",
+				"",
+				"2024-01-03 price VTI 101 USD",
+				"; end of prices",
+			},
+		},
+		{
+			name: "mixed",
+			lines: []string{
+				`2024-03-01 * "Landlord"`,
+				"  Expenses:Rent  1000 USD",
+				"  Assets:Checking",
+				"",
+				"2024-01-01 open Assets:Checking",
+				"",
+				`2024-02-01 * "Grocer"`,
+				"  Expenses:Food  40.00 USD",
+				"  Assets:Checking",
+				"2024-01-01 open Expenses:Food",
+				"2024-01-01 open Expenses:Rent",
+			},
+			want: []string{
+				"2024-01-01 open Assets:Checking",
+				"2024-01-01 open Expenses:Food",
+				"2024-01-01 open Expenses:Rent",
+				"",
+				`2024-02-01 * "Grocer"`,
+				"  Expenses:Food  40.00 USD",
+				"  Assets:Checking",
+				"",
+				`2024-03-01 * "Landlord"`,
+				"  Expenses:Rent  1000 USD",
+				"  Assets:Checking",
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := sortEntries(tt.lines); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s:\n%s\nwant:\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestEditUnifiedDiff(t *testing.T) {
+	var old []string
+	for i := 1; i <= 20; i++ {
+		old = append(old, "line "+strings.Repeat("x", i))
+	}
+	updated := append([]string(nil), old...)
+	updated[1] = "changed"
+	updated = append(updated[:15], updated[16:]...)
+	updated = append(updated, "appended")
+
+	edit := Edit{FilePath: "ledger.beancount", StartLine: 1, OldLines: old, NewLines: updated}
+	lines := strings.Split(strings.TrimSuffix(edit.UnifiedDiff(), "\n"), "\n")
+	want := []string{
+		"--- ledger.beancount",
+		"+++ ledger.beancount",
+		"@@ -1,5 +1,5 @@",
+		" " + old[0],
+		"-" + old[1],
+		"+changed",
+		" " + old[2],
+		" " + old[3],
+		" " + old[4],
+		"@@ -13,8 +13,8 @@",
+		" " + old[12],
+		" " + old[13],
+		" " + old[14],
+		"-" + old[15],
+		" " + old[16],
+		" " + old[17],
+		" " + old[18],
+		" " + old[19],
+		"+appended",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	if diff := (Edit{FilePath: "x", StartLine: 1, OldLines: old, NewLines: old}).UnifiedDiff(); diff != "" {
+		t.Errorf("expected no diff for an unchanged file, got:\n%s", diff)
+	}
+}
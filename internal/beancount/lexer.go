@@ -151,8 +151,9 @@ func parsePosting(line string, lineNum int) (*Posting, error) {
 	}
 
 	posting := &Posting{
-		Account:  matches[1],
-		Metadata: make(map[string]string),
+		Account:    matches[1],
+		Metadata:   make(map[string]string),
+		LineNumber: lineNum,
 	}
 
 	// Parse amount if present
@@ -161,26 +162,66 @@ func parsePosting(line string, lineNum int) (*Posting, error) {
 		amount, remaining, err := parseAmount(rest)
 		if err == nil {
 			posting.Amount = amount
-
-			// Check for cost or price in remaining text
-			// Format: @ price or @@ total_price or { cost }
-			// For now, we'll handle simple @ price
-			if strings.Contains(remaining, "@") {
-				// Simple price extraction (enhance later)
-				parts := strings.Split(remaining, "@")
-				if len(parts) > 1 {
-					priceAmount, _, err := parseAmount(strings.TrimSpace(parts[1]))
-					if err == nil {
-						posting.Price = priceAmount
-					}
-				}
-			}
+			remaining = parseCost(posting, strings.TrimSpace(remaining))
+			parsePrice(posting, remaining)
 		}
 	}
 
 	return posting, nil
 }
 
+// parseCost reads a cost basis, {N C} per unit or {{N C}} in total, returning the
+// text after it. A total cost is spread over the units; lot dates and labels after
+// the amount are skipped, and an empty or unreadable cost leaves Cost nil.
+func parseCost(posting *Posting, s string) string {
+	if !strings.HasPrefix(s, "{") {
+		return s
+	}
+	closing := "}"
+	if strings.HasPrefix(s, "{{") {
+		closing = "}}"
+	}
+	end := strings.Index(s, closing)
+	if end < 0 {
+		return s
+	}
+	spec, _, _ := strings.Cut(s[len(closing):end], ",")
+	if cost, _, err := parseAmount(strings.TrimSpace(spec)); err == nil {
+		if closing == "}}" {
+			cost.Number = perUnit(cost.Number, posting.Amount.Number)
+		}
+		posting.Cost = cost
+	}
+	return s[end+len(closing):]
+}
+
+// parsePrice reads a price, @ N C per unit or @@ N C in total
+// A total price is spread over the units.
+func parsePrice(posting *Posting, s string) {
+	at := strings.Index(s, "@")
+	if at < 0 {
+		return
+	}
+	total := strings.HasPrefix(s[at:], "@@")
+	text := strings.TrimLeft(s[at:], "@")
+	price, _, err := parseAmount(strings.TrimSpace(text))
+	if err != nil {
+		return
+	}
+	if total {
+		price.Number = perUnit(price.Number, posting.Amount.Number)
+	}
+	posting.Price = price
+}
+
+// perUnit divides a total over a number of units, ignoring their sign
+func perUnit(total, units decimal.Decimal) decimal.Decimal {
+	if units.IsZero() {
+		return total
+	}
+	return total.DivRound(units.Abs(), 16)
+}
+
 // parseAmount parses an amount from a string, returns amount and remaining text
 func parseAmount(s string) (*Amount, string, error) {
 	matches := amountRegex.FindStringSubmatch(s)
@@ -0,0 +1,73 @@
+package beancount
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"time"
+)
+
+// Loader keeps a ledger's transactions read for a server answering requests about it,
+// reading them again once one of the ledger's files is modified
+type Loader struct {
+	file *File
+	// loaded is called with the transactions each time they are read, such as to
+	// train a categorizer on them; nil when nothing else needs them
+	loaded func(transactions []*Transaction)
+
+	stamps       map[string]time.Time
+	transactions []*Transaction
+}
+
+// NewLoader reads the ledger's transactions, passing them to loaded if it isn't nil
+func NewLoader(file *File, loaded func(transactions []*Transaction)) (*Loader, error) {
+	l := &Loader{file: file, loaded: loaded}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Transactions returns the transactions last read
+func (l *Loader) Transactions() []*Transaction {
+	return l.transactions
+}
+
+// Refresh reads the ledger again if one of its files changed since it was read
+func (l *Loader) Refresh() error {
+	if maps.Equal(l.fileStamps(), l.stamps) {
+		return nil
+	}
+	if err := l.file.Reload(); err != nil {
+		return fmt.Errorf("failed to reload ledger: %w", err)
+	}
+	return l.load()
+}
+
+// load reads the ledger's transactions and notes when its files were modified
+func (l *Loader) load() error {
+	transactions, err := l.file.AllTransactions()
+	if err != nil {
+		return err
+	}
+	l.transactions = transactions
+	l.stamps = l.fileStamps()
+	if l.loaded != nil {
+		l.loaded(transactions)
+	}
+	return nil
+}
+
+// fileStamps returns the modification times of the ledger's files; a missing file
+// has the zero time
+func (l *Loader) fileStamps() map[string]time.Time {
+	stamps := make(map[string]time.Time)
+	for _, path := range l.file.Files() {
+		var stamp time.Time
+		if info, err := os.Stat(path); err == nil {
+			stamp = info.ModTime()
+		}
+		stamps[path] = stamp
+	}
+	return stamps
+}
@@ -0,0 +1,48 @@
+package beancount
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoader(t *testing.T) {
+	ledger := filepath.Join(t.TempDir(), "main.beancount")
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write ledger: %v", err)
+		}
+		if err := os.Chtimes(ledger, modTime, modTime); err != nil {
+			t.Fatalf("failed to date ledger: %v", err)
+		}
+	}
+	entry := "2025-01-01 * \"Store\" \"Transaction\"\n  Assets:Checking  -10.00 USD\n  Expenses:Test\n\n"
+	start := time.Now().Add(-time.Hour)
+	write(entry, start)
+
+	f, err := Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+	var loads []int
+	l, err := NewLoader(f, func(transactions []*Transaction) { loads = append(loads, len(transactions)) })
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	// Nothing changed, nothing is read again
+	if err := l.Refresh(); err != nil || len(loads) != 1 || len(l.Transactions()) != 1 {
+		t.Fatalf("expected one load of one transaction, got %v %v", loads, err)
+	}
+
+	write(entry+entry, start.Add(time.Minute))
+	if err := l.Refresh(); err != nil {
+		t.Fatalf("failed to refresh: %v", err)
+	}
+	if len(loads) != 2 || len(l.Transactions()) != 2 {
+		t.Errorf("expected the edit read, got loads %v and %d transactions", loads, len(l.Transactions()))
+	}
+}
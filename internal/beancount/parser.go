@@ -3,24 +3,37 @@ package beancount
 import (
 	"bufio"
 	"fmt"
-	"os"
+	"hash"
+	"hash/fnv"
+	"io"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 )
 
 // File represents an opened Beancount file with lazy loading support
 type File struct {
 	path  string
-	file  *os.File
+	file  ledgerFile
 	index *Index
 	cache *Cache
+	store *Store
 }
 
 // Index stores positions of all directives in the file for lazy loading
 type Index struct {
 	transactions []TransactionIndex
+	byDate       []int // Transaction indexes ordered by date, ties in file order
 	accounts     []string
 	commodities  []string
+	opens        []OpenAccount
+	closes       []CloseAccount
+	prices       []Price
+	customs      []Custom
+	files        []string    // The ledger and its includes, in the order they were scanned
+	content      hash.Hash64 // Every line scanned, includes in place
 }
 
 // TransactionIndex stores metadata about a transaction for quick access
@@ -38,16 +51,61 @@ type Cache struct {
 	maxSize      int
 }
 
-// Open opens a Beancount file and builds an index
+// Progress reports how far building the index has got
+type Progress struct {
+	File         string // File being scanned
+	Files        int    // Files scanned so far, the current one included
+	Lines        int    // Lines scanned in all files
+	Bytes        int64  // Bytes scanned in all files
+	Transactions int    // Transactions indexed
+}
+
+// progressInterval is how many lines are scanned between progress reports
+const progressInterval = 5000
+
+// indexProgress tracks an index build and passes its progress to a callback
+type indexProgress struct {
+	Progress
+	report func(Progress)
+}
+
+// send passes the progress so far to the callback, if any
+func (p *indexProgress) send() {
+	if p.report != nil {
+		p.report(p.Progress)
+	}
+}
+
+// Open opens a Beancount file and builds an index, reading its files as they are
 func Open(path string) (*File, error) {
-	file, err := os.Open(path)
+	return OpenWithProgress(path, nil)
+}
+
+// OpenWithProgress opens a Beancount file like Open, reporting progress while the
+// index is built: as each file starts, every few thousand lines and once at the end
+// report is called on the opening goroutine; nil reports nothing.
+func OpenWithProgress(path string, report func(Progress)) (*File, error) {
+	return NewStore(nil).OpenWithProgress(path, report)
+}
+
+// Open opens a Beancount file like the package's Open, its files read and written
+// through the store
+func (s *Store) Open(path string) (*File, error) {
+	return s.OpenWithProgress(path, nil)
+}
+
+// OpenWithProgress opens a Beancount file like the package's OpenWithProgress, its
+// files read and written through the store
+func (s *Store) OpenWithProgress(path string, report func(Progress)) (*File, error) {
+	file, err := s.open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
 
 	f := &File{
-		path: path,
-		file: file,
+		path:  path,
+		file:  file,
+		store: s,
 		cache: &Cache{
 			transactions: make(map[int]*Transaction),
 			maxSize:      100, // Cache last 100 transactions
@@ -55,10 +113,12 @@ func Open(path string) (*File, error) {
 	}
 
 	// Build index on first open
-	if err := f.buildIndex(); err != nil {
+	index, err := f.buildIndex(&indexProgress{report: report})
+	if err != nil {
 		file.Close()
 		return nil, fmt.Errorf("failed to build index: %w", err)
 	}
+	f.index = index
 
 	return f, nil
 }
@@ -71,6 +131,52 @@ func (f *File) Close() error {
 	return nil
 }
 
+// Store returns the store the ledger's files are read and written through
+func (f *File) Store() *Store {
+	return f.store
+}
+
+// Path returns the path of the root ledger file
+func (f *File) Path() string {
+	return f.path
+}
+
+// Files returns the ledger and the files it includes, in the order they were read
+func (f *File) Files() []string {
+	return slices.Clone(f.index.files)
+}
+
+// Hash returns a digest of the ledger's content as last indexed, its includes in
+// place; it changes whenever the ledger or one of its includes is edited and reloaded
+func (f *File) Hash() string {
+	return fmt.Sprintf("%016x", f.index.content.Sum64())
+}
+
+// Reload rebuilds the index and drops cached transactions
+// Call after the file (or one of its includes) has been modified on disk. The new
+// index replaces the old one, and the transactions cached from it, only once it is
+// complete; on failure the file reads as it did before.
+func (f *File) Reload() error {
+	file, err := f.store.open(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+
+	index, err := f.buildIndex(nil)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to build index: %w", err)
+	}
+
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = file
+	f.index = index
+	f.cache.transactions = make(map[int]*Transaction)
+	return nil
+}
+
 // TransactionCount returns the total number of transactions in the file
 func (f *File) TransactionCount() int {
 	return len(f.index.transactions)
@@ -131,6 +237,44 @@ func (f *File) GetTransactionsByDateRange(start, end time.Time) ([]*Transaction,
 	return transactions, nil
 }
 
+// TransactionsFrom returns the indexes of the transactions dated on or after date,
+// oldest first with same-day transactions in file order. The start is found by
+// binary search over the date-ordered index; the slice must not be modified.
+func (f *File) TransactionsFrom(date time.Time) []int {
+	byDate := f.index.byDate
+	start := sort.Search(len(byDate), func(i int) bool {
+		return !f.index.transactions[byDate[i]].Date.Before(date)
+	})
+	return byDate[start:]
+}
+
+// LatestTransactions returns the n most recent transactions by date, newest first
+// Same-day transactions come in reverse file order, so the last one entered is first.
+// Only the returned transactions are parsed; the rest are ordered by their index entries.
+func (f *File) LatestTransactions(n int) ([]*Transaction, error) {
+	order := make([]int, len(f.index.transactions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		da, db := f.index.transactions[order[a]].Date, f.index.transactions[order[b]].Date
+		if !da.Equal(db) {
+			return da.After(db)
+		}
+		return order[a] > order[b]
+	})
+
+	transactions := make([]*Transaction, 0, min(n, len(order)))
+	for _, i := range order[:min(n, len(order))] {
+		tx, err := f.GetTransaction(i)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, nil
+}
+
 // GetAccounts returns all unique account names found in the file
 func (f *File) GetAccounts() []string {
 	return f.index.accounts
@@ -141,12 +285,52 @@ func (f *File) GetCommodities() []string {
 	return f.index.commodities
 }
 
+// GetOpenDirectives returns all open directives declared in the file and its includes
+func (f *File) GetOpenDirectives() []OpenAccount {
+	return f.index.opens
+}
+
+// GetCloseDirectives returns all close directives declared in the file and its includes
+func (f *File) GetCloseDirectives() []CloseAccount {
+	return f.index.closes
+}
+
+// GetPriceDirectives returns all price directives declared in the file and its includes
+func (f *File) GetPriceDirectives() []Price {
+	return f.index.prices
+}
+
+// GetCustomDirectives returns all custom directives declared in the file and its includes
+func (f *File) GetCustomDirectives() []Custom {
+	return f.index.customs
+}
+
+// DeclaredAccounts returns the names of accounts with an open directive
+// Falls back to all referenced accounts when the ledger declares none
+func (f *File) DeclaredAccounts() []string {
+	if len(f.index.opens) == 0 {
+		return f.index.accounts
+	}
+
+	accounts := make([]string, 0, len(f.index.opens))
+	for _, open := range f.index.opens {
+		accounts = append(accounts, open.Account)
+	}
+	return accounts
+}
+
 // buildIndex scans the entire file and builds an index of all directives
-func (f *File) buildIndex() error {
-	f.index = &Index{
+// progress may be nil when nobody is watching.
+func (f *File) buildIndex(progress *indexProgress) (*Index, error) {
+	index := &Index{
 		transactions: make([]TransactionIndex, 0),
 		accounts:     make([]string, 0),
 		commodities:  make([]string, 0),
+		opens:        make([]OpenAccount, 0),
+		closes:       make([]CloseAccount, 0),
+		prices:       make([]Price, 0),
+		customs:      make([]Custom, 0),
+		content:      fnv.New64a(),
 	}
 
 	accountSet := make(map[string]bool)
@@ -154,15 +338,27 @@ func (f *File) buildIndex() error {
 	includedFiles := make(map[string]bool)
 
 	// Process the main file and all includes recursively
-	if err := f.processFile(f.path, accountSet, commoditySet, includedFiles); err != nil {
-		return err
+	if err := f.processFile(index, f.path, accountSet, commoditySet, includedFiles, progress); err != nil {
+		return nil, err
 	}
 
-	return nil
+	index.byDate = make([]int, len(index.transactions))
+	for i := range index.byDate {
+		index.byDate[i] = i
+	}
+	sort.SliceStable(index.byDate, func(a, b int) bool {
+		return index.transactions[index.byDate[a]].Date.Before(index.transactions[index.byDate[b]].Date)
+	})
+
+	if progress != nil {
+		progress.Transactions = len(index.transactions)
+		progress.send()
+	}
+	return index, nil
 }
 
-// processFile recursively processes a file and all its includes
-func (f *File) processFile(filePath string, accountSet, commoditySet map[string]bool, includedFiles map[string]bool) error {
+// processFile recursively processes a file and all its includes into index
+func (f *File) processFile(index *Index, filePath string, accountSet, commoditySet map[string]bool, includedFiles map[string]bool, progress *indexProgress) error {
 	// Check if already included to avoid infinite loops
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
@@ -173,14 +369,22 @@ func (f *File) processFile(filePath string, accountSet, commoditySet map[string]
 		return nil // Already processed
 	}
 	includedFiles[absPath] = true
+	index.files = append(index.files, filePath)
 
 	// Open the file
-	file, err := os.Open(filePath)
+	file, err := f.store.open(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open file %s: %w", filePath, err)
 	}
 	defer file.Close()
 
+	if progress != nil {
+		progress.File = filePath
+		progress.Files++
+		progress.Transactions = len(index.transactions)
+		progress.send()
+	}
+
 	scanner := bufio.NewScanner(file)
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // 1MB max line size
 
@@ -191,6 +395,17 @@ func (f *File) processFile(filePath string, accountSet, commoditySet map[string]
 	for scanner.Scan() {
 		lineNumber++
 		line := scanner.Text()
+		index.content.Write(scanner.Bytes())
+		index.content.Write([]byte{'\n'})
+
+		if progress != nil {
+			progress.Lines++
+			progress.Bytes += int64(len(scanner.Bytes()) + 1)
+			if progress.Lines%progressInterval == 0 {
+				progress.Transactions = len(index.transactions)
+				progress.send()
+			}
+		}
 
 		// Check for include directive
 		if matches := includeRegex.FindStringSubmatch(line); matches != nil {
@@ -200,16 +415,27 @@ func (f *File) processFile(filePath string, accountSet, commoditySet map[string]
 				includePath = filepath.Join(baseDir, includePath)
 			}
 			// Recursively process included file
-			if err := f.processFile(includePath, accountSet, commoditySet, includedFiles); err != nil {
+			if err := f.processFile(index, includePath, accountSet, commoditySet, includedFiles, progress); err != nil {
 				return fmt.Errorf("error processing include %s: %w", includePath, err)
 			}
+			if progress != nil {
+				progress.File = filePath
+			}
 			position += int64(len(scanner.Bytes()) + 1)
 			continue
 		}
 
 		// Try to parse as transaction start
 		if txIndex := parseTransactionIndexLine(line, filePath, position, lineNumber); txIndex != nil {
-			f.index.transactions = append(f.index.transactions, *txIndex)
+			index.transactions = append(index.transactions, *txIndex)
+		} else if open := parseOpenLine(line, lineNumber); open != nil {
+			index.opens = append(index.opens, *open)
+		} else if closeDirective := parseCloseLine(line, lineNumber); closeDirective != nil {
+			index.closes = append(index.closes, *closeDirective)
+		} else if price := parsePriceLine(line, lineNumber); price != nil {
+			index.prices = append(index.prices, *price)
+		} else if custom := parseCustomLine(line, lineNumber); custom != nil {
+			index.customs = append(index.customs, *custom)
 		}
 
 		// Extract accounts and commodities
@@ -217,13 +443,13 @@ func (f *File) processFile(filePath string, accountSet, commoditySet map[string]
 		for _, acc := range accounts {
 			if !accountSet[acc] {
 				accountSet[acc] = true
-				f.index.accounts = append(f.index.accounts, acc)
+				index.accounts = append(index.accounts, acc)
 			}
 		}
 		for _, comm := range commodities {
 			if !commoditySet[comm] {
 				commoditySet[comm] = true
-				f.index.commodities = append(f.index.commodities, comm)
+				index.commodities = append(index.commodities, comm)
 			}
 		}
 
@@ -237,15 +463,68 @@ func (f *File) processFile(filePath string, accountSet, commoditySet map[string]
 	return nil
 }
 
+// Includes returns the paths a ledger file's include directives name, as written
+func Includes(content []byte) []string {
+	var paths []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if matches := includeRegex.FindStringSubmatch(line); matches != nil {
+			paths = append(paths, matches[1])
+		}
+	}
+	return paths
+}
+
+// AllTransactions parses every transaction in index order
+// Each source file is opened once, which is much faster than GetTransaction for bulk reads
+func (f *File) AllTransactions() ([]*Transaction, error) {
+	transactions := make([]*Transaction, len(f.index.transactions))
+	handles := make(map[string]ledgerFile)
+	defer func() {
+		for _, h := range handles {
+			h.Close()
+		}
+	}()
+
+	for i, txIndex := range f.index.transactions {
+		if tx, ok := f.cache.transactions[i]; ok {
+			transactions[i] = tx
+			continue
+		}
+
+		handle, ok := handles[txIndex.FilePath]
+		if !ok {
+			var err error
+			handle, err = f.store.open(txIndex.FilePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open file %s: %w", txIndex.FilePath, err)
+			}
+			handles[txIndex.FilePath] = handle
+		}
+
+		tx, err := parseTransactionFrom(handle, txIndex.FilePath, txIndex.FilePosition, txIndex.LineNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse transaction at index %d: %w", i, err)
+		}
+		transactions[i] = tx
+	}
+
+	return transactions, nil
+}
+
 // parseTransactionAt seeks to a position and parses a complete transaction
 func (f *File) parseTransactionAt(filePath string, position int64, lineNumber int) (*Transaction, error) {
 	// Open the correct file (might be an included file, not the main file)
-	file, err := os.Open(filePath)
+	file, err := f.store.open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
 	}
 	defer file.Close()
 
+	return parseTransactionFrom(file, filePath, position, lineNumber)
+}
+
+// parseTransactionFrom seeks an open file to a position and parses the transaction there
+func parseTransactionFrom(file io.ReadSeeker, filePath string, position int64, lineNumber int) (*Transaction, error) {
 	// Seek to the position
 	if _, err := file.Seek(position, 0); err != nil {
 		return nil, fmt.Errorf("failed to seek to position %d: %w", position, err)
@@ -260,6 +539,7 @@ func (f *File) parseTransactionAt(filePath string, position int64, lineNumber in
 		return nil, fmt.Errorf("failed to parse transaction at line %d: %w", lineNumber, err)
 	}
 
+	tx.FilePath = filePath
 	tx.FilePosition = position
 	tx.LineNumber = lineNumber
 
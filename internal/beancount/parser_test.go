@@ -1,8 +1,10 @@
 package beancount
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -195,6 +197,103 @@ func TestGetTransactionsByDateRange(t *testing.T) {
 	}
 }
 
+func TestLatestTransactions(t *testing.T) {
+	content := `2025-01-10 * "Store" "Item 3"
+  Assets:Checking  -30.00 USD
+  Expenses:Test  30.00 USD
+
+2025-01-01 * "Store" "Item 1"
+  Assets:Checking  -10.00 USD
+  Expenses:Test  10.00 USD
+
+2025-01-05 * "Store" "Item 2a"
+  Assets:Checking  -20.00 USD
+  Expenses:Test  20.00 USD
+
+2025-01-05 * "Store" "Item 2b"
+  Assets:Checking  -25.00 USD
+  Expenses:Test  25.00 USD
+`
+
+	tmpFile, err := createTempFile(content)
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	f, err := Open(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	txs, err := f.LatestTransactions(3)
+	if err != nil {
+		t.Fatalf("failed to get latest transactions: %v", err)
+	}
+
+	var got []string
+	for _, tx := range txs {
+		got = append(got, tx.Narration)
+	}
+	want := []string{"Item 3", "Item 2b", "Item 2a"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if txs, _ := f.LatestTransactions(10); len(txs) != 4 {
+		t.Errorf("expected all 4 transactions when asking for more, got %d", len(txs))
+	}
+}
+
+func TestTransactionsFrom(t *testing.T) {
+	content := `2025-01-10 * "Store" "Item 3"
+  Assets:Checking  -30.00 USD
+  Expenses:Test  30.00 USD
+
+2025-01-01 * "Store" "Item 1"
+  Assets:Checking  -10.00 USD
+  Expenses:Test  10.00 USD
+
+2025-01-05 * "Store" "Item 2a"
+  Assets:Checking  -20.00 USD
+  Expenses:Test  20.00 USD
+
+2025-01-05 * "Store" "Item 2b"
+  Assets:Checking  -25.00 USD
+  Expenses:Test  25.00 USD
+`
+
+	tmpFile, err := createTempFile(content)
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	f, err := Open(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		date string
+		want []int
+	}{
+		{"2024-12-31", []int{1, 2, 3, 0}},
+		{"2025-01-02", []int{2, 3, 0}},
+		{"2025-01-05", []int{2, 3, 0}},
+		{"2025-01-10", []int{0}},
+		{"2025-01-11", []int{}},
+	}
+	for _, tt := range tests {
+		date, _ := time.Parse("2006-01-02", tt.date)
+		if got := f.TransactionsFrom(date); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("TransactionsFrom(%s) = %v, want %v", tt.date, got, tt.want)
+		}
+	}
+}
+
 func TestGetAccounts(t *testing.T) {
 	content := `2025-01-01 * "Test" "Test"
   Assets:Checking  -100.00 USD
@@ -290,3 +389,162 @@ func createTempFile(content string) (string, error) {
 
 	return tmpFile, nil
 }
+
+func TestAllTransactions(t *testing.T) {
+	var content string
+	for i := 1; i <= 120; i++ {
+		content += fmt.Sprintf("2025-01-01 * \"Store %d\" \"Transaction\"\n", i)
+		content += "  Assets:Checking  -10.00 USD\n"
+		content += "  Expenses:Test  10.00 USD\n\n"
+	}
+
+	tmpFile, err := createTempFile(content)
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	f, err := Open(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	// Warm the cache for one entry to exercise the mixed path
+	if _, err := f.GetTransaction(5); err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+
+	txs, err := f.AllTransactions()
+	if err != nil {
+		t.Fatalf("failed to read all transactions: %v", err)
+	}
+	if len(txs) != 120 {
+		t.Fatalf("expected 120 transactions, got %d", len(txs))
+	}
+	if txs[119].Payee != "Store 120" {
+		t.Errorf("expected last payee 'Store 120', got '%s'", txs[119].Payee)
+	}
+	if len(txs[0].Postings) != 2 {
+		t.Errorf("expected 2 postings, got %d", len(txs[0].Postings))
+	}
+}
+
+func TestOpenWithProgress(t *testing.T) {
+	dir := t.TempDir()
+	var included string
+	for i := 1; i <= 3000; i++ {
+		included += fmt.Sprintf("2025-01-01 * \"Store %d\" \"Transaction\"\n", i)
+		included += "  Assets:Checking  -10.00 USD\n"
+		included += "  Expenses:Test  10.00 USD\n\n"
+	}
+	if err := os.WriteFile(filepath.Join(dir, "2025.beancount"), []byte(included), 0644); err != nil {
+		t.Fatalf("failed to write include: %v", err)
+	}
+	ledger := filepath.Join(dir, "main.beancount")
+	content := "include \"2025.beancount\"\n\n2024-12-31 * \"Opening\" \"Balance\"\n  Assets:Checking  100.00 USD\n  Equity:Opening\n"
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	var reports []Progress
+	f, err := OpenWithProgress(ledger, func(p Progress) { reports = append(reports, p) })
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	// Each file start, every progressInterval lines and the end are reported
+	if len(reports) < 4 {
+		t.Fatalf("expected at least 4 progress reports, got %d", len(reports))
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].Lines < reports[i-1].Lines || reports[i].Transactions < reports[i-1].Transactions {
+			t.Errorf("expected progress to grow, got %+v after %+v", reports[i], reports[i-1])
+		}
+	}
+	if reports[1].File != filepath.Join(dir, "2025.beancount") || reports[1].Files != 2 {
+		t.Errorf("expected the include reported as the second file, got %+v", reports[1])
+	}
+
+	last := reports[len(reports)-1]
+	if last.Files != 2 || last.Transactions != 3001 || last.Lines != 12005 {
+		t.Errorf("unexpected final progress: %+v", last)
+	}
+	if last.Bytes != int64(len(included)+len(content)) {
+		t.Errorf("expected %d bytes scanned, got %d", len(included)+len(content), last.Bytes)
+	}
+}
+
+func TestHash(t *testing.T) {
+	dir := t.TempDir()
+	included := filepath.Join(dir, "2025.beancount")
+	ledger := filepath.Join(dir, "main.beancount")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	write(included, "2025-01-01 * \"Store\" \"Transaction\"\n  Assets:Checking  -10.00 USD\n  Expenses:Test\n")
+	write(ledger, "include \"2025.beancount\"\n")
+
+	f, err := Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	hash := f.Hash()
+	if err := f.Reload(); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if f.Hash() != hash {
+		t.Errorf("expected the same hash for the same content, got %s then %s", hash, f.Hash())
+	}
+
+	// An edit to an include changes it once reloaded
+	write(included, "2025-01-01 * \"Store\" \"Transaction\"\n  Assets:Checking  -12.00 USD\n  Expenses:Test\n")
+	if f.Hash() != hash {
+		t.Error("expected the hash to stay until the ledger is reloaded")
+	}
+	if err := f.Reload(); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if f.Hash() == hash {
+		t.Error("expected a new hash after the include changed")
+	}
+}
+
+func TestReloadFailureKeepsIndex(t *testing.T) {
+	dir := t.TempDir()
+	ledger := filepath.Join(dir, "main.beancount")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write ledger: %v", err)
+		}
+	}
+	write("2025-01-01 * \"Store\" \"Transaction\"\n  Assets:Checking  -10.00 USD\n  Expenses:Test\n")
+
+	f, err := Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+	hash := f.Hash()
+
+	// The include goes missing after a transaction is indexed; the half-built index is
+	// thrown away
+	write("2025-01-01 * \"Store\" \"Transaction\"\n  Assets:Checking  -10.00 USD\n  Expenses:Test\n\n" +
+		"2025-01-02 * \"Cafe\" \"Coffee\"\n  Assets:Checking  -4.00 USD\n  Expenses:Food\n\ninclude \"missing.beancount\"\n")
+	if err := f.Reload(); err == nil {
+		t.Fatal("expected the missing include to fail the reload")
+	}
+	if f.TransactionCount() != 1 || f.Hash() != hash {
+		t.Errorf("expected the old index kept, got %d transactions", f.TransactionCount())
+	}
+	if _, err := f.GetTransaction(0); err != nil {
+		t.Errorf("expected the old transaction still readable: %v", err)
+	}
+}
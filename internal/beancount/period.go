@@ -0,0 +1,137 @@
+package beancount
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PeriodUnit is the length of a reporting period
+type PeriodUnit int
+
+const (
+	PeriodAll PeriodUnit = iota // No date restriction
+	PeriodMonth
+	PeriodQuarter
+	PeriodYear
+)
+
+// Period is a calendar month, quarter or year, or all time
+type Period struct {
+	Unit  PeriodUnit
+	Start time.Time // First day of the period (zero for PeriodAll)
+}
+
+// AllTime returns the unrestricted period
+func AllTime() Period {
+	return Period{Unit: PeriodAll}
+}
+
+// PeriodContaining returns the period of the given unit that contains t
+func PeriodContaining(unit PeriodUnit, t time.Time) Period {
+	year, month, _ := t.Date()
+	var start time.Time
+	switch unit {
+	case PeriodMonth:
+		start = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	case PeriodQuarter:
+		quarterMonth := time.Month((int(month)-1)/3*3 + 1)
+		start = time.Date(year, quarterMonth, 1, 0, 0, 0, 0, time.UTC)
+	case PeriodYear:
+		start = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return AllTime()
+	}
+	return Period{Unit: unit, Start: start}
+}
+
+// IsAll reports whether the period is unrestricted
+func (p Period) IsAll() bool {
+	return p.Unit == PeriodAll
+}
+
+// End returns the first day after the period (zero for PeriodAll)
+func (p Period) End() time.Time {
+	return p.shift(1).Start
+}
+
+// Next returns the following period of the same unit
+func (p Period) Next() Period {
+	return p.shift(1)
+}
+
+// Prev returns the preceding period of the same unit
+func (p Period) Prev() Period {
+	return p.shift(-1)
+}
+
+// shift moves the period by n units
+func (p Period) shift(n int) Period {
+	switch p.Unit {
+	case PeriodMonth:
+		return Period{Unit: p.Unit, Start: p.Start.AddDate(0, n, 0)}
+	case PeriodQuarter:
+		return Period{Unit: p.Unit, Start: p.Start.AddDate(0, 3*n, 0)}
+	case PeriodYear:
+		return Period{Unit: p.Unit, Start: p.Start.AddDate(n, 0, 0)}
+	}
+	return p
+}
+
+// Contains reports whether t falls within the period
+func (p Period) Contains(t time.Time) bool {
+	if p.IsAll() {
+		return true
+	}
+	return !t.Before(p.Start) && t.Before(p.End())
+}
+
+// String returns a short label such as "Mar 2024", "2024 Q1", "2024" or "All time"
+func (p Period) String() string {
+	switch p.Unit {
+	case PeriodMonth:
+		return p.Start.Format("Jan 2006")
+	case PeriodQuarter:
+		return fmt.Sprintf("%d Q%d", p.Start.Year(), (int(p.Start.Month())-1)/3+1)
+	case PeriodYear:
+		return p.Start.Format("2006")
+	}
+	return "All time"
+}
+
+// ParsePeriod reads a period as written on the command line: "all", "this month",
+// "last quarter", "this year" and the like relative to today, or a calendar period
+// such as "2024", "2024-Q1" or "2024-03"
+func ParsePeriod(s string, today time.Time) (Period, error) {
+	text := strings.ToLower(strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '_' }), " "))
+	if text == "all" || text == "all time" {
+		return AllTime(), nil
+	}
+	if which, unit, ok := strings.Cut(text, " "); ok && (which == "this" || which == "last") {
+		units := map[string]PeriodUnit{"month": PeriodMonth, "quarter": PeriodQuarter, "year": PeriodYear}
+		if u, ok := units[unit]; ok {
+			period := PeriodContaining(u, today)
+			if which == "last" {
+				period = period.Prev()
+			}
+			return period, nil
+		}
+	}
+	if year, quarter, ok := strings.Cut(text, "-q"); ok && len(year) == 4 && len(quarter) == 1 && quarter >= "1" && quarter <= "4" {
+		if start, err := time.Parse("2006-01", fmt.Sprintf("%s-%02d", year, (int(quarter[0]-'0')-1)*3+1)); err == nil {
+			return Period{Unit: PeriodQuarter, Start: start}, nil
+		}
+	}
+	for _, l := range []struct {
+		layout string
+		unit   PeriodUnit
+	}{{"2006-01", PeriodMonth}, {"2006", PeriodYear}} {
+		if len(text) != len(l.layout) {
+			continue
+		}
+		if start, err := time.Parse(l.layout, text); err == nil {
+			return Period{Unit: l.unit, Start: start}, nil
+		}
+	}
+	return Period{}, fmt.Errorf("invalid period %q: use all, this/last month, quarter or year, YYYY, YYYY-Qn or YYYY-MM", s)
+}
@@ -0,0 +1,88 @@
+package beancount
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodContaining(t *testing.T) {
+	date := time.Date(2024, time.May, 17, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		unit  PeriodUnit
+		start string
+		end   string
+		label string
+	}{
+		{PeriodMonth, "2024-05-01", "2024-06-01", "May 2024"},
+		{PeriodQuarter, "2024-04-01", "2024-07-01", "2024 Q2"},
+		{PeriodYear, "2024-01-01", "2025-01-01", "2024"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			p := PeriodContaining(tt.unit, date)
+			if got := p.Start.Format("2006-01-02"); got != tt.start {
+				t.Errorf("expected start %s, got %s", tt.start, got)
+			}
+			if got := p.End().Format("2006-01-02"); got != tt.end {
+				t.Errorf("expected end %s, got %s", tt.end, got)
+			}
+			if p.String() != tt.label {
+				t.Errorf("expected label %s, got %s", tt.label, p.String())
+			}
+			if !p.Contains(date) || p.Contains(p.End()) {
+				t.Error("expected period to contain its date but not its end")
+			}
+		})
+	}
+}
+
+func TestPeriodNavigation(t *testing.T) {
+	q := PeriodContaining(PeriodQuarter, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC))
+	if got := q.Prev().String(); got != "2023 Q4" {
+		t.Errorf("expected 2023 Q4, got %s", got)
+	}
+	if got := q.Next().Next().String(); got != "2024 Q3" {
+		t.Errorf("expected 2024 Q3, got %s", got)
+	}
+
+	all := AllTime()
+	if !all.Contains(time.Time{}) || all.Next() != all || all.String() != "All time" {
+		t.Error("expected all-time period to contain everything and not move")
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	today := time.Date(2024, time.May, 17, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		text  string
+		label string
+	}{
+		{"all", "All time"},
+		{"this month", "May 2024"},
+		{"this_month", "May 2024"},
+		{"Last Month", "Apr 2024"},
+		{"last quarter", "2024 Q1"},
+		{"this year", "2024"},
+		{"2023", "2023"},
+		{"2023-q3", "2023 Q3"},
+		{"2023-11", "Nov 2023"},
+	}
+	for _, tt := range tests {
+		period, err := ParsePeriod(tt.text, today)
+		if err != nil {
+			t.Errorf("ParsePeriod(%q): %v", tt.text, err)
+			continue
+		}
+		if period.String() != tt.label {
+			t.Errorf("ParsePeriod(%q): expected %s, got %s", tt.text, tt.label, period.String())
+		}
+	}
+
+	for _, text := range []string{"", "next month", "2023-Q5", "2023-13", "May"} {
+		if _, err := ParsePeriod(text, today); err == nil {
+			t.Errorf("ParsePeriod(%q): expected an error", text)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package beancount
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// rate is one commodity's price in another on a date
+type rate struct {
+	date   time.Time
+	number decimal.Decimal
+}
+
+// pair identifies a commodity priced in a currency
+type pair struct {
+	commodity string
+	currency  string
+}
+
+// PriceDB looks up commodity prices declared with price directives
+type PriceDB struct {
+	rates      map[pair][]rate // By date, earliest first
+	currencies []string
+}
+
+// NewPriceDB builds a price database from price directives
+func NewPriceDB(prices []Price) *PriceDB {
+	db := &PriceDB{rates: make(map[pair][]rate)}
+	seen := make(map[string]bool)
+	for _, price := range prices {
+		if price.Amount.Number.IsZero() {
+			continue
+		}
+		key := pair{price.Commodity, price.Amount.Commodity}
+		db.rates[key] = append(db.rates[key], rate{price.Date, price.Amount.Number})
+		if !seen[price.Amount.Commodity] {
+			seen[price.Amount.Commodity] = true
+			db.currencies = append(db.currencies, price.Amount.Commodity)
+		}
+	}
+	for key := range db.rates {
+		sort.SliceStable(db.rates[key], func(i, j int) bool {
+			return db.rates[key][i].date.Before(db.rates[key][j].date)
+		})
+	}
+	sort.Strings(db.currencies)
+	return db
+}
+
+// Currencies returns the commodities prices are quoted in, sorted
+func (db *PriceDB) Currencies() []string {
+	return db.currencies
+}
+
+// Rate returns how much of currency one unit of commodity is worth on a date
+// The latest price on or before the date is used, or the earliest one when the date
+// precedes them all; a price quoted the other way round is inverted.
+func (db *PriceDB) Rate(commodity, currency string, date time.Time) (decimal.Decimal, bool) {
+	if commodity == currency {
+		return decimal.NewFromInt(1), true
+	}
+	if number, ok := lookup(db.rates[pair{commodity, currency}], date); ok {
+		return number, true
+	}
+	if number, ok := lookup(db.rates[pair{currency, commodity}], date); ok {
+		return decimal.NewFromInt(1).DivRound(number, 16), true
+	}
+	return decimal.Zero, false
+}
+
+// lookup returns the rate in effect on a date from rates ordered by date
+func lookup(rates []rate, date time.Time) (decimal.Decimal, bool) {
+	if len(rates) == 0 {
+		return decimal.Zero, false
+	}
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].date.After(date) })
+	if i == 0 {
+		return rates[0].number, true
+	}
+	return rates[i-1].number, true
+}
+
+// Convert returns an amount in currency at the date's price, or false when there is no price
+func (db *PriceDB) Convert(amount Amount, currency string, date time.Time) (Amount, bool) {
+	number, ok := db.Rate(amount.Commodity, currency, date)
+	if !ok {
+		return amount, false
+	}
+	return Amount{Number: amount.Number.Mul(number), Commodity: currency}, true
+}
+
+// ConvertBalance folds a per-commodity balance into currency at the date's prices
+// Commodities without a price stay as they are.
+func (db *PriceDB) ConvertBalance(balance map[string]decimal.Decimal, currency string, date time.Time) map[string]decimal.Decimal {
+	converted := make(map[string]decimal.Decimal, len(balance))
+	for commodity, number := range balance {
+		amount, _ := db.Convert(Amount{Number: number, Commodity: commodity}, currency, date)
+		converted[amount.Commodity] = converted[amount.Commodity].Add(amount.Number)
+	}
+	return converted
+}
+
+// ConvertTransactions returns copies of transactions with every posting in currency,
+// at the price on the transaction's date
+// Elided postings are filled in first; postings without a price keep their commodity.
+func (db *PriceDB) ConvertTransactions(transactions []*Transaction, currency string) []*Transaction {
+	converted := make([]*Transaction, len(transactions))
+	for i, tx := range transactions {
+		copied := *tx
+		copied.Postings = make([]Posting, 0, len(tx.Postings))
+		for _, posting := range tx.ResolvedPostings() {
+			if posting.Amount != nil {
+				if amount, ok := db.Convert(*posting.Amount, currency, tx.Date); ok {
+					posting.Amount = &amount
+					posting.Cost, posting.Price = nil, nil
+				}
+			}
+			copied.Postings = append(copied.Postings, posting)
+		}
+		converted[i] = &copied
+	}
+	return converted
+}
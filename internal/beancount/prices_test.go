@@ -0,0 +1,83 @@
+package beancount
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParsePriceLine(t *testing.T) {
+	price := parsePriceLine("2024-03-01 price EUR 1.10 USD ; ECB", 4)
+	if price == nil {
+		t.Fatal("expected price directive, got nil")
+	}
+	if price.Commodity != "EUR" || !price.Amount.Number.Equal(decimal.RequireFromString("1.10")) || price.Amount.Commodity != "USD" || price.LineNumber != 4 {
+		t.Errorf("unexpected price directive: %+v", price)
+	}
+	if parsePriceLine("2024-03-01 price EUR", 1) != nil {
+		t.Error("expected nil for price without an amount")
+	}
+}
+
+func TestPriceDB(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	db := NewPriceDB([]Price{
+		{Date: date("2024-02-01"), Commodity: "EUR", Amount: Amount{decimal.RequireFromString("1.20"), "USD"}},
+		{Date: date("2024-01-01"), Commodity: "EUR", Amount: Amount{decimal.RequireFromString("1.10"), "USD"}},
+		{Date: date("2024-01-01"), Commodity: "VTI", Amount: Amount{decimal.RequireFromString("200"), "USD"}},
+	})
+
+	tests := []struct {
+		from, to string
+		date     string
+		want     string
+	}{
+		{"EUR", "USD", "2024-01-15", "1.10"},
+		{"EUR", "USD", "2024-02-01", "1.20"},
+		{"EUR", "USD", "2023-06-01", "1.10"}, // Before every price
+		{"USD", "EUR", "2024-03-01", "0.8333333333333333"},
+		{"USD", "USD", "2024-03-01", "1"},
+	}
+	for _, tt := range tests {
+		got, ok := db.Rate(tt.from, tt.to, date(tt.date))
+		if !ok || !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("Rate(%s, %s, %s) = %s, %v; want %s", tt.from, tt.to, tt.date, got, ok, tt.want)
+		}
+	}
+	if _, ok := db.Rate("GBP", "USD", date("2024-03-01")); ok {
+		t.Error("expected no rate for an unpriced commodity")
+	}
+	if currencies := db.Currencies(); len(currencies) != 1 || currencies[0] != "USD" {
+		t.Errorf("unexpected currencies: %v", currencies)
+	}
+
+	balance := db.ConvertBalance(map[string]decimal.Decimal{
+		"EUR": decimal.NewFromInt(10),
+		"USD": decimal.NewFromInt(5),
+		"GBP": decimal.NewFromInt(3),
+	}, "USD", date("2024-03-01"))
+	if !balance["USD"].Equal(decimal.NewFromInt(17)) || !balance["GBP"].Equal(decimal.NewFromInt(3)) || len(balance) != 2 {
+		t.Errorf("unexpected converted balance: %v", balance)
+	}
+
+	tx := &Transaction{
+		Date: date("2024-01-10"),
+		Postings: []Posting{
+			{Account: "Expenses:Travel", Amount: &Amount{decimal.NewFromInt(50), "EUR"}},
+			{Account: "Assets:Checking"},
+		},
+	}
+	converted := db.ConvertTransactions([]*Transaction{tx}, "USD")[0]
+	if len(converted.Postings) != 2 || tx.Postings[1].Amount != nil {
+		t.Fatalf("expected a converted copy with the elided posting filled in, got %+v", converted.Postings)
+	}
+	for i, want := range []string{"55", "-55"} {
+		if amount := converted.Postings[i].Amount; amount.Commodity != "USD" || !amount.Number.Equal(decimal.RequireFromString(want)) {
+			t.Errorf("posting %d: got %s %s, want %s USD", i, amount.Number, amount.Commodity, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package beancount
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// yearFileRegex matches the names of the files SplitByYear writes, e.g. 2024.beancount
+var yearFileRegex = regexp.MustCompile(`^\d{4}(\..+)?$`)
+
+// stackRegex matches the directives applying to the entries after them in the same file,
+// which moving entries to other files would lose
+var stackRegex = regexp.MustCompile(`^(pushtag|poptag|pushmeta|popmeta)\b`)
+
+// SplitByYear builds the edits that move the dated entries of the ledger's main file into
+// one file per year, such as 2024.beancount, in dir relative to the main file, and
+// include those from where the entries were. Entries keep their order, and the comments
+// just above an entry move with it. Lines before the first entry, undated directives
+// such as options and includes, and comments after the last entry stay in the main file.
+// The year files take the main file's extension, so those of an encrypted ledger are
+// encrypted too; none may exist yet.
+func (w *Writer) SplitByYear(dir string) ([]Edit, error) {
+	path := w.file.Path()
+	lines, err := w.file.store.readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	first := -1
+	for i, line := range lines {
+		if stackRegex.MatchString(line) {
+			return nil, fmt.Errorf("%s:%d: entries under %s can't be moved to other files", path, i+1, strings.Fields(line)[0])
+		}
+		if first < 0 && datedLineRegex.MatchString(line) {
+			first = i
+		}
+	}
+	if first < 0 {
+		return nil, fmt.Errorf("%s has no dated entries to split", path)
+	}
+	start := commentsAbove(lines, first)
+
+	// Comments and blank lines wait for the entry they come before
+	years := make(map[string][]string)
+	var order []string
+	var kept, pending []string
+	for i := start; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case datedLineRegex.MatchString(line):
+			year := line[:4]
+			if _, ok := years[year]; !ok {
+				order = append(order, year)
+			}
+			end := entryEnd(lines, i)
+			years[year] = append(append(years[year], pending...), lines[i:end]...)
+			pending = nil
+			i = end
+			continue
+		case trimmed == "" || strings.HasPrefix(trimmed, ";"):
+			pending = append(pending, line)
+		default:
+			kept = append(kept, line)
+		}
+		i++
+	}
+
+	base := filepath.Base(path)
+	ext := ""
+	if dot := strings.Index(base, "."); dot >= 0 {
+		ext = base[dot:]
+	}
+	main := slices.Clone(trimBlank(lines[:start]))
+	if len(main) > 0 {
+		main = append(main, "")
+	}
+	var edits []Edit
+	for _, year := range order {
+		name := filepath.Join(dir, year+ext)
+		yearPath := name
+		if !filepath.IsAbs(name) {
+			yearPath = filepath.Join(filepath.Dir(path), name)
+		}
+		if _, err := os.Stat(yearPath); err == nil {
+			return nil, fmt.Errorf("%s already exists", yearPath)
+		}
+		main = append(main, fmt.Sprintf("include %q", filepath.ToSlash(name)))
+		edits = append(edits, Edit{FilePath: yearPath, StartLine: 1, NewLines: trimBlank(years[year])})
+	}
+	if len(kept) > 0 {
+		main = append(append(main, ""), kept...)
+	}
+	if rest := trimBlank(pending); len(rest) > 0 {
+		main = append(append(main, ""), rest...)
+	}
+	return append([]Edit{{FilePath: path, StartLine: 1, OldLines: lines, NewLines: main}}, edits...), nil
+}
+
+// JoinYears builds the edit that undoes SplitByYear: each include of the main file
+// naming a year file, such as 2024.beancount, is replaced by that file's lines. It also
+// returns the year files, which are no longer needed once the edit is applied. A year
+// file including others can't be joined, as its includes' paths are relative to it.
+func (w *Writer) JoinYears() ([]Edit, []string, error) {
+	path := w.file.Path()
+	lines, err := w.file.store.readLines(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var joined, files []string
+	previous := false // Whether the line before was a year joined
+	for i, line := range lines {
+		match := includeRegex.FindStringSubmatch(line)
+		if match == nil || !yearFileRegex.MatchString(filepath.Base(match[1])) {
+			joined = append(joined, line)
+			previous = false
+			continue
+		}
+		yearPath := match[1]
+		if !filepath.IsAbs(yearPath) {
+			yearPath = filepath.Join(filepath.Dir(path), yearPath)
+		}
+		yearLines, err := w.file.store.readLines(yearPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		for _, yearLine := range yearLines {
+			if includeRegex.MatchString(yearLine) {
+				return nil, nil, fmt.Errorf("%s includes other files and can't be joined", yearPath)
+			}
+		}
+		// Years joined one after another stay apart by a blank line
+		if previous {
+			joined = append(joined, "")
+		}
+		joined = append(joined, yearLines...)
+		files = append(files, yearPath)
+		previous = true
+	}
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("%s includes no year files to join", path)
+	}
+	return []Edit{{FilePath: path, StartLine: 1, OldLines: lines, NewLines: joined}}, files, nil
+}
+
+// commentsAbove returns the first line of the comments just above line i, i itself
+// without any
+func commentsAbove(lines []string, i int) int {
+	for i > 0 && strings.HasPrefix(strings.TrimSpace(lines[i-1]), ";") {
+		i--
+	}
+	return i
+}
+
+// trimBlank returns lines without the blank lines at either end
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// creates reports whether edits to a file that doesn't exist yet create it: each
+// replaces nothing at its first line
+func creates(edits []Edit, err error) bool {
+	if !errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	for _, edit := range edits {
+		if edit.StartLine != 1 || len(edit.OldLines) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package beancount
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriterSplitByYear(t *testing.T) {
+	dir := t.TempDir()
+	content := "option \"title\" \"Test\"\n" +
+		"include \"prices.beancount\"\n" +
+		"\n" +
+		"; Opening\n" +
+		"2023-01-01 open Assets:Checking\n" +
+		"2023-01-01 open Expenses:Food\n" +
+		"\n" +
+		"2023-12-30 * \"Grocer\" \"Groceries\"\n" +
+		"  Expenses:Food     40.00 USD\n" +
+		"  Assets:Checking\n" +
+		"\n" +
+		"; January\n" +
+		"2024-01-02 * \"Cafe\" \"Latte\"\n" +
+		"  Expenses:Food      5.00 USD\n" +
+		"  Assets:Checking\n" +
+		"\n" +
+		"option \"operating_currency\" \"USD\"\n" +
+		"\n" +
+		"2023-12-31 balance Assets:Checking  -40.00 USD\n" +
+		"\n" +
+		"; The end\n"
+	path := filepath.Join(dir, "main.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "prices.beancount"), []byte("2024-01-01 price VTI 100.00 USD\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := NewWriter(f)
+
+	edits, err := w.SplitByYear("years")
+	if err != nil {
+		t.Fatalf("SplitByYear failed: %v", err)
+	}
+	if len(edits) != 3 {
+		t.Fatalf("expected the main file and two years, got %d edits", len(edits))
+	}
+	if err := w.ApplyAll(edits); err != nil {
+		t.Fatalf("ApplyAll failed: %v", err)
+	}
+
+	main, _ := os.ReadFile(path)
+	want := "option \"title\" \"Test\"\n" +
+		"include \"prices.beancount\"\n" +
+		"\n" +
+		"include \"years/2023.beancount\"\n" +
+		"include \"years/2024.beancount\"\n" +
+		"\n" +
+		"option \"operating_currency\" \"USD\"\n" +
+		"\n" +
+		"; The end\n"
+	if string(main) != want {
+		t.Errorf("unexpected main file:\n%s", main)
+	}
+	year, _ := os.ReadFile(filepath.Join(dir, "years", "2023.beancount"))
+	if !strings.HasPrefix(string(year), "; Opening\n2023-01-01 open") || !strings.HasSuffix(string(year), "\n\n2023-12-31 balance Assets:Checking  -40.00 USD\n") {
+		t.Errorf("unexpected 2023:\n%s", year)
+	}
+	year, _ = os.ReadFile(filepath.Join(dir, "years", "2024.beancount"))
+	if !strings.HasPrefix(string(year), "; January\n2024-01-02 * \"Cafe\"") {
+		t.Errorf("unexpected 2024:\n%s", year)
+	}
+	transactions, err := f.AllTransactions()
+	if err != nil || len(transactions) != 2 {
+		t.Fatalf("expected both transactions read through the includes, got %d (%v)", len(transactions), err)
+	}
+
+	if _, err := w.SplitByYear("years"); err == nil {
+		t.Error("expected an error splitting into year files that exist")
+	}
+
+	edits, files, err := w.JoinYears()
+	if err != nil {
+		t.Fatalf("JoinYears failed: %v", err)
+	}
+	if len(files) != 2 || files[0] != filepath.Join(dir, "years", "2023.beancount") {
+		t.Errorf("unexpected year files %q", files)
+	}
+	if err := w.ApplyAll(edits); err != nil {
+		t.Fatal(err)
+	}
+	joined, _ := os.ReadFile(path)
+	if strings.Contains(string(joined), "years/") || !strings.Contains(string(joined), "  -40.00 USD\n\n; January\n2024-01-02") {
+		t.Errorf("unexpected joined file:\n%s", joined)
+	}
+	if !strings.Contains(string(joined), "include \"prices.beancount\"") {
+		t.Error("expected other includes kept")
+	}
+}
+
+func TestWriterSplitByYearStacks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.beancount")
+	content := "pushtag #trip\n2024-01-02 * \"Cafe\" \"Latte\"\n  Expenses:Food  5.00 USD\n  Assets:Checking\npoptag #trip\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := NewWriter(f).SplitByYear("."); err == nil || !strings.Contains(err.Error(), "pushtag") {
+		t.Errorf("expected an error for pushtag, got %v", err)
+	}
+}
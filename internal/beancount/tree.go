@@ -0,0 +1,86 @@
+package beancount
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// accountTypeOrder is the conventional order of the top-level account types
+var accountTypeOrder = []string{"Assets", "Liabilities", "Equity", "Income", "Expenses"}
+
+// AccountNode is one level of the account hierarchy, e.g. "Bank" in Assets:Bank:Checking
+type AccountNode struct {
+	Name     string // Last component of the account name
+	Account  string // Full account name up to this level
+	Depth    int
+	Children []*AccountNode
+}
+
+// AccountAncestry returns an account and its parents, outermost first:
+// "Assets:Bank:Checking" gives Assets, Assets:Bank and Assets:Bank:Checking
+func AccountAncestry(account string) []string {
+	parts := strings.Split(account, ":")
+	names := make([]string, len(parts))
+	for i := range parts {
+		names[i] = strings.Join(parts[:i+1], ":")
+	}
+	return names
+}
+
+// AccountUnder reports whether account is root or one of its descendants:
+// Expenses:Food:Coffee is under Expenses:Food, Expenses:FoodBank isn't
+func AccountUnder(account, root string) bool {
+	return account == root || strings.HasPrefix(account, root+":")
+}
+
+// AccountUnderAny reports whether account is under one of roots
+func AccountUnderAny(account string, roots []string) bool {
+	return slices.ContainsFunc(roots, func(root string) bool { return AccountUnder(account, root) })
+}
+
+// BuildAccountTree arranges account names into a hierarchy
+// Roots follow balance sheet order (Assets, Liabilities, Equity, Income, Expenses)
+// and children are sorted by name
+func BuildAccountTree(accounts []string) []*AccountNode {
+	nodes := make(map[string]*AccountNode)
+	var roots []*AccountNode
+	for _, account := range accounts {
+		var parent *AccountNode
+		for depth, name := range AccountAncestry(account) {
+			node, ok := nodes[name]
+			if !ok {
+				node = &AccountNode{Name: name[strings.LastIndex(name, ":")+1:], Account: name, Depth: depth}
+				nodes[name] = node
+				if parent == nil {
+					roots = append(roots, node)
+				} else {
+					parent.Children = append(parent.Children, node)
+				}
+			}
+			parent = node
+		}
+	}
+
+	for _, node := range nodes {
+		sort.Slice(node.Children, func(i, j int) bool { return node.Children[i].Name < node.Children[j].Name })
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		ri, rj := accountTypeRank(roots[i].Name), accountTypeRank(roots[j].Name)
+		if ri != rj {
+			return ri < rj
+		}
+		return roots[i].Name < roots[j].Name
+	})
+	return roots
+}
+
+// accountTypeRank orders account types; unknown roots sort last
+func accountTypeRank(name string) int {
+	for i, root := range accountTypeOrder {
+		if root == name {
+			return i
+		}
+	}
+	return len(accountTypeOrder)
+}
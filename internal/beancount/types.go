@@ -42,6 +42,7 @@ type Transaction struct {
 	Metadata  map[string]string
 
 	// For lazy loading - track position in file
+	FilePath     string // File containing the transaction (may be an include)
 	FilePosition int64
 	LineNumber   int
 }
@@ -56,6 +57,8 @@ type Posting struct {
 	Cost     *Amount // cost basis (optional)
 	Price    *Amount // price (optional)
 	Metadata map[string]string
+
+	LineNumber int // Line of the posting within the transaction's file
 }
 
 // Amount represents a monetary amount with commodity
@@ -92,6 +95,7 @@ type Balance struct {
 	Date       time.Time
 	Account    string
 	Amount     Amount
+	Tolerance  *decimal.Decimal // Given with ~, else inferred from the amount's precision
 	Metadata   map[string]string
 	LineNumber int
 }
@@ -145,3 +149,28 @@ type Note struct {
 
 func (n Note) GetDate() time.Time     { return n.Date }
 func (n Note) GetType() DirectiveType { return DirectiveTypeNote }
+
+// Event represents an event directive, e.g. event "location" "Tokyo, Japan": the
+// value holds from its date until the next event of the same type
+type Event struct {
+	Date       time.Time
+	Type       string
+	Value      string
+	Metadata   map[string]string
+	LineNumber int
+}
+
+func (e Event) GetDate() time.Time     { return e.Date }
+func (e Event) GetType() DirectiveType { return DirectiveTypeEvent }
+
+// Custom represents a custom directive, e.g. custom "budget" Expenses:Food "monthly" 400.00 USD
+type Custom struct {
+	Date       time.Time
+	Type       string   // The quoted name after "custom"
+	Values     []string // Remaining tokens, with quotes removed from strings
+	Metadata   map[string]string
+	LineNumber int
+}
+
+func (c Custom) GetDate() time.Time     { return c.Date }
+func (c Custom) GetType() DirectiveType { return DirectiveTypeCustom }
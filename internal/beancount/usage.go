@@ -0,0 +1,97 @@
+package beancount
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// usageHalfLife is the age at which a use counts half as much when ranking
+const usageHalfLife = 90 * 24 * time.Hour
+
+// usage scores names by how often and how recently they were used
+type usage struct {
+	score  map[string]float64
+	latest map[string]time.Time
+	now    time.Time
+}
+
+func newUsage(now time.Time) *usage {
+	return &usage{score: make(map[string]float64), latest: make(map[string]time.Time), now: now}
+}
+
+// add records a use of name on date; uses in the future count as recent ones
+func (u *usage) add(name string, date time.Time) {
+	age := max(0, u.now.Sub(date))
+	u.score[name] += math.Pow(0.5, float64(age)/float64(usageHalfLife))
+	if date.After(u.latest[name]) {
+		u.latest[name] = date
+	}
+}
+
+// ranked returns the used names, highest score first; ties go to the latest use, then by name
+func (u *usage) ranked() []string {
+	names := make([]string, 0, len(u.score))
+	for name := range u.score {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		if u.score[a] != u.score[b] {
+			return u.score[a] > u.score[b]
+		}
+		if !u.latest[a].Equal(u.latest[b]) {
+			return u.latest[a].After(u.latest[b])
+		}
+		return a < b
+	})
+	return names
+}
+
+// RankPayees returns the payees of transactions, most used first, for completion
+// Uses count less with age, halving every 90 days, so a payee used often lately
+// outranks one used more often long ago. Payees differing only in case are merged
+// under their latest spelling.
+func RankPayees(transactions []*Transaction, now time.Time) []string {
+	u := newUsage(now)
+	spelling := make(map[string]string)
+	spelled := make(map[string]time.Time)
+	for _, tx := range transactions {
+		if tx.Payee == "" {
+			continue
+		}
+		key := strings.ToLower(tx.Payee)
+		u.add(key, tx.Date)
+		if _, ok := spelling[key]; !ok || !tx.Date.Before(spelled[key]) {
+			spelling[key], spelled[key] = tx.Payee, tx.Date
+		}
+	}
+
+	payees := u.ranked()
+	for i, key := range payees {
+		payees[i] = spelling[key]
+	}
+	return payees
+}
+
+// RankAccounts returns the posting accounts of transactions, most used first as in
+// RankPayees, followed by the known accounts that were never posted to, sorted
+func RankAccounts(transactions []*Transaction, known []string, now time.Time) []string {
+	u := newUsage(now)
+	for _, tx := range transactions {
+		for _, posting := range tx.Postings {
+			u.add(posting.Account, tx.Date)
+		}
+	}
+
+	accounts := u.ranked()
+	var unused []string
+	for _, account := range known {
+		if _, ok := u.score[account]; !ok {
+			unused = append(unused, account)
+		}
+	}
+	sort.Strings(unused)
+	return append(accounts, unused...)
+}
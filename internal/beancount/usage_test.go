@@ -0,0 +1,49 @@
+package beancount
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRankPayees(t *testing.T) {
+	now := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(n int) time.Time { return now.AddDate(0, 0, -n) }
+	transactions := []*Transaction{
+		// Used most, but two years ago
+		{Date: daysAgo(730), Payee: "Old Diner"},
+		{Date: daysAgo(729), Payee: "Old Diner"},
+		{Date: daysAgo(728), Payee: "Old Diner"},
+		// Used twice lately, the second time spelled differently
+		{Date: daysAgo(20), Payee: "blue bottle"},
+		{Date: daysAgo(5), Payee: "Blue Bottle"},
+		// Used once lately
+		{Date: daysAgo(2), Payee: "Bakery"},
+		{Date: daysAgo(1), Narration: "No payee"},
+	}
+
+	got := RankPayees(transactions, now)
+	want := []string{"Blue Bottle", "Bakery", "Old Diner"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRankAccounts(t *testing.T) {
+	now := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	transactions := []*Transaction{
+		{Date: now.AddDate(0, 0, -3), Postings: []Posting{
+			{Account: "Assets:Checking"}, {Account: "Expenses:Food"},
+		}},
+		{Date: now.AddDate(0, 0, -1), Postings: []Posting{
+			{Account: "Assets:Checking"}, {Account: "Expenses:Rent"},
+		}},
+	}
+	known := []string{"Assets:Savings", "Expenses:Food", "Assets:Checking", "Assets:Brokerage"}
+
+	got := RankAccounts(transactions, known, now)
+	want := []string{"Assets:Checking", "Expenses:Rent", "Expenses:Food", "Assets:Brokerage", "Assets:Savings"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
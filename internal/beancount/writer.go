@@ -0,0 +1,783 @@
+package beancount
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// accountNameRegex validates a full account name written by the writer
+var accountNameRegex = regexp.MustCompile(`^[A-Z][A-Za-z0-9-]*(?::[A-Z0-9][A-Za-z0-9-]*)+$`)
+
+// IsValidAccount reports whether the writer accepts an account name
+func IsValidAccount(account string) bool {
+	return accountNameRegex.MatchString(account)
+}
+
+// IsValidCommodity reports whether the writer accepts a commodity name
+func IsValidCommodity(commodity string) bool {
+	return commodityNameRegex.MatchString(commodity)
+}
+
+// Edit is a line-based replacement in a ledger file
+// Edits are textual so comments, formatting and unrelated directives are preserved
+type Edit struct {
+	FilePath  string
+	StartLine int      // 1-based line where OldLines begin
+	OldLines  []string // Lines expected at StartLine (verified before applying)
+	NewLines  []string // Replacement lines
+}
+
+// Inverse returns the edit that reverts this one
+func (e Edit) Inverse() Edit {
+	return Edit{
+		FilePath:  e.FilePath,
+		StartLine: e.StartLine,
+		OldLines:  e.NewLines,
+		NewLines:  e.OldLines,
+	}
+}
+
+// ErrReadOnly is returned for edits to a ledger opened read-only
+var ErrReadOnly = errors.New("ledger is open read-only")
+
+// Writer applies edits to the files of an open ledger and keeps its index current
+type Writer struct {
+	file       *File
+	readOnly   bool
+	backup     func(path string) error
+	afterWrite func(path string) error
+}
+
+// NewWriter creates a writer for an open ledger
+func NewWriter(f *File) *Writer {
+	return &Writer{file: f}
+}
+
+// SetReadOnly sets whether the writer refuses every edit
+func (w *Writer) SetReadOnly(readOnly bool) {
+	w.readOnly = readOnly
+}
+
+// SetBackup sets a function called with each file's path before the file is rewritten;
+// when it fails nothing more is written. nil writes without one.
+func (w *Writer) SetBackup(backup func(path string) error) {
+	w.backup = backup
+}
+
+// SetAfterWrite sets a function called with each file's path once the file is
+// rewritten, such as to copy it elsewhere; nil calls nothing
+func (w *Writer) SetAfterWrite(afterWrite func(path string) error) {
+	w.afterWrite = afterWrite
+}
+
+// ReadOnly reports whether the writer refuses every edit
+func (w *Writer) ReadOnly() bool {
+	return w.readOnly
+}
+
+// Apply applies a single edit and reloads the ledger
+func (w *Writer) Apply(edit Edit) error {
+	return w.ApplyAll([]Edit{edit})
+}
+
+// ApplyAll applies a batch of edits, rewriting each affected file once, then reloads the ledger
+// Every edit is verified against the current file contents before anything is written
+func (w *Writer) ApplyAll(edits []Edit) error {
+	if len(edits) == 0 {
+		return nil
+	}
+	if w.readOnly {
+		return ErrReadOnly
+	}
+
+	byFile := make(map[string][]Edit)
+	var order []string
+	for _, edit := range edits {
+		if _, ok := byFile[edit.FilePath]; !ok {
+			order = append(order, edit.FilePath)
+		}
+		byFile[edit.FilePath] = append(byFile[edit.FilePath], edit)
+	}
+
+	// Build all new contents first so a stale edit aborts the whole batch
+	contents := make(map[string][]string, len(order))
+	for _, path := range order {
+		fileEdits := byFile[path]
+		lines, err := w.file.store.readLines(path)
+		if creates(fileEdits, err) {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			lines, err = []string{}, nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Apply bottom-up so earlier line numbers stay valid
+		sort.SliceStable(fileEdits, func(i, j int) bool {
+			return fileEdits[i].StartLine > fileEdits[j].StartLine
+		})
+		for _, edit := range fileEdits {
+			lines, err = applyEdit(lines, edit)
+			if err != nil {
+				return err
+			}
+		}
+		contents[path] = lines
+	}
+
+	if w.backup != nil {
+		for _, path := range order {
+			if err := w.backup(path); err != nil {
+				return err
+			}
+		}
+	}
+	for _, path := range order {
+		if err := w.file.store.writeLines(path, contents[path]); err != nil {
+			return err
+		}
+	}
+	if w.afterWrite != nil {
+		for _, path := range order {
+			if err := w.afterWrite(path); err != nil {
+				// The files are written; the index must still follow them
+				w.file.Reload()
+				return err
+			}
+		}
+	}
+
+	return w.file.Reload()
+}
+
+// SetPostingAccount builds an edit that replaces the account of one posting
+// The amount column is kept aligned where the spacing allows
+func (w *Writer) SetPostingAccount(tx *Transaction, postingIndex int, account string) (Edit, error) {
+	if tx == nil {
+		return Edit{}, fmt.Errorf("transaction cannot be nil")
+	}
+	if postingIndex < 0 || postingIndex >= len(tx.Postings) {
+		return Edit{}, fmt.Errorf("posting index out of range: %d", postingIndex)
+	}
+	if !accountNameRegex.MatchString(account) {
+		return Edit{}, fmt.Errorf("invalid account name: %s", account)
+	}
+
+	posting := tx.Postings[postingIndex]
+	if tx.FilePath == "" || posting.LineNumber == 0 {
+		return Edit{}, fmt.Errorf("transaction has no source location")
+	}
+
+	lines, err := w.file.store.readLines(tx.FilePath)
+	if err != nil {
+		return Edit{}, err
+	}
+	if posting.LineNumber > len(lines) {
+		return Edit{}, fmt.Errorf("line %d beyond end of %s", posting.LineNumber, tx.FilePath)
+	}
+
+	oldLine := lines[posting.LineNumber-1]
+	newLine, err := replacePostingAccount(oldLine, posting.Account, account)
+	if err != nil {
+		return Edit{}, fmt.Errorf("line %d: %w", posting.LineNumber, err)
+	}
+
+	return Edit{
+		FilePath:  tx.FilePath,
+		StartLine: posting.LineNumber,
+		OldLines:  []string{oldLine},
+		NewLines:  []string{newLine},
+	}, nil
+}
+
+// transactionHeaderRegex splits a transaction header into date, flag and the rest
+var transactionHeaderRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}\s+)(\*|!|txn)(.*)$`)
+
+// SetTransactionFlag creates an edit that changes a transaction's flag (e.g., "!" to "*")
+func (w *Writer) SetTransactionFlag(tx *Transaction, flag string) (Edit, error) {
+	if tx == nil {
+		return Edit{}, fmt.Errorf("transaction cannot be nil")
+	}
+	if flag != "*" && flag != "!" {
+		return Edit{}, fmt.Errorf("invalid transaction flag: %s", flag)
+	}
+	if tx.FilePath == "" || tx.LineNumber == 0 {
+		return Edit{}, fmt.Errorf("transaction has no source location")
+	}
+
+	lines, err := w.file.store.readLines(tx.FilePath)
+	if err != nil {
+		return Edit{}, err
+	}
+	if tx.LineNumber > len(lines) {
+		return Edit{}, fmt.Errorf("line %d beyond end of %s", tx.LineNumber, tx.FilePath)
+	}
+
+	oldLine := lines[tx.LineNumber-1]
+	match := transactionHeaderRegex.FindStringSubmatch(oldLine)
+	if match == nil {
+		return Edit{}, fmt.Errorf("line %d: not a transaction header", tx.LineNumber)
+	}
+
+	return Edit{
+		FilePath:  tx.FilePath,
+		StartLine: tx.LineNumber,
+		OldLines:  []string{oldLine},
+		NewLines:  []string{match[1] + flag + match[3]},
+	}, nil
+}
+
+// SetPayee builds an edit that renames a transaction's payee, keeping the rest of its
+// header as written
+func (w *Writer) SetPayee(tx *Transaction, payee string) (Edit, error) {
+	if tx == nil {
+		return Edit{}, fmt.Errorf("transaction cannot be nil")
+	}
+	if payee == "" || strings.ContainsAny(payee, "\"\n") {
+		return Edit{}, fmt.Errorf("invalid payee: %q", payee)
+	}
+	if tx.FilePath == "" || tx.LineNumber == 0 {
+		return Edit{}, fmt.Errorf("transaction has no source location")
+	}
+
+	lines, err := w.file.store.readLines(tx.FilePath)
+	if err != nil {
+		return Edit{}, err
+	}
+	if tx.LineNumber > len(lines) {
+		return Edit{}, fmt.Errorf("line %d beyond end of %s", tx.LineNumber, tx.FilePath)
+	}
+
+	// The payee is the first of two strings; a lone string is the narration
+	oldLine := lines[tx.LineNumber-1]
+	match := transactionRegex.FindStringSubmatchIndex(oldLine)
+	if match == nil {
+		return Edit{}, fmt.Errorf("line %d: not a transaction header", tx.LineNumber)
+	}
+	if match[6] < 0 {
+		return Edit{}, fmt.Errorf("line %d: transaction has no payee", tx.LineNumber)
+	}
+
+	return Edit{
+		FilePath:  tx.FilePath,
+		StartLine: tx.LineNumber,
+		OldLines:  []string{oldLine},
+		NewLines:  []string{oldLine[:match[6]] + payee + oldLine[match[7]:]},
+	}, nil
+}
+
+// AppendTransaction builds an edit that adds a transaction at the end of the main ledger file
+// A blank line separates it from the preceding entry
+func (w *Writer) AppendTransaction(tx *Transaction) (Edit, error) {
+	formatted, err := FormatTransaction(tx)
+	if err != nil {
+		return Edit{}, err
+	}
+
+	path := w.file.Path()
+	lines, err := w.file.store.readLines(path)
+	if err != nil {
+		return Edit{}, err
+	}
+	if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+		formatted = append([]string{""}, formatted...)
+	}
+
+	return Edit{
+		FilePath:  path,
+		StartLine: len(lines) + 1,
+		NewLines:  formatted,
+	}, nil
+}
+
+// UpdateTransaction builds an edit that rewrites a transaction in place
+// Postings in updated are matched to the original by LineNumber: 0 adds a posting, and
+// original postings missing from updated are removed along with their metadata lines.
+// Unchanged lines, comments and metadata are kept verbatim
+func (w *Writer) UpdateTransaction(original, updated *Transaction) (Edit, error) {
+	if original == nil {
+		return Edit{}, fmt.Errorf("transaction cannot be nil")
+	}
+	if err := validateTransaction(updated); err != nil {
+		return Edit{}, err
+	}
+	start, block, err := w.file.store.transactionBlock(original)
+	if err != nil {
+		return Edit{}, err
+	}
+
+	originals := make(map[int]Posting, len(original.Postings))
+	for _, posting := range original.Postings {
+		originals[posting.LineNumber] = posting
+	}
+	kept := make(map[int]Posting)
+	var added []Posting
+	for _, posting := range updated.Postings {
+		if posting.LineNumber == 0 {
+			added = append(added, posting)
+			continue
+		}
+		if _, ok := originals[posting.LineNumber]; !ok {
+			return Edit{}, fmt.Errorf("line %d is not a posting of this transaction", posting.LineNumber)
+		}
+		kept[posting.LineNumber] = posting
+	}
+
+	header, err := updateHeader(block[0], original, updated)
+	if err != nil {
+		return Edit{}, fmt.Errorf("line %d: %w", start+1, err)
+	}
+	newLines := []string{header}
+
+	indent := "  "
+	numberEnd := 0
+	insertAt := 0       // Index in newLines after the last kept posting and its metadata
+	postingIndent := -1 // Indent of the posting whose metadata lines follow
+	removing := false   // Whether those lines belong to a removed posting
+	for i := 1; i < len(block); i++ {
+		line := block[i]
+		lineNumber := start + i + 1
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		blank := strings.TrimSpace(line) == ""
+
+		if orig, ok := originals[lineNumber]; ok {
+			indent = line[:width]
+			if end := amountEnd(line, orig); end > 0 {
+				numberEnd = end
+			}
+			postingIndent = width
+			posting, keep := kept[lineNumber]
+			removing = !keep
+			if removing {
+				continue
+			}
+			rewritten, err := rewritePosting(line, orig, posting)
+			if err != nil {
+				return Edit{}, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			newLines = append(newLines, rewritten)
+			insertAt = len(newLines)
+			continue
+		}
+
+		if !blank && postingIndent >= 0 && width > postingIndent {
+			// Metadata or comment belonging to the posting above
+			if removing {
+				continue
+			}
+			newLines = append(newLines, line)
+			insertAt = len(newLines)
+			continue
+		}
+		if !blank {
+			postingIndent = -1
+			removing = false
+		}
+		newLines = append(newLines, line)
+	}
+
+	if insertAt == 0 {
+		insertAt = len(newLines)
+	}
+	var addedLines []string
+	for _, posting := range added {
+		addedLines = append(addedLines, formatPosting(indent, posting, numberEnd))
+		addedLines = append(addedLines, formatMetadata(posting.Metadata, indent+"  ")...)
+	}
+	newLines = append(newLines[:insertAt], append(addedLines, newLines[insertAt:]...)...)
+
+	return Edit{
+		FilePath:  original.FilePath,
+		StartLine: start + 1,
+		OldLines:  block,
+		NewLines:  newLines,
+	}, nil
+}
+
+// DeleteTransaction builds an edit that removes a transaction and its postings
+// One blank separator line goes with it so the surrounding entries stay evenly spaced
+func (w *Writer) DeleteTransaction(tx *Transaction) (Edit, error) {
+	if tx == nil {
+		return Edit{}, fmt.Errorf("transaction cannot be nil")
+	}
+	start, block, err := w.file.store.transactionBlock(tx)
+	if err != nil {
+		return Edit{}, err
+	}
+
+	lines, err := w.file.store.readLines(tx.FilePath)
+	if err != nil {
+		return Edit{}, err
+	}
+	end := start + len(block)
+	if start == 0 || strings.TrimSpace(lines[start-1]) == "" {
+		switch {
+		case end < len(lines) && strings.TrimSpace(lines[end]) == "":
+			end++
+		case end == len(lines) && start > 0:
+			// Last entry in the file: drop the separator above it instead
+			start--
+		}
+	}
+
+	return Edit{
+		FilePath:  tx.FilePath,
+		StartLine: start + 1,
+		OldLines:  lines[start:end],
+	}, nil
+}
+
+// TransactionSource returns a transaction's lines as written in the ledger,
+// including its metadata and comments between postings
+func (f *File) TransactionSource(tx *Transaction) ([]string, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("transaction cannot be nil")
+	}
+	_, block, err := f.store.transactionBlock(tx)
+	return block, err
+}
+
+// transactionBlock returns the 0-based start line and the lines of a transaction:
+// its header and every following indented line, excluding trailing blank lines
+func (s *Store) transactionBlock(tx *Transaction) (int, []string, error) {
+	if tx.FilePath == "" || tx.LineNumber == 0 {
+		return 0, nil, fmt.Errorf("transaction has no source location")
+	}
+	lines, err := s.readLines(tx.FilePath)
+	if err != nil {
+		return 0, nil, err
+	}
+	start := tx.LineNumber - 1
+	if start >= len(lines) {
+		return 0, nil, fmt.Errorf("line %d beyond end of %s", tx.LineNumber, tx.FilePath)
+	}
+	if !transactionHeaderRegex.MatchString(lines[start]) {
+		return 0, nil, fmt.Errorf("line %d: not a transaction header", tx.LineNumber)
+	}
+
+	end := start + 1
+	for i := start + 1; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			break
+		}
+		end = i + 1
+	}
+	return start, lines[start:end], nil
+}
+
+// updateHeader regenerates a header line when the header fields changed
+// A trailing comment on the original line is kept
+func updateHeader(line string, original, updated *Transaction) (string, error) {
+	flag := updated.Flag
+	if flag == "" {
+		flag = original.Flag
+	}
+	if original.Date.Equal(updated.Date) && original.Flag == flag &&
+		original.Payee == updated.Payee && original.Narration == updated.Narration &&
+		slices.Equal(original.Tags, updated.Tags) && slices.Equal(original.Links, updated.Links) {
+		return line, nil
+	}
+
+	match := transactionRegex.FindStringSubmatch(line)
+	if match == nil {
+		return "", fmt.Errorf("not a transaction header")
+	}
+	header := *updated
+	header.Flag = flag
+	result := formatHeader(&header)
+	if i := strings.Index(match[5], ";"); i >= 0 {
+		result += " " + match[5][i:]
+	}
+	return result, nil
+}
+
+// rewritePosting updates a posting line's account and amount
+// Anything after the amount (cost, price, comment) is kept
+func rewritePosting(line string, orig, updated Posting) (string, error) {
+	sameAmount := amountsEqual(orig.Amount, updated.Amount)
+	if sameAmount && orig.Account == updated.Account {
+		return line, nil
+	}
+	if sameAmount {
+		return replacePostingAccount(line, orig.Account, updated.Account)
+	}
+
+	width := len(line) - len(strings.TrimLeft(line, " \t"))
+	rest := line[width:]
+	if !strings.HasPrefix(rest, orig.Account) {
+		return "", fmt.Errorf("posting account '%s' not found", orig.Account)
+	}
+	tail := strings.TrimLeft(rest[len(orig.Account):], " \t")
+	numberEnd := amountEnd(line, orig)
+	if orig.Amount != nil {
+		if match := amountRegex.FindString(tail); match != "" {
+			tail = tail[len(match):]
+		}
+	} else if tail != "" {
+		tail = " " + tail
+	}
+	if updated.Amount == nil {
+		// Costs and prices need an amount, so only a comment survives
+		tail = ""
+		if i := strings.Index(line[width+len(orig.Account):], ";"); i >= 0 {
+			tail = " " + line[width+len(orig.Account)+i:]
+		}
+	}
+
+	return formatPosting(line[:width], Posting{Account: updated.Account, Amount: updated.Amount}, numberEnd) + tail, nil
+}
+
+// amountEnd returns the column just after a posting line's number, or 0 if it has none
+func amountEnd(line string, posting Posting) int {
+	if posting.Amount == nil {
+		return 0
+	}
+	i := strings.Index(line, posting.Account)
+	if i < 0 {
+		return 0
+	}
+	after := line[i+len(posting.Account):]
+	trimmed := strings.TrimLeft(after, " \t")
+	match := amountRegex.FindStringSubmatch(trimmed)
+	if match == nil {
+		return 0
+	}
+	return len(line) - len(trimmed) + len(match[1])
+}
+
+// amountsEqual reports whether two optional amounts are the same
+func amountsEqual(a, b *Amount) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Number.Equal(b.Number) && a.Commodity == b.Commodity
+}
+
+// postingAmountColumn is the minimum width of the account column in formatted postings
+const postingAmountColumn = 40
+
+// FormatTransaction renders a transaction as ledger lines
+// Amounts are right-aligned in a common column; at most one posting may omit its amount
+func FormatTransaction(tx *Transaction) ([]string, error) {
+	if err := validateTransaction(tx); err != nil {
+		return nil, err
+	}
+
+	lines := []string{formatHeader(tx)}
+	lines = append(lines, formatMetadata(tx.Metadata, "  ")...)
+
+	accountWidth := postingAmountColumn
+	numberWidth := 0
+	for _, posting := range tx.Postings {
+		if len(posting.Account) > accountWidth {
+			accountWidth = len(posting.Account)
+		}
+		if posting.Amount != nil && len(formatNumber(posting.Amount.Number)) > numberWidth {
+			numberWidth = len(formatNumber(posting.Amount.Number))
+		}
+	}
+
+	numberEnd := 2 + accountWidth + 2 + numberWidth
+	for _, posting := range tx.Postings {
+		lines = append(lines, formatPosting("  ", posting, numberEnd))
+		lines = append(lines, formatMetadata(posting.Metadata, "    ")...)
+	}
+	return lines, nil
+}
+
+// FormatPrice renders a price directive as a ledger line
+func FormatPrice(price Price) (string, error) {
+	if !IsValidCommodity(price.Commodity) || !IsValidCommodity(price.Amount.Commodity) {
+		return "", fmt.Errorf("invalid price of %q in %q", price.Commodity, price.Amount.Commodity)
+	}
+	if !price.Amount.Number.IsPositive() {
+		return "", fmt.Errorf("price of %s must be positive, got %s", price.Commodity, price.Amount.Number)
+	}
+	return fmt.Sprintf("%s price %s %s %s", price.Date.Format("2006-01-02"), price.Commodity, formatNumber(price.Amount.Number), price.Amount.Commodity), nil
+}
+
+// formatHeader renders the first line of a transaction
+func formatHeader(tx *Transaction) string {
+	flag := tx.Flag
+	if flag == "" {
+		flag = "*"
+	}
+	header := tx.Date.Format("2006-01-02") + " " + flag
+	if tx.Payee != "" {
+		header += ` "` + tx.Payee + `"`
+	}
+	header += ` "` + tx.Narration + `"`
+	for _, tag := range tx.Tags {
+		header += " #" + tag
+	}
+	for _, link := range tx.Links {
+		header += " ^" + link
+	}
+	return header
+}
+
+// formatPosting renders a posting line with its number ending at column numberEnd
+// A numberEnd of 0 uses the default amount column
+func formatPosting(indent string, posting Posting, numberEnd int) string {
+	line := indent + posting.Account
+	if posting.Amount == nil {
+		return line
+	}
+
+	number := formatNumber(posting.Amount.Number)
+	if numberEnd <= 0 {
+		numberEnd = len(indent) + postingAmountColumn + 2 + len(number)
+	}
+	gap := numberEnd - len(line) - len(number)
+	if gap < 2 {
+		gap = 2
+	}
+	line += strings.Repeat(" ", gap) + number + " " + posting.Amount.Commodity
+	if posting.Cost != nil {
+		line += fmt.Sprintf(" {%s %s}", formatNumber(posting.Cost.Number), posting.Cost.Commodity)
+	}
+	if posting.Price != nil {
+		line += fmt.Sprintf(" @ %s %s", formatNumber(posting.Price.Number), posting.Price.Commodity)
+	}
+	return line
+}
+
+// validateTransaction checks that a transaction can be written and parsed back
+func validateTransaction(tx *Transaction) error {
+	if tx == nil {
+		return fmt.Errorf("transaction cannot be nil")
+	}
+	if tx.Date.IsZero() {
+		return fmt.Errorf("transaction date is required")
+	}
+	if tx.Flag != "" && tx.Flag != "*" && tx.Flag != "!" {
+		return fmt.Errorf("invalid transaction flag: %s", tx.Flag)
+	}
+	if strings.ContainsAny(tx.Payee, "\"\n") || strings.ContainsAny(tx.Narration, "\"\n") {
+		return fmt.Errorf("payee and narration cannot contain quotes or newlines")
+	}
+	if len(tx.Postings) < 2 {
+		return fmt.Errorf("a transaction needs at least two postings")
+	}
+
+	elided := 0
+	for _, posting := range tx.Postings {
+		if !accountNameRegex.MatchString(posting.Account) {
+			return fmt.Errorf("invalid account name: %s", posting.Account)
+		}
+		if posting.Amount == nil {
+			elided++
+			continue
+		}
+		if !commodityNameRegex.MatchString(posting.Amount.Commodity) {
+			return fmt.Errorf("invalid commodity for %s: %q", posting.Account, posting.Amount.Commodity)
+		}
+	}
+	if elided > 1 {
+		return fmt.Errorf("only one posting may omit its amount")
+	}
+	return nil
+}
+
+// commodityNameRegex validates a commodity written by the writer
+var commodityNameRegex = regexp.MustCompile(`^[A-Z][A-Z0-9._'-]{0,22}[A-Z0-9]$`)
+
+// formatNumber renders an amount with at least two decimal places
+func formatNumber(d decimal.Decimal) string {
+	if d.Exponent() >= -2 {
+		return d.StringFixed(2)
+	}
+	return d.String()
+}
+
+// formatMetadata renders metadata as sorted key: "value" lines
+func formatMetadata(metadata map[string]string, indent string) []string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf(`%s%s: "%s"`, indent, k, metadata[k]))
+	}
+	return lines
+}
+
+// replacePostingAccount swaps the account in a posting line, preserving indentation
+// and keeping the amount in the same column when there is room
+func replacePostingAccount(line, oldAccount, newAccount string) (string, error) {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	rest := line[indent:]
+	if !strings.HasPrefix(rest, oldAccount) {
+		return "", fmt.Errorf("posting account '%s' not found", oldAccount)
+	}
+
+	tail := rest[len(oldAccount):]
+	trimmed := strings.TrimLeft(tail, " \t")
+	if trimmed == "" {
+		return line[:indent] + newAccount, nil
+	}
+
+	gap := len(tail) - len(trimmed) - (len(newAccount) - len(oldAccount))
+	if gap < 2 {
+		gap = 2
+	}
+	return line[:indent] + newAccount + strings.Repeat(" ", gap) + trimmed, nil
+}
+
+// applyEdit verifies and applies one edit to a file's lines
+func applyEdit(lines []string, edit Edit) ([]string, error) {
+	start := edit.StartLine - 1
+	if start < 0 || start+len(edit.OldLines) > len(lines) {
+		return nil, fmt.Errorf("%s:%d: edit out of range", edit.FilePath, edit.StartLine)
+	}
+	for i, old := range edit.OldLines {
+		if lines[start+i] != old {
+			return nil, fmt.Errorf("%s:%d: file changed since it was read", edit.FilePath, edit.StartLine+i)
+		}
+	}
+
+	result := make([]string, 0, len(lines)-len(edit.OldLines)+len(edit.NewLines))
+	result = append(result, lines[:start]...)
+	result = append(result, edit.NewLines...)
+	result = append(result, lines[start+len(edit.OldLines):]...)
+	return result, nil
+}
+
+// readLines reads a file into lines without trailing newlines
+func (s *Store) readLines(path string) ([]string, error) {
+	data, err := s.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	content := strings.TrimSuffix(string(data), "\n")
+	if content == "" {
+		return []string{}, nil
+	}
+	return strings.Split(content, "\n"), nil
+}
+
+// writeLines atomically replaces a file's contents, preserving its permissions
+func (s *Store) writeLines(path string, lines []string) error {
+	content := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		content += "\n"
+	}
+	return s.WriteFile(path, []byte(content))
+}
@@ -0,0 +1,656 @@
+package beancount
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWriterSetPostingAccount(t *testing.T) {
+	content := `; header comment
+2025-01-01 * "Coffee Shop" "Morning coffee"
+  Assets:Checking          -5.00 USD
+  Expenses:Uncategorized    5.00 USD ; keep me
+`
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	tx, err := f.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	if tx.FilePath != path {
+		t.Errorf("expected file path %s, got %s", path, tx.FilePath)
+	}
+	if tx.Postings[1].LineNumber != 4 {
+		t.Errorf("expected posting on line 4, got %d", tx.Postings[1].LineNumber)
+	}
+
+	w := NewWriter(f)
+	edit, err := w.SetPostingAccount(tx, 1, "Expenses:Food:Coffee")
+	if err != nil {
+		t.Fatalf("failed to build edit: %v", err)
+	}
+	if err := w.Apply(edit); err != nil {
+		t.Fatalf("failed to apply edit: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	expected := "  Expenses:Food:Coffee      5.00 USD ; keep me"
+	if !strings.Contains(string(data), expected+"\n") {
+		t.Errorf("expected aligned posting %q, got:\n%s", expected, data)
+	}
+	if !strings.HasPrefix(string(data), "; header comment\n") {
+		t.Error("expected comments to be preserved")
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions 0600, got %v", info.Mode().Perm())
+	}
+
+	// The index is reloaded after writing
+	tx, err = f.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	if tx.Postings[1].Account != "Expenses:Food:Coffee" {
+		t.Errorf("expected reloaded account, got %s", tx.Postings[1].Account)
+	}
+
+	// Undo restores the original text exactly
+	if err := w.Apply(edit.Inverse()); err != nil {
+		t.Fatalf("failed to apply inverse: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != content {
+		t.Errorf("expected original content after undo, got:\n%s", data)
+	}
+}
+
+func TestWriterSetTransactionFlag(t *testing.T) {
+	content := `2025-01-01 ! "Coffee Shop" "Morning coffee" #review
+  Assets:Checking          -5.00 USD
+  Expenses:Uncategorized    5.00 USD
+`
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	tx, err := f.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+
+	w := NewWriter(f)
+	flagEdit, err := w.SetTransactionFlag(tx, "*")
+	if err != nil {
+		t.Fatalf("failed to build flag edit: %v", err)
+	}
+	accountEdit, err := w.SetPostingAccount(tx, 1, "Expenses:Food:Coffee")
+	if err != nil {
+		t.Fatalf("failed to build account edit: %v", err)
+	}
+	if err := w.ApplyAll([]Edit{flagEdit, accountEdit}); err != nil {
+		t.Fatalf("failed to apply edits: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), `2025-01-01 * "Coffee Shop" "Morning coffee" #review`+"\n") {
+		t.Errorf("expected flag cleared, got:\n%s", data)
+	}
+
+	if _, err := w.SetTransactionFlag(tx, "?"); err == nil {
+		t.Error("expected error for invalid flag")
+	}
+}
+
+func TestWriterSetPayee(t *testing.T) {
+	content := `2025-01-01 * "AMZN Mktp US*2K3" "Books" #reading ; gift
+  Assets:Checking          -25.00 USD
+  Expenses:Books            25.00 USD
+
+2025-01-02 * "Cash withdrawal"
+  Assets:Cash               40.00 USD
+  Assets:Checking          -40.00 USD
+`
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	tx, err := f.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	w := NewWriter(f)
+	edit, err := w.SetPayee(tx, "Amazon")
+	if err != nil {
+		t.Fatalf("failed to build payee edit: %v", err)
+	}
+	if want := `2025-01-01 * "Amazon" "Books" #reading ; gift`; edit.NewLines[0] != want {
+		t.Errorf("got %q, want %q", edit.NewLines[0], want)
+	}
+	if _, err := w.SetPayee(tx, `Say "hi"`); err == nil {
+		t.Error("expected error for a payee with quotes")
+	}
+
+	// A transaction with only a narration has no payee to rename
+	noPayee, err := f.GetTransaction(1)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	if _, err := w.SetPayee(noPayee, "Bank"); err == nil {
+		t.Error("expected error for a transaction without payee")
+	}
+}
+
+func TestWriterApplyAllRejectsStaleEdits(t *testing.T) {
+	content := "line one\nline two\nline three\n"
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	w := NewWriter(f)
+	edits := []Edit{
+		{FilePath: path, StartLine: 1, OldLines: []string{"line one"}, NewLines: []string{"LINE ONE"}},
+		{FilePath: path, StartLine: 3, OldLines: []string{"something else"}, NewLines: []string{"LINE THREE"}},
+	}
+	if err := w.ApplyAll(edits); err == nil {
+		t.Fatal("expected error for stale edit")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != content {
+		t.Errorf("expected file untouched after failed batch, got:\n%s", data)
+	}
+
+	// Multi-line edits that change the line count apply bottom-up
+	edits = []Edit{
+		{FilePath: path, StartLine: 1, OldLines: []string{"line one"}, NewLines: []string{"a", "b"}},
+		{FilePath: path, StartLine: 3, OldLines: []string{"line three"}, NewLines: nil},
+	}
+	if err := w.ApplyAll(edits); err != nil {
+		t.Fatalf("failed to apply edits: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != "a\nb\nline two\n" {
+		t.Errorf("unexpected content:\n%s", data)
+	}
+}
+
+func TestWriterReadOnly(t *testing.T) {
+	content := "line one\n"
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	w := NewWriter(f)
+	w.SetReadOnly(true)
+	edit := Edit{FilePath: path, StartLine: 1, OldLines: []string{"line one"}, NewLines: []string{"LINE ONE"}}
+	if err := w.Apply(edit); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != content {
+		t.Errorf("expected file untouched while read-only, got:\n%s", data)
+	}
+
+	w.SetReadOnly(false)
+	if err := w.Apply(edit); err != nil {
+		t.Fatalf("failed to apply edit: %v", err)
+	}
+}
+
+func TestWriterBackup(t *testing.T) {
+	content := "line one\n"
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	w := NewWriter(f)
+	var backedUp []string
+	w.SetBackup(func(p string) error {
+		data, _ := os.ReadFile(p)
+		backedUp = append(backedUp, string(data))
+		return nil
+	})
+	edit := Edit{FilePath: path, StartLine: 1, OldLines: []string{"line one"}, NewLines: []string{"LINE ONE"}}
+	if err := w.Apply(edit); err != nil {
+		t.Fatalf("failed to apply edit: %v", err)
+	}
+	if len(backedUp) != 1 || backedUp[0] != content {
+		t.Errorf("expected the file backed up before the write, got %q", backedUp)
+	}
+
+	// A failed backup stops the write
+	w.SetBackup(func(string) error { return errors.New("disk full") })
+	edit = Edit{FilePath: path, StartLine: 1, OldLines: []string{"LINE ONE"}, NewLines: []string{"line 1"}}
+	if err := w.Apply(edit); err == nil {
+		t.Fatal("expected the failed backup's error")
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "LINE ONE\n" {
+		t.Errorf("expected file untouched after a failed backup, got:\n%s", data)
+	}
+}
+
+func TestWriterAfterWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte("line one\n"), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	w := NewWriter(f)
+	var written []string
+	w.SetAfterWrite(func(p string) error {
+		data, _ := os.ReadFile(p)
+		written = append(written, string(data))
+		return errors.New("server unreachable")
+	})
+	edit := Edit{FilePath: path, StartLine: 1, OldLines: []string{"line one"}, NewLines: []string{"LINE ONE"}}
+	if err := w.Apply(edit); err == nil || err.Error() != "server unreachable" {
+		t.Fatalf("expected the hook's error, got %v", err)
+	}
+	if len(written) != 1 || written[0] != "LINE ONE\n" {
+		t.Errorf("expected the hook called after the write, got %q", written)
+	}
+}
+
+func TestWriterAppendTransaction(t *testing.T) {
+	content := `2025-01-01 open Assets:Checking
+2025-01-02 * "Coffee Shop" "Morning coffee"
+  Assets:Checking          -5.00 USD
+  Expenses:Food:Coffee      5.00 USD`
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	tx := &Transaction{
+		Date:      time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC),
+		Payee:     "Grocer",
+		Narration: "Weekly shop",
+		Tags:      []string{"home"},
+		Metadata:  map[string]string{"receipt": "123"},
+		Postings: []Posting{
+			{Account: "Expenses:Food:Groceries", Amount: &Amount{Number: decimal.RequireFromString("42.5"), Commodity: "USD"}},
+			{Account: "Assets:Checking"},
+		},
+	}
+
+	w := NewWriter(f)
+	edit, err := w.AppendTransaction(tx)
+	if err != nil {
+		t.Fatalf("failed to build edit: %v", err)
+	}
+	if edit.StartLine != 5 || len(edit.OldLines) != 0 {
+		t.Errorf("expected insert at line 5, got line %d replacing %d lines", edit.StartLine, len(edit.OldLines))
+	}
+	if err := w.Apply(edit); err != nil {
+		t.Fatalf("failed to apply edit: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	expected := content + `
+
+2025-01-03 * "Grocer" "Weekly shop" #home
+  receipt: "123"
+  Expenses:Food:Groceries                   42.50 USD
+  Assets:Checking
+`
+	if string(data) != expected {
+		t.Errorf("unexpected content:\n%s", data)
+	}
+
+	if f.TransactionCount() != 2 {
+		t.Fatalf("expected 2 transactions after append, got %d", f.TransactionCount())
+	}
+	added, err := f.GetTransaction(1)
+	if err != nil {
+		t.Fatalf("failed to read appended transaction: %v", err)
+	}
+	if added.Payee != "Grocer" || len(added.Postings) != 2 || !added.Postings[0].Amount.Number.Equal(decimal.RequireFromString("42.50")) {
+		t.Errorf("appended transaction did not round-trip: %+v", added)
+	}
+
+	// The inverse removes the appended lines again
+	if err := w.Apply(edit.Inverse()); err != nil {
+		t.Fatalf("failed to undo append: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != content+"\n" {
+		t.Errorf("expected original content after undo, got:\n%s", data)
+	}
+}
+
+func TestWriterUpdateTransaction(t *testing.T) {
+	content := `2025-01-01 open Assets:Checking
+
+2025-01-02 * "Cafe" "Lunch" #work ; paid by card
+  receipt: "r-1"
+  Assets:Checking          -12.00 USD ; card
+  Expenses:Food:Dining      10.00 USD
+    note: "main course"
+  ; split for the tip
+  Expenses:Misc              2.00 USD
+2025-01-03 * "Next" "Untouched"
+  Assets:Checking  -1.00 USD
+  Expenses:Misc
+`
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	original, err := f.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+
+	// Change the amounts, drop the misc posting and add a tip posting
+	updated := *original
+	updated.Narration = "Team lunch"
+	updated.Postings = []Posting{
+		{Account: "Assets:Checking", Amount: &Amount{Number: decimal.RequireFromString("-15"), Commodity: "USD"}, LineNumber: original.Postings[0].LineNumber},
+		original.Postings[1],
+		{Account: "Expenses:Food:Tips", Amount: &Amount{Number: decimal.RequireFromString("5"), Commodity: "USD"}},
+	}
+
+	w := NewWriter(f)
+	edit, err := w.UpdateTransaction(original, &updated)
+	if err != nil {
+		t.Fatalf("failed to build edit: %v", err)
+	}
+	if err := w.Apply(edit); err != nil {
+		t.Fatalf("failed to apply edit: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	expected := `2025-01-01 open Assets:Checking
+
+2025-01-02 * "Cafe" "Team lunch" #work ; paid by card
+  receipt: "r-1"
+  Assets:Checking          -15.00 USD ; card
+  Expenses:Food:Dining      10.00 USD
+    note: "main course"
+  Expenses:Food:Tips         5.00 USD
+  ; split for the tip
+2025-01-03 * "Next" "Untouched"
+  Assets:Checking  -1.00 USD
+  Expenses:Misc
+`
+	if string(data) != expected {
+		t.Errorf("unexpected content:\n%s", data)
+	}
+
+	// The inverse restores the original text exactly
+	if err := w.Apply(edit.Inverse()); err != nil {
+		t.Fatalf("failed to undo edit: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != content {
+		t.Errorf("expected original content after undo, got:\n%s", data)
+	}
+
+	// An unchanged transaction produces an identical block
+	original, _ = f.GetTransaction(0)
+	edit, err = w.UpdateTransaction(original, original)
+	if err != nil {
+		t.Fatalf("failed to build edit: %v", err)
+	}
+	if strings.Join(edit.OldLines, "\n") != strings.Join(edit.NewLines, "\n") {
+		t.Errorf("expected no changes, got:\n%s", strings.Join(edit.NewLines, "\n"))
+	}
+
+	foreign := updated
+	foreign.Postings = append([]Posting{{Account: "Assets:Checking", LineNumber: 99}}, updated.Postings[1:]...)
+	if _, err := w.UpdateTransaction(original, &foreign); err == nil {
+		t.Error("expected error for a posting from another transaction")
+	}
+}
+
+func TestWriterDeleteTransaction(t *testing.T) {
+	content := `2025-01-01 open Assets:Checking
+
+2025-01-02 * "First" "One"
+  Assets:Checking  -1.00 USD
+  ; a comment
+  Expenses:Misc
+
+2025-01-03 * "Second" "Two"
+  Assets:Checking  -2.00 USD
+  Expenses:Misc
+
+2025-01-04 * "Third" "Three"
+  Assets:Checking  -3.00 USD
+  Expenses:Misc
+`
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	f, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+	w := NewWriter(f)
+
+	tests := []struct {
+		index    int
+		expected string
+	}{
+		{0, `2025-01-01 open Assets:Checking
+
+2025-01-03 * "Second" "Two"
+  Assets:Checking  -2.00 USD
+  Expenses:Misc
+
+2025-01-04 * "Third" "Three"
+  Assets:Checking  -3.00 USD
+  Expenses:Misc
+`},
+		{2, `2025-01-01 open Assets:Checking
+
+2025-01-02 * "First" "One"
+  Assets:Checking  -1.00 USD
+  ; a comment
+  Expenses:Misc
+
+2025-01-03 * "Second" "Two"
+  Assets:Checking  -2.00 USD
+  Expenses:Misc
+`},
+	}
+
+	for _, tt := range tests {
+		tx, err := f.GetTransaction(tt.index)
+		if err != nil {
+			t.Fatalf("failed to get transaction %d: %v", tt.index, err)
+		}
+		edit, err := w.DeleteTransaction(tx)
+		if err != nil {
+			t.Fatalf("failed to build edit: %v", err)
+		}
+		if err := w.Apply(edit); err != nil {
+			t.Fatalf("failed to apply edit: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != tt.expected {
+			t.Errorf("deleting transaction %d: unexpected content:\n%s", tt.index, data)
+		}
+		if f.TransactionCount() != 2 {
+			t.Errorf("expected 2 transactions after delete, got %d", f.TransactionCount())
+		}
+
+		if err := w.Apply(edit.Inverse()); err != nil {
+			t.Fatalf("failed to restore transaction: %v", err)
+		}
+		data, _ = os.ReadFile(path)
+		if string(data) != content {
+			t.Errorf("expected original content after restore, got:\n%s", data)
+		}
+	}
+}
+
+func TestFormatTransactionValidation(t *testing.T) {
+	date := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+	usd := func(n string) *Amount {
+		return &Amount{Number: decimal.RequireFromString(n), Commodity: "USD"}
+	}
+
+	tests := []struct {
+		name string
+		tx   *Transaction
+	}{
+		{"nil", nil},
+		{"no date", &Transaction{Narration: "x", Postings: []Posting{{Account: "Assets:A", Amount: usd("1")}, {Account: "Assets:B"}}}},
+		{"one posting", &Transaction{Date: date, Narration: "x", Postings: []Posting{{Account: "Assets:A", Amount: usd("1")}}}},
+		{"two elided", &Transaction{Date: date, Narration: "x", Postings: []Posting{{Account: "Assets:A"}, {Account: "Assets:B"}}}},
+		{"bad account", &Transaction{Date: date, Narration: "x", Postings: []Posting{{Account: "assets", Amount: usd("1")}, {Account: "Assets:B"}}}},
+		{"quote in payee", &Transaction{Date: date, Payee: `Joe's "Diner"`, Narration: "x", Postings: []Posting{{Account: "Assets:A", Amount: usd("1")}, {Account: "Assets:B"}}}},
+		{"bad commodity", &Transaction{Date: date, Narration: "x", Postings: []Posting{{Account: "Assets:A", Amount: &Amount{Number: decimal.NewFromInt(1), Commodity: "usd"}}, {Account: "Assets:B"}}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := FormatTransaction(tt.tx); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+
+	lines, err := FormatTransaction(&Transaction{Date: date, Flag: "!", Narration: "Transfer", Postings: []Posting{
+		{Account: "Assets:Savings", Amount: usd("100")},
+		{Account: "Assets:Checking", Amount: usd("-100.125")},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines[0] != `2025-01-03 ! "Transfer"` {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "  100.00 USD") || !strings.HasSuffix(lines[2], " -100.125 USD") || len(lines[1]) != len(lines[2]) {
+		t.Errorf("expected aligned amounts, got %q and %q", lines[1], lines[2])
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	price := Price{
+		Date:      time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		Commodity: "EUR",
+		Amount:    Amount{Number: decimal.RequireFromString("1.0956"), Commodity: "USD"},
+	}
+	line, err := FormatPrice(price)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if line != "2024-01-05 price EUR 1.0956 USD" {
+		t.Errorf("unexpected line %q", line)
+	}
+	if parsed := parsePriceLine(line, 1); parsed == nil || !parsed.Amount.Number.Equal(price.Amount.Number) {
+		t.Errorf("expected the line to parse back, got %+v", parsed)
+	}
+
+	price.Amount.Number = decimal.Zero
+	if _, err := FormatPrice(price); err == nil {
+		t.Error("expected an error for a zero price")
+	}
+	price.Amount = Amount{Number: decimal.NewFromInt(1), Commodity: "usd"}
+	if _, err := FormatPrice(price); err == nil {
+		t.Error("expected an error for an invalid currency")
+	}
+}
+
+func TestReplacePostingAccount(t *testing.T) {
+	tests := []struct {
+		line     string
+		old      string
+		new      string
+		expected string
+	}{
+		{"  Expenses:Unknown", "Expenses:Unknown", "Expenses:Food", "  Expenses:Food"},
+		{"  Expenses:A  1.00 USD", "Expenses:A", "Expenses:Longer:Name", "  Expenses:Longer:Name  1.00 USD"},
+		{"\tExpenses:Misc    2 EUR", "Expenses:Misc", "Expenses:X", "\tExpenses:X       2 EUR"},
+	}
+
+	for _, tt := range tests {
+		got, err := replacePostingAccount(tt.line, tt.old, tt.new)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", tt.line, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, got)
+		}
+	}
+
+	if _, err := replacePostingAccount("  Assets:Cash  1 USD", "Expenses:Food", "Expenses:X"); err == nil {
+		t.Error("expected error when account does not match")
+	}
+}
@@ -0,0 +1,294 @@
+// Package budget reads spending budgets and compares them with actual postings
+//
+// Budgets come from fava-compatible custom directives in the ledger:
+//
+//	2024-01-01 custom "budget" Expenses:Food "monthly" 400.00 USD
+//
+// or from a YAML file (see LoadFile). A budget applies from its date until the next
+// budget for the same account; amounts are spread evenly over the days of their interval,
+// so a monthly budget covers a quarter three times over.
+package budget
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// Interval is the period a budget amount covers
+type Interval string
+
+const (
+	Daily     Interval = "daily"
+	Weekly    Interval = "weekly"
+	Monthly   Interval = "monthly"
+	Quarterly Interval = "quarterly"
+	Yearly    Interval = "yearly"
+)
+
+// Budget is an amount allowed for an account (and its subaccounts) per interval
+type Budget struct {
+	Account   string
+	Interval  Interval
+	Amount    decimal.Decimal
+	Commodity string
+	Start     time.Time // Zero when the budget applies from the beginning
+}
+
+// parseInterval validates an interval name
+func parseInterval(s string) (Interval, error) {
+	switch interval := Interval(strings.ToLower(s)); interval {
+	case Daily, Weekly, Monthly, Quarterly, Yearly:
+		return interval, nil
+	}
+	return "", fmt.Errorf("unknown budget interval %q (use daily, weekly, monthly, quarterly or yearly)", s)
+}
+
+// intervalDays returns the number of days in the interval that contains day
+func (i Interval) intervalDays(day time.Time) int {
+	var unit beancount.PeriodUnit
+	switch i {
+	case Daily:
+		return 1
+	case Weekly:
+		return 7
+	case Monthly:
+		unit = beancount.PeriodMonth
+	case Quarterly:
+		unit = beancount.PeriodQuarter
+	default:
+		unit = beancount.PeriodYear
+	}
+	period := beancount.PeriodContaining(unit, day)
+	return int(period.End().Sub(period.Start).Hours()/24 + 0.5)
+}
+
+// FromDirectives reads budgets from custom "budget" directives
+// Other custom directives are ignored
+func FromDirectives(customs []beancount.Custom) ([]Budget, error) {
+	var budgets []Budget
+	for _, custom := range customs {
+		if custom.Type != "budget" {
+			continue
+		}
+		if len(custom.Values) != 4 {
+			return nil, fmt.Errorf("line %d: budget needs an account, interval, amount and commodity", custom.LineNumber)
+		}
+		interval, err := parseInterval(custom.Values[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", custom.LineNumber, err)
+		}
+		amount, err := decimal.NewFromString(custom.Values[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid budget amount %q", custom.LineNumber, custom.Values[2])
+		}
+		budgets = append(budgets, Budget{
+			Account:   custom.Values[0],
+			Interval:  interval,
+			Amount:    amount,
+			Commodity: custom.Values[3],
+			Start:     custom.Date,
+		})
+	}
+	return budgets, nil
+}
+
+// File is the structure of a lima budgets YAML file
+//
+//	budgets:
+//	  - account: Expenses:Food
+//	    amount: 400
+//	    commodity: USD
+//	    interval: monthly   # default
+//	    start: 2024-01-01   # optional
+type File struct {
+	Budgets []BudgetYAML `yaml:"budgets"`
+}
+
+// BudgetYAML is a budget as stored in YAML
+type BudgetYAML struct {
+	Account   string `yaml:"account"`
+	Amount    string `yaml:"amount"`
+	Commodity string `yaml:"commodity"`
+	Interval  string `yaml:"interval,omitempty"`
+	Start     string `yaml:"start,omitempty"`
+}
+
+// LoadFile reads budgets from a YAML file
+func LoadFile(path string) ([]Budget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read budgets file: %w", err)
+	}
+	return ParseYAML(data)
+}
+
+// ParseYAML reads budgets from YAML data
+func ParseYAML(data []byte) ([]Budget, error) {
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse budgets: %w", err)
+	}
+
+	budgets := make([]Budget, 0, len(file.Budgets))
+	for i, entry := range file.Budgets {
+		if entry.Account == "" || entry.Commodity == "" {
+			return nil, fmt.Errorf("budget %d: account and commodity are required", i+1)
+		}
+		interval := Monthly
+		if entry.Interval != "" {
+			var err error
+			if interval, err = parseInterval(entry.Interval); err != nil {
+				return nil, fmt.Errorf("budget %d: %w", i+1, err)
+			}
+		}
+		amount, err := decimal.NewFromString(entry.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("budget %d: invalid amount %q", i+1, entry.Amount)
+		}
+		var start time.Time
+		if entry.Start != "" {
+			if start, err = time.Parse("2006-01-02", entry.Start); err != nil {
+				return nil, fmt.Errorf("budget %d: invalid start date %q", i+1, entry.Start)
+			}
+		}
+		budgets = append(budgets, Budget{
+			Account:   entry.Account,
+			Interval:  interval,
+			Amount:    amount,
+			Commodity: entry.Commodity,
+			Start:     start,
+		})
+	}
+	return budgets, nil
+}
+
+// Set holds budgets by account, each account's budgets ordered by start date
+type Set struct {
+	byAccount map[string][]Budget
+}
+
+// NewSet indexes budgets; a later budget for an account replaces the earlier one from its start
+func NewSet(budgets []Budget) Set {
+	s := Set{byAccount: make(map[string][]Budget)}
+	for _, b := range budgets {
+		s.byAccount[b.Account] = append(s.byAccount[b.Account], b)
+	}
+	for _, list := range s.byAccount {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Start.Before(list[j].Start) })
+	}
+	return s
+}
+
+// Empty reports whether the set has no budgets
+func (s Set) Empty() bool {
+	return len(s.byAccount) == 0
+}
+
+// Accounts returns the budgeted accounts in sorted order
+func (s Set) Accounts() []string {
+	accounts := make([]string, 0, len(s.byAccount))
+	for account := range s.byAccount {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// Amount returns the budget for an account over [start, end)
+// Each day contributes the budget in effect that day divided by the days in its interval
+func (s Set) Amount(account string, start, end time.Time) (decimal.Decimal, string, bool) {
+	list := s.byAccount[account]
+	if len(list) == 0 {
+		return decimal.Zero, "", false
+	}
+
+	total := decimal.Zero
+	commodity := list[len(list)-1].Commodity
+	active := false
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		var current *Budget
+		for i := range list {
+			if list[i].Start.After(day) {
+				break
+			}
+			current = &list[i]
+		}
+		if current == nil {
+			continue
+		}
+		active = true
+		commodity = current.Commodity
+		days := decimal.NewFromInt(int64(current.Interval.intervalDays(day)))
+		total = total.Add(current.Amount.Div(days))
+	}
+	return total.Round(2), commodity, active
+}
+
+// Line compares one account's budget with its actual spending in a period
+type Line struct {
+	Account   string
+	Commodity string
+	Budget    decimal.Decimal
+	Actual    decimal.Decimal
+}
+
+// Remaining returns how much of the budget is left; negative when overspent
+func (l Line) Remaining() decimal.Decimal {
+	return l.Budget.Sub(l.Actual)
+}
+
+// Progress returns actual spending as a fraction of the budget
+func (l Line) Progress() float64 {
+	if !l.Budget.IsPositive() {
+		if l.Actual.IsPositive() {
+			return 1
+		}
+		return 0
+	}
+	progress, _ := l.Actual.Div(l.Budget).Float64()
+	return progress
+}
+
+// Overspent reports whether spending exceeded the budget
+func (l Line) Overspent() bool {
+	return l.Actual.GreaterThan(l.Budget)
+}
+
+// Compare totals each budgeted account's postings in a period, including subaccounts
+// Postings in other commodities than the budget's are not counted
+func Compare(set Set, transactions []*beancount.Transaction, period beancount.Period) []Line {
+	balances := beancount.RollUpBalances(beancount.AccountBalances(inPeriod(transactions, period)))
+
+	var lines []Line
+	for _, account := range set.Accounts() {
+		amount, commodity, ok := set.Amount(account, period.Start, period.End())
+		if !ok {
+			continue
+		}
+		lines = append(lines, Line{
+			Account:   account,
+			Commodity: commodity,
+			Budget:    amount,
+			Actual:    balances[account][commodity],
+		})
+	}
+	return lines
+}
+
+// inPeriod returns the transactions dated within a period
+func inPeriod(transactions []*beancount.Transaction, period beancount.Period) []*beancount.Transaction {
+	var matched []*beancount.Transaction
+	for _, tx := range transactions {
+		if period.Contains(tx.Date) {
+			matched = append(matched, tx)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,131 @@
+package budget
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/shopspring/decimal"
+)
+
+func TestFromDirectives(t *testing.T) {
+	customs := []beancount.Custom{
+		{Date: beancounttest.Date("2024-01-01"), Type: "budget", Values: []string{"Expenses:Food", "monthly", "400.00", "USD"}},
+		{Date: beancounttest.Date("2024-01-01"), Type: "fava-option", Values: []string{"language", "en"}},
+	}
+	budgets, err := FromDirectives(customs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(budgets) != 1 {
+		t.Fatalf("expected 1 budget, got %d", len(budgets))
+	}
+	b := budgets[0]
+	if b.Account != "Expenses:Food" || b.Interval != Monthly || !b.Amount.Equal(decimal.NewFromInt(400)) || b.Commodity != "USD" {
+		t.Errorf("unexpected budget: %+v", b)
+	}
+
+	bad := []beancount.Custom{{Type: "budget", Values: []string{"Expenses:Food", "fortnightly", "1", "USD"}, LineNumber: 7}}
+	if _, err := FromDirectives(bad); err == nil || !strings.Contains(err.Error(), "line 7") {
+		t.Errorf("expected interval error with line number, got %v", err)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	budgets, err := ParseYAML([]byte(`budgets:
+  - account: Expenses:Food
+    amount: 400
+    commodity: USD
+  - account: Expenses:Travel
+    amount: "1200.00"
+    commodity: USD
+    interval: yearly
+    start: 2024-06-01
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(budgets) != 2 {
+		t.Fatalf("expected 2 budgets, got %d", len(budgets))
+	}
+	if budgets[0].Interval != Monthly || !budgets[0].Start.IsZero() {
+		t.Errorf("expected monthly budget from the beginning, got %+v", budgets[0])
+	}
+	if budgets[1].Interval != Yearly || !budgets[1].Start.Equal(beancounttest.Date("2024-06-01")) {
+		t.Errorf("unexpected yearly budget: %+v", budgets[1])
+	}
+
+	if _, err := ParseYAML([]byte("budgets:\n  - account: Expenses:Food\n    amount: 10\n")); err == nil {
+		t.Error("expected error for missing commodity")
+	}
+}
+
+func TestSetAmount(t *testing.T) {
+	set := NewSet([]Budget{
+		{Account: "Expenses:Food", Interval: Monthly, Amount: decimal.NewFromInt(400), Commodity: "USD", Start: beancounttest.Date("2024-01-01")},
+		{Account: "Expenses:Food", Interval: Monthly, Amount: decimal.NewFromInt(500), Commodity: "USD", Start: beancounttest.Date("2024-03-01")},
+		{Account: "Expenses:Coffee", Interval: Daily, Amount: decimal.NewFromInt(5), Commodity: "USD"},
+	})
+
+	tests := []struct {
+		account    string
+		start, end string
+		want       string
+	}{
+		{"Expenses:Food", "2024-02-01", "2024-03-01", "400"},
+		{"Expenses:Food", "2024-03-01", "2024-04-01", "500"},
+		{"Expenses:Food", "2024-01-01", "2024-04-01", "1300"},  // Quarter spanning the change
+		{"Expenses:Food", "2023-12-01", "2024-01-01", "0"},     // Before the first budget
+		{"Expenses:Coffee", "2024-02-01", "2024-03-01", "145"}, // 29 days of a leap February
+	}
+	for _, tt := range tests {
+		got, _, _ := set.Amount(tt.account, beancounttest.Date(tt.start), beancounttest.Date(tt.end))
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("%s %s..%s: got %s, want %s", tt.account, tt.start, tt.end, got, tt.want)
+		}
+	}
+
+	if _, _, ok := set.Amount("Expenses:Rent", beancounttest.Date("2024-01-01"), beancounttest.Date("2024-02-01")); ok {
+		t.Error("expected no budget for unbudgeted account")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	usd := func(n string) *beancount.Amount {
+		return &beancount.Amount{Number: decimal.RequireFromString(n), Commodity: "USD"}
+	}
+	transactions := []*beancount.Transaction{
+		{Date: beancounttest.Date("2024-03-02"), Postings: []beancount.Posting{
+			{Account: "Expenses:Food:Coffee", Amount: usd("30.00")},
+			{Account: "Assets:Checking"},
+		}},
+		{Date: beancounttest.Date("2024-03-10"), Postings: []beancount.Posting{
+			{Account: "Expenses:Food:Groceries", Amount: usd("420.00")},
+			{Account: "Assets:Checking"},
+		}},
+		{Date: beancounttest.Date("2024-02-10"), Postings: []beancount.Posting{
+			{Account: "Expenses:Food:Groceries", Amount: usd("99.00")},
+			{Account: "Assets:Checking"},
+		}},
+	}
+	set := NewSet([]Budget{
+		{Account: "Expenses:Food", Interval: Monthly, Amount: decimal.NewFromInt(400), Commodity: "USD"},
+		{Account: "Expenses:Rent", Interval: Monthly, Amount: decimal.NewFromInt(1000), Commodity: "USD"},
+	})
+
+	lines := Compare(set, transactions, beancount.PeriodContaining(beancount.PeriodMonth, beancounttest.Date("2024-03-15")))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	food := lines[0]
+	if food.Account != "Expenses:Food" || !food.Actual.Equal(decimal.NewFromInt(450)) {
+		t.Errorf("expected food actual 450 including subaccounts, got %+v", food)
+	}
+	if !food.Overspent() || !food.Remaining().Equal(decimal.NewFromInt(-50)) {
+		t.Errorf("expected food overspent by 50, got remaining %s", food.Remaining())
+	}
+	if rent := lines[1]; rent.Overspent() || rent.Progress() != 0 {
+		t.Errorf("expected untouched rent budget, got %+v", rent)
+	}
+}
@@ -0,0 +1,130 @@
+package categorizer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditApplied = "applied"
+	AuditStaged  = "staged"
+	AuditUndone  = "undone"
+)
+
+// AuditEntry records one automatic categorization decision
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Action     string    `json:"action"`
+	File       string    `json:"file"`
+	Line       int       `json:"line"`
+	Date       string    `json:"date"`
+	Payee      string    `json:"payee"`
+	OldAccount string    `json:"old_account"`
+	NewAccount string    `json:"new_account"`
+	Confidence float64   `json:"confidence"`
+	Source     string    `json:"source"`
+	PatternID  string    `json:"pattern_id,omitempty"`
+}
+
+// NewAuditEntry creates an audit entry for an auto-categorization candidate
+func NewAuditEntry(candidate AutoCandidate, action string) AuditEntry {
+	tx := candidate.Transaction
+	posting := tx.Postings[candidate.PostingIndex]
+
+	payee := tx.Payee
+	if payee == "" {
+		payee = tx.Narration
+	}
+
+	entry := AuditEntry{
+		Time:       time.Now(),
+		Action:     action,
+		File:       tx.FilePath,
+		Line:       posting.LineNumber,
+		Date:       tx.Date.Format("2006-01-02"),
+		Payee:      payee,
+		OldAccount: posting.Account,
+		NewAccount: candidate.Suggestion.Category,
+		Confidence: candidate.Suggestion.Confidence,
+		Source:     string(candidate.Suggestion.Source),
+	}
+	if candidate.Suggestion.Pattern != nil {
+		entry.PatternID = candidate.Suggestion.Pattern.ID
+	}
+	return entry
+}
+
+// AuditLog is an append-only JSON Lines log of automatic categorizations
+type AuditLog struct {
+	path string
+}
+
+// NewAuditLog creates an audit log writing to the given path
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Path returns the location of the log file
+func (a *AuditLog) Path() string {
+	return a.path
+}
+
+// Record appends entries to the log, creating the file and its directory if needed
+func (a *AuditLog) Record(entries ...AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write audit entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// Entries reads all entries from the log
+// A missing log file yields no entries
+func (a *AuditLog) Entries() ([]AuditEntry, error) {
+	f, err := os.Open(a.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("audit log line %d: %w", lineNumber, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,64 @@
+package categorizer
+
+import (
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// Auto-categorization modes
+const (
+	// AutoModeStage queues confident suggestions for one-key review
+	AutoModeStage = "stage"
+
+	// AutoModeWrite writes confident suggestions straight to the ledger
+	AutoModeWrite = "write"
+)
+
+// AutoCandidate is a suggestion confident enough to apply without review
+type AutoCandidate struct {
+	Transaction  *beancount.Transaction
+	PostingIndex int // Placeholder posting the suggested category replaces
+	Suggestion   *Suggestion
+}
+
+// AutoCandidates returns suggestions at or above the auto-categorize threshold
+// for transactions that post to a placeholder account
+// Returns nothing unless categorization and auto-categorization are both enabled
+func (c *Categorizer) AutoCandidates(transactions []*beancount.Transaction) ([]AutoCandidate, error) {
+	cfg := c.config.Categorization
+	if !cfg.Enabled || !cfg.AutoCategorize {
+		return nil, nil
+	}
+	return c.Candidates(transactions, cfg.AutoThreshold)
+}
+
+// Candidates returns suggestions with at least minConfidence for transactions that
+// post to a placeholder account, whatever the auto-categorize settings
+func (c *Categorizer) Candidates(transactions []*beancount.Transaction, minConfidence float64) ([]AutoCandidate, error) {
+	var candidates []AutoCandidate
+	for _, tx := range transactions {
+		postingIndex := PlaceholderPosting(tx)
+		if postingIndex < 0 {
+			continue
+		}
+
+		suggestion, err := c.Suggest(tx)
+		if err != nil {
+			return nil, err
+		}
+		if suggestion == nil || suggestion.Confidence < minConfidence {
+			continue
+		}
+		// Never "categorize" into another placeholder
+		if IsUncategorizedAccount(suggestion.Category) {
+			continue
+		}
+
+		candidates = append(candidates, AutoCandidate{
+			Transaction:  tx,
+			PostingIndex: postingIndex,
+			Suggestion:   suggestion,
+		})
+	}
+
+	return candidates, nil
+}
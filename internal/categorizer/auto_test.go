@@ -0,0 +1,114 @@
+package categorizer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+func TestCategorizer_AutoCandidates(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	cfg.Categorization.AutoThreshold = 0.7
+
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	txs := []*beancount.Transaction{
+		categorizedTx("NETFLIX.COM", "Expenses:Uncategorized"),
+		categorizedTx("NETFLIX.COM", "Expenses:Streaming"), // already categorized
+		categorizedTx("Local Shop", "Expenses:Uncategorized"),
+	}
+
+	// Disabled by default
+	candidates, err := c.AutoCandidates(txs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(candidates) != 0 {
+		t.Fatalf("Expected no candidates while auto-categorize is off, got %d", len(candidates))
+	}
+
+	cfg.Categorization.AutoCategorize = true
+	candidates, err = c.AutoCandidates(txs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("Expected 1 candidate, got %d", len(candidates))
+	}
+	if candidates[0].Transaction != txs[0] || candidates[0].PostingIndex != 1 {
+		t.Errorf("Expected placeholder posting of first transaction, got %+v", candidates[0])
+	}
+
+	// Threshold above the suggestion's confidence
+	cfg.Categorization.AutoThreshold = 0.95
+	candidates, _ = c.AutoCandidates(txs)
+	if len(candidates) != 0 {
+		t.Errorf("Expected no candidates above threshold, got %d", len(candidates))
+	}
+
+	// Candidates takes its own threshold and ignores the auto-categorize settings
+	cfg.Categorization.AutoCategorize = false
+	candidates, err = c.Candidates(txs, 0.7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Transaction != txs[0] {
+		t.Errorf("Expected the first transaction as the only candidate, got %d", len(candidates))
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "audit.log")
+	log := NewAuditLog(path)
+
+	entries, err := log.Entries()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Expected empty log, got %d entries", len(entries))
+	}
+
+	tx := categorizedTx("NETFLIX.COM", "Expenses:Uncategorized")
+	tx.Date = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	tx.FilePath = "/ledger.beancount"
+	tx.Postings[1].LineNumber = 12
+	candidate := AutoCandidate{
+		Transaction:  tx,
+		PostingIndex: 1,
+		Suggestion:   &Suggestion{Category: "Expenses:Streaming", Confidence: 0.97, Source: SourcePattern},
+	}
+
+	if err := log.Record(NewAuditEntry(candidate, AuditApplied)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := log.Record(NewAuditEntry(candidate, AuditUndone)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err = log.Entries()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Action != AuditApplied || entry.Line != 12 || entry.Date != "2025-03-01" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry.OldAccount != "Expenses:Uncategorized" || entry.NewAccount != "Expenses:Streaming" {
+		t.Errorf("Expected account change to be recorded, got %+v", entry)
+	}
+	if entries[1].Action != AuditUndone {
+		t.Errorf("Expected second entry to be an undo, got %s", entries[1].Action)
+	}
+}
@@ -1,8 +1,11 @@
 package categorizer
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/mmichie/lima/internal/beancount"
 	"github.com/mmichie/lima/pkg/config"
@@ -19,6 +22,24 @@ type Categorizer struct {
 
 	// patterns stores all loaded patterns
 	patterns []*Pattern
+
+	// knownAccounts is the ledger's account set used to validate categories
+	knownAccounts []string
+
+	// categoryWarnings holds the result of the last category validation
+	categoryWarnings []CategoryWarning
+
+	// loadWarnings lists patterns skipped by the last non-strict load
+	loadWarnings []PatternLoadWarning
+
+	// similarity suggests categories for payees no pattern matches (nil until trained)
+	similarity *SimilarityIndex
+
+	// llm is the optional language-model suggester (nil when disabled)
+	llm *LLMSuggester
+
+	// merchants is the built-in merchant knowledge base (nil when disabled)
+	merchants *MerchantDB
 }
 
 // New creates a new Categorizer with the given configuration
@@ -27,7 +48,9 @@ func New(cfg *config.Config) (*Categorizer, error) {
 		cfg = config.DefaultConfig()
 	}
 
-	loader := NewLoader()
+	loaderConfig := DefaultLoaderConfig()
+	loaderConfig.StrictMode = cfg.Categorization.StrictPatterns
+	loader := NewLoaderWithConfig(loaderConfig)
 
 	c := &Categorizer{
 		config:   cfg,
@@ -35,6 +58,30 @@ func New(cfg *config.Config) (*Categorizer, error) {
 		patterns: make([]*Pattern, 0),
 	}
 
+	if llm := cfg.Categorization.LLM; llm.Enabled {
+		c.llm = NewLLMSuggester(LLMConfig{
+			Endpoint:    llm.Endpoint,
+			Model:       llm.Model,
+			APIKey:      os.Getenv(llm.APIKeyEnv),
+			Timeout:     time.Duration(llm.TimeoutSeconds) * time.Second,
+			MinInterval: time.Minute / time.Duration(llm.RequestsPerMinute),
+		})
+	}
+
+	if m := cfg.Categorization.Merchants; m.Enabled {
+		db, err := DefaultMerchantDB()
+		if err != nil {
+			return nil, err
+		}
+		if m.File != "" {
+			if err := db.LoadFile(m.File); err != nil {
+				return nil, fmt.Errorf("failed to load merchants: %w", err)
+			}
+		}
+		db.SetAccountOverrides(m.Archetypes)
+		c.merchants = db
+	}
+
 	// Load patterns if file is configured
 	if cfg.Files.PatternsFile != "" {
 		if err := c.LoadPatterns(cfg.Files.PatternsFile); err != nil {
@@ -60,15 +107,64 @@ func (c *Categorizer) LoadPatterns(path string) error {
 	defer c.mu.Unlock()
 
 	c.patterns = patterns
+	c.loadWarnings = c.loader.Warnings()
+	c.validateCategoriesUnlocked()
 
 	// Create new matcher with loaded patterns
-	matcherConfig := MatcherConfig{
+	c.matcher = NewPatternMatcherWithConfig(patterns, c.matcherConfig())
+
+	return nil
+}
+
+// matcherConfig returns the matcher settings derived from the configuration
+func (c *Categorizer) matcherConfig() MatcherConfig {
+	return MatcherConfig{
 		EarlyExitThreshold: c.config.Categorization.ConfidenceThreshold,
 		MaxAlternatives:    3,
 	}
-	c.matcher = NewPatternMatcherWithConfig(patterns, matcherConfig)
+}
 
-	return nil
+// LoadWarnings returns the patterns skipped by the last load
+// Only non-strict loading (strict_patterns: false) skips patterns
+func (c *Categorizer) LoadWarnings() []PatternLoadWarning {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.loadWarnings
+}
+
+// SetKnownAccounts sets the ledger's account set and re-validates pattern categories
+func (c *Categorizer) SetKnownAccounts(accounts []string) []CategoryWarning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.knownAccounts = accounts
+	c.validateCategoriesUnlocked()
+	if c.llm != nil {
+		c.llm.SetAccounts(accounts)
+	}
+	if c.merchants != nil {
+		c.merchants.SetAccounts(accounts)
+	}
+	return c.categoryWarnings
+}
+
+// CategoryWarnings returns patterns whose category is not a known ledger account
+// Always empty until SetKnownAccounts has been called
+func (c *Categorizer) CategoryWarnings() []CategoryWarning {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.categoryWarnings
+}
+
+// validateCategoriesUnlocked recomputes category warnings without locking (internal use)
+func (c *Categorizer) validateCategoriesUnlocked() {
+	if c.knownAccounts == nil {
+		c.categoryWarnings = nil
+		return
+	}
+	c.categoryWarnings = ValidateCategories(c.patterns, c.knownAccounts)
 }
 
 // ReloadPatterns reloads patterns from the configured patterns file
@@ -92,16 +188,17 @@ func (c *Categorizer) Suggest(tx *beancount.Transaction) (*Suggestion, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	if c.matcher == nil {
-		return nil, nil
-	}
-
-	suggestion, err := c.matcher.Match(tx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to match pattern: %w", err)
+	if c.matcher != nil {
+		suggestion, err := c.matcher.Match(tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match pattern: %w", err)
+		}
+		if suggestion != nil {
+			return suggestion, nil
+		}
 	}
 
-	return suggestion, nil
+	return c.fallbackSuggestionUnlocked(tx), nil
 }
 
 // SuggestAll returns all matching suggestions for a transaction
@@ -117,16 +214,64 @@ func (c *Categorizer) SuggestAll(tx *beancount.Transaction) ([]*Suggestion, erro
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	if c.matcher == nil {
-		return nil, nil
+	if c.matcher != nil {
+		suggestions, err := c.matcher.MatchAll(tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match patterns: %w", err)
+		}
+		if len(suggestions) > 0 {
+			return suggestions, nil
+		}
 	}
 
-	suggestions, err := c.matcher.MatchAll(tx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to match patterns: %w", err)
+	if fallback := c.fallbackSuggestionUnlocked(tx); fallback != nil {
+		return []*Suggestion{fallback}, nil
 	}
+	return nil, nil
+}
+
+// HasLLM reports whether the LLM backend is configured
+func (c *Categorizer) HasLLM() bool {
+	return c.llm != nil
+}
+
+// SuggestLLM asks the LLM backend for a suggestion
+// This may take seconds; UI callers must run it inside a tea.Cmd
+func (c *Categorizer) SuggestLLM(ctx context.Context, tx *beancount.Transaction) (*Suggestion, error) {
+	if c.llm == nil || !c.config.Categorization.Enabled {
+		return nil, ErrLLMDisabled
+	}
+	return c.llm.Suggest(ctx, tx)
+}
+
+// TrainSimilarity builds the payee similarity index from categorized transactions
+// Has no effect unless similarity suggestions are enabled in the config
+func (c *Categorizer) TrainSimilarity(transactions []*beancount.Transaction) {
+	if !c.config.Categorization.SimilarityEnabled {
+		return
+	}
+
+	index := NewSimilarityIndex(c.config.Categorization.SimilarityThreshold)
+	index.Train(transactions)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.similarity = index
+}
 
-	return suggestions, nil
+// fallbackSuggestionUnlocked returns a suggestion from the non-pattern backends
+// when no pattern matched (internal use, caller holds the lock)
+// The user's own history is preferred over the generic merchant database
+func (c *Categorizer) fallbackSuggestionUnlocked(tx *beancount.Transaction) *Suggestion {
+	if c.similarity != nil && c.config.Categorization.SimilarityEnabled {
+		if suggestion := c.similarity.Suggest(tx); suggestion != nil {
+			return suggestion
+		}
+	}
+	if c.merchants != nil {
+		return c.merchants.Suggest(tx)
+	}
+	return nil
 }
 
 // Feedback records user feedback on a suggestion for learning
@@ -195,15 +340,56 @@ func (c *Categorizer) AddPattern(pattern *Pattern) error {
 	}
 
 	c.patterns = append(c.patterns, pattern)
+	c.validateCategoriesUnlocked()
 
-	// Update matcher
+	// Update matcher, creating one if no patterns file was loaded
 	if c.matcher != nil {
 		c.matcher.AddPattern(pattern)
+	} else {
+		c.matcher = NewPatternMatcherWithConfig(c.patterns, c.matcherConfig())
 	}
 
 	return nil
 }
 
+// UpdatePattern replaces the pattern with the same ID, keeping its statistics and creation time
+func (c *Categorizer) UpdatePattern(pattern *Pattern) error {
+	if pattern == nil {
+		return fmt.Errorf("pattern cannot be nil")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, p := range c.patterns {
+		if p.ID == pattern.ID {
+			pattern.Statistics = p.Statistics
+			pattern.Created = p.Created
+			pattern.Group = p.Group
+			pattern.Updated = time.Now()
+			c.patterns[i] = pattern
+			c.validateCategoriesUnlocked()
+			c.matcher = NewPatternMatcherWithConfig(c.patterns, c.matcherConfig())
+			return nil
+		}
+	}
+
+	return fmt.Errorf("pattern not found: %s", pattern.ID)
+}
+
+// CompilePattern validates and compiles a pattern definition without adding it
+func (c *Categorizer) CompilePattern(y PatternYAML) (*Pattern, error) {
+	return c.loader.CompilePattern(y)
+}
+
+// WritePatterns saves all patterns to the configured patterns file
+func (c *Categorizer) WritePatterns() error {
+	if c.config.Files.PatternsFile == "" {
+		return fmt.Errorf("no patterns file configured")
+	}
+	return c.SavePatterns(c.config.Files.PatternsFile)
+}
+
 // RemovePattern removes a pattern by ID
 func (c *Categorizer) RemovePattern(id string) error {
 	c.mu.Lock()
@@ -222,6 +408,7 @@ func (c *Categorizer) RemovePattern(id string) error {
 	if !found {
 		return fmt.Errorf("pattern not found: %s", id)
 	}
+	c.validateCategoriesUnlocked()
 
 	// Update matcher
 	if c.matcher != nil {
@@ -266,6 +453,106 @@ func (c *Categorizer) PatternCount() int {
 	return len(c.patterns)
 }
 
+// SetPatternEnabled enables or disables a single pattern, keeping its statistics
+func (c *Categorizer) SetPatternEnabled(id string, enabled bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, p := range c.patterns {
+		if p.ID == id {
+			p.Disabled = !enabled
+			return nil
+		}
+	}
+
+	return fmt.Errorf("pattern not found: %s", id)
+}
+
+// TogglePattern flips a pattern's enabled state and returns the new state
+func (c *Categorizer) TogglePattern(id string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, p := range c.patterns {
+		if p.ID == id {
+			p.Disabled = !p.Disabled
+			return !p.Disabled, nil
+		}
+	}
+
+	return false, fmt.Errorf("pattern not found: %s", id)
+}
+
+// GetGroups returns all pattern groups referenced by loaded patterns
+func (c *Categorizer) GetGroups() []*PatternGroup {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return GroupsOf(c.patterns)
+}
+
+// GetGroup returns a pattern group by name
+func (c *Categorizer) GetGroup(name string) (*PatternGroup, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.findGroupUnlocked(name)
+}
+
+// SetGroupEnabled enables or disables all patterns in a group
+func (c *Categorizer) SetGroupEnabled(name string, enabled bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	group, err := c.findGroupUnlocked(name)
+	if err != nil {
+		return err
+	}
+	group.Enabled = enabled
+	return nil
+}
+
+// SetGroupPriorityOffset changes a group's priority offset and re-sorts the matcher
+func (c *Categorizer) SetGroupPriorityOffset(name string, offset int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	group, err := c.findGroupUnlocked(name)
+	if err != nil {
+		return err
+	}
+	group.PriorityOffset = offset
+
+	if c.matcher != nil {
+		c.matcher.Resort()
+	}
+	return nil
+}
+
+// GetGroupPatterns returns all patterns belonging to a group
+func (c *Categorizer) GetGroupPatterns(name string) []*Pattern {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var patterns []*Pattern
+	for _, p := range c.patterns {
+		if p.Group != nil && p.Group.Name == name {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// findGroupUnlocked looks up a group by name without locking (internal use)
+func (c *Categorizer) findGroupUnlocked(name string) (*PatternGroup, error) {
+	for _, group := range GroupsOf(c.patterns) {
+		if group.Name == name {
+			return group, nil
+		}
+	}
+	return nil, fmt.Errorf("pattern group not found: %s", name)
+}
+
 // IsEnabled returns whether categorization is enabled
 func (c *Categorizer) IsEnabled() bool {
 	return c.config.Categorization.Enabled
@@ -276,6 +563,19 @@ func (c *Categorizer) SetEnabled(enabled bool) {
 	c.config.Categorization.Enabled = enabled
 }
 
+// SetThresholds changes the confidence at which pattern matching stops early and the
+// minimum confidence of automatic categorization, e.g. after the config is reloaded
+func (c *Categorizer) SetThresholds(confidence, auto float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.config.Categorization.ConfidenceThreshold = confidence
+	c.config.Categorization.AutoThreshold = auto
+	if c.matcher != nil {
+		c.matcher = NewPatternMatcherWithConfig(c.patterns, c.matcherConfig())
+	}
+}
+
 // GetConfig returns the categorization configuration
 func (c *Categorizer) GetConfig() config.CategorizationConfig {
 	return c.config.Categorization
@@ -434,6 +434,47 @@ func TestCategorizer_AddPattern(t *testing.T) {
 	}
 }
 
+func TestCategorizer_UpdatePattern(t *testing.T) {
+	c, err := New(nil)
+	if err != nil {
+		t.Fatalf("Failed to create categorizer: %v", err)
+	}
+
+	original, err := c.CompilePattern(PatternYAML{ID: "coffee", Name: "Coffee", Pattern: "STARBUCKS", Category: "Expenses:Coffee"})
+	if err != nil {
+		t.Fatalf("Failed to compile pattern: %v", err)
+	}
+	original.Statistics.AcceptCount = 3
+	if err := c.AddPattern(original); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	updated, err := c.CompilePattern(PatternYAML{ID: "coffee", Name: "Coffee", Pattern: "PEETS", Category: "Expenses:Food:Coffee"})
+	if err != nil {
+		t.Fatalf("Failed to compile pattern: %v", err)
+	}
+	if err := c.UpdatePattern(updated); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, _ := c.GetPattern("coffee")
+	if got.Category != "Expenses:Food:Coffee" {
+		t.Errorf("Expected updated category, got %s", got.Category)
+	}
+	if got.Statistics.AcceptCount != 3 {
+		t.Errorf("Expected statistics to be kept, got %d accepts", got.Statistics.AcceptCount)
+	}
+
+	suggestion, err := c.SuggestAll(&beancount.Transaction{Payee: "PEETS COFFEE"})
+	if err != nil || len(suggestion) == 0 || suggestion[0].Category != "Expenses:Food:Coffee" {
+		t.Errorf("Expected updated pattern to match, got %v (err %v)", suggestion, err)
+	}
+
+	if err := c.UpdatePattern(&Pattern{ID: "missing"}); err == nil {
+		t.Error("Expected error for unknown pattern")
+	}
+}
+
 func TestCategorizer_AddPattern_Duplicate(t *testing.T) {
 	c, err := New(nil)
 	if err != nil {
@@ -648,3 +689,127 @@ func TestCategorizer_GetConfig(t *testing.T) {
 		t.Error("Expected LearnFromEdits to be true")
 	}
 }
+
+func TestCategorizer_SetThresholds(t *testing.T) {
+	patternsFile := filepath.Join(t.TempDir(), "patterns.yaml")
+	yaml := `
+patterns:
+  - id: starbucks
+    name: Starbucks
+    pattern: "STARBUCKS"
+    category: Expenses:Food:DiningOut
+    confidence: 0.9
+`
+	if err := os.WriteFile(patternsFile, []byte(yaml), 0644); err != nil {
+		t.Fatalf("Failed to create patterns file: %v", err)
+	}
+
+	c, err := New(nil)
+	if err != nil {
+		t.Fatalf("Failed to create categorizer: %v", err)
+	}
+	if err := c.LoadPatterns(patternsFile); err != nil {
+		t.Fatalf("Failed to load patterns: %v", err)
+	}
+
+	c.SetThresholds(0.7, 0.9)
+	categCfg := c.GetConfig()
+	if categCfg.ConfidenceThreshold != 0.7 || categCfg.AutoThreshold != 0.9 {
+		t.Errorf("Expected thresholds 0.7 and 0.9, got %f and %f", categCfg.ConfidenceThreshold, categCfg.AutoThreshold)
+	}
+	if c.matcher.EarlyExitThreshold != 0.7 {
+		t.Errorf("Expected the matcher to stop early at 0.7, got %f", c.matcher.EarlyExitThreshold)
+	}
+	if c.PatternCount() != 1 {
+		t.Errorf("Expected the patterns to be kept, got %d", c.PatternCount())
+	}
+}
+
+func TestCategorizer_SetPatternEnabled(t *testing.T) {
+	c, _ := New(nil)
+	c.LoadPatterns("/nonexistent")
+
+	pattern := &Pattern{
+		ID:         "shop",
+		Name:       "Shop",
+		Regex:      regexp.MustCompile("SHOP"),
+		Category:   "Expenses:Shopping",
+		Confidence: 0.9,
+		Statistics: PatternStatistics{AcceptCount: 3, Accuracy: 1.0},
+	}
+	c.matcher = NewPatternMatcher(nil)
+	if err := c.AddPattern(pattern); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tx := &beancount.Transaction{Payee: "SHOP"}
+
+	if err := c.SetPatternEnabled("shop", false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s, _ := c.Suggest(tx); s != nil {
+		t.Error("Expected no suggestion from disabled pattern")
+	}
+	if pattern.Statistics.AcceptCount != 3 {
+		t.Error("Expected statistics to be preserved while disabled")
+	}
+
+	enabled, err := c.TogglePattern("shop")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !enabled {
+		t.Error("Expected toggle to re-enable pattern")
+	}
+	if s, _ := c.Suggest(tx); s == nil {
+		t.Error("Expected suggestion after re-enabling pattern")
+	}
+
+	if err := c.SetPatternEnabled("missing", true); err == nil {
+		t.Error("Expected error for unknown pattern")
+	}
+}
+
+func TestCategorizer_LoadWarnings(t *testing.T) {
+	tmpDir := t.TempDir()
+	patternsFile := filepath.Join(tmpDir, "patterns.yaml")
+	content := `
+patterns:
+  - id: valid
+    name: Valid
+    pattern: "VALID"
+    category: Expenses:Valid
+groups:
+  - name: travel
+    patterns:
+      - id: broken
+        name: Broken
+        pattern: "VALID"
+`
+	if err := os.WriteFile(patternsFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write patterns file: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = patternsFile
+	if _, err := New(cfg); err == nil {
+		t.Fatal("Expected strict loading to fail on the invalid pattern")
+	}
+
+	cfg.Categorization.StrictPatterns = false
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if c.PatternCount() != 1 {
+		t.Errorf("Expected 1 pattern, got %d", c.PatternCount())
+	}
+
+	warnings := c.LoadWarnings()
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	if warnings[0].Group != "travel" || warnings[0].ID != "broken" {
+		t.Errorf("Unexpected warning: %+v", warnings[0])
+	}
+}
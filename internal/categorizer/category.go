@@ -0,0 +1,73 @@
+package categorizer
+
+import (
+	"strings"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// uncategorizedLeaves are account leaf names that mark a posting as not yet categorized
+var uncategorizedLeaves = []string{"Uncategorized", "Unknown"}
+
+// IsUncategorizedAccount reports whether an account is a placeholder category
+func IsUncategorizedAccount(account string) bool {
+	leaf := account
+	if i := strings.LastIndex(account, ":"); i >= 0 {
+		leaf = account[i+1:]
+	}
+	return contains(uncategorizedLeaves, leaf)
+}
+
+// IsUncategorized reports whether a transaction still needs a category
+// A transaction is uncategorized when it has a single posting or posts to a placeholder account
+func IsUncategorized(tx *beancount.Transaction) bool {
+	if len(tx.Postings) < 2 {
+		return true
+	}
+	for _, p := range tx.Postings {
+		if IsUncategorizedAccount(p.Account) {
+			return true
+		}
+	}
+	return false
+}
+
+// CategoryOf returns the category account of a transaction (its first expense or
+// income posting), or "" if the transaction is uncategorized
+func CategoryOf(tx *beancount.Transaction) string {
+	if IsUncategorized(tx) {
+		return ""
+	}
+	for _, p := range tx.Postings {
+		if strings.HasPrefix(p.Account, "Expenses:") || strings.HasPrefix(p.Account, "Income:") {
+			return p.Account
+		}
+	}
+	return ""
+}
+
+// PlaceholderPosting returns the index of the first posting to a placeholder
+// account, or -1 if the transaction has none
+func PlaceholderPosting(tx *beancount.Transaction) int {
+	for i, p := range tx.Postings {
+		if IsUncategorizedAccount(p.Account) {
+			return i
+		}
+	}
+	return -1
+}
+
+// CategoryPosting returns the index of the posting that holds the transaction's
+// category: the placeholder posting if there is one, otherwise the first expense
+// or income posting; -1 if there is neither
+func CategoryPosting(tx *beancount.Transaction) int {
+	if i := PlaceholderPosting(tx); i >= 0 {
+		return i
+	}
+	for i, p := range tx.Postings {
+		if strings.HasPrefix(p.Account, "Expenses:") || strings.HasPrefix(p.Account, "Income:") {
+			return i
+		}
+	}
+	return -1
+}
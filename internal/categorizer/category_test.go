@@ -0,0 +1,72 @@
+package categorizer
+
+import (
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+func TestIsUncategorized(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []string
+		want     bool
+	}{
+		{"single posting", []string{"Assets:Checking"}, true},
+		{"placeholder account", []string{"Assets:Checking", "Expenses:Uncategorized"}, true},
+		{"unknown leaf", []string{"Assets:Checking", "Expenses:Unknown"}, true},
+		{"categorized", []string{"Assets:Checking", "Expenses:Food"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &beancount.Transaction{}
+			for _, acc := range tt.accounts {
+				tx.Postings = append(tx.Postings, beancount.Posting{Account: acc})
+			}
+			if got := IsUncategorized(tx); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCategoryOf(t *testing.T) {
+	tx := categorizedTx("Employer", "Income:Salary")
+	if got := CategoryOf(tx); got != "Income:Salary" {
+		t.Errorf("Expected 'Income:Salary', got '%s'", got)
+	}
+
+	transfer := &beancount.Transaction{Postings: []beancount.Posting{
+		{Account: "Assets:Checking"},
+		{Account: "Assets:Savings"},
+	}}
+	if got := CategoryOf(transfer); got != "" {
+		t.Errorf("Expected no category for transfer, got '%s'", got)
+	}
+}
+
+func TestCategoryPosting(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []string
+		want     int
+	}{
+		{"placeholder wins", []string{"Expenses:Food", "Expenses:Uncategorized"}, 1},
+		{"first expense", []string{"Assets:Checking", "Expenses:Food", "Expenses:Tips"}, 1},
+		{"income", []string{"Assets:Checking", "Income:Salary"}, 1},
+		{"transfer", []string{"Assets:Checking", "Assets:Savings"}, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &beancount.Transaction{}
+			for _, acc := range tt.accounts {
+				tx.Postings = append(tx.Postings, beancount.Posting{Account: acc})
+			}
+			if got := CategoryPosting(tx); got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
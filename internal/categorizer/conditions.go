@@ -0,0 +1,227 @@
+package categorizer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// ConditionOp is the kind of test a condition node performs
+type ConditionOp string
+
+const (
+	// Combinators
+	ConditionAll ConditionOp = "all"
+	ConditionAny ConditionOp = "any"
+	ConditionNot ConditionOp = "not"
+
+	// Leaf tests
+	ConditionPayee     ConditionOp = "payee"     // regex on the payee
+	ConditionNarration ConditionOp = "narration" // regex on the narration
+	ConditionText      ConditionOp = "text"      // regex on payee or narration
+	ConditionAccount   ConditionOp = "account"   // regex on any posting account
+	ConditionTag       ConditionOp = "tag"       // transaction has the tag
+	ConditionAmount    ConditionOp = "amount"    // largest posting amount within bounds
+)
+
+// ConditionYAML is a condition node as stored in a v2 patterns file
+// Exactly one field must be set per node
+type ConditionYAML struct {
+	All       []ConditionYAML  `yaml:"all,omitempty"`
+	Any       []ConditionYAML  `yaml:"any,omitempty"`
+	Not       *ConditionYAML   `yaml:"not,omitempty"`
+	Payee     string           `yaml:"payee,omitempty"`
+	Narration string           `yaml:"narration,omitempty"`
+	Text      string           `yaml:"text,omitempty"`
+	Account   string           `yaml:"account,omitempty"`
+	Tag       string           `yaml:"tag,omitempty"`
+	Amount    *AmountRangeYAML `yaml:"amount,omitempty"`
+}
+
+// AmountRangeYAML is an inclusive amount range; either bound may be omitted
+type AmountRangeYAML struct {
+	Min *float64 `yaml:"min,omitempty"`
+	Max *float64 `yaml:"max,omitempty"`
+}
+
+// Condition is a compiled condition tree evaluated against a transaction
+type Condition struct {
+	Op       ConditionOp
+	Children []*Condition // For all/any (any number) and not (exactly one)
+	Value    string       // Regex source or tag name for leaf tests
+	Regex    *regexp.Regexp
+	Min      *float64
+	Max      *float64
+}
+
+// CompileCondition validates a YAML condition tree and compiles its regexes
+func CompileCondition(y ConditionYAML) (*Condition, error) {
+	var nodes []*Condition
+	var errs []string
+
+	leaf := func(op ConditionOp, value string) {
+		if value == "" {
+			return
+		}
+		c := &Condition{Op: op, Value: value}
+		if op != ConditionTag {
+			regex, err := regexp.Compile(value)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("invalid %s regex: %v", op, err))
+				return
+			}
+			c.Regex = regex
+		}
+		nodes = append(nodes, c)
+	}
+	leaf(ConditionPayee, y.Payee)
+	leaf(ConditionNarration, y.Narration)
+	leaf(ConditionText, y.Text)
+	leaf(ConditionAccount, y.Account)
+	leaf(ConditionTag, y.Tag)
+
+	if y.Amount != nil {
+		if y.Amount.Min == nil && y.Amount.Max == nil {
+			errs = append(errs, "amount condition needs min or max")
+		} else if y.Amount.Min != nil && y.Amount.Max != nil && *y.Amount.Min > *y.Amount.Max {
+			errs = append(errs, fmt.Sprintf("amount min (%f) cannot be greater than max (%f)", *y.Amount.Min, *y.Amount.Max))
+		} else {
+			nodes = append(nodes, &Condition{Op: ConditionAmount, Min: y.Amount.Min, Max: y.Amount.Max})
+		}
+	}
+
+	combinator := func(op ConditionOp, children []ConditionYAML) {
+		if children == nil {
+			return
+		}
+		if len(children) == 0 {
+			errs = append(errs, fmt.Sprintf("%s needs at least one condition", op))
+			return
+		}
+		c := &Condition{Op: op}
+		for _, child := range children {
+			compiled, err := CompileCondition(child)
+			if err != nil {
+				errs = append(errs, err.Error())
+				return
+			}
+			c.Children = append(c.Children, compiled)
+		}
+		nodes = append(nodes, c)
+	}
+	combinator(ConditionAll, y.All)
+	combinator(ConditionAny, y.Any)
+	if y.Not != nil {
+		combinator(ConditionNot, []ConditionYAML{*y.Not})
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	if len(nodes) > 1 {
+		return nil, fmt.Errorf("condition must have exactly one test, found %d (use all/any to combine)", len(nodes))
+	}
+	return nodes[0], nil
+}
+
+// Eval reports whether the transaction satisfies the condition
+func (c *Condition) Eval(tx *beancount.Transaction) bool {
+	switch c.Op {
+	case ConditionAll:
+		for _, child := range c.Children {
+			if !child.Eval(tx) {
+				return false
+			}
+		}
+		return true
+	case ConditionAny:
+		for _, child := range c.Children {
+			if child.Eval(tx) {
+				return true
+			}
+		}
+		return false
+	case ConditionNot:
+		return !c.Children[0].Eval(tx)
+	case ConditionPayee:
+		return c.Regex.MatchString(tx.Payee)
+	case ConditionNarration:
+		return c.Regex.MatchString(tx.Narration)
+	case ConditionText:
+		return c.Regex.MatchString(tx.Payee) || c.Regex.MatchString(tx.Narration)
+	case ConditionAccount:
+		for _, posting := range tx.Postings {
+			if c.Regex.MatchString(posting.Account) {
+				return true
+			}
+		}
+		return false
+	case ConditionTag:
+		return contains(tx.Tags, c.Value)
+	case ConditionAmount:
+		amount := transactionAmount(tx)
+		if c.Min != nil && amount < *c.Min {
+			return false
+		}
+		if c.Max != nil && amount > *c.Max {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// ToYAML converts a compiled condition back to its YAML form
+func (c *Condition) ToYAML() ConditionYAML {
+	var y ConditionYAML
+	children := func() []ConditionYAML {
+		out := make([]ConditionYAML, len(c.Children))
+		for i, child := range c.Children {
+			out[i] = child.ToYAML()
+		}
+		return out
+	}
+
+	switch c.Op {
+	case ConditionAll:
+		y.All = children()
+	case ConditionAny:
+		y.Any = children()
+	case ConditionNot:
+		not := c.Children[0].ToYAML()
+		y.Not = &not
+	case ConditionPayee:
+		y.Payee = c.Value
+	case ConditionNarration:
+		y.Narration = c.Value
+	case ConditionText:
+		y.Text = c.Value
+	case ConditionAccount:
+		y.Account = c.Value
+	case ConditionTag:
+		y.Tag = c.Value
+	case ConditionAmount:
+		y.Amount = &AmountRangeYAML{Min: c.Min, Max: c.Max}
+	}
+	return y
+}
+
+// transactionAmount returns the largest absolute posting amount (typically the expense)
+func transactionAmount(tx *beancount.Transaction) float64 {
+	var maxAmount float64
+	for _, posting := range tx.Postings {
+		if posting.Amount == nil {
+			continue
+		}
+		amount, _ := posting.Amount.Number.Abs().Float64()
+		if amount > maxAmount {
+			maxAmount = amount
+		}
+	}
+	return maxAmount
+}
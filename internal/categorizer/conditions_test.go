@@ -0,0 +1,271 @@
+package categorizer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+func conditionTestTransaction(payee string, amount string, tags ...string) *beancount.Transaction {
+	return &beancount.Transaction{
+		Payee: payee,
+		Tags:  tags,
+		Postings: []beancount.Posting{
+			{Account: "Expenses:Uncategorized", Amount: &beancount.Amount{Number: decimal.RequireFromString(amount), Commodity: "USD"}},
+			{Account: "Liabilities:CreditCard"},
+		},
+	}
+}
+
+func TestCompileCondition_Tree(t *testing.T) {
+	min := 10.0
+	cond, err := CompileCondition(ConditionYAML{
+		All: []ConditionYAML{
+			{Any: []ConditionYAML{{Payee: "AMAZON"}, {Payee: "AMZN"}}},
+			{Not: &ConditionYAML{Tag: "business"}},
+			{Amount: &AmountRangeYAML{Min: &min}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tx   *beancount.Transaction
+		want bool
+	}{
+		{"matches", conditionTestTransaction("AMZN MKTP", "25.00"), true},
+		{"wrong payee", conditionTestTransaction("TARGET", "25.00"), false},
+		{"excluded tag", conditionTestTransaction("AMAZON", "25.00", "business"), false},
+		{"below minimum", conditionTestTransaction("AMAZON", "5.00"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cond.Eval(tt.tx); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompileCondition_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		y    ConditionYAML
+	}{
+		{"empty", ConditionYAML{}},
+		{"multiple tests", ConditionYAML{Payee: "A", Narration: "B"}},
+		{"invalid regex", ConditionYAML{Payee: "[invalid"}},
+		{"empty any", ConditionYAML{Any: []ConditionYAML{}}},
+		{"empty amount", ConditionYAML{Amount: &AmountRangeYAML{}}},
+		{"invalid child", ConditionYAML{All: []ConditionYAML{{Payee: "("}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CompileCondition(tt.y); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestCondition_ToYAMLRoundTrip(t *testing.T) {
+	max := 50.0
+	original := ConditionYAML{
+		Any: []ConditionYAML{
+			{Text: "COFFEE"},
+			{All: []ConditionYAML{{Account: "^Liabilities:"}, {Amount: &AmountRangeYAML{Max: &max}}}},
+		},
+	}
+	cond, err := CompileCondition(original)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	recompiled, err := CompileCondition(cond.ToYAML())
+	if err != nil {
+		t.Fatalf("Expected round-tripped condition to compile, got: %v", err)
+	}
+	tx := conditionTestTransaction("SHOP", "20.00")
+	if cond.Eval(tx) != recompiled.Eval(tx) {
+		t.Error("Expected round-tripped condition to evaluate the same")
+	}
+}
+
+func TestLoader_LoadYAML_Version2(t *testing.T) {
+	yaml := `
+version: "2"
+patterns:
+  - id: amazon
+    name: Amazon
+    category: Expenses:Shopping
+    when:
+      any:
+        - payee: "AMAZON"
+        - payee: "AMZN"
+    exclude:
+      - tag: business
+    splits:
+      - category: Expenses:Shopping
+        percent: 60
+      - category: Expenses:Household
+        percent: 40
+`
+	loader := NewLoader()
+	patterns, err := loader.LoadYAML([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(patterns) != 1 {
+		t.Fatalf("Expected 1 pattern, got %d", len(patterns))
+	}
+
+	p := patterns[0]
+	if p.Regex != nil {
+		t.Error("Expected condition-only pattern to have no regex")
+	}
+	if len(p.Splits) != 2 || p.Splits[1].Percent != 40 {
+		t.Errorf("Expected 2 splits, got %+v", p.Splits)
+	}
+	if !p.Matches(conditionTestTransaction("AMZN MKTP", "10.00")) {
+		t.Error("Expected pattern to match AMZN")
+	}
+	if p.Matches(conditionTestTransaction("AMZN MKTP", "10.00", "business")) {
+		t.Error("Expected exclusion to veto the match")
+	}
+	if p.Matches(conditionTestTransaction("TARGET", "10.00")) {
+		t.Error("Expected pattern not to match TARGET")
+	}
+}
+
+func TestLoader_LoadYAML_Version2InvalidSplits(t *testing.T) {
+	yaml := `
+version: "2"
+patterns:
+  - id: split
+    name: Split
+    pattern: "SHOP"
+    category: Expenses:Shopping
+    splits:
+      - category: Expenses:A
+        percent: 70
+      - category: Expenses:B
+        percent: 40
+`
+	if _, err := NewLoader().LoadYAML([]byte(yaml)); err == nil {
+		t.Fatal("Expected error for splits totalling more than 100")
+	}
+}
+
+func TestLoader_LoadYAML_MigratesVersion1(t *testing.T) {
+	yaml := `
+version: "1"
+patterns:
+  - id: coffee
+    name: Coffee
+    pattern: "STARBUCKS"
+    category: Expenses:Food:Coffee
+`
+	var file PatternFile
+	file.Version = PatternVersion1
+	if err := migratePatternFile(&file); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if file.Version != CurrentPatternVersion {
+		t.Errorf("Expected version %s after migration, got %s", CurrentPatternVersion, file.Version)
+	}
+
+	patterns, err := NewLoader().LoadYAML([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Expected v1 file to load, got: %v", err)
+	}
+	if len(patterns) != 1 || patterns[0].Regex == nil {
+		t.Error("Expected v1 pattern to load with its regex")
+	}
+}
+
+func TestLoader_LoadYAML_Version1RejectsV2Fields(t *testing.T) {
+	yaml := `
+version: "1"
+patterns:
+  - id: coffee
+    name: Coffee
+    category: Expenses:Food:Coffee
+    when:
+      payee: "STARBUCKS"
+`
+	_, err := NewLoader().LoadYAML([]byte(yaml))
+	if err == nil {
+		t.Fatal("Expected error for v2 fields in a v1 file")
+	}
+	if !strings.Contains(err.Error(), "version 2") {
+		t.Errorf("Expected error to mention version 2, got: %v", err)
+	}
+}
+
+func TestLoader_SaveFileVersion(t *testing.T) {
+	loader := NewLoader()
+	v1, err := loader.LoadYAML([]byte(`
+patterns:
+  - id: coffee
+    name: Coffee
+    pattern: "STARBUCKS"
+    category: Expenses:Food:Coffee
+`))
+	if err != nil {
+		t.Fatalf("Failed to load v1 patterns: %v", err)
+	}
+	v2, err := loader.LoadYAML([]byte(`
+version: "2"
+patterns:
+  - id: amazon
+    name: Amazon
+    category: Expenses:Shopping
+    when:
+      payee: "AMAZON"
+    exclude:
+      - tag: business
+`))
+	if err != nil {
+		t.Fatalf("Failed to load v2 patterns: %v", err)
+	}
+
+	dir := t.TempDir()
+
+	// Auto-selected version is the lowest that fits
+	v1Path := filepath.Join(dir, "v1.yaml")
+	if err := loader.SaveFile(v1Path, v1); err != nil {
+		t.Fatalf("Failed to save v1 patterns: %v", err)
+	}
+	if data, _ := os.ReadFile(v1Path); !strings.Contains(string(data), `version: "1"`) {
+		t.Errorf("Expected v1 patterns saved as version 1, got:\n%s", data)
+	}
+
+	v2Path := filepath.Join(dir, "v2.yaml")
+	if err := loader.SaveFile(v2Path, v2); err != nil {
+		t.Fatalf("Failed to save v2 patterns: %v", err)
+	}
+	reloaded, err := loader.LoadFile(v2Path)
+	if err != nil {
+		t.Fatalf("Failed to reload v2 patterns: %v", err)
+	}
+	if len(reloaded) != 1 || reloaded[0].Condition == nil || len(reloaded[0].Exclusions) != 1 {
+		t.Errorf("Expected condition and exclusion to round-trip, got %+v", reloaded)
+	}
+
+	// Explicit versions
+	if err := loader.SaveFileVersion(filepath.Join(dir, "bad.yaml"), v2, PatternVersion1); err == nil {
+		t.Error("Expected error saving v2 features as version 1")
+	}
+	if err := loader.SaveFileVersion(filepath.Join(dir, "up.yaml"), v1, PatternVersion2); err != nil {
+		t.Errorf("Expected v1 patterns to save as version 2, got: %v", err)
+	}
+	if err := loader.SaveFileVersion(filepath.Join(dir, "x.yaml"), v1, "9"); err == nil {
+		t.Error("Expected error for unknown version")
+	}
+}
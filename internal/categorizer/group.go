@@ -0,0 +1,59 @@
+package categorizer
+
+import "strings"
+
+// groupSeparator separates a group name from a pattern ID in qualified IDs
+const groupSeparator = "/"
+
+// PatternGroup is a named collection of patterns (e.g., "subscriptions", "travel")
+// Groups can be enabled or disabled as a unit and shift the priority of all their patterns
+type PatternGroup struct {
+	// Name is the unique name of the group, also used as the ID namespace
+	Name string
+
+	// Description is an optional human-readable description
+	Description string
+
+	// Enabled controls whether patterns in this group participate in matching
+	Enabled bool
+
+	// PriorityOffset is added to the priority of every pattern in the group
+	PriorityOffset int
+}
+
+// NewPatternGroup creates a new enabled group with no priority offset
+func NewPatternGroup(name string) *PatternGroup {
+	return &PatternGroup{
+		Name:    name,
+		Enabled: true,
+	}
+}
+
+// QualifyID returns the namespaced ID for a pattern in the given group
+func QualifyID(group, id string) string {
+	if group == "" {
+		return id
+	}
+	return group + groupSeparator + id
+}
+
+// UnqualifyID strips the group namespace from a qualified pattern ID
+func UnqualifyID(group, id string) string {
+	if group == "" {
+		return id
+	}
+	return strings.TrimPrefix(id, group+groupSeparator)
+}
+
+// GroupsOf returns the unique groups referenced by the given patterns, in first-seen order
+func GroupsOf(patterns []*Pattern) []*PatternGroup {
+	seen := make(map[*PatternGroup]bool)
+	groups := make([]*PatternGroup, 0)
+	for _, p := range patterns {
+		if p.Group != nil && !seen[p.Group] {
+			seen[p.Group] = true
+			groups = append(groups, p.Group)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,222 @@
+package categorizer
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+const groupedPatternsYAML = `
+version: "1"
+patterns:
+  - id: coffee
+    name: Coffee
+    pattern: "COFFEE"
+    category: Expenses:Food:Coffee
+    priority: 5
+groups:
+  - name: subscriptions
+    description: Recurring services
+    priority_offset: 10
+    patterns:
+      - id: netflix
+        name: Netflix
+        pattern: "NETFLIX"
+        category: Expenses:Subscriptions:Streaming
+  - name: travel
+    enabled: false
+    patterns:
+      - id: airbnb
+        name: Airbnb
+        pattern: "AIRBNB"
+        category: Expenses:Travel:Lodging
+`
+
+func TestQualifyID(t *testing.T) {
+	if got := QualifyID("travel", "airbnb"); got != "travel/airbnb" {
+		t.Errorf("Expected 'travel/airbnb', got '%s'", got)
+	}
+	if got := QualifyID("", "airbnb"); got != "airbnb" {
+		t.Errorf("Expected 'airbnb', got '%s'", got)
+	}
+	if got := UnqualifyID("travel", "travel/airbnb"); got != "airbnb" {
+		t.Errorf("Expected 'airbnb', got '%s'", got)
+	}
+}
+
+func TestLoader_LoadYAML_Groups(t *testing.T) {
+	loader := NewLoader()
+	patterns, err := loader.LoadYAML([]byte(groupedPatternsYAML))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(patterns) != 3 {
+		t.Fatalf("Expected 3 patterns, got %d", len(patterns))
+	}
+
+	if patterns[0].Group != nil {
+		t.Error("Expected ungrouped pattern to have nil group")
+	}
+
+	netflix := patterns[1]
+	if netflix.ID != "subscriptions/netflix" {
+		t.Errorf("Expected namespaced ID 'subscriptions/netflix', got '%s'", netflix.ID)
+	}
+	if netflix.Group == nil || netflix.Group.Name != "subscriptions" {
+		t.Fatalf("Expected group 'subscriptions', got %v", netflix.Group)
+	}
+	if !netflix.Group.Enabled {
+		t.Error("Expected group to be enabled by default")
+	}
+	if netflix.EffectivePriority() != 10 {
+		t.Errorf("Expected effective priority 10, got %d", netflix.EffectivePriority())
+	}
+
+	airbnb := patterns[2]
+	if airbnb.IsActive() {
+		t.Error("Expected pattern in disabled group to be inactive")
+	}
+
+	groups := GroupsOf(patterns)
+	if len(groups) != 2 {
+		t.Errorf("Expected 2 groups, got %d", len(groups))
+	}
+}
+
+func TestLoader_LoadYAML_DuplicateGroup(t *testing.T) {
+	yaml := `
+groups:
+  - name: travel
+    patterns: []
+  - name: travel
+    patterns: []
+`
+	loader := NewLoader()
+	if _, err := loader.LoadYAML([]byte(yaml)); err == nil {
+		t.Fatal("Expected error for duplicate group")
+	}
+}
+
+func TestLoader_SaveFile_Groups(t *testing.T) {
+	loader := NewLoader()
+	patterns, err := loader.LoadYAML([]byte(groupedPatternsYAML))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tmpFile := filepath.Join(t.TempDir(), "patterns.yaml")
+	if err := loader.SaveFile(tmpFile, patterns); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+
+	reloaded, err := loader.LoadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to load saved file: %v", err)
+	}
+
+	if len(reloaded) != 3 {
+		t.Fatalf("Expected 3 patterns, got %d", len(reloaded))
+	}
+	if reloaded[1].ID != "subscriptions/netflix" {
+		t.Errorf("Expected ID 'subscriptions/netflix', got '%s'", reloaded[1].ID)
+	}
+	if reloaded[2].Group.Enabled {
+		t.Error("Expected travel group to stay disabled")
+	}
+	if reloaded[1].Group.PriorityOffset != 10 {
+		t.Errorf("Expected priority offset 10, got %d", reloaded[1].Group.PriorityOffset)
+	}
+}
+
+func TestPatternMatcher_GroupOffsetAndDisabled(t *testing.T) {
+	streaming := NewPatternGroup("streaming")
+	streaming.PriorityOffset = 20
+
+	disabled := NewPatternGroup("disabled")
+	disabled.Enabled = false
+
+	patterns := []*Pattern{
+		{ID: "generic", Name: "Generic", Regex: regexp.MustCompile("NETFLIX"), Category: "Expenses:Misc", Priority: 10, Confidence: 0.7},
+		{ID: "streaming/netflix", Name: "Netflix", Regex: regexp.MustCompile("NETFLIX"), Category: "Expenses:Streaming", Priority: 0, Confidence: 0.7, Group: streaming},
+		{ID: "disabled/netflix", Name: "Off", Regex: regexp.MustCompile("NETFLIX"), Category: "Expenses:Off", Priority: 100, Confidence: 0.99, Group: disabled},
+	}
+
+	matcher := NewPatternMatcher(patterns)
+	tx := &beancount.Transaction{Payee: "NETFLIX.COM"}
+
+	suggestion, err := matcher.Match(tx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if suggestion == nil || suggestion.Category != "Expenses:Streaming" {
+		t.Fatalf("Expected group offset to win, got %+v", suggestion)
+	}
+
+	all, err := matcher.MatchAll(tx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, s := range all {
+		if s.Category == "Expenses:Off" {
+			t.Error("Expected disabled group to be skipped by MatchAll")
+		}
+	}
+}
+
+func TestCategorizer_GroupRuntimeToggles(t *testing.T) {
+	tmpDir := t.TempDir()
+	patternsFile := filepath.Join(tmpDir, "patterns.yaml")
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = patternsFile
+	cfg.Categorization.Merchants.Enabled = false
+
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	patterns, err := NewLoader().LoadYAML([]byte(groupedPatternsYAML))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := NewLoader().SaveFile(patternsFile, patterns); err != nil {
+		t.Fatalf("Failed to save patterns: %v", err)
+	}
+	if err := c.LoadPatterns(patternsFile); err != nil {
+		t.Fatalf("Failed to load patterns: %v", err)
+	}
+
+	if len(c.GetGroups()) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(c.GetGroups()))
+	}
+
+	tx := &beancount.Transaction{Payee: "AIRBNB * STAY"}
+	suggestion, _ := c.Suggest(tx)
+	if suggestion != nil {
+		t.Fatal("Expected no suggestion while travel group is disabled")
+	}
+
+	if err := c.SetGroupEnabled("travel", true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	suggestion, _ = c.Suggest(tx)
+	if suggestion == nil || suggestion.Category != "Expenses:Travel:Lodging" {
+		t.Fatalf("Expected travel suggestion after enabling group, got %+v", suggestion)
+	}
+
+	if err := c.SetGroupPriorityOffset("subscriptions", -5); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := c.GetGroupPatterns("subscriptions"); len(got) != 1 || got[0].EffectivePriority() != -5 {
+		t.Errorf("Expected subscriptions pattern with effective priority -5, got %v", got)
+	}
+
+	if err := c.SetGroupEnabled("missing", true); err == nil {
+		t.Error("Expected error for unknown group")
+	}
+}
@@ -0,0 +1,276 @@
+package categorizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// Suggester is a pluggable source of categorization suggestions
+// Implementations may be slow (e.g., network calls) and must honor ctx cancellation
+type Suggester interface {
+	Suggest(ctx context.Context, tx *beancount.Transaction) (*Suggestion, error)
+}
+
+// ErrLLMDisabled is returned when the LLM backend is not configured
+var ErrLLMDisabled = errors.New("llm suggester is disabled")
+
+// LLMConfig holds configuration for the LLM suggester
+type LLMConfig struct {
+	// Endpoint is the base URL of an OpenAI-compatible API (e.g., https://api.openai.com/v1)
+	Endpoint string
+
+	// Model is the chat model name to request
+	Model string
+
+	// APIKey is sent as a bearer token (may be empty for local servers)
+	APIKey string
+
+	// Timeout bounds a single request
+	Timeout time.Duration
+
+	// MinInterval is the minimum time between requests (rate limit)
+	MinInterval time.Duration
+}
+
+// LLMSuggester asks an OpenAI-compatible chat endpoint to categorize transactions
+// Results are cached per payee/narration/amount and requests are rate-limited
+type LLMSuggester struct {
+	config   LLMConfig
+	client   *http.Client
+	accounts []string
+
+	mu          sync.Mutex
+	cache       map[string]*Suggestion
+	lastRequest time.Time
+}
+
+// NewLLMSuggester creates a new LLM suggester
+func NewLLMSuggester(config LLMConfig) *LLMSuggester {
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &LLMSuggester{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		cache:  make(map[string]*Suggestion),
+	}
+}
+
+// SetAccounts sets the ledger account list offered to the model as valid categories
+func (l *LLMSuggester) SetAccounts(accounts []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.accounts = accounts
+}
+
+// llmAnswer is the JSON object the model is asked to return
+type llmAnswer struct {
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+	Rationale  string  `json:"rationale"`
+}
+
+// chatRequest is an OpenAI-compatible chat completion request
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatResponse is the subset of the chat completion response we use
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// Suggest asks the model for a category, returning a cached answer when available
+func (l *LLMSuggester) Suggest(ctx context.Context, tx *beancount.Transaction) (*Suggestion, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("transaction cannot be nil")
+	}
+	if l.config.Endpoint == "" {
+		return nil, ErrLLMDisabled
+	}
+
+	key := cacheKey(tx)
+	l.mu.Lock()
+	if cached, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return cloneForTransaction(cached, tx), nil
+	}
+	accounts := l.accounts
+	l.mu.Unlock()
+
+	if err := l.waitForSlot(ctx); err != nil {
+		return nil, err
+	}
+
+	answer, err := l.ask(ctx, buildPrompt(tx, accounts))
+	if err != nil {
+		return nil, err
+	}
+
+	if answer.Category == "" {
+		return nil, nil
+	}
+	if len(accounts) > 0 && !contains(accounts, answer.Category) {
+		return nil, fmt.Errorf("model suggested unknown account: %s", answer.Category)
+	}
+
+	confidence := answer.Confidence
+	if confidence <= 0 || confidence > 1 {
+		confidence = 0.5
+	}
+
+	suggestion := &Suggestion{
+		Transaction: tx,
+		Category:    answer.Category,
+		Confidence:  confidence,
+		Source:      SourceLLM,
+		Reason:      "LLM: " + answer.Rationale,
+		Created:     time.Now(),
+	}
+
+	l.mu.Lock()
+	l.cache[key] = suggestion
+	l.mu.Unlock()
+
+	return suggestion, nil
+}
+
+// waitForSlot blocks until the rate limit allows another request or ctx is done
+func (l *LLMSuggester) waitForSlot(ctx context.Context) error {
+	l.mu.Lock()
+	wait := time.Until(l.lastRequest.Add(l.config.MinInterval))
+	if wait <= 0 {
+		l.lastRequest = time.Now()
+		l.mu.Unlock()
+		return nil
+	}
+	l.lastRequest = l.lastRequest.Add(l.config.MinInterval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ask sends the prompt and decodes the model's JSON answer
+func (l *LLMSuggester) ask(ctx context.Context, prompt string) (*llmAnswer, error) {
+	body, err := json.Marshal(chatRequest{
+		Model: l.config.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: llmSystemPrompt},
+			{Role: "user", Content: prompt},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	url := strings.TrimRight(l.config.Endpoint, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.config.APIKey)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("llm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("llm request failed: %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+
+	var chat chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chat); err != nil {
+		return nil, fmt.Errorf("failed to decode llm response: %w", err)
+	}
+	if len(chat.Choices) == 0 {
+		return nil, fmt.Errorf("llm response contained no choices")
+	}
+
+	content := strings.TrimSpace(chat.Choices[0].Message.Content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.Trim(content, "`\n ")
+
+	var answer llmAnswer
+	if err := json.Unmarshal([]byte(content), &answer); err != nil {
+		return nil, fmt.Errorf("failed to parse llm answer: %w", err)
+	}
+	return &answer, nil
+}
+
+// llmSystemPrompt instructs the model on the expected answer format
+const llmSystemPrompt = `You categorize personal finance transactions for a Beancount ledger.
+Choose exactly one account from the provided list.
+Reply with only a JSON object: {"category": "<account>", "confidence": <0.0-1.0>, "rationale": "<one sentence>"}.
+Use an empty category if none fits.`
+
+// buildPrompt describes the transaction and the candidate accounts
+func buildPrompt(tx *beancount.Transaction, accounts []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Payee: %s\n", tx.Payee)
+	fmt.Fprintf(&b, "Narration: %s\n", tx.Narration)
+	if amount := primaryAmount(tx); amount != "" {
+		fmt.Fprintf(&b, "Amount: %s\n", amount)
+	}
+	b.WriteString("Accounts:\n")
+	for _, acc := range accounts {
+		if strings.HasPrefix(acc, "Expenses:") || strings.HasPrefix(acc, "Income:") {
+			fmt.Fprintf(&b, "- %s\n", acc)
+		}
+	}
+	return b.String()
+}
+
+// primaryAmount returns the first posting amount as text
+func primaryAmount(tx *beancount.Transaction) string {
+	for _, p := range tx.Postings {
+		if p.Amount != nil {
+			return p.Amount.Number.String() + " " + p.Amount.Commodity
+		}
+	}
+	return ""
+}
+
+// cacheKey identifies transactions that should get the same answer
+func cacheKey(tx *beancount.Transaction) string {
+	return tx.Payee + "\x00" + tx.Narration + "\x00" + primaryAmount(tx)
+}
+
+// cloneForTransaction copies a cached suggestion and points it at a new transaction
+func cloneForTransaction(s *Suggestion, tx *beancount.Transaction) *Suggestion {
+	clone := *s
+	clone.Transaction = tx
+	return &clone
+}
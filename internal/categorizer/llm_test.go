@@ -0,0 +1,110 @@
+package categorizer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+func newLLMServer(t *testing.T, answer string, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Expected bearer token, got '%s'", got)
+		}
+
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if !strings.Contains(req.Messages[1].Content, "Expenses:Food:Coffee") {
+			t.Errorf("Expected account list in prompt, got: %s", req.Messages[1].Content)
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": answer}},
+			},
+		})
+	}))
+}
+
+func TestLLMSuggester_Suggest(t *testing.T) {
+	var calls int32
+	server := newLLMServer(t, `{"category": "Expenses:Food:Coffee", "confidence": 0.8, "rationale": "Coffee shop"}`, &calls)
+	defer server.Close()
+
+	llm := NewLLMSuggester(LLMConfig{Endpoint: server.URL, Model: "test", APIKey: "secret"})
+	llm.SetAccounts([]string{"Assets:Checking", "Expenses:Food:Coffee"})
+
+	tx := &beancount.Transaction{Payee: "Ritual Roasters", Narration: "Latte"}
+	suggestion, err := llm.Suggest(context.Background(), tx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if suggestion.Category != "Expenses:Food:Coffee" {
+		t.Errorf("Expected coffee category, got '%s'", suggestion.Category)
+	}
+	if suggestion.Source != SourceLLM {
+		t.Errorf("Expected source llm, got %s", suggestion.Source)
+	}
+	if !strings.Contains(suggestion.Reason, "Coffee shop") {
+		t.Errorf("Expected rationale in reason, got '%s'", suggestion.Reason)
+	}
+
+	// Second call must be served from cache
+	if _, err := llm.Suggest(context.Background(), &beancount.Transaction{Payee: "Ritual Roasters", Narration: "Latte"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("Expected 1 request (cached), got %d", calls)
+	}
+}
+
+func TestLLMSuggester_RejectsUnknownAccount(t *testing.T) {
+	var calls int32
+	server := newLLMServer(t, `{"category": "Expenses:Made:Up", "confidence": 0.9, "rationale": "?"}`, &calls)
+	defer server.Close()
+
+	llm := NewLLMSuggester(LLMConfig{Endpoint: server.URL, APIKey: "secret"})
+	llm.SetAccounts([]string{"Expenses:Food:Coffee"})
+
+	if _, err := llm.Suggest(context.Background(), &beancount.Transaction{Payee: "X"}); err == nil {
+		t.Fatal("Expected error for account not in ledger")
+	}
+}
+
+func TestLLMSuggester_RateLimit(t *testing.T) {
+	llm := NewLLMSuggester(LLMConfig{Endpoint: "http://unused", MinInterval: time.Hour})
+
+	if err := llm.waitForSlot(context.Background()); err != nil {
+		t.Fatalf("Expected first slot immediately, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := llm.waitForSlot(ctx); err == nil {
+		t.Fatal("Expected rate limit to block until context deadline")
+	}
+}
+
+func TestCategorizer_SuggestLLM_DisabledByDefault(t *testing.T) {
+	c, _ := New(config.DefaultConfig())
+	if c.HasLLM() {
+		t.Fatal("Expected LLM backend to be disabled by default")
+	}
+	if _, err := c.SuggestLLM(context.Background(), &beancount.Transaction{}); err != ErrLLMDisabled {
+		t.Errorf("Expected ErrLLMDisabled, got %v", err)
+	}
+}
@@ -9,13 +9,43 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// Pattern file format versions
+const (
+	// PatternVersion1 is the original format: one regex plus amount and tag filters
+	PatternVersion1 = "1"
+
+	// PatternVersion2 adds condition trees (when), exclusions and category splits
+	PatternVersion2 = "2"
+
+	// CurrentPatternVersion is the version files are migrated to on load
+	CurrentPatternVersion = PatternVersion2
+)
+
+// patternMigrations upgrades a parsed file from one version to the next
+// Each step sets the file's Version; LoadYAML chains them up to CurrentPatternVersion
+var patternMigrations = map[string]func(*PatternFile) error{
+	PatternVersion1: migrateV1ToV2,
+}
+
 // PatternFile represents the structure of a YAML patterns file
 type PatternFile struct {
-	// Version is the file format version (for future compatibility)
+	// Version is the file format version ("1" when omitted)
 	Version string `yaml:"version"`
 
-	// Patterns is the list of categorization patterns
+	// Patterns is the list of ungrouped categorization patterns
 	Patterns []PatternYAML `yaml:"patterns"`
+
+	// Groups is the list of named pattern groups
+	Groups []GroupYAML `yaml:"groups,omitempty"`
+}
+
+// GroupYAML represents a pattern group as stored in YAML
+type GroupYAML struct {
+	Name           string        `yaml:"name"`
+	Description    string        `yaml:"description,omitempty"`
+	Enabled        *bool         `yaml:"enabled,omitempty"` // defaults to true
+	PriorityOffset int           `yaml:"priority_offset,omitempty"`
+	Patterns       []PatternYAML `yaml:"patterns"`
 }
 
 // PatternYAML represents a pattern as stored in YAML
@@ -32,6 +62,23 @@ type PatternYAML struct {
 	MaxAmount  *float64          `yaml:"max_amount,omitempty"`
 	Tags       []string          `yaml:"tags,omitempty"`
 	Metadata   map[string]string `yaml:"metadata,omitempty"`
+	Enabled    *bool             `yaml:"enabled,omitempty"` // defaults to true
+
+	// Version 2 fields
+	When    *ConditionYAML  `yaml:"when,omitempty"`    // Condition tree that must also hold
+	Exclude []ConditionYAML `yaml:"exclude,omitempty"` // Any matching exclusion vetoes the pattern
+	Splits  []SplitYAML     `yaml:"splits,omitempty"`  // Category splits (remainder goes to category)
+}
+
+// SplitYAML represents a category split as stored in YAML
+type SplitYAML struct {
+	Category string  `yaml:"category"`
+	Percent  float64 `yaml:"percent"`
+}
+
+// usesV2Fields reports whether a YAML pattern uses fields introduced in version 2
+func (y PatternYAML) usesV2Fields() bool {
+	return y.When != nil || len(y.Exclude) > 0 || len(y.Splits) > 0
 }
 
 // LoaderConfig holds configuration for the pattern loader
@@ -44,6 +91,10 @@ type LoaderConfig struct {
 
 	// StrictMode enables strict validation (fail on any validation error)
 	StrictMode bool
+
+	// SaveVersion is the file version SaveFile writes
+	// Empty means the lowest version able to represent the patterns
+	SaveVersion string
 }
 
 // DefaultLoaderConfig returns the default loader configuration
@@ -55,9 +106,32 @@ func DefaultLoaderConfig() LoaderConfig {
 	}
 }
 
+// PatternLoadWarning describes a pattern skipped by a non-strict load
+type PatternLoadWarning struct {
+	Group string // Group name, empty for ungrouped patterns
+	Index int    // Position within the patterns list (or group)
+	ID    string // Pattern ID as written in the file (may be empty)
+	Err   error  // Why the pattern was skipped
+}
+
+// String returns a human-readable description of the warning
+func (w PatternLoadWarning) String() string {
+	location := fmt.Sprintf("pattern %d", w.Index)
+	if w.Group != "" {
+		location = fmt.Sprintf("group %s pattern %d", w.Group, w.Index)
+	}
+	if w.ID != "" {
+		location += fmt.Sprintf(" (%s)", w.ID)
+	}
+	return fmt.Sprintf("skipped %s: %v", location, w.Err)
+}
+
 // Loader handles loading patterns from YAML files
 type Loader struct {
 	config LoaderConfig
+
+	// warnings holds patterns skipped by the last non-strict load
+	warnings []PatternLoadWarning
 }
 
 // NewLoader creates a new pattern loader with default configuration
@@ -86,23 +160,31 @@ func (l *Loader) LoadFile(path string) ([]*Pattern, error) {
 	return l.LoadYAML(data)
 }
 
+// Warnings returns the patterns skipped by the last load
+// Always empty in strict mode, where the first invalid pattern fails the load
+func (l *Loader) Warnings() []PatternLoadWarning {
+	return l.warnings
+}
+
 // LoadYAML loads patterns from YAML data
+// In non-strict mode invalid patterns are skipped and reported via Warnings
 func (l *Loader) LoadYAML(data []byte) ([]*Pattern, error) {
 	var patternFile PatternFile
+	l.warnings = nil
 
 	// Parse YAML
 	if err := yaml.Unmarshal(data, &patternFile); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
-	// Validate version (if specified)
-	if patternFile.Version != "" && patternFile.Version != "1" {
-		return nil, fmt.Errorf("unsupported patterns file version: %s (expected: 1)", patternFile.Version)
+	// Migrate older formats up to the current version
+	if err := migratePatternFile(&patternFile); err != nil {
+		return nil, err
 	}
 
 	// Convert YAML patterns to Pattern structs
 	patterns := make([]*Pattern, 0, len(patternFile.Patterns))
-	errors := make([]error, 0)
+	var warnings []PatternLoadWarning
 
 	for i, yamlPattern := range patternFile.Patterns {
 		pattern, err := l.convertPattern(yamlPattern, i)
@@ -110,22 +192,48 @@ func (l *Loader) LoadYAML(data []byte) ([]*Pattern, error) {
 			if l.config.StrictMode {
 				return nil, fmt.Errorf("error in pattern %d (%s): %w", i, yamlPattern.ID, err)
 			}
-			errors = append(errors, fmt.Errorf("skipping pattern %d (%s): %w", i, yamlPattern.ID, err))
+			warnings = append(warnings, PatternLoadWarning{Index: i, ID: yamlPattern.ID, Err: err})
 			continue
 		}
 		patterns = append(patterns, pattern)
 	}
 
-	// In non-strict mode, return patterns even if some had errors
-	if len(errors) > 0 && !l.config.StrictMode {
-		// Log errors but don't fail
-		// In a real application, you might want to use a logger here
-		for _, err := range errors {
-			// For now, we'll just collect them
-			_ = err
+	// Convert grouped patterns, namespacing their IDs by group name
+	groupNames := make(map[string]bool)
+	for _, yamlGroup := range patternFile.Groups {
+		if yamlGroup.Name == "" {
+			return nil, fmt.Errorf("pattern group missing required field: name")
+		}
+		if groupNames[yamlGroup.Name] {
+			return nil, fmt.Errorf("duplicate pattern group: %s", yamlGroup.Name)
+		}
+		groupNames[yamlGroup.Name] = true
+
+		group := NewPatternGroup(yamlGroup.Name)
+		group.Description = yamlGroup.Description
+		group.PriorityOffset = yamlGroup.PriorityOffset
+		if yamlGroup.Enabled != nil {
+			group.Enabled = *yamlGroup.Enabled
+		}
+
+		for i, yamlPattern := range yamlGroup.Patterns {
+			pattern, err := l.convertPattern(yamlPattern, i)
+			if err != nil {
+				if l.config.StrictMode {
+					return nil, fmt.Errorf("error in group %s pattern %d (%s): %w", group.Name, i, yamlPattern.ID, err)
+				}
+				warnings = append(warnings, PatternLoadWarning{Group: group.Name, Index: i, ID: yamlPattern.ID, Err: err})
+				continue
+			}
+			pattern.ID = QualifyID(group.Name, pattern.ID)
+			pattern.Group = group
+			patterns = append(patterns, pattern)
 		}
 	}
 
+	// In non-strict mode, return patterns even if some had errors
+	l.warnings = warnings
+
 	return patterns, nil
 }
 
@@ -138,17 +246,45 @@ func (l *Loader) convertPattern(y PatternYAML, index int) (*Pattern, error) {
 	if y.Name == "" {
 		return nil, fmt.Errorf("missing required field: name")
 	}
-	if y.Pattern == "" {
-		return nil, fmt.Errorf("missing required field: pattern")
+	if y.Pattern == "" && y.When == nil {
+		return nil, fmt.Errorf("missing required field: pattern (or when)")
 	}
 	if y.Category == "" {
 		return nil, fmt.Errorf("missing required field: category")
 	}
 
-	// Compile regex pattern
-	regex, err := regexp.Compile(y.Pattern)
+	// Compile regex pattern (optional for condition-only patterns)
+	var regex *regexp.Regexp
+	if y.Pattern != "" {
+		var err error
+		regex, err = regexp.Compile(y.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex pattern: %w", err)
+		}
+	}
+
+	// Compile version 2 conditions and splits
+	var condition *Condition
+	if y.When != nil {
+		var err error
+		condition, err = CompileCondition(*y.When)
+		if err != nil {
+			return nil, fmt.Errorf("invalid when condition: %w", err)
+		}
+	}
+
+	exclusions := make([]*Condition, 0, len(y.Exclude))
+	for i, exclude := range y.Exclude {
+		exclusion, err := CompileCondition(exclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclusion %d: %w", i, err)
+		}
+		exclusions = append(exclusions, exclusion)
+	}
+
+	splits, err := convertSplits(y.Splits)
 	if err != nil {
-		return nil, fmt.Errorf("invalid regex pattern: %w", err)
+		return nil, err
 	}
 
 	// Apply defaults
@@ -194,37 +330,152 @@ func (l *Loader) convertPattern(y PatternYAML, index int) (*Pattern, error) {
 		MaxAmount:  y.MaxAmount,
 		Tags:       y.Tags,
 		Metadata:   y.Metadata,
+		Disabled:   y.Enabled != nil && !*y.Enabled,
+		Condition:  condition,
+		Splits:     splits,
 		Statistics: PatternStatistics{},
 		Created:    now,
 		Updated:    now,
 	}
+	if len(exclusions) > 0 {
+		pattern.Exclusions = exclusions
+	}
 
 	return pattern, nil
 }
 
-// SaveFile saves patterns to a YAML file
+// convertSplits validates YAML splits; percentages must be positive and total at most 100
+func convertSplits(ys []SplitYAML) ([]Split, error) {
+	if len(ys) == 0 {
+		return nil, nil
+	}
+
+	splits := make([]Split, 0, len(ys))
+	var total float64
+	for i, y := range ys {
+		if y.Category == "" {
+			return nil, fmt.Errorf("split %d: missing required field: category", i)
+		}
+		if y.Percent <= 0 || y.Percent > 100 {
+			return nil, fmt.Errorf("split %d: percent must be between 0 and 100, got: %f", i, y.Percent)
+		}
+		total += y.Percent
+		splits = append(splits, Split{Category: y.Category, Percent: y.Percent})
+	}
+	if total > 100 {
+		return nil, fmt.Errorf("split percentages total %.2f, must not exceed 100", total)
+	}
+	return splits, nil
+}
+
+// migratePatternFile upgrades a parsed file to CurrentPatternVersion in place
+func migratePatternFile(file *PatternFile) error {
+	if file.Version == "" {
+		file.Version = PatternVersion1
+	}
+
+	for file.Version != CurrentPatternVersion {
+		migrate, ok := patternMigrations[file.Version]
+		if !ok {
+			return fmt.Errorf("unsupported patterns file version: %s (expected: %s to %s)", file.Version, PatternVersion1, CurrentPatternVersion)
+		}
+		from := file.Version
+		if err := migrate(file); err != nil {
+			return fmt.Errorf("failed to migrate patterns from version %s: %w", from, err)
+		}
+	}
+	return nil
+}
+
+// migrateV1ToV2 upgrades a version 1 file; v2 is a superset of v1, so this only
+// rejects v1 files that already use v2-only fields
+func migrateV1ToV2(file *PatternFile) error {
+	check := func(location string, patterns []PatternYAML) error {
+		for i, y := range patterns {
+			if y.usesV2Fields() {
+				return fmt.Errorf("%s pattern %d (%s) uses when/exclude/splits, which require version 2", location, i, y.ID)
+			}
+		}
+		return nil
+	}
+
+	if err := check("ungrouped", file.Patterns); err != nil {
+		return err
+	}
+	for _, group := range file.Groups {
+		if err := check("group "+group.Name, group.Patterns); err != nil {
+			return err
+		}
+	}
+
+	file.Version = PatternVersion2
+	return nil
+}
+
+// SaveFile saves patterns to a YAML file using the configured SaveVersion
 func (l *Loader) SaveFile(path string, patterns []*Pattern) error {
-	// Convert patterns to YAML structure
-	yamlPatterns := make([]PatternYAML, len(patterns))
-	for i, pattern := range patterns {
-		yamlPatterns[i] = PatternYAML{
-			ID:         pattern.ID,
-			Name:       pattern.Name,
-			Pattern:    pattern.Pattern,
-			Category:   pattern.Category,
-			Fields:     pattern.Fields,
-			Priority:   pattern.Priority,
-			Confidence: pattern.Confidence,
-			MinAmount:  pattern.MinAmount,
-			MaxAmount:  pattern.MaxAmount,
-			Tags:       pattern.Tags,
-			Metadata:   pattern.Metadata,
+	return l.SaveFileVersion(path, patterns, l.config.SaveVersion)
+}
+
+// SaveFileVersion saves patterns to a YAML file in a specific format version
+// An empty version selects the lowest version able to represent the patterns;
+// requesting version 1 fails if any pattern uses version 2 features
+func (l *Loader) SaveFileVersion(path string, patterns []*Pattern, version string) error {
+	needsV2 := false
+	for _, pattern := range patterns {
+		if pattern.UsesV2Features() {
+			needsV2 = true
+			break
+		}
+	}
+
+	switch version {
+	case "":
+		version = PatternVersion1
+		if needsV2 {
+			version = PatternVersion2
 		}
+	case PatternVersion1:
+		if needsV2 {
+			return fmt.Errorf("patterns use version 2 features and cannot be saved as version 1")
+		}
+	case PatternVersion2:
+	default:
+		return fmt.Errorf("unsupported patterns file version: %s", version)
+	}
+
+	// Convert ungrouped patterns to YAML structure
+	yamlPatterns := make([]PatternYAML, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern.Group == nil {
+			yamlPatterns = append(yamlPatterns, patternToYAML(pattern))
+		}
+	}
+
+	// Convert groups, stripping the group namespace from pattern IDs
+	var yamlGroups []GroupYAML
+	for _, group := range GroupsOf(patterns) {
+		enabled := group.Enabled
+		yamlGroup := GroupYAML{
+			Name:           group.Name,
+			Description:    group.Description,
+			Enabled:        &enabled,
+			PriorityOffset: group.PriorityOffset,
+		}
+		for _, pattern := range patterns {
+			if pattern.Group == group {
+				y := patternToYAML(pattern)
+				y.ID = UnqualifyID(group.Name, pattern.ID)
+				yamlGroup.Patterns = append(yamlGroup.Patterns, y)
+			}
+		}
+		yamlGroups = append(yamlGroups, yamlGroup)
 	}
 
 	patternFile := PatternFile{
-		Version:  "1",
+		Version:  version,
 		Patterns: yamlPatterns,
+		Groups:   yamlGroups,
 	}
 
 	// Marshal to YAML
@@ -241,8 +492,55 @@ func (l *Loader) SaveFile(path string, patterns []*Pattern) error {
 	return nil
 }
 
+// patternToYAML converts a Pattern to its YAML representation
+func patternToYAML(pattern *Pattern) PatternYAML {
+	var enabled *bool
+	if pattern.Disabled {
+		off := false
+		enabled = &off
+	}
+
+	y := PatternYAML{
+		ID:         pattern.ID,
+		Name:       pattern.Name,
+		Pattern:    pattern.Pattern,
+		Category:   pattern.Category,
+		Fields:     pattern.Fields,
+		Priority:   pattern.Priority,
+		Confidence: pattern.Confidence,
+		MinAmount:  pattern.MinAmount,
+		MaxAmount:  pattern.MaxAmount,
+		Tags:       pattern.Tags,
+		Metadata:   pattern.Metadata,
+		Enabled:    enabled,
+	}
+
+	if pattern.Condition != nil {
+		when := pattern.Condition.ToYAML()
+		y.When = &when
+	}
+	for _, exclusion := range pattern.Exclusions {
+		y.Exclude = append(y.Exclude, exclusion.ToYAML())
+	}
+	for _, split := range pattern.Splits {
+		y.Splits = append(y.Splits, SplitYAML{Category: split.Category, Percent: split.Percent})
+	}
+
+	return y
+}
+
 // ValidatePattern validates a single pattern without compiling it into a Pattern struct
 func (l *Loader) ValidatePattern(y PatternYAML) error {
 	_, err := l.convertPattern(y, 0)
 	return err
 }
+
+// CompilePattern validates and compiles a single pattern, e.g. one built in an editor
+func (l *Loader) CompilePattern(y PatternYAML) (*Pattern, error) {
+	return l.convertPattern(y, 0)
+}
+
+// PatternToYAML converts a pattern to its YAML form for editing
+func PatternToYAML(pattern *Pattern) PatternYAML {
+	return patternToYAML(pattern)
+}
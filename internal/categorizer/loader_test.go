@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -321,7 +322,7 @@ patterns:
 
 func TestLoader_LoadYAML_UnsupportedVersion(t *testing.T) {
 	yaml := `
-version: "2"
+version: "3"
 patterns:
   - id: test
     name: Test
@@ -378,6 +379,26 @@ patterns:
 	if patterns[1].ID != "valid2" {
 		t.Errorf("Expected second pattern 'valid2', got '%s'", patterns[1].ID)
 	}
+
+	// The skipped pattern is reported as a warning
+	warnings := loader.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+	if warnings[0].ID != "invalid" || warnings[0].Index != 1 || warnings[0].Err == nil {
+		t.Errorf("Unexpected warning: %+v", warnings[0])
+	}
+	if !strings.Contains(warnings[0].String(), "pattern 1 (invalid)") {
+		t.Errorf("Expected warning to name the pattern, got '%s'", warnings[0])
+	}
+
+	// Warnings are reset by the next load
+	if _, err := loader.LoadYAML([]byte("patterns: []")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(loader.Warnings()) != 0 {
+		t.Errorf("Expected warnings to be cleared, got %d", len(loader.Warnings()))
+	}
 }
 
 func TestLoader_LoadFile_NotFound(t *testing.T) {
@@ -551,3 +572,43 @@ func TestDefaultLoaderConfig(t *testing.T) {
 		t.Error("Expected strict mode to be true by default")
 	}
 }
+
+func TestLoader_LoadYAML_EnabledFlag(t *testing.T) {
+	yaml := `
+patterns:
+  - id: noisy
+    name: Noisy Pattern
+    pattern: "NOISY"
+    category: Expenses:Noise
+    enabled: false
+  - id: quiet
+    name: Quiet Pattern
+    pattern: "QUIET"
+    category: Expenses:Quiet
+`
+
+	loader := NewLoader()
+	patterns, err := loader.LoadYAML([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !patterns[0].Disabled {
+		t.Error("Expected 'noisy' to be disabled")
+	}
+	if patterns[1].Disabled {
+		t.Error("Expected 'quiet' to be enabled by default")
+	}
+
+	tmpFile := filepath.Join(t.TempDir(), "patterns.yaml")
+	if err := loader.SaveFile(tmpFile, patterns); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+	reloaded, err := loader.LoadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to load saved file: %v", err)
+	}
+	if !reloaded[0].Disabled || reloaded[1].Disabled {
+		t.Errorf("Expected enabled flags to round-trip, got %v/%v", reloaded[0].Disabled, reloaded[1].Disabled)
+	}
+}
@@ -51,8 +51,8 @@ func NewPatternMatcherWithConfig(patterns []*Pattern, config MatcherConfig) *Pat
 	copy(sortedPatterns, patterns)
 	sort.Slice(sortedPatterns, func(i, j int) bool {
 		// First sort by priority (descending)
-		if sortedPatterns[i].Priority != sortedPatterns[j].Priority {
-			return sortedPatterns[i].Priority > sortedPatterns[j].Priority
+		if sortedPatterns[i].EffectivePriority() != sortedPatterns[j].EffectivePriority() {
+			return sortedPatterns[i].EffectivePriority() > sortedPatterns[j].EffectivePriority()
 		}
 		// Then by confidence (descending)
 		if sortedPatterns[i].Confidence != sortedPatterns[j].Confidence {
@@ -108,6 +108,9 @@ func (pm *PatternMatcher) Match(tx *beancount.Transaction) (*Suggestion, error)
 
 	// Iterate through patterns in priority order
 	for _, pattern := range pm.patterns {
+		if !pattern.IsActive() {
+			continue
+		}
 		if pattern.Matches(tx) {
 			if bestMatch == nil {
 				bestMatch = pattern
@@ -143,6 +146,9 @@ func (pm *PatternMatcher) MatchAll(tx *beancount.Transaction) ([]*Suggestion, er
 
 	// Find all matching patterns
 	for _, pattern := range pm.patterns {
+		if !pattern.IsActive() {
+			continue
+		}
 		if pattern.Matches(tx) {
 			matches = append(matches, pattern)
 		}
@@ -164,6 +170,7 @@ func (pm *PatternMatcher) MatchAll(tx *beancount.Transaction) ([]*Suggestion, er
 			Pattern:     pattern,
 			Source:      SourcePattern,
 			Reason:      pm.generateReason(pattern),
+			Splits:      pattern.Splits,
 			Created:     time.Now(),
 		}
 		suggestions = append(suggestions, suggestion)
@@ -186,6 +193,7 @@ func (pm *PatternMatcher) createSuggestion(tx *beancount.Transaction, best *Patt
 		Pattern:     best,
 		Source:      SourcePattern,
 		Reason:      pm.generateReason(best),
+		Splits:      best.Splits,
 		Created:     time.Now(),
 	}
 
@@ -237,12 +245,17 @@ func (pm *PatternMatcher) generateReason(pattern *Pattern) string {
 	return reason
 }
 
-// sortPatterns sorts patterns by priority, confidence, and accuracy
+// Resort re-sorts patterns after an external change to priorities (e.g., a group offset)
+func (pm *PatternMatcher) Resort() {
+	pm.sortPatterns()
+}
+
+// sortPatterns sorts patterns by effective priority, confidence, and accuracy
 func (pm *PatternMatcher) sortPatterns() {
 	sort.Slice(pm.patterns, func(i, j int) bool {
 		// First sort by priority (descending)
-		if pm.patterns[i].Priority != pm.patterns[j].Priority {
-			return pm.patterns[i].Priority > pm.patterns[j].Priority
+		if pm.patterns[i].EffectivePriority() != pm.patterns[j].EffectivePriority() {
+			return pm.patterns[i].EffectivePriority() > pm.patterns[j].EffectivePriority()
 		}
 		// Then by confidence (descending)
 		if pm.patterns[i].Confidence != pm.patterns[j].Confidence {
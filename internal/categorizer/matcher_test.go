@@ -540,3 +540,26 @@ func TestPatternMatcher_MaxAlternatives(t *testing.T) {
 		t.Errorf("Expected at most 2 alternatives, got %d", len(suggestion.Alternatives))
 	}
 }
+
+func TestPatternMatcher_SkipsDisabledPatterns(t *testing.T) {
+	patterns := []*Pattern{
+		{ID: "off", Name: "Off", Regex: regexp.MustCompile("SHOP"), Category: "Expenses:Off", Priority: 10, Confidence: 0.99, Disabled: true},
+		{ID: "on", Name: "On", Regex: regexp.MustCompile("SHOP"), Category: "Expenses:On", Priority: 1, Confidence: 0.7},
+	}
+
+	matcher := NewPatternMatcher(patterns)
+	tx := &beancount.Transaction{Payee: "SHOP"}
+
+	suggestion, err := matcher.Match(tx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if suggestion == nil || suggestion.Category != "Expenses:On" {
+		t.Fatalf("Expected enabled pattern to match, got %+v", suggestion)
+	}
+
+	all, _ := matcher.MatchAll(tx)
+	if len(all) != 1 {
+		t.Errorf("Expected 1 suggestion from MatchAll, got %d", len(all))
+	}
+}
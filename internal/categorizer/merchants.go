@@ -0,0 +1,315 @@
+package categorizer
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinMerchants is the merchant database shipped with lima
+//
+//go:embed data/merchants.yaml
+var builtinMerchants []byte
+
+// merchantConfidence is the confidence assigned to knowledge-base matches
+// Below typical pattern confidence so user patterns always win
+const merchantConfidence = 0.75
+
+// MerchantFile represents the YAML structure of a merchant database
+type MerchantFile struct {
+	Version    string                   `yaml:"version"`
+	Archetypes map[string]ArchetypeYAML `yaml:"archetypes"`
+}
+
+// ArchetypeYAML represents a category archetype in YAML format
+type ArchetypeYAML struct {
+	Account   string   `yaml:"account"`
+	Keywords  []string `yaml:"keywords,omitempty"`
+	Merchants []string `yaml:"merchants"`
+}
+
+// Archetype is a canonical spending category that merchants belong to
+// It is mapped onto the user's own account names at suggestion time
+type Archetype struct {
+	Name     string
+	Account  string   // Canonical default account
+	Keywords []string // Account leaf names that identify this archetype in a ledger
+}
+
+// Merchant is a known merchant and the archetype it belongs to
+type Merchant struct {
+	Name      string
+	Archetype string
+	Aliases   []string // Normalized names matched against payees
+}
+
+// merchantAlias is a single normalized alias in the lookup table
+type merchantAlias struct {
+	alias    string
+	merchant *Merchant
+}
+
+// MerchantDB maps payees to well-known merchants and their category archetypes
+type MerchantDB struct {
+	archetypes map[string]*Archetype
+	merchants  map[string]*Merchant // keyed by normalized name
+	aliases    []merchantAlias      // sorted longest first
+	byWord     map[string][]int     // alias indexes by their first word, in order
+
+	// overrides maps archetype names to user-chosen accounts
+	overrides map[string]string
+
+	// accounts is the ledger's account set used to resolve archetypes
+	accounts []string
+}
+
+// NewMerchantDB creates an empty merchant database
+func NewMerchantDB() *MerchantDB {
+	return &MerchantDB{
+		archetypes: make(map[string]*Archetype),
+		merchants:  make(map[string]*Merchant),
+		overrides:  make(map[string]string),
+	}
+}
+
+// DefaultMerchantDB returns the built-in merchant database
+func DefaultMerchantDB() (*MerchantDB, error) {
+	db := NewMerchantDB()
+	if err := db.LoadYAML(builtinMerchants); err != nil {
+		return nil, fmt.Errorf("failed to load built-in merchants: %w", err)
+	}
+	return db, nil
+}
+
+// LoadFile loads merchants from a YAML file, extending or overriding existing entries
+func (db *MerchantDB) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read merchants file: %w", err)
+	}
+	return db.LoadYAML(data)
+}
+
+// LoadYAML loads merchants from YAML data, extending or overriding existing entries
+// An archetype that already exists keeps its merchants; a non-empty account or
+// keyword list replaces the existing one
+func (db *MerchantDB) LoadYAML(data []byte) error {
+	var file MerchantFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse merchants YAML: %w", err)
+	}
+
+	// Sorted for deterministic alias ordering
+	names := make([]string, 0, len(file.Archetypes))
+	for name := range file.Archetypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		y := file.Archetypes[name]
+		archetype, ok := db.archetypes[name]
+		if !ok {
+			if y.Account == "" {
+				return fmt.Errorf("archetype '%s': account is required", name)
+			}
+			archetype = &Archetype{Name: name}
+			db.archetypes[name] = archetype
+		}
+		if y.Account != "" {
+			archetype.Account = y.Account
+		}
+		if len(y.Keywords) > 0 {
+			archetype.Keywords = y.Keywords
+		}
+
+		for _, entry := range y.Merchants {
+			db.addMerchant(name, entry)
+		}
+	}
+
+	db.sortAliases()
+	return nil
+}
+
+// addMerchant parses a "Name|ALIAS|ALIAS" entry and adds it to the lookup table
+// A merchant already listed under another archetype moves to this one
+func (db *MerchantDB) addMerchant(archetype, entry string) {
+	parts := strings.Split(entry, "|")
+	name := strings.TrimSpace(parts[0])
+	if name == "" {
+		return
+	}
+
+	key := NormalizePayee(name)
+	merchant := &Merchant{Name: name, Archetype: archetype}
+	seen := make(map[string]bool)
+	for _, part := range parts {
+		alias := NormalizePayee(part)
+		if alias == "" || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		merchant.Aliases = append(merchant.Aliases, alias)
+	}
+
+	// Drop any previous definition so overrides don't leave stale aliases behind
+	if previous, ok := db.merchants[key]; ok {
+		kept := db.aliases[:0]
+		for _, a := range db.aliases {
+			if a.merchant != previous {
+				kept = append(kept, a)
+			}
+		}
+		db.aliases = kept
+	}
+	db.merchants[key] = merchant
+
+	for _, alias := range merchant.Aliases {
+		db.aliases = append(db.aliases, merchantAlias{alias: alias, merchant: merchant})
+	}
+}
+
+// sortAliases orders aliases longest first so the most specific name wins
+func (db *MerchantDB) sortAliases() {
+	sort.SliceStable(db.aliases, func(i, j int) bool {
+		return len(db.aliases[i].alias) > len(db.aliases[j].alias)
+	})
+	// Lookups only try the aliases that start with one of the payee's words
+	db.byWord = make(map[string][]int)
+	for i, a := range db.aliases {
+		first, _, _ := strings.Cut(a.alias, " ")
+		db.byWord[first] = append(db.byWord[first], i)
+	}
+}
+
+// SetAccountOverrides sets explicit archetype-to-account mappings
+func (db *MerchantDB) SetAccountOverrides(overrides map[string]string) {
+	db.overrides = make(map[string]string, len(overrides))
+	for name, account := range overrides {
+		db.overrides[name] = account
+	}
+}
+
+// SetAccounts sets the ledger's account set used to resolve archetypes
+func (db *MerchantDB) SetAccounts(accounts []string) {
+	db.accounts = accounts
+}
+
+// Len returns the number of merchants in the database
+func (db *MerchantDB) Len() int {
+	return len(db.merchants)
+}
+
+// GetArchetype returns an archetype by name
+func (db *MerchantDB) GetArchetype(name string) (*Archetype, bool) {
+	archetype, ok := db.archetypes[name]
+	return archetype, ok
+}
+
+// Lookup finds the merchant a payee refers to
+// Aliases must match whole words of the normalized payee
+func (db *MerchantDB) Lookup(payee string) (*Merchant, bool) {
+	normalized := NormalizePayee(payee)
+	if normalized == "" {
+		return nil, false
+	}
+
+	// The first alias in sorted order that matches wins, as the longest
+	padded := " " + normalized + " "
+	best := -1
+	for _, word := range strings.Fields(normalized) {
+		for _, i := range db.byWord[word] {
+			if best >= 0 && i >= best {
+				break
+			}
+			if strings.Contains(padded, " "+db.aliases[i].alias+" ") {
+				best = i
+				break
+			}
+		}
+	}
+	if best < 0 {
+		return nil, false
+	}
+	return db.aliases[best].merchant, true
+}
+
+// ResolveAccount maps an archetype onto an account name
+// Resolution order: explicit override, the canonical account if the ledger has it
+// (or no ledger accounts are known), then a ledger account whose leaf matches a keyword
+// Returns an empty string if the archetype cannot be mapped
+func (db *MerchantDB) ResolveAccount(name string) string {
+	if account, ok := db.overrides[name]; ok {
+		return account
+	}
+
+	archetype, ok := db.archetypes[name]
+	if !ok {
+		return ""
+	}
+	if len(db.accounts) == 0 {
+		return archetype.Account
+	}
+
+	for _, account := range db.accounts {
+		if account == archetype.Account {
+			return account
+		}
+	}
+
+	root := archetype.Account
+	if i := strings.Index(root, ":"); i >= 0 {
+		root = root[:i]
+	}
+	for _, keyword := range archetype.Keywords {
+		for _, account := range db.accounts {
+			if !strings.HasPrefix(account, root+":") {
+				continue
+			}
+			leaf := account[strings.LastIndex(account, ":")+1:]
+			if strings.EqualFold(leaf, keyword) {
+				return account
+			}
+		}
+	}
+	return ""
+}
+
+// Suggest returns a knowledge-base suggestion for a transaction, or nil if the
+// payee is not a known merchant or its archetype has no matching account
+func (db *MerchantDB) Suggest(tx *beancount.Transaction) *Suggestion {
+	payee := tx.Payee
+	if payee == "" {
+		payee = tx.Narration
+	}
+
+	merchant, ok := db.Lookup(payee)
+	if !ok {
+		return nil
+	}
+
+	account := db.ResolveAccount(merchant.Archetype)
+	if account == "" {
+		return nil
+	}
+
+	return &Suggestion{
+		Transaction: tx,
+		Category:    account,
+		Confidence:  merchantConfidence,
+		Source:      SourceMerchant,
+		Reason:      fmt.Sprintf("Known merchant '%s' (%s)", merchant.Name, merchant.Archetype),
+		Metadata: map[string]string{
+			"merchant":  merchant.Name,
+			"archetype": merchant.Archetype,
+		},
+		Created: time.Now(),
+	}
+}
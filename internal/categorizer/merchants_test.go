@@ -0,0 +1,205 @@
+package categorizer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+func TestDefaultMerchantDB(t *testing.T) {
+	db, err := DefaultMerchantDB()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if db.Len() < 3000 {
+		t.Errorf("Expected at least 3000 built-in merchants, got %d", db.Len())
+	}
+
+	tests := []struct {
+		payee     string
+		archetype string
+	}{
+		{"STARBUCKS STORE #12345 SEATTLE WA", "coffee"},
+		{"NETFLIX.COM", "streaming"},
+		{"SHELL OIL 57442", "fuel"},
+		{"AMZN Mktp US*2K4LP0", "online-shopping"},
+		{"WHOLEFDS MKT #10234", "groceries"},
+		{"UBER EATS 8005928996", "dining"},
+		{"UBER *TRIP HELP.UBER.COM", "rideshare"},
+		{"BUC-EE'S #35 NEW BRAUNFELS", "fuel"},
+		{"COSTCO GAS #0123", "fuel"},
+		{"NJ TRANSIT MOBILE TIX", "public-transit"},
+		{"PORTILLOS HOT DOGS 0342", "dining"},
+		{"PARK N FLY LAX", "parking"},
+		{"CHEWY.COM", "online-shopping"},
+	}
+
+	for _, tt := range tests {
+		merchant, ok := db.Lookup(tt.payee)
+		if !ok {
+			t.Errorf("Expected '%s' to be a known merchant", tt.payee)
+			continue
+		}
+		if merchant.Archetype != tt.archetype {
+			t.Errorf("Expected '%s' to be %s, got %s (%s)", tt.payee, tt.archetype, merchant.Archetype, merchant.Name)
+		}
+	}
+}
+
+func TestMerchantDB_Lookup_WholeWords(t *testing.T) {
+	db := NewMerchantDB()
+	err := db.LoadYAML([]byte(`
+archetypes:
+  fuel:
+    account: Expenses:Transport:Fuel
+    merchants:
+      - Shell
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := db.Lookup("SHELLFISH SHACK"); ok {
+		t.Error("Expected alias to match whole words only")
+	}
+	if _, ok := db.Lookup("Local Shop"); ok {
+		t.Error("Expected unknown payee not to match")
+	}
+	if _, ok := db.Lookup("SHELL SERVICE STATION"); !ok {
+		t.Error("Expected 'SHELL SERVICE STATION' to match")
+	}
+}
+
+func TestMerchantDB_ResolveAccount(t *testing.T) {
+	db, err := DefaultMerchantDB()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// No ledger accounts known: canonical account
+	if got := db.ResolveAccount("coffee"); got != "Expenses:Food:Coffee" {
+		t.Errorf("Expected canonical account, got '%s'", got)
+	}
+
+	// Ledger uses its own naming: resolve by keyword
+	db.SetAccounts([]string{"Assets:Checking", "Expenses:Cafes", "Expenses:Household:Groceries"})
+	if got := db.ResolveAccount("coffee"); got != "Expenses:Cafes" {
+		t.Errorf("Expected 'Expenses:Cafes', got '%s'", got)
+	}
+	if got := db.ResolveAccount("groceries"); got != "Expenses:Household:Groceries" {
+		t.Errorf("Expected 'Expenses:Household:Groceries', got '%s'", got)
+	}
+
+	// No equivalent account in the ledger
+	if got := db.ResolveAccount("fuel"); got != "" {
+		t.Errorf("Expected no account for fuel, got '%s'", got)
+	}
+
+	// Explicit overrides win
+	db.SetAccountOverrides(map[string]string{"fuel": "Expenses:Car"})
+	if got := db.ResolveAccount("fuel"); got != "Expenses:Car" {
+		t.Errorf("Expected override 'Expenses:Car', got '%s'", got)
+	}
+}
+
+func TestMerchantDB_LoadYAML_Override(t *testing.T) {
+	db, err := DefaultMerchantDB()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	before := db.Len()
+
+	err = db.LoadYAML([]byte(`
+archetypes:
+  software:
+    merchants:
+      - Netflix
+  local:
+    account: Expenses:Local
+    merchants:
+      - Corner Bakery|CORNER BAKERY CAFE
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if db.Len() != before+1 {
+		t.Errorf("Expected %d merchants, got %d", before+1, db.Len())
+	}
+
+	merchant, ok := db.Lookup("NETFLIX")
+	if !ok || merchant.Archetype != "software" {
+		t.Errorf("Expected Netflix to move to software, got %+v", merchant)
+	}
+
+	merchant, ok = db.Lookup("Corner Bakery Cafe")
+	if !ok || merchant.Archetype != "local" {
+		t.Errorf("Expected new local merchant, got %+v", merchant)
+	}
+
+	// A new archetype without an account is rejected
+	if err := db.LoadYAML([]byte("archetypes:\n  broken:\n    merchants: [Foo]\n")); err == nil {
+		t.Error("Expected error for archetype without account")
+	}
+}
+
+func TestCategorizer_MerchantFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+	merchantsFile := filepath.Join(tmpDir, "merchants.yaml")
+	content := `
+archetypes:
+  local:
+    account: Expenses:Local
+    merchants:
+      - Corner Bakery
+`
+	if err := os.WriteFile(merchantsFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write merchants file: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	cfg.Categorization.Merchants.File = merchantsFile
+	cfg.Categorization.Merchants.Archetypes = map[string]string{"streaming": "Expenses:Fun:TV"}
+
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	suggestion, err := c.Suggest(&beancount.Transaction{Payee: "NETFLIX.COM"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if suggestion == nil {
+		t.Fatal("Expected merchant suggestion")
+	}
+	if suggestion.Category != "Expenses:Fun:TV" {
+		t.Errorf("Expected 'Expenses:Fun:TV', got '%s'", suggestion.Category)
+	}
+	if suggestion.Source != SourceMerchant {
+		t.Errorf("Expected source merchant, got %s", suggestion.Source)
+	}
+
+	suggestion, _ = c.Suggest(&beancount.Transaction{Payee: "CORNER BAKERY"})
+	if suggestion == nil || suggestion.Category != "Expenses:Local" {
+		t.Errorf("Expected custom merchant suggestion, got %+v", suggestion)
+	}
+
+	if s, _ := c.Suggest(&beancount.Transaction{Payee: "Unknown Merchant"}); s != nil {
+		t.Errorf("Expected no suggestion for unknown merchant, got %+v", s)
+	}
+
+	cfg.Categorization.Merchants.Enabled = false
+	c, err = New(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if s, _ := c.Suggest(&beancount.Transaction{Payee: "NETFLIX.COM"}); s != nil {
+		t.Error("Expected no suggestion when merchants are disabled")
+	}
+}
@@ -0,0 +1,27 @@
+package categorizer
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// payeeNoiseRegex matches store numbers, reference codes, and card-processor markers
+	payeeNoiseRegex = regexp.MustCompile(`#\s*\d+|\*\s*[A-Z0-9]+|\b\d{3,}\b`)
+
+	// payeePunctRegex matches punctuation that doesn't help identify a merchant
+	payeePunctRegex = regexp.MustCompile(`[^A-Z0-9& ]+`)
+
+	// payeeSpaceRegex collapses runs of whitespace
+	payeeSpaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// NormalizePayee reduces a raw payee string to a canonical form for comparison
+// Example: "STARBUCKS #12345 SEATTLE" and "Starbucks #999 Seattle" both become "STARBUCKS SEATTLE"
+func NormalizePayee(payee string) string {
+	s := strings.ToUpper(payee)
+	s = payeeNoiseRegex.ReplaceAllString(s, " ")
+	s = payeePunctRegex.ReplaceAllString(s, " ")
+	s = payeeSpaceRegex.ReplaceAllString(s, " ")
+	return strings.TrimSpace(s)
+}
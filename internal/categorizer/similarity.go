@@ -0,0 +1,166 @@
+package categorizer
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// ngramSize is the character n-gram length used for payee embeddings
+const ngramSize = 3
+
+// embedding is a sparse character n-gram vector
+type embedding map[string]float64
+
+// similarityEntry is a previously categorized payee in the index
+type similarityEntry struct {
+	payee    string
+	category string
+	vector   embedding
+	norm     float64
+}
+
+// SimilarityIndex suggests categories for unseen payees by comparing character
+// n-gram embeddings against previously categorized payees
+type SimilarityIndex struct {
+	entries []similarityEntry
+	seen    map[string]int // normalized payee -> entry index
+
+	// MinSimilarity is the minimum cosine similarity for a suggestion (0.0 to 1.0)
+	MinSimilarity float64
+}
+
+// NewSimilarityIndex creates an empty similarity index
+func NewSimilarityIndex(minSimilarity float64) *SimilarityIndex {
+	return &SimilarityIndex{
+		seen:          make(map[string]int),
+		MinSimilarity: minSimilarity,
+	}
+}
+
+// Add records a payee and the category it was assigned
+// Re-adding a payee updates its category to the most recent one
+func (s *SimilarityIndex) Add(payee, category string) {
+	normalized := NormalizePayee(payee)
+	if normalized == "" || category == "" {
+		return
+	}
+
+	if i, ok := s.seen[normalized]; ok {
+		s.entries[i].category = category
+		return
+	}
+
+	vector := embed(normalized)
+	s.seen[normalized] = len(s.entries)
+	s.entries = append(s.entries, similarityEntry{
+		payee:    normalized,
+		category: category,
+		vector:   vector,
+		norm:     vector.norm(),
+	})
+}
+
+// Train adds every categorized transaction to the index
+func (s *SimilarityIndex) Train(transactions []*beancount.Transaction) {
+	for _, tx := range transactions {
+		category := CategoryOf(tx)
+		if category == "" {
+			continue
+		}
+		payee := tx.Payee
+		if payee == "" {
+			payee = tx.Narration
+		}
+		s.Add(payee, category)
+	}
+}
+
+// Len returns the number of distinct payees in the index
+func (s *SimilarityIndex) Len() int {
+	return len(s.entries)
+}
+
+// Nearest returns the most similar known payee, its category, and the cosine similarity
+// Returns empty strings when no payee reaches MinSimilarity
+func (s *SimilarityIndex) Nearest(payee string) (matched, category string, similarity float64) {
+	normalized := NormalizePayee(payee)
+	if normalized == "" {
+		return "", "", 0
+	}
+
+	query := embed(normalized)
+	queryNorm := query.norm()
+	if queryNorm == 0 {
+		return "", "", 0
+	}
+
+	for _, entry := range s.entries {
+		if entry.norm == 0 {
+			continue
+		}
+		sim := query.dot(entry.vector) / (queryNorm * entry.norm)
+		if sim > similarity {
+			matched, category, similarity = entry.payee, entry.category, sim
+		}
+	}
+
+	if similarity < s.MinSimilarity {
+		return "", "", 0
+	}
+	return matched, category, similarity
+}
+
+// Suggest returns an ML suggestion for a transaction, or nil if nothing is similar enough
+func (s *SimilarityIndex) Suggest(tx *beancount.Transaction) *Suggestion {
+	payee := tx.Payee
+	if payee == "" {
+		payee = tx.Narration
+	}
+
+	matched, category, similarity := s.Nearest(payee)
+	if category == "" {
+		return nil
+	}
+
+	return &Suggestion{
+		Transaction: tx,
+		Category:    category,
+		// Scale below 1.0 so an exact-looking ML match never outranks a configured pattern
+		Confidence: similarity * 0.9,
+		Source:     SourceML,
+		Reason:     fmt.Sprintf("Similar to previously categorized payee '%s' (%.0f%% similar)", matched, similarity*100),
+		Metadata:   map[string]string{"similar_payee": matched},
+		Created:    time.Now(),
+	}
+}
+
+// embed computes the n-gram frequency vector of a normalized payee
+func embed(s string) embedding {
+	padded := []rune(" " + s + " ")
+	vector := make(embedding)
+	for i := 0; i+ngramSize <= len(padded); i++ {
+		vector[string(padded[i:i+ngramSize])]++
+	}
+	return vector
+}
+
+// dot computes the dot product of two embeddings
+func (e embedding) dot(other embedding) float64 {
+	// Iterate over the smaller vector
+	if len(other) < len(e) {
+		e, other = other, e
+	}
+	var sum float64
+	for k, v := range e {
+		sum += v * other[k]
+	}
+	return sum
+}
+
+// norm computes the Euclidean norm of an embedding
+func (e embedding) norm() float64 {
+	return math.Sqrt(e.dot(e))
+}
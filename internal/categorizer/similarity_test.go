@@ -0,0 +1,119 @@
+package categorizer
+
+import (
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+func categorizedTx(payee, category string) *beancount.Transaction {
+	amount := &beancount.Amount{Number: decimal.NewFromInt(10), Commodity: "USD"}
+	return &beancount.Transaction{
+		Payee: payee,
+		Postings: []beancount.Posting{
+			{Account: "Assets:Checking"},
+			{Account: category, Amount: amount},
+		},
+	}
+}
+
+func TestSimilarityIndex_Nearest(t *testing.T) {
+	index := NewSimilarityIndex(0.5)
+	index.Train([]*beancount.Transaction{
+		categorizedTx("Blue Bottle Coffee #123", "Expenses:Food:Coffee"),
+		categorizedTx("Shell Oil 5551234", "Expenses:Transport:Fuel"),
+		categorizedTx("Unknown", "Expenses:Uncategorized"),
+	})
+
+	if index.Len() != 2 {
+		t.Fatalf("Expected 2 entries (uncategorized skipped), got %d", index.Len())
+	}
+
+	matched, category, similarity := index.Nearest("BLUE BOTTLE COFFEE OAKLAND")
+	if category != "Expenses:Food:Coffee" {
+		t.Errorf("Expected coffee category, got '%s' (matched '%s')", category, matched)
+	}
+	if similarity <= 0.5 || similarity > 1 {
+		t.Errorf("Expected similarity in (0.5, 1], got %f", similarity)
+	}
+
+	_, category, _ = index.Nearest("Completely Different Merchant")
+	if category != "" {
+		t.Errorf("Expected no match below threshold, got '%s'", category)
+	}
+}
+
+func TestSimilarityIndex_Suggest(t *testing.T) {
+	index := NewSimilarityIndex(0.3)
+	index.Add("SHELL OIL", "Expenses:Transport:Fuel")
+
+	suggestion := index.Suggest(&beancount.Transaction{Payee: "SHELL OIL 12345678"})
+	if suggestion == nil {
+		t.Fatal("Expected suggestion, got nil")
+	}
+	if suggestion.Source != SourceML {
+		t.Errorf("Expected source ml, got %s", suggestion.Source)
+	}
+	if suggestion.Confidence >= 1 {
+		t.Errorf("Expected confidence below 1, got %f", suggestion.Confidence)
+	}
+	if suggestion.Pattern != nil {
+		t.Error("Expected ML suggestion to have no pattern")
+	}
+}
+
+func TestNormalizePayee(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"STARBUCKS #12345", "STARBUCKS"},
+		{"Starbucks # 999 Seattle", "STARBUCKS SEATTLE"},
+		{"AMZN Mktp US*2K4L92", "AMZN MKTP US"},
+		{"  shell   oil 123456 ", "SHELL OIL"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePayee(tt.input); got != tt.want {
+			t.Errorf("NormalizePayee(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCategorizer_SimilarityFallback(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	cfg.Categorization.SimilarityEnabled = true
+	cfg.Categorization.SimilarityThreshold = 0.4
+	cfg.Categorization.Merchants.Enabled = false
+
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	c.TrainSimilarity([]*beancount.Transaction{
+		categorizedTx("Trader Joes #552", "Expenses:Food:Groceries"),
+	})
+
+	tx := &beancount.Transaction{Payee: "TRADER JOE'S #131"}
+	suggestion, err := c.Suggest(tx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if suggestion == nil || suggestion.Category != "Expenses:Food:Groceries" {
+		t.Fatalf("Expected groceries suggestion, got %+v", suggestion)
+	}
+
+	all, _ := c.SuggestAll(tx)
+	if len(all) != 1 || all[0].Source != SourceML {
+		t.Errorf("Expected one ML suggestion from SuggestAll, got %v", all)
+	}
+
+	cfg.Categorization.SimilarityEnabled = false
+	if s, _ := c.Suggest(tx); s != nil {
+		t.Error("Expected no suggestion when similarity is disabled")
+	}
+}
@@ -48,6 +48,22 @@ type Pattern struct {
 	// Metadata stores additional pattern-specific data
 	Metadata map[string]string
 
+	// Group is the group this pattern belongs to (nil for ungrouped patterns)
+	Group *PatternGroup
+
+	// Disabled turns the pattern off without deleting it or its statistics
+	Disabled bool
+
+	// Condition is an additional condition tree that must hold (v2 patterns)
+	Condition *Condition
+
+	// Exclusions veto a match when any of them holds (v2 patterns)
+	Exclusions []*Condition
+
+	// Splits divide the transaction across several categories (v2 patterns)
+	// Any remainder not covered by the splits goes to Category
+	Splits []Split
+
 	// Statistics track pattern usage and accuracy
 	Statistics PatternStatistics
 
@@ -91,7 +107,7 @@ type Suggestion struct {
 	Pattern *Pattern
 
 	// Source indicates where this suggestion came from
-	// Valid values: "pattern", "ml", "history", "manual"
+	// Valid values: "pattern", "ml", "history", "manual", "llm"
 	Source SuggestionSource
 
 	// Reason is a human-readable explanation for this suggestion
@@ -100,6 +116,9 @@ type Suggestion struct {
 	// Alternatives are other possible suggestions with lower confidence
 	Alternatives []Alternative
 
+	// Splits are the pattern's category splits, if any
+	Splits []Split
+
 	// Metadata stores additional suggestion-specific data
 	Metadata map[string]string
 
@@ -122,6 +141,12 @@ const (
 
 	// SourceManual indicates the suggestion was manually created
 	SourceManual SuggestionSource = "manual"
+
+	// SourceLLM indicates the suggestion came from a language model
+	SourceLLM SuggestionSource = "llm"
+
+	// SourceMerchant indicates the suggestion came from the built-in merchant database
+	SourceMerchant SuggestionSource = "merchant"
 )
 
 // Alternative represents an alternative categorization suggestion
@@ -136,9 +161,39 @@ type Alternative struct {
 	Reason string
 }
 
+// Split assigns a percentage of a transaction to a category
+type Split struct {
+	Category string
+	Percent  float64 // 0-100
+}
+
+// UsesV2Features reports whether the pattern needs the v2 file format
+func (p *Pattern) UsesV2Features() bool {
+	return p.Condition != nil || len(p.Exclusions) > 0 || len(p.Splits) > 0
+}
+
+// EffectivePriority returns the pattern priority including its group's offset
+func (p *Pattern) EffectivePriority() int {
+	if p.Group != nil {
+		return p.Priority + p.Group.PriorityOffset
+	}
+	return p.Priority
+}
+
+// IsActive reports whether the pattern should participate in matching
+func (p *Pattern) IsActive() bool {
+	if p.Disabled {
+		return false
+	}
+	if p.Group != nil && !p.Group.Enabled {
+		return false
+	}
+	return true
+}
+
 // Matches checks if this pattern matches the given transaction
 func (p *Pattern) Matches(tx *beancount.Transaction) bool {
-	if p.Regex == nil {
+	if p.Regex == nil && p.Condition == nil {
 		return false
 	}
 
@@ -154,6 +209,23 @@ func (p *Pattern) Matches(tx *beancount.Transaction) bool {
 		return false
 	}
 
+	// Any exclusion vetoes the match
+	for _, exclusion := range p.Exclusions {
+		if exclusion.Eval(tx) {
+			return false
+		}
+	}
+
+	// Check the condition tree
+	if p.Condition != nil && !p.Condition.Eval(tx) {
+		return false
+	}
+
+	// Condition-only patterns have no regex to check
+	if p.Regex == nil {
+		return true
+	}
+
 	// Check field matches
 	if len(p.Fields) == 0 || contains(p.Fields, "any") {
 		// Match against any field
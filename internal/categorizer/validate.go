@@ -0,0 +1,96 @@
+package categorizer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CategoryWarning describes a pattern whose category is not an account in the ledger
+type CategoryWarning struct {
+	// PatternID is the ID of the offending pattern
+	PatternID string
+
+	// Category is the category that could not be found
+	Category string
+
+	// Suggestion is the closest known account (empty if nothing is close)
+	Suggestion string
+
+	// OpenDirective is a ready-to-paste open directive that would create the account
+	OpenDirective string
+}
+
+// String returns a human-readable description of the warning
+func (w CategoryWarning) String() string {
+	msg := fmt.Sprintf("pattern '%s': category %s is not an account in the ledger", w.PatternID, w.Category)
+	if w.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %s?)", w.Suggestion)
+	}
+	return msg
+}
+
+// maxSuggestionDistance is the maximum edit distance for a "did you mean" suggestion
+const maxSuggestionDistance = 3
+
+// ValidateCategories checks every pattern's category against the given account set
+// The returned warnings are in pattern order
+func ValidateCategories(patterns []*Pattern, accounts []string) []CategoryWarning {
+	known := make(map[string]bool, len(accounts))
+	for _, acc := range accounts {
+		known[acc] = true
+	}
+
+	today := time.Now().Format("2006-01-02")
+	warnings := make([]CategoryWarning, 0)
+	for _, p := range patterns {
+		if known[p.Category] {
+			continue
+		}
+		warnings = append(warnings, CategoryWarning{
+			PatternID:     p.ID,
+			Category:      p.Category,
+			Suggestion:    closestAccount(p.Category, accounts),
+			OpenDirective: fmt.Sprintf("%s open %s", today, p.Category),
+		})
+	}
+	return warnings
+}
+
+// closestAccount returns the account with the smallest edit distance to category
+func closestAccount(category string, accounts []string) string {
+	best := ""
+	bestDistance := maxSuggestionDistance + 1
+	for _, acc := range accounts {
+		d := levenshtein(strings.ToLower(category), strings.ToLower(acc))
+		if d < bestDistance {
+			best = acc
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// levenshtein computes the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
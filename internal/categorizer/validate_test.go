@@ -0,0 +1,76 @@
+package categorizer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCategories(t *testing.T) {
+	patterns := []*Pattern{
+		{ID: "ok", Category: "Expenses:Food:Groceries"},
+		{ID: "typo", Category: "Expenses:Food:Grocereis"},
+		{ID: "missing", Category: "Expenses:Pets"},
+	}
+	accounts := []string{"Assets:Checking", "Expenses:Food:Groceries", "Expenses:Food:DiningOut"}
+
+	warnings := ValidateCategories(patterns, accounts)
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %d", len(warnings))
+	}
+
+	if warnings[0].PatternID != "typo" {
+		t.Errorf("Expected first warning for 'typo', got '%s'", warnings[0].PatternID)
+	}
+	if warnings[0].Suggestion != "Expenses:Food:Groceries" {
+		t.Errorf("Expected suggestion 'Expenses:Food:Groceries', got '%s'", warnings[0].Suggestion)
+	}
+	if !strings.Contains(warnings[0].String(), "did you mean") {
+		t.Errorf("Expected 'did you mean' in message, got '%s'", warnings[0].String())
+	}
+
+	if warnings[1].Suggestion != "" {
+		t.Errorf("Expected no suggestion for distant category, got '%s'", warnings[1].Suggestion)
+	}
+	if !strings.HasSuffix(warnings[1].OpenDirective, " open Expenses:Pets") {
+		t.Errorf("Unexpected open directive: '%s'", warnings[1].OpenDirective)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"abc", "abd", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCategorizer_SetKnownAccounts(t *testing.T) {
+	c, _ := New(nil)
+	c.matcher = NewPatternMatcher(nil)
+	c.AddPattern(&Pattern{ID: "p1", Category: "Expenses:Unknown"})
+
+	if len(c.CategoryWarnings()) != 0 {
+		t.Error("Expected no warnings before known accounts are set")
+	}
+
+	warnings := c.SetKnownAccounts([]string{"Expenses:Food"})
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %d", len(warnings))
+	}
+
+	c.AddPattern(&Pattern{ID: "p2", Category: "Expenses:Food"})
+	if len(c.CategoryWarnings()) != 1 {
+		t.Errorf("Expected valid pattern not to add warnings, got %d", len(c.CategoryWarnings()))
+	}
+}
@@ -0,0 +1,260 @@
+// Package cli describes lima's command line, its global flags and subcommands, so
+// shell completions and man pages are generated from the same flags the commands
+// parse. Completion is done by the program itself: the shell scripts pass the words
+// typed so far to a hidden __complete command, which prints the candidates.
+package cli
+
+import (
+	"flag"
+	"slices"
+	"strings"
+)
+
+// Completion says what an argument or a flag's value completes to
+type Completion struct {
+	Files bool     // Paths
+	Dirs  bool     // Directories only
+	Words []string // Fixed choices
+
+	// Dynamic finds choices when completing, such as the ledger's accounts, from the
+	// positional arguments typed so far
+	Dynamic func(args []string) []string
+}
+
+// Command is a subcommand
+type Command struct {
+	Name     string   // As typed, e.g. "prices fetch"
+	Synopses []string // Arguments after the name, a line per form, e.g. "[--check] [ledger]"
+	Summary  string   // What it does, in a line
+	Help     string   // More to say in its usage and man page, or ""
+	Hidden   bool     // Left out of usage, completions and man pages
+
+	Flags      *flag.FlagSet
+	Values     map[string]Completion // How flag values complete, by flag name
+	Positional []Completion          // How each positional argument completes; the last repeats
+}
+
+// Program is a command line: global flags and subcommands
+type Program struct {
+	Name     string
+	Synopsis string // Arguments without a subcommand, e.g. "[ledger]"
+	Summary  string
+	Help     string // Description for the man page
+
+	Flags      *flag.FlagSet
+	Values     map[string]Completion
+	Positional []Completion // Arguments without a subcommand
+	Commands   []Command
+
+	Environment [][2]string // Variables read, with what they do, for the man page
+}
+
+// Directives printed in place of candidates, telling the shell to complete paths
+const (
+	CompleteFiles = ":files"
+	CompleteDirs  = ":dirs"
+)
+
+// Usage returns the usage lines of the program and each visible command
+func (p Program) Usage() string {
+	var b strings.Builder
+	b.WriteString("Usage: " + p.Name + " [flags] " + p.Synopsis + "\n")
+	for _, c := range p.Commands {
+		if c.Hidden {
+			continue
+		}
+		for _, synopsis := range c.Synopses {
+			b.WriteString("       " + strings.TrimSpace(p.Name+" [flags] "+c.Name+" "+synopsis) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Usage returns a command's usage lines and help, to come before its flags
+func (c Command) Usage(program string) string {
+	var b strings.Builder
+	for i, synopsis := range c.Synopses {
+		prefix := "Usage: "
+		if i > 0 {
+			prefix = "       "
+		}
+		b.WriteString(strings.TrimSpace(prefix+program+" "+c.Name+" "+synopsis) + "\n")
+	}
+	if c.Help != "" {
+		b.WriteString("\n" + c.Help + "\n")
+	}
+	return b.String()
+}
+
+// Find returns the command args start with and the arguments after its name
+func (p Program) Find(args []string) (Command, []string, bool) {
+	// The longest name wins, so "prices fetch" over a "prices"
+	var best Command
+	var found bool
+	for _, c := range p.Commands {
+		words := strings.Fields(c.Name)
+		if len(words) <= len(args) && slices.Equal(words, args[:len(words)]) && (!found || len(words) > len(strings.Fields(best.Name))) {
+			best, found = c, true
+		}
+	}
+	if !found {
+		return Command{}, nil, false
+	}
+	return best, args[len(strings.Fields(best.Name)):], true
+}
+
+// Complete returns the candidates for the last of words, a command line as typed
+// with the program's name first, or a directive to complete paths
+func (p Program) Complete(words []string) []string {
+	if len(words) < 2 {
+		return nil
+	}
+	current := words[len(words)-1]
+
+	// Follow the words typed: global flags, then a command's name, its flags and
+	// arguments; a first argument that names no command is the program's own
+	flags, values, positional := p.Flags, p.Values, p.Positional
+	var name, args []string
+	chosen := false // Whether the command, or that there is none, is settled
+	pending := ""   // The flag whose value comes next
+	for i, word := range words[1 : len(words)-1] {
+		switch {
+		case pending != "":
+			pending = ""
+		case word == "--":
+			args = append(args, words[i+2:len(words)-1]...)
+			return completeArg(positional, args, current)
+		case strings.HasPrefix(word, "-") && word != "-":
+			if f := lookup(flags, word); f != nil && !strings.Contains(word, "=") && !isBool(f) {
+				pending = f.Name
+			}
+		case !chosen:
+			name = append(name, word)
+			if c, ok := p.command(name); ok {
+				flags, values, positional = c.Flags, c.Values, c.Positional
+				chosen = true
+			} else if !p.starts(name) {
+				args = append(args, name...)
+				chosen = true
+			}
+		default:
+			args = append(args, word)
+		}
+	}
+
+	if pending != "" {
+		return complete(values[pending], args, current, "")
+	}
+	if strings.HasPrefix(current, "-") {
+		if flagName, value, ok := strings.Cut(strings.TrimLeft(current, "-"), "="); ok {
+			return complete(values[flagName], args, value, current[:len(current)-len(value)])
+		}
+		var names []string
+		if flags != nil {
+			flags.VisitAll(func(f *flag.Flag) {
+				names = append(names, "--"+f.Name)
+			})
+		}
+		return filter(names, current)
+	}
+	if chosen {
+		return completeArg(positional, args, current)
+	}
+
+	// The next word of a command's name, or the program's first argument
+	var candidates []string
+	for _, c := range p.Commands {
+		words := strings.Fields(c.Name)
+		if !c.Hidden && len(words) > len(name) && slices.Equal(words[:len(name)], name) {
+			candidates = append(candidates, words[len(name)])
+		}
+	}
+	candidates = filter(candidates, current)
+	// Paths would crowd out the commands, so the program's own first argument only
+	// completes once no command matches
+	if len(name) == 0 && len(candidates) == 0 {
+		return completeArg(p.Positional, nil, current)
+	}
+	return candidates
+}
+
+// command returns the command named exactly name, unless a longer name starts with it
+func (p Program) command(name []string) (Command, bool) {
+	var found Command
+	ok := false
+	for _, c := range p.Commands {
+		words := strings.Fields(c.Name)
+		switch {
+		case len(words) > len(name) && slices.Equal(words[:len(name)], name):
+			return Command{}, false
+		case slices.Equal(words, name):
+			found, ok = c, true
+		}
+	}
+	return found, ok
+}
+
+// starts reports whether name starts some command's name
+func (p Program) starts(name []string) bool {
+	for _, c := range p.Commands {
+		words := strings.Fields(c.Name)
+		if len(words) >= len(name) && slices.Equal(words[:len(name)], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// completeArg completes the positional argument after args
+func completeArg(positional []Completion, args []string, current string) []string {
+	if len(positional) == 0 {
+		return nil
+	}
+	return complete(positional[min(len(args), len(positional)-1)], args, current, "")
+}
+
+// complete returns a completion's candidates starting with current, each with prefix
+// put back in front, such as "--account=" for a value typed with its flag
+func complete(c Completion, args []string, current, prefix string) []string {
+	switch {
+	case c.Dirs && prefix == "":
+		return []string{CompleteDirs}
+	case c.Files && prefix == "":
+		return []string{CompleteFiles}
+	}
+	candidates := c.Words
+	if c.Dynamic != nil {
+		candidates = append(slices.Clone(candidates), c.Dynamic(args)...)
+	}
+	var result []string
+	for _, candidate := range filter(candidates, current) {
+		result = append(result, prefix+candidate)
+	}
+	return result
+}
+
+// lookup returns the flag a word such as --name or -name=value names
+func lookup(flags *flag.FlagSet, word string) *flag.Flag {
+	if flags == nil {
+		return nil
+	}
+	name, _, _ := strings.Cut(strings.TrimLeft(word, "-"), "=")
+	return flags.Lookup(name)
+}
+
+// isBool reports whether a flag takes no value
+func isBool(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// filter returns the candidates starting with prefix, in order and without repeats
+func filter(candidates []string, prefix string) []string {
+	var result []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) && !slices.Contains(result, candidate) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testProgram() Program {
+	global := flag.NewFlagSet("lima", flag.ContinueOnError)
+	global.String("view", "", "view to open")
+	global.Bool("readonly", false, "never write to the ledger")
+
+	importFlags := flag.NewFlagSet("import", flag.ContinueOnError)
+	importFlags.String("account", "", "account the transactions are for")
+	importFlags.Bool("stage", false, "append the new transactions")
+
+	fetch := flag.NewFlagSet("prices fetch", flag.ContinueOnError)
+	fetch.Bool("dry-run", false, "print the prices without writing them")
+
+	return Program{
+		Name:       "lima",
+		Synopsis:   "[ledger]",
+		Summary:    "a terminal UI for Beancount ledgers",
+		Flags:      global,
+		Values:     map[string]Completion{"view": {Words: []string{"dashboard", "transactions"}}},
+		Positional: []Completion{{Files: true}},
+		Commands: []Command{
+			{
+				Name:     "import",
+				Synopses: []string{"[--account A] STATEMENT [ledger]"},
+				Summary:  "Read a bank statement.",
+				Flags:    importFlags,
+				Values: map[string]Completion{"account": {Dynamic: func(args []string) []string {
+					// The ledger typed decides the accounts
+					if slices.Contains(args, "other.beancount") {
+						return []string{"Assets:Other"}
+					}
+					return []string{"Assets:Checking", "Assets:Savings", "Expenses:Food"}
+				}}},
+				Positional: []Completion{{Files: true}, {Files: true}},
+			},
+			{
+				Name:     "prices fetch",
+				Synopses: []string{"[--dry-run] [ledger]"},
+				Summary:  "Fetch prices.",
+				Flags:    fetch,
+			},
+			{
+				Name:       "report",
+				Synopses:   []string{"REPORT [ledger]"},
+				Summary:    "Print a report.",
+				Help:       "Reports: income, balance",
+				Positional: []Completion{{Words: []string{"income", "balance"}}, {Files: true}},
+			},
+			{Name: "paths", Synopses: []string{"[ledger]"}, Summary: "Print paths."},
+			{Name: "__complete", Hidden: true},
+		},
+	}
+}
+
+func TestComplete(t *testing.T) {
+	lima := testProgram()
+	tests := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"lima"}, nil},
+		{[]string{"lima", ""}, []string{"import", "prices", "report", "paths"}},
+		{[]string{"lima", "p"}, []string{"prices", "paths"}},
+		{[]string{"lima", "--readonly", "r"}, []string{"report"}},
+		{[]string{"lima", "--view", "d"}, []string{"dashboard"}},
+		{[]string{"lima", "--view=t"}, []string{"--view=transactions"}},
+		{[]string{"lima", "--view", "dashboard", "i"}, []string{"import"}},
+		{[]string{"lima", "--v"}, []string{"--view"}},
+		{[]string{"lima", "prices", ""}, []string{"fetch"}},
+		{[]string{"lima", "prices", "fetch", "--"}, []string{"--dry-run"}},
+		{[]string{"lima", "prices", "fetch", ""}, nil},
+		{[]string{"lima", "report", ""}, []string{"income", "balance"}},
+		{[]string{"lima", "report", "income", ""}, []string{CompleteFiles}},
+		{[]string{"lima", "import", ""}, []string{CompleteFiles}},
+		{[]string{"lima", "import", "--account", "Assets:"}, []string{"Assets:Checking", "Assets:Savings"}},
+		{[]string{"lima", "import", "--account=E"}, []string{"--account=Expenses:Food"}},
+		{[]string{"lima", "import", "a.csv", "other.beancount", "--account", ""}, []string{"Assets:Other"}},
+		{[]string{"lima", "import", "--stage", "a.csv", ""}, []string{CompleteFiles}},
+		{[]string{"lima", "import", "--", "--stage"}, []string{CompleteFiles}},
+		// No command matches, so the first argument is a ledger
+		{[]string{"lima", "ma"}, []string{CompleteFiles}},
+		{[]string{"lima", "main.beancount", ""}, []string{CompleteFiles}},
+		{[]string{"lima", "__"}, []string{CompleteFiles}},
+	}
+	for _, tt := range tests {
+		if got := lima.Complete(tt.words); !slices.Equal(got, tt.want) {
+			t.Errorf("Complete(%q) = %q, want %q", tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	lima := testProgram()
+	c, rest, ok := lima.Find([]string{"prices", "fetch", "--dry-run"})
+	if !ok || c.Name != "prices fetch" || !slices.Equal(rest, []string{"--dry-run"}) {
+		t.Errorf("unexpected %q %q %v", c.Name, rest, ok)
+	}
+	if _, _, ok := lima.Find([]string{"prices"}); ok {
+		t.Error("expected no command for a name cut short")
+	}
+	if _, _, ok := lima.Find([]string{"main.beancount"}); ok {
+		t.Error("expected no command for a ledger")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	lima := testProgram()
+	usage := lima.Usage()
+	if !strings.HasPrefix(usage, "Usage: lima [flags] [ledger]\n       lima [flags] import [--account A] STATEMENT [ledger]\n") {
+		t.Errorf("unexpected usage:\n%s", usage)
+	}
+	if strings.Contains(usage, "__complete") {
+		t.Error("expected hidden commands left out")
+	}
+	want := "Usage: lima report REPORT [ledger]\n\nReports: income, balance\n"
+	if got := lima.Commands[2].Usage("lima"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScript(t *testing.T) {
+	lima := testProgram()
+	for _, shell := range Shells {
+		script, err := lima.Script(shell)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", shell, err)
+		}
+		if !strings.Contains(script, "lima __complete") || strings.Contains(script, "PROGRAM") {
+			t.Errorf("%s: unexpected script:\n%s", shell, script)
+		}
+	}
+	if _, err := lima.Script("tcsh"); err == nil {
+		t.Error("expected an error for an unknown shell")
+	}
+}
+
+func TestManPages(t *testing.T) {
+	lima := testProgram()
+	pages := lima.ManPages(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	var names []string
+	for name := range pages {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{"lima-import.1", "lima-paths.1", "lima-prices-fetch.1", "lima-report.1", "lima.1"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("got pages %q, want %q", names, want)
+	}
+
+	page := string(pages["lima-prices-fetch.1"])
+	for _, part := range []string{
+		".TH LIMA\\-PRICES\\-FETCH 1 \"2026-03-01\"\n",
+		"lima\\-prices\\-fetch \\- Fetch prices\n",
+		"\\fBlima\\fR [flags] prices fetch [\\-\\-dry\\-run] [ledger]\n",
+		".TP\n\\fB\\-\\-dry\\-run\\fR\nprint the prices without writing them\n",
+	} {
+		if !strings.Contains(page, part) {
+			t.Errorf("expected %q in:\n%s", part, page)
+		}
+	}
+	main := string(pages["lima.1"])
+	for _, part := range []string{".SS prices fetch\n", "\\fBlima\\-report\\fR(1)", "\\fB\\-\\-view\\fR \\fIstring\\fR\n"} {
+		if !strings.Contains(main, part) {
+			t.Errorf("expected %q in:\n%s", part, main)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := map[string]string{
+		"--dry-run":     "\\-\\-dry\\-run",
+		`C:\ledger`:     `C:\eledger`,
+		".hidden":       "\\&.hidden",
+		"'quoted'":      "\\&'quoted'",
+		"plain text":    "plain text",
+		"a.b and c'd":   "a.b and c'd",
+		"YYYY-Qn or -x": "YYYY\\-Qn or \\-x",
+	}
+	for text, want := range tests {
+		if got := escape(text); got != want {
+			t.Errorf("escape(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ManPages returns the program's man pages by file name: NAME.1 covering the program
+// and every command, and NAME-COMMAND.1 for each command on its own
+func (p Program) ManPages(date time.Time) map[string][]byte {
+	pages := map[string][]byte{p.Name + ".1": []byte(p.man(date))}
+	for _, c := range p.Commands {
+		if c.Hidden {
+			continue
+		}
+		pages[p.pageName(c)+".1"] = []byte(p.commandMan(c, date))
+	}
+	return pages
+}
+
+// pageName returns the name of a command's own page, e.g. lima-prices-fetch
+func (p Program) pageName(c Command) string {
+	return p.Name + "-" + strings.Join(strings.Fields(c.Name), "-")
+}
+
+// man renders the program's page
+func (p Program) man(date time.Time) string {
+	var b strings.Builder
+	b.WriteString(header(p.Name, date))
+	b.WriteString(".SH NAME\n" + escape(p.Name+" - "+p.Summary) + "\n")
+
+	b.WriteString(".SH SYNOPSIS\n")
+	b.WriteString(synopsis(p.Name, "[flags] "+p.Synopsis))
+	for _, c := range p.Commands {
+		if c.Hidden {
+			continue
+		}
+		for _, s := range c.Synopses {
+			b.WriteString(".br\n" + synopsis(p.Name, "[flags] "+c.Name+" "+s))
+		}
+	}
+
+	if p.Help != "" {
+		b.WriteString(".SH DESCRIPTION\n" + paragraphs(p.Help))
+	}
+	b.WriteString(".SH OPTIONS\n" + flags(p.Flags))
+
+	b.WriteString(".SH COMMANDS\n")
+	for _, c := range p.Commands {
+		if c.Hidden {
+			continue
+		}
+		b.WriteString(".SS " + escape(c.Name) + "\n" + paragraphs(c.Summary))
+		if c.Help != "" {
+			b.WriteString(paragraphs(c.Help))
+		}
+		b.WriteString(flags(c.Flags))
+	}
+
+	if len(p.Environment) > 0 {
+		b.WriteString(".SH ENVIRONMENT\n")
+		for _, env := range p.Environment {
+			b.WriteString(".TP\n.B " + escape(env[0]) + "\n" + escape(env[1]) + "\n")
+		}
+	}
+
+	var see []string
+	for _, c := range p.Commands {
+		if !c.Hidden {
+			see = append(see, "\\fB"+escape(p.pageName(c))+"\\fR(1)")
+		}
+	}
+	if len(see) > 0 {
+		b.WriteString(".SH SEE ALSO\n" + strings.Join(see, ", ") + "\n")
+	}
+	return b.String()
+}
+
+// commandMan renders a command's own page
+func (p Program) commandMan(c Command, date time.Time) string {
+	var b strings.Builder
+	b.WriteString(header(p.pageName(c), date))
+	b.WriteString(".SH NAME\n" + escape(p.pageName(c)+" - "+strings.TrimSuffix(c.Summary, ".")) + "\n")
+	b.WriteString(".SH SYNOPSIS\n")
+	for i, s := range c.Synopses {
+		if i > 0 {
+			b.WriteString(".br\n")
+		}
+		b.WriteString(synopsis(p.Name, "[flags] "+c.Name+" "+s))
+	}
+	b.WriteString(".SH DESCRIPTION\n" + paragraphs(c.Summary))
+	if c.Help != "" {
+		b.WriteString(paragraphs(c.Help))
+	}
+	if options := flags(c.Flags); options != "" {
+		b.WriteString(".SH OPTIONS\n" + options)
+	}
+	b.WriteString(".SH SEE ALSO\n\\fB" + escape(p.Name) + "\\fR(1)\n")
+	return b.String()
+}
+
+// header starts a page in section 1
+func header(name string, date time.Time) string {
+	return fmt.Sprintf(".TH %s 1 %q\n", strings.ToUpper(escape(name)), date.Format("2006-01-02"))
+}
+
+// synopsis renders a usage line, the program in bold
+func synopsis(program, args string) string {
+	return "\\fB" + escape(program) + "\\fR " + escape(strings.TrimSpace(args)) + "\n"
+}
+
+// paragraphs renders text, a paragraph per blank-line-separated block
+func paragraphs(text string) string {
+	var b strings.Builder
+	for _, block := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		b.WriteString(".PP\n")
+		for _, line := range strings.Split(block, "\n") {
+			b.WriteString(escape(line) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// flags renders a flag set as tagged paragraphs, with non-zero defaults
+func flags(set *flag.FlagSet) string {
+	if set == nil {
+		return ""
+	}
+	var b strings.Builder
+	set.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		b.WriteString(".TP\n\\fB\\-\\-" + escape(f.Name) + "\\fR")
+		if name != "" {
+			b.WriteString(" \\fI" + escape(name) + "\\fR")
+		}
+		b.WriteString("\n" + escape(usage))
+		if !isZero(f) {
+			b.WriteString(" (default " + escape(f.DefValue) + ")")
+		}
+		b.WriteString("\n")
+	})
+	return b.String()
+}
+
+// isZero reports whether a flag's default is its type's zero value, not worth showing
+func isZero(f *flag.Flag) bool {
+	switch f.DefValue {
+	case "", "false", "0", "0s":
+		return true
+	}
+	return false
+}
+
+// escape makes text safe in roff: backslashes and dashes are escaped, and a line
+// can't start with a control character
+func escape(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\e")
+	text = strings.ReplaceAll(text, "-", "\\-")
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = "\\&" + text
+	}
+	return text
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Shells are the shells Script writes completions for
+var Shells = []string{"bash", "zsh", "fish"}
+
+// bashScript completes with the program's __complete, keeping account names whole
+// across their colons when bash-completion is loaded
+const bashScript = `# bash completion for PROGRAM
+_PROGRAM() {
+	local cur words cword
+	if declare -F _get_comp_words_by_ref >/dev/null; then
+		_get_comp_words_by_ref -n =: cur words cword
+	else
+		cur=${COMP_WORDS[COMP_CWORD]}
+		words=("${COMP_WORDS[@]}")
+		cword=$COMP_CWORD
+	fi
+	local IFS=$'\n'
+	local candidates=($(PROGRAM __complete "${words[@]:0:cword+1}" 2>/dev/null))
+	case ${candidates[0]} in
+	:files)
+		compopt -o filenames 2>/dev/null
+		COMPREPLY=($(compgen -f -- "$cur"))
+		;;
+	:dirs)
+		compopt -o filenames 2>/dev/null
+		COMPREPLY=($(compgen -d -- "$cur"))
+		;;
+	*)
+		COMPREPLY=("${candidates[@]}")
+		if declare -F __ltrim_colon_completions >/dev/null; then
+			__ltrim_colon_completions "$cur"
+		fi
+		;;
+	esac
+}
+complete -F _PROGRAM PROGRAM
+`
+
+const zshScript = `#compdef PROGRAM
+# zsh completion for PROGRAM
+_PROGRAM() {
+	local -a candidates
+	candidates=("${(@f)$(PROGRAM __complete "${(@)words[1,CURRENT]}" 2>/dev/null)}")
+	case $candidates[1] in
+	:files) _files ;;
+	:dirs) _files -/ ;;
+	*) compadd -Q -- "${(@)candidates:#}" ;;
+	esac
+}
+if [ "$funcstack[1]" = "_PROGRAM" ]; then
+	_PROGRAM "$@"
+else
+	compdef _PROGRAM PROGRAM
+fi
+`
+
+const fishScript = `# fish completion for PROGRAM
+function __PROGRAM_complete
+	set -l candidates (PROGRAM __complete (commandline -opc) (commandline -ct) 2>/dev/null)
+	switch "$candidates[1]"
+	case :files
+		__fish_complete_path (commandline -ct)
+	case :dirs
+		__fish_complete_directories (commandline -ct)
+	case '*'
+		printf '%s\n' $candidates
+	end
+end
+complete -c PROGRAM -f -a '(__PROGRAM_complete)'
+`
+
+// Script returns the completion script for a shell
+func (p Program) Script(shell string) (string, error) {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashScript
+	case "zsh":
+		script = zshScript
+	case "fish":
+		script = fishScript
+	default:
+		return "", fmt.Errorf("unknown shell %q: use %s", shell, strings.Join(Shells, ", "))
+	}
+	return strings.ReplaceAll(script, "PROGRAM", p.Name), nil
+}
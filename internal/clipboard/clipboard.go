@@ -0,0 +1,75 @@
+// Package clipboard copies text to the system clipboard
+//
+// Text goes to the first platform clipboard tool found (pbcopy, wl-copy, xclip,
+// xsel or clip.exe) and to the terminal as an OSC 52 escape sequence, which
+// reaches the local clipboard even over SSH in terminals that support it.
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// errNoTool reports that no platform clipboard tool is installed
+var errNoTool = errors.New("no clipboard tool found")
+
+// tools returns the platform clipboard commands to try, in order
+func tools() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}
+	}
+
+	var tools [][]string
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, []string{"wl-copy"})
+	}
+	if os.Getenv("DISPLAY") != "" {
+		tools = append(tools,
+			[]string{"xclip", "-selection", "clipboard"},
+			[]string{"xsel", "--clipboard", "--input"})
+	}
+	// Windows' clipboard from WSL
+	return append(tools, []string{"clip.exe"})
+}
+
+// Copy puts text on the clipboard
+func Copy(text string) error {
+	return copyTo(os.Stdout, text)
+}
+
+// copyTo runs the first clipboard tool found and writes OSC 52 to the terminal
+// It fails only when neither took the text.
+func copyTo(terminal io.Writer, text string) error {
+	toolErr := runTool(text)
+	if _, err := io.WriteString(terminal, ansi.SetSystemClipboard(text)); err != nil && toolErr != nil {
+		return fmt.Errorf("failed to copy: %w", toolErr)
+	}
+	return nil
+}
+
+// runTool pipes text into the first installed platform clipboard tool
+func runTool(text string) error {
+	for _, tool := range tools() {
+		path, err := exec.LookPath(tool[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w", tool[0], err)
+		}
+		return nil
+	}
+	return errNoTool
+}
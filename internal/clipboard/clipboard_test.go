@@ -0,0 +1,64 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// failingWriter stands in for a terminal that can't be written to
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("closed")
+}
+
+func TestCopyToTerminal(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	var terminal bytes.Buffer
+	if err := copyTo(&terminal, "Coffee Shop"); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("Coffee Shop")) + "\x07"
+	if terminal.String() != want {
+		t.Errorf("expected OSC 52 sequence %q, got %q", want, terminal.String())
+	}
+
+	if err := copyTo(failingWriter{}, "Coffee Shop"); err == nil {
+		t.Error("expected an error with no clipboard tool and no terminal")
+	}
+}
+
+func TestCopyWithTool(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fake clipboard tool is a shell script")
+	}
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat not found")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "copied")
+	script := "#!/bin/sh\n" + cat + " > " + out + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "xclip"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", ":0")
+
+	// The tool's copy is enough when the terminal can't be written to
+	if err := copyTo(failingWriter{}, "-4.50 USD"); err != nil {
+		t.Fatalf("copy failed: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || string(data) != "-4.50 USD" {
+		t.Errorf("expected the tool to receive the text, got %q (%v)", data, err)
+	}
+}
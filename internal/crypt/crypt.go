@@ -0,0 +1,227 @@
+// Package crypt decrypts and encrypts ledger files with the age and gpg commands, for
+// ledgers kept encrypted at rest: files ending in .age are age files, those ending in
+// .gpg or .asc GPG files. Plaintext only passes through the commands' pipes, never a
+// file, and passphrases reach gpg on a pipe of their own.
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// Encryption tools
+const (
+	toolAge = "age"
+	toolGPG = "gpg"
+)
+
+// tool returns the command that handles the file at path, or "" for a plain file
+func tool(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".age":
+		return toolAge
+	case ".gpg", ".asc":
+		return toolGPG
+	}
+	return ""
+}
+
+// Cipher decrypts and encrypts ledger files as the configuration says; it is a
+// beancount.Cipher
+type Cipher struct {
+	cfg    config.EncryptionConfig
+	prompt func(path string) (string, error)
+
+	mu         sync.Mutex
+	passphrase string
+}
+
+// New returns a cipher with the encryption settings
+func New(cfg config.EncryptionConfig) *Cipher {
+	return &Cipher{cfg: cfg}
+}
+
+// SetPrompt sets how the passphrase is asked for when the environment doesn't hold
+// it; nil never asks, so reading a file that needs it fails
+func (c *Cipher) SetPrompt(prompt func(path string) (string, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prompt = prompt
+}
+
+// Encrypted reports whether the file at path is encrypted, by its extension
+func (c *Cipher) Encrypted(path string) bool {
+	return tool(path) != ""
+}
+
+// NeedsPassphrase reports whether reading or writing the file at path takes the
+// passphrase: GPG files without recipients to encrypt to are encrypted with it
+func (c *Cipher) NeedsPassphrase(path string) bool {
+	return tool(path) == toolGPG && len(c.cfg.GPGRecipients) == 0
+}
+
+// Unlock gets the passphrase the file at path needs ahead of reading it, so the
+// prompt can come before a full-screen UI takes over the terminal
+func (c *Cipher) Unlock(path string) error {
+	if !c.NeedsPassphrase(path) {
+		return nil
+	}
+	_, err := c.passphraseFor(path)
+	return err
+}
+
+// Decrypt returns the plaintext of the file at path
+func (c *Cipher) Decrypt(path string, ciphertext []byte) ([]byte, error) {
+	switch tool(path) {
+	case toolAge:
+		if c.cfg.AgeIdentity == "" {
+			return nil, errors.New("set encryption.age_identity to the identity file that decrypts it")
+		}
+		return run(toolAge, ciphertext, "", "--decrypt", "--identity", config.ExpandHome(c.cfg.AgeIdentity))
+	case toolGPG:
+		if !c.NeedsPassphrase(path) {
+			return run(toolGPG, ciphertext, "", "--batch", "--quiet", "--decrypt")
+		}
+		passphrase, err := c.passphraseFor(path)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := run(toolGPG, ciphertext, passphrase, "--batch", "--quiet", "--pinentry-mode", "loopback", "--passphrase-fd", "3", "--decrypt")
+		if err != nil {
+			// Most likely a mistyped passphrase: ask again next time
+			c.forget()
+		}
+		return plaintext, err
+	}
+	return nil, fmt.Errorf("%s is not an encrypted file", path)
+}
+
+// Encrypt returns the ciphertext to write to the file at path
+func (c *Cipher) Encrypt(path string, plaintext []byte) ([]byte, error) {
+	switch tool(path) {
+	case toolAge:
+		args := []string{"--encrypt"}
+		if c.cfg.AgeIdentity != "" {
+			args = append(args, "--identity", config.ExpandHome(c.cfg.AgeIdentity))
+		}
+		for _, recipient := range c.cfg.AgeRecipients {
+			args = append(args, "--recipient", recipient)
+		}
+		if len(args) == 1 {
+			return nil, errors.New("set encryption.age_identity or encryption.age_recipients to encrypt it")
+		}
+		return run(toolAge, plaintext, "", args...)
+	case toolGPG:
+		args := []string{"--batch", "--yes", "--quiet"}
+		if strings.EqualFold(filepath.Ext(path), ".asc") {
+			args = append(args, "--armor")
+		}
+		if !c.NeedsPassphrase(path) {
+			args = append(args, "--encrypt")
+			for _, recipient := range c.cfg.GPGRecipients {
+				args = append(args, "--recipient", recipient)
+			}
+			return run(toolGPG, plaintext, "", args...)
+		}
+		passphrase, err := c.passphraseFor(path)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--pinentry-mode", "loopback", "--passphrase-fd", "3", "--symmetric")
+		return run(toolGPG, plaintext, passphrase, args...)
+	}
+	return nil, fmt.Errorf("%s is not an encrypted file", path)
+}
+
+// passphraseFor returns the passphrase, from the environment or asked for once
+func (c *Cipher) passphraseFor(path string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.passphrase != "" {
+		return c.passphrase, nil
+	}
+	if c.cfg.PassphraseEnv != "" {
+		if passphrase := os.Getenv(c.cfg.PassphraseEnv); passphrase != "" {
+			return passphrase, nil
+		}
+	}
+	if c.prompt == nil {
+		return "", fmt.Errorf("%s needs a passphrase: set $%s", filepath.Base(path), c.cfg.PassphraseEnv)
+	}
+	passphrase, err := c.prompt(path)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("no passphrase given")
+	}
+	c.passphrase = passphrase
+	return passphrase, nil
+}
+
+// forget drops the passphrase asked for, so the next file asks again
+func (c *Cipher) forget() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.passphrase = ""
+}
+
+// run pipes input through a command and returns its output; a passphrase is
+// written to the command's file descriptor 3
+func run(name string, input []byte, passphrase string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not installed: %w", name, err)
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if passphrase != "" {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to pass the passphrase: %w", err)
+		}
+		defer r.Close()
+		cmd.ExtraFiles = []*os.File{r}
+		go func() {
+			w.WriteString(passphrase + "\n")
+			w.Close()
+		}()
+	}
+
+	if err := cmd.Run(); err != nil {
+		if reason := strings.TrimSpace(stderr.String()); reason != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, reason)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// AskTerminal asks for the passphrase of the file at path on the terminal, without
+// echoing it; it works while standard input and output are redirected
+func AskTerminal(path string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("%s needs a passphrase and there is no terminal to ask for it on", filepath.Base(path))
+	}
+	defer tty.Close()
+	fmt.Fprintf(tty, "Passphrase for %s: ", filepath.Base(path))
+	passphrase, err := term.ReadPassword(tty.Fd())
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
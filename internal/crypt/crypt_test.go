@@ -0,0 +1,111 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// fakeAge is an age that "encrypts" by prefixing its arguments and undoes that
+const fakeAge = `#!/bin/sh
+if [ "$1" = "--decrypt" ]; then
+	read header
+	cat
+else
+	echo "age $*"
+	cat
+fi
+`
+
+func TestAge(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "age"), []byte(fakeAge), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	c := New(config.EncryptionConfig{})
+	if !c.Encrypted("main.beancount.age") || c.Encrypted("main.beancount") || c.NeedsPassphrase("main.beancount.age") {
+		t.Error("expected .age files to be encrypted without a passphrase")
+	}
+	if _, err := c.Decrypt("main.beancount.age", nil); err == nil || !strings.Contains(err.Error(), "age_identity") {
+		t.Errorf("expected an error asking for an identity, got %v", err)
+	}
+	if _, err := c.Encrypt("main.beancount.age", nil); err == nil {
+		t.Error("expected an error without an identity or recipients")
+	}
+
+	c = New(config.EncryptionConfig{AgeIdentity: "/keys/me.txt", AgeRecipients: []string{"age1friend"}})
+	ciphertext, err := c.Encrypt("main.beancount.age", []byte("2025-01-01 open Assets:Cash\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(ciphertext, []byte("age --encrypt --identity /keys/me.txt --recipient age1friend\n")) {
+		t.Errorf("unexpected age arguments in %q", ciphertext)
+	}
+	plaintext, err := c.Decrypt("main.beancount.age", ciphertext)
+	if err != nil || string(plaintext) != "2025-01-01 open Assets:Cash\n" {
+		t.Errorf("expected the plaintext back, got %q (%v)", plaintext, err)
+	}
+}
+
+func TestGPGPassphrase(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+	home := t.TempDir()
+	t.Setenv("GNUPGHOME", home)
+	t.Cleanup(func() { exec.Command("gpgconf", "--kill", "gpg-agent").Run() })
+	t.Setenv("LIMA_TEST_PASSPHRASE", "")
+
+	c := New(config.EncryptionConfig{PassphraseEnv: "LIMA_TEST_PASSPHRASE"})
+	if !c.NeedsPassphrase("main.beancount.gpg") {
+		t.Fatal("expected GPG files without recipients to need the passphrase")
+	}
+	if _, err := c.Encrypt("main.beancount.gpg", []byte("x")); err == nil || !strings.Contains(err.Error(), "LIMA_TEST_PASSPHRASE") {
+		t.Errorf("expected an error naming the variable without a prompt, got %v", err)
+	}
+
+	asked := 0
+	c.SetPrompt(func(path string) (string, error) {
+		asked++
+		return "correct horse", nil
+	})
+	if err := c.Unlock("main.beancount.gpg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ciphertext, err := c.Encrypt("main.beancount.asc", []byte("2025-01-01 open Assets:Cash\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(ciphertext, []byte("-----BEGIN PGP MESSAGE-----")) {
+		t.Errorf("expected armored output for .asc, got %q", ciphertext)
+	}
+	plaintext, err := c.Decrypt("main.beancount.asc", ciphertext)
+	if err != nil || string(plaintext) != "2025-01-01 open Assets:Cash\n" {
+		t.Errorf("expected the plaintext back, got %q (%v)", plaintext, err)
+	}
+	if asked != 1 {
+		t.Errorf("expected the passphrase asked for once, got %d", asked)
+	}
+
+	// A wrong passphrase fails and is asked for again
+	c = New(config.EncryptionConfig{})
+	c.SetPrompt(func(path string) (string, error) {
+		asked++
+		return "wrong", nil
+	})
+	if _, err := c.Decrypt("main.beancount.asc", ciphertext); err == nil {
+		t.Fatal("expected a wrong passphrase to fail")
+	}
+	c.SetPrompt(func(path string) (string, error) { return "", errors.New("cancelled") })
+	if _, err := c.Decrypt("main.beancount.asc", ciphertext); err == nil || err.Error() != "cancelled" {
+		t.Errorf("expected the prompt asked again, got %v", err)
+	}
+}
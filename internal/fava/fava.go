@@ -0,0 +1,302 @@
+// Package fava encodes a ledger's entries and query results as JSON in the shapes
+// fava's API serves them, so tools built around fava can read what lima exports
+package fava
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/query"
+	"github.com/shopspring/decimal"
+)
+
+// amount is an amount as fava serialises it
+type amount struct {
+	Number   json.Number `json:"number"`
+	Currency string      `json:"currency"`
+}
+
+// entry holds the fields every entry has
+type entry struct {
+	Type string         `json:"t"`
+	Date string         `json:"date"`
+	Meta map[string]any `json:"meta"`
+}
+
+// posting is a transaction's posting, its amount as text
+type posting struct {
+	Account string            `json:"account"`
+	Amount  string            `json:"amount"`
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// The entries, by type
+type transaction struct {
+	entry
+	Flag      string    `json:"flag"`
+	Payee     string    `json:"payee"`
+	Narration string    `json:"narration"`
+	Tags      []string  `json:"tags"`
+	Links     []string  `json:"links"`
+	Postings  []posting `json:"postings"`
+}
+
+type open struct {
+	entry
+	Account    string   `json:"account"`
+	Currencies []string `json:"currencies"`
+	Booking    *string  `json:"booking"`
+}
+
+type closing struct {
+	entry
+	Account string `json:"account"`
+}
+
+type balance struct {
+	entry
+	Account    string       `json:"account"`
+	Amount     amount       `json:"amount"`
+	Tolerance  *json.Number `json:"tolerance"`
+	DiffAmount *amount      `json:"diff_amount"`
+}
+
+type price struct {
+	entry
+	Currency string `json:"currency"`
+	Amount   amount `json:"amount"`
+}
+
+// customValue is a custom directive's value with its type
+type customValue struct {
+	Value any    `json:"value"`
+	Dtype string `json:"dtype"`
+}
+
+type custom struct {
+	entry
+	CustomType string        `json:"type"`
+	Values     []customValue `json:"values"`
+}
+
+// Entries encodes directives as {"entries": [...]}, each entry with its type under
+// "t", its date and its metadata, filename and line number included where known
+func Entries(directives []beancount.Directive) ([]byte, error) {
+	entries := make([]any, 0, len(directives))
+	for _, directive := range directives {
+		encoded, err := encodeEntry(directive)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, encoded)
+	}
+	return marshal(struct {
+		Entries []any `json:"entries"`
+	}{entries})
+}
+
+// encodeEntry converts a directive to its fava form
+func encodeEntry(directive beancount.Directive) (any, error) {
+	head := func(kind string, metadata map[string]string, filename string, line int) entry {
+		meta := make(map[string]any, len(metadata)+2)
+		for k, v := range metadata {
+			meta[k] = v
+		}
+		if filename != "" {
+			meta["filename"] = filename
+		}
+		if line > 0 {
+			meta["lineno"] = line
+		}
+		return entry{Type: kind, Date: directive.GetDate().Format("2006-01-02"), Meta: meta}
+	}
+
+	switch d := directive.(type) {
+	case *beancount.Transaction:
+		encoded := transaction{
+			entry:     head("Transaction", d.Metadata, d.FilePath, d.LineNumber),
+			Flag:      d.Flag,
+			Payee:     d.Payee,
+			Narration: d.Narration,
+			Tags:      orEmpty(d.Tags),
+			Links:     orEmpty(d.Links),
+			Postings:  []posting{},
+		}
+		if encoded.Flag == "" {
+			encoded.Flag = "*"
+		}
+		for _, p := range d.Postings {
+			encoded.Postings = append(encoded.Postings, posting{Account: p.Account, Amount: postingAmount(p), Meta: p.Metadata})
+		}
+		return encoded, nil
+	case beancount.OpenAccount:
+		return open{entry: head("Open", d.Metadata, "", d.LineNumber), Account: d.Account, Currencies: orEmpty(d.Commodities)}, nil
+	case beancount.CloseAccount:
+		return closing{entry: head("Close", d.Metadata, "", d.LineNumber), Account: d.Account}, nil
+	case beancount.Balance:
+		encoded := balance{entry: head("Balance", d.Metadata, "", d.LineNumber), Account: d.Account, Amount: encodeAmount(d.Amount)}
+		if d.Tolerance != nil {
+			tolerance := number(*d.Tolerance)
+			encoded.Tolerance = &tolerance
+		}
+		return encoded, nil
+	case beancount.Price:
+		return price{entry: head("Price", d.Metadata, "", d.LineNumber), Currency: d.Commodity, Amount: encodeAmount(d.Amount)}, nil
+	case beancount.Custom:
+		encoded := custom{entry: head("Custom", d.Metadata, "", d.LineNumber), CustomType: d.Type, Values: []customValue{}}
+		for _, value := range d.Values {
+			encoded.Values = append(encoded.Values, customValue{Value: value, Dtype: customType(value)})
+		}
+		return encoded, nil
+	}
+	return nil, fmt.Errorf("unsupported %s directive of %s", directive.GetType(), directive.GetDate().Format("2006-01-02"))
+}
+
+// postingAmount renders a posting's units, cost and price as fava shows them, e.g.
+// "10 VTI {100.00 USD}"; "" for an elided amount
+func postingAmount(p beancount.Posting) string {
+	if p.Amount == nil {
+		return ""
+	}
+	text := string(number(p.Amount.Number)) + " " + p.Amount.Commodity
+	if p.Cost != nil {
+		text += " {" + string(number(p.Cost.Number)) + " " + p.Cost.Commodity + "}"
+	}
+	if p.Price != nil {
+		text += " @ " + string(number(p.Price.Number)) + " " + p.Price.Commodity
+	}
+	return text
+}
+
+// customType names the type of a custom directive's value
+func customType(value string) string {
+	switch {
+	case beancount.IsValidAccount(value):
+		return "account"
+	case value == "TRUE" || value == "FALSE":
+		return "bool"
+	}
+	if _, err := decimal.NewFromString(value); err == nil {
+		return "Decimal"
+	}
+	if _, err := time.Parse("2006-01-02", value); err == nil {
+		return "date"
+	}
+	return "str"
+}
+
+// column is a query result column with its type
+type column struct {
+	Name  string `json:"name"`
+	Dtype string `json:"dtype"`
+}
+
+// Query encodes a query result as fava's query table: {"t": "table", "types":
+// [{"name", "dtype"}...], "rows": [[...]...]}, numbers as JSON numbers, dates as
+// YYYY-MM-DD, amounts as {"number", "currency"} and inventories by currency
+func Query(result *query.Result) ([]byte, error) {
+	types := make([]column, len(result.Columns))
+	for j, name := range result.Columns {
+		types[j] = column{Name: name, Dtype: "str"}
+		for _, row := range result.Rows {
+			if j < len(row) && row[j] != nil {
+				types[j].Dtype = dtype(row[j])
+				break
+			}
+		}
+	}
+	rows := make([][]any, len(result.Rows))
+	for i, row := range result.Rows {
+		rows[i] = make([]any, len(row))
+		for j, value := range row {
+			rows[i][j] = encodeValue(value)
+		}
+	}
+	return marshal(struct {
+		Type  string   `json:"t"`
+		Types []column `json:"types"`
+		Rows  [][]any  `json:"rows"`
+	}{"table", types, rows})
+}
+
+// dtype names a query value's type as fava does
+func dtype(value any) string {
+	switch value.(type) {
+	case decimal.Decimal:
+		return "Decimal"
+	case time.Time:
+		return "date"
+	case bool:
+		return "bool"
+	case beancount.Amount:
+		return "Amount"
+	case query.Inventory:
+		return "Inventory"
+	case []string, []any:
+		return "set"
+	}
+	return "str"
+}
+
+// encodeValue converts a query value to its JSON form
+func encodeValue(value any) any {
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return number(v)
+	case time.Time:
+		return v.Format("2006-01-02")
+	case beancount.Amount:
+		return encodeAmount(v)
+	case query.Inventory:
+		inventory := make(map[string]json.Number, len(v))
+		for currency, n := range v {
+			if !n.IsZero() {
+				inventory[currency] = number(n)
+			}
+		}
+		return inventory
+	case []string:
+		return orEmpty(v)
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = encodeValue(item)
+		}
+		return items
+	}
+	return value
+}
+
+// encodeAmount converts an amount to its fava form
+func encodeAmount(a beancount.Amount) amount {
+	return amount{Number: number(a.Number), Currency: a.Commodity}
+}
+
+// number renders a decimal with the places it was written with, as a JSON number
+func number(d decimal.Decimal) json.Number {
+	return json.Number(d.StringFixed(max(0, -d.Exponent())))
+}
+
+// orEmpty returns an empty list for nil, so it encodes as [] rather than null
+func orEmpty(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// marshal encodes a value as indented JSON with a final newline, leaving <, > and &
+// as they are
+func marshal(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,128 @@
+package fava
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/query"
+)
+
+const testLedger = `2024-01-01 open Assets:Checking USD
+2024-01-01 open Assets:Brokerage
+
+2024-01-05 * "Grocer" "Groceries" #food ^receipt-1
+  source: "bank"
+  Expenses:Food  10.50 USD
+  Assets:Checking
+
+2024-01-06 * "Broker" "Buy"
+  Assets:Brokerage  2 VTI {100.00 USD}
+  Assets:Checking  -200.00 USD
+
+2024-01-31 balance Assets:Checking -210.50 USD
+2024-01-31 price VTI 110.5 USD
+2024-01-01 custom "budget" Expenses:Food "monthly" 400.00 USD
+`
+
+func openLedger(t *testing.T) *beancount.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.beancount")
+	if err := os.WriteFile(path, []byte(testLedger), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := beancount.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestEntries(t *testing.T) {
+	f := openLedger(t)
+	directives, err := f.Directives()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Entries(directives)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded struct {
+		Entries []map[string]any `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	var types []string
+	for _, entry := range decoded.Entries {
+		types = append(types, entry["t"].(string))
+	}
+	if got := strings.Join(types, ","); got != "Open,Open,Custom,Transaction,Transaction,Balance,Price" {
+		t.Errorf("unexpected entries %s", got)
+	}
+
+	groceries := decoded.Entries[3]
+	meta := groceries["meta"].(map[string]any)
+	if groceries["date"] != "2024-01-05" || groceries["payee"] != "Grocer" || meta["source"] != "bank" || meta["lineno"] != float64(4) || meta["filename"] == nil {
+		t.Errorf("unexpected transaction %v", groceries)
+	}
+	if tags := groceries["tags"].([]any); len(tags) != 1 || tags[0] != "food" {
+		t.Errorf("unexpected tags %v", groceries["tags"])
+	}
+	postings := decoded.Entries[4]["postings"].([]any)
+	if amount := postings[0].(map[string]any)["amount"]; amount != "2 VTI {100.00 USD}" {
+		t.Errorf("unexpected posting amount %v", amount)
+	}
+	if !strings.Contains(string(data), `"amount": {
+        "number": -210.50,
+        "currency": "USD"
+      }`) {
+		t.Errorf("expected the balance amount as a number keeping its places:\n%s", data)
+	}
+	values := decoded.Entries[2]["values"].([]any)
+	if len(values) != 4 || values[0].(map[string]any)["dtype"] != "account" || values[2].(map[string]any)["dtype"] != "Decimal" {
+		t.Errorf("unexpected custom values %v", values)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	result, err := query.Run(openLedger(t), "SELECT account, date, sum(amount), count(*) AS n, sum(number) WHERE account ~ 'Assets' GROUP BY account, date ORDER BY 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Query(result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded struct {
+		Type  string `json:"t"`
+		Types []struct {
+			Name  string `json:"name"`
+			Dtype string `json:"dtype"`
+		} `json:"types"`
+		Rows [][]any `json:"rows"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, data)
+	}
+	var types []string
+	for _, column := range decoded.Types {
+		types = append(types, column.Name+":"+column.Dtype)
+	}
+	if decoded.Type != "table" || strings.Join(types, " ") != "account:str date:date sum(amount):Inventory n:Decimal sum(number):Decimal" {
+		t.Errorf("unexpected table %s %v", decoded.Type, types)
+	}
+	if len(decoded.Rows) != 3 {
+		t.Fatalf("expected 3 rows, got %v", decoded.Rows)
+	}
+	first := decoded.Rows[0]
+	if first[0] != "Assets:Brokerage" || first[1] != "2024-01-06" || first[2].(map[string]any)["VTI"] != float64(2) || first[3] != float64(1) {
+		t.Errorf("unexpected row %v", first)
+	}
+}
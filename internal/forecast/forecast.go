@@ -0,0 +1,246 @@
+// Package forecast projects account balances months ahead
+//
+// Each account's flows are split in two. Recurring charges, as the recurring package
+// detects them, are expected on their dates from the account they were paid from.
+// Everything else is extrapolated from the months before by a trend model: their
+// average, or the straight line best fitting them. The spread of those months also
+// gives a low balance the account might fall to, so a month can be flagged before
+// the projection itself goes below zero.
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/recurring"
+	"github.com/shopspring/decimal"
+)
+
+// Model is how the flows of the months ahead are extrapolated from those before
+type Model string
+
+const (
+	Average Model = "average" // The mean of the months before
+	Linear  Model = "linear"  // The least-squares line through the months before
+)
+
+// Models lists the trend models by name
+var Models = []string{string(Average), string(Linear)}
+
+// Options choose what is projected and how
+type Options struct {
+	Months  int    // Months projected, the current one first
+	History int    // Full months before the current one the trend is fitted to
+	Model   Model  // Trend model; Average when empty
+	Account string // Accounts projected: this one and those under it; Assets when empty
+}
+
+// DefaultOptions projects six months from the six before with the average
+var DefaultOptions = Options{Months: 6, History: 6, Model: Average, Account: "Assets"}
+
+// Month is a projected month
+type Month struct {
+	Start     time.Time       // First day of the month
+	Recurring decimal.Decimal // Recurring charges expected, negative
+	Trend     decimal.Decimal // Other flows extrapolated
+	Balance   decimal.Decimal // Balance projected at the month's end
+	Low       decimal.Decimal // Balance one spread of the past months lower
+}
+
+// Negative reports whether the balance is projected below zero
+func (m Month) Negative() bool {
+	return m.Balance.IsNegative()
+}
+
+// AtRisk reports whether the balance may go below zero, the projection or its low
+func (m Month) AtRisk() bool {
+	return m.Low.IsNegative()
+}
+
+// Projection is one account's balance in one commodity, today and the months ahead
+type Projection struct {
+	Account   string
+	Commodity string
+	Balance   decimal.Decimal // Today
+	Months    []Month
+}
+
+// AtRisk returns the projected months in which the balance may go below zero
+func (p Projection) AtRisk() []Month {
+	var months []Month
+	for _, month := range p.Months {
+		if month.AtRisk() {
+			months = append(months, month)
+		}
+	}
+	return months
+}
+
+// key is an account's balance in one commodity
+type key struct {
+	account   string
+	commodity string
+}
+
+// Project projects the balances of the accounts options choose, as of today, for the
+// months ahead; accounts are in name order, then commodities. Postings held at cost,
+// such as shares, are left out, as their value follows prices rather than flows, and
+// so are opening balances from the months the trend is fitted to.
+func Project(transactions []*beancount.Transaction, today time.Time, opts Options) ([]Projection, error) {
+	if opts.Months < 1 || opts.History < 1 {
+		return nil, fmt.Errorf("a forecast needs at least one month ahead and one before")
+	}
+	if opts.Model == "" {
+		opts.Model = Average
+	}
+	if opts.Model != Average && opts.Model != Linear {
+		return nil, fmt.Errorf("unknown model %q: use %s", opts.Model, strings.Join(Models, ", "))
+	}
+	if opts.Account == "" {
+		opts.Account = "Assets"
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	current := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	first := current.AddDate(0, -opts.History, 0)
+
+	balances := make(map[key]decimal.Decimal)
+	flows := make(map[key][]decimal.Decimal)
+	for _, tx := range transactions {
+		if tx.Date.After(today) {
+			continue
+		}
+		month := -1
+		if !tx.Date.Before(first) && tx.Date.Before(current) && !opening(tx) {
+			month = (tx.Date.Year()-first.Year())*12 + int(tx.Date.Month()) - int(first.Month())
+		}
+		for _, posting := range tx.ResolvedPostings() {
+			if posting.Amount == nil || posting.Cost != nil || !beancount.AccountUnder(posting.Account, opts.Account) {
+				continue
+			}
+			k := key{posting.Account, posting.Amount.Commodity}
+			balances[k] = balances[k].Add(posting.Amount.Number)
+			if _, ok := flows[k]; !ok {
+				flows[k] = make([]decimal.Decimal, opts.History)
+			}
+			if month >= 0 {
+				flows[k][month] = flows[k][month].Add(posting.Amount.Number)
+			}
+		}
+	}
+
+	// The charges of series still running come out of the accounts that paid them, and
+	// their monthly cost out of the months the trend is fitted to
+	series := make(map[key][]recurring.Series)
+	for _, s := range recurring.Detect(transactions) {
+		k := key{s.Source, s.Commodity}
+		if _, ok := balances[k]; ok && !s.Ended(today) {
+			series[k] = append(series[k], s)
+		}
+	}
+
+	var projections []Projection
+	for k, balance := range balances {
+		history := flows[k]
+		for _, s := range series[k] {
+			for i := range history {
+				history[i] = history[i].Add(s.Monthly())
+			}
+		}
+		if balance.IsZero() && allZero(history) && len(series[k]) == 0 {
+			continue
+		}
+		trend := fit(history, opts.Model)
+		spread := deviation(history, trend)
+
+		p := Projection{Account: k.account, Commodity: k.commodity, Balance: balance}
+		for i := range opts.Months {
+			start := current.AddDate(0, i, 0)
+			end := start.AddDate(0, 1, 0)
+			month := Month{Start: start, Trend: decimal.NewFromFloat(trend(opts.History + i)).Round(2)}
+			if i == 0 {
+				// Only the days left of the current month are still to come
+				left := float64(end.Sub(today)/(24*time.Hour)-1) / float64(end.Sub(start)/(24*time.Hour))
+				month.Trend = month.Trend.Mul(decimal.NewFromFloat(left)).Round(2)
+			}
+			for _, s := range series[k] {
+				for date := s.Next; date.Before(end); date = s.Cadence.Next(date) {
+					// Charges overdue today are still expected, this month
+					if i == 0 || !date.Before(start) {
+						month.Recurring = month.Recurring.Sub(s.LastAmount)
+					}
+				}
+			}
+			balance = balance.Add(month.Trend).Add(month.Recurring)
+			month.Balance = balance
+			month.Low = balance.Sub(decimal.NewFromFloat(spread * math.Sqrt(float64(i+1))).Round(2))
+			p.Months = append(p.Months, month)
+		}
+		projections = append(projections, p)
+	}
+	sort.Slice(projections, func(i, j int) bool {
+		if projections[i].Account != projections[j].Account {
+			return projections[i].Account < projections[j].Account
+		}
+		return projections[i].Commodity < projections[j].Commodity
+	})
+	return projections, nil
+}
+
+// opening reports whether a transaction sets a balance rather than moves money, such
+// as opening balances and pads: it posts to Equity
+func opening(tx *beancount.Transaction) bool {
+	for _, posting := range tx.Postings {
+		if beancount.AccountUnder(posting.Account, "Equity") {
+			return true
+		}
+	}
+	return false
+}
+
+// allZero reports whether every flow is zero
+func allZero(flows []decimal.Decimal) bool {
+	for _, flow := range flows {
+		if !flow.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// fit returns the model of monthly flows: the flow expected in month x, counted from
+// the first month of history
+func fit(history []decimal.Decimal, model Model) func(x int) float64 {
+	n := float64(len(history))
+	var sumX, sumY, sumXY, sumXX float64
+	for i, flow := range history {
+		x, y := float64(i), flow.InexactFloat64()
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	mean := sumY / n
+	if model == Average || len(history) < 2 {
+		return func(int) float64 { return mean }
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / n
+	return func(x int) float64 { return intercept + slope*float64(x) }
+}
+
+// deviation returns the standard deviation of the months' flows from the model's
+func deviation(history []decimal.Decimal, trend func(x int) float64) float64 {
+	if len(history) < 2 {
+		return 0
+	}
+	var sum float64
+	for i, flow := range history {
+		d := flow.InexactFloat64() - trend(i)
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(history)-1))
+}
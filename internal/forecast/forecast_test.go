@@ -0,0 +1,98 @@
+package forecast
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/shopspring/decimal"
+)
+
+// ledger is six months of pay, rent and groceries alternating between 1,300 and 1,500,
+// then this month's pay and rent
+func ledger() []*beancount.Transaction {
+	transactions := []*beancount.Transaction{beancounttest.Tx("2024-01-01", "Opening", "Equity:Opening-Balances", "-3000")}
+	for month := 1; month <= 7; month++ {
+		transactions = append(transactions,
+			beancounttest.Tx(fmt.Sprintf("2024-%02d-01", month), "Employer", "Income:Salary", "-3000"),
+			beancounttest.Tx(fmt.Sprintf("2024-%02d-05", month), "Landlord", "Expenses:Rent", "2000"))
+		if month == 7 {
+			break
+		}
+		groceries := "1300"
+		if month%2 == 0 {
+			groceries = "1500"
+		}
+		transactions = append(transactions, beancounttest.Tx(fmt.Sprintf("2024-%02d-20", month), fmt.Sprintf("Store %d", month), "Expenses:Food", groceries))
+	}
+	// Shares at cost aren't projected
+	transactions = append(transactions, &beancount.Transaction{
+		Date: beancounttest.Date("2024-03-01"),
+		Postings: []beancount.Posting{
+			{Account: "Assets:Brokerage", Amount: &beancount.Amount{Number: decimal.NewFromInt(1), Commodity: "VTI"}, Cost: &beancount.Amount{Number: decimal.NewFromInt(200), Commodity: "USD"}},
+			{Account: "Assets:Savings", Amount: &beancount.Amount{Number: decimal.NewFromInt(-200), Commodity: "USD"}},
+		},
+	})
+	return transactions
+}
+
+func TestProject(t *testing.T) {
+	opts := DefaultOptions
+	opts.Months = 9
+	projections, err := Project(ledger(), beancounttest.Date("2024-07-10"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projections) != 2 || projections[0].Account != "Assets:Checking" || projections[1].Account != "Assets:Savings" {
+		t.Fatalf("unexpected projections %+v", projections)
+	}
+
+	checking := projections[0]
+	if !checking.Balance.Equal(decimal.NewFromInt(1600)) || len(checking.Months) != 9 {
+		t.Fatalf("unexpected balance %s over %d months", checking.Balance, len(checking.Months))
+	}
+	// Pay less groceries is 1,600 a month once rent is taken out; 21 of July's 31 days
+	// are left, and rent is next due in August
+	july := checking.Months[0]
+	if !july.Trend.Equal(decimal.RequireFromString("1083.87")) || !july.Recurring.IsZero() {
+		t.Errorf("unexpected July: trend %s, recurring %s", july.Trend, july.Recurring)
+	}
+	august := checking.Months[1]
+	if !august.Trend.Equal(decimal.NewFromInt(1600)) || !august.Recurring.Equal(decimal.NewFromInt(-2000)) ||
+		!august.Balance.Equal(decimal.RequireFromString("2283.87")) {
+		t.Errorf("unexpected August: trend %s, recurring %s, balance %s", august.Trend, august.Recurring, august.Balance)
+	}
+
+	// The low balance goes negative a month before the projection does
+	risky := checking.AtRisk()
+	if len(risky) != 3 || risky[0].Start != beancounttest.Date("2025-01-01") || risky[0].Negative() || !risky[1].Negative() {
+		t.Errorf("unexpected months at risk %+v", risky)
+	}
+}
+
+func TestProjectOptions(t *testing.T) {
+	if _, err := Project(ledger(), beancounttest.Date("2024-07-10"), Options{Months: 3, History: 3, Model: "median"}); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+	if _, err := Project(ledger(), beancounttest.Date("2024-07-10"), Options{Months: 0, History: 3}); err == nil {
+		t.Error("expected an error without months to project")
+	}
+	projections, err := Project(ledger(), beancounttest.Date("2024-07-10"), Options{Months: 1, History: 6, Account: "Assets:Savings"})
+	if err != nil || len(projections) != 1 || !projections[0].Balance.Equal(decimal.NewFromInt(-200)) {
+		t.Errorf("expected only savings projected, got %+v (%v)", projections, err)
+	}
+}
+
+func TestFit(t *testing.T) {
+	history := []decimal.Decimal{decimal.NewFromInt(100), decimal.NewFromInt(200), decimal.NewFromInt(300)}
+	if got := fit(history, Average)(3); got != 200 {
+		t.Errorf("average: got %v, want 200", got)
+	}
+	if got := fit(history, Linear)(3); got != 400 {
+		t.Errorf("linear: got %v, want 400", got)
+	}
+	if got := deviation(history, fit(history, Linear)); got != 0 {
+		t.Errorf("expected no spread around the line, got %v", got)
+	}
+}
@@ -0,0 +1,189 @@
+// Package goals tracks spending limits and savings targets
+//
+// A limit, such as dining under 300 a month, counts what its accounts spent in the
+// current interval; it is on pace while the spending is no more than the limit spread
+// over the days gone. A target, such as 10000 saved by December, counts its accounts'
+// balance; it is on pace while the balance is on or above the straight line from the
+// balance at the start to the target at the deadline. The history tells whether each
+// earlier interval stayed under its limit, or each month ended on the line.
+package goals
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// HistoryLength is how many earlier intervals or months a goal's history covers at most
+const HistoryLength = 12
+
+// Result is how a goal did over one earlier interval, or by the end of one month
+type Result struct {
+	Period beancount.Period
+	Value  decimal.Decimal // Spent in the interval, or the balance at the month's end
+	Met    bool
+}
+
+// Progress is where a goal stands today
+type Progress struct {
+	Goal      config.GoalConfig
+	Commodity string
+	Amount    decimal.Decimal // The limit or target
+	Current   decimal.Decimal // Spent this interval, or the balance
+	Expected  decimal.Decimal // Where the pace puts Current today
+	Period    beancount.Period
+	Elapsed   float64  // Share of the interval, or of the time to the deadline, gone by today
+	History   []Result // Oldest first
+}
+
+// IsLimit reports whether the goal is a spending limit rather than a savings target
+func (p Progress) IsLimit() bool {
+	return p.Goal.Limit > 0
+}
+
+// Fraction returns how much of the limit is spent or of the target saved, from 0
+func (p Progress) Fraction() float64 {
+	if !p.Amount.IsPositive() || p.Current.IsNegative() {
+		return 0
+	}
+	fraction, _ := p.Current.Div(p.Amount).Float64()
+	return fraction
+}
+
+// OnPace reports whether the goal is where the pace says it should be by today
+func (p Progress) OnPace() bool {
+	if p.IsLimit() {
+		return p.Current.LessThanOrEqual(p.Expected)
+	}
+	return p.Current.GreaterThanOrEqual(p.Expected)
+}
+
+// Status says briefly how the goal is doing
+func (p Progress) Status() string {
+	switch {
+	case p.IsLimit() && p.Current.GreaterThan(p.Amount):
+		return "over limit"
+	case !p.IsLimit() && p.Current.GreaterThanOrEqual(p.Amount):
+		return "reached"
+	case p.OnPace():
+		return "on pace"
+	case p.IsLimit():
+		return "spending fast"
+	}
+	return "behind"
+}
+
+// Met returns how many results of the history met the goal
+func (p Progress) Met() int {
+	met := 0
+	for _, result := range p.History {
+		if result.Met {
+			met++
+		}
+	}
+	return met
+}
+
+// Evaluate reports a goal's progress on today; commodity is the one counted when the
+// goal doesn't name one, or "" for the one its postings use most
+func Evaluate(transactions []*beancount.Transaction, goal config.GoalConfig, commodity string, today time.Time) Progress {
+	if goal.Commodity != "" {
+		commodity = goal.Commodity
+	}
+	// The goal's postings, oldest first
+	type entry struct {
+		date   time.Time
+		amount beancount.Amount
+	}
+	var entries []entry
+	counts := make(map[string]int)
+	for _, tx := range transactions {
+		for _, posting := range tx.ResolvedPostings() {
+			if posting.Amount != nil && beancount.AccountUnderAny(posting.Account, goal.Accounts) {
+				entries = append(entries, entry{tx.Date, *posting.Amount})
+				counts[posting.Amount.Commodity]++
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].date.Before(entries[j].date) })
+	if commodity == "" {
+		for c, n := range counts {
+			if n > counts[commodity] || n == counts[commodity] && c < commodity {
+				commodity = c
+			}
+		}
+	}
+	total := func(from, until time.Time) decimal.Decimal { // Postings on or after from and before until
+		sum := decimal.Zero
+		for _, e := range entries {
+			if e.amount.Commodity == commodity && !e.date.Before(from) && e.date.Before(until) {
+				sum = sum.Add(e.amount.Number)
+			}
+		}
+		return sum
+	}
+
+	p := Progress{Goal: goal, Commodity: commodity}
+	day := 24 * time.Hour
+	if p.IsLimit() {
+		p.Amount = decimal.NewFromFloat(goal.Limit)
+		unit := beancount.PeriodMonth
+		switch goal.Interval {
+		case "quarterly":
+			unit = beancount.PeriodQuarter
+		case "yearly":
+			unit = beancount.PeriodYear
+		}
+		p.Period = beancount.PeriodContaining(unit, today)
+		p.Current = total(p.Period.Start, p.Period.End())
+		// Today counts as gone
+		gone := decimal.NewFromInt(int64(today.Sub(p.Period.Start)/day + 1)).Div(decimal.NewFromInt(int64(p.Period.End().Sub(p.Period.Start) / day)))
+		p.Elapsed = gone.InexactFloat64()
+		p.Expected = p.Amount.Mul(gone)
+
+		// Earlier intervals back to the goal's first posting
+		for period := p.Period.Prev(); len(entries) > 0 && entries[0].date.Before(period.End()) && len(p.History) < HistoryLength; period = period.Prev() {
+			spent := total(period.Start, period.End())
+			p.History = append(p.History, Result{Period: period, Value: spent, Met: spent.LessThanOrEqual(p.Amount)})
+		}
+		slices.Reverse(p.History)
+		return p
+	}
+
+	p.Amount = decimal.NewFromFloat(goal.Target)
+	deadline, _ := time.Parse("2006-01-02", goal.By)
+	start := today
+	if len(entries) > 0 {
+		start = entries[0].date
+	}
+	if from, err := time.Parse("2006-01-02", goal.From); err == nil {
+		start = from
+	}
+	p.Period = beancount.PeriodContaining(beancount.PeriodMonth, today)
+	balance := func(on time.Time) decimal.Decimal { return total(time.Time{}, on.Add(day)) }
+	startBalance := total(time.Time{}, start)
+	// The pace line runs from the balance before the start to the target at the deadline
+	line := func(on time.Time) (decimal.Decimal, float64) {
+		elapsed := 1.0
+		if span := deadline.Sub(start); span > 0 {
+			elapsed = min(1, max(0, on.Sub(start).Hours()/span.Hours()))
+		}
+		return startBalance.Add(p.Amount.Sub(startBalance).Mul(decimal.NewFromFloat(elapsed))), elapsed
+	}
+	p.Current = balance(today)
+	p.Expected, p.Elapsed = line(today)
+
+	// Month ends since the start
+	for month := p.Period.Prev(); start.Before(month.End()) && len(p.History) < HistoryLength; month = month.Prev() {
+		end := month.End().Add(-day)
+		value := balance(end)
+		expected, _ := line(end)
+		p.History = append(p.History, Result{Period: month, Value: value, Met: value.GreaterThanOrEqual(expected)})
+	}
+	slices.Reverse(p.History)
+	return p
+}
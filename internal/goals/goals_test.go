@@ -0,0 +1,63 @@
+package goals
+
+import (
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+func TestLimit(t *testing.T) {
+	transactions := []*beancount.Transaction{
+		beancounttest.Tx("2024-01-10", "", "Expenses:Dining:Restaurants", "250"),
+		beancounttest.Tx("2024-02-10", "", "Expenses:Dining", "350"),
+		beancounttest.Tx("2024-04-02", "", "Expenses:Dining", "100"),
+		beancounttest.Tx("2024-04-05", "", "Expenses:Dining", "80"),
+		beancounttest.Tx("2024-04-06", "", "Expenses:Food", "500"),
+	}
+	goal := config.GoalConfig{Name: "Dining", Accounts: []string{"Expenses:Dining"}, Limit: 300}
+	p := Evaluate(transactions, goal, "", beancounttest.Date("2024-04-15"))
+
+	// Half of April gone, 180 spent of the 150 the pace allows
+	if p.Commodity != "USD" || !p.Current.Equal(decimal.NewFromInt(180)) || p.Elapsed != 0.5 || !p.Expected.Equal(decimal.NewFromInt(150)) {
+		t.Errorf("unexpected progress %+v", p)
+	}
+	if p.OnPace() || p.Status() != "spending fast" || p.Fraction() != 0.6 {
+		t.Errorf("got %s, %v on pace", p.Status(), p.OnPace())
+	}
+
+	// January to March, February over
+	if len(p.History) != 3 || p.History[0].Period.String() != "Jan 2024" || p.Met() != 2 || p.History[1].Met {
+		t.Errorf("unexpected history %+v", p.History)
+	}
+
+	if over := Evaluate(transactions, goal, "", beancounttest.Date("2024-02-29")); over.Status() != "over limit" || len(over.History) != 1 {
+		t.Errorf("expected February over its limit, got %s with %+v", over.Status(), over.History)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	transactions := []*beancount.Transaction{
+		beancounttest.Tx("2024-01-01", "", "Assets:Savings", "1000"),
+		beancounttest.Tx("2024-02-01", "", "Assets:Savings", "1000"),
+		beancounttest.Tx("2024-03-01", "", "Assets:Savings", "500"),
+	}
+	// From nothing at the start of the year to 12000 at its end
+	goal := config.GoalConfig{Name: "Emergency fund", Accounts: []string{"Assets:Savings"}, Target: 12000, From: "2024-01-01", By: "2025-01-01"}
+	p := Evaluate(transactions, goal, "USD", beancounttest.Date("2024-04-01"))
+
+	if !p.Current.Equal(decimal.NewFromInt(2500)) || p.OnPace() || p.Status() != "behind" {
+		t.Errorf("unexpected progress %+v: %s", p, p.Status())
+	}
+	// January and February ended above the line; March didn't
+	if len(p.History) != 3 || !p.History[0].Met || !p.History[1].Met || p.History[2].Met {
+		t.Errorf("unexpected history %+v", p.History)
+	}
+
+	goal.Target = 2000
+	if reached := Evaluate(transactions, goal, "USD", beancounttest.Date("2024-04-01")); reached.Status() != "reached" {
+		t.Errorf("expected the target reached, got %s", reached.Status())
+	}
+}
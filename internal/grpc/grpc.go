@@ -0,0 +1,293 @@
+// Package grpc serves lima's categorization engine as the gRPC service described in
+// categorizer.proto, so other tools can reuse its patterns and learned statistics.
+// It speaks the gRPC protocol over net/http's HTTP/2, which needs TLS: unary calls,
+// uncompressed messages and the "authorization: Bearer TOKEN" metadata on every call.
+package grpc
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/importer"
+	"github.com/shopspring/decimal"
+)
+
+// ServiceName is the full name of the service in categorizer.proto
+const ServiceName = "lima.categorizer.v1.Categorizer"
+
+// maxMessageBytes bounds a request message
+const maxMessageBytes = 4 << 20
+
+// gRPC status codes
+const (
+	codeOK                 = 0
+	codeInvalidArgument    = 3
+	codeNotFound           = 5
+	codeFailedPrecondition = 9
+	codeUnimplemented      = 12
+	codeInternal           = 13
+	codeUnauthenticated    = 16
+)
+
+// statusError is a call that failed with a gRPC status
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string { return e.message }
+
+// errorf returns a status error with a formatted message
+func errorf(code int, format string, args ...any) error {
+	return &statusError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Server answers calls to the categorizer service; it is an http.Handler
+type Server struct {
+	categorizer *categorizer.Categorizer
+	token       string
+	currency    string
+	now         func() time.Time
+}
+
+// New returns a server over a categorizer that accepts calls bearing token; amounts
+// without a currency are in currency
+func New(cat *categorizer.Categorizer, token, currency string) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("an API token is required")
+	}
+	return &Server{categorizer: cat, token: token, currency: currency, now: time.Now}, nil
+}
+
+// ServeHTTP answers one unary call
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		http.Error(w, "gRPC requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "gRPC calls are POST requests", http.StatusMethodNotAllowed)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "not a gRPC request", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	response, err := s.call(r)
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+	code, message := codeOK, ""
+	if err != nil {
+		code, message = codeInternal, err.Error()
+		var status *statusError
+		if errors.As(err, &status) {
+			code = status.code
+		}
+	} else {
+		frame := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(response)))
+		w.Write(append(frame, response...))
+	}
+	w.Header().Set("Grpc-Status", fmt.Sprint(code))
+	if message != "" {
+		w.Header().Set("Grpc-Message", percentEncode(message))
+	}
+}
+
+// call checks the token, reads the request message and runs the method
+func (s *Server) call(r *http.Request) ([]byte, error) {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(s.token)) != 1 {
+		return nil, errorf(codeUnauthenticated, "missing or wrong API token")
+	}
+	method, ok := strings.CutPrefix(r.URL.Path, "/"+ServiceName+"/")
+	if !ok {
+		return nil, errorf(codeUnimplemented, "unknown service in %s", r.URL.Path)
+	}
+	message, err := readMessage(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case "Suggest":
+		req, err := unmarshalSuggestRequest(message)
+		if err != nil {
+			return nil, errorf(codeInvalidArgument, "invalid request: %v", err)
+		}
+		resp, err := s.suggest(req.Transaction)
+		if err != nil {
+			return nil, err
+		}
+		return resp.marshal(), nil
+	case "SuggestBatch":
+		req, err := unmarshalSuggestBatchRequest(message)
+		if err != nil {
+			return nil, errorf(codeInvalidArgument, "invalid request: %v", err)
+		}
+		var resp SuggestBatchResponse
+		for i, tx := range req.Transactions {
+			result, err := s.suggest(tx)
+			if err != nil {
+				return nil, fmt.Errorf("transaction %d: %w", i, err)
+			}
+			resp.Results = append(resp.Results, result)
+		}
+		return resp.marshal(), nil
+	case "Feedback":
+		req, err := unmarshalFeedbackRequest(message)
+		if err != nil {
+			return nil, errorf(codeInvalidArgument, "invalid request: %v", err)
+		}
+		resp, err := s.feedback(req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.marshal(), nil
+	case "ReloadPatterns":
+		if err := s.categorizer.ReloadPatterns(); err != nil {
+			return nil, errorf(codeFailedPrecondition, "%v", err)
+		}
+		resp := ReloadPatternsResponse{Patterns: int32(s.categorizer.PatternCount())}
+		for _, warning := range s.categorizer.LoadWarnings() {
+			resp.Warnings = append(resp.Warnings, warning.String())
+		}
+		return resp.marshal(), nil
+	}
+	return nil, errorf(codeUnimplemented, "unknown method %s", method)
+}
+
+// readMessage reads the one length-prefixed message of a unary call
+func readMessage(body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxMessageBytes+6))
+	if err != nil {
+		return nil, errorf(codeInternal, "failed to read request: %v", err)
+	}
+	if len(data) < 5 {
+		return nil, errorf(codeInvalidArgument, "missing request message")
+	}
+	if data[0] != 0 {
+		return nil, errorf(codeUnimplemented, "compressed messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(data[1:5])
+	if length > maxMessageBytes {
+		return nil, errorf(codeInvalidArgument, "request message over %d bytes", maxMessageBytes)
+	}
+	if uint32(len(data)-5) != length {
+		return nil, errorf(codeInvalidArgument, "expected one request message")
+	}
+	return data[5:], nil
+}
+
+// suggest returns the suggestions for a described transaction
+func (s *Server) suggest(described Transaction) (SuggestResponse, error) {
+	tx, err := s.transaction(described)
+	if err != nil {
+		return SuggestResponse{}, err
+	}
+	suggestions, err := s.categorizer.SuggestAll(tx)
+	if err != nil {
+		return SuggestResponse{}, errorf(codeInternal, "%v", err)
+	}
+	var resp SuggestResponse
+	for _, suggestion := range suggestions {
+		resp.Suggestions = append(resp.Suggestions, newSuggestion(suggestion))
+	}
+	return resp, nil
+}
+
+// feedback finds the suggestion feedback is about and passes it to the categorizer
+func (s *Server) feedback(req FeedbackRequest) (FeedbackResponse, error) {
+	if req.Category == "" {
+		return FeedbackResponse{}, errorf(codeInvalidArgument, "category is required")
+	}
+	tx, err := s.transaction(req.Transaction)
+	if err != nil {
+		return FeedbackResponse{}, err
+	}
+	suggestions, err := s.categorizer.SuggestAll(tx)
+	if err != nil {
+		return FeedbackResponse{}, errorf(codeInternal, "%v", err)
+	}
+	i := slices.IndexFunc(suggestions, func(suggestion *categorizer.Suggestion) bool {
+		return suggestion.Category == req.Category
+	})
+	if i < 0 {
+		return FeedbackResponse{}, errorf(codeNotFound, "%s is not suggested for this transaction", req.Category)
+	}
+	if err := s.categorizer.Feedback(suggestions[i], req.Accepted); err != nil {
+		return FeedbackResponse{}, errorf(codeInternal, "%v", err)
+	}
+	// Only pattern suggestions learn from feedback
+	suggestion := newSuggestion(suggestions[i])
+	return FeedbackResponse{Recorded: suggestion.PatternID != "", PatternID: suggestion.PatternID}, nil
+}
+
+// transaction builds the beancount transaction a request describes, balanced against
+// the placeholder an import would use
+func (s *Server) transaction(described Transaction) (*beancount.Transaction, error) {
+	if described.Payee == "" && described.Narration == "" {
+		return nil, errorf(codeInvalidArgument, "a transaction needs a payee or narration")
+	}
+	date := s.now()
+	if described.Date != "" {
+		var err error
+		if date, err = time.Parse(time.DateOnly, described.Date); err != nil {
+			return nil, errorf(codeInvalidArgument, "invalid date %q: use YYYY-MM-DD", described.Date)
+		}
+	}
+	var amount *beancount.Amount
+	if described.Amount != "" {
+		n, err := decimal.NewFromString(described.Amount)
+		if err != nil {
+			return nil, errorf(codeInvalidArgument, "invalid amount %q", described.Amount)
+		}
+		currency := described.Currency
+		if currency == "" {
+			currency = s.currency
+		}
+		amount = &beancount.Amount{Number: n, Commodity: currency}
+	}
+	tx := importer.NewTransaction(date, described.Payee, described.Narration, described.Account, amount)
+	tx.Tags = described.Tags
+	return tx, nil
+}
+
+// newSuggestion converts a categorizer suggestion to its message
+func newSuggestion(suggestion *categorizer.Suggestion) Suggestion {
+	out := Suggestion{
+		Category:   suggestion.Category,
+		Confidence: suggestion.Confidence,
+		Source:     string(suggestion.Source),
+		Reason:     suggestion.Reason,
+	}
+	if suggestion.Pattern != nil {
+		out.PatternID = suggestion.Pattern.ID
+	}
+	return out
+}
+
+// percentEncode escapes a status message for the grpc-message trailer, which may
+// hold only printable ASCII
+func percentEncode(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c < 0x20 || c > 0x7e || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,188 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+const testPatterns = `version: "1"
+patterns:
+  - id: cafe
+    name: Cafe
+    pattern: "(?i)cafe"
+    category: Expenses:Food:Dining
+    confidence: 0.9
+`
+
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	patterns := filepath.Join(t.TempDir(), "patterns.yaml")
+	if err := os.WriteFile(patterns, []byte(testPatterns), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = patterns
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(cat, "secret", "USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := httptest.NewUnstartedServer(s)
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return ts, patterns
+}
+
+// marshalTransaction encodes a Transaction as a client would, with a field the
+// server doesn't know
+func marshalTransaction(tx Transaction) []byte {
+	var b []byte
+	b = appendString(b, 2, tx.Payee)
+	b = appendString(b, 3, tx.Narration)
+	b = appendString(b, 4, tx.Amount)
+	b = appendInt32(b, 99, -1)
+	return b
+}
+
+// invoke makes a unary call and returns the response message and status
+func invoke(t *testing.T, ts *httptest.Server, token, method string, message []byte) ([]byte, string, string) {
+	t.Helper()
+	frame := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(message)))
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/"+ServiceName+"/"+method, bytes.NewReader(append(frame, message...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got %s", resp.Proto)
+	}
+	if len(body) >= 5 {
+		body = body[5:]
+	}
+	return body, resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+}
+
+// decodeSuggestions reads the suggestions of a SuggestResponse
+func decodeSuggestions(t *testing.T, data []byte) []Suggestion {
+	t.Helper()
+	fs, err := fields(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []Suggestion
+	for _, f := range fs {
+		inner, err := fields(f.bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s Suggestion
+		for _, g := range inner {
+			switch g.number {
+			case 1:
+				s.Category = string(g.bytes)
+			case 5:
+				s.PatternID = string(g.bytes)
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestSuggest(t *testing.T) {
+	ts, _ := newTestServer(t)
+	cafe := marshalTransaction(Transaction{Payee: "Corner Cafe", Amount: "-4.50"})
+
+	body, status, message := invoke(t, ts, "secret", "Suggest", appendBytes(nil, 1, cafe))
+	if status != "0" {
+		t.Fatalf("expected OK, got %s %s", status, message)
+	}
+	suggestions := decodeSuggestions(t, body)
+	if len(suggestions) == 0 || suggestions[0].Category != "Expenses:Food:Dining" || suggestions[0].PatternID != "cafe" {
+		t.Errorf("unexpected suggestions %+v", suggestions)
+	}
+
+	batch := appendBytes(appendBytes(nil, 1, cafe), 1, marshalTransaction(Transaction{Narration: "Unknown thing"}))
+	body, status, _ = invoke(t, ts, "secret", "SuggestBatch", batch)
+	results, err := fields(body)
+	if status != "0" || err != nil || len(results) != 2 {
+		t.Errorf("expected two results, got %d (status %s, %v)", len(results), status, err)
+	}
+
+	if _, status, _ := invoke(t, ts, "wrong", "Suggest", appendBytes(nil, 1, cafe)); status != "16" {
+		t.Errorf("expected UNAUTHENTICATED, got %s", status)
+	}
+	if _, status, _ := invoke(t, ts, "secret", "Suggest", nil); status != "3" {
+		t.Errorf("expected INVALID_ARGUMENT without a payee, got %s", status)
+	}
+	if _, status, _ := invoke(t, ts, "secret", "Nope", nil); status != "12" {
+		t.Errorf("expected UNIMPLEMENTED, got %s", status)
+	}
+}
+
+func TestFeedbackAndReload(t *testing.T) {
+	ts, patterns := newTestServer(t)
+	cafe := marshalTransaction(Transaction{Payee: "Corner Cafe"})
+
+	request := appendBool(appendString(appendBytes(nil, 1, cafe), 2, "Expenses:Food:Dining"), 3, true)
+	body, status, message := invoke(t, ts, "secret", "Feedback", request)
+	fs, _ := fields(body)
+	if status != "0" || len(fs) != 2 || fs[0].varint != 1 || string(fs[1].bytes) != "cafe" {
+		t.Errorf("unexpected feedback answer %v (status %s %s)", fs, status, message)
+	}
+	request = appendString(appendBytes(nil, 1, cafe), 2, "Expenses:Rent")
+	if _, status, message := invoke(t, ts, "secret", "Feedback", request); status != "5" || message == "" {
+		t.Errorf("expected NOT_FOUND with a message, got %s %q", status, message)
+	}
+
+	more := testPatterns + "  - id: bar\n    name: Bar\n    pattern: \"(?i)bar\"\n    category: Expenses:Food:Drinks\n"
+	if err := os.WriteFile(patterns, []byte(more), 0644); err != nil {
+		t.Fatal(err)
+	}
+	body, status, _ = invoke(t, ts, "secret", "ReloadPatterns", nil)
+	fs, _ = fields(body)
+	if status != "0" || len(fs) != 1 || fs[0].varint != 2 {
+		t.Errorf("expected 2 patterns after reload, got %v (status %s)", fs, status)
+	}
+}
+
+func TestFields(t *testing.T) {
+	data := appendString(appendDouble(appendInt32(nil, 1, -3), 2, 0.5), 3, "héllo")
+	fs, err := fields(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs) != 3 || int32(fs[0].varint) != -3 || fs[1].wireType != wireFixed64 || string(fs[2].bytes) != "héllo" {
+		t.Errorf("unexpected fields %+v", fs)
+	}
+	if _, err := fields(data[:len(data)-1]); err == nil {
+		t.Error("expected an error for a truncated message")
+	}
+	if got := percentEncode("100% sure\n"); got != "100%25 sure%0A" {
+		t.Errorf("unexpected encoding %q", got)
+	}
+}
@@ -0,0 +1,232 @@
+package grpc
+
+import "fmt"
+
+// The messages of categorizer.proto, with the codecs the service needs: requests are
+// decoded, responses encoded. Unknown fields are skipped, as protobuf requires.
+
+// Transaction describes a transaction to categorize
+type Transaction struct {
+	Date      string
+	Payee     string
+	Narration string
+	Amount    string
+	Currency  string
+	Account   string
+	Tags      []string
+}
+
+// Suggestion is a suggested category
+type Suggestion struct {
+	Category   string
+	Confidence float64
+	Source     string
+	Reason     string
+	PatternID  string
+}
+
+// SuggestRequest asks for the categories of one transaction
+type SuggestRequest struct {
+	Transaction Transaction
+}
+
+// SuggestResponse holds the suggestions for a transaction, best first
+type SuggestResponse struct {
+	Suggestions []Suggestion
+}
+
+// SuggestBatchRequest asks for the categories of many transactions
+type SuggestBatchRequest struct {
+	Transactions []Transaction
+}
+
+// SuggestBatchResponse holds one result per transaction asked about
+type SuggestBatchResponse struct {
+	Results []SuggestResponse
+}
+
+// FeedbackRequest reports whether a suggested category was taken
+type FeedbackRequest struct {
+	Transaction Transaction
+	Category    string
+	Accepted    bool
+}
+
+// FeedbackResponse tells whether a pattern learned from the feedback
+type FeedbackResponse struct {
+	Recorded  bool
+	PatternID string
+}
+
+// ReloadPatternsResponse reports the patterns loaded
+type ReloadPatternsResponse struct {
+	Patterns int32
+	Warnings []string
+}
+
+// unmarshalTransaction decodes a Transaction
+func unmarshalTransaction(data []byte) (Transaction, error) {
+	var tx Transaction
+	fs, err := fields(data)
+	if err != nil {
+		return tx, err
+	}
+	for _, f := range fs {
+		var target *string
+		switch f.number {
+		case 1:
+			target = &tx.Date
+		case 2:
+			target = &tx.Payee
+		case 3:
+			target = &tx.Narration
+		case 4:
+			target = &tx.Amount
+		case 5:
+			target = &tx.Currency
+		case 6:
+			target = &tx.Account
+		case 7:
+			tag, err := f.text()
+			if err != nil {
+				return tx, err
+			}
+			tx.Tags = append(tx.Tags, tag)
+			continue
+		default:
+			continue
+		}
+		if *target, err = f.text(); err != nil {
+			return tx, err
+		}
+	}
+	return tx, nil
+}
+
+// unmarshalSuggestRequest decodes a SuggestRequest
+func unmarshalSuggestRequest(data []byte) (SuggestRequest, error) {
+	var req SuggestRequest
+	fs, err := fields(data)
+	if err != nil {
+		return req, err
+	}
+	var transaction []byte
+	for _, f := range fs {
+		if f.number == 1 {
+			if transaction, err = mergeMessage(transaction, f); err != nil {
+				return req, err
+			}
+		}
+	}
+	req.Transaction, err = decodeTransaction(transaction)
+	return req, err
+}
+
+// unmarshalSuggestBatchRequest decodes a SuggestBatchRequest
+func unmarshalSuggestBatchRequest(data []byte) (SuggestBatchRequest, error) {
+	var req SuggestBatchRequest
+	fs, err := fields(data)
+	if err != nil {
+		return req, err
+	}
+	for _, f := range fs {
+		if f.number == 1 {
+			tx, err := embeddedTransaction(f)
+			if err != nil {
+				return req, err
+			}
+			req.Transactions = append(req.Transactions, tx)
+		}
+	}
+	return req, nil
+}
+
+// unmarshalFeedbackRequest decodes a FeedbackRequest
+func unmarshalFeedbackRequest(data []byte) (FeedbackRequest, error) {
+	var req FeedbackRequest
+	fs, err := fields(data)
+	if err != nil {
+		return req, err
+	}
+	var transaction []byte
+	for _, f := range fs {
+		switch f.number {
+		case 1:
+			transaction, err = mergeMessage(transaction, f)
+		case 2:
+			req.Category, err = f.text()
+		case 3:
+			req.Accepted, err = f.boolean()
+		}
+		if err != nil {
+			return req, err
+		}
+	}
+	req.Transaction, err = decodeTransaction(transaction)
+	return req, err
+}
+
+// embeddedTransaction decodes a Transaction field of another message
+func embeddedTransaction(f field) (Transaction, error) {
+	data, err := f.message()
+	if err != nil {
+		return Transaction{}, err
+	}
+	return decodeTransaction(data)
+}
+
+// decodeTransaction decodes the Transaction of another message
+func decodeTransaction(data []byte) (Transaction, error) {
+	tx, err := unmarshalTransaction(data)
+	if err != nil {
+		return tx, fmt.Errorf("transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// marshal encodes a Suggestion
+func (s Suggestion) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, s.Category)
+	b = appendDouble(b, 2, s.Confidence)
+	b = appendString(b, 3, s.Source)
+	b = appendString(b, 4, s.Reason)
+	b = appendString(b, 5, s.PatternID)
+	return b
+}
+
+// marshal encodes a SuggestResponse
+func (r SuggestResponse) marshal() []byte {
+	var b []byte
+	for _, s := range r.Suggestions {
+		b = appendBytes(b, 1, s.marshal())
+	}
+	return b
+}
+
+// marshal encodes a SuggestBatchResponse
+func (r SuggestBatchResponse) marshal() []byte {
+	var b []byte
+	for _, result := range r.Results {
+		b = appendBytes(b, 1, result.marshal())
+	}
+	return b
+}
+
+// marshal encodes a FeedbackResponse
+func (r FeedbackResponse) marshal() []byte {
+	var b []byte
+	b = appendBool(b, 1, r.Recorded)
+	b = appendString(b, 2, r.PatternID)
+	return b
+}
+
+// marshal encodes a ReloadPatternsResponse
+func (r ReloadPatternsResponse) marshal() []byte {
+	var b []byte
+	b = appendInt32(b, 1, r.Patterns)
+	for _, warning := range r.Warnings {
+		b = appendBytes(b, 2, []byte(warning))
+	}
+	return b
+}
@@ -0,0 +1,164 @@
+package grpc
+
+// The protocol buffer wire format, as far as categorizer.proto needs it
+//
+// The codec is written by hand rather than generated with protoc so that lima builds
+// with the Go toolchain alone and doesn't take google.golang.org/protobuf and grpc-go
+// as dependencies for four unary calls. The messages only use strings, bools, an
+// int32, a double and embedded messages, so encoding follows proto3: fields in number
+// order, defaults left out. Decoding skips unknown fields of every wire type and
+// merges repeated occurrences of a message field, as generated code does. wire_test.go
+// checks both against encodings worked out from the protobuf specification; change the
+// codec and those tests together with categorizer.proto.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Protocol buffer wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// errTruncated is returned for a message that ends inside a field
+var errTruncated = errors.New("truncated message")
+
+// field is one field of an encoded message
+type field struct {
+	number   int
+	wireType int
+	varint   uint64
+	bytes    []byte // The contents of a length-delimited field
+}
+
+// fields splits an encoded message into its fields, in the order they appear
+func fields(data []byte) ([]field, error) {
+	var out []field
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		data = data[n:]
+		f := field{number: int(key >> 3), wireType: int(key & 7)}
+		if f.number == 0 {
+			return nil, errors.New("invalid field number 0")
+		}
+		switch f.wireType {
+		case wireVarint:
+			if f.varint, n = binary.Uvarint(data); n <= 0 {
+				return nil, errTruncated
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return nil, errTruncated
+			}
+			f.varint = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return nil, errTruncated
+			}
+			f.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case wireFixed32:
+			if len(data) < 4 {
+				return nil, errTruncated
+			}
+			f.varint = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d", f.wireType)
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// text returns a string field's value
+func (f field) text() (string, error) {
+	if f.wireType != wireBytes {
+		return "", fmt.Errorf("field %d: expected a string", f.number)
+	}
+	return string(f.bytes), nil
+}
+
+// message returns an embedded message field's encoding
+func (f field) message() ([]byte, error) {
+	if f.wireType != wireBytes {
+		return nil, fmt.Errorf("field %d: expected a message", f.number)
+	}
+	return f.bytes, nil
+}
+
+// mergeMessage adds an occurrence of a message field to the ones before it
+// A message field that appears more than once is merged, which decoding the
+// concatenated encodings does.
+func mergeMessage(merged []byte, f field) ([]byte, error) {
+	data, err := f.message()
+	if err != nil {
+		return nil, err
+	}
+	return append(merged, data...), nil
+}
+
+// boolean returns a bool field's value
+func (f field) boolean() (bool, error) {
+	if f.wireType != wireVarint {
+		return false, fmt.Errorf("field %d: expected a bool", f.number)
+	}
+	return f.varint != 0, nil
+}
+
+// appendKey appends a field's key
+func appendKey(b []byte, number, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(number)<<3|uint64(wireType))
+}
+
+// appendString appends a string field, leaving out the empty string as proto3 does
+func appendString(b []byte, number int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendBytes(b, number, []byte(s))
+}
+
+// appendBytes appends a length-delimited field, as an embedded message is
+func appendBytes(b []byte, number int, data []byte) []byte {
+	b = appendKey(b, number, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+// appendBool appends a bool field, leaving out false
+func appendBool(b []byte, number int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return binary.AppendUvarint(appendKey(b, number, wireVarint), 1)
+}
+
+// appendInt32 appends an int32 field, leaving out zero
+func appendInt32(b []byte, number int, v int32) []byte {
+	if v == 0 {
+		return b
+	}
+	// Negative int32s are sign-extended to ten bytes
+	return binary.AppendUvarint(appendKey(b, number, wireVarint), uint64(int64(v)))
+}
+
+// appendDouble appends a double field, leaving out zero
+func appendDouble(b []byte, number int, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.LittleEndian.AppendUint64(appendKey(b, number, wireFixed64), math.Float64bits(v))
+}
@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// unhex decodes an encoding written as hex, ignoring spaces
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// The expected encodings below are worked out by hand from the protobuf encoding
+// specification: a key of field number << 3 | wire type, varints in little-endian
+// groups of seven bits, doubles as eight little-endian bytes and strings and
+// messages prefixed with their length.
+
+func TestMarshalConformance(t *testing.T) {
+	tests := []struct {
+		name    string
+		message interface{ marshal() []byte }
+		want    string
+	}{
+		{"empty suggestion", Suggestion{}, ""},
+		{
+			"suggestion",
+			Suggestion{Category: "Exp:Food", Confidence: 0.5, Source: "pattern", PatternID: "cafe"},
+			"0a 08 4578703a466f6f64" + // 1: "Exp:Food"
+				" 11 000000000000e03f" + // 2: 0.5
+				" 1a 07 7061747465726e" + // 3: "pattern"
+				" 2a 04 63616665", // 5: "cafe"
+		},
+		{
+			"suggestions are embedded messages",
+			SuggestResponse{Suggestions: []Suggestion{{Category: "A"}, {Category: "B"}}},
+			"0a 03 0a0141 0a 03 0a0142",
+		},
+		{
+			// An empty message in a repeated field is still there, with length zero
+			"batch with an empty result",
+			SuggestBatchResponse{Results: []SuggestResponse{{Suggestions: []Suggestion{{Category: "A"}}}, {}}},
+			"0a 05 0a030a0141 0a 00",
+		},
+		{"feedback", FeedbackResponse{Recorded: true, PatternID: "x"}, "08 01 12 01 78"},
+		{"feedback not recorded", FeedbackResponse{}, ""},
+		{"multi-byte varint", ReloadPatternsResponse{Patterns: 150}, "08 9601"},
+		{
+			// Negative int32s take ten bytes, sign-extended to 64 bits
+			"negative int32 and repeated strings",
+			ReloadPatternsResponse{Patterns: -1, Warnings: []string{"a", ""}},
+			"08 ffffffffffffffffff01 12 01 61 12 00",
+		},
+	}
+	for _, tt := range tests {
+		if got, want := tt.message.marshal(), unhex(t, tt.want); !bytes.Equal(got, want) {
+			t.Errorf("%s: got % x, want % x", tt.name, got, want)
+		}
+	}
+}
+
+func TestUnmarshalConformance(t *testing.T) {
+	// A transaction with a field of every wire type the server doesn't know:
+	// 9 fixed32, 10 fixed64, 11 varint and 12 length-delimited
+	transaction := "0a 0a 323032342d30332d3035" + // 1: "2024-03-05"
+		" 12 04 43616665" + // 2: "Cafe"
+		" 4d 01020304" +
+		" 51 0102030405060708" +
+		" 58 9601" +
+		" 62 02 0000" +
+		" 3a 01 61 3a 01 62" + // 7: "a", "b"
+		" 22 04 2d342e35" // 4: "-4.5"
+	want := Transaction{Date: "2024-03-05", Payee: "Cafe", Amount: "-4.5", Tags: []string{"a", "b"}}
+
+	req, err := unmarshalSuggestRequest(unhex(t, "0a 33 "+transaction))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(req.Transaction, want) {
+		t.Errorf("got %+v, want %+v", req.Transaction, want)
+	}
+
+	// The last value of a scalar field wins; a message field given twice is merged
+	feedback := "0a 06 1204 43616665" + // 1: {payee: "Cafe"}
+		" 12 01 58 12 01 59" + // 2: "X", then "Y"
+		" 0a 06 1a04 4c756e63" + // 1: {narration: "Lunc"}
+		" 18 01" // 3: true
+	got, err := unmarshalFeedbackRequest(unhex(t, feedback))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFeedback := FeedbackRequest{Transaction: Transaction{Payee: "Cafe", Narration: "Lunc"}, Category: "Y", Accepted: true}
+	if !reflect.DeepEqual(got, wantFeedback) {
+		t.Errorf("got %+v, want %+v", got, wantFeedback)
+	}
+
+	batch, err := unmarshalSuggestBatchRequest(unhex(t, "0a 06 1204 43616665 0a 00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Transactions) != 2 || batch.Transactions[0].Payee != "Cafe" || batch.Transactions[1].Payee != "" {
+		t.Errorf("expected two transactions, the second empty, got %+v", batch.Transactions)
+	}
+
+	for _, invalid := range []string{
+		"0a 05 1204 4361", // Length past the end
+		"00 01",           // Field number 0
+		"0b",              // Group start, not in proto3
+		"08 ffffffffffff", // Varint without its last byte
+		"0d 00000000",     // The transaction as a fixed32
+	} {
+		if _, err := unmarshalFeedbackRequest(unhex(t, invalid)); err == nil {
+			t.Errorf("expected an error for % x", unhex(t, invalid))
+		}
+	}
+}
+
+func TestFraming(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		want  string
+		code  int
+	}{
+		// One byte of compression flag, then the length as four big-endian bytes
+		{"message", "00 00000003 0a0141", "0a0141", codeOK},
+		{"empty message", "00 00000000", "", codeOK},
+		{"compressed", "01 00000003 0a0141", "", codeUnimplemented},
+		{"short frame", "00 0000", "", codeInvalidArgument},
+		{"length past the end", "00 00000004 0a0141", "", codeInvalidArgument},
+		{"two messages", "00 00000001 08 00 00000001 08", "", codeInvalidArgument},
+	}
+	for _, tt := range tests {
+		got, err := readMessage(bytes.NewReader(unhex(t, tt.frame)))
+		code := codeOK
+		if status, ok := err.(*statusError); ok {
+			code = status.code
+		} else if err != nil {
+			code = codeInternal
+		}
+		if code != tt.code || !bytes.Equal(got, unhex(t, tt.want)) {
+			t.Errorf("%s: got % x with code %d, want % x with code %d", tt.name, got, code, unhex(t, tt.want), tt.code)
+		}
+	}
+}
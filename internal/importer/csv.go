@@ -0,0 +1,281 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// utf8BOM is the byte order mark some programs start UTF-8 files with
+const utf8BOM = "\ufeff"
+
+// CSVConfig maps the columns of one bank's CSV statements to transactions
+type CSVConfig struct {
+	Account          string     `yaml:"account"`           // Account the statement is for, e.g. Assets:Checking
+	Currency         string     `yaml:"currency"`          // Commodity of the amounts unless a column gives it
+	DateFormat       string     `yaml:"date_format"`       // Go time layout; default 2006-01-02
+	Delimiter        string     `yaml:"delimiter"`         // Field separator; default ","
+	DecimalSeparator string     `yaml:"decimal_separator"` // "." (default) or ","; the other one groups thousands
+	SkipRows         int        `yaml:"skip_rows"`         // Lines before the header, such as an account summary
+	NoHeader         bool       `yaml:"no_header"`         // Columns are then numbered from 1
+	Negate           bool       `yaml:"negate"`            // Amounts are positive for money going out, as on card statements
+	Columns          CSVColumns `yaml:"columns"`
+}
+
+// CSVColumns names the columns of a statement by header, or by number from 1
+// Amount is signed; statements with separate debit and credit columns map those instead.
+type CSVColumns struct {
+	Date      string `yaml:"date"`
+	Amount    string `yaml:"amount"`
+	Debit     string `yaml:"debit"`  // Money going out
+	Credit    string `yaml:"credit"` // Money coming in
+	Payee     string `yaml:"payee"`
+	Narration string `yaml:"narration"`
+	Currency  string `yaml:"currency"`
+}
+
+// Validate checks that a mapping can read a statement
+func (c CSVConfig) Validate() error {
+	var errs []string
+	if !beancount.IsValidAccount(c.Account) {
+		errs = append(errs, fmt.Sprintf("invalid account %q", c.Account))
+	}
+	if c.Currency == "" && c.Columns.Currency == "" {
+		errs = append(errs, "currency or a currency column is required")
+	}
+	if c.Columns.Date == "" {
+		errs = append(errs, "a date column is required")
+	}
+	if c.Columns.Amount == "" && c.Columns.Debit == "" && c.Columns.Credit == "" {
+		errs = append(errs, "an amount column, or debit and credit columns, is required")
+	}
+	if c.Columns.Payee == "" && c.Columns.Narration == "" {
+		errs = append(errs, "a payee or narration column is required")
+	}
+	if c.DecimalSeparator != "" && c.DecimalSeparator != "." && c.DecimalSeparator != "," {
+		errs = append(errs, fmt.Sprintf("decimal_separator must be \".\" or \",\", not %q", c.DecimalSeparator))
+	}
+	if utf8.RuneCountInString(c.Delimiter) > 1 {
+		errs = append(errs, fmt.Sprintf("delimiter must be one character, not %q", c.Delimiter))
+	}
+	if c.SkipRows < 0 {
+		errs = append(errs, "skip_rows cannot be negative")
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Read reads a statement's rows as entries posting to the configured account and a
+// placeholder: Expenses:Uncategorized for money going out, Income:Uncategorized for
+// money coming in
+// Blank rows are skipped; a row that can't be read fails the import with its line.
+func (c CSVConfig) Read(r io.Reader) ([]Entry, error) {
+	// Spreadsheets saving UTF-8 start the file with a byte order mark, which would
+	// otherwise end up in the first header and hide its column
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		buffered.Discard(len(utf8BOM))
+	}
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if c.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(c.Delimiter)
+	}
+
+	var header []string
+	var entries []Entry
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read statement: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if row < c.SkipRows {
+			continue
+		}
+		if header == nil && !c.NoHeader {
+			header = record
+			continue
+		}
+		if blank(record) {
+			continue
+		}
+
+		tx, err := c.transaction(header, record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, Entry{Transaction: tx, Line: line})
+	}
+	return entries, nil
+}
+
+// transaction builds the transaction of one row
+func (c CSVConfig) transaction(header, record []string) (*beancount.Transaction, error) {
+	field := func(column string) (string, error) {
+		if column == "" {
+			return "", nil
+		}
+		i, err := columnIndex(header, column)
+		if err != nil {
+			return "", err
+		}
+		if i >= len(record) {
+			return "", nil
+		}
+		return strings.TrimSpace(record[i]), nil
+	}
+
+	text, err := field(c.Columns.Date)
+	if err != nil {
+		return nil, err
+	}
+	layout := c.DateFormat
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+	date, err := time.Parse(layout, text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: expected the format %s", text, layout)
+	}
+
+	number, err := c.amount(field)
+	if err != nil {
+		return nil, err
+	}
+	if c.Negate {
+		number = number.Neg()
+	}
+	commodity, err := field(c.Columns.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if commodity == "" {
+		commodity = c.Currency
+	}
+
+	payee, err := field(c.Columns.Payee)
+	if err != nil {
+		return nil, err
+	}
+	narration, err := field(c.Columns.Narration)
+	if err != nil {
+		return nil, err
+	}
+	return NewTransaction(date, payee, narration, c.Account, &beancount.Amount{Number: number, Commodity: commodity}), nil
+}
+
+// amount reads a row's signed amount: the amount column, or credit less debit
+func (c CSVConfig) amount(field func(string) (string, error)) (decimal.Decimal, error) {
+	if c.Columns.Amount != "" {
+		text, err := field(c.Columns.Amount)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return parseNumber(text, c.DecimalSeparator)
+	}
+	total := decimal.Zero
+	for _, column := range []struct {
+		name string
+		sign int64
+	}{{c.Columns.Credit, 1}, {c.Columns.Debit, -1}} {
+		text, err := field(column.name)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if text == "" {
+			continue
+		}
+		number, err := parseNumber(text, c.DecimalSeparator)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		// Some banks sign their debits; a debit always takes money out
+		total = total.Add(number.Abs().Mul(decimal.NewFromInt(column.sign)))
+	}
+	return total, nil
+}
+
+// parseNumber reads an amount as banks write it: with grouping separators, currency
+// symbols, a leading or trailing sign or parentheses around negative amounts, with
+// decimalSeparator "," or else "."
+func parseNumber(text, decimalSeparator string) (decimal.Decimal, error) {
+	group := "."
+	if decimalSeparator != "," {
+		decimalSeparator, group = ".", ","
+	}
+
+	s := strings.TrimSpace(text)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative, s = true, s[1:len(s)-1]
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case string(r) == decimalSeparator:
+			b.WriteByte('.')
+		case r == '-' || r == '−':
+			negative = !negative
+		case string(r) == group, r == '+', r == ' ', r == '\u00a0', r == '\'':
+		case strings.ContainsRune("$€£¥", r) || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z':
+			// Currency symbols and codes written with the number
+		default:
+			return decimal.Zero, fmt.Errorf("invalid amount %q", text)
+		}
+	}
+	if b.Len() == 0 {
+		return decimal.Zero, fmt.Errorf("invalid amount %q", text)
+	}
+	number, err := decimal.NewFromString(b.String())
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid amount %q", text)
+	}
+	if negative {
+		number = number.Neg()
+	}
+	return number, nil
+}
+
+// columnIndex finds a column by header name, ignoring case, or by number from 1
+func columnIndex(header []string, column string) (int, error) {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), column) {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(column); err == nil && n >= 1 {
+		return n - 1, nil
+	}
+	if header == nil {
+		return 0, fmt.Errorf("column %q must be a number when the statement has no header", column)
+	}
+	return 0, fmt.Errorf("no column %q (have %s)", column, strings.Join(header, ", "))
+}
+
+// blank reports whether every field of a record is empty
+func blank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// ProtocolVersion is the version of the JSON protocol lima speaks with external
+// importers, sent with each request
+const ProtocolVersion = 1
+
+// externalTimeout is how long an external importer may take to answer
+const externalTimeout = time.Minute
+
+// ExternalConfig runs a program, written in any language, as an importer
+//
+// For each request lima runs the command with one JSON object on stdin:
+//
+//	{"version": 1, "method": "detect" or "parse", "path": "statement.ofx",
+//	 "content": "<base64 of the statement, only its start for detect>",
+//	 "account": "Assets:Checking", "currency": "USD"}
+//
+// and reads one JSON object from stdout: {"detected": true} for detect, and for
+// parse {"records": [...]}, each record as Record encodes it. {"error": "..."} or
+// exiting with an error fails the request, stderr giving the reason.
+type ExternalConfig struct {
+	Command  []string `yaml:"command"`  // Program and its arguments, e.g. [python3, /home/me/mybank.py]
+	Account  string   `yaml:"account"`  // Account of records without one
+	Currency string   `yaml:"currency"` // Commodity of records without one
+}
+
+// Validate checks that the command is given and the defaults are valid
+func (c ExternalConfig) Validate() error {
+	var problems []string
+	if len(c.Command) == 0 || c.Command[0] == "" {
+		problems = append(problems, "no command")
+	}
+	if c.Account != "" && !beancount.IsValidAccount(c.Account) {
+		problems = append(problems, fmt.Sprintf("invalid account %q", c.Account))
+	}
+	if c.Currency != "" && !beancount.IsValidCommodity(c.Currency) {
+		problems = append(problems, fmt.Sprintf("invalid currency %q", c.Currency))
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// External is an importer running an external program
+type External struct {
+	name string
+	cfg  ExternalConfig
+}
+
+// NewExternal creates the importer running an external program
+func NewExternal(name string, cfg ExternalConfig) *External {
+	return &External{name: name, cfg: cfg}
+}
+
+// externalRequest is what lima sends an external importer
+type externalRequest struct {
+	Version  int    `json:"version"`
+	Method   string `json:"method"`
+	Path     string `json:"path,omitempty"`
+	Content  []byte `json:"content"`
+	Account  string `json:"account,omitempty"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// externalResponse is what an external importer answers
+type externalResponse struct {
+	Detected bool     `json:"detected"`
+	Records  []Record `json:"records"`
+	Error    string   `json:"error"`
+}
+
+// Name returns the importer's name in the importers file
+func (e *External) Name() string {
+	return e.name
+}
+
+// Detect asks the program whether it reads a statement; a program that fails to
+// answer doesn't
+func (e *External) Detect(path string, head []byte) bool {
+	response, err := e.call("detect", path, head)
+	return err == nil && response.Detected
+}
+
+// Parse has the program read a statement, giving records without an account or
+// currency the configured ones
+func (e *External) Parse(r io.Reader) ([]Record, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+	response, err := e.call("parse", "", content)
+	if err != nil {
+		return nil, err
+	}
+	records := response.Records
+	for i := range records {
+		if records[i].Account == "" {
+			records[i].Account = e.cfg.Account
+		}
+		if records[i].Currency == "" {
+			records[i].Currency = e.cfg.Currency
+		}
+	}
+	return records, nil
+}
+
+// Transactions builds a transaction from each record
+func (e *External) Transactions(records []Record) ([]Entry, error) {
+	return RecordEntries(records)
+}
+
+// call runs the program with a request and decodes its answer
+func (e *External) call(method, path string, content []byte) (externalResponse, error) {
+	var response externalResponse
+	request, err := json.Marshal(externalRequest{
+		Version:  ProtocolVersion,
+		Method:   method,
+		Path:     path,
+		Content:  content,
+		Account:  e.cfg.Account,
+		Currency: e.cfg.Currency,
+	})
+	if err != nil {
+		return response, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), externalTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, e.cfg.Command[0], e.cfg.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(request)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return response, fmt.Errorf("%s timed out after %s", e.cfg.Command[0], externalTimeout)
+		}
+		if reason := strings.TrimSpace(stderr.String()); reason != "" {
+			return response, fmt.Errorf("%s: %w: %s", e.cfg.Command[0], err, reason)
+		}
+		return response, fmt.Errorf("%s: %w", e.cfg.Command[0], err)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return response, fmt.Errorf("%s answered %s with invalid JSON: %w", e.cfg.Command[0], method, err)
+	}
+	if response.Error != "" {
+		return response, errors.New(response.Error)
+	}
+	return response, nil
+}
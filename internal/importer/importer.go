@@ -0,0 +1,463 @@
+// Package importer turns bank statements into beancount transactions: CSV rows are
+// read with a configured column mapping, QIF files and ledger-cli journals as they
+// are, and other formats by registered Go importers or external programs, then
+// checked against the ledger for duplicates and categorized, and printed as beancount
+// text or staged in the ledger for review.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"gopkg.in/yaml.v3"
+)
+
+// Placeholder accounts imported rows post to until they are categorized
+const (
+	ExpensePlaceholder = "Expenses:Uncategorized"
+	IncomePlaceholder  = "Income:Uncategorized"
+)
+
+// duplicateDays is how far apart the dates of a row and a ledger transaction with the
+// same amount on the same account may be for the row to count as a duplicate, since
+// banks often post a few days after the purchase
+const duplicateDays = 3
+
+// Reader reads a statement or journal as entries; CSVConfig, QIFConfig and
+// LedgerConfig are readers
+type Reader interface {
+	Read(r io.Reader) ([]Entry, error)
+}
+
+// Formats lists the statement formats lima imports
+var Formats = []string{"csv", "qif", "ledger"}
+
+// FormatOf returns the format of a statement by its extension, csv for any but QIF
+// files and ledger-cli or hledger journals
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".qif":
+		return "qif"
+	case ".ledger", ".journal", ".hledger", ".j", ".dat":
+		return "ledger"
+	}
+	return "csv"
+}
+
+// Entry is a statement row as a transaction, with what the import made of it
+type Entry struct {
+	Transaction *beancount.Transaction
+
+	// Line is the row's line in the statement
+	Line int
+
+	// Duplicate is the ledger transaction the row repeats, nil for a new one
+	Duplicate *beancount.Transaction
+
+	// Suggestion is the category applied, nil when the row is left for review
+	Suggestion *categorizer.Suggestion
+}
+
+// File is the structure of a lima importers YAML file, one column mapping per
+// bank statement format, by name
+//
+//	importers:
+//	  checking:
+//	    account: Assets:Checking
+//	    currency: USD
+//	    date_format: 01/02/2006
+//	    columns:
+//	      date: Posting Date
+//	      amount: Amount
+//	      payee: Description
+//	external:
+//	  mybank:
+//	    command: [python3, /home/me/importers/mybank.py]
+//	    account: Assets:MyBank
+//	    currency: EUR
+type File struct {
+	Importers map[string]CSVConfig      `yaml:"importers"`
+	External  map[string]ExternalConfig `yaml:"external"`
+}
+
+// LoadFile reads the importers of a YAML file by name
+func LoadFile(path string) (map[string]CSVConfig, error) {
+	file, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return file.Importers, nil
+}
+
+// ParseYAML reads importers from YAML data, checking each mapping
+func ParseYAML(data []byte) (map[string]CSVConfig, error) {
+	file, err := ParseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	return file.Importers, nil
+}
+
+// ReadFile reads an importers YAML file
+func ReadFile(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read importers file: %w", err)
+	}
+	return ParseFile(data)
+}
+
+// ParseFile reads an importers file from YAML data, checking each importer
+// An importer name may only be used once, across sections.
+func ParseFile(data []byte) (File, error) {
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return File{}, fmt.Errorf("failed to parse importers: %w", err)
+	}
+	for name, cfg := range file.Importers {
+		if err := cfg.Validate(); err != nil {
+			return File{}, fmt.Errorf("importer %s: %w", name, err)
+		}
+	}
+	for name, cfg := range file.External {
+		if _, ok := file.Importers[name]; ok {
+			return File{}, fmt.Errorf("importer %s is both a column mapping and external", name)
+		}
+		if err := cfg.Validate(); err != nil {
+			return File{}, fmt.Errorf("importer %s: %w", name, err)
+		}
+	}
+	return file, nil
+}
+
+// Plugins returns the file's external importers and the registered Go importers,
+// sorted by name
+// Neither column mappings nor external importers may take a registered importer's
+// name.
+func (f File) Plugins() ([]Importer, error) {
+	importers := Registered()
+	for _, imp := range importers {
+		if _, ok := f.Importers[imp.Name()]; ok {
+			return nil, fmt.Errorf("importer %s is already registered", imp.Name())
+		}
+	}
+	for name, cfg := range f.External {
+		if _, ok := Find(importers, name); ok {
+			return nil, fmt.Errorf("importer %s is already registered", name)
+		}
+		importers = append(importers, NewExternal(name, cfg))
+	}
+	sortImporters(importers)
+	return importers, nil
+}
+
+// Names returns the names of importers, sorted
+func Names(importers map[string]CSVConfig) []string {
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Options controls how rows are categorized
+type Options struct {
+	// MinConfidence is the lowest confidence a suggestion needs to be applied; rows
+	// below it keep their placeholder account
+	MinConfidence float64
+
+	// Stage flags every new entry ! so the review queue confirms each, not only
+	// those left uncategorized
+	Stage bool
+}
+
+// Process marks the entries that repeat a ledger transaction and categorizes the
+// others that post to a placeholder, flagging those left with one ! for the review queue
+// cat may be nil to leave every entry uncategorized.
+func Process(entries []Entry, ledger []*beancount.Transaction, cat *categorizer.Categorizer, opts Options) ([]Entry, error) {
+	dedup := newDeduplicator(ledger)
+	for i := range entries {
+		entry := &entries[i]
+		tx := entry.Transaction
+		if entry.Duplicate = dedup.match(tx); entry.Duplicate != nil {
+			continue
+		}
+
+		if opts.Stage {
+			tx.Flag = "!"
+		}
+		// Entries read with their accounts, as from QIF and ledger files, keep them
+		placeholder := categorizer.PlaceholderPosting(tx)
+		if placeholder < 0 {
+			continue
+		}
+		if cat == nil {
+			tx.Flag = "!"
+			continue
+		}
+		suggestion, err := cat.Suggest(tx)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", entry.Line, err)
+		}
+		if suggestion == nil || suggestion.Confidence < opts.MinConfidence || categorizer.IsUncategorizedAccount(suggestion.Category) {
+			tx.Flag = "!"
+			continue
+		}
+		tx.Postings[placeholder].Account = suggestion.Category
+		entry.Suggestion = suggestion
+	}
+	return entries, nil
+}
+
+// deduplicator finds ledger transactions with the same amount on the same account
+// within a few days, each matching one row at most
+type deduplicator struct {
+	byAmount map[string][]*beancount.Transaction
+	used     map[*beancount.Transaction]bool
+}
+
+// newDeduplicator indexes the ledger's postings by account and amount
+func newDeduplicator(ledger []*beancount.Transaction) *deduplicator {
+	d := &deduplicator{byAmount: make(map[string][]*beancount.Transaction), used: make(map[*beancount.Transaction]bool)}
+	for _, tx := range ledger {
+		for _, posting := range tx.ResolvedPostings() {
+			if posting.Amount != nil {
+				key := amountKey(posting.Account, *posting.Amount)
+				d.byAmount[key] = append(d.byAmount[key], tx)
+			}
+		}
+	}
+	return d
+}
+
+// amountKey identifies an amount posted to an account, whatever its decimal places
+func amountKey(account string, amount beancount.Amount) string {
+	return account + " " + amount.Number.String() + " " + amount.Commodity
+}
+
+// match returns the closest unused ledger transaction a new one repeats, or nil
+// Only the first posting, the statement's account, is compared.
+func (d *deduplicator) match(tx *beancount.Transaction) *beancount.Transaction {
+	posting := tx.Postings[0]
+	if posting.Amount == nil {
+		return nil
+	}
+	var best *beancount.Transaction
+	var bestDays float64
+	for _, candidate := range d.byAmount[amountKey(posting.Account, *posting.Amount)] {
+		days := candidate.Date.Sub(tx.Date).Hours() / 24
+		if days < 0 {
+			days = -days
+		}
+		if d.used[candidate] || days > duplicateDays {
+			continue
+		}
+		if best == nil || days < bestDays {
+			best, bestDays = candidate, days
+		}
+	}
+	if best != nil {
+		d.used[best] = true
+	}
+	return best
+}
+
+// Text renders the new entries as beancount text, blank lines between them
+func Text(entries []Entry) (string, error) {
+	var blocks []string
+	for _, entry := range entries {
+		if entry.Duplicate != nil {
+			continue
+		}
+		lines, err := beancount.FormatTransaction(entry.Transaction)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", entry.Line, err)
+		}
+		blocks = append(blocks, strings.Join(lines, "\n")+"\n")
+	}
+	return strings.Join(blocks, "\n"), nil
+}
+
+// Stage builds the edit appending the new entries to the main ledger file, where those
+// flagged ! wait in the review queue
+func Stage(w *beancount.Writer, entries []Entry) ([]beancount.Edit, error) {
+	var transactions []*beancount.Transaction
+	for _, entry := range entries {
+		if entry.Duplicate == nil {
+			transactions = append(transactions, entry.Transaction)
+		}
+	}
+	return Append(w, transactions)
+}
+
+// Append builds the edit appending transactions to the main ledger file, blank lines
+// between them; there is none for no transactions
+func Append(w *beancount.Writer, transactions []*beancount.Transaction) ([]beancount.Edit, error) {
+	var edit *beancount.Edit
+	for _, tx := range transactions {
+		// The first transaction starts the append; the rest follow it in the same edit
+		if edit == nil {
+			appended, err := w.AppendTransaction(tx)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", tx.Date.Format("2006-01-02"), tx.Payee, err)
+			}
+			edit = &appended
+			continue
+		}
+		lines, err := beancount.FormatTransaction(tx)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", tx.Date.Format("2006-01-02"), tx.Payee, err)
+		}
+		edit.NewLines = append(append(edit.NewLines, ""), lines...)
+	}
+	if edit == nil {
+		return nil, nil
+	}
+	return []beancount.Edit{*edit}, nil
+}
+
+// NewTransaction builds a transaction moving an amount in or out of account, balanced
+// against the placeholder for its direction, as an import writes it
+// The servers describe transactions to categorize with it: without an account only
+// the placeholder is posted, and without an amount nothing is.
+func NewTransaction(date time.Time, payee, narration, account string, amount *beancount.Amount) *beancount.Transaction {
+	tx := &beancount.Transaction{
+		Date:      date,
+		Flag:      "*",
+		Payee:     clean(payee),
+		Narration: clean(narration),
+	}
+	if amount == nil {
+		return tx
+	}
+	placeholder := ExpensePlaceholder
+	if amount.Number.IsPositive() {
+		placeholder = IncomePlaceholder
+	}
+	if account != "" {
+		tx.Postings = append(tx.Postings, beancount.Posting{Account: account, Amount: &beancount.Amount{Number: amount.Number, Commodity: amount.Commodity}})
+	}
+	tx.Postings = append(tx.Postings, beancount.Posting{Account: placeholder, Amount: &beancount.Amount{Number: amount.Number.Neg(), Commodity: amount.Commodity}})
+	return tx
+}
+
+// clean makes statement text fit in a beancount string: quotes become apostrophes
+// and runs of whitespace single spaces
+func clean(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(text, `"`, "'")), " ")
+}
+
+// roots maps the root account names other tools use, lowercase, to beancount's
+var roots = map[string]string{
+	"assets":      "Assets",
+	"asset":       "Assets",
+	"liabilities": "Liabilities",
+	"liability":   "Liabilities",
+	"equity":      "Equity",
+	"income":      "Income",
+	"revenue":     "Income",
+	"revenues":    "Income",
+	"expenses":    "Expenses",
+	"expense":     "Expenses",
+}
+
+// accountName turns an account or category name from another tool into a beancount
+// account: each component capitalized, with dashes for the characters beancount
+// doesn't allow. Names under a root account keep it; others are put under root, and
+// are "" when root is "".
+func accountName(root, name string) string {
+	var parts []string
+	for _, part := range strings.Split(name, ":") {
+		if part = accountComponent(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	if known, ok := roots[strings.ToLower(parts[0])]; ok && len(parts) > 1 {
+		parts[0] = known
+		return strings.Join(parts, ":")
+	}
+	if root == "" {
+		return ""
+	}
+	return root + ":" + strings.Join(parts, ":")
+}
+
+// accountComponent makes one component of an account name valid
+func accountComponent(part string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(part) {
+		if r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	component := []rune(b.String())
+	if len(component) == 0 {
+		return ""
+	}
+	component[0] = unicode.ToUpper(component[0])
+	return string(component)
+}
+
+// symbols maps currency symbols to the commodities they stand for, besides the
+// dollar sign, which is the configured currency
+var symbols = map[string]string{
+	"€": "EUR",
+	"£": "GBP",
+	"¥": "JPY",
+	"₹": "INR",
+	"₩": "KRW",
+}
+
+// commodityName turns a commodity from another tool into a beancount commodity, the
+// dollar sign standing for currency; it is "" when there is no valid equivalent
+func commodityName(symbol, currency string) string {
+	if symbol == "$" {
+		return currency
+	}
+	if name, ok := symbols[symbol]; ok {
+		return name
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return unicode.ToUpper(r)
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune("._'-", r):
+			return r
+		}
+		return '-'
+	}, strings.Trim(symbol, `"`))
+	if !beancount.IsValidCommodity(name) {
+		return ""
+	}
+	return name
+}
+
+// tagName makes a tag from another tool valid in beancount, "" when nothing is left
+func tagName(tag string) string {
+	tag = strings.Map(func(r rune) rune {
+		if r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r)) || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, strings.TrimSpace(tag))
+	return strings.Trim(tag, "-")
+}
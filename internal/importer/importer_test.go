@@ -0,0 +1,711 @@
+package importer
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+func TestParseYAML(t *testing.T) {
+	importers, err := ParseYAML([]byte(`importers:
+  checking:
+    account: Assets:Checking
+    currency: USD
+    columns:
+      date: Date
+      amount: Amount
+      payee: Description
+  card:
+    account: Liabilities:Card
+    currency: EUR
+    columns:
+      date: "1"
+      debit: "2"
+      payee: "3"
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if names := strings.Join(Names(importers), ","); names != "card,checking" {
+		t.Errorf("Expected card,checking, got %s", names)
+	}
+
+	_, err = ParseYAML([]byte(`importers:
+  broken:
+    account: checking
+    columns:
+      date: Date
+`))
+	if err == nil {
+		t.Fatal("Expected an error for an invalid mapping")
+	}
+	for _, want := range []string{"importer broken", "invalid account", "currency", "amount column", "payee or narration"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestCSVConfig_Read(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       CSVConfig
+		statement string
+		want      []string // date payee amount placeholder of each entry
+	}{
+		{
+			name: "header and signed amounts",
+			cfg: CSVConfig{
+				Account: "Assets:Checking", Currency: "USD", DateFormat: "01/02/2006",
+				Columns: CSVColumns{Date: "posting date", Amount: "Amount", Payee: "Description"},
+			},
+			statement: "Posting Date,Description,Amount\n01/05/2024,\"NETFLIX.COM  \"\"HD\"\"\",-15.49\n\n01/06/2024,Employer,\"$2,500.00\"\n",
+			want: []string{
+				"2024-01-05 NETFLIX.COM 'HD' -15.49 USD Expenses:Uncategorized",
+				"2024-01-06 Employer 2500.00 USD Income:Uncategorized",
+			},
+		},
+		{
+			name: "semicolons, decimal comma and skipped rows",
+			cfg: CSVConfig{
+				Account: "Assets:Giro", Currency: "EUR", DateFormat: "02.01.2006", Delimiter: ";",
+				DecimalSeparator: ",", SkipRows: 2,
+				Columns: CSVColumns{Date: "Buchungstag", Amount: "Betrag", Payee: "Empfänger"},
+			},
+			statement: "Konto;DE00\nZeitraum;Januar\nBuchungstag;Empfänger;Betrag\n03.01.2024;Bäckerei;-1.234,50 €\n",
+			want:      []string{"2024-01-03 Bäckerei -1234.50 EUR Expenses:Uncategorized"},
+		},
+		{
+			name: "debit and credit columns without a header",
+			cfg: CSVConfig{
+				Account: "Assets:Savings", Currency: "USD", NoHeader: true,
+				Columns: CSVColumns{Date: "1", Debit: "3", Credit: "4", Payee: "2"},
+			},
+			statement: "2024-02-01,Transfer out,-100.00,\n2024-02-02,Interest,,1.25\n",
+			want: []string{
+				"2024-02-01 Transfer out -100.00 USD Expenses:Uncategorized",
+				"2024-02-02 Interest 1.25 USD Income:Uncategorized",
+			},
+		},
+		{
+			name: "negated card statement with parentheses",
+			cfg: CSVConfig{
+				Account: "Liabilities:Card", Currency: "USD", Negate: true,
+				Columns: CSVColumns{Date: "Date", Amount: "Amount", Payee: "Merchant"},
+			},
+			statement: "Date,Merchant,Amount\n2024-03-01,Grocer,42.10\n2024-03-02,Refund,(5.00)\n",
+			want: []string{
+				"2024-03-01 Grocer -42.10 USD Expenses:Uncategorized",
+				"2024-03-02 Refund 5.00 USD Income:Uncategorized",
+			},
+		},
+		{
+			name: "byte order mark before a quoted header",
+			cfg: CSVConfig{
+				Account: "Assets:Checking", Currency: "USD",
+				Columns: CSVColumns{Date: "Date", Amount: "Amount", Payee: "Payee"},
+			},
+			statement: "\ufeff\"Date\",Payee,Amount\r\n2024-04-01,Bookshop,-12.00\r\n",
+			want:      []string{"2024-04-01 Bookshop -12.00 USD Expenses:Uncategorized"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := tt.cfg.Read(strings.NewReader(tt.statement))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var got []string
+			for _, entry := range entries {
+				tx := entry.Transaction
+				if tx.Postings[0].Account != tt.cfg.Account {
+					t.Errorf("Expected first posting to %s, got %s", tt.cfg.Account, tx.Postings[0].Account)
+				}
+				got = append(got, strings.Join([]string{
+					tx.Date.Format("2006-01-02"), tx.Payee, tx.Postings[0].Amount.Number.StringFixed(2) + " " + tx.Postings[0].Amount.Commodity, tx.Postings[1].Account,
+				}, " "))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Unexpected entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCSVConfig_ReadErrors(t *testing.T) {
+	cfg := CSVConfig{
+		Account: "Assets:Checking", Currency: "USD",
+		Columns: CSVColumns{Date: "Date", Amount: "Amount", Payee: "Payee"},
+	}
+	tests := []struct {
+		statement string
+		err       string
+	}{
+		{"Date,Payee,Amount\n2024-01-01,Shop,12.00\n01/02/2024,Shop,1.00\n", "line 3: invalid date"},
+		{"Date,Payee,Amount\n2024-01-01,Shop,twelve\n", `line 2: invalid amount "twelve"`},
+		{"When,Payee,Amount\n2024-01-01,Shop,12.00\n", `no column "Date"`},
+	}
+	for _, tt := range tests {
+		_, err := cfg.Read(strings.NewReader(tt.statement))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Expected an error containing %q, got %v", tt.err, err)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	mapping := CSVConfig{
+		Account: "Assets:Checking", Currency: "USD",
+		Columns: CSVColumns{Date: "Date", Amount: "Amount", Payee: "Payee"},
+	}
+	entries, err := mapping.Read(strings.NewReader(`Date,Payee,Amount
+2024-01-03,Grocer,-45.50
+2024-01-04,Grocer,-45.50
+2024-01-10,NETFLIX.COM,-15.49
+2024-01-12,Local Shop,-8.00
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The ledger has the first grocer charge, posted two days later
+	recorded := &beancount.Transaction{
+		Date:  time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		Flag:  "*",
+		Payee: "Grocer",
+		Postings: []beancount.Posting{
+			{Account: "Expenses:Food:Groceries", Amount: amount("45.50")},
+			{Account: "Assets:Checking", Amount: amount("-45.5")},
+		},
+	}
+	entries, err = Process(entries, []*beancount.Transaction{recorded}, cat, Options{MinConfidence: 0.7})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if entries[0].Duplicate != recorded && entries[1].Duplicate != recorded {
+		t.Error("Expected one grocer row to repeat the ledger transaction")
+	}
+	if entries[0].Duplicate != nil && entries[1].Duplicate != nil {
+		t.Error("Expected the ledger transaction to match one row only")
+	}
+	netflix := entries[2]
+	if netflix.Suggestion == nil || netflix.Transaction.Flag != "*" || netflix.Transaction.Postings[1].Account == ExpensePlaceholder {
+		t.Errorf("Expected the Netflix row categorized and cleared, got %+v", netflix.Transaction)
+	}
+	shop := entries[3]
+	if shop.Suggestion != nil || shop.Transaction.Flag != "!" || shop.Transaction.Postings[1].Account != ExpensePlaceholder {
+		t.Errorf("Expected the unknown shop left for review, got %+v", shop.Transaction)
+	}
+
+	text, err := Text(entries)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Count(text, "Grocer") != 1 || !strings.Contains(text, `2024-01-12 ! "Local Shop"`) {
+		t.Errorf("Unexpected text:\n%s", text)
+	}
+
+	// Staging flags every new entry for review
+	entries, _ = mapping.Read(strings.NewReader("Date,Payee,Amount\n2024-01-10,NETFLIX.COM,-15.49\n"))
+	entries, _ = Process(entries, nil, cat, Options{MinConfidence: 0.7, Stage: true})
+	if entries[0].Suggestion == nil || entries[0].Transaction.Flag != "!" {
+		t.Errorf("Expected a staged entry categorized and flagged !, got %+v", entries[0].Transaction)
+	}
+}
+
+func TestStage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.beancount")
+	ledger := "2024-01-01 open Assets:Checking\n"
+	if err := os.WriteFile(path, []byte(ledger), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := beancount.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	mapping := CSVConfig{
+		Account: "Assets:Checking", Currency: "USD",
+		Columns: CSVColumns{Date: "Date", Amount: "Amount", Payee: "Payee"},
+	}
+	entries, err := mapping.Read(strings.NewReader("Date,Payee,Amount\n2024-01-02,Shop,-3.00\n2024-01-03,Cafe,-4.25\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	entries, _ = Process(entries, nil, nil, Options{Stage: true})
+
+	w := beancount.NewWriter(f)
+	edits, err := Stage(w, entries)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.ApplyAll(edits); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	want := ledger + `
+2024-01-02 ! "Shop" ""
+  Assets:Checking  -3.00 USD
+  Expenses:Uncategorized  3.00 USD
+
+2024-01-03 ! "Cafe" ""
+  Assets:Checking  -4.25 USD
+  Expenses:Uncategorized  4.25 USD
+`
+	if normalize(string(data)) != normalize(want) {
+		t.Errorf("Unexpected ledger:\n%s", data)
+	}
+}
+
+func amount(number string) *beancount.Amount {
+	return &beancount.Amount{Number: decimal.RequireFromString(number), Commodity: "USD"}
+}
+
+// normalize collapses runs of spaces so tests don't depend on amount alignment
+func normalize(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		lines[i] = line[:indent] + strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestNewTransaction(t *testing.T) {
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	postings := func(tx *beancount.Transaction) string {
+		var parts []string
+		for _, posting := range tx.Postings {
+			parts = append(parts, posting.Account+" "+posting.Amount.Number.String()+" "+posting.Amount.Commodity)
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	tx := NewTransaction(date, `Joe's "Cafe"`, "  Lunch\tout ", "Assets:Checking", &beancount.Amount{Number: decimal.RequireFromString("-4.50"), Commodity: "USD"})
+	if tx.Payee != "Joe's 'Cafe'" || tx.Narration != "Lunch out" {
+		t.Errorf("expected cleaned text, got %q and %q", tx.Payee, tx.Narration)
+	}
+	if got, want := postings(tx), "Assets:Checking -4.5 USD, "+ExpensePlaceholder+" 4.5 USD"; got != want {
+		t.Errorf("got postings %s, want %s", got, want)
+	}
+
+	// Without an account only the placeholder is posted, without an amount nothing is
+	tx = NewTransaction(date, "Employer", "", "", &beancount.Amount{Number: decimal.NewFromInt(3000), Commodity: "USD"})
+	if got, want := postings(tx), IncomePlaceholder+" -3000 USD"; got != want {
+		t.Errorf("got postings %s, want %s", got, want)
+	}
+	if tx := NewTransaction(date, "Cafe", "", "Assets:Checking", nil); len(tx.Postings) != 0 {
+		t.Errorf("expected no postings without an amount, got %s", postings(tx))
+	}
+}
+
+func TestAccountName(t *testing.T) {
+	tests := []struct {
+		root, name, want string
+	}{
+		{"Expenses", "Food:Groceries", "Expenses:Food:Groceries"},
+		{"Expenses", "bills & utilities:phone", "Expenses:Bills-utilities:Phone"},
+		{"Income", "Interest Inc", "Income:Interest-Inc"},
+		{"", "expenses:food", "Expenses:Food"},
+		{"", "revenues:salary", "Income:Salary"},
+		{"", "Assets:Bank Account", "Assets:Bank-Account"},
+		{"", "personal:cash", ""},
+		{"Assets", "Assets", "Assets:Assets"},
+	}
+	for _, tt := range tests {
+		if got := accountName(tt.root, tt.name); got != tt.want {
+			t.Errorf("accountName(%q, %q) = %q, want %q", tt.root, tt.name, got, tt.want)
+		}
+	}
+}
+
+// describe renders a transaction on one line: date flag payee, then each posting
+func describe(tx *beancount.Transaction) string {
+	parts := []string{tx.Date.Format("2006-01-02"), tx.Flag, tx.Payee}
+	for _, tag := range tx.Tags {
+		parts = append(parts, "#"+tag)
+	}
+	for _, posting := range tx.Postings {
+		part := posting.Account
+		if posting.Amount != nil {
+			part += " " + posting.Amount.Number.String() + " " + posting.Amount.Commodity
+		}
+		if posting.Cost != nil {
+			part += " {" + posting.Cost.Number.String() + " " + posting.Cost.Commodity + "}"
+		}
+		if posting.Price != nil {
+			part += " @ " + posting.Price.Number.String() + " " + posting.Price.Commodity
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func TestQIFConfig_Read(t *testing.T) {
+	const statement = `!Option:AutoSwitch
+!Account
+NChecking
+TBank
+^
+NVisa
+TCCard
+^
+!Clear:AutoSwitch
+!Account
+NChecking
+TBank
+^
+!Type:Bank
+D1/5'24
+T-45.50
+PGrocer
+LFood:Groceries
+^
+D1/6'24
+T-100.00
+PTransfer
+L[Visa]
+^
+D1/7'24
+T-60.00
+PHardware Store
+SHome:Repairs/Rental
+$-40.00
+EPaint
+SHousehold
+$-20.00
+^
+D1/8'24
+T2,500.00
+PEmployer
+^
+!Account
+NVisa
+TCCard
+^
+!Type:CCard
+D01/06/2024
+T100.00
+PPayment
+L[Checking]
+^
+!Type:Invst
+D1/9'24
+NBuy
+YACME
+^
+`
+	entries, err := QIFConfig{Currency: "USD"}.Read(strings.NewReader(statement))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, describe(entry.Transaction))
+	}
+	want := []string{
+		"2024-01-05 | * | Grocer | Assets:Checking -45.5 USD | Expenses:Food:Groceries 45.5 USD",
+		"2024-01-06 | * | Transfer | Assets:Checking -100 USD | Liabilities:Visa 100 USD",
+		"2024-01-07 | * | Hardware Store | #Rental | Assets:Checking -60 USD | Expenses:Home:Repairs 40 USD | Expenses:Household 20 USD",
+		"2024-01-08 | * | Employer | Assets:Checking 2500 USD | Income:Uncategorized -2500 USD",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if memo := entries[2].Transaction.Postings[1].Metadata["memo"]; memo != "Paint" {
+		t.Errorf("Expected the split's memo as metadata, got %q", memo)
+	}
+
+	// Without !Account headers the account is given; dates may be day first
+	entries, err = QIFConfig{Account: "Assets:Giro", Currency: "EUR", DayFirst: true, DecimalSeparator: ","}.Read(
+		strings.NewReader("!Type:Bank\nD25.01.2024\nT-1.234,50\nPRent\nLHousing\n^\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 1 || describe(entries[0].Transaction) != "2024-01-25 | * | Rent | Assets:Giro -1234.5 EUR | Expenses:Housing 1234.5 EUR" {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+
+	_, err = QIFConfig{Currency: "USD"}.Read(strings.NewReader("!Type:Bank\nD1/5'24\nT-1.00\n^\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "names no account") {
+		t.Errorf("Expected an error for a missing account, got %v", err)
+	}
+}
+
+func TestLedgerConfig_Read(t *testing.T) {
+	const journal = `; Personal finances
+alias checking = assets:bank:checking
+Y 2023
+
+account assets:bank:checking
+    note Main account
+
+2024/01/05 * Grocer  ; :food:
+    expenses:food:groceries     $45.50
+    checking
+
+12/31 ! (1001) Landlord | December rent
+    expenses:rent               $1,200.00
+    (budget:rent)              -$1,200.00
+    [assets:bank:checking]     $-1,200.00 = $3,000.00
+
+2024-02-01 Broker
+    assets:brokerage     10 "VANGUARD 500" @@ $4,000
+    assets:brokerage     2 AAPL {$150} ; :buy:
+    assets:bank:checking
+
+= expenses:food
+    (budget:food)  -1
+
+~ monthly
+    expenses:rent  $1,200
+    assets:bank:checking
+
+comment
+2024-03-01 Ignored
+    expenses:misc  $1
+    assets:cash
+end comment
+`
+	entries, err := LedgerConfig{Currency: "USD"}.Read(strings.NewReader(journal))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, describe(entry.Transaction))
+	}
+	want := []string{
+		"2024-01-05 | * | Grocer | #food | Expenses:Food:Groceries 45.5 USD | Assets:Bank:Checking",
+		"2023-12-31 | ! | Landlord | Expenses:Rent 1200 USD | Assets:Bank:Checking -1200 USD",
+		"2024-02-01 | * | Broker | #buy | Assets:Brokerage 10 VANGUARD-500 @ 400 USD | Assets:Brokerage 2 AAPL {150 USD} | Assets:Bank:Checking",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if entries[1].Transaction.Narration != "December rent" || entries[1].Line != 12 {
+		t.Errorf("Expected the note as narration on line 12, got %q on line %d", entries[1].Transaction.Narration, entries[1].Line)
+	}
+
+	tests := []struct {
+		journal string
+		err     string
+	}{
+		{"2024/01/05 Shop\n    personal:misc  $1\n    assets:cash\n", `line 2: account "personal:misc" is not under`},
+		{"2024/01/05 Shop\n    expenses:misc\n    assets:cash\n\n", "line 1: only one posting may omit its amount"},
+		{"include other.ledger\n", "import other.ledger on its own"},
+		{"2024/01/05 Shop\n    expenses:misc  ($1 * 2)\n    assets:cash\n", "amount expressions"},
+	}
+	for _, tt := range tests {
+		_, err := LedgerConfig{Currency: "USD"}.Read(strings.NewReader(tt.journal))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Expected an error containing %q, got %v", tt.err, err)
+		}
+	}
+}
+
+// bankImporter is a Go importer of a made-up format: "DATE|PAYEE|AMOUNT" lines after a
+// BANK header
+type bankImporter struct{}
+
+func (bankImporter) Name() string { return "test-bank" }
+
+func (bankImporter) Detect(path string, head []byte) bool {
+	return strings.HasPrefix(string(head), "BANK\n")
+}
+
+func (bankImporter) Parse(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var records []Record
+	for i, line := range strings.Split(strings.TrimSpace(string(data)), "\n")[1:] {
+		fields := strings.Split(line, "|")
+		records = append(records, Record{
+			Line: i + 2, Date: fields[0], Payee: fields[1], Amount: fields[2],
+			Account: "Assets:Bank", Currency: "EUR",
+		})
+	}
+	return records, nil
+}
+
+func (bankImporter) Transactions(records []Record) ([]Entry, error) {
+	return RecordEntries(records)
+}
+
+func TestRegisteredImporter(t *testing.T) {
+	Register(bankImporter{})
+	defer func() {
+		registry.Lock()
+		delete(registry.importers, "test-bank")
+		registry.Unlock()
+	}()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected registering a name twice to panic")
+			}
+		}()
+		Register(bankImporter{})
+	}()
+
+	file, err := ParseFile([]byte(`importers:
+  checking:
+    account: Assets:Checking
+    currency: USD
+    columns:
+      date: Date
+      amount: Amount
+      payee: Description
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	plugins, err := file.Plugins()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := Find(plugins, "test-bank"); !ok {
+		t.Fatal("Expected the registered importer among the plugins")
+	}
+
+	statement := "BANK\n2024-01-05|Bakery|-5.00\n2024-01-06|Employer|100\n"
+	plugin := Detect(plugins, "statement.txt", []byte(statement))
+	if plugin == nil || plugin.Name() != "test-bank" {
+		t.Fatalf("Expected test-bank to detect the statement, got %v", plugin)
+	}
+	if Detect(plugins, "statement.csv", []byte("Date,Amount\n")) != nil {
+		t.Error("Expected no importer to detect a CSV statement")
+	}
+	entries, err := ReaderOf(plugin).Read(strings.NewReader(statement))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Line != 3 {
+		t.Fatalf("Expected 2 entries, got %+v", entries)
+	}
+	if got := entries[1].Transaction.Postings[1].Account; got != IncomePlaceholder {
+		t.Errorf("Expected the deposit to post to %s, got %s", IncomePlaceholder, got)
+	}
+
+	// A column mapping can't take a registered importer's name
+	file.Importers["test-bank"] = file.Importers["checking"]
+	if _, err := file.Plugins(); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("Expected a name clash error, got %v", err)
+	}
+}
+
+func TestRecordEntries(t *testing.T) {
+	entries, err := RecordEntries([]Record{{
+		Date: "2024-01-05", Amount: "-42.10", Currency: "USD", Account: "Assets:Checking",
+		Payee: `Hardware "Store"`, Category: "Expenses:Home", Tags: []string{"house move"},
+		Metadata: map[string]string{"ref": "A-1"},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tx := entries[0].Transaction
+	if tx.Payee != "Hardware 'Store'" || tx.Postings[1].Account != "Expenses:Home" || !tx.Postings[1].Amount.Number.Equal(decimal.RequireFromString("42.10")) {
+		t.Errorf("Unexpected transaction %+v", tx)
+	}
+	if len(tx.Tags) != 1 || tx.Tags[0] != "house-move" || tx.Metadata["ref"] != "A-1" {
+		t.Errorf("Expected the tag and metadata kept, got %v %v", tx.Tags, tx.Metadata)
+	}
+
+	tests := []struct {
+		record Record
+		want   string
+	}{
+		{Record{Line: 4, Date: "01/05/2024", Amount: "1", Currency: "USD", Account: "Assets:Checking", Payee: "x"}, "line 4: invalid date"},
+		{Record{Date: "2024-01-05", Amount: "1", Currency: "USD", Account: "checking", Payee: "x"}, "record 1: invalid account"},
+		{Record{Date: "2024-01-05", Amount: "1", Currency: "USD", Account: "Assets:Checking"}, "no payee or narration"},
+		{Record{Date: "2024-01-05", Amount: "1", Account: "Assets:Checking", Payee: "x"}, "invalid currency"},
+		{Record{Date: "2024-01-05", Amount: "1", Currency: "USD", Account: "Assets:Checking", Payee: "x", Category: "food"}, "invalid category"},
+	}
+	for _, tt := range tests {
+		if _, err := RecordEntries([]Record{tt.record}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+		}
+	}
+}
+
+func TestExternalImporter(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to run an external importer with")
+	}
+	script := filepath.Join(t.TempDir(), "importer.sh")
+	// Detects statements starting with OFXHEADER (T0ZYSEVBREVS in base64) and parses
+	// any into one record without an account or currency
+	if err := os.WriteFile(script, []byte(`request=$(cat)
+case "$request" in
+*'"method":"detect"'*)
+	case "$request" in
+	*'"content":"T0ZYSEVBREVS'*) echo '{"detected": true}' ;;
+	*) echo '{"detected": false}' ;;
+	esac ;;
+*'"content":""'*) echo 'empty statement' >&2; exit 3 ;;
+*) echo '{"records": [{"line": 7, "date": "2024-02-01", "amount": "-9.99", "payee": "Streaming"}]}' ;;
+esac
+`), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	file, err := ParseFile([]byte(`external:
+  ofx:
+    command: [` + sh + `, ` + script + `]
+    account: Assets:Checking
+    currency: USD
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	plugins, err := file.Plugins()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if Detect(plugins, "statement.csv", []byte("Date,Amount\n")) != nil {
+		t.Error("Expected the external importer not to detect a CSV statement")
+	}
+	plugin := Detect(plugins, "statement.ofx", []byte("OFXHEADER:100\n"))
+	if plugin == nil || plugin.Name() != "ofx" {
+		t.Fatalf("Expected the external importer to detect an OFX statement, got %v", plugin)
+	}
+
+	entries, err := ReaderOf(plugin).Read(strings.NewReader("OFXHEADER:100\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tx := entries[0].Transaction
+	if entries[0].Line != 7 || tx.Postings[0].Account != "Assets:Checking" || tx.Postings[0].Amount.Commodity != "USD" {
+		t.Errorf("Expected the configured account and currency, got %+v", tx.Postings[0])
+	}
+
+	if _, err := ReaderOf(plugin).Read(strings.NewReader("")); err == nil || !strings.Contains(err.Error(), "empty statement") {
+		t.Errorf("Expected the program's error, got %v", err)
+	}
+
+	if _, err := ParseFile([]byte("external:\n  broken:\n    account: checking\n")); err == nil || !strings.Contains(err.Error(), "no command") {
+		t.Errorf("Expected an invalid external importer to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,357 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// LedgerConfig reads ledger-cli and hledger journals
+// Accounts are capitalized with dashes for characters beancount doesn't allow, and
+// must be under assets, liabilities, equity, income (or revenues) or expenses.
+// Directives other than alias and year, automated and periodic transactions and
+// unbalanced virtual postings are skipped; included files are imported on their own.
+type LedgerConfig struct {
+	Currency string // Commodity of amounts in $
+}
+
+// ledgerDate matches a transaction's date, with or without the year, and an
+// auxiliary date after =
+var ledgerDate = regexp.MustCompile(`^(?:(\d{4})[/.-])?(\d{1,2})[/.-](\d{1,2})(?:=\S+)?$`)
+
+// ledgerTag matches the :tag1:tag2: lists of comments
+var ledgerTag = regexp.MustCompile(`(?:^|\s):((?:[^:\s]+:)+)`)
+
+// Read reads the transactions of a journal
+func (c LedgerConfig) Read(r io.Reader) ([]Entry, error) {
+	j := &journalReader{cfg: c, aliases: make(map[string]string), year: time.Now().Year()}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if err := j.line(line, strings.TrimRight(scanner.Text(), "\r")); err != nil {
+			if j.failed > 0 {
+				line = j.failed
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	if err := j.flush(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", j.failed, err)
+	}
+	return j.entries, nil
+}
+
+// journalReader holds the state of reading a journal line by line
+type journalReader struct {
+	cfg     LedgerConfig
+	entries []Entry
+
+	// entry is the transaction being read; skipping is set in the indented lines of
+	// blocks lima doesn't read, and block to the directive ending a comment block
+	entry    *Entry
+	skipping bool
+	block    string
+
+	// failed is the line of a transaction that can't be imported
+	failed int
+
+	aliases map[string]string
+	year    int
+}
+
+// line reads one line of a journal
+func (j *journalReader) line(n int, line string) error {
+	if j.block != "" {
+		if strings.TrimSpace(line) == j.block {
+			j.block = ""
+		}
+		return nil
+	}
+	if strings.TrimSpace(line) == "" {
+		return j.flush()
+	}
+	if line[0] == ' ' || line[0] == '\t' {
+		if j.skipping || j.entry == nil {
+			return nil
+		}
+		return j.posting(strings.TrimSpace(line))
+	}
+
+	if err := j.flush(); err != nil {
+		return err
+	}
+	j.skipping = false
+	switch {
+	case line[0] >= '0' && line[0] <= '9':
+		return j.header(n, line)
+	case strings.ContainsRune(";#%|*", rune(line[0])):
+		return nil
+	case line[0] == '=' || line[0] == '~':
+		j.skipping = true
+		return nil
+	}
+
+	directive, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+	switch directive {
+	case "comment", "test":
+		j.block = "end " + directive
+	case "include", "!include":
+		return fmt.Errorf("include is not followed: import %s on its own", rest)
+	case "apply", "bucket", "A":
+		return fmt.Errorf("%s directives are not supported", directive)
+	case "alias":
+		from, to, ok := strings.Cut(rest, "=")
+		if !ok {
+			return fmt.Errorf("invalid alias %q", rest)
+		}
+		j.aliases[strings.TrimSpace(from)] = strings.TrimSpace(to)
+	case "Y", "year":
+		year, err := strconv.Atoi(rest)
+		if err != nil {
+			return fmt.Errorf("invalid year %q", rest)
+		}
+		j.year = year
+	default:
+		// account, commodity, payee, tag, P prices and the like, as well as their
+		// indented subdirectives
+		j.skipping = true
+	}
+	return nil
+}
+
+// header starts a transaction: DATE [*|!] [(CODE)] DESCRIPTION [; COMMENT]
+func (j *journalReader) header(n int, line string) error {
+	line, comment, _ := strings.Cut(line, ";")
+	fields := strings.Fields(line)
+	m := ledgerDate.FindStringSubmatch(fields[0])
+	if m == nil {
+		return fmt.Errorf("invalid date %q", fields[0])
+	}
+	year := j.year
+	if m[1] != "" {
+		year, _ = strconv.Atoi(m[1])
+	}
+	month, _ := strconv.Atoi(m[2])
+	day, _ := strconv.Atoi(m[3])
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Month() != time.Month(month) || date.Day() != day {
+		return fmt.Errorf("invalid date %q", fields[0])
+	}
+
+	tx := &beancount.Transaction{Date: date, Flag: "*"}
+	description := strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+	if strings.HasPrefix(description, "!") {
+		tx.Flag = "!"
+	}
+	description = strings.TrimSpace(strings.TrimLeft(description, "*!"))
+	if strings.HasPrefix(description, "(") {
+		if end := strings.Index(description, ")"); end >= 0 {
+			description = strings.TrimSpace(description[end+1:])
+		}
+	}
+	// hledger separates the payee from a note with |
+	if payee, note, ok := strings.Cut(description, "|"); ok {
+		tx.Payee, tx.Narration = clean(payee), clean(note)
+	} else {
+		tx.Payee = clean(description)
+	}
+	tx.Tags = appendTags(tx.Tags, comment)
+	j.entry = &Entry{Transaction: tx, Line: n}
+	return nil
+}
+
+// posting reads an indented line of a transaction: a comment or a posting
+// [*|!] ACCOUNT  [AMOUNT [{COST}] [@ PRICE]] [= ASSERTION] [; COMMENT]
+func (j *journalReader) posting(line string) error {
+	tx := j.entry.Transaction
+	line, comment, _ := strings.Cut(line, ";")
+	tx.Tags = appendTags(tx.Tags, comment)
+	line = strings.TrimSpace(strings.TrimLeft(line, "*! "))
+	if line == "" {
+		return nil
+	}
+
+	name, amount := line, ""
+	if i := strings.IndexAny(line, "\t"); i >= 0 {
+		name, amount = line[:i], line[i+1:]
+	}
+	if i := strings.Index(name, "  "); i >= 0 {
+		name, amount = line[:i], line[i+2:]
+	}
+	name = strings.TrimSpace(name)
+	switch {
+	case strings.HasPrefix(name, "(") && strings.HasSuffix(name, ")"):
+		// Unbalanced virtual postings have no place in a balanced ledger
+		return nil
+	case strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]"):
+		name = name[1 : len(name)-1]
+	}
+	for from, to := range j.aliases {
+		if name == from || strings.HasPrefix(name, from+":") {
+			name = to + name[len(from):]
+			break
+		}
+	}
+	account := accountName("", name)
+	if account == "" {
+		return fmt.Errorf("account %q is not under assets, liabilities, equity, income or expenses", name)
+	}
+
+	posting := beancount.Posting{Account: account}
+	amount, _, _ = strings.Cut(amount, "=") // Balance assertions are checked by ledger, not imported
+	if amount = strings.TrimSpace(amount); amount != "" {
+		if err := j.cfg.parseAmounts(amount, &posting); err != nil {
+			return err
+		}
+	}
+	tx.Postings = append(tx.Postings, posting)
+	return nil
+}
+
+// flush ends the transaction being read
+func (j *journalReader) flush() error {
+	entry := j.entry
+	if entry == nil {
+		return nil
+	}
+	tx := entry.Transaction
+	elided := 0
+	for _, posting := range tx.Postings {
+		if posting.Amount == nil {
+			elided++
+		}
+	}
+	switch {
+	case len(tx.Postings) == 0:
+		// Only virtual postings
+	case len(tx.Postings) < 2:
+		j.failed = entry.Line
+		return errors.New("a transaction needs at least two postings")
+	case elided > 1:
+		j.failed = entry.Line
+		return errors.New("only one posting may omit its amount")
+	default:
+		j.entries = append(j.entries, *entry)
+	}
+	j.entry = nil
+	return nil
+}
+
+// parseAmounts reads a posting's amount with its cost and price
+func (c LedgerConfig) parseAmounts(text string, posting *beancount.Posting) error {
+	text, price, hasPrice := strings.Cut(text, "@")
+	text, cost, hasCost := strings.Cut(text, "{")
+
+	amount, err := c.parseAmount(text)
+	if err != nil {
+		return err
+	}
+	posting.Amount = &amount
+	units := amount.Number.Abs()
+
+	if hasCost {
+		total := strings.HasPrefix(cost, "{")
+		cost = strings.Trim(cost, "{} ")
+		perUnit, err := c.parseAmount(cost)
+		if err != nil {
+			return err
+		}
+		if total && !units.IsZero() {
+			perUnit.Number = perUnit.Number.Div(units)
+		}
+		posting.Cost = &perUnit
+	}
+	if hasPrice {
+		total := strings.HasPrefix(price, "@")
+		perUnit, err := c.parseAmount(strings.TrimPrefix(price, "@"))
+		if err != nil {
+			return err
+		}
+		if total && !units.IsZero() {
+			perUnit.Number = perUnit.Number.Div(units)
+		}
+		posting.Price = &perUnit
+	}
+	return nil
+}
+
+// parseAmount reads an amount with its commodity before or after the number:
+// $-12.50, -$12.50, 12.50 USD, EUR 1,000 or 10 "VANGUARD 500"
+func (c LedgerConfig) parseAmount(text string) (beancount.Amount, error) {
+	s := strings.TrimSpace(text)
+	if strings.HasPrefix(s, "(") {
+		return beancount.Amount{}, fmt.Errorf("amount expressions such as %s are not supported", s)
+	}
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative, s = true, strings.TrimSpace(s[1:])
+	}
+
+	var symbol, number string
+	if strings.HasPrefix(s, `"`) {
+		end := strings.Index(s[1:], `"`)
+		if end < 0 {
+			return beancount.Amount{}, fmt.Errorf("invalid amount %q", text)
+		}
+		symbol, number = s[1:end+1], s[end+2:]
+	} else if i := strings.IndexFunc(s, isNumberRune); i > 0 {
+		symbol, number = s[:i], s[i:]
+	} else {
+		i = strings.IndexFunc(s, func(r rune) bool { return !isNumberRune(r) })
+		if i < 0 {
+			i = len(s)
+		}
+		number, symbol = s[:i], s[i:]
+	}
+	symbol = strings.Trim(strings.TrimSpace(symbol), `"`)
+	number = strings.TrimSpace(number)
+
+	parsed, err := decimal.NewFromString(strings.ReplaceAll(number, ",", ""))
+	if err != nil {
+		return beancount.Amount{}, fmt.Errorf("invalid amount %q", text)
+	}
+	if negative {
+		parsed = parsed.Neg()
+	}
+	commodity := c.Currency
+	if symbol != "" {
+		commodity = commodityName(symbol, c.Currency)
+	}
+	if commodity == "" && (symbol == "" || symbol == "$") {
+		return beancount.Amount{}, fmt.Errorf("amount %q needs a currency: none is configured", text)
+	}
+	if commodity == "" {
+		return beancount.Amount{}, fmt.Errorf("commodity %q has no valid beancount name", symbol)
+	}
+	return beancount.Amount{Number: parsed, Commodity: commodity}, nil
+}
+
+// isNumberRune reports whether a rune is part of a number as journals write it
+func isNumberRune(r rune) bool {
+	return r >= '0' && r <= '9' || r == '.' || r == ',' || r == '-'
+}
+
+// appendTags adds the tags of a comment's :tag1:tag2: lists
+func appendTags(tags []string, comment string) []string {
+	for _, m := range ledgerTag.FindAllStringSubmatch(comment, -1) {
+		for _, tag := range strings.Split(strings.Trim(m[1], ":"), ":") {
+			if tag = tagName(tag); tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
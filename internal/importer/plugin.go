@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// HeadSize is how much of a statement importers see to detect it
+const HeadSize = 4096
+
+// Importer reads the statements of one institution or format: registered Go
+// importers and external programs named in the importers file are importers, and
+// are picked by name or by detecting the statements they read
+type Importer interface {
+	// Name identifies the importer, e.g. for lima import --importer
+	Name() string
+
+	// Detect reports whether the importer reads a statement, from its path and its
+	// first HeadSize bytes
+	Detect(path string, head []byte) bool
+
+	// Parse reads a statement's rows as records
+	Parse(r io.Reader) ([]Record, error)
+
+	// Transactions turns records into entries; most importers use RecordEntries
+	Transactions(records []Record) ([]Entry, error)
+}
+
+// Record is a statement row as an importer parses it, before it is a transaction
+// Amounts are from the account's side: negative for money going out.
+type Record struct {
+	Line      int               `json:"line,omitempty"`      // Row's line in the statement
+	Date      string            `json:"date"`                // YYYY-MM-DD
+	Amount    string            `json:"amount"`              // Decimal number, e.g. -12.50
+	Currency  string            `json:"currency"`            // Commodity, e.g. USD
+	Account   string            `json:"account"`             // Statement's account, e.g. Assets:Checking
+	Payee     string            `json:"payee,omitempty"`     // Counterparty
+	Narration string            `json:"narration,omitempty"` // Description or memo
+	Category  string            `json:"category,omitempty"`  // Other account; "" for a placeholder to categorize
+	Tags      []string          `json:"tags,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Transaction builds the record's transaction: the amount on the account, balanced by
+// the category or, without one, a placeholder
+func (r Record) Transaction() (*beancount.Transaction, error) {
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(r.Date))
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: use YYYY-MM-DD", r.Date)
+	}
+	number, err := decimal.NewFromString(strings.TrimSpace(r.Amount))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", r.Amount)
+	}
+	if !beancount.IsValidAccount(r.Account) {
+		return nil, fmt.Errorf("invalid account %q", r.Account)
+	}
+	if !beancount.IsValidCommodity(r.Currency) {
+		return nil, fmt.Errorf("invalid currency %q", r.Currency)
+	}
+	if r.Payee == "" && r.Narration == "" {
+		return nil, errors.New("no payee or narration")
+	}
+
+	tx := NewTransaction(date, r.Payee, r.Narration, r.Account, &beancount.Amount{Number: number, Commodity: r.Currency})
+	if r.Category != "" {
+		if !beancount.IsValidAccount(r.Category) {
+			return nil, fmt.Errorf("invalid category %q", r.Category)
+		}
+		tx.Postings[1].Account = r.Category
+	}
+	for _, tag := range r.Tags {
+		if tag = tagName(tag); tag != "" {
+			tx.Tags = append(tx.Tags, tag)
+		}
+	}
+	for key, value := range r.Metadata {
+		if tx.Metadata == nil {
+			tx.Metadata = make(map[string]string)
+		}
+		tx.Metadata[key] = clean(value)
+	}
+	return tx, nil
+}
+
+// RecordEntries turns records into entries, one transaction each
+func RecordEntries(records []Record) ([]Entry, error) {
+	entries := make([]Entry, 0, len(records))
+	for i, record := range records {
+		tx, err := record.Transaction()
+		if err != nil {
+			line := record.Line
+			if line == 0 {
+				return nil, fmt.Errorf("record %d: %w", i+1, err)
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, Entry{Transaction: tx, Line: record.Line})
+	}
+	return entries, nil
+}
+
+// ReaderOf reads statements with an importer, parsing them and then building their
+// transactions
+func ReaderOf(imp Importer) Reader {
+	return importerReader{imp}
+}
+
+// importerReader is an importer as a Reader
+type importerReader struct {
+	imp Importer
+}
+
+// Read parses a statement and builds its transactions
+func (r importerReader) Read(statement io.Reader) ([]Entry, error) {
+	records, err := r.imp.Parse(statement)
+	if err != nil {
+		return nil, err
+	}
+	return r.imp.Transactions(records)
+}
+
+// registry holds the Go importers registered, by name
+var registry = struct {
+	sync.Mutex
+	importers map[string]Importer
+}{importers: make(map[string]Importer)}
+
+// Register makes a Go importer available to lima import and the Imports view,
+// usually from the init function of the file defining it
+// It panics when the name is empty or already registered.
+func Register(imp Importer) {
+	registry.Lock()
+	defer registry.Unlock()
+	name := imp.Name()
+	if name == "" {
+		panic("importer: Register with an empty name")
+	}
+	if _, ok := registry.importers[name]; ok {
+		panic("importer: Register called twice for " + name)
+	}
+	registry.importers[name] = imp
+}
+
+// Registered returns the registered Go importers, sorted by name
+func Registered() []Importer {
+	registry.Lock()
+	defer registry.Unlock()
+	importers := make([]Importer, 0, len(registry.importers))
+	for _, imp := range registry.importers {
+		importers = append(importers, imp)
+	}
+	sortImporters(importers)
+	return importers
+}
+
+// sortImporters sorts importers by name
+func sortImporters(importers []Importer) {
+	sort.Slice(importers, func(i, j int) bool { return importers[i].Name() < importers[j].Name() })
+}
+
+// Find returns the importer with a name, if any
+func Find(importers []Importer, name string) (Importer, bool) {
+	for _, imp := range importers {
+		if imp.Name() == name {
+			return imp, true
+		}
+	}
+	return nil, false
+}
+
+// Detect returns the first importer that reads a statement, given its content, or nil
+func Detect(importers []Importer, path string, data []byte) Importer {
+	head := data[:min(len(data), HeadSize)]
+	for _, imp := range importers {
+		if imp.Detect(path, head) {
+			return imp
+		}
+	}
+	return nil
+}
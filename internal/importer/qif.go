@@ -0,0 +1,343 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// QIFConfig reads QIF exports, as written by Quicken, Microsoft Money and GnuCash
+// Categories become Expenses: or Income: accounts by the sign of their amount and
+// [transfers] the account named; rows without a category post to a placeholder.
+// Investment accounts and lists such as categories and memorized transactions are
+// skipped.
+type QIFConfig struct {
+	Account          string // Account of files without !Account headers, e.g. Assets:Checking
+	Currency         string // Commodity of every amount
+	DayFirst         bool   // Dates are day/month/year rather than month/day/year
+	DecimalSeparator string // "." (default) or ","
+}
+
+// qifRoots maps QIF account types to the root of their beancount account
+var qifRoots = map[string]string{
+	"bank":  "Assets",
+	"cash":  "Assets",
+	"oth a": "Assets",
+	"invst": "Assets",
+	"port":  "Assets",
+	"ccard": "Liabilities",
+	"oth l": "Liabilities",
+}
+
+// qifRecord is a transaction of a QIF file as written, one field per code
+type qifRecord struct {
+	line     int
+	date     string
+	amount   string
+	payee    string
+	memo     string
+	category string
+	splits   []qifSplit
+}
+
+// qifSplit is one line of a split transaction
+type qifSplit struct {
+	category string
+	memo     string
+	amount   string
+}
+
+// Read reads the transactions of a QIF file
+// A transfer between two accounts of the file is read once, not from both sides.
+func (c QIFConfig) Read(r io.Reader) ([]Entry, error) {
+	q := &qifReader{
+		cfg:       c,
+		accounts:  make(map[string]string),
+		transfers: make(map[string]int),
+		account:   c.Account,
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if err := q.line(line, strings.TrimRight(scanner.Text(), "\r")); err != nil {
+			if q.record != nil {
+				line = q.record.line
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read QIF file: %w", err)
+	}
+	if q.record != nil {
+		if err := q.flush(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", q.record.line, err)
+		}
+	}
+	return q.entries, nil
+}
+
+// qifReader holds the state of reading a QIF file line by line
+type qifReader struct {
+	cfg     QIFConfig
+	entries []Entry
+
+	// section is the !Type of the lines being read, lowercase; account the account
+	// their transactions are for
+	section string
+	account string
+
+	// accounts maps the names of the file's accounts to beancount accounts
+	accounts   map[string]string
+	autoSwitch bool
+
+	// accountName and accountType collect an !Account record's fields
+	accountName, accountType string
+
+	record *qifRecord
+
+	// transfers counts the transfers read, by date, accounts and amount, so their
+	// other side can be skipped
+	transfers map[string]int
+}
+
+// line reads one line of a QIF file
+func (q *qifReader) line(n int, line string) error {
+	if strings.HasPrefix(line, "!") {
+		header := strings.ToLower(strings.TrimSpace(line))
+		switch {
+		case header == "!option:autoswitch":
+			q.autoSwitch = true
+		case header == "!clear:autoswitch":
+			q.autoSwitch = false
+		case strings.HasPrefix(header, "!type:"):
+			q.section = strings.TrimSpace(strings.TrimPrefix(header, "!type:"))
+		default:
+			q.section = header
+		}
+		return nil
+	}
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	code, value := line[0], strings.TrimSpace(line[1:])
+	if q.section == "!account" {
+		switch code {
+		case 'N':
+			q.accountName = value
+		case 'T':
+			q.accountType = strings.ToLower(value)
+		case '^':
+			return q.endAccount()
+		}
+		return nil
+	}
+	if !q.transactions() {
+		return nil
+	}
+
+	if q.record == nil {
+		q.record = &qifRecord{line: n}
+	}
+	rec := q.record
+	switch code {
+	case 'D':
+		rec.date = value
+	case 'T', 'U':
+		if rec.amount == "" || code == 'T' {
+			rec.amount = value
+		}
+	case 'P':
+		rec.payee = value
+	case 'M':
+		rec.memo = value
+	case 'L':
+		rec.category = value
+	case 'S':
+		rec.splits = append(rec.splits, qifSplit{category: value})
+	case 'E':
+		if len(rec.splits) > 0 {
+			rec.splits[len(rec.splits)-1].memo = value
+		}
+	case '$':
+		if len(rec.splits) > 0 {
+			rec.splits[len(rec.splits)-1].amount = value
+		}
+	case '^':
+		if err := q.flush(); err != nil {
+			return err
+		}
+		q.record = nil
+	}
+	return nil
+}
+
+// transactions reports whether the current section lists transactions lima reads
+func (q *qifReader) transactions() bool {
+	switch q.section {
+	case "bank", "cash", "ccard", "oth a", "oth l":
+		return true
+	}
+	return false
+}
+
+// endAccount records an account of the file, which its transactions are for unless
+// the file only lists its accounts
+func (q *qifReader) endAccount() error {
+	name, kind := q.accountName, q.accountType
+	q.accountName, q.accountType = "", ""
+	if name == "" {
+		return nil
+	}
+	root, ok := qifRoots[kind]
+	if !ok {
+		root = "Assets"
+	}
+	account := accountName(root, name)
+	if account == "" {
+		return fmt.Errorf("account %q has no valid beancount name", name)
+	}
+	q.accounts[strings.ToLower(name)] = account
+	if !q.autoSwitch {
+		q.account = account
+	}
+	return nil
+}
+
+// flush turns the record read into an entry
+func (q *qifReader) flush() error {
+	rec := q.record
+	if rec.date == "" && rec.amount == "" {
+		return nil
+	}
+	if q.account == "" {
+		return fmt.Errorf("the file names no account: give the account its transactions are for")
+	}
+	date, err := q.cfg.parseDate(rec.date)
+	if err != nil {
+		return err
+	}
+	number, err := parseNumber(rec.amount, q.cfg.DecimalSeparator)
+	if err != nil {
+		return err
+	}
+	amount := beancount.Amount{Number: number, Commodity: q.cfg.Currency}
+	tx := NewTransaction(date, rec.payee, rec.memo, q.account, &amount)
+
+	if len(rec.splits) == 0 {
+		account, tag, transfer := q.category(rec.category, number)
+		if account != "" {
+			tx.Postings[1].Account = account
+		}
+		if tag != "" {
+			tx.Tags = append(tx.Tags, tag)
+		}
+		if transfer {
+			// Both accounts of a transfer list it: keep whichever comes first
+			key := transferKey(date, account, q.account, number.Neg())
+			if q.transfers[key] > 0 {
+				q.transfers[key]--
+				return nil
+			}
+			q.transfers[transferKey(date, q.account, account, number)]++
+		}
+	} else {
+		tx.Postings = tx.Postings[:1]
+		for _, split := range rec.splits {
+			splitNumber, err := parseNumber(split.amount, q.cfg.DecimalSeparator)
+			if err != nil {
+				return err
+			}
+			account, tag, _ := q.category(split.category, splitNumber)
+			if account == "" {
+				account = ExpensePlaceholder
+				if splitNumber.IsPositive() {
+					account = IncomePlaceholder
+				}
+			}
+			posting := beancount.Posting{
+				Account: account,
+				Amount:  &beancount.Amount{Number: splitNumber.Neg(), Commodity: q.cfg.Currency},
+			}
+			if memo := clean(split.memo); memo != "" {
+				posting.Metadata = map[string]string{"memo": memo}
+			}
+			tx.Postings = append(tx.Postings, posting)
+			if tag != "" && !slices.Contains(tx.Tags, tag) {
+				tx.Tags = append(tx.Tags, tag)
+			}
+		}
+	}
+	q.entries = append(q.entries, Entry{Transaction: tx, Line: rec.line})
+	return nil
+}
+
+// category returns the account of a QIF category, "" for none, with the tag of its
+// class and whether it is a [transfer]; amount is the account's side of it
+func (q *qifReader) category(category string, amount decimal.Decimal) (string, string, bool) {
+	category, class, _ := strings.Cut(category, "/")
+	tag := tagName(class)
+	category = strings.TrimSpace(category)
+	if strings.HasPrefix(category, "[") && strings.HasSuffix(category, "]") {
+		name := strings.TrimSpace(category[1 : len(category)-1])
+		if account, ok := q.accounts[strings.ToLower(name)]; ok {
+			return account, tag, true
+		}
+		return accountName("Assets", name), tag, true
+	}
+	if category == "" {
+		return "", tag, false
+	}
+	root := "Expenses"
+	if amount.IsPositive() {
+		root = "Income"
+	}
+	return accountName(root, category), tag, false
+}
+
+// transferKey identifies a transfer by its date, accounts and the amount from's side
+func transferKey(date time.Time, from, to string, amount decimal.Decimal) string {
+	return date.Format("2006-01-02") + " " + from + " " + to + " " + amount.String()
+}
+
+// qifDate matches the dates of QIF files: 1/5'24, 01/05/2024, 1-5-24 or 05.01.2024
+var qifDate = regexp.MustCompile(`^(\d{1,2})\s*[/.-]\s*(\d{1,2})\s*(['/.-])\s*(\d{2}|\d{4})$`)
+
+// parseDate reads a QIF date; two-digit years after an apostrophe, as Quicken writes
+// them from 2000, and below 70 otherwise are in this century
+func (c QIFConfig) parseDate(text string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", text); err == nil {
+		return date, nil
+	}
+	m := qifDate.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", text)
+	}
+	month, _ := strconv.Atoi(m[1])
+	day, _ := strconv.Atoi(m[2])
+	if c.DayFirst {
+		month, day = day, month
+	}
+	year, _ := strconv.Atoi(m[4])
+	if len(m[4]) == 2 {
+		if m[3] == "'" || year < 70 {
+			year += 2000
+		} else {
+			year += 1900
+		}
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Month() != time.Month(month) || date.Day() != day {
+		return time.Time{}, fmt.Errorf("invalid date %q", text)
+	}
+	return date, nil
+}
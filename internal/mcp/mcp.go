@@ -0,0 +1,218 @@
+// Package mcp serves a ledger to AI assistants over the Model Context Protocol: JSON-RPC
+// messages, one per line, on standard input and output. The assistant calls tools to
+// query the ledger and suggest categories; nothing is written and the ledger never
+// leaves the machine, though what the tools return goes to the assistant's model.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+)
+
+// protocolVersions are the MCP revisions the server speaks, newest first
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// maxMessageBytes bounds one message from the client
+const maxMessageBytes = 4 << 20
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Server answers MCP requests about one ledger
+type Server struct {
+	file        *beancount.File
+	categorizer *categorizer.Categorizer
+	currency    string
+	version     string
+	now         func() time.Time
+
+	ledger *beancount.Loader
+}
+
+// New returns a server over a ledger, reporting amounts in currency where they are
+// converted; a nil categorizer leaves out the suggest_category tool
+func New(file *beancount.File, cat *categorizer.Categorizer, currency, version string) (*Server, error) {
+	s := &Server{
+		file:        file,
+		categorizer: cat,
+		currency:    currency,
+		version:     version,
+		now:         time.Now,
+	}
+	ledger, err := beancount.NewLoader(file, s.loaded)
+	if err != nil {
+		return nil, err
+	}
+	s.ledger = ledger
+	return s, nil
+}
+
+// request is a JSON-RPC request, or a notification when it has no id
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC response, with a result or an error
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// Serve reads requests from r and writes responses to w until r ends
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	encoder := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if resp := s.handle(line); resp != nil {
+			if err := encoder.Encode(resp); err != nil {
+				return fmt.Errorf("failed to write response: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle answers one message; notifications get no response
+func (s *Server) handle(message []byte) *response {
+	var req request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, "invalid JSON: " + err.Error()}}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &response{JSONRPC: "2.0", ID: orNull(req.ID), Error: &rpcError{codeInvalidRequest, "not a JSON-RPC 2.0 request"}}
+	}
+	result, err := s.dispatch(req)
+	if req.ID == nil {
+		return nil
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		rerr, ok := err.(*rpcError)
+		if !ok {
+			rerr = &rpcError{codeInvalidParams, err.Error()}
+		}
+		resp.Result, resp.Error = nil, rerr
+	}
+	return resp
+}
+
+// dispatch runs a method
+func (s *Server) dispatch(req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		version := protocolVersions[0]
+		if slices.Contains(protocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "lima", "version": s.version},
+			"instructions": "Tools for reading the user's beancount ledger. Amounts are exact " +
+				"decimals; expenses are positive, income negative. Use query for totals.",
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		list := []map[string]any{}
+		for _, t := range s.tools() {
+			list = append(list, map[string]any{"name": t.name, "description": t.description, "inputSchema": t.schema})
+		}
+		return map[string]any{"tools": list}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{codeInvalidParams, "invalid params: " + err.Error()}
+		}
+		i := slices.IndexFunc(s.tools(), func(t tool) bool { return t.name == params.Name })
+		if i < 0 {
+			return nil, &rpcError{codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+		if len(params.Arguments) == 0 || string(params.Arguments) == "null" {
+			params.Arguments = json.RawMessage("{}")
+		}
+		// A failing tool is reported to the model, which may try again differently
+		text, err := s.call(s.tools()[i], params.Arguments)
+		if err != nil {
+			return toolResult(err.Error(), true), nil
+		}
+		return toolResult(text, false), nil
+	}
+	if req.ID == nil {
+		// Notifications such as notifications/initialized need nothing done
+		return nil, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("unknown method %q", req.Method)}
+}
+
+// call brings the ledger up to date and runs a tool
+func (s *Server) call(t tool, arguments json.RawMessage) (string, error) {
+	if err := s.ledger.Refresh(); err != nil {
+		return "", err
+	}
+	return t.run(s, arguments)
+}
+
+// toolResult is the result of tools/call: text content, flagged when it is an error
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]any{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// orNull returns a request id, or null when the request had none
+func orNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// loaded brings the categorizer up to date with the transactions just read
+func (s *Server) loaded(transactions []*beancount.Transaction) {
+	if s.categorizer != nil {
+		s.categorizer.SetKnownAccounts(s.file.DeclaredAccounts())
+		s.categorizer.TrainSimilarity(transactions)
+	}
+}
@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+const testLedger = `2024-01-01 open Assets:Checking USD
+2024-01-01 open Expenses:Food:Dining
+2024-01-01 open Expenses:Food:Groceries
+
+2024-03-02 * "Cafe" "Lunch"
+  Expenses:Food:Dining  12.25 USD
+  Assets:Checking
+
+2024-03-20 * "Bistro" "Dinner"
+  Expenses:Food:Dining  40.00 USD
+  Assets:Checking
+
+2024-04-01 * "Grocer" "Groceries"
+  Expenses:Food:Groceries  45.50 USD
+  Assets:Checking
+`
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.beancount")
+	if err := os.WriteFile(path, []byte(testLedger), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patterns := filepath.Join(dir, "patterns.yaml")
+	yaml := "version: \"1\"\npatterns:\n  - id: cafe\n    name: Cafe\n    pattern: \"(?i)cafe\"\n    category: Expenses:Food:Dining\n"
+	if err := os.WriteFile(patterns, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := beancount.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	t.Cleanup(func() { file.Close() })
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = patterns
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(file, cat, "USD", "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.now = func() time.Time { return time.Date(2024, 4, 10, 0, 0, 0, 0, time.UTC) }
+	return s
+}
+
+// exchange sends messages, one per line, and decodes the responses
+func exchange(t *testing.T, s *Server, messages ...string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(strings.Join(messages, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var responses []map[string]any
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var resp map[string]any
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// text returns the text of a tools/call result and whether it is an error
+func text(t *testing.T, resp map[string]any) (string, bool) {
+	t.Helper()
+	result, ok := resp["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected a result, got %v", resp)
+	}
+	content := result["content"].([]any)[0].(map[string]any)
+	return content["text"].(string), result["isError"] == true
+}
+
+func TestProtocol(t *testing.T) {
+	s := newTestServer(t)
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":"four","method":"tools/call","params":{"name":"nope"}}`,
+	)
+	if len(responses) != 5 {
+		t.Fatalf("expected 5 responses, the notification unanswered, got %v", responses)
+	}
+
+	initialized := responses[0]["result"].(map[string]any)
+	if initialized["protocolVersion"] != "2025-03-26" || initialized["serverInfo"].(map[string]any)["name"] != "lima" {
+		t.Errorf("unexpected initialize result %v", initialized)
+	}
+	var names []string
+	for _, item := range responses[1]["result"].(map[string]any)["tools"].([]any) {
+		names = append(names, item.(map[string]any)["name"].(string))
+	}
+	if strings.Join(names, ",") != "query,transactions,accounts,balances,report,suggest_category" {
+		t.Errorf("unexpected tools %v", names)
+	}
+	for i, code := range map[int]float64{2: -32601, 3: -32700, 4: -32602} {
+		if rerr, ok := responses[i]["error"].(map[string]any); !ok || rerr["code"] != code {
+			t.Errorf("response %d: expected error %v, got %v", i, code, responses[i])
+		}
+	}
+	if responses[4]["id"] != "four" {
+		t.Errorf("expected the request's id, got %v", responses[4]["id"])
+	}
+}
+
+func TestTools(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		call    string
+		want    string
+		isError bool
+	}{
+		{`{"name":"query","arguments":{"query":"SELECT sum(amount) WHERE account ~ '^Expenses:Food:Dining' AND year = 2024 AND month = 3"}}`, "52.25 USD", false},
+		{`{"name":"query","arguments":{"query":"SELECT nope"}}`, `unknown column "nope"`, true},
+		{`{"name":"transactions","arguments":{"period":"2024-03","limit":1}}`, "The newest 1 of 2 transactions\n\n2024-03-20 * \"Bistro\" \"Dinner\"", false},
+		{`{"name":"accounts"}`, "Expenses:Food:Dining     2024-01-01", false},
+		{`{"name":"balances","arguments":{"account":"Expenses:Food","date":"2024-03-31"}}`, "Expenses:Food:Dining  52.25 USD", false},
+		{`{"name":"report","arguments":{"kind":"spending","period":"2024-03"}}`, "Expenses:Food", false},
+		{`{"name":"suggest_category","arguments":{"payee":"Corner Cafe","amount":"-5.00"}}`, "Expenses:Food:Dining", false},
+		{`{"name":"suggest_category","arguments":{}}`, "give a payee or narration", true},
+	}
+	for i, tt := range tests {
+		responses := exchange(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":`+tt.call+`}`)
+		got, isError := text(t, responses[0])
+		if !strings.Contains(got, tt.want) || isError != tt.isError {
+			t.Errorf("call %d: expected %q (error %v), got %q (error %v)", i, tt.want, tt.isError, got, isError)
+		}
+	}
+}
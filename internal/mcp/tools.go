@@ -0,0 +1,366 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/importer"
+	"github.com/mmichie/lima/internal/query"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/reports"
+	"github.com/shopspring/decimal"
+)
+
+// maxRows bounds the rows and transactions a tool returns, to keep answers within
+// what a model reads
+const maxRows = 500
+
+// periodHelp describes the periods tools accept
+const periodHelp = `"2024", "2024-Q1", "2024-03", "this month", "last quarter", "this year" or "all"`
+
+// tool is a function the assistant can call, with a JSON schema of its arguments
+type tool struct {
+	name        string
+	description string
+	schema      map[string]any
+	run         func(s *Server, arguments json.RawMessage) (string, error)
+}
+
+// object is the JSON schema of an object with the given properties
+func object(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// property is the JSON schema of a described value of a type
+func property(kind, description string) map[string]any {
+	return map[string]any{"type": kind, "description": description}
+}
+
+// allTools are the tools the server offers
+var allTools = []tool{
+	{
+		name: "query",
+		description: "Run a bean-query style SQL query over the ledger's postings, one row per posting. " +
+			"Columns: " + strings.Join(query.ColumnNames(), ", ") + ". Aggregates: sum, count, avg, min, max, first, last. " +
+			"`~` matches a case-insensitive regular expression; 'tag' IN tags tests tags. Example, dining in March 2024: " +
+			"SELECT sum(amount) WHERE account ~ '^Expenses:Food:Dining' AND year = 2024 AND month = 3",
+		schema: object(map[string]any{"query": property("string", "the query, starting with SELECT")}, "query"),
+		run:    runQuery,
+	},
+	{
+		name: "transactions",
+		description: "List transactions, newest first, as beancount text. The filter takes words matched against payee " +
+			"and narration, and terms such as account:food, payee:starbucks, tag:travel, amount>50 or date:2024-03.",
+		schema: object(map[string]any{
+			"filter": property("string", "filter terms, all of which must match; empty for every transaction"),
+			"period": property("string", "only transactions in this period: "+periodHelp),
+			"limit":  property("integer", fmt.Sprintf("most transactions to return, default 50, at most %d", maxRows)),
+		}),
+		run: listTransactions,
+	},
+	{
+		name:        "accounts",
+		description: "List the ledger's accounts with the dates they were opened and closed.",
+		schema:      object(map[string]any{}),
+		run:         listAccounts,
+	},
+	{
+		name:        "balances",
+		description: "Balances of accounts at the end of a date, each parent including its children.",
+		schema: object(map[string]any{
+			"account": property("string", "only this account and those under it, e.g. Assets or Liabilities:CreditCard"),
+			"date":    property("string", "YYYY-MM-DD; defaults to today"),
+		}),
+		run: listBalances,
+	},
+	{
+		name: "report",
+		description: "A built-in report for a period: the income statement, balance sheet, spending by category or " +
+			"net worth at the end of each month, with amounts converted at the ledger's prices.",
+		schema: object(map[string]any{
+			"kind":     map[string]any{"type": "string", "enum": reports.Kinds},
+			"period":   property("string", periodHelp+"; defaults to this month"),
+			"currency": property("string", "currency to convert into; defaults to the configured one"),
+		}, "kind"),
+		run: runReport,
+	},
+	{
+		name:        "suggest_category",
+		description: "Suggest expense or income accounts for a transaction, from the user's categorization patterns and history.",
+		schema: object(map[string]any{
+			"payee":     property("string", "payee or merchant"),
+			"narration": property("string", "description"),
+			"amount":    property("string", `amount leaving the account as a negative number, e.g. "-12.50"`),
+			"currency":  property("string", "commodity of the amount; defaults to the configured currency"),
+		}),
+		run: suggestCategory,
+	},
+}
+
+// tools returns the tools this server offers
+func (s *Server) tools() []tool {
+	if s.categorizer != nil {
+		return allTools
+	}
+	return slices.DeleteFunc(slices.Clone(allTools), func(t tool) bool { return t.name == "suggest_category" })
+}
+
+// runQuery runs a query and returns its result as an aligned table
+func runQuery(s *Server, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	result, err := query.Run(s.file, args.Query)
+	if err != nil {
+		return "", err
+	}
+	if len(result.Rows) == 0 {
+		return "No rows", nil
+	}
+	return table("rows", result.Columns, result.Strings())
+}
+
+// listTransactions returns the transactions a filter selects within a period, newest
+// first, as beancount
+func listTransactions(s *Server, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Filter string `json:"filter"`
+		Period string `json:"period"`
+		Limit  int    `json:"limit"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	filter, err := beancount.ParseFilter(args.Filter)
+	if err != nil {
+		return "", err
+	}
+	period := beancount.AllTime()
+	if args.Period != "" {
+		if period, err = beancount.ParsePeriod(args.Period, s.now()); err != nil {
+			return "", err
+		}
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	limit = min(limit, maxRows)
+
+	order := s.file.TransactionsFrom(time.Time{})
+	var lines []string
+	matched, shown := 0, 0
+	for j := len(order) - 1; j >= 0; j-- {
+		tx := s.ledger.Transactions()[order[j]]
+		if !period.Contains(tx.Date) || !filter.Match(tx) {
+			continue
+		}
+		matched++
+		if shown == limit {
+			continue
+		}
+		formatted, err := beancount.FormatTransaction(tx)
+		if err != nil {
+			return "", err
+		}
+		lines = append(append(lines, formatted...), "")
+		shown++
+	}
+	if matched == 0 {
+		return "No transactions", nil
+	}
+	header := fmt.Sprintf("%d transactions", matched)
+	if shown < matched {
+		header = fmt.Sprintf("The newest %d of %d transactions", shown, matched)
+	}
+	return header + "\n\n" + strings.Join(lines, "\n"), nil
+}
+
+// listAccounts returns the accounts with their open and close dates
+func listAccounts(s *Server, arguments json.RawMessage) (string, error) {
+	opened := make(map[string]time.Time)
+	for _, open := range s.file.GetOpenDirectives() {
+		opened[open.Account] = open.Date
+	}
+	closed := make(map[string]time.Time)
+	for _, c := range s.file.GetCloseDirectives() {
+		closed[c.Account] = c.Date
+	}
+	names := slices.Clone(s.file.GetAccounts())
+	for name := range opened {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var rows [][]string
+	for _, name := range names {
+		row := []string{name, "", ""}
+		if date, ok := opened[name]; ok {
+			row[1] = date.Format(time.DateOnly)
+		}
+		if date, ok := closed[name]; ok {
+			row[2] = date.Format(time.DateOnly)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return "No accounts", nil
+	}
+	return table("accounts", []string{"account", "opened", "closed"}, rows)
+}
+
+// listBalances returns the rolled-up balances of accounts under a prefix at a date
+func listBalances(s *Server, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Account string `json:"account"`
+		Date    string `json:"date"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	date := s.now()
+	if args.Date != "" {
+		var err error
+		if date, err = time.Parse(time.DateOnly, args.Date); err != nil {
+			return "", fmt.Errorf("invalid date %q: use YYYY-MM-DD", args.Date)
+		}
+	}
+
+	var transactions []*beancount.Transaction
+	for _, tx := range s.ledger.Transactions() {
+		if !tx.Date.After(date) {
+			transactions = append(transactions, tx)
+		}
+	}
+	balances := beancount.RollUpBalances(beancount.AccountBalances(transactions))
+	accounts := make([]string, 0, len(balances))
+	for account := range balances {
+		if args.Account == "" || beancount.AccountUnder(account, args.Account) {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Strings(accounts)
+
+	var rows [][]string
+	for _, account := range accounts {
+		commodities := make([]string, 0, len(balances[account]))
+		for commodity, n := range balances[account] {
+			if !n.IsZero() {
+				commodities = append(commodities, commodity)
+			}
+		}
+		sort.Strings(commodities)
+		for _, commodity := range commodities {
+			rows = append(rows, []string{account, query.Format(beancount.Amount{Number: balances[account][commodity], Commodity: commodity})})
+		}
+	}
+	if len(rows) == 0 {
+		return fmt.Sprintf("No balances on %s", date.Format(time.DateOnly)), nil
+	}
+	text, err := table("balances", []string{"account", "balance"}, rows)
+	return fmt.Sprintf("Balances at the end of %s\n\n%s", date.Format(time.DateOnly), text), err
+}
+
+// runReport computes one of lima report's reports
+func runReport(s *Server, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Kind     string  `json:"kind"`
+		Period   string  `json:"period"`
+		Currency *string `json:"currency"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Period == "" {
+		args.Period = "this month"
+	}
+	period, err := beancount.ParsePeriod(args.Period, s.now())
+	if err != nil {
+		return "", err
+	}
+	currency := s.currency
+	if args.Currency != nil {
+		currency = *args.Currency
+	}
+	report, err := reports.Run(s.file, args.Kind, period, currency)
+	if err != nil {
+		return "", err
+	}
+	if len(report.Rows) == 0 {
+		return fmt.Sprintf("Nothing to report for %s", period), nil
+	}
+	text, err := table(report.Name, report.Columns, report.Rows)
+	return fmt.Sprintf("%s report for %s\n\n%s", args.Kind, period, text), err
+}
+
+// suggestCategory returns the categorizer's suggestions for a described transaction
+func suggestCategory(s *Server, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Payee     string `json:"payee"`
+		Narration string `json:"narration"`
+		Amount    string `json:"amount"`
+		Currency  string `json:"currency"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Payee == "" && args.Narration == "" {
+		return "", fmt.Errorf("give a payee or narration")
+	}
+	var amount *beancount.Amount
+	if args.Amount != "" {
+		n, err := decimal.NewFromString(args.Amount)
+		if err != nil {
+			return "", fmt.Errorf("invalid amount %q", args.Amount)
+		}
+		currency := args.Currency
+		if currency == "" {
+			currency = s.currency
+		}
+		amount = &beancount.Amount{Number: n, Commodity: currency}
+	}
+	suggestions, err := s.categorizer.SuggestAll(importer.NewTransaction(s.now(), args.Payee, args.Narration, "", amount))
+	if err != nil {
+		return "", err
+	}
+	if len(suggestions) == 0 {
+		return "No suggestions", nil
+	}
+	var lines []string
+	for _, suggestion := range suggestions {
+		line := fmt.Sprintf("%s (%.0f%%, %s)", suggestion.Category, suggestion.Confidence*100, suggestion.Source)
+		if suggestion.Reason != "" {
+			line += ": " + suggestion.Reason
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// table renders rows as aligned text, cut at maxRows
+func table(name string, columns []string, rows [][]string) (string, error) {
+	var note string
+	if len(rows) > maxRows {
+		note = fmt.Sprintf("\n(first %d of %d rows)", maxRows, len(rows))
+		rows = rows[:maxRows]
+	}
+	data, err := export.Encode(export.Table{Name: name, Columns: columns, Rows: rows}, "table")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\n") + note, nil
+}
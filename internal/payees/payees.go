@@ -0,0 +1,289 @@
+// Package payees finds payees that name the same merchant spelled differently, such
+// as "AMAZON.COM" and "AMZN Mktp US*2K3", and renames them to one name
+//
+// Payees are compared in the form the categorizer normalizes them to. Two names are
+// taken for the same merchant when their first words match, one perhaps abbreviating
+// the other, or when they differ by no more than a typo.
+package payees
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+)
+
+// typoSimilarity is how alike two normalized names must be, as a share of the longer
+// one's letters, to be the same merchant whatever their words
+const typoSimilarity = 0.85
+
+// leadingNoise are words card processors put before the merchant's name
+var leadingNoise = map[string]bool{
+	"THE": true, "SQ": true, "TST": true, "POS": true, "SP": true,
+	"DEBIT": true, "PURCHASE": true, "CHECKCARD": true,
+}
+
+// Variant is one spelling of a payee and the transactions using it
+type Variant struct {
+	Name         string
+	Transactions []*beancount.Transaction
+}
+
+// Cluster is the spellings of one merchant, most used first, and the name proposed
+// for all of them
+type Cluster struct {
+	Name     string
+	Variants []Variant
+}
+
+// Count returns how many transactions the cluster's spellings have
+func (c Cluster) Count() int {
+	n := 0
+	for _, v := range c.Variants {
+		n += len(v.Transactions)
+	}
+	return n
+}
+
+// Renamed returns the transactions whose payee is not yet the cluster's name
+func (c Cluster) Renamed() int {
+	n := 0
+	for _, v := range c.Variants {
+		if v.Name != c.Name {
+			n += len(v.Transactions)
+		}
+	}
+	return n
+}
+
+// group is the spellings that normalize alike
+type group struct {
+	key      string
+	words    []string
+	variants []Variant
+	count    int
+}
+
+// Find clusters the payees of transactions, returning the clusters of two or more
+// spellings, those with the most transactions first
+func Find(transactions []*beancount.Transaction) []Cluster {
+	// Spellings that normalize alike are one group from the start
+	groups := make(map[string]*group)
+	byName := make(map[string]*Variant)
+	var names []string
+	for _, tx := range transactions {
+		if tx.Payee == "" {
+			continue
+		}
+		v, ok := byName[tx.Payee]
+		if !ok {
+			v = &Variant{Name: tx.Payee}
+			byName[tx.Payee] = v
+			names = append(names, tx.Payee)
+		}
+		v.Transactions = append(v.Transactions, tx)
+	}
+	for _, name := range names {
+		key := categorizer.NormalizePayee(name)
+		if key == "" {
+			continue
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{key: key, words: significantWords(key)}
+			groups[key] = g
+		}
+		g.variants = append(g.variants, *byName[name])
+		g.count += len(byName[name].Transactions)
+	}
+
+	ordered := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		ordered = append(ordered, g)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].count != ordered[j].count {
+			return ordered[i].count > ordered[j].count
+		}
+		return ordered[i].key < ordered[j].key
+	})
+
+	// Each group joins the first cluster whose most used group it is like, so
+	// likenesses don't chain from one merchant to another
+	var seeds []*group
+	members := make(map[*group][]*group)
+	for _, g := range ordered {
+		joined := false
+		for _, seed := range seeds {
+			if similar(seed, g) {
+				members[seed] = append(members[seed], g)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			seeds = append(seeds, g)
+			members[g] = []*group{g}
+		}
+	}
+
+	var clusters []Cluster
+	for _, seed := range seeds {
+		var c Cluster
+		for _, g := range members[seed] {
+			c.Variants = append(c.Variants, g.variants...)
+		}
+		if len(c.Variants) < 2 {
+			continue
+		}
+		sort.SliceStable(c.Variants, func(i, j int) bool {
+			if len(c.Variants[i].Transactions) != len(c.Variants[j].Transactions) {
+				return len(c.Variants[i].Transactions) > len(c.Variants[j].Transactions)
+			}
+			return c.Variants[i].Name < c.Variants[j].Name
+		})
+		c.Name = Propose(c.Variants)
+		clusters = append(clusters, c)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].Count() > clusters[j].Count()
+	})
+	return clusters
+}
+
+// Similar reports whether two payees name the same merchant
+func Similar(a, b string) bool {
+	ka, kb := categorizer.NormalizePayee(a), categorizer.NormalizePayee(b)
+	if ka == "" || kb == "" {
+		return false
+	}
+	return similar(&group{key: ka, words: significantWords(ka)}, &group{key: kb, words: significantWords(kb)})
+}
+
+// similar reports whether two groups name the same merchant: their first words match,
+// and either one abbreviates the other, or a name has no more words or the second
+// words match too; or the names are a typo apart
+func similar(a, b *group) bool {
+	if a.key == b.key {
+		return true
+	}
+	if len(a.words) > 0 && len(b.words) > 0 {
+		first, second := a.words[0], b.words[0]
+		switch {
+		case first == second:
+			if len(a.words) == 1 || len(b.words) == 1 || a.words[1] == b.words[1] {
+				return true
+			}
+		case abbreviates(first, second) || abbreviates(second, first):
+			return true
+		}
+	}
+	longest := max(len(a.key), len(b.key))
+	return longest >= 5 && 1-float64(distance(a.key, b.key))/float64(longest) >= typoSimilarity
+}
+
+// significantWords returns a normalized name's words without the processors' noise
+// in front of them
+func significantWords(key string) []string {
+	words := strings.Fields(key)
+	for len(words) > 1 && leadingNoise[words[0]] {
+		words = words[1:]
+	}
+	return words
+}
+
+// abbreviates reports whether short abbreviates long: it starts with the same letter
+// and has the rest of its letters in order, such as AMZN for AMAZON
+func abbreviates(short, long string) bool {
+	if len(short) < 3 || len(short) >= len(long) || short[0] != long[0] {
+		return false
+	}
+	i := 0
+	for j := 0; j < len(long) && i < len(short); j++ {
+		if short[i] == long[j] {
+			i++
+		}
+	}
+	return i == len(short)
+}
+
+// distance returns how many letters must be added, removed, changed or swapped with
+// the next to turn one string into the other
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// Propose picks the name for a cluster's spellings: the most used one without store
+// numbers or reference codes, one not in capitals when as many use it, else the most
+// used
+func Propose(variants []Variant) string {
+	best := -1
+	for i, v := range variants {
+		if !clean(v.Name) {
+			continue
+		}
+		if best < 0 || len(v.Transactions) > len(variants[best].Transactions) ||
+			len(v.Transactions) == len(variants[best].Transactions) && shouted(variants[best].Name) && !shouted(v.Name) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		return variants[best].Name
+	}
+	if len(variants) == 0 {
+		return ""
+	}
+	return variants[0].Name
+}
+
+// clean reports whether a payee is free of digits and processor markers
+func clean(name string) bool {
+	return !strings.ContainsAny(name, "*#") && !strings.ContainsFunc(name, unicode.IsDigit)
+}
+
+// shouted reports whether a payee is all in capitals, as bank statements write them
+func shouted(name string) bool {
+	return strings.ToUpper(name) == name && strings.ContainsFunc(name, unicode.IsLetter)
+}
+
+// Edits builds the edits renaming the payee of the variants' transactions to name;
+// those already named so are left alone
+func Edits(writer *beancount.Writer, variants []Variant, name string) ([]beancount.Edit, error) {
+	var edits []beancount.Edit
+	for _, v := range variants {
+		if v.Name == name {
+			continue
+		}
+		for _, tx := range v.Transactions {
+			edit, err := writer.SetPayee(tx, name)
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, edit)
+		}
+	}
+	return edits, nil
+}
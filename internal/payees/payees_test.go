@@ -0,0 +1,142 @@
+package payees
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// payees builds a transaction for each payee, in order
+func transactions(payees ...string) []*beancount.Transaction {
+	var result []*beancount.Transaction
+	for _, payee := range payees {
+		result = append(result, &beancount.Transaction{Payee: payee})
+	}
+	return result
+}
+
+func names(c Cluster) []string {
+	var result []string
+	for _, v := range c.Variants {
+		result = append(result, v.Name)
+	}
+	return result
+}
+
+func TestFind(t *testing.T) {
+	clusters := Find(transactions(
+		"Amazon.com", "Amazon.com", "AMAZON.COM", "AMZN Mktp US*2K3", "AMZN Mktp US*9QX",
+		"Starbucks #123", "STARBUCKS #456 SEATTLE", "Starbucks",
+		"American Airlines", "American Express",
+		"Whole Foods", "WHOLEFDS MKT 10234",
+		"Netflix",
+		"",
+	))
+	if len(clusters) != 3 {
+		t.Fatalf("expected 3 clusters, got %d: %+v", len(clusters), clusters)
+	}
+
+	amazon := clusters[0]
+	if amazon.Name != "Amazon.com" || amazon.Count() != 5 || amazon.Renamed() != 3 {
+		t.Errorf("unexpected cluster %q with %d transactions, %d renamed", amazon.Name, amazon.Count(), amazon.Renamed())
+	}
+	if want := []string{"Amazon.com", "AMAZON.COM", "AMZN Mktp US*2K3", "AMZN Mktp US*9QX"}; !slices.Equal(names(amazon), want) {
+		t.Errorf("got spellings %q, want %q", names(amazon), want)
+	}
+
+	// The clean spelling is proposed over those with store numbers
+	if clusters[1].Name != "Starbucks" || len(clusters[1].Variants) != 3 {
+		t.Errorf("unexpected cluster %q: %q", clusters[1].Name, names(clusters[1]))
+	}
+	if clusters[2].Name != "Whole Foods" {
+		t.Errorf("unexpected cluster %q: %q", clusters[2].Name, names(clusters[2]))
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"AMAZON.COM", "AMZN Mktp US*ABC", true},
+		{"Starbucks #12", "STARBUCKS SEATTLE", true},
+		{"SQ *BLUE BOTTLE", "Blue Bottle Coffee", false},
+		{"The Home Depot", "HOME DEPOT #4410", true},
+		{"Trader Joes", "Trader Joe's", true},
+		{"Safeway", "Safway", true},
+		{"Chipotle 1234", "Chiptole", true},
+		{"American Airlines", "American Express", false},
+		{"Shell", "Chevron", false},
+		{"AB", "ABC", false},
+	}
+	for _, tt := range tests {
+		if got := Similar(tt.a, tt.b); got != tt.want {
+			t.Errorf("Similar(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPropose(t *testing.T) {
+	variants := []Variant{
+		{Name: "UBER *TRIP 123", Transactions: transactions("", "", "")},
+		{Name: "Uber", Transactions: transactions("")},
+	}
+	if got := Propose(variants); got != "Uber" {
+		t.Errorf("got %q, want the clean spelling", got)
+	}
+	if got := Propose(variants[:1]); got != "UBER *TRIP 123" {
+		t.Errorf("got %q, want the most used spelling", got)
+	}
+}
+
+func TestEdits(t *testing.T) {
+	content := `2025-01-01 * "AMAZON.COM" "Books"
+  Assets:Checking  -25.00 USD
+  Expenses:Books    25.00 USD
+
+2025-01-02 * "AMZN Mktp US*2K3" "Cable"
+  Assets:Checking  -10.00 USD
+  Expenses:Home     10.00 USD
+
+2025-01-03 * "Amazon.com" "Lamp"
+  Assets:Checking  -30.00 USD
+  Expenses:Home     30.00 USD
+`
+	path := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := beancount.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	all, err := file.AllTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusters := Find(all)
+	if len(clusters) != 1 {
+		t.Fatalf("expected one cluster, got %+v", clusters)
+	}
+	writer := beancount.NewWriter(file)
+	edits, err := Edits(writer, clusters[0].Variants, clusters[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 2 {
+		t.Fatalf("expected the two other spellings renamed, got %d edits", len(edits))
+	}
+	if err := writer.ApplyAll(edits); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Count(string(data), `"Amazon.com"`) != 3 {
+		t.Errorf("expected every payee renamed:\n%s", data)
+	}
+}
@@ -0,0 +1,242 @@
+// Package portfolio reports the performance of investments held at cost
+//
+// Each account's positions are kept as lots, one per purchase: postings with a cost,
+// such as 10 VTI {200 USD}, add a lot and postings selling a commodity held there take
+// units from the oldest lots first. Holdings are valued at the latest price on or
+// before the report date, from price directives or the prices purchases and sales
+// imply. The time-weighted return compounds the growth between each purchase and sale,
+// so money moving in and out doesn't count as performance.
+package portfolio
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// Holding is a commodity held in one account
+type Holding struct {
+	Account   string
+	Commodity string
+	Currency  string          // What the cost basis and value are in
+	Units     decimal.Decimal // Left in the account's lots
+	Cost      decimal.Decimal // Cost basis of the units left
+	Value     decimal.Decimal // Units at the latest price
+	Priced    bool            // Whether there is a price; without one the value is the cost
+	Since     time.Time       // Date of the first purchase
+	Return    decimal.Decimal // Time-weighted return since the first purchase, 0.05 for 5%
+}
+
+// Gain returns the holding's unrealized gain
+func (h Holding) Gain() decimal.Decimal {
+	return h.Value.Sub(h.Cost)
+}
+
+// Account is all of an account's holdings in one currency
+type Account struct {
+	Name        string
+	Currency    string
+	Cost        decimal.Decimal
+	Value       decimal.Decimal
+	Since       time.Time
+	Return      decimal.Decimal // Time-weighted return of the holdings together
+	Commodities []string        // Held, sorted
+}
+
+// Gain returns the account's unrealized gain
+func (a Account) Gain() decimal.Decimal {
+	return a.Value.Sub(a.Cost)
+}
+
+// Report is the holdings on a date and how they have performed
+type Report struct {
+	Date     time.Time
+	Holdings []Holding // By account, then commodity
+	Accounts []Account // By account, then currency
+}
+
+// lot is units bought together at one cost
+type lot struct {
+	units decimal.Decimal
+	cost  decimal.Decimal // Per unit
+}
+
+// flow is a purchase or sale of a commodity on a date
+type flow struct {
+	date      time.Time
+	commodity string
+	units     decimal.Decimal
+}
+
+// position is a commodity in one account, as its lots are bought and sold
+type position struct {
+	account, commodity, currency string
+	lots                         []lot
+	flows                        []flow
+}
+
+// Compute reports the holdings of accounts under roots, e.g. Assets:Investments, on
+// date; prices are the ledger's price directives
+func Compute(transactions []*beancount.Transaction, prices []beancount.Price, roots []string, date time.Time) Report {
+	sorted := slices.Clone(transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	positions := make(map[[2]string]*position)
+	var order [][2]string
+	known := slices.Clone(prices)
+	for _, tx := range sorted {
+		if tx.Date.After(date) {
+			break
+		}
+		for _, posting := range tx.ResolvedPostings() {
+			if posting.Amount == nil || !beancount.AccountUnderAny(posting.Account, roots) {
+				continue
+			}
+			k := [2]string{posting.Account, posting.Amount.Commodity}
+			p := positions[k]
+			if p == nil && posting.Cost == nil {
+				continue // Not held at cost, like cash
+			}
+			if p == nil {
+				p = &position{account: k[0], commodity: k[1], currency: posting.Cost.Commodity}
+				positions[k] = p
+				order = append(order, k)
+			}
+
+			// Buying and selling imply prices, as beancount's implicit_prices plugin does
+			if implied := posting.Cost; implied != nil || posting.Price != nil {
+				if posting.Price != nil {
+					implied = posting.Price
+				}
+				known = append(known, beancount.Price{Date: tx.Date, Commodity: k[1], Amount: *implied})
+			}
+			p.flows = append(p.flows, flow{date: tx.Date, commodity: k[1], units: posting.Amount.Number})
+			if posting.Amount.Number.IsPositive() {
+				cost := decimal.Zero
+				if posting.Cost != nil {
+					cost = posting.Cost.Number
+				}
+				p.lots = append(p.lots, lot{units: posting.Amount.Number, cost: cost})
+			} else {
+				p.lots = reduce(p.lots, posting.Amount.Number.Neg())
+			}
+		}
+	}
+
+	db := beancount.NewPriceDB(known)
+	report := Report{Date: date}
+	type group struct{ account, currency string }
+	grouped := make(map[group][]*position)
+	var groups []group
+	for _, k := range order {
+		p := positions[k]
+		g := group{p.account, p.currency}
+		if grouped[g] == nil {
+			groups = append(groups, g)
+		}
+		grouped[g] = append(grouped[g], p)
+
+		holding := Holding{Account: p.account, Commodity: p.commodity, Currency: p.currency, Since: p.flows[0].date}
+		for _, l := range p.lots {
+			holding.Units = holding.Units.Add(l.units)
+			holding.Cost = holding.Cost.Add(l.units.Mul(l.cost))
+		}
+		if holding.Units.IsZero() {
+			continue
+		}
+		holding.Value = holding.Cost
+		if price, ok := db.Rate(p.commodity, p.currency, date); ok {
+			holding.Value = holding.Units.Mul(price)
+			holding.Priced = true
+		}
+		holding.Return = timeWeighted(p.flows, db, p.currency, date)
+		report.Holdings = append(report.Holdings, holding)
+	}
+	sort.SliceStable(report.Holdings, func(i, j int) bool {
+		a, b := report.Holdings[i], report.Holdings[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		return a.Commodity < b.Commodity
+	})
+
+	for _, g := range groups {
+		account := Account{Name: g.account, Currency: g.currency}
+		var flows []flow
+		for _, p := range grouped[g] {
+			flows = append(flows, p.flows...)
+			if account.Since.IsZero() || p.flows[0].date.Before(account.Since) {
+				account.Since = p.flows[0].date
+			}
+		}
+		for _, holding := range report.Holdings {
+			if holding.Account == g.account && holding.Currency == g.currency {
+				account.Cost = account.Cost.Add(holding.Cost)
+				account.Value = account.Value.Add(holding.Value)
+				account.Commodities = append(account.Commodities, holding.Commodity)
+			}
+		}
+		if len(account.Commodities) == 0 {
+			continue // Everything sold
+		}
+		sort.SliceStable(flows, func(i, j int) bool { return flows[i].date.Before(flows[j].date) })
+		account.Return = timeWeighted(flows, db, g.currency, date)
+		report.Accounts = append(report.Accounts, account)
+	}
+	sort.SliceStable(report.Accounts, func(i, j int) bool {
+		a, b := report.Accounts[i], report.Accounts[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Currency < b.Currency
+	})
+	return report
+}
+
+// reduce takes units from the oldest lots first
+func reduce(lots []lot, units decimal.Decimal) []lot {
+	for len(lots) > 0 && units.IsPositive() {
+		taken := decimal.Min(lots[0].units, units)
+		lots[0].units = lots[0].units.Sub(taken)
+		units = units.Sub(taken)
+		if lots[0].units.IsZero() {
+			lots = lots[1:]
+		}
+	}
+	return lots
+}
+
+// timeWeighted compounds the growth of the units held between each day they change,
+// and from the last change to date, leaving out the purchases and sales themselves
+func timeWeighted(flows []flow, db *beancount.PriceDB, currency string, date time.Time) decimal.Decimal {
+	units := make(map[string]decimal.Decimal)
+	value := func(on time.Time) decimal.Decimal {
+		total := decimal.Zero
+		for commodity, n := range units {
+			if price, ok := db.Rate(commodity, currency, on); ok {
+				total = total.Add(n.Mul(price))
+			}
+		}
+		return total
+	}
+
+	growth := decimal.NewFromInt(1)
+	last := decimal.Zero // Value just after the previous change
+	for i := 0; i < len(flows); {
+		day := flows[i].date
+		if last.IsPositive() {
+			growth = growth.Mul(value(day)).DivRound(last, 16)
+		}
+		for ; i < len(flows) && flows[i].date.Equal(day); i++ {
+			units[flows[i].commodity] = units[flows[i].commodity].Add(flows[i].units)
+		}
+		last = value(day)
+	}
+	if last.IsPositive() {
+		growth = growth.Mul(value(date)).DivRound(last, 16)
+	}
+	return growth.Sub(decimal.NewFromInt(1)).Round(6)
+}
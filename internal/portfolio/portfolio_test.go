@@ -0,0 +1,90 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/shopspring/decimal"
+)
+
+func amount(n, commodity string) *beancount.Amount {
+	return &beancount.Amount{Number: decimal.RequireFromString(n), Commodity: commodity}
+}
+
+// trade builds a transaction buying (or selling, with negative units) a commodity at
+// a cost or price per unit for cash
+func trade(day, account, units, commodity string, cost, price *beancount.Amount) *beancount.Transaction {
+	return &beancount.Transaction{
+		Date: beancounttest.Date(day),
+		Postings: []beancount.Posting{
+			{Account: account, Amount: amount(units, commodity), Cost: cost, Price: price},
+			{Account: "Assets:Brokerage:Cash"},
+		},
+	}
+}
+
+func price(day, commodity, number string) beancount.Price {
+	return beancount.Price{Date: beancounttest.Date(day), Commodity: commodity, Amount: *amount(number, "USD")}
+}
+
+func TestCompute(t *testing.T) {
+	transactions := []*beancount.Transaction{
+		trade("2024-01-02", "Assets:Brokerage:VTI", "10", "VTI", amount("100", "USD"), nil),
+		// Doubling the holding after the price rose doesn't change the return
+		trade("2024-03-01", "Assets:Brokerage:VTI", "10", "VTI", amount("110", "USD"), nil),
+		// Selling takes from the first lot
+		trade("2024-05-01", "Assets:Brokerage:VTI", "-5", "VTI", nil, amount("121", "USD")),
+		trade("2024-02-01", "Assets:Brokerage:GOLD", "2", "GOLD", amount("50", "USD"), nil),
+		trade("2024-02-01", "Assets:Checking", "100", "USD", nil, nil),
+		trade("2025-01-01", "Assets:Brokerage:VTI", "10", "VTI", amount("200", "USD"), nil),
+	}
+	prices := []beancount.Price{price("2024-06-30", "VTI", "133.1")}
+	report := Compute(transactions, prices, []string{"Assets:Brokerage"}, beancounttest.Date("2024-06-30"))
+
+	if len(report.Holdings) != 2 || report.Holdings[0].Commodity != "GOLD" || report.Holdings[1].Commodity != "VTI" {
+		t.Fatalf("unexpected holdings %+v", report.Holdings)
+	}
+
+	// Gold was never priced again: valued at cost, no return
+	gold := report.Holdings[0]
+	if !gold.Value.Equal(decimal.NewFromInt(100)) || !gold.Gain().IsZero() || !gold.Return.IsZero() {
+		t.Errorf("unexpected gold %+v", gold)
+	}
+
+	vti := report.Holdings[1]
+	if !vti.Units.Equal(decimal.NewFromInt(15)) || !vti.Cost.Equal(decimal.NewFromInt(5*100+10*110)) {
+		t.Errorf("unexpected VTI lots %+v", vti)
+	}
+	if !vti.Priced || !vti.Value.Equal(decimal.RequireFromString("1996.5")) || !vti.Gain().Equal(decimal.RequireFromString("396.5")) {
+		t.Errorf("unexpected VTI value %+v", vti)
+	}
+	// 10% three times over
+	if !vti.Return.Equal(decimal.RequireFromString("0.331")) {
+		t.Errorf("got VTI return %s, want 0.331", vti.Return)
+	}
+	if !vti.Since.Equal(beancounttest.Date("2024-01-02")) {
+		t.Errorf("got VTI held since %s", vti.Since)
+	}
+}
+
+func TestComputeAccounts(t *testing.T) {
+	transactions := []*beancount.Transaction{
+		trade("2024-01-01", "Assets:IRA", "10", "VTI", amount("100", "USD"), nil),
+		trade("2024-01-01", "Assets:IRA", "10", "BND", amount("100", "USD"), nil),
+		trade("2024-01-01", "Assets:Old", "1", "VTI", amount("100", "USD"), nil),
+		trade("2024-02-01", "Assets:Old", "-1", "VTI", nil, amount("100", "USD")),
+	}
+	prices := []beancount.Price{price("2024-12-31", "VTI", "120"), price("2024-12-31", "BND", "90")}
+	report := Compute(transactions, prices, []string{"Assets"}, beancounttest.Date("2024-12-31"))
+
+	// The sold-out account is left out; the IRA gained 10% together
+	if len(report.Accounts) != 1 {
+		t.Fatalf("unexpected accounts %+v", report.Accounts)
+	}
+	ira := report.Accounts[0]
+	if ira.Name != "Assets:IRA" || !ira.Value.Equal(decimal.NewFromInt(2100)) || !ira.Gain().Equal(decimal.NewFromInt(100)) ||
+		!ira.Return.Equal(decimal.RequireFromString("0.05")) || len(ira.Commodities) != 2 {
+		t.Errorf("unexpected IRA %+v", ira)
+	}
+}
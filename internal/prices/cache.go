@@ -0,0 +1,100 @@
+package prices
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// cachedQuote is a quote in the cache file
+type cachedQuote struct {
+	Date     string    `yaml:"date"`
+	Price    string    `yaml:"price"`
+	Currency string    `yaml:"currency"`
+	Fetched  time.Time `yaml:"fetched"`
+}
+
+// Cache keeps the quotes fetched recently, by source, symbol and currency
+type Cache struct {
+	path   string
+	quotes map[string]cachedQuote
+}
+
+// LoadCache reads the cache file at path; a missing file is an empty cache
+func LoadCache(path string) (*Cache, error) {
+	cache := &Cache{path: path, quotes: make(map[string]cachedQuote)}
+	if path == "" {
+		return cache, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price cache: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cache.quotes); err != nil {
+		return nil, fmt.Errorf("failed to parse price cache %s: %w", path, err)
+	}
+	if cache.quotes == nil {
+		cache.quotes = make(map[string]cachedQuote)
+	}
+	return cache, nil
+}
+
+// Get returns the quote cached under key if it was fetched less than ttl before now
+func (c *Cache) Get(key string, now time.Time, ttl time.Duration) (Quote, bool) {
+	if c == nil || ttl <= 0 {
+		return Quote{}, false
+	}
+	cached, ok := c.quotes[key]
+	if !ok || now.Sub(cached.Fetched) >= ttl {
+		return Quote{}, false
+	}
+	date, err := time.Parse("2006-01-02", cached.Date)
+	if err != nil {
+		return Quote{}, false
+	}
+	price, err := decimal.NewFromString(cached.Price)
+	if err != nil {
+		return Quote{}, false
+	}
+	return Quote{Date: date, Price: price, Currency: cached.Currency}, true
+}
+
+// Put caches a quote fetched at now under key
+func (c *Cache) Put(key string, quote Quote, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.quotes[key] = cachedQuote{
+		Date:     quote.Date.Format("2006-01-02"),
+		Price:    quote.Price.String(),
+		Currency: quote.Currency,
+		Fetched:  now,
+	}
+}
+
+// Save writes the cache back to its file
+func (c *Cache) Save() error {
+	if c == nil || c.path == "" {
+		return nil
+	}
+	data, err := yaml.Marshal(c.quotes)
+	if err != nil {
+		return fmt.Errorf("failed to encode price cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create price cache directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write price cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Package prices fetches the latest prices of held commodities from quote services,
+// Yahoo Finance for securities, CoinGecko for crypto and the ECB for currencies, and
+// turns them into price directives. Quotes are cached so running it again soon after
+// asks nothing of the services, and requests to each service are rate limited.
+package prices
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// Quote is a commodity's price as a source reports it
+type Quote struct {
+	Date     time.Time
+	Price    decimal.Decimal
+	Currency string
+}
+
+// Source is a quote service
+type Source interface {
+	// Quote returns the latest price of the symbol, in currency where the service
+	// lets us choose
+	Quote(ctx context.Context, symbol, currency string) (Quote, error)
+}
+
+// NewSources creates the quote services, each limited to requestsPerMinute requests
+func NewSources(client *http.Client, requestsPerMinute int) map[string]Source {
+	interval := time.Minute / time.Duration(max(requestsPerMinute, 1))
+	return map[string]Source{
+		"yahoo":     limit(NewYahoo(yahooEndpoint, client), interval),
+		"coingecko": limit(NewCoinGecko(coingeckoEndpoint, client), interval),
+		"ecb":       limit(NewECB(ecbEndpoint, client), interval),
+	}
+}
+
+// limited is a source waiting between requests
+type limited struct {
+	source      Source
+	interval    time.Duration
+	mu          sync.Mutex
+	lastRequest time.Time
+}
+
+// limit makes a source wait interval between requests
+func limit(source Source, interval time.Duration) *limited {
+	return &limited{source: source, interval: interval}
+}
+
+// Quote waits until the rate limit allows another request, then asks the source
+func (l *limited) Quote(ctx context.Context, symbol, currency string) (Quote, error) {
+	l.mu.Lock()
+	wait := time.Until(l.lastRequest.Add(l.interval))
+	if wait <= 0 {
+		l.lastRequest = time.Now()
+	} else {
+		l.lastRequest = l.lastRequest.Add(l.interval)
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return Quote{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return l.source.Quote(ctx, symbol, currency)
+}
+
+// Held returns the commodities with a balance in asset or liability accounts, other
+// than currency, sorted
+func Held(transactions []*beancount.Transaction, currency string) []string {
+	totals := make(map[string]decimal.Decimal)
+	for account, balance := range beancount.AccountBalances(transactions) {
+		if !strings.HasPrefix(account, "Assets:") && !strings.HasPrefix(account, "Liabilities:") {
+			continue
+		}
+		for commodity, number := range balance {
+			totals[commodity] = totals[commodity].Add(number)
+		}
+	}
+	var held []string
+	for commodity, total := range totals {
+		if commodity != currency && !total.IsZero() {
+			held = append(held, commodity)
+		}
+	}
+	sort.Strings(held)
+	return held
+}
+
+// SourceOf returns the source a commodity is fetched from and its symbol there, the
+// commodity itself when the spec names only the source, e.g. "ecb"
+func SourceOf(spec, commodity string) (string, string) {
+	source, symbol, ok := strings.Cut(spec, ":")
+	if !ok || symbol == "" {
+		symbol = commodity
+	}
+	return source, symbol
+}
+
+// Failure is a commodity whose price could not be fetched
+type Failure struct {
+	Commodity string
+	Err       error
+}
+
+// Result is what a fetch found
+type Result struct {
+	// Prices are the new price directives, by commodity
+	Prices []beancount.Price
+
+	// Cached counts the prices taken from the cache, Known those already in the ledger
+	Cached int
+	Known  int
+
+	// Unsourced are the commodities with no source in prices.sources
+	Unsourced []string
+	Failed    []Failure
+}
+
+// Fetch quotes each commodity from its configured source, reusing cached quotes, and
+// returns the prices not already among known
+// A commodity that fails is reported and the others are still fetched.
+func Fetch(ctx context.Context, sources map[string]Source, cfg config.PricesConfig, commodities []string, known []beancount.Price, cache *Cache, now time.Time) Result {
+	var result Result
+	have := make(map[string]bool, len(known))
+	for _, price := range known {
+		have[priceKey(price)] = true
+	}
+	ttl := time.Duration(cfg.CacheMinutes) * time.Minute
+
+	for _, commodity := range commodities {
+		spec, ok := cfg.Sources[commodity]
+		if !ok {
+			result.Unsourced = append(result.Unsourced, commodity)
+			continue
+		}
+		name, symbol := SourceOf(spec, commodity)
+		source, ok := sources[name]
+		if !ok {
+			result.Failed = append(result.Failed, Failure{commodity, fmt.Errorf("unknown price source %q", name)})
+			continue
+		}
+
+		key := name + ":" + symbol + ":" + cfg.Currency
+		quote, cached := cache.Get(key, now, ttl)
+		if cached {
+			result.Cached++
+		} else {
+			var err error
+			if quote, err = source.Quote(ctx, symbol, cfg.Currency); err != nil {
+				result.Failed = append(result.Failed, Failure{commodity, err})
+				continue
+			}
+			cache.Put(key, quote, now)
+		}
+
+		price := beancount.Price{
+			Date:      quote.Date,
+			Commodity: commodity,
+			Amount:    beancount.Amount{Number: quote.Price, Commodity: quote.Currency},
+		}
+		if have[priceKey(price)] {
+			result.Known++
+			continue
+		}
+		have[priceKey(price)] = true
+		result.Prices = append(result.Prices, price)
+	}
+	return result
+}
+
+// priceKey identifies a commodity's price in a currency on a date
+func priceKey(price beancount.Price) string {
+	return price.Date.Format("2006-01-02") + " " + price.Commodity + " " + price.Amount.Commodity
+}
@@ -0,0 +1,167 @@
+package prices
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// quoteServer answers like the three quote services, counting the requests
+func quoteServer(t *testing.T, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		switch {
+		case r.URL.Path == "/v8/finance/chart/VTI":
+			fmt.Fprint(w, `{"chart": {"result": [{"meta": {"currency": "USD", "regularMarketPrice": 238.45,
+				"regularMarketTime": 1704488400, "exchangeTimezoneName": "America/New_York"}}], "error": null}}`)
+		case r.URL.Path == "/v8/finance/chart/VOD.L":
+			fmt.Fprint(w, `{"chart": {"result": [{"meta": {"currency": "GBp", "regularMarketPrice": 68.5,
+				"regularMarketTime": 1704472200, "exchangeTimezoneName": "Europe/London"}}], "error": null}}`)
+		case strings.HasPrefix(r.URL.Path, "/v8/finance/chart/"):
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"chart": {"result": null, "error": {"code": "Not Found", "description": "No data found, symbol may be delisted"}}}`)
+		case r.URL.Path == "/simple/price":
+			if r.URL.Query().Get("vs_currencies") != "usd" {
+				t.Errorf("unexpected request %s", r.URL)
+			}
+			fmt.Fprintf(w, `{"%s": {"usd": 43921.5, "last_updated_at": 1704500000}}`, r.URL.Query().Get("ids"))
+		case r.URL.Path == "/eurofxref-daily.xml":
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2024-01-05">
+			<Cube currency="USD" rate="1.0921"/>
+			<Cube currency="GBP" rate="0.86"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestFetch(t *testing.T) {
+	requests := 0
+	server := quoteServer(t, &requests)
+	defer server.Close()
+	sources := map[string]Source{
+		"yahoo":     NewYahoo(server.URL, server.Client()),
+		"coingecko": NewCoinGecko(server.URL, server.Client()),
+		"ecb":       NewECB(server.URL+"/eurofxref-daily.xml", server.Client()),
+	}
+	cfg := config.DefaultConfig().Prices
+	cfg.Sources = map[string]string{
+		"VTI":  "yahoo",
+		"VOD":  "yahoo:VOD.L",
+		"BTC":  "coingecko:bitcoin",
+		"EUR":  "ecb",
+		"GBP":  "ecb",
+		"GONE": "yahoo",
+	}
+	known := []beancount.Price{{
+		Date:      time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		Commodity: "GBP",
+		Amount:    beancount.Amount{Number: decimal.RequireFromString("1.27"), Commodity: "USD"},
+	}}
+	cache, err := LoadCache(filepath.Join(t.TempDir(), "prices.yaml"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC)
+
+	commodities := []string{"BTC", "EUR", "GBP", "GONE", "HOUSE", "VOD", "VTI"}
+	result := Fetch(context.Background(), sources, cfg, commodities, known, cache, now)
+	var got []string
+	for _, price := range result.Prices {
+		line, err := beancount.FormatPrice(price)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		got = append(got, line)
+	}
+	want := []string{
+		"2024-01-06 price BTC 43921.50 USD",
+		"2024-01-05 price EUR 1.0921 USD",
+		"2024-01-05 price VOD 0.685 GBP",
+		"2024-01-05 price VTI 238.45 USD",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	if result.Known != 1 || len(result.Unsourced) != 1 || result.Unsourced[0] != "HOUSE" {
+		t.Errorf("Expected GBP known and HOUSE unsourced, got %d and %v", result.Known, result.Unsourced)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Commodity != "GONE" || !strings.Contains(result.Failed[0].Err.Error(), "delisted") {
+		t.Errorf("Expected GONE to fail with Yahoo's reason, got %+v", result.Failed)
+	}
+	// The ECB rates are fetched once for both currencies
+	if requests != 5 {
+		t.Errorf("Expected 5 requests, got %d", requests)
+	}
+
+	// Within the cache's lifetime nothing is fetched again, even after a restart
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cache, err = LoadCache(cache.path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	again := Fetch(context.Background(), sources, cfg, []string{"BTC", "VTI"}, nil, cache, now.Add(30*time.Minute))
+	if requests != 5 || again.Cached != 2 || len(again.Prices) != 2 || !again.Prices[1].Amount.Number.Equal(decimal.RequireFromString("238.45")) {
+		t.Errorf("Expected 2 cached prices without requests, got %+v after %d requests", again, requests)
+	}
+	Fetch(context.Background(), sources, cfg, []string{"BTC"}, nil, cache, now.Add(2*time.Hour))
+	if requests != 6 {
+		t.Errorf("Expected an expired quote fetched again, got %d requests", requests)
+	}
+}
+
+func TestHeld(t *testing.T) {
+	posting := func(account, number, commodity string) beancount.Posting {
+		return beancount.Posting{Account: account, Amount: &beancount.Amount{Number: decimal.RequireFromString(number), Commodity: commodity}}
+	}
+	transactions := []*beancount.Transaction{
+		{Postings: []beancount.Posting{posting("Assets:Brokerage", "10", "VTI"), posting("Assets:Checking", "-2000", "USD"), posting("Expenses:Fees", "0", "USD")}},
+		{Postings: []beancount.Posting{posting("Assets:Wallet", "0.5", "BTC"), posting("Assets:Checking", "-20000", "USD")}},
+		{Postings: []beancount.Posting{posting("Assets:Wallet", "-0.5", "BTC"), posting("Income:Gains", "20000", "USD")}},
+		{Postings: []beancount.Posting{posting("Liabilities:Card", "-100", "EUR"), posting("Expenses:Travel", "100", "EUR")}},
+	}
+	if got := strings.Join(Held(transactions, "USD"), " "); got != "EUR VTI" {
+		t.Errorf("Expected EUR VTI held, got %s", got)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	calls := 0
+	source := limit(sourceFunc(func(ctx context.Context, symbol, currency string) (Quote, error) {
+		calls++
+		return Quote{}, nil
+	}), time.Hour)
+	if _, err := source.Quote(context.Background(), "A", "USD"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := source.Quote(ctx, "B", "USD"); err == nil || calls != 1 {
+		t.Errorf("Expected the second request held back, got %v after %d calls", err, calls)
+	}
+}
+
+// sourceFunc is a function used as a source
+type sourceFunc func(ctx context.Context, symbol, currency string) (Quote, error)
+
+func (f sourceFunc) Quote(ctx context.Context, symbol, currency string) (Quote, error) {
+	return f(ctx, symbol, currency)
+}
@@ -0,0 +1,262 @@
+package prices
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// The services' public endpoints
+const (
+	yahooEndpoint     = "https://query1.finance.yahoo.com"
+	coingeckoEndpoint = "https://api.coingecko.com/api/v3"
+	ecbEndpoint       = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+)
+
+// maxResponse is the most of a response read, well above what a quote takes
+const maxResponse = 1 << 20
+
+// get requests a URL and returns the body of the response, with an error unless it
+// succeeded
+func get(ctx context.Context, client *http.Client, service, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Yahoo refuses requests without a user agent
+	req.Header.Set("User-Agent", "lima")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", service, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", service, err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return body, fmt.Errorf("%s is rate limiting requests: lower prices.requests_per_minute", service)
+	}
+	if resp.StatusCode != http.StatusOK {
+		snippet := body[:min(len(body), 512)]
+		return body, fmt.Errorf("%s request failed: %s: %s", service, resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	return body, nil
+}
+
+// Yahoo quotes securities and funds from Yahoo Finance, in the currency they trade in
+type Yahoo struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewYahoo creates the Yahoo Finance source
+func NewYahoo(endpoint string, client *http.Client) *Yahoo {
+	return &Yahoo{endpoint: strings.TrimRight(endpoint, "/"), client: client}
+}
+
+// yahooChart is the answer to a chart request
+type yahooChart struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				Currency             string      `json:"currency"`
+				RegularMarketPrice   json.Number `json:"regularMarketPrice"`
+				RegularMarketTime    int64       `json:"regularMarketTime"`
+				ExchangeTimezoneName string      `json:"exchangeTimezoneName"`
+			} `json:"meta"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// Quote returns a symbol's last market price; the currency asked for is ignored
+// Prices quoted in pence (GBp) are converted to pounds.
+func (y *Yahoo) Quote(ctx context.Context, symbol, _ string) (Quote, error) {
+	u := y.endpoint + "/v8/finance/chart/" + url.PathEscape(symbol) + "?range=5d&interval=1d"
+	body, err := get(ctx, y.client, "yahoo", u)
+	if err != nil {
+		// Unknown symbols answer 404 with the reason in the body
+		var chart yahooChart
+		if json.Unmarshal(body, &chart) == nil && chart.Chart.Error != nil {
+			return Quote{}, fmt.Errorf("yahoo: %s: %s", symbol, chart.Chart.Error.Description)
+		}
+		return Quote{}, err
+	}
+	var chart yahooChart
+	if err := json.Unmarshal(body, &chart); err != nil {
+		return Quote{}, fmt.Errorf("failed to decode yahoo response: %w", err)
+	}
+	if chart.Chart.Error != nil {
+		return Quote{}, fmt.Errorf("yahoo: %s: %s", symbol, chart.Chart.Error.Description)
+	}
+	if len(chart.Chart.Result) == 0 {
+		return Quote{}, fmt.Errorf("yahoo: no quote for %s", symbol)
+	}
+
+	meta := chart.Chart.Result[0].Meta
+	price, err := decimal.NewFromString(meta.RegularMarketPrice.String())
+	if err != nil || !price.IsPositive() {
+		return Quote{}, fmt.Errorf("yahoo: no price for %s", symbol)
+	}
+	currency := meta.Currency
+	if currency == "GBp" {
+		price, currency = price.Div(decimal.NewFromInt(100)), "GBP"
+	}
+	location, err := time.LoadLocation(meta.ExchangeTimezoneName)
+	if err != nil {
+		location = time.UTC
+	}
+	date := time.Unix(meta.RegularMarketTime, 0).In(location)
+	return Quote{
+		Date:     time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		Price:    price,
+		Currency: strings.ToUpper(currency),
+	}, nil
+}
+
+// CoinGecko quotes crypto currencies from CoinGecko by their CoinGecko ID, e.g. bitcoin
+type CoinGecko struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewCoinGecko creates the CoinGecko source
+func NewCoinGecko(endpoint string, client *http.Client) *CoinGecko {
+	return &CoinGecko{endpoint: strings.TrimRight(endpoint, "/"), client: client}
+}
+
+// Quote returns a coin's current price in currency
+func (c *CoinGecko) Quote(ctx context.Context, id, currency string) (Quote, error) {
+	vs := strings.ToLower(currency)
+	u := c.endpoint + "/simple/price?" + url.Values{
+		"ids":                     {id},
+		"vs_currencies":           {vs},
+		"include_last_updated_at": {"true"},
+	}.Encode()
+	body, err := get(ctx, c.client, "coingecko", u)
+	if err != nil {
+		return Quote{}, err
+	}
+	var answer map[string]map[string]json.Number
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&answer); err != nil {
+		return Quote{}, fmt.Errorf("failed to decode coingecko response: %w", err)
+	}
+	coin, ok := answer[id]
+	if !ok {
+		return Quote{}, fmt.Errorf("coingecko: unknown coin %q: use its CoinGecko ID, e.g. coingecko:bitcoin", id)
+	}
+	number, ok := coin[vs]
+	if !ok {
+		return Quote{}, fmt.Errorf("coingecko: no %s price for %s", currency, id)
+	}
+	price, err := decimal.NewFromString(number.String())
+	if err != nil || !price.IsPositive() {
+		return Quote{}, fmt.Errorf("coingecko: invalid price %q for %s", number, id)
+	}
+	date := time.Now().UTC()
+	if updated, err := coin["last_updated_at"].Int64(); err == nil && updated > 0 {
+		date = time.Unix(updated, 0).UTC()
+	}
+	return Quote{
+		Date:     time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		Price:    price,
+		Currency: currency,
+	}, nil
+}
+
+// ECB quotes currencies from the European Central Bank's daily reference rates
+// The rates are fetched once and shared by every currency quoted.
+type ECB struct {
+	endpoint string
+	client   *http.Client
+
+	mu    sync.Mutex
+	date  time.Time
+	rates map[string]decimal.Decimal // Units of each currency per euro
+}
+
+// NewECB creates the ECB source
+func NewECB(endpoint string, client *http.Client) *ECB {
+	return &ECB{endpoint: endpoint, client: client}
+}
+
+// ecbEnvelope is the daily reference rates document
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// Quote returns the value of one unit of a currency in another, crossing through
+// the euro when neither is
+func (e *ECB) Quote(ctx context.Context, symbol, currency string) (Quote, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.rates == nil {
+		if err := e.load(ctx); err != nil {
+			return Quote{}, err
+		}
+	}
+	from, ok := e.rates[symbol]
+	if !ok {
+		return Quote{}, fmt.Errorf("ecb: no reference rate for %s", symbol)
+	}
+	to, ok := e.rates[currency]
+	if !ok {
+		return Quote{}, fmt.Errorf("ecb: no reference rate for %s", currency)
+	}
+	return Quote{Date: e.date, Price: to.DivRound(from, 8), Currency: currency}, nil
+}
+
+// load fetches the latest reference rates
+func (e *ECB) load(ctx context.Context) error {
+	body, err := get(ctx, e.client, "ecb", e.endpoint)
+	if err != nil {
+		return err
+	}
+	var envelope ecbEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return fmt.Errorf("failed to decode ecb rates: %w", err)
+	}
+	if len(envelope.Cube.Days) == 0 {
+		return fmt.Errorf("ecb: no reference rates")
+	}
+	day := envelope.Cube.Days[0]
+	date, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return fmt.Errorf("ecb: invalid date %q", day.Time)
+	}
+	rates := map[string]decimal.Decimal{"EUR": decimal.NewFromInt(1)}
+	for _, rate := range day.Rates {
+		number, err := decimal.NewFromString(rate.Rate)
+		if err != nil || !number.IsPositive() {
+			return fmt.Errorf("ecb: invalid rate %q for %s", rate.Rate, rate.Currency)
+		}
+		rates[rate.Currency] = number
+	}
+	e.date, e.rates = date, rates
+	return nil
+}
@@ -0,0 +1,616 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// Values are nil, decimal.Decimal, string, time.Time, bool, beancount.Amount,
+// Inventory or []string (tags and links)
+
+// Inventory is a sum of amounts in several commodities, by commodity
+type Inventory map[string]decimal.Decimal
+
+// context is what an expression is evaluated against: one posting, or in a grouped
+// query one group of postings, of which row is the first
+type context struct {
+	row   *row
+	group []*row
+}
+
+// node is a parsed expression
+type node interface {
+	eval(ctx context) (any, error)
+	String() string
+}
+
+// literal is a constant
+type literal struct {
+	value any
+}
+
+func (l literal) eval(context) (any, error) { return l.value, nil }
+
+func (l literal) String() string {
+	if s, ok := l.value.(string); ok {
+		return "'" + s + "'"
+	}
+	return Format(l.value)
+}
+
+// column is a posting attribute
+type column struct {
+	name string
+}
+
+func (c column) eval(ctx context) (any, error) { return columns[c.name].value(ctx.row), nil }
+
+func (c column) String() string { return c.name }
+
+// list is a parenthesized list of values, for IN
+type list struct {
+	items []node
+}
+
+func (l list) eval(ctx context) (any, error) {
+	values := make([]any, len(l.items))
+	for i, item := range l.items {
+		value, err := item.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (l list) String() string {
+	items := make([]string, len(l.items))
+	for i, item := range l.items {
+		items[i] = item.String()
+	}
+	return "(" + strings.Join(items, ", ") + ")"
+}
+
+// unary is a negation: - of a number or amount, or NOT of a condition
+type unary struct {
+	op      string
+	operand node
+}
+
+func (u unary) eval(ctx context) (any, error) {
+	value, err := u.operand.eval(ctx)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	if u.op == "NOT" {
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("NOT needs a condition, not %s", typeName(value))
+		}
+		return !b, nil
+	}
+	return negate(value)
+}
+
+func (u unary) String() string {
+	if u.op == "NOT" {
+		return "NOT " + u.operand.String()
+	}
+	return u.op + u.operand.String()
+}
+
+// binary is an operator between two expressions
+type binary struct {
+	op          string
+	left, right node
+
+	// pattern is the compiled right side of ~ when it is a literal
+	pattern *regexp.Regexp
+}
+
+func (b binary) eval(ctx context) (any, error) {
+	left, err := b.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// AND and OR only look at the right side when they must
+	switch b.op {
+	case "AND", "OR":
+		// A missing value counts as false
+		l, ok := left.(bool)
+		if !ok && left != nil {
+			return nil, fmt.Errorf("%s needs conditions, not %s", b.op, typeName(left))
+		}
+		if b.op == "AND" && !l || b.op == "OR" && l {
+			return l, nil
+		}
+		right, err := b.right.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok && right != nil {
+			return nil, fmt.Errorf("%s needs conditions, not %s", b.op, typeName(right))
+		}
+		return r, nil
+	}
+
+	right, err := b.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch b.op {
+	case "IN":
+		return contains(right, left)
+	case "~":
+		if left == nil {
+			return false, nil
+		}
+		text, ok := left.(string)
+		if !ok {
+			return nil, fmt.Errorf("~ needs text on the left, not %s", typeName(left))
+		}
+		pattern := b.pattern
+		if pattern == nil {
+			p, ok := right.(string)
+			if !ok {
+				return nil, fmt.Errorf("~ needs a pattern on the right, not %s", typeName(right))
+			}
+			if pattern, err = compilePattern(p); err != nil {
+				return nil, err
+			}
+		}
+		return pattern.MatchString(text), nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		if left == nil || right == nil {
+			// Nothing compares with a missing value, except inequality
+			return b.op == "!=" && (left == nil) != (right == nil), nil
+		}
+		c, err := compare(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch b.op {
+		case "=":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+	return arithmetic(b.op, left, right)
+}
+
+func (b binary) String() string {
+	return b.left.String() + " " + b.op + " " + b.right.String()
+}
+
+// call is a function call; aggregates combine a group's postings
+type call struct {
+	name string
+	args []node
+	star bool // count(*)
+}
+
+func (c call) eval(ctx context) (any, error) {
+	if aggregate, ok := aggregates[c.name]; ok {
+		if ctx.group == nil {
+			return nil, fmt.Errorf("%s() needs a grouped query", c.name)
+		}
+		var values []any
+		for _, r := range ctx.group {
+			if c.star {
+				values = append(values, true)
+				continue
+			}
+			value, err := c.args[0].eval(context{row: r})
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				values = append(values, value)
+			}
+		}
+		return aggregate(values)
+	}
+
+	args := make([]any, len(c.args))
+	for i, arg := range c.args {
+		value, err := arg.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return nil, nil
+		}
+		args[i] = value
+	}
+	return functions[c.name].call(args)
+}
+
+func (c call) String() string {
+	if c.star {
+		return c.name + "(*)"
+	}
+	args := make([]string, len(c.args))
+	for i, arg := range c.args {
+		args[i] = arg.String()
+	}
+	return c.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// isAggregate reports whether an expression contains an aggregate function
+func isAggregate(n node) bool {
+	switch e := n.(type) {
+	case call:
+		if _, ok := aggregates[e.name]; ok {
+			return true
+		}
+		return slices.ContainsFunc(e.args, isAggregate)
+	case unary:
+		return isAggregate(e.operand)
+	case binary:
+		return isAggregate(e.left) || isAggregate(e.right)
+	case list:
+		return slices.ContainsFunc(e.items, isAggregate)
+	}
+	return false
+}
+
+// aggregates combine the non-missing values of a group
+var aggregates = map[string]func(values []any) (any, error){
+	"count": func(values []any) (any, error) {
+		return decimal.NewFromInt(int64(len(values))), nil
+	},
+	"sum": func(values []any) (any, error) {
+		var total any
+		for _, value := range values {
+			if total == nil {
+				total = value
+				if amount, ok := value.(beancount.Amount); ok {
+					total = Inventory{amount.Commodity: amount.Number}
+				}
+				continue
+			}
+			var err error
+			if total, err = add(total, value); err != nil {
+				return nil, fmt.Errorf("sum: %w", err)
+			}
+		}
+		return total, nil
+	},
+	"avg": func(values []any) (any, error) {
+		if len(values) == 0 {
+			return nil, nil
+		}
+		total := decimal.Zero
+		for _, value := range values {
+			n, ok := number(value)
+			if !ok {
+				return nil, fmt.Errorf("avg needs numbers, not %s", typeName(value))
+			}
+			total = total.Add(n)
+		}
+		return divide(total, decimal.NewFromInt(int64(len(values)))), nil
+	},
+	"min": func(values []any) (any, error) { return extreme(values, -1) },
+	"max": func(values []any) (any, error) { return extreme(values, 1) },
+	"first": func(values []any) (any, error) {
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return values[0], nil
+	},
+	"last": func(values []any) (any, error) {
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return values[len(values)-1], nil
+	},
+}
+
+// extreme returns the smallest value for sign -1 and the largest for 1
+func extreme(values []any, sign int) (any, error) {
+	var best any
+	for _, value := range values {
+		if best == nil {
+			best = value
+			continue
+		}
+		c, err := compare(value, best)
+		if err != nil {
+			return nil, err
+		}
+		if c*sign > 0 {
+			best = value
+		}
+	}
+	return best, nil
+}
+
+// function is a scalar function taking a fixed number of arguments
+type function struct {
+	args int
+	call func(args []any) (any, error)
+}
+
+// functions are the scalar functions; a missing argument makes the result missing
+var functions = map[string]function{
+	"year":     {1, datePart(func(d time.Time) int { return d.Year() })},
+	"month":    {1, datePart(func(d time.Time) int { return int(d.Month()) })},
+	"day":      {1, datePart(func(d time.Time) int { return d.Day() })},
+	"upper":    {1, text(strings.ToUpper)},
+	"lower":    {1, text(strings.ToLower)},
+	"parent":   {1, text(parentAccount)},
+	"leaf":     {1, text(func(account string) string { return account[strings.LastIndex(account, ":")+1:] })},
+	"number":   {1, amountPart(func(a beancount.Amount) any { return a.Number })},
+	"currency": {1, amountPart(func(a beancount.Amount) any { return a.Commodity })},
+	"abs": {1, func(args []any) (any, error) {
+		switch v := args[0].(type) {
+		case decimal.Decimal:
+			return v.Abs(), nil
+		case beancount.Amount:
+			return beancount.Amount{Number: v.Number.Abs(), Commodity: v.Commodity}, nil
+		}
+		return nil, fmt.Errorf("abs needs a number or amount, not %s", typeName(args[0]))
+	}},
+	"root": {2, func(args []any) (any, error) {
+		account, ok := args[0].(string)
+		n, isNumber := args[1].(decimal.Decimal)
+		if !ok || !isNumber {
+			return nil, fmt.Errorf("root needs an account and a number of components")
+		}
+		parts := strings.Split(account, ":")
+		return strings.Join(parts[:min(len(parts), max(1, int(n.IntPart())))], ":"), nil
+	}},
+}
+
+// datePart makes a function returning a part of a date
+func datePart(part func(time.Time) int) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		date, ok := args[0].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("expected a date, not %s", typeName(args[0]))
+		}
+		return decimal.NewFromInt(int64(part(date))), nil
+	}
+}
+
+// text makes a function of text
+func text(f func(string) string) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("expected text, not %s", typeName(args[0]))
+		}
+		return f(s), nil
+	}
+}
+
+// amountPart makes a function returning a part of an amount
+func amountPart(part func(beancount.Amount) any) func([]any) (any, error) {
+	return func(args []any) (any, error) {
+		amount, ok := args[0].(beancount.Amount)
+		if !ok {
+			return nil, fmt.Errorf("expected an amount, not %s", typeName(args[0]))
+		}
+		return part(amount), nil
+	}
+}
+
+// parentAccount returns the account above one, or "" for a root account
+func parentAccount(account string) string {
+	if i := strings.LastIndex(account, ":"); i >= 0 {
+		return account[:i]
+	}
+	return ""
+}
+
+// typeName names a value's type for error messages
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "nothing"
+	case decimal.Decimal:
+		return "a number"
+	case string:
+		return "text"
+	case time.Time:
+		return "a date"
+	case bool:
+		return "a condition"
+	case beancount.Amount:
+		return "an amount"
+	case Inventory:
+		return "a sum of amounts"
+	case []string, []any:
+		return "a list"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// number returns the number of a number or amount
+func number(value any) (decimal.Decimal, bool) {
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return v, true
+	case beancount.Amount:
+		return v.Number, true
+	}
+	return decimal.Zero, false
+}
+
+// compare orders two values of the same kind; text compares with dates as a date
+// and amounts compare with numbers by their number
+func compare(a, b any) (int, error) {
+	switch x := a.(type) {
+	case decimal.Decimal:
+		if y, ok := number(b); ok {
+			return x.Cmp(y), nil
+		}
+	case beancount.Amount:
+		if y, ok := b.(beancount.Amount); ok && x.Commodity != y.Commodity {
+			return strings.Compare(x.Commodity, y.Commodity), nil
+		}
+		if y, ok := number(b); ok {
+			return x.Number.Cmp(y), nil
+		}
+	case string:
+		switch y := b.(type) {
+		case string:
+			return strings.Compare(x, y), nil
+		case time.Time:
+			c, err := compare(y, x)
+			return -c, err
+		}
+	case time.Time:
+		switch y := b.(type) {
+		case time.Time:
+			return x.Compare(y), nil
+		case string:
+			date, err := time.Parse("2006-01-02", y)
+			if err != nil {
+				return 0, fmt.Errorf("can't compare a date with %q", y)
+			}
+			return x.Compare(date), nil
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0, nil
+			case !x:
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, fmt.Errorf("can't compare %s with %s", typeName(a), typeName(b))
+}
+
+// contains reports whether a list, or the tags or links, holds a value
+func contains(collection, value any) (any, error) {
+	switch c := collection.(type) {
+	case nil:
+		return false, nil
+	case []string:
+		s, ok := value.(string)
+		return ok && slices.Contains(c, s), nil
+	case []any:
+		for _, item := range c {
+			if item == nil || value == nil {
+				continue
+			}
+			if equal, err := compare(value, item); err == nil && equal == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return nil, fmt.Errorf("IN needs a list, not %s", typeName(collection))
+}
+
+// negate returns the negative of a number, amount or sum of amounts
+func negate(value any) (any, error) {
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return v.Neg(), nil
+	case beancount.Amount:
+		return beancount.Amount{Number: v.Number.Neg(), Commodity: v.Commodity}, nil
+	case Inventory:
+		negated := make(Inventory, len(v))
+		for commodity, n := range v {
+			negated[commodity] = n.Neg()
+		}
+		return negated, nil
+	}
+	return nil, fmt.Errorf("can't negate %s", typeName(value))
+}
+
+// add sums numbers, or amounts and sums of amounts into a sum of amounts
+func add(a, b any) (any, error) {
+	if x, ok := a.(decimal.Decimal); ok {
+		if y, ok := b.(decimal.Decimal); ok {
+			return x.Add(y), nil
+		}
+	}
+	total := Inventory{}
+	for _, value := range []any{a, b} {
+		switch v := value.(type) {
+		case beancount.Amount:
+			total[v.Commodity] = total[v.Commodity].Add(v.Number)
+		case Inventory:
+			for commodity, n := range v {
+				total[commodity] = total[commodity].Add(n)
+			}
+		default:
+			return nil, fmt.Errorf("can't add %s and %s", typeName(a), typeName(b))
+		}
+	}
+	return total, nil
+}
+
+// arithmetic applies +, -, * or / to numbers; amounts can be added and subtracted,
+// and multiplied or divided by a number
+func arithmetic(op string, left, right any) (any, error) {
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	switch op {
+	case "+":
+		return add(left, right)
+	case "-":
+		negated, err := negate(right)
+		if err != nil {
+			return nil, fmt.Errorf("can't subtract %s from %s", typeName(right), typeName(left))
+		}
+		return add(left, negated)
+	}
+
+	factor, ok := right.(decimal.Decimal)
+	if !ok {
+		return nil, fmt.Errorf("can't %s by %s", map[string]string{"*": "multiply", "/": "divide"}[op], typeName(right))
+	}
+	if op == "/" && factor.IsZero() {
+		return nil, fmt.Errorf("division by zero")
+	}
+	scale := func(n decimal.Decimal) decimal.Decimal {
+		if op == "*" {
+			return n.Mul(factor)
+		}
+		return divide(n, factor)
+	}
+	switch v := left.(type) {
+	case decimal.Decimal:
+		return scale(v), nil
+	case beancount.Amount:
+		return beancount.Amount{Number: scale(v.Number), Commodity: v.Commodity}, nil
+	case Inventory:
+		scaled := make(Inventory, len(v))
+		for commodity, n := range v {
+			scaled[commodity] = scale(n)
+		}
+		return scaled, nil
+	}
+	return nil, fmt.Errorf("can't %s %s", map[string]string{"*": "multiply", "/": "divide"}[op], typeName(left))
+}
+
+// divide divides without the trailing zeros of the division's 16 decimal places,
+// which Format would otherwise show
+func divide(n, d decimal.Decimal) decimal.Decimal {
+	return decimal.RequireFromString(n.Div(d).String())
+}
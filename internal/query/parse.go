@@ -0,0 +1,607 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// tokenKind classifies a token of a query
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenDate
+	tokenSymbol
+)
+
+// token is a word, literal or symbol of a query, at a byte offset
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// describe names a token for error messages
+func (t token) describe() string {
+	if t.kind == tokenEnd {
+		return "end of query"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// dateRegex matches a date literal at the start of the remaining text
+var dateRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+
+// symbols are the operators and punctuation, longest first
+var symbols = []string{"!=", "<>", "<=", ">=", "=", "<", ">", "~", "+", "-", "*", "/", "(", ")", ","}
+
+// keywords cannot name columns; they end the expression before them
+var keywords = map[string]bool{
+	"select": true, "distinct": true, "from": true, "where": true, "group": true, "by": true,
+	"order": true, "asc": true, "desc": true, "limit": true, "and": true, "or": true,
+	"not": true, "as": true, "in": true,
+}
+
+// tokenize splits a query into tokens, ending with a tokenEnd
+func tokenize(text string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(text); {
+		r := rune(text[pos])
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+
+		case dateRegex.MatchString(text[pos:]):
+			tokens = append(tokens, token{tokenDate, text[pos : pos+10], pos})
+			pos += 10
+
+		case unicode.IsDigit(r) || r == '.' && pos+1 < len(text) && unicode.IsDigit(rune(text[pos+1])):
+			end := pos
+			for end < len(text) && (unicode.IsDigit(rune(text[end])) || text[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{tokenNumber, text[pos:end], pos})
+			pos = end
+
+		case r == '\'' || r == '"':
+			end := strings.IndexByte(text[pos+1:], text[pos])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", pos+1)
+			}
+			tokens = append(tokens, token{tokenString, text[pos+1 : pos+1+end], pos})
+			pos += end + 2
+
+		case unicode.IsLetter(r) || r == '_':
+			end := pos
+			for end < len(text) && (unicode.IsLetter(rune(text[end])) || unicode.IsDigit(rune(text[end])) || text[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, token{tokenIdent, text[pos:end], pos})
+			pos = end
+
+		default:
+			matched := false
+			for _, symbol := range symbols {
+				if strings.HasPrefix(text[pos:], symbol) {
+					tokens = append(tokens, token{tokenSymbol, symbol, pos})
+					pos += len(symbol)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q at position %d", r, pos+1)
+			}
+		}
+	}
+	return append(tokens, token{tokenEnd, "", len(text)}), nil
+}
+
+// parser reads a query from its tokens
+type parser struct {
+	tokens []token
+	pos    int
+
+	// aliases are the targets named with AS, which GROUP BY and ORDER BY can use
+	aliases map[string]node
+}
+
+// peek returns the next token without consuming it
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the next token
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the keyword, in any case
+func (p *parser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol consumes the next token if it is the symbol
+func (p *parser) symbol(s string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == s {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// errorf reports a problem at the next token
+func (p *parser) errorf(format string, args ...any) error {
+	t := p.peek()
+	return fmt.Errorf("%s at position %d, found %s", fmt.Sprintf(format, args...), t.pos+1, t.describe())
+}
+
+// Parse reads a query:
+//
+//	SELECT [DISTINCT] targets [FROM postings] [WHERE condition]
+//	  [GROUP BY expressions] [ORDER BY expressions [ASC|DESC]] [LIMIT n]
+//
+// Targets are expressions, optionally named with AS, or * for the usual columns.
+// GROUP BY and ORDER BY also take a target's name or 1-based position.
+func Parse(text string) (*Query, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	q := &Query{limit: -1}
+
+	if !p.keyword("select") {
+		return nil, p.errorf("expected SELECT")
+	}
+	q.distinct = p.keyword("distinct")
+	aliases := make(map[string]node)
+	if p.symbol("*") {
+		for _, name := range starColumns {
+			q.targets = append(q.targets, target{expr: column{name}, name: name})
+		}
+	} else {
+		for {
+			expr, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			t := target{expr: expr, name: expr.String()}
+			if p.keyword("as") {
+				name := p.next()
+				if name.kind != tokenIdent && name.kind != tokenString {
+					p.pos--
+					return nil, p.errorf("expected a name after AS")
+				}
+				t.name = name.text
+				aliases[strings.ToLower(name.text)] = expr
+			}
+			q.targets = append(q.targets, t)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("from") {
+		table := p.next()
+		if table.kind != tokenIdent || !strings.EqualFold(table.text, "postings") {
+			return nil, fmt.Errorf("unknown table %s: only postings can be queried", table.describe())
+		}
+	}
+	if p.keyword("where") {
+		if q.where, err = p.expr(); err != nil {
+			return nil, err
+		}
+		if isAggregate(q.where) {
+			return nil, fmt.Errorf("aggregate functions can't be used in WHERE")
+		}
+	}
+	// Names given with AS stand for their targets after WHERE
+	p.aliases = aliases
+	if p.keyword("group") {
+		if !p.keyword("by") {
+			return nil, p.errorf("expected BY")
+		}
+		for {
+			expr, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			resolved, err := q.resolve("GROUP BY", expr)
+			if err != nil {
+				return nil, err
+			}
+			q.groupBy = append(q.groupBy, resolved)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+	if p.keyword("order") {
+		if !p.keyword("by") {
+			return nil, p.errorf("expected BY")
+		}
+		for {
+			expr, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			resolved, err := q.resolve("ORDER BY", expr)
+			if err != nil {
+				return nil, err
+			}
+			o := ordering{expr: resolved}
+			if p.keyword("desc") {
+				o.descending = true
+			} else {
+				p.keyword("asc")
+			}
+			q.orderBy = append(q.orderBy, o)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+	if p.keyword("limit") {
+		t := p.next()
+		n, err := strconv.Atoi(t.text)
+		if t.kind != tokenNumber || err != nil {
+			p.pos--
+			return nil, p.errorf("expected a row count after LIMIT")
+		}
+		q.limit = n
+	}
+	if p.peek().kind != tokenEnd {
+		return nil, p.errorf("unexpected text")
+	}
+	if err := q.check(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// resolve turns a GROUP BY or ORDER BY item naming a target, by its name or 1-based
+// position, into that target's expression
+// A position past the targets is an error rather than a constant to group or order by.
+func (q *Query) resolve(clause string, expr node) (node, error) {
+	switch e := expr.(type) {
+	case literal:
+		if n, ok := e.value.(decimal.Decimal); ok && n.IsInteger() {
+			i := n.IntPart()
+			if i < 1 || i > int64(len(q.targets)) {
+				return nil, fmt.Errorf("%s %d names no target: there are %d", clause, i, len(q.targets))
+			}
+			return q.targets[i-1].expr, nil
+		}
+	case column:
+		for _, t := range q.targets {
+			if strings.EqualFold(t.name, e.name) {
+				return t.expr, nil
+			}
+		}
+	}
+	return expr, nil
+}
+
+// expr parses an expression: OR has the lowest precedence, then AND, NOT,
+// comparisons, addition and multiplication
+func (p *parser) expr() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+// and parses conditions joined by AND
+func (p *parser) and() (node, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+// not parses a negated condition
+func (p *parser) not() (node, error) {
+	if p.keyword("not") {
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return unary{op: "NOT", operand: operand}, nil
+	}
+	return p.comparison()
+}
+
+// comparison parses a comparison, a regular expression match or an IN test
+func (p *parser) comparison() (node, error) {
+	left, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	negated := p.keyword("not")
+	if p.keyword("in") {
+		// A parenthesized list after IN is a list even with one item
+		var right node
+		var err error
+		if p.symbol("(") {
+			right, err = p.list()
+		} else {
+			right, err = p.sum()
+		}
+		if err != nil {
+			return nil, err
+		}
+		var result node = binary{op: "IN", left: left, right: right}
+		if negated {
+			result = unary{op: "NOT", operand: result}
+		}
+		return result, nil
+	}
+	if negated {
+		return nil, p.errorf("expected IN after NOT")
+	}
+
+	t := p.peek()
+	if t.kind != tokenSymbol {
+		return left, nil
+	}
+	switch t.text {
+	case "=", "!=", "<>", "<", "<=", ">", ">=", "~":
+		p.next()
+		right, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		op := t.text
+		if op == "<>" {
+			op = "!="
+		}
+		b := binary{op: op, left: left, right: right}
+		if op == "~" {
+			if pattern, ok := right.(literal); ok {
+				if text, ok := pattern.value.(string); ok {
+					if b.pattern, err = compilePattern(text); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		return b, nil
+	}
+	return left, nil
+}
+
+// sum parses terms joined by + and -
+func (p *parser) sum() (node, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.symbol("+"):
+			right, err := p.product()
+			if err != nil {
+				return nil, err
+			}
+			left = binary{op: "+", left: left, right: right}
+		case p.symbol("-"):
+			right, err := p.product()
+			if err != nil {
+				return nil, err
+			}
+			left = binary{op: "-", left: left, right: right}
+		default:
+			return left, nil
+		}
+	}
+}
+
+// product parses factors joined by * and /
+func (p *parser) product() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.symbol("*"):
+			right, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			left = binary{op: "*", left: left, right: right}
+		case p.symbol("/"):
+			right, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			left = binary{op: "/", left: left, right: right}
+		default:
+			return left, nil
+		}
+	}
+}
+
+// unary parses a negated factor
+func (p *parser) unary() (node, error) {
+	if p.symbol("-") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{op: "-", operand: operand}, nil
+	}
+	return p.primary()
+}
+
+// primary parses a literal, a column, a function call, a parenthesized expression
+// or a parenthesized list for IN
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		n, err := decimal.NewFromString(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos+1)
+		}
+		return literal{n}, nil
+
+	case tokenString:
+		return literal{t.text}, nil
+
+	case tokenDate:
+		date, err := time.Parse("2006-01-02", t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %s at position %d", t.text, t.pos+1)
+		}
+		return literal{date}, nil
+
+	case tokenIdent:
+		name := strings.ToLower(t.text)
+		switch name {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		}
+		if keywords[name] {
+			p.pos--
+			return nil, p.errorf("expected an expression")
+		}
+		if !p.symbol("(") {
+			if expr, ok := p.aliases[name]; ok {
+				return expr, nil
+			}
+			if _, ok := columns[name]; !ok {
+				return nil, fmt.Errorf("unknown column %q at position %d (have %s)", t.text, t.pos+1, strings.Join(ColumnNames(), ", "))
+			}
+			return column{name}, nil
+		}
+		return p.call(name, t)
+
+	case tokenSymbol:
+		if t.text == "(" {
+			expr, err := p.list()
+			if err != nil {
+				return nil, err
+			}
+			if l, ok := expr.(list); ok && len(l.items) == 1 {
+				return l.items[0], nil
+			}
+			return expr, nil
+		}
+	}
+	p.pos--
+	return nil, p.errorf("expected an expression")
+}
+
+// call parses the arguments of a function call whose name and ( were read
+func (p *parser) call(name string, at token) (node, error) {
+	c := call{name: name}
+	_, aggregate := aggregates[name]
+	_, scalar := functions[name]
+	if !aggregate && !scalar {
+		return nil, fmt.Errorf("unknown function %q at position %d", at.text, at.pos+1)
+	}
+	if name == "count" && p.symbol("*") {
+		c.star = true
+	} else if !p.symbol(")") {
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			c.args = append(c.args, arg)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	} else {
+		return nil, fmt.Errorf("%s() needs an argument", name)
+	}
+	if c.star || len(c.args) > 0 {
+		if !p.symbol(")") {
+			return nil, p.errorf("expected )")
+		}
+	}
+	if aggregate && !c.star && len(c.args) != 1 {
+		return nil, fmt.Errorf("%s() takes one argument", name)
+	}
+	if aggregate {
+		for _, arg := range c.args {
+			if isAggregate(arg) {
+				return nil, fmt.Errorf("aggregate functions can't be nested in %s()", name)
+			}
+		}
+	}
+	if scalar && len(c.args) != functions[name].args {
+		return nil, fmt.Errorf("%s() takes %d argument(s)", name, functions[name].args)
+	}
+	return c, nil
+}
+
+// compilePattern compiles the right side of ~, which matches case-insensitively
+// anywhere in the text
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// list parses the items of a parenthesized list after its (
+func (p *parser) list() (node, error) {
+	var items []node
+	for {
+		item, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if !p.symbol(",") {
+			break
+		}
+	}
+	if !p.symbol(")") {
+		return nil, p.errorf("expected )")
+	}
+	return list{items}, nil
+}
@@ -0,0 +1,391 @@
+// Package query runs SQL-like queries over the postings of a ledger, in the spirit of
+// beancount's bean-query:
+//
+//	SELECT account, sum(amount) WHERE year = 2024 GROUP BY account ORDER BY 2 DESC
+//
+// Each row is one posting, with the elided amount of a transaction filled in. A query
+// with aggregate functions groups its rows by GROUP BY, or when that is left out by
+// the targets that aren't aggregates.
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// Query is a parsed query, ready to run
+type Query struct {
+	targets  []target
+	distinct bool
+	where    node
+	groupBy  []node
+	orderBy  []ordering
+	limit    int // -1 for no limit
+}
+
+// target is a selected expression and its column name
+type target struct {
+	expr node
+	name string
+}
+
+// ordering is an ORDER BY item
+type ordering struct {
+	expr       node
+	descending bool
+}
+
+// row is one posting of a transaction
+type row struct {
+	tx      *beancount.Transaction
+	posting beancount.Posting
+}
+
+// columnDef describes a column of the postings table
+type columnDef struct {
+	description string
+	value       func(r *row) any
+}
+
+// columns are the attributes of a posting a query can use
+var columns = map[string]columnDef{
+	"date":      {"transaction date", func(r *row) any { return r.tx.Date }},
+	"year":      {"year of the date", func(r *row) any { return decimal.NewFromInt(int64(r.tx.Date.Year())) }},
+	"month":     {"month of the date, 1-12", func(r *row) any { return decimal.NewFromInt(int64(r.tx.Date.Month())) }},
+	"day":       {"day of the month", func(r *row) any { return decimal.NewFromInt(int64(r.tx.Date.Day())) }},
+	"flag":      {"transaction flag, * or !", func(r *row) any { return r.tx.Flag }},
+	"payee":     {"transaction payee", func(r *row) any { return r.tx.Payee }},
+	"narration": {"transaction narration", func(r *row) any { return r.tx.Narration }},
+	"description": {"payee and narration", func(r *row) any {
+		if r.tx.Payee == "" {
+			return r.tx.Narration
+		}
+		return r.tx.Payee + " | " + r.tx.Narration
+	}},
+	"tags":    {"transaction tags, for IN", func(r *row) any { return r.tx.Tags }},
+	"links":   {"transaction links, for IN", func(r *row) any { return r.tx.Links }},
+	"account": {"posting account", func(r *row) any { return r.posting.Account }},
+	"number": {"posting number", func(r *row) any {
+		if r.posting.Amount == nil {
+			return nil
+		}
+		return r.posting.Amount.Number
+	}},
+	"currency": {"posting commodity", func(r *row) any {
+		if r.posting.Amount == nil {
+			return nil
+		}
+		return r.posting.Amount.Commodity
+	}},
+	"amount": {"posting amount", func(r *row) any {
+		if r.posting.Amount == nil {
+			return nil
+		}
+		return *r.posting.Amount
+	}},
+	"weight": {"amount at cost or price, as it balances the transaction", func(r *row) any {
+		if weight := r.posting.Weight(); weight != nil {
+			return *weight
+		}
+		return nil
+	}},
+	"filename": {"file of the transaction", func(r *row) any { return r.tx.FilePath }},
+	"lineno":   {"line of the posting", func(r *row) any { return decimal.NewFromInt(int64(r.posting.LineNumber)) }},
+}
+
+// starColumns are the columns SELECT * shows
+var starColumns = []string{"date", "flag", "payee", "narration", "account", "amount"}
+
+// ColumnNames returns the names of the columns, sorted
+func ColumnNames() []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// check reports targets that a grouped query can't compute from its groups
+func (q *Query) check() error {
+	if !q.grouped() {
+		return nil
+	}
+	if len(q.groupBy) > 0 {
+		return nil
+	}
+	// Without GROUP BY the targets that aren't aggregates are the groups
+	for _, t := range q.targets {
+		if !isAggregate(t.expr) {
+			q.groupBy = append(q.groupBy, t.expr)
+		}
+	}
+	return nil
+}
+
+// grouped reports whether the query combines postings into groups
+func (q *Query) grouped() bool {
+	if len(q.groupBy) > 0 {
+		return true
+	}
+	for _, t := range q.targets {
+		if isAggregate(t.expr) {
+			return true
+		}
+	}
+	for _, o := range q.orderBy {
+		if isAggregate(o.expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Result is the output of a query: named columns and rows of values
+type Result struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Strings returns the rows with each value formatted as text
+func (r *Result) Strings() [][]string {
+	rows := make([][]string, len(r.Rows))
+	for i, values := range r.Rows {
+		rows[i] = make([]string, len(values))
+		for j, value := range values {
+			rows[i][j] = Format(value)
+		}
+	}
+	return rows
+}
+
+// Format renders a value as text: dates as YYYY-MM-DD, amounts with their commodity
+// and sums of amounts one commodity after another
+func Format(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case decimal.Decimal:
+		return formatNumber(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format("2006-01-02")
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case beancount.Amount:
+		return formatNumber(v.Number) + " " + v.Commodity
+	case Inventory:
+		commodities := make([]string, 0, len(v))
+		for commodity, n := range v {
+			if !n.IsZero() {
+				commodities = append(commodities, commodity)
+			}
+		}
+		sort.Strings(commodities)
+		parts := make([]string, len(commodities))
+		for i, commodity := range commodities {
+			parts[i] = formatNumber(v[commodity]) + " " + commodity
+		}
+		return strings.Join(parts, ", ")
+	case []string:
+		return strings.Join(v, ",")
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = Format(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// formatNumber renders a number with the decimal places it was written with, so
+// 1000.00 stays 1000.00
+func formatNumber(n decimal.Decimal) string {
+	return n.StringFixed(max(0, -n.Exponent()))
+}
+
+// Run parses a query and runs it over a ledger's transactions, oldest first
+func Run(file *beancount.File, text string) (*Result, error) {
+	q, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	all, err := file.AllTransactions()
+	if err != nil {
+		return nil, err
+	}
+	var transactions []*beancount.Transaction
+	for _, i := range file.TransactionsFrom(time.Time{}) {
+		transactions = append(transactions, all[i])
+	}
+	return q.Run(transactions)
+}
+
+// Transactions returns the transactions with a posting the query's WHERE matches, in
+// their order, as bean-query's PRINT does; the targets and grouping play no part
+func (q *Query) Transactions(transactions []*beancount.Transaction) ([]*beancount.Transaction, error) {
+	var matched []*beancount.Transaction
+	for _, tx := range transactions {
+		for _, posting := range tx.ResolvedPostings() {
+			keep := any(true)
+			if q.where != nil {
+				var err error
+				if keep, err = q.where.eval(context{row: &row{tx: tx, posting: posting}}); err != nil {
+					return nil, err
+				}
+			}
+			if keep == true {
+				matched = append(matched, tx)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// Run runs the query over transactions, whose order is the order of the rows unless
+// the query sorts them
+func (q *Query) Run(transactions []*beancount.Transaction) (*Result, error) {
+	var rows []*row
+	for _, tx := range transactions {
+		for _, posting := range tx.ResolvedPostings() {
+			r := &row{tx: tx, posting: posting}
+			if q.where != nil {
+				keep, err := q.where.eval(context{row: r})
+				if err != nil {
+					return nil, err
+				}
+				if keep != true {
+					continue
+				}
+			}
+			rows = append(rows, r)
+		}
+	}
+
+	// Each output row is evaluated in a context: a posting, or a group of them
+	var contexts []context
+	if q.grouped() {
+		groups := make(map[string]int)
+		for _, r := range rows {
+			key := make([]string, len(q.groupBy))
+			for i, expr := range q.groupBy {
+				value, err := expr.eval(context{row: r})
+				if err != nil {
+					return nil, err
+				}
+				key[i] = fmt.Sprintf("%T:%s", value, Format(value))
+			}
+			k := strings.Join(key, "\x00")
+			if i, ok := groups[k]; ok {
+				contexts[i].group = append(contexts[i].group, r)
+				continue
+			}
+			groups[k] = len(contexts)
+			contexts = append(contexts, context{row: r, group: []*row{r}})
+		}
+		if len(contexts) == 0 && len(q.groupBy) == 0 {
+			// Aggregates over no postings still give one row, e.g. a count of 0
+			contexts = append(contexts, context{row: &row{tx: &beancount.Transaction{}}, group: []*row{}})
+		}
+	} else {
+		for _, r := range rows {
+			contexts = append(contexts, context{row: r})
+		}
+	}
+
+	type output struct {
+		values []any
+		keys   []any
+	}
+	outputs := make([]output, 0, len(contexts))
+	for _, ctx := range contexts {
+		o := output{values: make([]any, len(q.targets)), keys: make([]any, len(q.orderBy))}
+		for i, t := range q.targets {
+			value, err := t.expr.eval(ctx)
+			if err != nil {
+				return nil, err
+			}
+			o.values[i] = value
+		}
+		for i, order := range q.orderBy {
+			value, err := order.expr.eval(ctx)
+			if err != nil {
+				return nil, err
+			}
+			o.keys[i] = value
+		}
+		outputs = append(outputs, o)
+	}
+
+	var sortErr error
+	sort.SliceStable(outputs, func(a, b int) bool {
+		for i, order := range q.orderBy {
+			c, err := compareForOrder(outputs[a].keys[i], outputs[b].keys[i])
+			if err != nil && sortErr == nil {
+				sortErr = fmt.Errorf("ORDER BY %s: %w", order.expr, err)
+			}
+			if c != 0 {
+				return c < 0 != order.descending
+			}
+		}
+		return false
+	})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	result := &Result{}
+	for _, t := range q.targets {
+		result.Columns = append(result.Columns, t.name)
+	}
+	seen := make(map[string]bool)
+	for _, o := range outputs {
+		if q.limit >= 0 && len(result.Rows) == q.limit {
+			break
+		}
+		if q.distinct {
+			key := fmt.Sprint(o.values...)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		result.Rows = append(result.Rows, o.values)
+	}
+	return result, nil
+}
+
+// compareForOrder orders values for ORDER BY: missing values come first, sums of
+// amounts compare by their only commodity
+func compareForOrder(a, b any) (int, error) {
+	switch {
+	case a == nil && b == nil:
+		return 0, nil
+	case a == nil:
+		return -1, nil
+	case b == nil:
+		return 1, nil
+	}
+	return compare(single(a), single(b))
+}
+
+// single turns a sum of amounts in one commodity into that amount
+func single(value any) any {
+	if inventory, ok := value.(Inventory); ok && len(inventory) == 1 {
+		for commodity, n := range inventory {
+			return beancount.Amount{Number: n, Commodity: commodity}
+		}
+	}
+	return value
+}
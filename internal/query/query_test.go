@@ -0,0 +1,193 @@
+package query
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+const testLedger = `2023-12-20 * "Grocer" "Groceries" #food
+  Expenses:Food:Groceries   30.00 USD
+  Assets:Checking
+
+2024-01-05 * "Grocer" "Groceries" #food
+  Expenses:Food:Groceries   45.50 USD
+  Assets:Checking
+
+2024-01-10 * "Cafe" "Lunch" #food #work
+  Expenses:Food:DiningOut   12.25 USD
+  Assets:Checking          -12.25 USD
+
+2024-02-01 * "Landlord" "Rent"
+  Expenses:Housing:Rent   1200.00 USD
+  Assets:Checking
+
+2024-02-03 ! "Airline" "Flight"
+  Expenses:Travel   300.00 EUR
+  Assets:Checking
+`
+
+func openLedger(t *testing.T) *beancount.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.beancount")
+	if err := os.WriteFile(path, []byte(testLedger), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := beancount.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestRun(t *testing.T) {
+	f := openLedger(t)
+	tests := []struct {
+		name    string
+		query   string
+		columns []string
+		rows    []string // Cells of each row joined with |
+	}{
+		{
+			name:    "star",
+			query:   "SELECT * WHERE account = 'Expenses:Travel'",
+			columns: []string{"date", "flag", "payee", "narration", "account", "amount"},
+			rows:    []string{"2024-02-03|!|Airline|Flight|Expenses:Travel|300.00 EUR"},
+		},
+		{
+			// Amounts in different commodities order by commodity first
+			name:    "group with sum and order by alias",
+			query:   "SELECT account, sum(amount) AS total, count(*) FROM postings WHERE year = 2024 AND account ~ '^expenses' GROUP BY account ORDER BY total DESC",
+			columns: []string{"account", "total", "count(*)"},
+			rows: []string{
+				"Expenses:Housing:Rent|1200.00 USD|1",
+				"Expenses:Food:Groceries|45.50 USD|1",
+				"Expenses:Food:DiningOut|12.25 USD|1",
+				"Expenses:Travel|300.00 EUR|1",
+			},
+		},
+		{
+			name:    "implicit groups and elided amounts",
+			query:   "SELECT currency, sum(number) WHERE account = 'Assets:Checking' ORDER BY 1",
+			columns: []string{"currency", "sum(number)"},
+			rows:    []string{"EUR|-300.00", "USD|-1287.75"},
+		},
+		{
+			name:    "tags",
+			query:   "SELECT date, payee WHERE 'work' IN tags AND number > 0",
+			columns: []string{"date", "payee"},
+			rows:    []string{"2024-01-10|Cafe"},
+		},
+		{
+			name:    "distinct and limit",
+			query:   "SELECT DISTINCT year, month ORDER BY year DESC, month DESC LIMIT 2",
+			columns: []string{"year", "month"},
+			rows:    []string{"2024|2", "2024|1"},
+		},
+		{
+			name:    "functions and arithmetic",
+			query:   "SELECT parent(account), number * 2 AS double WHERE date >= 2024-02-01 AND NOT currency IN ('EUR') AND number > 0",
+			columns: []string{"parent(account)", "double"},
+			rows:    []string{"Expenses:Housing|2400.00"},
+		},
+		{
+			name:    "aggregate over nothing",
+			query:   "SELECT count(*) WHERE payee = 'Nobody'",
+			columns: []string{"count(*)"},
+			rows:    []string{"0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Run(f, tt.query)
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if strings.Join(result.Columns, ",") != strings.Join(tt.columns, ",") {
+				t.Errorf("expected columns %v, got %v", tt.columns, result.Columns)
+			}
+			var rows []string
+			for _, row := range result.Strings() {
+				rows = append(rows, strings.Join(row, "|"))
+			}
+			if strings.Join(rows, "\n") != strings.Join(tt.rows, "\n") {
+				t.Errorf("unexpected rows:\n%s\nwant:\n%s", strings.Join(rows, "\n"), strings.Join(tt.rows, "\n"))
+			}
+		})
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	transactions, err := openLedger(t).AllTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"SELECT * WHERE 'food' IN tags AND year = 2024": "Groceries,Lunch",
+		"SELECT account, sum(amount) GROUP BY account":  "Groceries,Groceries,Lunch,Rent,Flight",
+		// The elided posting of each transaction matches too
+		"SELECT date WHERE account = 'Assets:Checking' AND number < -100": "Rent,Flight",
+	}
+	for text, want := range tests {
+		q, err := Parse(text)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		matched, err := q.Transactions(transactions)
+		if err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		var got []string
+		for _, tx := range matched {
+			got = append(got, tx.Narration)
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("%s: expected %s, got %s", text, want, strings.Join(got, ","))
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{"account", "expected SELECT"},
+		{"SELECT nope", `unknown column "nope"`},
+		{"SELECT * FROM transactions", "only postings"},
+		{"SELECT account WHERE sum(number) > 0", "can't be used in WHERE"},
+		{"SELECT account LIMIT many", "expected a row count"},
+		{"SELECT account WHERE payee ~ '('", "invalid regular expression"},
+		{"SELECT 'unterminated", "unterminated"},
+		{"SELECT account, count(*) GROUP BY 5", "GROUP BY 5 names no target: there are 2"},
+		{"SELECT account ORDER BY 0", "ORDER BY 0 names no target: there are 1"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Parse(%q): expected an error containing %q, got %v", tt.query, tt.err, err)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	f := openLedger(t)
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{"SELECT 1/0", "division by zero"},
+		{"SELECT account, sum(amount) / 0 GROUP BY account", "division by zero"},
+		{"SELECT account WHERE number / (number - number) > 1", "division by zero"},
+	}
+	for _, tt := range tests {
+		_, err := Run(f, tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Run(%q): expected an error containing %q, got %v", tt.query, tt.err, err)
+		}
+	}
+}
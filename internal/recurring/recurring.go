@@ -0,0 +1,340 @@
+// Package recurring detects regular charges, such as bills and subscriptions, in a ledger
+//
+// Transactions are grouped by payee; a group is recurring when it has at least
+// MinOccurrences expense charges of similar amount at a regular interval.
+package recurring
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// MinOccurrences is how many charges a payee needs before it counts as recurring
+	MinOccurrences = 3
+
+	// amountTolerance is how far a charge may stray from the typical amount, as a fraction
+	amountTolerance = 0.25
+
+	// regularShare is the share of intervals that must match the cadence
+	regularShare = 0.75
+)
+
+// Cadence is how often a recurring charge happens
+type Cadence string
+
+const (
+	Weekly    Cadence = "weekly"
+	Biweekly  Cadence = "biweekly"
+	Monthly   Cadence = "monthly"
+	Quarterly Cadence = "quarterly"
+	Yearly    Cadence = "yearly"
+)
+
+// cadences lists each cadence's typical interval and tolerance in days
+var cadences = []struct {
+	cadence   Cadence
+	days      int
+	tolerance int
+}{
+	{Weekly, 7, 1},
+	{Biweekly, 14, 2},
+	{Monthly, 30, 4},
+	{Quarterly, 91, 8},
+	{Yearly, 365, 12},
+}
+
+// Next returns the date a charge made on date is next expected
+func (c Cadence) Next(date time.Time) time.Time {
+	switch c {
+	case Weekly:
+		return date.AddDate(0, 0, 7)
+	case Biweekly:
+		return date.AddDate(0, 0, 14)
+	case Monthly:
+		return date.AddDate(0, 1, 0)
+	case Quarterly:
+		return date.AddDate(0, 3, 0)
+	}
+	return date.AddDate(1, 0, 0)
+}
+
+// perYear returns how many charges of the cadence fall in a year
+func (c Cadence) perYear() int64 {
+	switch c {
+	case Weekly:
+		return 52
+	case Biweekly:
+		return 26
+	case Monthly:
+		return 12
+	case Quarterly:
+		return 4
+	}
+	return 1
+}
+
+// grace returns how many days a charge may be late before it counts as missing
+func (c Cadence) grace() int {
+	switch c {
+	case Weekly:
+		return 2
+	case Biweekly:
+		return 3
+	case Monthly:
+		return 5
+	case Quarterly:
+		return 10
+	}
+	return 14
+}
+
+// Series is a payee's recurring charge
+type Series struct {
+	Payee     string
+	Account   string // Expense account charged most recently
+	Source    string // Account the most recent charge was paid from, "" when unclear
+	Cadence   Cadence
+	Amount    decimal.Decimal // Typical (median) charge
+	Commodity string
+	Count     int
+	Last      time.Time
+	Next      time.Time // Expected date of the next charge
+
+	LastAmount decimal.Decimal // Most recent charge
+	Usual      decimal.Decimal // Typical charge before the most recent one
+}
+
+// Ended reports whether the series has missed two charges in a row by today, and so
+// is taken to have ended
+func (s Series) Ended(today time.Time) bool {
+	return today.After(s.Cadence.Next(s.Next).AddDate(0, 0, s.Cadence.grace()))
+}
+
+// PriceChange returns how much the most recent charge differs from the usual one
+func (s Series) PriceChange() decimal.Decimal {
+	return s.LastAmount.Sub(s.Usual)
+}
+
+// Annual returns the yearly cost at the most recent price
+func (s Series) Annual() decimal.Decimal {
+	return s.LastAmount.Mul(decimal.NewFromInt(s.Cadence.perYear()))
+}
+
+// Monthly returns the average monthly cost at the most recent price
+func (s Series) Monthly() decimal.Decimal {
+	return s.Annual().Div(decimal.NewFromInt(12)).Round(2)
+}
+
+// charge is one transaction's expense total
+type charge struct {
+	date      time.Time
+	payee     string
+	account   string
+	source    string
+	amount    decimal.Decimal
+	commodity string
+}
+
+// Detect finds recurring charges, ordered by their next expected date
+func Detect(transactions []*beancount.Transaction) []Series {
+	groups := make(map[string][]charge)
+	for _, tx := range transactions {
+		c, ok := expenseCharge(tx)
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(strings.Join(strings.Fields(c.payee), " "))
+		groups[key] = append(groups[key], c)
+	}
+
+	var series []Series
+	for _, charges := range groups {
+		if s, ok := detectSeries(charges); ok {
+			series = append(series, s)
+		}
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if !series[i].Next.Equal(series[j].Next) {
+			return series[i].Next.Before(series[j].Next)
+		}
+		return series[i].Payee < series[j].Payee
+	})
+	return series
+}
+
+// expenseCharge totals a transaction's expense postings
+// Transactions without expenses, or with expenses in several commodities, are not charges
+func expenseCharge(tx *beancount.Transaction) (charge, bool) {
+	c := charge{date: tx.Date, payee: tx.Payee}
+	if c.payee == "" {
+		c.payee = tx.Narration
+	}
+	if strings.TrimSpace(c.payee) == "" {
+		return charge{}, false
+	}
+
+	largest := decimal.Zero
+	for _, posting := range tx.ResolvedPostings() {
+		if posting.Amount == nil {
+			continue
+		}
+		if !strings.HasPrefix(posting.Account, "Expenses") {
+			// The charge is paid from the first account money leaves
+			if c.source == "" && posting.Amount.Number.IsNegative() {
+				c.source = posting.Account
+			}
+			continue
+		}
+		if c.commodity != "" && posting.Amount.Commodity != c.commodity {
+			return charge{}, false
+		}
+		c.commodity = posting.Amount.Commodity
+		c.amount = c.amount.Add(posting.Amount.Number)
+		if posting.Amount.Number.GreaterThan(largest) {
+			largest = posting.Amount.Number
+			c.account = posting.Account
+		}
+	}
+	return c, c.amount.IsPositive()
+}
+
+// detectSeries checks whether one payee's charges recur
+func detectSeries(charges []charge) (Series, bool) {
+	// Only the commodity most charges use is considered
+	counts := make(map[string]int)
+	for _, c := range charges {
+		counts[c.commodity]++
+	}
+	commodity := ""
+	for name, count := range counts {
+		if commodity == "" || count > counts[commodity] || (count == counts[commodity] && name < commodity) {
+			commodity = name
+		}
+	}
+	var matched []charge
+	for _, c := range charges {
+		if c.commodity == commodity {
+			matched = append(matched, c)
+		}
+	}
+	if len(matched) < MinOccurrences {
+		return Series{}, false
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].date.Before(matched[j].date) })
+
+	intervals := make([]int, 0, len(matched)-1)
+	for i := 1; i < len(matched); i++ {
+		intervals = append(intervals, int(matched[i].date.Sub(matched[i-1].date).Hours()/24+0.5))
+	}
+	cadence, ok := matchCadence(intervals)
+	if !ok {
+		return Series{}, false
+	}
+
+	amounts := make([]decimal.Decimal, len(matched))
+	for i, c := range matched {
+		amounts[i] = c.amount
+	}
+	typical := median(amounts)
+	limit := typical.Mul(decimal.NewFromFloat(amountTolerance))
+	for _, amount := range amounts {
+		if amount.Sub(typical).Abs().GreaterThan(limit) {
+			return Series{}, false
+		}
+	}
+
+	last := matched[len(matched)-1]
+	return Series{
+		Payee:      last.payee,
+		Account:    last.account,
+		Source:     last.source,
+		Cadence:    cadence,
+		Amount:     typical,
+		Commodity:  commodity,
+		Count:      len(matched),
+		Last:       last.date,
+		Next:       cadence.Next(last.date),
+		LastAmount: last.amount,
+		Usual:      median(amounts[:len(amounts)-1]),
+	}, true
+}
+
+// matchCadence returns the cadence the median interval fits,
+// provided most intervals fit it too
+func matchCadence(intervals []int) (Cadence, bool) {
+	sorted := append([]int(nil), intervals...)
+	sort.Ints(sorted)
+	typical := sorted[len(sorted)/2]
+
+	for _, spec := range cadences {
+		if abs(typical-spec.days) > spec.tolerance {
+			continue
+		}
+		regular := 0
+		for _, interval := range intervals {
+			if abs(interval-spec.days) <= spec.tolerance {
+				regular++
+			}
+		}
+		if float64(regular) >= regularShare*float64(len(intervals)) {
+			return spec.cadence, true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// Expected is an upcoming or missing charge of a recurring series
+type Expected struct {
+	Series
+	Date    time.Time
+	Missing bool // The charge is past its grace period
+}
+
+// Upcoming returns the charges expected within horizon of today, and the missing ones
+// Missing charges come first. A series that missed two charges in a row is
+// assumed to have ended and is left out.
+func Upcoming(series []Series, today time.Time, horizon time.Duration) []Expected {
+	var expected []Expected
+	for _, s := range series {
+		grace := time.Duration(s.Cadence.grace()) * 24 * time.Hour
+		switch {
+		case s.Ended(today):
+			continue
+		case today.After(s.Next.Add(grace)):
+			expected = append(expected, Expected{Series: s, Date: s.Next, Missing: true})
+		case !s.Next.After(today.Add(horizon)):
+			expected = append(expected, Expected{Series: s, Date: s.Next})
+		}
+	}
+	sort.SliceStable(expected, func(i, j int) bool {
+		if expected[i].Missing != expected[j].Missing {
+			return expected[i].Missing
+		}
+		return expected[i].Date.Before(expected[j].Date)
+	})
+	return expected
+}
+
+// median returns the middle amount, averaging the two middle ones for an even count
+func median(amounts []decimal.Decimal) decimal.Decimal {
+	sorted := append([]decimal.Decimal(nil), amounts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].LessThan(sorted[j]) })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return sorted[mid-1].Add(sorted[mid]).Div(decimal.NewFromInt(2))
+	}
+	return sorted[mid]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,105 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/shopspring/decimal"
+)
+
+func TestDetect(t *testing.T) {
+	transactions := []*beancount.Transaction{
+		// Monthly subscription
+		beancounttest.Tx("2024-01-15", "Netflix", "Expenses:Subscriptions", "15.99"),
+		beancounttest.Tx("2024-02-15", "Netflix", "Expenses:Subscriptions", "15.99"),
+		beancounttest.Tx("2024-03-15", "NETFLIX", "Expenses:Subscriptions", "17.99"),
+		// Weekly, with a varying amount
+		beancounttest.Tx("2024-03-01", "Farm Box", "Expenses:Food", "30.00"),
+		beancounttest.Tx("2024-03-08", "Farm Box", "Expenses:Food", "32.00"),
+		beancounttest.Tx("2024-03-15", "Farm Box", "Expenses:Food", "28.00"),
+		beancounttest.Tx("2024-03-22", "Farm Box", "Expenses:Food", "31.00"),
+		// Irregular interval
+		beancounttest.Tx("2024-01-02", "Hardware Store", "Expenses:Home", "40.00"),
+		beancounttest.Tx("2024-01-20", "Hardware Store", "Expenses:Home", "40.00"),
+		beancounttest.Tx("2024-03-30", "Hardware Store", "Expenses:Home", "40.00"),
+		// Regular interval but wildly different amounts
+		beancounttest.Tx("2024-01-05", "Grocer", "Expenses:Food", "20.00"),
+		beancounttest.Tx("2024-02-05", "Grocer", "Expenses:Food", "150.00"),
+		beancounttest.Tx("2024-03-05", "Grocer", "Expenses:Food", "60.00"),
+		// Too few charges
+		beancounttest.Tx("2024-02-01", "Gym", "Expenses:Health", "50.00"),
+		beancounttest.Tx("2024-03-01", "Gym", "Expenses:Health", "50.00"),
+	}
+
+	series := Detect(transactions)
+	if len(series) != 2 {
+		t.Fatalf("expected 2 recurring series, got %d: %+v", len(series), series)
+	}
+
+	farm, netflix := series[0], series[1]
+	if farm.Payee != "Farm Box" || farm.Cadence != Weekly || !farm.Next.Equal(beancounttest.Date("2024-03-29")) {
+		t.Errorf("unexpected weekly series: %+v", farm)
+	}
+	if !farm.Amount.Equal(decimal.RequireFromString("30.50")) {
+		t.Errorf("expected median amount 30.50, got %s", farm.Amount)
+	}
+
+	if netflix.Cadence != Monthly || netflix.Count != 3 || netflix.Account != "Expenses:Subscriptions" || netflix.Source != "Assets:Checking" {
+		t.Errorf("unexpected monthly series: %+v", netflix)
+	}
+	if !netflix.Next.Equal(beancounttest.Date("2024-04-15")) || !netflix.Amount.Equal(decimal.RequireFromString("15.99")) {
+		t.Errorf("expected next 15.99 charge on 2024-04-15, got %s on %s", netflix.Amount, netflix.Next.Format("2006-01-02"))
+	}
+
+	// The last charge went up, which is flagged and sets the cost
+	if !netflix.PriceChange().Equal(decimal.RequireFromString("2.00")) {
+		t.Errorf("expected price change of 2.00, got %s", netflix.PriceChange())
+	}
+	if !netflix.Annual().Equal(decimal.RequireFromString("215.88")) || !netflix.Monthly().Equal(decimal.RequireFromString("17.99")) {
+		t.Errorf("expected 17.99 monthly and 215.88 yearly, got %s and %s", netflix.Monthly(), netflix.Annual())
+	}
+	if !farm.Monthly().Equal(decimal.RequireFromString("134.33")) {
+		t.Errorf("expected weekly 31.00 to cost 134.33 a month, got %s", farm.Monthly())
+	}
+}
+
+func TestDetectToleratesMissedCharge(t *testing.T) {
+	series := Detect([]*beancount.Transaction{
+		beancounttest.Tx("2024-01-10", "Power Co", "Expenses:Utilities", "80.00"),
+		beancounttest.Tx("2024-02-10", "Power Co", "Expenses:Utilities", "95.00"),
+		beancounttest.Tx("2024-03-10", "Power Co", "Expenses:Utilities", "70.00"),
+		beancounttest.Tx("2024-05-10", "Power Co", "Expenses:Utilities", "85.00"),
+		beancounttest.Tx("2024-06-10", "Power Co", "Expenses:Utilities", "90.00"),
+	})
+	if len(series) != 1 || series[0].Cadence != Monthly {
+		t.Errorf("expected a monthly series despite the missed April bill, got %+v", series)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	series := []Series{
+		{Payee: "Rent", Cadence: Monthly, Next: beancounttest.Date("2024-04-01")},
+		{Payee: "Netflix", Cadence: Monthly, Next: beancounttest.Date("2024-04-15")},
+		{Payee: "Insurance", Cadence: Yearly, Next: beancounttest.Date("2024-09-01")},
+		{Payee: "Old Gym", Cadence: Monthly, Next: beancounttest.Date("2024-01-01")},
+	}
+
+	expected := Upcoming(series, beancounttest.Date("2024-04-10"), 30*24*time.Hour)
+	if len(expected) != 2 {
+		t.Fatalf("expected 2 charges, got %+v", expected)
+	}
+	if expected[0].Payee != "Rent" || !expected[0].Missing {
+		t.Errorf("expected missing rent first, got %+v", expected[0])
+	}
+	if expected[1].Payee != "Netflix" || expected[1].Missing || !expected[1].Date.Equal(beancounttest.Date("2024-04-15")) {
+		t.Errorf("expected upcoming netflix charge, got %+v", expected[1])
+	}
+
+	// Within the grace period a late charge is still due, not missing
+	expected = Upcoming(series[:1], beancounttest.Date("2024-04-04"), 0)
+	if len(expected) != 1 || expected[0].Missing {
+		t.Errorf("expected late rent within grace to be due, got %+v", expected)
+	}
+}
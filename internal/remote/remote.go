@@ -0,0 +1,236 @@
+// Package remote opens ledgers kept on another machine, named scp-style as
+// [user@]host:path. The ledger and the files it includes are fetched over SFTP into a
+// private local mirror, which lima opens like any ledger; with write-back, each file
+// lima rewrites is put back on the server. The sftp command does the transfers, so
+// ~/.ssh/config, keys and agents apply as they do for ssh.
+package remote
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// Location is a file on another machine
+type Location struct {
+	Host string // [user@]host as ssh takes it
+	Path string // Path on the host; relative paths start in the login directory
+}
+
+// String returns the location as it is written
+func (l Location) String() string {
+	return l.Host + ":" + l.Path
+}
+
+// Parse reads a [user@]host:path location; ok is false for anything else, such as a
+// local path, even one with a colon in a file name, or a host sftp would take for an
+// option
+func Parse(s string) (Location, bool) {
+	host, file, ok := strings.Cut(s, ":")
+	if !ok || host == "" || file == "" || strings.ContainsAny(host, `/\`) || strings.HasPrefix(host, "-") {
+		return Location{}, false
+	}
+	// A drive letter, as in C:\ledger.beancount
+	if len(host) == 1 {
+		return Location{}, false
+	}
+	if _, err := os.Stat(s); err == nil {
+		return Location{}, false
+	}
+	return Location{Host: host, Path: file}, true
+}
+
+// Mirror is the local copy of a remote ledger and its includes
+type Mirror struct {
+	location Location
+	dir      string
+
+	mu      sync.Mutex
+	remotes map[string]string // Local path -> remote path
+	fetched map[string][]byte // Local path -> the server's contents when last fetched or put
+}
+
+// Fetch copies the ledger at location and the files it includes into dir, which is
+// emptied first and private to the user, and returns the mirror; encrypted files are
+// read through the store to find their includes
+func Fetch(location Location, dir string, store *beancount.Store) (*Mirror, error) {
+	if strings.HasPrefix(location.Host, "-") {
+		return nil, fmt.Errorf("invalid host %q", location.Host)
+	}
+	if escapes(location.Path) {
+		return nil, fmt.Errorf("%s is above the login directory, which a remote ledger can't be kept in", location.Path)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to clear %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	m := &Mirror{
+		location: location,
+		dir:      dir,
+		remotes:  make(map[string]string),
+		fetched:  make(map[string][]byte),
+	}
+
+	// Fetch a level of includes at a time, each in one session
+	queue := []string{location.Path}
+	for len(queue) > 0 {
+		var batch []string
+		for _, remote := range queue {
+			local := m.localPath(remote)
+			if err := os.MkdirAll(filepath.Dir(local), 0700); err != nil {
+				return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(local), err)
+			}
+			batch = append(batch, "get "+quote(remote)+" "+quote(local))
+			m.remotes[local] = remote
+		}
+		if err := m.sftp(batch); err != nil {
+			return nil, err
+		}
+
+		var next []string
+		for _, remote := range queue {
+			local := m.localPath(remote)
+			data, err := os.ReadFile(local)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", local, err)
+			}
+			m.fetched[local] = data
+
+			// An encrypted file is decrypted in memory to find its includes
+			content, err := store.ReadFile(local)
+			if err != nil {
+				return nil, err
+			}
+			for _, include := range beancount.Includes(content) {
+				if path.IsAbs(include) {
+					return nil, fmt.Errorf("%s includes %s by its absolute path, which can't be followed on a remote ledger", remote, include)
+				}
+				include = path.Join(path.Dir(remote), include)
+				// Kept in the mirror, or the fetch would write wherever the ledger says
+				if escapes(include) {
+					return nil, fmt.Errorf("%s includes %s, above the login directory, which can't be followed on a remote ledger", remote, include)
+				}
+				if _, seen := m.remotes[m.localPath(include)]; !seen && !slices.Contains(next, include) {
+					next = append(next, include)
+				}
+			}
+		}
+		queue = next
+	}
+	return m, nil
+}
+
+// localPath returns where a remote file is kept in the mirror
+func (m *Mirror) localPath(remote string) string {
+	return filepath.Join(m.dir, filepath.FromSlash(strings.TrimPrefix(path.Clean(remote), "/")))
+}
+
+// escapes reports whether a remote path climbs above the directory it is relative to,
+// which would put its copy outside the mirror
+func escapes(remote string) bool {
+	clean := path.Clean(remote)
+	return clean == ".." || strings.HasPrefix(clean, "../")
+}
+
+// Path returns the local path of the ledger
+func (m *Mirror) Path() string {
+	return m.localPath(m.location.Path)
+}
+
+// Location returns where the ledger is kept
+func (m *Mirror) Location() Location {
+	return m.location
+}
+
+// Put writes a file of the mirror back to the server, unless the server's copy has
+// changed since it was fetched, which Put refuses to overwrite
+func (m *Mirror) Put(local string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	remote, ok := m.remotes[local]
+	if !ok {
+		return fmt.Errorf("%s is not part of the remote ledger", local)
+	}
+
+	check, err := os.CreateTemp(m.dir, ".check*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	check.Close()
+	defer os.Remove(check.Name())
+	if err := m.sftp([]string{"get " + quote(remote) + " " + quote(check.Name())}); err != nil {
+		return err
+	}
+	current, err := os.ReadFile(check.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", check.Name(), err)
+	}
+	if !bytes.Equal(current, m.fetched[local]) {
+		return fmt.Errorf("%s:%s changed on the server since it was fetched; reopen the ledger to see the changes", m.location.Host, remote)
+	}
+
+	data, err := os.ReadFile(local)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", local, err)
+	}
+	if err := m.sftp([]string{"put " + quote(local) + " " + quote(remote)}); err != nil {
+		return err
+	}
+	m.fetched[local] = data
+	return nil
+}
+
+// Close removes the mirror
+func (m *Mirror) Close() error {
+	return os.RemoveAll(m.dir)
+}
+
+// sftp runs batch commands in one session, stopping at the first that fails
+func (m *Mirror) sftp(commands []string) error {
+	cmd := exec.Command("sftp", "-q", "-b", "-", "--", m.location.Host)
+	cmd.Stdin = strings.NewReader(strings.Join(commands, "\n") + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if reason := strings.TrimSpace(stderr.String()); reason != "" && errors.As(err, &exitErr) {
+			return fmt.Errorf("sftp %s: %s", m.location.Host, reason)
+		}
+		return fmt.Errorf("sftp %s: %w", m.location.Host, err)
+	}
+	return nil
+}
+
+// quote quotes a path for an sftp batch command, escaping what sftp would expand
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		if strings.ContainsRune(`"\*?[]`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// CacheDir returns the mirror directory for a location under base, the same on every
+// run so the session on the ledger is resumed
+func CacheDir(base string, location Location) string {
+	sum := sha256.Sum256([]byte(location.String()))
+	return filepath.Join(base, "remote", hex.EncodeToString(sum[:8]))
+}
@@ -0,0 +1,157 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// fakeSFTP runs get and put batch commands against $FAKE_SFTP_ROOT, logging them
+const fakeSFTP = `#!/bin/sh
+while read -r line; do
+	echo "$line" >> "$FAKE_SFTP_ROOT.log"
+	eval "set -- $line"
+	case "$1" in
+	get) cp "$FAKE_SFTP_ROOT/$2" "$3" 2>/dev/null || { echo "File \"$2\" not found." >&2; exit 1; } ;;
+	put) cp "$2" "$FAKE_SFTP_ROOT/$3" || exit 1 ;;
+	esac
+done
+`
+
+func newServer(t *testing.T) string {
+	t.Helper()
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "sftp"), []byte(fakeSFTP), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	root := filepath.Join(t.TempDir(), "server")
+	t.Setenv("FAKE_SFTP_ROOT", root)
+
+	files := map[string]string{
+		"home/me/fin/main.beancount":          "include \"years/2025.beancount\"\ninclude \"prices.beancount\"\n",
+		"home/me/fin/years/2025.beancount":    "include \"../prices.beancount\"\n2025-01-01 open Assets:Cash\n",
+		"home/me/fin/prices.beancount":        "2025-01-01 price BTC 90000 USD\n",
+		"home/me/elsewhere/ignored.beancount": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Location
+		ok   bool
+	}{
+		{"me@nas:/home/me/fin/main.beancount", Location{Host: "me@nas", Path: "/home/me/fin/main.beancount"}, true},
+		{"nas:fin/main.beancount", Location{Host: "nas", Path: "fin/main.beancount"}, true},
+		{"main.beancount", Location{}, false},
+		{"./a:b.beancount", Location{}, false},
+		{`C:\fin\main.beancount`, Location{}, false},
+		{"nas:", Location{}, false},
+		{"-oProxyCommand=sh:main.beancount", Location{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFetchAndPut(t *testing.T) {
+	root := newServer(t)
+	dir := filepath.Join(t.TempDir(), "mirror")
+	m, err := Fetch(Location{Host: "me@nas", Path: "/home/me/fin/main.beancount"}, dir, beancount.NewStore(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.Path() != filepath.Join(dir, "home/me/fin/main.beancount") {
+		t.Errorf("unexpected local path %s", m.Path())
+	}
+	for _, name := range []string{"years/2025.beancount", "prices.beancount"} {
+		if _, err := os.Stat(filepath.Join(dir, "home/me/fin", name)); err != nil {
+			t.Errorf("expected %s fetched: %v", name, err)
+		}
+	}
+	// Files included twice are fetched once, a level of includes per session
+	log, _ := os.ReadFile(root + ".log")
+	if strings.Count(string(log), `get "/home/me/fin/prices.beancount"`) != 1 {
+		t.Errorf("expected prices.beancount fetched once, got:\n%s", log)
+	}
+	if info, _ := os.Stat(dir); info.Mode().Perm() != 0700 {
+		t.Errorf("expected a private mirror, got %v", info.Mode().Perm())
+	}
+
+	prices := filepath.Join(dir, "home/me/fin/prices.beancount")
+	if err := os.WriteFile(prices, []byte("2025-01-02 price BTC 95000 USD\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Put(prices); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, "home/me/fin/prices.beancount"))
+	if string(data) != "2025-01-02 price BTC 95000 USD\n" {
+		t.Errorf("expected the file put back, got %q", data)
+	}
+
+	// A file changed on the server since is left alone
+	if err := os.WriteFile(filepath.Join(root, "home/me/fin/prices.beancount"), []byte("edited elsewhere\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Put(prices); err == nil || !strings.Contains(err.Error(), "changed on the server") {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+	if err := m.Put(filepath.Join(dir, "elsewhere.beancount")); err == nil {
+		t.Error("expected an error for a file outside the ledger")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the mirror removed, got %v", err)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	root := newServer(t)
+	dir := filepath.Join(t.TempDir(), "mirror")
+	if _, err := Fetch(Location{Host: "nas", Path: "/home/me/missing.beancount"}, dir, beancount.NewStore(nil)); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "home/me/abs.beancount"), []byte("include \"/etc/ledger.beancount\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Fetch(Location{Host: "nas", Path: "/home/me/abs.beancount"}, dir, beancount.NewStore(nil)); err == nil || !strings.Contains(err.Error(), "absolute path") {
+		t.Errorf("expected an error for an absolute include, got %v", err)
+	}
+
+	// An include above a relative ledger's login directory would land outside the mirror
+	if err := os.WriteFile(filepath.Join(root, "home/me/escape.beancount"), []byte("include \"../../../outside.beancount\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Fetch(Location{Host: "nas", Path: "home/me/escape.beancount"}, dir, beancount.NewStore(nil)); err == nil || !strings.Contains(err.Error(), "above the login directory") {
+		t.Errorf("expected an error for an include outside the mirror, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "outside.beancount")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing written outside the mirror, got %v", err)
+	}
+	if _, err := Fetch(Location{Host: "-oProxyCommand=sh", Path: "main.beancount"}, dir, beancount.NewStore(nil)); err == nil {
+		t.Error("expected an error for a host starting with -")
+	}
+}
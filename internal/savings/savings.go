@@ -0,0 +1,133 @@
+// Package savings measures how much of its income a ledger keeps, month by month
+//
+// A month's savings rate is its income less its expenses, over its income. Rolling
+// rates over several months divide the months' savings by their income together, so a
+// month of little income sways them less than it sways its own rate.
+package savings
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// Windows are the rolling rates reported, in months
+var Windows = []int{3, 6, 12}
+
+// Month is one month's income and expenses
+type Month struct {
+	Start    time.Time       // First day of the month
+	Income   decimal.Decimal // Positive for money earned
+	Expenses decimal.Decimal // Positive for money spent
+}
+
+// Saved returns the income the month's expenses left
+func (m Month) Saved() decimal.Decimal {
+	return m.Income.Sub(m.Expenses)
+}
+
+// Rate returns the percentage of the month's income saved, or false without income
+func (m Month) Rate() (float64, bool) {
+	return rate(m.Saved(), m.Income)
+}
+
+// rate returns saved as a percentage of income, or false when income isn't positive
+func rate(saved, income decimal.Decimal) (float64, bool) {
+	if !income.IsPositive() {
+		return 0, false
+	}
+	return saved.Div(income).InexactFloat64() * 100, true
+}
+
+// Months totals income and expenses in commodity for every month from the first with
+// either to the last, months without any included; postings in other commodities are
+// left out
+func Months(transactions []*beancount.Transaction, commodity string) []Month {
+	totals := make(map[time.Time]*Month)
+	var first, last time.Time
+	for _, tx := range transactions {
+		start := time.Date(tx.Date.Year(), tx.Date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for _, posting := range tx.ResolvedPostings() {
+			if posting.Amount == nil || posting.Amount.Commodity != commodity {
+				continue
+			}
+			root, _, _ := strings.Cut(posting.Account, ":")
+			if root != "Income" && root != "Expenses" {
+				continue
+			}
+			month, ok := totals[start]
+			if !ok {
+				month = &Month{Start: start}
+				totals[start] = month
+			}
+			if root == "Income" {
+				month.Income = month.Income.Sub(posting.Amount.Number)
+			} else {
+				month.Expenses = month.Expenses.Add(posting.Amount.Number)
+			}
+			if first.IsZero() || start.Before(first) {
+				first = start
+			}
+			if start.After(last) {
+				last = start
+			}
+		}
+	}
+	if first.IsZero() {
+		return nil
+	}
+
+	var months []Month
+	for start := first; !start.After(last); start = start.AddDate(0, 1, 0) {
+		if month, ok := totals[start]; ok {
+			months = append(months, *month)
+		} else {
+			months = append(months, Month{Start: start})
+		}
+	}
+	return months
+}
+
+// Rolling returns the savings rate of the window months ending with months[i], or
+// false when fewer months come before it or they had no income
+func Rolling(months []Month, i, window int) (float64, bool) {
+	if window < 1 || i < window-1 || i >= len(months) {
+		return 0, false
+	}
+	saved, income := decimal.Zero, decimal.Zero
+	for _, month := range months[i-window+1 : i+1] {
+		saved = saved.Add(month.Saved())
+		income = income.Add(month.Income)
+	}
+	return rate(saved, income)
+}
+
+// Overall returns the savings rate of all the months together, or false without income
+func Overall(months []Month) (float64, bool) {
+	return Rolling(months, len(months)-1, len(months))
+}
+
+// Deviations returns the indexes of up to top months whose rate strays furthest from
+// the overall rate, furthest first; months without income have no rate and are left out
+func Deviations(months []Month, top int) []int {
+	overall, ok := Overall(months)
+	if !ok {
+		return nil
+	}
+	var indexes []int
+	distance := make(map[int]float64)
+	for i, month := range months {
+		if r, ok := month.Rate(); ok {
+			indexes = append(indexes, i)
+			distance[i] = math.Abs(r - overall)
+		}
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return distance[indexes[a]] > distance[indexes[b]]
+	})
+	return indexes[:min(top, len(indexes))]
+}
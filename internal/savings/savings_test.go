@@ -0,0 +1,86 @@
+package savings
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/shopspring/decimal"
+)
+
+// ledger earns 4,000 a month through 2024 and spends 3,000, but 5,000 in March,
+// nothing is booked in May and June, and a trip in euros is left out
+func ledger() []*beancount.Transaction {
+	var transactions []*beancount.Transaction
+	for month := 1; month <= 8; month++ {
+		if month == 5 || month == 6 {
+			continue
+		}
+		spent := "3000"
+		if month == 3 {
+			spent = "5000"
+		}
+		transactions = append(transactions,
+			beancounttest.Tx(fmt.Sprintf("2024-%02d-01", month), "", "Income:Salary", "-4000"),
+			beancounttest.Tx(fmt.Sprintf("2024-%02d-15", month), "", "Expenses:Rent", spent))
+	}
+	return append(transactions, beancounttest.Tx("2024-04-10", "", "Expenses:Travel", "900 EUR"))
+}
+
+func TestMonths(t *testing.T) {
+	months := Months(ledger(), "USD")
+	if len(months) != 8 || months[0].Start != beancounttest.Date("2024-01-01") || months[7].Start != beancounttest.Date("2024-08-01") {
+		t.Fatalf("unexpected months %+v", months)
+	}
+	if r, ok := months[0].Rate(); !ok || r != 25 {
+		t.Errorf("January: got %v (%v), want 25", r, ok)
+	}
+	if r, ok := months[2].Rate(); !ok || r != -25 || !months[2].Saved().Equal(decimal.NewFromInt(-1000)) {
+		t.Errorf("March: got %v (%v), saved %s", r, ok, months[2].Saved())
+	}
+	if !months[3].Expenses.Equal(decimal.NewFromInt(3000)) {
+		t.Errorf("expected euros left out of April, got %s", months[3].Expenses)
+	}
+	if _, ok := months[4].Rate(); ok || !months[4].Income.IsZero() {
+		t.Errorf("expected May empty, got %+v", months[4])
+	}
+	if Months(ledger(), "GBP") != nil {
+		t.Error("expected no months in an unused commodity")
+	}
+}
+
+func TestRolling(t *testing.T) {
+	months := Months(ledger(), "USD")
+	// January to March: 12,000 earned, 11,000 spent
+	if r, ok := Rolling(months, 2, 3); !ok || math.Abs(r-100.0/12) > 1e-9 {
+		t.Errorf("three months to March: got %v (%v)", r, ok)
+	}
+	if _, ok := Rolling(months, 1, 3); ok {
+		t.Error("expected no three-month rate before March")
+	}
+	if _, ok := Rolling(months, 5, 2); ok {
+		t.Error("expected no rate over months without income")
+	}
+	// Six months to August hold four of income, March's overspending among them
+	if r, ok := Rolling(months, 7, 6); !ok || r != 12.5 {
+		t.Errorf("six months to August: got %v (%v)", r, ok)
+	}
+}
+
+func TestDeviations(t *testing.T) {
+	months := Months(ledger(), "USD")
+	// 24,000 earned and 20,000 spent overall: March strays furthest from 16.7%
+	if r, _ := Overall(months); math.Abs(r-100.0/6) > 1e-9 {
+		t.Errorf("unexpected overall rate %v", r)
+	}
+	got := Deviations(months, 2)
+	if len(got) != 2 || got[0] != 2 || got[1] != 0 {
+		t.Errorf("got %v, want March then January", got)
+	}
+	if all := Deviations(months, 10); !slices.Equal(all, []int{2, 0, 1, 3, 6, 7}) {
+		t.Errorf("expected every month with income, got %v", all)
+	}
+}
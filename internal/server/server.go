@@ -0,0 +1,583 @@
+// Package server answers lima's JSON API over HTTP, so other tools and a web UI can
+// read a ledger and use the categorizer without the TUI. Every request must bear the
+// API token as "Authorization: Bearer TOKEN".
+//
+//	GET  /api/transactions          ?filter=&from=&to=&offset=&limit=, newest first
+//	GET  /api/transactions/{id}
+//	GET  /api/accounts
+//	GET  /api/balances              ?date=&rollup=true
+//	GET  /api/reports/{kind}        ?period=&currency=
+//	POST /api/categorize/suggest    {"id": 12} or {"payee": ..., "narration": ...}
+//	POST /api/categorize/feedback   {"id": 12, "category": ..., "accepted": true}
+//
+// A transaction's id is its place in the ledger, which changes when transactions are
+// added before it. The ledger is read again whenever one of its files changes.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/importer"
+	"github.com/mmichie/lima/internal/ui/reports"
+	"github.com/shopspring/decimal"
+)
+
+// Page sizes of /api/transactions
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// maxBodyBytes bounds the JSON a POST may send
+const maxBodyBytes = 1 << 20
+
+// Server is the API over one ledger and a categorizer; it is an http.Handler
+type Server struct {
+	token    string
+	currency string
+	now      func() time.Time
+	mux      *http.ServeMux
+
+	// mu guards the ledger, which is read again when it changes, and its transactions
+	mu          sync.Mutex
+	file        *beancount.File
+	categorizer *categorizer.Categorizer
+	ledger      *beancount.Loader
+}
+
+// New returns a server over a ledger that accepts requests bearing token; reports
+// default to currency, and a nil categorizer leaves out the categorize endpoints
+func New(file *beancount.File, cat *categorizer.Categorizer, token, currency string) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("an API token is required")
+	}
+	s := &Server{
+		token:       token,
+		currency:    currency,
+		now:         time.Now,
+		mux:         http.NewServeMux(),
+		file:        file,
+		categorizer: cat,
+	}
+	ledger, err := beancount.NewLoader(file, s.loaded)
+	if err != nil {
+		return nil, err
+	}
+	s.ledger = ledger
+
+	s.mux.HandleFunc("GET /api/transactions", s.handleTransactions)
+	s.mux.HandleFunc("GET /api/transactions/{id}", s.handleTransaction)
+	s.mux.HandleFunc("GET /api/accounts", s.handleAccounts)
+	s.mux.HandleFunc("GET /api/balances", s.handleBalances)
+	s.mux.HandleFunc("GET /api/reports/{kind}", s.handleReport)
+	if cat != nil {
+		s.mux.HandleFunc("POST /api/categorize/suggest", s.handleSuggest)
+		s.mux.HandleFunc("POST /api/categorize/feedback", s.handleFeedback)
+	}
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	})
+	return s, nil
+}
+
+// ServeHTTP checks the token, brings the ledger up to date and answers the request;
+// requests are answered one at a time
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(s.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="lima"`)
+		writeError(w, http.StatusUnauthorized, "missing or wrong API token")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ledger.Refresh(); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// loaded brings the categorizer up to date with the transactions just read
+func (s *Server) loaded(transactions []*beancount.Transaction) {
+	if s.categorizer != nil {
+		s.categorizer.SetKnownAccounts(s.file.DeclaredAccounts())
+		s.categorizer.TrainSimilarity(transactions)
+	}
+}
+
+// handleTransactions lists the transactions a filter selects within a date range,
+// newest first, a page at a time
+func (s *Server) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	filter, err := beancount.ParseFilter(params.Get("filter"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, err := dateParam(params.Get("from"), time.Time{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := dateParam(params.Get("to"), time.Time{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset, err := intParam(params.Get("offset"), 0, 0, -1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "offset: "+err.Error())
+		return
+	}
+	limit, err := intParam(params.Get("limit"), defaultLimit, 1, maxLimit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "limit: "+err.Error())
+		return
+	}
+
+	order := s.file.TransactionsFrom(from)
+	var matched []int
+	for j := len(order) - 1; j >= 0; j-- {
+		tx := s.ledger.Transactions()[order[j]]
+		if !to.IsZero() && tx.Date.After(to) {
+			continue
+		}
+		if filter.Match(tx) {
+			matched = append(matched, order[j])
+		}
+	}
+	// Clamped before adding, so a huge offset can't overflow past the end
+	start := min(offset, len(matched))
+	page := make([]transactionJSON, 0, limit)
+	for _, i := range matched[start:min(start+limit, len(matched))] {
+		page = append(page, newTransactionJSON(i, s.ledger.Transactions()[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"total":        len(matched),
+		"offset":       offset,
+		"limit":        limit,
+		"transactions": page,
+	})
+}
+
+// handleTransaction returns one transaction by id
+func (s *Server) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	i, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || i < 0 || i >= len(s.ledger.Transactions()) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no transaction %q", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, newTransactionJSON(i, s.ledger.Transactions()[i]))
+}
+
+// accountJSON is an account as the API returns it
+type accountJSON struct {
+	Name       string   `json:"name"`
+	Open       string   `json:"open,omitempty"`
+	Close      string   `json:"close,omitempty"`
+	Currencies []string `json:"currencies"`
+}
+
+// handleAccounts lists the accounts used or declared in the ledger with the dates
+// they were opened and closed
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	byName := make(map[string]*accountJSON)
+	var accounts []*accountJSON
+	account := func(name string) *accountJSON {
+		if a, ok := byName[name]; ok {
+			return a
+		}
+		a := &accountJSON{Name: name, Currencies: []string{}}
+		byName[name] = a
+		accounts = append(accounts, a)
+		return a
+	}
+	for _, name := range s.file.GetAccounts() {
+		account(name)
+	}
+	for _, open := range s.file.GetOpenDirectives() {
+		a := account(open.Account)
+		a.Open = open.Date.Format(time.DateOnly)
+		if open.Commodities != nil {
+			a.Currencies = open.Commodities
+		}
+	}
+	for _, closed := range s.file.GetCloseDirectives() {
+		account(closed.Account).Close = closed.Date.Format(time.DateOnly)
+	}
+	slices.SortFunc(accounts, func(a, b *accountJSON) int { return strings.Compare(a.Name, b.Name) })
+	writeJSON(w, http.StatusOK, map[string]any{"accounts": accounts})
+}
+
+// handleBalances returns each account's balance by commodity at the end of a date,
+// today by default, and with rollup=true each parent's balance including its children
+func (s *Server) handleBalances(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	date, err := dateParam(params.Get("date"), s.now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rollup, err := boolParam(params.Get("rollup"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "rollup: "+err.Error())
+		return
+	}
+
+	var transactions []*beancount.Transaction
+	for _, tx := range s.ledger.Transactions() {
+		if !tx.Date.After(date) {
+			transactions = append(transactions, tx)
+		}
+	}
+	balances := beancount.AccountBalances(transactions)
+	if rollup {
+		balances = beancount.RollUpBalances(balances)
+	}
+	out := make(map[string]map[string]json.RawMessage, len(balances))
+	for account, commodities := range balances {
+		out[account] = make(map[string]json.RawMessage, len(commodities))
+		for commodity, n := range commodities {
+			out[account][commodity] = number(n)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"date":     date.Format(time.DateOnly),
+		"balances": out,
+	})
+}
+
+// handleReport computes one of lima report's reports for a period, this month by
+// default, in a currency, the configured one by default
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	periodText := params.Get("period")
+	if periodText == "" {
+		periodText = "this month"
+	}
+	period, err := beancount.ParsePeriod(periodText, s.now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	currency := s.currency
+	if params.Has("currency") {
+		currency = params.Get("currency")
+	}
+	kind := r.PathValue("kind")
+	if !slices.Contains(reports.Kinds, kind) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown report %q: use %s", kind, strings.Join(reports.Kinds, ", ")))
+		return
+	}
+	table, err := reports.Run(s.file, kind, period, currency)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rows := table.Rows
+	if rows == nil {
+		rows = [][]string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"report":  kind,
+		"period":  period.String(),
+		"columns": table.Columns,
+		"rows":    rows,
+	})
+}
+
+// categorizeRequest names a ledger transaction by id, or describes one that isn't in
+// the ledger, such as a line of a bank statement
+type categorizeRequest struct {
+	ID        *int     `json:"id"`
+	Date      string   `json:"date"`
+	Payee     string   `json:"payee"`
+	Narration string   `json:"narration"`
+	Amount    string   `json:"amount"`   // Change to account, e.g. "-12.50"
+	Currency  string   `json:"currency"` // Defaults to the configured currency
+	Account   string   `json:"account"`  // The account the money left or reached
+	Tags      []string `json:"tags"`
+
+	// For feedback: the suggested category and whether it was taken
+	Category string `json:"category"`
+	Accepted bool   `json:"accepted"`
+}
+
+// transaction returns the ledger transaction the request names or the one it describes
+func (s *Server) transaction(req categorizeRequest) (*beancount.Transaction, int, error) {
+	if req.ID != nil {
+		if *req.ID < 0 || *req.ID >= len(s.ledger.Transactions()) {
+			return nil, http.StatusNotFound, fmt.Errorf("no transaction %d", *req.ID)
+		}
+		return s.ledger.Transactions()[*req.ID], 0, nil
+	}
+	if req.Payee == "" && req.Narration == "" {
+		return nil, http.StatusBadRequest, errors.New("give an id, or a payee or narration")
+	}
+	date := s.now()
+	if req.Date != "" {
+		var err error
+		if date, err = time.Parse(time.DateOnly, req.Date); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid date %q: use YYYY-MM-DD", req.Date)
+		}
+	}
+	var amount *beancount.Amount
+	if req.Amount != "" {
+		n, err := decimal.NewFromString(req.Amount)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("invalid amount %q", req.Amount)
+		}
+		currency := req.Currency
+		if currency == "" {
+			currency = s.currency
+		}
+		amount = &beancount.Amount{Number: n, Commodity: currency}
+	}
+	// Balanced against the placeholder an import would use
+	tx := importer.NewTransaction(date, req.Payee, req.Narration, req.Account, amount)
+	tx.Tags = req.Tags
+	return tx, 0, nil
+}
+
+// suggestionJSON is a categorizer suggestion as the API returns it
+type suggestionJSON struct {
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+	Reason     string  `json:"reason,omitempty"`
+	Pattern    string  `json:"pattern,omitempty"`
+}
+
+// handleSuggest returns the categorizer's suggestions for a transaction, best first
+func (s *Server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	req, ok := readRequest(w, r)
+	if !ok {
+		return
+	}
+	tx, status, err := s.transaction(req)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	suggestions, err := s.categorizer.SuggestAll(tx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	out := make([]suggestionJSON, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		sj := suggestionJSON{
+			Category:   suggestion.Category,
+			Confidence: suggestion.Confidence,
+			Source:     string(suggestion.Source),
+			Reason:     suggestion.Reason,
+		}
+		if suggestion.Pattern != nil {
+			sj.Pattern = suggestion.Pattern.ID
+		}
+		out = append(out, sj)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"suggestions": out})
+}
+
+// handleFeedback records whether a suggested category was taken, so the pattern that
+// suggested it gains or loses confidence
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	req, ok := readRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Category == "" {
+		writeError(w, http.StatusBadRequest, "category is required")
+		return
+	}
+	tx, status, err := s.transaction(req)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	suggestions, err := s.categorizer.SuggestAll(tx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	i := slices.IndexFunc(suggestions, func(suggestion *categorizer.Suggestion) bool {
+		return suggestion.Category == req.Category
+	})
+	if i < 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s is not suggested for this transaction", req.Category))
+		return
+	}
+	if err := s.categorizer.Feedback(suggestions[i], req.Accepted); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// Only pattern suggestions learn from feedback
+	var pattern string
+	if suggestions[i].Pattern != nil {
+		pattern = suggestions[i].Pattern.ID
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"recorded": pattern != "", "pattern": pattern})
+}
+
+// readRequest decodes a categorize request body, answering with an error when it
+// can't
+func readRequest(w http.ResponseWriter, r *http.Request) (categorizeRequest, bool) {
+	var req categorizeRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return req, false
+	}
+	return req, true
+}
+
+// postingJSON is a posting as the API returns it; numbers keep their decimal places
+type postingJSON struct {
+	Account  string            `json:"account"`
+	Number   json.RawMessage   `json:"number,omitempty"`
+	Currency string            `json:"currency,omitempty"`
+	Cost     *amountJSON       `json:"cost,omitempty"`
+	Price    *amountJSON       `json:"price,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// amountJSON is a number in a commodity
+type amountJSON struct {
+	Number   json.RawMessage `json:"number"`
+	Currency string          `json:"currency"`
+}
+
+// transactionJSON is a transaction as the API returns it
+type transactionJSON struct {
+	ID        int               `json:"id"`
+	Date      string            `json:"date"`
+	Flag      string            `json:"flag"`
+	Payee     string            `json:"payee"`
+	Narration string            `json:"narration"`
+	Tags      []string          `json:"tags"`
+	Links     []string          `json:"links"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Postings  []postingJSON     `json:"postings"`
+	Filename  string            `json:"filename"`
+	Lineno    int               `json:"lineno"`
+}
+
+// newTransactionJSON renders a transaction with its id, elided amounts filled in
+func newTransactionJSON(id int, tx *beancount.Transaction) transactionJSON {
+	out := transactionJSON{
+		ID:        id,
+		Date:      tx.Date.Format(time.DateOnly),
+		Flag:      tx.Flag,
+		Payee:     tx.Payee,
+		Narration: tx.Narration,
+		Tags:      orEmpty(tx.Tags),
+		Links:     orEmpty(tx.Links),
+		Metadata:  tx.Metadata,
+		Filename:  tx.FilePath,
+		Lineno:    tx.LineNumber,
+	}
+	for _, posting := range tx.ResolvedPostings() {
+		pj := postingJSON{
+			Account:  posting.Account,
+			Cost:     amount(posting.Cost),
+			Price:    amount(posting.Price),
+			Metadata: posting.Metadata,
+		}
+		if posting.Amount != nil {
+			pj.Number = number(posting.Amount.Number)
+			pj.Currency = posting.Amount.Commodity
+		}
+		out.Postings = append(out.Postings, pj)
+	}
+	return out
+}
+
+// amount renders an optional amount
+func amount(a *beancount.Amount) *amountJSON {
+	if a == nil {
+		return nil
+	}
+	return &amountJSON{Number: number(a.Number), Currency: a.Commodity}
+}
+
+// number renders a number as JSON with the decimal places it was written with, so
+// 1000.00 stays 1000.00
+func number(n decimal.Decimal) json.RawMessage {
+	return json.RawMessage(n.StringFixed(max(0, -n.Exponent())))
+}
+
+// orEmpty returns an empty list for nil, so JSON has [] rather than null
+func orEmpty(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// dateParam parses a YYYY-MM-DD query parameter, or returns fallback when it is empty
+func dateParam(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// intParam parses an integer query parameter between low and high, -1 for no upper
+// bound, or returns fallback when it is empty
+func intParam(value string, fallback, low, high int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	if n < low || (high >= 0 && n > high) {
+		if high < 0 {
+			return 0, fmt.Errorf("must be at least %d", low)
+		}
+		return 0, fmt.Errorf("must be between %d and %d", low, high)
+	}
+	return n, nil
+}
+
+// boolParam parses a true/false query parameter; empty is false
+func boolParam(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// writeJSON answers with a value as JSON
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+// writeError answers with an error as {"error": message}
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+const testLedger = `2024-01-01 open Assets:Checking USD
+2024-01-01 open Expenses:Food
+2024-01-01 open Income:Salary
+
+2024-01-02 * "Employer" "Salary"
+  Assets:Checking  1000.00 USD
+  Income:Salary
+
+2024-01-05 * "Grocer" "Groceries" #food
+  Expenses:Food  45.50 USD
+  Assets:Checking
+
+2024-02-03 * "Grocer" "More groceries" #food
+  Expenses:Food  20.00 USD
+  Assets:Checking
+`
+
+const testPatterns = `version: "1"
+patterns:
+  - id: grocer
+    name: Grocer
+    pattern: "(?i)grocer"
+    category: Expenses:Food
+    confidence: 0.9
+`
+
+const testToken = "secret"
+
+func newTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.beancount")
+	if err := os.WriteFile(path, []byte(testLedger), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patterns := filepath.Join(dir, "patterns.yaml")
+	if err := os.WriteFile(patterns, []byte(testPatterns), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := beancount.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	t.Cleanup(func() { file.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = patterns
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(file, cat, testToken, "USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.now = func() time.Time { return time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC) }
+	return s, path
+}
+
+// call makes an authorized request and decodes the JSON answer
+func call(t *testing.T, s *Server, method, target, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	var decoded map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("%s %s: invalid JSON: %v\n%s", method, target, err, w.Body)
+	}
+	return w.Code, decoded
+}
+
+func TestAuth(t *testing.T) {
+	s, _ := newTestServer(t)
+	for _, header := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/accounts", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: expected 401, got %d", header, w.Code)
+		}
+	}
+	if _, err := New(nil, nil, "", "USD"); err == nil {
+		t.Error("expected an error without a token")
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	s, path := newTestServer(t)
+
+	status, body := call(t, s, http.MethodGet, "/api/transactions?filter=grocer&limit=1", "")
+	if status != http.StatusOK || body["total"] != float64(2) {
+		t.Fatalf("unexpected answer %d %v", status, body)
+	}
+	page := body["transactions"].([]any)
+	newest := page[0].(map[string]any)
+	if len(page) != 1 || newest["narration"] != "More groceries" || newest["id"] != float64(2) {
+		t.Errorf("expected the newest groceries first, got %v", page)
+	}
+	// The elided amount is filled in, keeping its places
+	postings := newest["postings"].([]any)
+	if postings[1].(map[string]any)["number"] != -20.0 {
+		t.Errorf("unexpected postings %v", postings)
+	}
+
+	_, body = call(t, s, http.MethodGet, "/api/transactions?from=2024-01-03&to=2024-01-31", "")
+	if body["total"] != float64(1) {
+		t.Errorf("expected one transaction in range, got %v", body)
+	}
+	if status, _ := call(t, s, http.MethodGet, "/api/transactions?limit=0", ""); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for limit=0, got %d", status)
+	}
+	// An offset past the end, however large, is an empty page
+	status, body = call(t, s, http.MethodGet, "/api/transactions?offset=9223372036854775807", "")
+	if status != http.StatusOK || len(body["transactions"].([]any)) != 0 {
+		t.Errorf("expected an empty last page, got %d %v", status, body)
+	}
+	if status, _ := call(t, s, http.MethodGet, "/api/transactions/1", ""); status != http.StatusOK {
+		t.Errorf("expected transaction 1, got %d", status)
+	}
+	if status, _ := call(t, s, http.MethodGet, "/api/transactions/9", ""); status != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing transaction, got %d", status)
+	}
+
+	// An edit to the ledger is picked up by the next request
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("\n2024-02-05 * \"Grocer\" \"Snacks\"\n  Expenses:Food  5.00 USD\n  Assets:Checking\n")
+	f.Close()
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	if _, body := call(t, s, http.MethodGet, "/api/transactions?filter=grocer", ""); body["total"] != float64(3) {
+		t.Errorf("expected the appended transaction, got %v", body["total"])
+	}
+}
+
+func TestAccountsAndBalances(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	_, body := call(t, s, http.MethodGet, "/api/accounts", "")
+	accounts := body["accounts"].([]any)
+	checking := accounts[0].(map[string]any)
+	if len(accounts) != 3 || checking["name"] != "Assets:Checking" || checking["open"] != "2024-01-01" {
+		t.Errorf("unexpected accounts %v", accounts)
+	}
+
+	_, body = call(t, s, http.MethodGet, "/api/balances?date=2024-01-31&rollup=true", "")
+	balances := body["balances"].(map[string]any)
+	if balances["Assets:Checking"].(map[string]any)["USD"] != 954.5 || balances["Expenses"].(map[string]any)["USD"] != 45.5 {
+		t.Errorf("unexpected balances %v", balances)
+	}
+
+	status, body := call(t, s, http.MethodGet, "/api/reports/income?period=2024-01", "")
+	if status != http.StatusOK || body["period"] == "" || len(body["rows"].([]any)) == 0 {
+		t.Errorf("unexpected report %d %v", status, body)
+	}
+	if status, _ := call(t, s, http.MethodGet, "/api/reports/nope", ""); status != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown report, got %d", status)
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	status, body := call(t, s, http.MethodPost, "/api/categorize/suggest", `{"payee": "Corner Grocer", "amount": "-12.00", "account": "Assets:Checking"}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected answer %d %v", status, body)
+	}
+	suggestions := body["suggestions"].([]any)
+	if len(suggestions) == 0 || suggestions[0].(map[string]any)["category"] != "Expenses:Food" || suggestions[0].(map[string]any)["pattern"] != "grocer" {
+		t.Errorf("unexpected suggestions %v", suggestions)
+	}
+
+	status, body = call(t, s, http.MethodPost, "/api/categorize/feedback", `{"id": 1, "category": "Expenses:Food", "accepted": true}`)
+	if status != http.StatusOK || body["recorded"] != true {
+		t.Errorf("unexpected feedback answer %d %v", status, body)
+	}
+	if status, _ := call(t, s, http.MethodPost, "/api/categorize/feedback", `{"id": 1, "category": "Expenses:Rent", "accepted": false}`); status != http.StatusNotFound {
+		t.Errorf("expected 404 for a category not suggested, got %d", status)
+	}
+	if status, _ := call(t, s, http.MethodPost, "/api/categorize/suggest", `{}`); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an empty request, got %d", status)
+	}
+}
@@ -0,0 +1,228 @@
+// Package shared works out who owes whom for expenses split with other people
+//
+// A transaction is shared when it carries the configured tag, e.g. #shared, or any of
+// the metadata below. Its expenses are split between you and the people sharing it:
+//
+//	shared_with: Alex, Sam   who shares it besides you; by default the configured people
+//	paid_by: Alex            who paid; by default you
+//	share: 50%               your part of it; by default everyone's parts are equal
+//
+// Whoever didn't pay owes the payer their part. A transaction with settle: Alex
+// metadata is a payment between you and Alex instead: money leaving your assets is you
+// paying Alex, and money coming in is Alex paying you.
+package shared
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// Metadata keys read from shared transactions
+const (
+	SharedWithKey = "shared_with"
+	PaidByKey     = "paid_by"
+	ShareKey      = "share"
+	SettleKey     = "settle"
+)
+
+// Item is what one person owes another from one transaction
+type Item struct {
+	Transaction *beancount.Transaction
+	From, To    string // From owes To
+	Settlement  bool   // Whether the transaction is a payment rather than an expense
+	Amount      beancount.Amount
+}
+
+// Balance is what one person owes another over the whole ledger, in one commodity
+type Balance struct {
+	From, To string // From owes To
+	Amount   beancount.Amount
+}
+
+// Report is the ledger's shared expenses and settlements and what they leave owing
+type Report struct {
+	Items    []Item    // In date order
+	Balances []Balance // Netted between each pair, by who owes, then who is owed
+}
+
+// Summarize splits the ledger's shared transactions and nets what they leave each
+// person owing each other
+func Summarize(transactions []*beancount.Transaction, cfg config.SharedConfig) (Report, error) {
+	sorted := slices.Clone(transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	var report Report
+	for _, tx := range sorted {
+		items, err := split(tx, cfg)
+		if err != nil {
+			return Report{}, fmt.Errorf("%s:%d: %w", filepath.Base(tx.FilePath), tx.LineNumber, err)
+		}
+		report.Items = append(report.Items, items...)
+	}
+	report.Balances = net(report.Items)
+	return report, nil
+}
+
+// split returns what a transaction leaves each person owing; nothing when it isn't shared
+func split(tx *beancount.Transaction, cfg config.SharedConfig) ([]Item, error) {
+	if to := strings.TrimSpace(tx.Metadata[SettleKey]); to != "" {
+		return settle(tx, cfg.Me, to), nil
+	}
+	_, withKey := tx.Metadata[SharedWithKey]
+	_, paidKey := tx.Metadata[PaidByKey]
+	_, shareKey := tx.Metadata[ShareKey]
+	if !withKey && !paidKey && !shareKey && !slices.Contains(tx.Tags, strings.TrimPrefix(cfg.Tag, "#")) {
+		return nil, nil
+	}
+
+	others := cfg.People
+	if withKey {
+		others = names(tx.Metadata[SharedWithKey])
+	}
+	payer := cfg.Me
+	if paidKey {
+		payer = strings.TrimSpace(tx.Metadata[PaidByKey])
+	}
+	if payer != cfg.Me && !slices.Contains(others, payer) {
+		others = append(slices.Clone(others), payer)
+	}
+	others = slices.DeleteFunc(slices.Clone(others), func(name string) bool { return name == cfg.Me })
+	if len(others) == 0 {
+		return nil, fmt.Errorf("shared with nobody: add %s metadata or configure shared.people", SharedWithKey)
+	}
+
+	// Your part, then the others' equal parts of the rest
+	mine := decimal.NewFromInt(1).Div(decimal.NewFromInt(int64(len(others) + 1)))
+	if shareKey {
+		value := strings.TrimSpace(tx.Metadata[ShareKey])
+		percent, err := decimal.NewFromString(strings.TrimSuffix(value, "%"))
+		if err != nil || percent.IsNegative() || percent.GreaterThan(decimal.NewFromInt(100)) {
+			return nil, fmt.Errorf("invalid share %q: use a percentage, e.g. 50%%", value)
+		}
+		mine = percent.Div(decimal.NewFromInt(100))
+	}
+	theirs := decimal.NewFromInt(1).Sub(mine).Div(decimal.NewFromInt(int64(len(others))))
+
+	var items []Item
+	for _, spent := range expenses(tx) {
+		for _, person := range append([]string{cfg.Me}, others...) {
+			if person == payer {
+				continue
+			}
+			part := theirs
+			if person == cfg.Me {
+				part = mine
+			}
+			owed := spent.Number.Mul(part).Round(2)
+			if owed.IsZero() {
+				continue
+			}
+			items = append(items, Item{Transaction: tx, From: person, To: payer, Amount: beancount.Amount{Number: owed, Commodity: spent.Commodity}})
+		}
+	}
+	return items, nil
+}
+
+// settle returns a payment between you and another person, from the transaction's
+// postings to your assets
+func settle(tx *beancount.Transaction, me, other string) []Item {
+	totals := make(map[string]decimal.Decimal)
+	var commodities []string
+	for _, posting := range tx.ResolvedPostings() {
+		if posting.Amount == nil || !beancount.AccountUnder(posting.Account, "Assets") {
+			continue
+		}
+		commodity := posting.Amount.Commodity
+		if _, ok := totals[commodity]; !ok {
+			commodities = append(commodities, commodity)
+		}
+		totals[commodity] = totals[commodity].Add(posting.Amount.Number)
+	}
+
+	var items []Item
+	for _, commodity := range commodities {
+		total := totals[commodity]
+		// Paying someone leaves them owing you what you paid
+		item := Item{Transaction: tx, From: other, To: me, Settlement: true, Amount: beancount.Amount{Number: total.Neg(), Commodity: commodity}}
+		if total.IsPositive() {
+			item.From, item.To, item.Amount.Number = me, other, total
+		}
+		if !total.IsZero() {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// expenses totals a transaction's postings to expense accounts in each commodity, in
+// the order the commodities first appear
+func expenses(tx *beancount.Transaction) []beancount.Amount {
+	var totals []beancount.Amount
+	for _, posting := range tx.ResolvedPostings() {
+		if posting.Amount == nil || !beancount.AccountUnder(posting.Account, "Expenses") {
+			continue
+		}
+		i := slices.IndexFunc(totals, func(a beancount.Amount) bool { return a.Commodity == posting.Amount.Commodity })
+		if i < 0 {
+			totals = append(totals, beancount.Amount{Number: decimal.Zero, Commodity: posting.Amount.Commodity})
+			i = len(totals) - 1
+		}
+		totals[i].Number = totals[i].Number.Add(posting.Amount.Number)
+	}
+	return slices.DeleteFunc(totals, func(a beancount.Amount) bool { return a.Number.IsZero() })
+}
+
+// net offsets what each pair of people owe each other, leaving one balance a pair and
+// commodity
+func net(items []Item) []Balance {
+	type pair struct{ a, b, commodity string } // a sorts before b
+	owed := make(map[pair]decimal.Decimal)     // Positive when a owes b
+	for _, item := range items {
+		k := pair{item.From, item.To, item.Amount.Commodity}
+		number := item.Amount.Number
+		if k.b < k.a {
+			k.a, k.b, number = k.b, k.a, number.Neg()
+		}
+		owed[k] = owed[k].Add(number)
+	}
+
+	var balances []Balance
+	for k, number := range owed {
+		balance := Balance{From: k.a, To: k.b, Amount: beancount.Amount{Number: number, Commodity: k.commodity}}
+		if number.IsNegative() {
+			balance.From, balance.To, balance.Amount.Number = k.b, k.a, number.Neg()
+		}
+		if !number.IsZero() {
+			balances = append(balances, balance)
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		a, b := balances[i], balances[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Amount.Commodity < b.Amount.Commodity
+	})
+	return balances
+}
+
+// names splits a comma-separated list of people
+func names(list string) []string {
+	var result []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// withMetadata sets a transaction's metadata
+func withMetadata(tx *beancount.Transaction, metadata map[string]string) *beancount.Transaction {
+	tx.Metadata = metadata
+	return tx
+}
+
+func cfg() config.SharedConfig {
+	return config.SharedConfig{Me: "Me", People: []string{"Alex"}, Tag: "shared"}
+}
+
+func TestSummarize(t *testing.T) {
+	transactions := []*beancount.Transaction{
+		beancounttest.Tx("2024-03-01", "Grocer", "Expenses:Food", "120", "shared"),
+		withMetadata(beancounttest.Tx("2024-03-02", "Landlord", "Expenses:Rent", "1000"), map[string]string{"share": "60%"}),
+		withMetadata(beancounttest.Tx("2024-03-03", "Cinema", "Expenses:Fun", "30"), map[string]string{"paid_by": "Alex", "shared_with": "Alex, Sam"}),
+		beancounttest.Tx("2024-03-04", "Cafe", "Expenses:Food", "12"),
+		withMetadata(beancounttest.Tx("2024-03-10", "Alex", "Equity:Shared", "100"), map[string]string{"settle": "Alex"}),
+	}
+	report, err := Summarize(transactions, cfg())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Alex owes half the groceries, 40% of the rent and what I paid them; Sam and I
+	// each owe Alex a third of the cinema
+	want := []struct {
+		from, to string
+		amount   int64
+	}{
+		{"Alex", "Me", 60 + 400 + 100 - 10},
+		{"Sam", "Alex", 10},
+	}
+	if len(report.Balances) != len(want) {
+		t.Fatalf("got balances %+v", report.Balances)
+	}
+	for i, balance := range report.Balances {
+		w := want[i]
+		if balance.From != w.from || balance.To != w.to || !balance.Amount.Number.Equal(decimal.NewFromInt(w.amount)) {
+			t.Errorf("balance %d: got %+v, want %+v", i, balance, w)
+		}
+	}
+	if len(report.Items) != 5 || !report.Items[4].Settlement || report.Items[4].From != "Alex" {
+		t.Errorf("unexpected items %+v", report.Items)
+	}
+}
+
+func TestSummarizeErrors(t *testing.T) {
+	bad := withMetadata(beancounttest.Tx("2024-03-01", "Grocer", "Expenses:Food", "10"), map[string]string{"share": "half"})
+	bad.FilePath, bad.LineNumber = "/ledger/main.beancount", 12
+	if _, err := Summarize([]*beancount.Transaction{bad}, cfg()); err == nil || !strings.Contains(err.Error(), "main.beancount:12") {
+		t.Errorf("expected an error at the transaction, got %v", err)
+	}
+
+	alone := cfg()
+	alone.People = nil
+	tagged := beancounttest.Tx("2024-03-01", "Grocer", "Expenses:Food", "10", "shared")
+	if _, err := Summarize([]*beancount.Transaction{tagged}, alone); err == nil {
+		t.Error("expected an error for a transaction shared with nobody")
+	}
+}
@@ -0,0 +1,176 @@
+// Package stats measures the health of a ledger: how many transactions it has and
+// where, how many still need a category or don't balance, its largest transactions,
+// the dates it spans and the commodities it uses
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/shopspring/decimal"
+)
+
+// Count is how many transactions fall in a year or post to an account
+type Count struct {
+	Name         string
+	Transactions int
+}
+
+// Large is a transaction and its size: the total of its positive postings
+type Large struct {
+	Transaction *beancount.Transaction
+	Amount      beancount.Amount
+}
+
+// Stats are the measures of a ledger
+type Stats struct {
+	Transactions  int
+	Postings      int
+	Uncategorized int
+	Unbalanced    int
+	First, Last   time.Time // Zero without transactions
+
+	Years       []Count // In date order
+	Accounts    []Count // Most transactions first
+	Commodities []string
+	Largest     []Large // Largest first
+}
+
+// UncategorizedShare returns the percentage of transactions still needing a category
+func (s Stats) UncategorizedShare() float64 {
+	if s.Transactions == 0 {
+		return 0
+	}
+	return 100 * float64(s.Uncategorized) / float64(s.Transactions)
+}
+
+// AveragePostings returns how many postings a transaction has on average
+func (s Stats) AveragePostings() float64 {
+	if s.Transactions == 0 {
+		return 0
+	}
+	return float64(s.Postings) / float64(s.Transactions)
+}
+
+// Compute measures the ledger, keeping its top largest transactions. With a currency,
+// sizes are converted into it at the ledger's prices and only transactions sized in it
+// are ranked; otherwise sizes of every commodity are ranked together.
+func Compute(file *beancount.File, currency string, top int) (Stats, error) {
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		return Stats{}, err
+	}
+	problems, err := file.Check()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var s Stats
+	for _, problem := range problems {
+		if problem.Kind == beancount.ProblemUnbalanced {
+			s.Unbalanced++
+		}
+	}
+
+	years := make(map[string]int)
+	accounts := make(map[string]int)
+	commodities := make(map[string]bool)
+	for _, tx := range transactions {
+		s.Transactions++
+		s.Postings += len(tx.Postings)
+		if categorizer.IsUncategorized(tx) {
+			s.Uncategorized++
+		}
+		if s.First.IsZero() || tx.Date.Before(s.First) {
+			s.First = tx.Date
+		}
+		if tx.Date.After(s.Last) {
+			s.Last = tx.Date
+		}
+		years[tx.Date.Format("2006")]++
+
+		seen := make(map[string]bool)
+		for _, posting := range tx.Postings {
+			if !seen[posting.Account] {
+				seen[posting.Account] = true
+				accounts[posting.Account]++
+			}
+			for _, amount := range []*beancount.Amount{posting.Amount, posting.Cost, posting.Price} {
+				if amount != nil && amount.Commodity != "" {
+					commodities[amount.Commodity] = true
+				}
+			}
+		}
+	}
+
+	s.Years = counts(years)
+	sort.Slice(s.Years, func(i, j int) bool { return s.Years[i].Name < s.Years[j].Name })
+	s.Accounts = counts(accounts)
+	sort.Slice(s.Accounts, func(i, j int) bool {
+		if s.Accounts[i].Transactions != s.Accounts[j].Transactions {
+			return s.Accounts[i].Transactions > s.Accounts[j].Transactions
+		}
+		return s.Accounts[i].Name < s.Accounts[j].Name
+	})
+	for commodity := range commodities {
+		s.Commodities = append(s.Commodities, commodity)
+	}
+	sort.Strings(s.Commodities)
+	s.Largest = largest(file, transactions, currency, top)
+	return s, nil
+}
+
+// counts lists the counts of a map
+func counts(byName map[string]int) []Count {
+	result := make([]Count, 0, len(byName))
+	for name, n := range byName {
+		result = append(result, Count{Name: name, Transactions: n})
+	}
+	return result
+}
+
+// largest returns the top transactions by size
+func largest(file *beancount.File, transactions []*beancount.Transaction, currency string, top int) []Large {
+	if top <= 0 {
+		return nil
+	}
+	sized := transactions
+	if currency != "" {
+		sized = beancount.NewPriceDB(file.GetPriceDirectives()).ConvertTransactions(transactions, currency)
+	}
+
+	var result []Large
+	for i, tx := range sized {
+		amount, ok := size(tx)
+		if !ok || currency != "" && amount.Commodity != currency {
+			continue
+		}
+		result = append(result, Large{Transaction: transactions[i], Amount: amount})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Amount.Number.GreaterThan(result[j].Amount.Number)
+	})
+	return result[:min(top, len(result))]
+}
+
+// size returns the total of a transaction's positive postings in the commodity where
+// it is largest, or false when it has none
+func size(tx *beancount.Transaction) (beancount.Amount, bool) {
+	totals := make(map[string]decimal.Decimal)
+	for _, posting := range tx.ResolvedPostings() {
+		if weight := posting.Weight(); weight != nil && weight.Number.IsPositive() {
+			totals[weight.Commodity] = totals[weight.Commodity].Add(weight.Number)
+		}
+	}
+	var best beancount.Amount
+	found := false
+	for commodity, total := range totals {
+		if !found || total.GreaterThan(best.Number) || total.Equal(best.Number) && commodity < best.Commodity {
+			best = beancount.Amount{Number: total, Commodity: commodity}
+			found = true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,102 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+const ledger = `2023-01-01 open Assets:Checking
+2023-01-01 open Expenses:Food
+2023-01-01 open Expenses:Uncategorized
+2023-01-01 open Assets:Brokerage
+
+2023-06-01 * "Grocer" "Groceries"
+  Expenses:Food      40.00 USD
+  Assets:Checking   -40.00 USD
+
+2023-12-01 * "Bistro" "Dinner"
+  Expenses:Uncategorized  120.00 USD
+  Assets:Checking
+
+2024-01-10 * "Broker" "Buy"
+  Assets:Brokerage   10 VTI {20.00 USD}
+  Assets:Checking  -200.00 USD
+
+2024-02-01 * "Cafe" "Off by a cent"
+  Expenses:Food      5.00 USD
+  Assets:Checking   -4.99 USD
+
+2024-03-01 * "Exchange" "Euros"
+  Assets:Checking    100.00 EUR
+  Assets:Checking   -110.00 USD
+
+2024-01-01 price EUR 1.50 USD
+`
+
+func open(t *testing.T) *beancount.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.beancount")
+	if err := os.WriteFile(path, []byte(ledger), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := beancount.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+func TestCompute(t *testing.T) {
+	s, err := Compute(open(t), "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Transactions != 5 || s.Postings != 10 || s.AveragePostings() != 2 {
+		t.Errorf("unexpected counts: %d transactions, %d postings", s.Transactions, s.Postings)
+	}
+	if s.Uncategorized != 1 || s.UncategorizedShare() != 20 {
+		t.Errorf("expected 1 uncategorized (20%%), got %d (%v%%)", s.Uncategorized, s.UncategorizedShare())
+	}
+	// The exchange has no price on the posting, so it doesn't balance either
+	if s.Unbalanced != 2 {
+		t.Errorf("expected 2 unbalanced, got %d", s.Unbalanced)
+	}
+	if s.First.Format("2006-01-02") != "2023-06-01" || s.Last.Format("2006-01-02") != "2024-03-01" {
+		t.Errorf("unexpected span %s to %s", s.First, s.Last)
+	}
+	if want := []Count{{"2023", 2}, {"2024", 3}}; !slices.Equal(s.Years, want) {
+		t.Errorf("got years %v, want %v", s.Years, want)
+	}
+	if s.Accounts[0] != (Count{"Assets:Checking", 5}) || len(s.Accounts) != 4 {
+		t.Errorf("unexpected accounts %v", s.Accounts)
+	}
+	if want := []string{"EUR", "USD", "VTI"}; !slices.Equal(s.Commodities, want) {
+		t.Errorf("got commodities %q, want %q", s.Commodities, want)
+	}
+
+	if len(s.Largest) != 3 || s.Largest[0].Transaction.Payee != "Broker" || s.Largest[0].Amount.Number.String() != "200" {
+		t.Fatalf("unexpected largest %+v", s.Largest)
+	}
+	if s.Largest[1].Transaction.Payee != "Bistro" || s.Largest[2].Transaction.Payee != "Exchange" {
+		t.Errorf("unexpected order: %s, %s", s.Largest[1].Transaction.Payee, s.Largest[2].Transaction.Payee)
+	}
+}
+
+func TestComputeCurrency(t *testing.T) {
+	s, err := Compute(open(t), "USD", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 100 EUR at 1.50 outweighs the dinner in dollars
+	if s.Largest[1].Transaction.Payee != "Exchange" || s.Largest[1].Amount.Number.String() != "150" {
+		t.Errorf("unexpected second largest %+v", s.Largest[1])
+	}
+	if len(s.Largest) != 5 {
+		t.Errorf("expected every transaction ranked, got %d", len(s.Largest))
+	}
+}
@@ -0,0 +1,171 @@
+// Package tax gathers the spending and income an accountant asks for, by tax year
+//
+// The groups are configured: each counts the postings to some accounts, and the income
+// and expense postings of transactions carrying some tags. A posting can fall in more
+// than one group, such as a medical bill also tagged #tax-deductible.
+package tax
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// Item is a posting counted in a group
+type Item struct {
+	Year        string // Tax year, e.g. 2024, or 2024-25 for one starting after January 1st
+	Group       string
+	Transaction *beancount.Transaction
+	Account     string
+	Amount      beancount.Amount // Spending positive, and income as well
+}
+
+// Total is what a group counted in one tax year and commodity
+type Total struct {
+	Year         string
+	Group        string
+	Commodity    string
+	Transactions int
+	Amount       decimal.Decimal
+}
+
+// Report is the ledger's tax-relevant postings and their totals
+type Report struct {
+	Years  []string // In date order
+	Items  []Item   // In date order, then the groups' configured order
+	Totals []Total  // By year, then the groups' configured order, then commodity
+}
+
+// Start parses the month and day a tax year starts on, e.g. 04-06
+func Start(yearStart string) (time.Month, int, error) {
+	date, err := time.Parse("01-02", yearStart)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid tax year start %q: use MM-DD, e.g. 04-06", yearStart)
+	}
+	return date.Month(), date.Day(), nil
+}
+
+// YearOf names the tax year containing date, for years starting on month and day
+func YearOf(date time.Time, month time.Month, day int) string {
+	year := date.Year()
+	if date.Month() < month || date.Month() == month && date.Day() < day {
+		year--
+	}
+	if month == time.January && day == 1 {
+		return fmt.Sprint(year)
+	}
+	return fmt.Sprintf("%d-%02d", year, (year+1)%100)
+}
+
+// Summarize gathers the postings of the configured groups and totals them by tax year
+func Summarize(transactions []*beancount.Transaction, cfg config.TaxConfig) (Report, error) {
+	month, day, err := Start(cfg.YearStart)
+	if err != nil {
+		return Report{}, err
+	}
+
+	sorted := slices.Clone(transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	var report Report
+	for _, tx := range sorted {
+		year := YearOf(tx.Date, month, day)
+		for _, group := range cfg.Groups {
+			tagged := false
+			for _, tag := range group.Tags {
+				tagged = tagged || slices.Contains(tx.Tags, strings.TrimPrefix(tag, "#"))
+			}
+			for _, posting := range tx.ResolvedPostings() {
+				if posting.Amount == nil || !counts(posting.Account, group, tagged) {
+					continue
+				}
+				amount := *posting.Amount
+				if beancount.AccountUnder(posting.Account, "Income") {
+					amount.Number = amount.Number.Neg()
+				}
+				report.Items = append(report.Items, Item{Year: year, Group: group.Name, Transaction: tx, Account: posting.Account, Amount: amount})
+			}
+		}
+	}
+
+	// Totals in the order the items came, years first
+	type key struct{ year, group, commodity string }
+	totals := make(map[key]*Total)
+	seen := make(map[key]map[*beancount.Transaction]bool)
+	var order []key
+	for _, item := range report.Items {
+		k := key{item.Year, item.Group, item.Amount.Commodity}
+		total, ok := totals[k]
+		if !ok {
+			total = &Total{Year: k.year, Group: k.group, Commodity: k.commodity}
+			totals[k] = total
+			seen[k] = make(map[*beancount.Transaction]bool)
+			order = append(order, k)
+		}
+		total.Amount = total.Amount.Add(item.Amount.Number)
+		if !seen[k][item.Transaction] {
+			seen[k][item.Transaction] = true
+			total.Transactions++
+		}
+		if !slices.Contains(report.Years, item.Year) {
+			report.Years = append(report.Years, item.Year)
+		}
+	}
+	groupIndex := func(name string) int {
+		return slices.IndexFunc(cfg.Groups, func(g config.TaxGroup) bool { return g.Name == name })
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if a.year != b.year {
+			return a.year < b.year
+		}
+		if a.group != b.group {
+			return groupIndex(a.group) < groupIndex(b.group)
+		}
+		return a.commodity < b.commodity
+	})
+	for _, k := range order {
+		report.Totals = append(report.Totals, *totals[k])
+	}
+	return report, nil
+}
+
+// Year keeps the postings and totals of one tax year, named in full or by the calendar
+// year it starts in
+func (r Report) Year(name string) Report {
+	in := func(year string) bool { return year == name || strings.HasPrefix(year, name+"-") }
+	var kept Report
+	for _, year := range r.Years {
+		if in(year) {
+			kept.Years = append(kept.Years, year)
+		}
+	}
+	for _, item := range r.Items {
+		if in(item.Year) {
+			kept.Items = append(kept.Items, item)
+		}
+	}
+	for _, total := range r.Totals {
+		if in(total.Year) {
+			kept.Totals = append(kept.Totals, total)
+		}
+	}
+	return kept
+}
+
+// counts reports whether a group counts a posting to account: one to its accounts, or
+// an income or expense posting of a transaction carrying its tags
+func counts(account string, group config.TaxGroup, tagged bool) bool {
+	for _, root := range group.Accounts {
+		if beancount.AccountUnder(account, root) {
+			return true
+		}
+	}
+	return tagged && (beancount.AccountUnder(account, "Income") || beancount.AccountUnder(account, "Expenses"))
+}
@@ -0,0 +1,89 @@
+package tax
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+func ledger() []*beancount.Transaction {
+	return []*beancount.Transaction{
+		beancounttest.Tx("2024-05-02", "Clinic", "Expenses:Medical:Doctor", "120", "tax-deductible"),
+		beancounttest.Tx("2024-02-10", "Red Cross", "Expenses:Charity", "50"),
+		beancounttest.Tx("2024-03-01", "Office Depot", "Expenses:Office", "80", "tax-deductible"),
+		beancounttest.Tx("2024-04-01", "Pharmacy", "Expenses:Medical", "30"),
+		beancounttest.Tx("2024-06-30", "Bank", "Income:Interest", "-15"),
+		beancounttest.Tx("2024-07-01", "Grocer", "Expenses:Food", "60"),
+		beancounttest.Tx("2025-01-15", "Clinic", "Expenses:Medical:Doctor", "90"),
+	}
+}
+
+func groups() config.TaxConfig {
+	return config.TaxConfig{
+		YearStart: "01-01",
+		Groups: []config.TaxGroup{
+			{Name: "Deductible", Tags: []string{"#tax-deductible"}},
+			{Name: "Medical", Accounts: []string{"Expenses:Medical"}},
+			{Name: "Interest", Accounts: []string{"Income:Interest"}},
+		},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	report, err := Summarize(ledger(), groups())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(report.Years, []string{"2024", "2025"}) {
+		t.Errorf("unexpected years %v", report.Years)
+	}
+	// The clinic's tagged bill counts as deductible and medical; the charity isn't grouped
+	if len(report.Items) != 6 || report.Items[0].Transaction.Payee != "Office Depot" {
+		t.Fatalf("unexpected items %+v", report.Items)
+	}
+
+	want := []Total{
+		{"2024", "Deductible", "USD", 2, decimal.NewFromInt(200)},
+		{"2024", "Medical", "USD", 2, decimal.NewFromInt(150)},
+		{"2024", "Interest", "USD", 1, decimal.NewFromInt(15)},
+		{"2025", "Medical", "USD", 1, decimal.NewFromInt(90)},
+	}
+	if len(report.Totals) != len(want) {
+		t.Fatalf("got totals %+v, want %+v", report.Totals, want)
+	}
+	for i, total := range report.Totals {
+		w := want[i]
+		if total.Year != w.Year || total.Group != w.Group || total.Transactions != w.Transactions || !total.Amount.Equal(w.Amount) {
+			t.Errorf("total %d: got %+v, want %+v", i, total, w)
+		}
+	}
+
+	if kept := report.Year("2025"); len(kept.Items) != 1 || len(kept.Totals) != 1 || !slices.Equal(kept.Years, []string{"2025"}) {
+		t.Errorf("expected only 2025, got %+v", kept)
+	}
+}
+
+func TestTaxYears(t *testing.T) {
+	cfg := groups()
+	cfg.YearStart = "04-06"
+	report, err := Summarize(ledger(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Before April 6th falls in the year before
+	if !slices.Equal(report.Years, []string{"2023-24", "2024-25"}) {
+		t.Errorf("unexpected years %v", report.Years)
+	}
+	if kept := report.Year("2024"); !slices.Equal(kept.Years, []string{"2024-25"}) || len(kept.Items) != 4 {
+		t.Errorf("expected the year starting in 2024, got %+v", kept)
+	}
+
+	cfg.YearStart = "6 April"
+	if _, err := Summarize(ledger(), cfg); err == nil {
+		t.Error("expected an error for an unreadable year start")
+	}
+}
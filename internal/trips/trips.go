@@ -0,0 +1,198 @@
+// Package trips totals the spending of trips and events
+//
+// A trip is either the transactions carrying a tag, such as #trip-japan, from the first
+// to the last of them, or the days an event directive's value holds, such as
+// event "location" "Tokyo", until the next event of the same type. Only expenses count,
+// by top-level category.
+package trips
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/shopspring/decimal"
+)
+
+// Options choose what marks a trip
+type Options struct {
+	TagPrefix string // Tags starting with this mark trips, e.g. trip-; "" takes none
+	Event     string // Type of the events whose values are trips, e.g. location; "" takes none
+}
+
+// DefaultOptions take #trip- tags and location events
+var DefaultOptions = Options{TagPrefix: "trip-", Event: "location"}
+
+// Category is a trip's spending in one top-level expense category and commodity
+type Category struct {
+	Account string // e.g. Expenses:Food
+	Amount  beancount.Amount
+}
+
+// Trip is the spending of one trip or event
+type Trip struct {
+	Name         string    // The tag with its #, or the event's value
+	Event        bool      // Whether an event marks the trip rather than a tag
+	Start, End   time.Time // First and last day
+	Transactions int
+	Categories   []Category // By commodity, then largest first
+}
+
+// Days returns how many days the trip spans, its first and last included
+func (t Trip) Days() int {
+	return int(t.End.Sub(t.Start)/(24*time.Hour)) + 1
+}
+
+// Totals returns the trip's spending in each commodity, in commodity order
+func (t Trip) Totals() []beancount.Amount {
+	var totals []beancount.Amount
+	for _, category := range t.Categories {
+		if len(totals) == 0 || totals[len(totals)-1].Commodity != category.Amount.Commodity {
+			totals = append(totals, beancount.Amount{Number: decimal.Zero, Commodity: category.Amount.Commodity})
+		}
+		last := &totals[len(totals)-1]
+		last.Number = last.Number.Add(category.Amount.Number)
+	}
+	return totals
+}
+
+// PerDay spreads an amount over the trip's days, to the cent
+func (t Trip) PerDay(amount decimal.Decimal) decimal.Decimal {
+	return amount.Div(decimal.NewFromInt(int64(t.Days()))).Round(2)
+}
+
+// Query returns the transaction filter showing the trip's transactions
+func (t Trip) Query() string {
+	if !t.Event {
+		return "tag:" + strings.TrimPrefix(t.Name, "#")
+	}
+	return "date:" + t.Start.Format("2006-01-02") + ".." + t.End.Format("2006-01-02")
+}
+
+// trip gathers a trip's spending as transactions are added to it
+type trip struct {
+	Trip
+	totals map[Category]decimal.Decimal // Keyed by account and commodity, amounts zero
+}
+
+// add counts a transaction's expenses; one without any isn't part of the trip
+func (t *trip) add(tx *beancount.Transaction) {
+	spent := false
+	for _, posting := range tx.ResolvedPostings() {
+		category, ok := category(posting.Account)
+		if !ok || posting.Amount == nil {
+			continue
+		}
+		k := Category{Account: category, Amount: beancount.Amount{Commodity: posting.Amount.Commodity}}
+		t.totals[k] = t.totals[k].Add(posting.Amount.Number)
+		spent = true
+	}
+	if !spent {
+		return
+	}
+	t.Transactions++
+	if t.Start.IsZero() || tx.Date.Before(t.Start) {
+		t.Start = tx.Date
+	}
+	if tx.Date.After(t.End) {
+		t.End = tx.Date
+	}
+}
+
+// Find returns the trips options choose, the most recent first; trips without any
+// expenses are left out
+func Find(transactions []*beancount.Transaction, events []beancount.Event, opts Options) []Trip {
+	var found []*trip
+	newTrip := func(name string, event bool) *trip {
+		t := &trip{Trip: Trip{Name: name, Event: event}, totals: make(map[Category]decimal.Decimal)}
+		found = append(found, t)
+		return t
+	}
+
+	if opts.TagPrefix != "" {
+		tagged := make(map[string]*trip)
+		for _, tx := range transactions {
+			for _, tag := range tx.Tags {
+				if !strings.HasPrefix(tag, opts.TagPrefix) {
+					continue
+				}
+				if tagged[tag] == nil {
+					tagged[tag] = newTrip("#"+tag, false)
+				}
+				tagged[tag].add(tx)
+			}
+		}
+	}
+
+	if opts.Event != "" {
+		var spans []beancount.Event
+		for _, event := range events {
+			if event.Type == opts.Event {
+				spans = append(spans, event)
+			}
+		}
+		sort.SliceStable(spans, func(i, j int) bool { return spans[i].Date.Before(spans[j].Date) })
+		for i, event := range spans {
+			// The value holds until the day the next event changes it
+			var until time.Time
+			if i+1 < len(spans) {
+				until = spans[i+1].Date
+			}
+			t := newTrip(event.Value, true)
+			for _, tx := range transactions {
+				if !tx.Date.Before(event.Date) && (until.IsZero() || tx.Date.Before(until)) {
+					t.add(tx)
+				}
+			}
+			// The span runs from the event, and to the day before the next one
+			t.Start = event.Date
+			if !until.IsZero() {
+				t.End = until.AddDate(0, 0, -1)
+			}
+		}
+	}
+
+	var result []Trip
+	for _, t := range found {
+		for k, total := range t.totals {
+			if !total.IsZero() {
+				k.Amount.Number = total
+				t.Categories = append(t.Categories, k)
+			}
+		}
+		sort.Slice(t.Categories, func(i, j int) bool {
+			a, b := t.Categories[i], t.Categories[j]
+			if a.Amount.Commodity != b.Amount.Commodity {
+				return a.Amount.Commodity < b.Amount.Commodity
+			}
+			if !a.Amount.Number.Equal(b.Amount.Number) {
+				return a.Amount.Number.GreaterThan(b.Amount.Number)
+			}
+			return a.Account < b.Account
+		})
+		if len(t.Categories) > 0 {
+			result = append(result, t.Trip)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].Start.Equal(result[j].Start) {
+			return result[i].Start.After(result[j].Start)
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// category returns the top-level expense category of an account, e.g. Expenses:Food
+// for Expenses:Food:Coffee
+func category(account string) (string, bool) {
+	parts := strings.SplitN(account, ":", 3)
+	if parts[0] != "Expenses" {
+		return "", false
+	}
+	if len(parts) == 1 {
+		return account, true
+	}
+	return parts[0] + ":" + parts[1], true
+}
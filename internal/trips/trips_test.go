@@ -0,0 +1,73 @@
+package trips
+
+import (
+	"testing"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/beancount/beancounttest"
+	"github.com/shopspring/decimal"
+)
+
+func ledger() []*beancount.Transaction {
+	return []*beancount.Transaction{
+		beancounttest.Tx("2024-03-01", "", "Expenses:Travel:Flights", "900 USD", "trip-japan"),
+		beancounttest.Tx("2024-03-10", "", "Expenses:Food:Restaurants", "8000 JPY", "trip-japan"),
+		beancounttest.Tx("2024-03-12", "", "Expenses:Food", "4000 JPY", "trip-japan"),
+		beancounttest.Tx("2024-03-14", "", "Expenses:Lodging", "30000 JPY", "trip-japan"),
+		beancounttest.Tx("2024-03-14", "", "Assets:Savings", "100 USD", "trip-japan"),
+		beancounttest.Tx("2024-03-20", "", "Expenses:Food", "60 USD", "groceries"),
+		beancounttest.Tx("2024-06-02", "", "Expenses:Food", "40 EUR"),
+		beancounttest.Tx("2024-06-05", "", "Expenses:Lodging", "300 EUR"),
+		beancounttest.Tx("2024-06-09", "", "Expenses:Food", "25 USD"),
+	}
+}
+
+func events() []beancount.Event {
+	return []beancount.Event{
+		{Date: beancounttest.Date("2024-06-08"), Type: "location", Value: "Boston"},
+		{Date: beancounttest.Date("2024-06-01"), Type: "location", Value: "Lisbon"},
+		{Date: beancounttest.Date("2024-06-01"), Type: "employer", Value: "Acme"},
+	}
+}
+
+func TestFind(t *testing.T) {
+	found := Find(ledger(), events(), DefaultOptions)
+	if len(found) != 3 || found[0].Name != "Boston" || found[1].Name != "Lisbon" || found[2].Name != "#trip-japan" {
+		t.Fatalf("unexpected trips %+v", found)
+	}
+
+	japan := found[2]
+	if japan.Event || japan.Days() != 14 || japan.Transactions != 4 || japan.Query() != "tag:trip-japan" {
+		t.Errorf("unexpected trip %+v over %d days", japan, japan.Days())
+	}
+	// Yen before dollars, largest first, food in one category
+	if len(japan.Categories) != 3 || japan.Categories[0].Account != "Expenses:Lodging" ||
+		japan.Categories[1].Account != "Expenses:Food" || !japan.Categories[1].Amount.Number.Equal(decimal.NewFromInt(12000)) {
+		t.Errorf("unexpected categories %+v", japan.Categories)
+	}
+	totals := japan.Totals()
+	if len(totals) != 2 || totals[0].Commodity != "JPY" || !totals[0].Number.Equal(decimal.NewFromInt(42000)) {
+		t.Errorf("unexpected totals %+v", totals)
+	}
+	if got := japan.PerDay(totals[0].Number); !got.Equal(decimal.NewFromInt(3000)) {
+		t.Errorf("got %s a day, want 3000", got)
+	}
+
+	// Lisbon holds until the day before Boston
+	lisbon := found[1]
+	if !lisbon.Event || lisbon.Start != beancounttest.Date("2024-06-01") || lisbon.End != beancounttest.Date("2024-06-07") || lisbon.Days() != 7 {
+		t.Errorf("unexpected span %s to %s", lisbon.Start, lisbon.End)
+	}
+	if lisbon.Query() != "date:2024-06-01..2024-06-07" || lisbon.Totals()[0].Number.String() != "340" {
+		t.Errorf("unexpected Lisbon %+v", lisbon)
+	}
+}
+
+func TestFindOptions(t *testing.T) {
+	if found := Find(ledger(), events(), Options{TagPrefix: "trip-"}); len(found) != 1 {
+		t.Errorf("expected only tagged trips, got %+v", found)
+	}
+	if found := Find(ledger(), events(), Options{Event: "employer"}); len(found) != 1 || found[0].Name != "Acme" || found[0].Transactions != 3 {
+		t.Errorf("expected the employer's span, got %+v", found)
+	}
+}
@@ -2,88 +2,266 @@ package accounts
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
 	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
 )
 
 // keyMap defines key bindings for the accounts view
 type keyMap struct {
-	Up     key.Binding
-	Down   key.Binding
-	Top    key.Binding
-	Bottom key.Binding
+	Up          key.Binding
+	Down        key.Binding
+	PageUp      key.Binding
+	PageDown    key.Binding
+	Top         key.Binding
+	Bottom      key.Binding
+	Toggle      key.Binding
+	Expand      key.Binding
+	Collapse    key.Binding
+	ExpandAll   key.Binding
+	CollapseAll key.Binding
+	Filter      key.Binding
+	Clear       key.Binding
 }
 
-func newKeyMap() keyMap {
+// newKeyMap creates the key bindings, taking navigation keys from the config
+func newKeyMap(cfg config.KeybindingsConfig) keyMap {
 	return keyMap{
-		Up: key.NewBinding(
-			key.WithKeys("up", "k"),
-			key.WithHelp("↑/k", "up"),
+		Up:       components.Binding(cfg.Up, "up"),
+		Down:     components.Binding(cfg.Down, "down"),
+		PageUp:   components.Binding(cfg.PageUp, "page up"),
+		PageDown: components.Binding(cfg.PageDown, "page down"),
+		Top:      components.Binding(cfg.Top, "top"),
+		Bottom:   components.Binding(cfg.Bottom, "bottom"),
+		Toggle:   components.Binding(cfg.Select, "expand/collapse"),
+		Expand: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "expand"),
 		),
-		Down: key.NewBinding(
-			key.WithKeys("down", "j"),
-			key.WithHelp("↓/j", "down"),
+		Collapse: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "collapse"),
 		),
-		Top: key.NewBinding(
-			key.WithKeys("home", "g"),
-			key.WithHelp("g/home", "top"),
+		ExpandAll: key.NewBinding(
+			key.WithKeys("+"),
+			key.WithHelp("+", "expand all"),
 		),
-		Bottom: key.NewBinding(
-			key.WithKeys("end", "G"),
-			key.WithHelp("G/end", "bottom"),
+		CollapseAll: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "collapse all"),
 		),
+		Filter: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "filter"),
+		),
+		Clear: components.Binding(cfg.Back, "clear filter"),
 	}
 }
 
+// FixedKeys returns the keys the view handles whatever the config binds, by what they do
+func FixedKeys() map[string]string {
+	return components.FixedKeys(newKeyMap(config.KeybindingsConfig{}))
+}
+
 // Model represents the accounts view model
 type Model struct {
-	file   *beancount.File
-	width  int
-	height int
+	file    *beancount.File
+	width   int
+	height  int
+	display components.Display
+
+	// Tree state
+	roots    []*beancount.AccountNode
+	rows     []*beancount.AccountNode              // visible nodes in display order
+	expanded map[string]bool                       // by account name, kept across reloads
+	balances map[string]map[string]decimal.Decimal // as of today, aggregated by account and ancestors
+	count    int
+	future   int // transactions dated after today, left out of the balances
+	err      string
 
 	// List state
-	cursor   int
-	accounts []string
-	keys     keyMap
+	list components.Scroller
+	keys keyMap
 
-	// Grouped accounts
-	assets      []string
-	liabilities []string
-	equity      []string
-	income      []string
-	expenses    []string
+	// Filter state
+	filtering bool
+	filter    components.TextInput
 }
 
 // New creates a new accounts model
-func New(file *beancount.File) Model {
-	accounts := file.GetAccounts()
-
+func New(file *beancount.File, keys config.KeybindingsConfig) Model {
 	m := Model{
 		file:     file,
-		cursor:   0,
-		accounts: accounts,
-		keys:     newKeyMap(),
+		expanded: make(map[string]bool),
+		keys:     newKeyMap(keys),
+		filter:   components.NewTextInput("/").SetWidth(40),
+	}
+	m.filter.Placeholder = "type to filter accounts"
+
+	m = m.build()
+	// Start with the account types open one level
+	for _, root := range m.roots {
+		m.expanded[root.Account] = true
+	}
+	return m.refreshRows()
+}
+
+// SetKeys rebinds the view's keys, e.g. after the config is reloaded
+func (m Model) SetKeys(keys config.KeybindingsConfig) Model {
+	m.keys = newKeyMap(keys)
+	return m
+}
+
+// Reload rebuilds the tree from the ledger, e.g. after an edit changed balances
+// Expansion, filter and the selected account are kept
+func (m Model) Reload() Model {
+	selected := m.selected()
+	m = m.build().refreshRows()
+	if selected != nil {
+		m = m.selectPath(selected.Account)
+	}
+	return m
+}
+
+// Editing reports whether the view is capturing keys (filter bar open)
+func (m Model) Editing() bool {
+	return m.filtering
+}
+
+// build constructs the account tree with balances as of today, aggregated by ancestor
+// Accounts only used by future-dated transactions still appear, with no balance.
+func (m Model) build() Model {
+	m.err = ""
+	transactions, err := m.file.AllTransactions()
+	if err != nil {
+		m.err = err.Error()
 	}
 
-	// Group accounts by type
-	for _, acc := range accounts {
-		if strings.HasPrefix(acc, "Assets:") {
-			m.assets = append(m.assets, acc)
-		} else if strings.HasPrefix(acc, "Liabilities:") {
-			m.liabilities = append(m.liabilities, acc)
-		} else if strings.HasPrefix(acc, "Equity:") {
-			m.equity = append(m.equity, acc)
-		} else if strings.HasPrefix(acc, "Income:") {
-			m.income = append(m.income, acc)
-		} else if strings.HasPrefix(acc, "Expenses:") {
-			m.expenses = append(m.expenses, acc)
+	accounts := m.file.GetAccounts()
+	m.count = len(accounts)
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var current []*beancount.Transaction
+	m.future = 0
+	for _, tx := range transactions {
+		for _, posting := range tx.Postings {
+			accounts = append(accounts, posting.Account)
 		}
+		if tx.Date.After(today) {
+			m.future++
+			continue
+		}
+		current = append(current, tx)
 	}
+	balances := beancount.AccountBalances(current)
 
+	m.roots = beancount.BuildAccountTree(accounts)
+	m.balances = beancount.RollUpBalances(balances)
+	return m
+}
+
+// refreshRows flattens the tree into the visible rows
+// While a filter is set, matching accounts and their ancestors are shown fully expanded
+func (m Model) refreshRows() Model {
+	query := strings.ToLower(strings.TrimSpace(m.filter.Value()))
+	m.rows = nil
+
+	var walk func(n *beancount.AccountNode) bool
+	walk = func(n *beancount.AccountNode) bool {
+		if query == "" {
+			m.rows = append(m.rows, n)
+			if m.expanded[n.Account] {
+				for _, child := range n.Children {
+					walk(child)
+				}
+			}
+			return true
+		}
+
+		// Add the node first so it precedes its children, then drop it if nothing matched
+		at := len(m.rows)
+		m.rows = append(m.rows, n)
+		matched := strings.Contains(strings.ToLower(n.Account), query)
+		for _, child := range n.Children {
+			if walk(child) {
+				matched = true
+			}
+		}
+		if !matched {
+			m.rows = m.rows[:at]
+		}
+		return matched
+	}
+	for _, root := range m.roots {
+		walk(root)
+	}
+
+	return m.scroll()
+}
+
+// selected returns the node under the cursor
+func (m Model) selected() *beancount.AccountNode {
+	if len(m.rows) == 0 {
+		return nil
+	}
+	return m.rows[m.list.Cursor()]
+}
+
+// selectPath moves the cursor to the row with the given account path, if visible
+func (m Model) selectPath(path string) Model {
+	for i, n := range m.rows {
+		if n.Account == path {
+			m.list = m.list.Select(i)
+			break
+		}
+	}
+	return m.scroll()
+}
+
+// listHeight returns how many rows fit below the title and column header
+// Until the view is sized it shows a page.
+func (m Model) listHeight() int {
+	if m.height == 0 {
+		return max(1, m.display.PageSize)
+	}
+	height := m.height - 3 + m.display.Spared(1)
+	if m.filtering || m.filter.Value() != "" {
+		height -= 2
+	}
+	return max(1, height)
+}
+
+// scroll keeps the cursor on a row and inside the visible window
+func (m Model) scroll() Model {
+	m.list = m.list.Fit(len(m.rows), m.listHeight())
+	return m
+}
+
+// setExpanded opens or closes every node in the tree
+func (m Model) setExpanded(open bool) Model {
+	var walk func(n *beancount.AccountNode)
+	walk = func(n *beancount.AccountNode) {
+		if len(n.Children) > 0 {
+			m.expanded[n.Account] = open
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	for _, root := range m.roots {
+		walk(root)
+	}
 	return m
 }
 
@@ -94,28 +272,138 @@ func (m Model) Init() tea.Cmd {
 
 // Update handles messages
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch {
-		case key.Matches(msg, m.keys.Up):
-			if m.cursor > 0 {
-				m.cursor--
-			}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.filtering {
+		return m.updateFilter(keyMsg), nil
+	}
+
+	selected := m.selected()
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		m.list = m.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		m.list = m.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		m.list = m.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		m.list = m.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		m.list = m.list.Top()
 
-		case key.Matches(msg, m.keys.Down):
-			if m.cursor < len(m.accounts)-1 {
-				m.cursor++
+	case key.Matches(keyMsg, m.keys.Bottom):
+		m.list = m.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.Toggle):
+		if selected != nil && len(selected.Children) > 0 {
+			m.expanded[selected.Account] = !m.expanded[selected.Account]
+			m = m.refreshRows()
+		}
+
+	case key.Matches(keyMsg, m.keys.Expand):
+		if selected != nil && len(selected.Children) > 0 {
+			m.expanded[selected.Account] = true
+			m = m.refreshRows()
+		}
+
+	case key.Matches(keyMsg, m.keys.Collapse):
+		// Close an open node, otherwise jump to its parent
+		if selected == nil {
+			break
+		}
+		if m.expanded[selected.Account] && len(selected.Children) > 0 {
+			m.expanded[selected.Account] = false
+			m = m.refreshRows()
+		} else if parent, _, found := cutLast(selected.Account); found {
+			m = m.selectPath(parent)
+		}
+
+	case key.Matches(keyMsg, m.keys.ExpandAll):
+		m = m.setExpanded(true).refreshRows()
+
+	case key.Matches(keyMsg, m.keys.CollapseAll):
+		m = m.setExpanded(false).refreshRows()
+		if selected != nil {
+			root, _, _ := strings.Cut(selected.Account, ":")
+			m = m.selectPath(root)
+		}
+
+	case key.Matches(keyMsg, m.keys.Filter):
+		m.filtering = true
+		m.filter = m.filter.Focus()
+
+	case key.Matches(keyMsg, m.keys.Clear):
+		if m.filter.Value() != "" {
+			m.filter = m.filter.SetValue("")
+			m = m.refreshRows()
+			if selected != nil {
+				m = m.selectPath(selected.Account)
 			}
+		}
+	}
 
-		case key.Matches(msg, m.keys.Top):
-			m.cursor = 0
+	return m.scroll(), nil
+}
 
-		case key.Matches(msg, m.keys.Bottom):
-			m.cursor = len(m.accounts) - 1
+// updateFilter handles keys while the filter bar is open
+// Enter keeps the filter, Esc clears it; the tree updates as the query is typed
+func (m Model) updateFilter(msg tea.KeyMsg) Model {
+	selected := m.selected()
+	switch msg.String() {
+	case "enter":
+		m.filtering = false
+		m.filter = m.filter.Blur()
+		return m.scroll()
+	case "esc":
+		m.filtering = false
+		m.filter = m.filter.SetValue("").Blur()
+	case "up", "down":
+		// Move through the matches without leaving the filter
+		if msg.String() == "up" {
+			m.list = m.list.Up()
+		} else {
+			m.list = m.list.Down()
+		}
+		return m.scroll()
+	default:
+		before := m.filter.Value()
+		m.filter, _ = m.filter.Update(msg)
+		if m.filter.Value() == before {
+			return m
+		}
+	}
+
+	m = m.refreshRows()
+	if selected != nil {
+		m = m.selectPath(selected.Account)
+	}
+	// Land on the first match rather than an ancestor shown for context
+	if query := strings.ToLower(m.filter.Value()); query != "" {
+		if current := m.selected(); current == nil || !strings.Contains(strings.ToLower(current.Account), query) {
+			for i, n := range m.rows {
+				if strings.Contains(strings.ToLower(n.Account), query) {
+					m.list = m.list.Select(i)
+					break
+				}
+			}
 		}
 	}
+	return m.scroll()
+}
 
-	return m, nil
+// cutLast splits an account path at its last separator
+func cutLast(path string) (string, string, bool) {
+	i := strings.LastIndex(path, ":")
+	if i < 0 {
+		return path, "", false
+	}
+	return path[:i], path[i+1:], true
 }
 
 // View renders the accounts view with TP7 styling
@@ -127,114 +415,165 @@ func (m Model) View() string {
 	var lines []string
 
 	// Title - fill full width
-	titleText := fmt.Sprintf("Accounts (%d total)", len(m.accounts))
-	titlePadded := titleText
-	if m.width > len(titleText) {
-		titlePadded = titleText + strings.Repeat(" ", m.width-len(titleText))
+	titleText := fmt.Sprintf("Accounts (%d total)", m.count)
+	if query := m.filter.Value(); query != "" {
+		titleText += " - filter: " + query
 	}
+	if m.future > 0 {
+		titleText += fmt.Sprintf(" - balances as of today, %d future transactions left out", m.future)
+	}
+	titlePadded := components.PadRight(titleText, m.width)
 	title := theme.TitleStyle.Width(m.width).Render(titlePadded)
 	lines = append(lines, title)
 
-	if len(m.accounts) == 0 {
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to compute balances: "+m.err))
+	}
+
+	if len(m.roots) == 0 {
 		lines = append(lines, theme.NormalTextStyle.Render("No accounts found"))
 		return strings.Join(lines, "\n")
 	}
 
-	// Render grouped accounts
-	currentIdx := 0
+	// Column header
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(m.formatRow("Account", "Balance")))
+	lines = append(lines, m.display.Rule(m.width)...)
 
-	if len(m.assets) > 0 {
-		lines = append(lines, "")
-		categoryLine := "Assets"
-		if m.width > len(categoryLine) {
-			categoryLine = categoryLine + strings.Repeat(" ", m.width-len(categoryLine))
-		}
-		lines = append(lines, theme.HighlightStyle.Width(m.width).Render(categoryLine))
-		// Add separator line
-		separator := strings.Repeat("─", m.width)
-		lines = append(lines, theme.MutedTextStyle.Width(m.width).Render(separator))
-		currentIdx = m.renderAccountGroup(m.assets, currentIdx, &lines)
+	if len(m.rows) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("No accounts match the filter"))
 	}
 
-	if len(m.liabilities) > 0 {
-		lines = append(lines, "")
-		categoryLine := "Liabilities"
-		if m.width > len(categoryLine) {
-			categoryLine = categoryLine + strings.Repeat(" ", m.width-len(categoryLine))
+	start, end := m.list.Window()
+	for i := start; i < end; i++ {
+		n := m.rows[i]
+		marker := "  "
+		if len(n.Children) > 0 {
+			marker = "▸ "
+			if m.expanded[n.Account] || m.filter.Value() != "" {
+				marker = "▾ "
+			}
 		}
-		lines = append(lines, theme.HighlightStyle.Width(m.width).Render(categoryLine))
-		separator := strings.Repeat("─", m.width)
-		lines = append(lines, theme.MutedTextStyle.Width(m.width).Render(separator))
-		currentIdx = m.renderAccountGroup(m.liabilities, currentIdx, &lines)
-	}
+		label := m.display.Indent(n.Depth) + marker + n.Name
 
-	if len(m.equity) > 0 {
-		lines = append(lines, "")
-		categoryLine := "Equity"
-		if m.width > len(categoryLine) {
-			categoryLine = categoryLine + strings.Repeat(" ", m.width-len(categoryLine))
+		switch {
+		case i == m.list.Cursor():
+			line := m.formatRow(label, m.formatBalance(m.balances[n.Account]))
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line))
+		case n.Depth == 0:
+			lines = append(lines, m.renderRow(label, m.balances[n.Account], theme.HighlightStyle))
+		default:
+			lines = append(lines, m.renderRow(label, m.balances[n.Account], theme.ListItemStyle))
 		}
-		lines = append(lines, theme.HighlightStyle.Width(m.width).Render(categoryLine))
-		separator := strings.Repeat("─", m.width)
-		lines = append(lines, theme.MutedTextStyle.Width(m.width).Render(separator))
-		currentIdx = m.renderAccountGroup(m.equity, currentIdx, &lines)
 	}
 
-	if len(m.income) > 0 {
+	// Filter bar
+	if m.filtering || m.filter.Value() != "" {
 		lines = append(lines, "")
-		categoryLine := "Income"
-		if m.width > len(categoryLine) {
-			categoryLine = categoryLine + strings.Repeat(" ", m.width-len(categoryLine))
+		lines = append(lines, m.filter.View())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// formatRow lays out an account label with its balance right-aligned
+func (m Model) formatRow(label, balance string) string {
+	label = " " + label
+	gap := m.width - components.Width(label) - components.Width(balance) - 1
+	if gap < 1 {
+		// Too narrow: cut the label so the balance stays visible
+		room := max(0, m.width-components.Width(balance)-2)
+		label = components.Truncate(label, room, "")
+		gap = max(1, m.width-components.Width(label)-components.Width(balance)-1)
+	}
+	return label + strings.Repeat(" ", gap) + balance + " "
+}
+
+// renderRow renders an unselected row in style, with negative amounts in
+// AmountNegativeStyle; the selected row is rendered whole so the cursor bar is unbroken
+func (m Model) renderRow(label string, balance map[string]decimal.Decimal, style lipgloss.Style) string {
+	commodities := balanceCommodities(balance)
+	amounts := make([]string, len(commodities))
+	for i, commodity := range commodities {
+		amounts[i] = m.display.Amount(balance[commodity], commodity)
+	}
+	// The laid out row ends with the amounts and a space; style them separately
+	text := strings.Join(amounts, balanceSeparator)
+	prefix := strings.TrimSuffix(m.formatRow(label, text), text+" ")
+
+	var b strings.Builder
+	b.WriteString(style.Render(prefix))
+	for i, commodity := range commodities {
+		if i > 0 {
+			b.WriteString(style.Render(balanceSeparator))
+		}
+		amountStyle := style
+		if balance[commodity].IsNegative() {
+			amountStyle = theme.AmountNegativeStyle.Bold(style.GetBold())
 		}
-		lines = append(lines, theme.HighlightStyle.Width(m.width).Render(categoryLine))
-		separator := strings.Repeat("─", m.width)
-		lines = append(lines, theme.MutedTextStyle.Width(m.width).Render(separator))
-		currentIdx = m.renderAccountGroup(m.income, currentIdx, &lines)
+		b.WriteString(amountStyle.Render(amounts[i]))
 	}
+	b.WriteString(style.Render(" "))
+	return b.String()
+}
 
-	if len(m.expenses) > 0 {
-		lines = append(lines, "")
-		categoryLine := "Expenses"
-		if m.width > len(categoryLine) {
-			categoryLine = categoryLine + strings.Repeat(" ", m.width-len(categoryLine))
+// balanceSeparator goes between the amounts of a balance in several commodities
+const balanceSeparator = "  "
+
+// balanceCommodities returns the commodities of a balance that are not zero, sorted
+func balanceCommodities(balance map[string]decimal.Decimal) []string {
+	commodities := make([]string, 0, len(balance))
+	for commodity, number := range balance {
+		if !number.IsZero() {
+			commodities = append(commodities, commodity)
 		}
-		lines = append(lines, theme.HighlightStyle.Width(m.width).Render(categoryLine))
-		separator := strings.Repeat("─", m.width)
-		lines = append(lines, theme.MutedTextStyle.Width(m.width).Render(separator))
-		currentIdx = m.renderAccountGroup(m.expenses, currentIdx, &lines)
 	}
+	sort.Strings(commodities)
+	return commodities
+}
 
-	return strings.Join(lines, "\n")
+// formatBalance renders per-commodity amounts, sorted by commodity
+func (m Model) formatBalance(balance map[string]decimal.Decimal) string {
+	commodities := balanceCommodities(balance)
+	parts := make([]string, len(commodities))
+	for i, commodity := range commodities {
+		parts[i] = m.display.Amount(balance[commodity], commodity)
+	}
+	return strings.Join(parts, balanceSeparator)
 }
 
 // SetSize updates the accounts view size
 func (m Model) SetSize(width, height int) Model {
 	m.width = width
 	m.height = height
-	return m
+	m.filter = m.filter.SetWidth(max(20, min(60, width-4)))
+	return m.scroll()
 }
 
-// renderAccountGroup renders a group of accounts with TP7 styling
-func (m Model) renderAccountGroup(accounts []string, startIdx int, lines *[]string) int {
-	idx := startIdx
-	for _, acc := range accounts {
-		var line string
-		if idx == m.cursor {
-			line = "  > " + acc
-			// Pad to full width
-			if m.width > len(line) {
-				line = line + strings.Repeat(" ", m.width-len(line))
-			}
-			*lines = append(*lines, theme.SelectedItemStyle.Width(m.width).Render(line))
-		} else {
-			line = "    " + acc
-			// Pad to full width
-			if m.width > len(line) {
-				line = line + strings.Repeat(" ", m.width-len(line))
-			}
-			*lines = append(*lines, theme.ListItemStyle.Width(m.width).Render(line))
-		}
-		idx++
+// SetDisplay updates the accounts view display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m.SetSize(m.width, m.height)
+}
+
+// ShortHelp returns the view's main key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	k := m.keys
+	return []key.Binding{k.Toggle, k.ExpandAll, k.CollapseAll, k.Filter}
+}
+
+// FullHelp returns all of the view's key bindings in columns, for the help row
+func (m Model) FullHelp() [][]key.Binding {
+	k := m.keys
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Top, k.Bottom},
+		{k.Toggle, k.Expand, k.Collapse},
+		{k.ExpandAll, k.CollapseAll},
+		{k.Filter, k.Clear},
 	}
-	return idx
+}
+
+// KeyBindings returns the view's key bindings, for the help overlay
+func (m Model) KeyBindings() []key.Binding {
+	return slices.Concat(m.FullHelp()...)
 }
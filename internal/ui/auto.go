@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/confirm"
+)
+
+// autoCandidatesMsg carries the result of the background auto-categorize scan
+type autoCandidatesMsg struct {
+	candidates []categorizer.AutoCandidate
+	err        error
+}
+
+// findAutoCandidatesCmd scans the ledger for confident suggestions in the background
+// The transactions are read before the command starts, on the UI goroutine, as for
+// similarity training.
+func findAutoCandidatesCmd(file *beancount.File, cat *categorizer.Categorizer) tea.Cmd {
+	transactions, err := file.AllTransactions()
+	return func() tea.Msg {
+		if err != nil {
+			return autoCandidatesMsg{err: err}
+		}
+		candidates, err := cat.AutoCandidates(transactions)
+		return autoCandidatesMsg{candidates: candidates, err: err}
+	}
+}
+
+// handleAutoCandidates writes or stages the scan results depending on the configured mode
+func (m Model) handleAutoCandidates(msg autoCandidatesMsg) Model {
+	if msg.err != nil {
+		return m.notifyf(components.LevelError, "Auto-categorize failed: %v", msg.err)
+	}
+	if len(msg.candidates) == 0 {
+		return m
+	}
+
+	if m.config.Categorization.AutoMode == categorizer.AutoModeWrite {
+		return m.applyAutoCandidates(msg.candidates)
+	}
+
+	m.staged = msg.candidates
+	m.transactions = m.transactions.SetStaged(m.staged)
+	m.recordAudit(msg.candidates, categorizer.AuditStaged)
+	return m.notifyf(components.LevelInfo, "%d suggestions staged (A: apply all)", len(m.staged))
+}
+
+// autoAppliedMsg reports the outcome of writing auto-categorize candidates
+type autoAppliedMsg struct {
+	batch   editBatch
+	applied []categorizer.AutoCandidate
+	err     error
+}
+
+// autoBatch builds the edits for candidates, skipping those that no longer apply
+func (m Model) autoBatch(candidates []categorizer.AutoCandidate) (editBatch, []categorizer.AutoCandidate) {
+	var batch editBatch
+	var applied []categorizer.AutoCandidate
+	for _, candidate := range candidates {
+		edit, err := m.writer.SetPostingAccount(candidate.Transaction, candidate.PostingIndex, candidate.Suggestion.Category)
+		if err != nil {
+			continue
+		}
+		batch.edits = append(batch.edits, edit)
+		applied = append(applied, candidate)
+	}
+	return batch, applied
+}
+
+// applyAutoCandidates writes candidates to the ledger as one undoable batch
+// This is the unattended write mode, so nothing is confirmed
+func (m Model) applyAutoCandidates(candidates []categorizer.AutoCandidate) Model {
+	batch, applied := m.autoBatch(candidates)
+	return m.autoApplied(autoAppliedMsg{batch: batch, applied: applied, err: m.writer.ApplyAll(batch.edits)})
+}
+
+// confirmAutoCandidates asks to write staged candidates as one undoable batch
+func (m Model) confirmAutoCandidates(candidates []categorizer.AutoCandidate) (tea.Model, tea.Cmd) {
+	batch, applied := m.autoBatch(candidates)
+	return m.requestWrite(confirm.RequestMsg{
+		Title: fmt.Sprintf("Apply %d staged suggestions", len(applied)),
+		Edits: batch.edits,
+		Done: func(err error) tea.Msg {
+			return autoAppliedMsg{batch: batch, applied: applied, err: err}
+		},
+	})
+}
+
+// autoApplied journals and audits written candidates and clears the staged ones
+func (m Model) autoApplied(msg autoAppliedMsg) Model {
+	if msg.err != nil {
+		return m.notifyf(components.LevelError, "Auto-categorize failed: %v", msg.err)
+	}
+
+	batch := msg.batch
+	for _, candidate := range msg.applied {
+		batch.entries = append(batch.entries, categorizer.NewAuditEntry(candidate, categorizer.AuditApplied))
+	}
+	m.recordAuditEntries(batch.entries)
+
+	batch.description = fmt.Sprintf("%d automatic categorizations", len(batch.edits))
+	m.journal.record(batch)
+	m.staged = nil
+	m.transactions = m.transactions.SetStaged(nil)
+	return m.notifyf(components.LevelSuccess, "Auto-categorized %d transactions (u: undo)", len(batch.edits))
+}
+
+// recordAudit logs candidates to the audit trail under the given action
+func (m Model) recordAudit(candidates []categorizer.AutoCandidate, action string) {
+	entries := make([]categorizer.AuditEntry, len(candidates))
+	for i, candidate := range candidates {
+		entries[i] = categorizer.NewAuditEntry(candidate, action)
+	}
+	m.recordAuditEntries(entries)
+}
+
+// recordAuditEntries appends entries to the audit log if one is configured
+// Audit failures are non-fatal; the ledger edit has already happened
+func (m Model) recordAuditEntries(entries []categorizer.AuditEntry) {
+	if m.audit == nil {
+		return
+	}
+	_ = m.audit.Record(entries...)
+}
@@ -0,0 +1,387 @@
+package budgets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/budget"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// warnProgress is the share of a budget at which its bar turns yellow
+const warnProgress = 0.9
+
+// keyMap defines key bindings for the budgets view
+type keyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	PageUp    key.Binding
+	PageDown  key.Binding
+	Top       key.Binding
+	Bottom    key.Binding
+	Month     key.Binding
+	Quarter   key.Binding
+	Year      key.Binding
+	PrevPer   key.Binding
+	NextPer   key.Binding
+	DrillDown key.Binding
+}
+
+// newKeyMap creates the key bindings, taking navigation keys from the config
+func newKeyMap(cfg config.KeybindingsConfig) keyMap {
+	return keyMap{
+		Up:       components.Binding(cfg.Up, "up"),
+		Down:     components.Binding(cfg.Down, "down"),
+		PageUp:   components.Binding(cfg.PageUp, "page up"),
+		PageDown: components.Binding(cfg.PageDown, "page down"),
+		Top:      components.Binding(cfg.Top, "top"),
+		Bottom:   components.Binding(cfg.Bottom, "bottom"),
+		Month: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "month"),
+		),
+		Quarter: key.NewBinding(
+			key.WithKeys("Q"),
+			key.WithHelp("Q", "quarter"),
+		),
+		Year: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "year"),
+		),
+		PrevPer: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "previous period"),
+		),
+		NextPer: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next period"),
+		),
+		DrillDown: components.Binding(cfg.Select, "transactions"),
+	}
+}
+
+// FixedKeys returns the keys the view handles whatever the config binds, by what they do
+func FixedKeys() map[string]string {
+	return components.FixedKeys(newKeyMap(config.KeybindingsConfig{}))
+}
+
+// DrillDownMsg asks the root model to show a budgeted account's transactions for a period
+type DrillDownMsg struct {
+	Account string
+	Period  beancount.Period
+}
+
+// Model represents the budgets view
+type Model struct {
+	file        *beancount.File
+	budgetsFile string
+	width       int
+	height      int
+	display     components.Display
+	keys        keyMap
+
+	// Budgets and where they came from
+	set    budget.Set
+	source string
+	err    string
+
+	transactions []*beancount.Transaction
+	period       beancount.Period
+	lines        []budget.Line
+
+	// List state
+	list components.Scroller
+}
+
+// New creates a new budgets model
+// Budgets come from the ledger's custom "budget" directives, or budgetsFile if it has none
+func New(file *beancount.File, budgetsFile string, keys config.KeybindingsConfig) Model {
+	m := Model{
+		file:        file,
+		budgetsFile: budgetsFile,
+		keys:        newKeyMap(keys),
+	}
+	m = m.load()
+
+	// Open on the month of the latest transaction
+	latest := time.Now()
+	if len(m.transactions) > 0 {
+		latest = time.Time{}
+		for _, tx := range m.transactions {
+			if tx.Date.After(latest) {
+				latest = tx.Date
+			}
+		}
+	}
+	return m.SetPeriod(beancount.PeriodContaining(beancount.PeriodMonth, latest))
+}
+
+// SetKeys rebinds the view's keys, e.g. after the config is reloaded
+func (m Model) SetKeys(keys config.KeybindingsConfig) Model {
+	m.keys = newKeyMap(keys)
+	return m
+}
+
+// Reload re-reads budgets and transactions, keeping the period and selection
+func (m Model) Reload() Model {
+	return m.load().refresh()
+}
+
+// load reads the budgets and every transaction
+func (m Model) load() Model {
+	m.err = ""
+	budgets, err := budget.FromDirectives(m.file.GetCustomDirectives())
+	m.source = "ledger"
+	if err == nil && len(budgets) == 0 && m.budgetsFile != "" {
+		budgets, err = budget.LoadFile(m.budgetsFile)
+		m.source = m.budgetsFile
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+	}
+	if err != nil {
+		m.err = err.Error()
+	}
+	m.set = budget.NewSet(budgets)
+
+	transactions, err := m.file.AllTransactions()
+	if err != nil {
+		m.err = err.Error()
+	}
+	m.transactions = transactions
+	return m
+}
+
+// SetPeriod changes the budgeted period and recomputes actuals
+func (m Model) SetPeriod(period beancount.Period) Model {
+	m.period = period
+	return m.refresh()
+}
+
+// refresh compares budgets with spending for the period
+func (m Model) refresh() Model {
+	m.lines = budget.Compare(m.set, m.transactions, m.period)
+	return m.scroll()
+}
+
+// listHeight returns how many budget rows fit below the title and header
+func (m Model) listHeight() int {
+	return max(1, m.height-8+m.display.Spared(2))
+}
+
+// scroll keeps the cursor on a budget and inside the visible window
+func (m Model) scroll() Model {
+	m.list = m.list.Fit(len(m.lines), m.listHeight())
+	return m
+}
+
+// Init initializes the budgets view
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		m.list = m.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		m.list = m.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		m.list = m.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		m.list = m.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		m.list = m.list.Top()
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		m.list = m.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.Month):
+		m = m.SetPeriod(beancount.PeriodContaining(beancount.PeriodMonth, m.period.Start))
+
+	case key.Matches(keyMsg, m.keys.Quarter):
+		m = m.SetPeriod(beancount.PeriodContaining(beancount.PeriodQuarter, m.period.Start))
+
+	case key.Matches(keyMsg, m.keys.Year):
+		m = m.SetPeriod(beancount.PeriodContaining(beancount.PeriodYear, m.period.Start))
+
+	case key.Matches(keyMsg, m.keys.PrevPer):
+		m = m.SetPeriod(m.period.Prev())
+
+	case key.Matches(keyMsg, m.keys.NextPer):
+		m = m.SetPeriod(m.period.Next())
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		if len(m.lines) > 0 {
+			msg := DrillDownMsg{Account: m.lines[m.list.Cursor()].Account, Period: m.period}
+			return m, func() tea.Msg { return msg }
+		}
+	}
+
+	return m.scroll(), nil
+}
+
+// View renders the budgets with progress bars; overspent budgets are red
+func (m Model) View() string {
+	if m.width == 0 {
+		return theme.NormalTextStyle.Render("Loading budgets...")
+	}
+
+	var lines []string
+
+	// Title - fill full width
+	titleText := fmt.Sprintf("Budgets - %s ([ ])", m.period)
+	titlePadded := components.PadRight(titleText, m.width)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(titlePadded))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to load budgets: "+m.err))
+	}
+
+	if m.set.Empty() {
+		lines = append(lines, "")
+		lines = append(lines, theme.NormalTextStyle.Render(` No budgets found. Add directives to the ledger, e.g.`))
+		lines = append(lines, theme.MutedTextStyle.Render(`   2024-01-01 custom "budget" Expenses:Food "monthly" 400.00 USD`))
+		if m.budgetsFile != "" {
+			lines = append(lines, theme.NormalTextStyle.Render(" or list them in "+m.budgetsFile))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if len(m.lines) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("No budgets apply to "+m.period.String()))
+		return strings.Join(lines, "\n")
+	}
+
+	labelWidth := 10
+	for _, line := range m.lines {
+		labelWidth = max(labelWidth, components.Width(budgetLabel(line.Account)))
+	}
+	labelWidth = min(labelWidth, 28)
+	barWidth := max(6, m.width-labelWidth-3*12-10)
+
+	header := fmt.Sprintf(" %-*s %11s %11s %11s  %s", labelWidth, "Account", "Budget", "Spent", "Left", "Progress")
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(header))
+	lines = append(lines, m.display.Rule(m.width)...)
+
+	start, end := m.list.Window()
+	for i := start; i < end; i++ {
+		lines = append(lines, m.renderLine(m.lines[i], labelWidth, barWidth, i == m.list.Cursor()))
+	}
+
+	// Totals per commodity
+	budgets, actuals := make(map[string]decimal.Decimal), make(map[string]decimal.Decimal)
+	var commodities []string
+	for _, line := range m.lines {
+		if _, ok := budgets[line.Commodity]; !ok {
+			commodities = append(commodities, line.Commodity)
+		}
+		budgets[line.Commodity] = budgets[line.Commodity].Add(line.Budget)
+		actuals[line.Commodity] = actuals[line.Commodity].Add(line.Actual)
+	}
+	lines = append(lines, m.display.Rule(m.width)...)
+	for _, commodity := range commodities {
+		total := budget.Line{Account: "Total " + commodity, Commodity: commodity, Budget: budgets[commodity], Actual: actuals[commodity]}
+		lines = append(lines, m.renderLine(total, labelWidth, barWidth, false))
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, theme.MutedTextStyle.Render(" Budgets from "+m.source))
+
+	return strings.Join(lines, "\n")
+}
+
+// renderLine renders a budget row: amounts, then a bar coloured by progress
+func (m Model) renderLine(line budget.Line, labelWidth, barWidth int, selected bool) string {
+	label := components.Fit(budgetLabel(line.Account), labelWidth, "…")
+	progress := line.Progress()
+	amounts := fmt.Sprintf(" %s %s %s %s  ", label,
+		components.PadLeft(m.display.Number(line.Budget, line.Commodity), 11),
+		components.PadLeft(m.display.Number(line.Actual, line.Commodity), 11),
+		components.PadLeft(m.display.Number(line.Remaining(), line.Commodity), 11))
+	bar := components.RenderBar(progress, barWidth)
+	rest := strings.Repeat(" ", max(0, barWidth-components.Width(bar))) + fmt.Sprintf(" %4s%%", decimal.NewFromFloat(progress*100).StringFixed(0))
+
+	if selected {
+		return theme.SelectedItemStyle.Width(m.width).Render(amounts + bar + rest)
+	}
+
+	var barStyle lipgloss.Style
+	switch {
+	case line.Overspent():
+		barStyle = theme.ErrorStyle
+	case progress >= warnProgress:
+		barStyle = theme.WarningStyle
+	default:
+		barStyle = theme.SuccessStyle
+	}
+	amountStyle := theme.NormalTextStyle
+	if line.Overspent() {
+		amountStyle = theme.ErrorStyle
+	}
+	return amountStyle.Render(amounts) + barStyle.Render(bar) + amountStyle.Render(rest)
+}
+
+// budgetLabel drops the Expenses: prefix for display
+func budgetLabel(account string) string {
+	if label, ok := strings.CutPrefix(account, "Expenses:"); ok {
+		return label
+	}
+	return account
+}
+
+// SetSize updates the budgets view size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m.scroll()
+}
+
+// SetDisplay updates the budgets view display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m.SetSize(m.width, m.height)
+}
+
+// ShortHelp returns the view's main key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	k := m.keys
+	return []key.Binding{k.Month, k.PrevPer, k.NextPer, k.DrillDown}
+}
+
+// FullHelp returns all of the view's key bindings in columns, for the help row
+func (m Model) FullHelp() [][]key.Binding {
+	k := m.keys
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Top, k.Bottom},
+		{k.Month, k.Quarter, k.Year},
+		{k.PrevPer, k.NextPer, k.DrillDown},
+	}
+}
+
+// KeyBindings returns the view's key bindings, for the help overlay
+func (m Model) KeyBindings() []key.Binding {
+	return slices.Concat(m.FullHelp()...)
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/clipboard"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/transactions"
+)
+
+// copyToClipboard puts text on the system clipboard; tests replace it
+var copyToClipboard = clipboard.Copy
+
+// clipboardCopiedMsg reports the outcome of a copy to the clipboard
+type clipboardCopiedMsg struct {
+	what string
+	err  error
+}
+
+// copyCmd copies text to the clipboard in the background, as it may run a platform tool
+func copyCmd(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		return clipboardCopiedMsg{what: what, err: copyToClipboard(text)}
+	}
+}
+
+// handleCopyRequest copies the part of a transaction a view asked for
+func (m Model) handleCopyRequest(msg transactions.CopyRequestedMsg) (Model, tea.Cmd) {
+	if msg.Err != nil {
+		return m.notifyf(components.LevelError, "Copy failed: %v", msg.Err), nil
+	}
+	return m, copyCmd(msg.What, msg.Text)
+}
+
+// handleClipboardCopied reports a finished copy in the status bar
+func (m Model) handleClipboardCopied(msg clipboardCopiedMsg) Model {
+	if msg.err != nil {
+		return m.notifyf(components.LevelError, "Copy failed: %v", msg.err)
+	}
+	return m.notifyf(components.LevelSuccess, "Copied %s to the clipboard", msg.what)
+}
@@ -3,7 +3,8 @@ package ui
 import (
 	"strings"
 
-	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/mmichie/lima/internal/ui/components"
 	"github.com/mmichie/lima/internal/ui/theme"
 )
@@ -13,27 +14,38 @@ func renderHeader(menuBar components.MenuBar) string {
 	return menuBar.View()
 }
 
-// renderFooter renders the TP7-style status bar based on current view
-func renderFooter(currentView ViewType, statusBar components.StatusBar) string {
-	// Set context-specific status bar items based on view
-	var items []components.StatusBarItem
-	switch currentView {
-	case DashboardView:
-		items = components.DashboardStatusBar()
-	case TransactionsView:
-		items = components.TransactionsStatusBar()
-	case AccountsView:
-		items = components.AccountsStatusBar()
-	case ReportsView:
-		items = components.ReportsStatusBar()
-	default:
-		items = components.DashboardStatusBar()
+// renderFooter renders the TP7-style status bar with the given key hints
+// A non-empty message is shown on the right side of the bar
+func renderFooter(statusBar components.StatusBar, bindings []key.Binding, message string) string {
+	statusBar = statusBar.SetBindings(bindings)
+	if message != "" {
+		return statusBar.RenderWithMessage(message)
 	}
-
-	statusBar = statusBar.SetItems(items)
 	return statusBar.View()
 }
 
+// overlayDropdown draws the menu bar's open dropdown over the top of the content
+func overlayDropdown(content string, menuBar components.MenuBar) string {
+	dropdown, column := menuBar.Dropdown()
+	if dropdown == "" {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, row := range strings.Split(dropdown, "\n") {
+		if i >= len(lines) {
+			lines = append(lines, "")
+		}
+		left := ansi.Truncate(lines[i], column, "")
+		if pad := column - ansi.StringWidth(left); pad > 0 {
+			left += strings.Repeat(" ", pad)
+		}
+		right := ansi.TruncateLeft(lines[i], column+ansi.StringWidth(row), "")
+		lines[i] = left + row + right
+	}
+	return strings.Join(lines, "\n")
+}
+
 // formatAmount formats a decimal amount with commodity using TP7 theme
 func formatAmount(amount string, commodity string) string {
 	amountStyle := theme.AmountPositiveStyle
@@ -53,23 +65,9 @@ func formatAccount(account string) string {
 	return theme.NormalTextStyle.Render(account)
 }
 
-// renderFullScreenContent fills the content area with TP7 blue background
+// renderFullScreenContent fills the content area with the theme's background
 func renderFullScreenContent(content string, width, height int) string {
-	// Create a style that fills the entire content area with blue background
-	fullScreenStyle := lipgloss.NewStyle().
-		Background(lipgloss.Color(theme.TP7Blue)).
-		Width(width).
-		Height(height)
-
-	return fullScreenStyle.Render(content)
-}
-
-// renderReportsPlaceholder renders a placeholder for the reports view
-func renderReportsPlaceholder() string {
-	title := theme.TitleStyle.Render("Reports")
-	message := theme.NormalTextStyle.Render("\n  Reports view coming soon...")
-
-	return title + message
+	return theme.ScreenStyle.Width(width).Height(height).Render(content)
 }
 
 // renderLoadingScreen renders a TP7-styled loading screen
@@ -0,0 +1,110 @@
+package components
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Completer returns the completions of an input's value, best first
+// Each completion is a whole new value for the input.
+type Completer func(value string) []string
+
+// Match quality tiers, best first
+const (
+	matchPrefix    = iota // Candidate starts with the query
+	matchWordStart        // A word of the candidate starts with the query
+	matchSubstring        // Query appears inside the candidate
+	matchFuzzy            // Query's characters appear in order
+)
+
+// FuzzyMatch returns the candidates matching query, best match first
+// Candidates are expected most used first: within a tier of match quality (prefix,
+// word start, substring, then characters in order) they keep that order. Matching is
+// case-insensitive and a candidate equal to the query is not a completion.
+func FuzzyMatch(query string, candidates []string) []string {
+	query = strings.ToLower(query)
+	if query == "" {
+		return nil
+	}
+
+	type match struct {
+		candidate string
+		tier      int
+	}
+	var matches []match
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		if lower == query {
+			continue
+		}
+		if tier, ok := matchTier(lower, query); ok {
+			matches = append(matches, match{candidate, tier})
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].tier < matches[j].tier
+	})
+
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.candidate
+	}
+	return result
+}
+
+// matchTier rates how a lower-cased candidate matches a lower-cased query
+func matchTier(candidate, query string) (int, bool) {
+	if strings.HasPrefix(candidate, query) {
+		return matchPrefix, true
+	}
+	if i := strings.Index(candidate, query); i >= 0 {
+		// Words are separated by spaces and punctuation, e.g. the parts of an account
+		for ; i >= 0; i = nextIndex(candidate, query, i) {
+			before := []rune(candidate[:i])
+			if last := before[len(before)-1]; !unicode.IsLetter(last) && !unicode.IsDigit(last) {
+				return matchWordStart, true
+			}
+		}
+		return matchSubstring, true
+	}
+
+	rest := []rune(candidate)
+	for _, r := range query {
+		i := indexRune(rest, r)
+		if i < 0 {
+			return 0, false
+		}
+		rest = rest[i+1:]
+	}
+	return matchFuzzy, true
+}
+
+// nextIndex returns the next occurrence of query in s after the one at i, or -1
+func nextIndex(s, query string, i int) int {
+	j := strings.Index(s[i+1:], query)
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
+
+// indexRune returns the position of r in runes, or -1
+func indexRune(runes []rune, r rune) int {
+	for i, c := range runes {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// FuzzyCompleter completes against candidates listed most used first
+func FuzzyCompleter(candidates []string) Completer {
+	return func(value string) []string {
+		return FuzzyMatch(value, candidates)
+	}
+}
@@ -0,0 +1,39 @@
+package components
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	// Most used first
+	candidates := []string{
+		"Expenses:Food:Groceries",
+		"Assets:Checking",
+		"Expenses:Food:Coffee",
+		"Expenses:Transport",
+		"Expenses:Coffee Gear",
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Prefix matches keep their order of use
+		{"exp", []string{"Expenses:Food:Groceries", "Expenses:Food:Coffee", "Expenses:Transport", "Expenses:Coffee Gear"}},
+		// Word starts rank above other substrings, then characters in order
+		{"co", []string{"Expenses:Food:Coffee", "Expenses:Coffee Gear"}},
+		{"ck", []string{"Assets:Checking"}},
+		{"eff", []string{"Expenses:Food:Coffee", "Expenses:Coffee Gear"}},
+		{"efc", []string{"Expenses:Food:Groceries", "Expenses:Food:Coffee"}},
+		{"CHECK", []string{"Assets:Checking"}},
+		// The exact value is not a completion
+		{"assets:checking", nil},
+		{"xyz", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := FuzzyMatch(tt.query, candidates); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FuzzyMatch(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
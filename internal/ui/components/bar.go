@@ -0,0 +1,48 @@
+package components
+
+import (
+	"math"
+	"strings"
+)
+
+// barEighths are the partial block characters for fractional bar ends
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// RenderBar draws a horizontal bar of fraction*width cells with eighth-cell precision
+// The fraction is clamped to [0, 1]; any positive fraction shows at least a sliver
+func RenderBar(fraction float64, width int) string {
+	fraction = max(0, min(1, fraction))
+	eighths := int(fraction*float64(width*8) + 0.5)
+	bar := strings.Repeat("█", eighths/8) + barEighths[eighths%8]
+	if bar == "" && fraction > 0 {
+		bar = barEighths[1]
+	}
+	return bar
+}
+
+// sparkLevels are the sparkline cells from the lowest value to the highest
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// RenderSparkline draws one cell per value, scaled from the lowest to the highest
+// NaN values, such as months without data, are left blank; equal values sit midway.
+func RenderSparkline(values []float64) string {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			low, high = min(low, v), max(high, v)
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v):
+			b.WriteRune(' ')
+		case high == low:
+			b.WriteRune(sparkLevels[len(sparkLevels)/2-1])
+		default:
+			level := int((v-low)/(high-low)*float64(len(sparkLevels)-1) + 0.5)
+			b.WriteRune(sparkLevels[level])
+		}
+	}
+	return b.String()
+}
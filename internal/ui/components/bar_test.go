@@ -0,0 +1,23 @@
+package components
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRenderSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   string
+	}{
+		{[]float64{0, 7, 14}, "▁▅█"},
+		{[]float64{-10, math.NaN(), 10}, "▁ █"},
+		{[]float64{5, 5}, "▄▄"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := RenderSparkline(tt.values); got != tt.want {
+			t.Errorf("RenderSparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/mmichie/lima/internal/ui/format"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// Display is how views render amounts and dates, how dense their layout is and how
+// far their lists page
+// The root model builds it from the config and hands it to every view, again when
+// the config is reloaded or the layout or page size is changed at runtime; views
+// given none render with the zero Display, the defaults.
+type Display struct {
+	format.Formatter
+	Compact  bool // No separator or spacer lines, abbreviated account names and less padding between columns
+	PageSize int  // Rows PageUp and PageDown move, 0 for a window's height
+}
+
+// NewDisplay creates the display the config describes
+func NewDisplay(cfg *config.Config) Display {
+	return Display{
+		Formatter: format.New(cfg.Amounts, cfg.UI.DateFormat),
+		Compact:   cfg.UI.CompactMode,
+		PageSize:  max(0, cfg.UI.PageSize),
+	}
+}
+
+// Spared returns how many of a view's lines the compact layout leaves out, given how
+// many separator and spacer lines the standard layout has, for sizing lists
+func (d Display) Spared(lines int) int {
+	if d.Compact {
+		return lines
+	}
+	return 0
+}
+
+// Rule returns a full-width separator line, or none in the compact layout
+func (d Display) Rule(width int) []string {
+	if d.Compact {
+		return nil
+	}
+	return []string{theme.MutedTextStyle.Width(width).Render(strings.Repeat("─", width))}
+}
+
+// ShortAccount abbreviates an account's top-level name to two letters in the compact
+// layout, e.g. Ex:Food:Dining for Expenses:Food:Dining
+func (d Display) ShortAccount(account string) string {
+	root, rest, found := strings.Cut(account, ":")
+	if !d.Compact || !found || len(root) <= 2 {
+		return account
+	}
+	return root[:2] + ":" + rest
+}
+
+// Indent returns the indentation of a tree row at a depth: two spaces per level,
+// one in the compact layout
+func (d Display) Indent(depth int) string {
+	if d.Compact {
+		return strings.Repeat(" ", depth)
+	}
+	return strings.Repeat("  ", depth)
+}
@@ -0,0 +1,62 @@
+package components
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keySymbols are shown in help text instead of key names
+var keySymbols = map[string]string{
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+}
+
+// Binding creates a key binding from configured key names
+// "space" stands for the space bar, which bubbletea reports as " ".
+func Binding(keys []string, desc string) key.Binding {
+	matched := make([]string, len(keys))
+	for i, k := range keys {
+		if k == "space" {
+			k = " "
+		}
+		matched[i] = k
+	}
+	return key.NewBinding(key.WithKeys(matched...), key.WithHelp(KeyHelp(keys), desc))
+}
+
+// KeyHelp formats a binding's keys for help text, e.g. "↑/k" or "q/ctrl+c"
+func KeyHelp(keys []string) string {
+	shown := make([]string, len(keys))
+	for i, k := range keys {
+		if symbol, ok := keySymbols[k]; ok {
+			k = symbol
+		}
+		shown[i] = k
+	}
+	return strings.Join(shown, "/")
+}
+
+// FixedKeys returns what each key of a key map's bindings does, by key, for a key map
+// built without configured keys: those left are the ones the view always handles
+// The space bar is named "space", as in the config.
+func FixedKeys(keyMap any) map[string]string {
+	fixed := make(map[string]string)
+	v := reflect.ValueOf(keyMap)
+	for i := range v.NumField() {
+		binding, ok := v.Field(i).Interface().(key.Binding)
+		if !ok {
+			continue
+		}
+		for _, k := range binding.Keys() {
+			if k == " " {
+				k = "space"
+			}
+			fixed[k] = binding.Help().Desc
+		}
+	}
+	return fixed
+}
@@ -10,18 +10,27 @@ import (
 
 // MenuItem represents a single menu in the menu bar
 type MenuItem struct {
-	Label   string // Display text (e.g., "File")
-	Hotkey  rune   // Alt+key (e.g., 'F' for Alt+F)
-	Active  bool   // Is this menu currently open?
-	Items   []string // Submenu items (for future dropdown implementation)
+	Label  string   // Display text (e.g., "File")
+	Hotkey rune     // Alt+key (e.g., 'F' for Alt+F)
+	Active bool     // Is this menu currently open?
+	Items  []string // Dropdown entries
+}
+
+// MenuSelectedMsg is sent when a dropdown entry is chosen
+type MenuSelectedMsg struct {
+	Menu string // Menu label (e.g., "File")
+	Item string // Entry label (e.g., "New Transaction")
 }
 
 // MenuBar represents the top menu bar
 type MenuBar struct {
-	items        []MenuItem
-	activeIndex  int  // Which menu is highlighted (-1 = none)
-	menuActive   bool // Is the menu bar active (F10 or Alt pressed)?
-	width        int
+	items       []MenuItem
+	activeIndex int               // Which menu is highlighted (-1 = none)
+	menuActive  bool              // Is the menu bar active (F10 or Alt pressed)?
+	open        bool              // Is the active menu's dropdown shown?
+	itemIndex   int               // Highlighted dropdown entry
+	badges      map[string]string // Text shown after dropdown entries, by entry label
+	width       int
 }
 
 // NewMenuBar creates a new TP7-style menu bar
@@ -31,12 +40,12 @@ func NewMenuBar() MenuBar {
 			{
 				Label:  "File",
 				Hotkey: 'f',
-				Items:  []string{"Open", "Export Patterns", "Preferences", "Exit"},
+				Items:  []string{"New Transaction", "Open", "Import", "Sync", "Export", "Export Patterns", "Merge Payees", "Preferences", "Exit"},
 			},
 			{
 				Label:  "View",
 				Hotkey: 'v',
-				Items:  []string{"Dashboard", "Transactions", "Accounts", "Reports"},
+				Items:  []string{"Dashboard", "Transactions", "Accounts", "Reports", "Patterns", "Review", "Budgets", "Tags", "Problems", "Imports", "Theme", "Currency", "Compact Mode"},
 			},
 			{
 				Label:  "Reports",
@@ -69,15 +78,14 @@ func (m MenuBar) Update(msg tea.Msg) (MenuBar, tea.Cmd) {
 				m.activeIndex = 0 // Activate first menu
 			} else if !m.menuActive {
 				m.activeIndex = -1 // Deactivate
+				m.open = false
 			}
 			return m, nil
 		}
 
 		// Escape deactivates menu
 		if msg.String() == "esc" && m.menuActive {
-			m.menuActive = false
-			m.activeIndex = -1
-			return m, nil
+			return m.Deactivate(), nil
 		}
 
 		// Alt+key activates specific menu
@@ -87,6 +95,8 @@ func (m MenuBar) Update(msg tea.Msg) (MenuBar, tea.Cmd) {
 				if item.Hotkey == key {
 					m.menuActive = true
 					m.activeIndex = i
+					m.open = true
+					m.itemIndex = 0
 					return m, nil
 				}
 			}
@@ -100,13 +110,40 @@ func (m MenuBar) Update(msg tea.Msg) (MenuBar, tea.Cmd) {
 				if m.activeIndex < 0 {
 					m.activeIndex = len(m.items) - 1
 				}
+				m.itemIndex = 0
 				return m, nil
 			case "right":
 				m.activeIndex++
 				if m.activeIndex >= len(m.items) {
 					m.activeIndex = 0
 				}
+				m.itemIndex = 0
+				return m, nil
+			case "down":
+				if !m.open {
+					m.open = true
+					m.itemIndex = 0
+				} else if m.itemIndex < len(m.items[m.activeIndex].Items)-1 {
+					m.itemIndex++
+				}
+				return m, nil
+			case "up":
+				if m.open && m.itemIndex > 0 {
+					m.itemIndex--
+				}
 				return m, nil
+			case "enter":
+				if !m.open {
+					m.open = true
+					m.itemIndex = 0
+					return m, nil
+				}
+				selected := MenuSelectedMsg{
+					Menu: m.items[m.activeIndex].Label,
+					Item: m.items[m.activeIndex].Items[m.itemIndex],
+				}
+				m = m.Deactivate()
+				return m, func() tea.Msg { return selected }
 			}
 		}
 	}
@@ -152,6 +189,65 @@ func (m MenuBar) IsActive() bool {
 func (m MenuBar) Deactivate() MenuBar {
 	m.menuActive = false
 	m.activeIndex = -1
+	m.open = false
+	return m
+}
+
+// Dropdown renders the open menu's entries and the column where it starts
+// It returns an empty string when no dropdown is open
+func (m MenuBar) Dropdown() (string, int) {
+	if !m.menuActive || !m.open || m.activeIndex < 0 {
+		return "", 0
+	}
+
+	// Column of the active label: " Lima " plus one space and the label of each earlier menu
+	column := lipgloss.Width(" Lima ")
+	for _, item := range m.items[:m.activeIndex] {
+		column += 1 + lipgloss.Width(item.Label)
+	}
+	column++
+
+	entries := make([]string, len(m.items[m.activeIndex].Items))
+	for i, entry := range m.items[m.activeIndex].Items {
+		entries[i] = entry
+		if badge := m.badges[entry]; badge != "" {
+			entries[i] += " " + badge
+		}
+	}
+	width := 0
+	for _, entry := range entries {
+		if w := lipgloss.Width(entry); w > width {
+			width = w
+		}
+	}
+
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		text := " " + entry + strings.Repeat(" ", width-lipgloss.Width(entry)) + " "
+		if i == m.itemIndex {
+			lines[i] = theme.MenuItemActiveStyle.Render(text)
+		} else {
+			lines[i] = theme.MenuItemInactiveStyle.Render(text)
+		}
+	}
+
+	box := theme.MenuDropdownStyle.Render(strings.Join(lines, "\n"))
+	return box, column
+}
+
+// SetBadge shows text after a dropdown entry, e.g. a count; "" removes it
+// The entry is still reported by its label when chosen.
+func (m MenuBar) SetBadge(entry, badge string) MenuBar {
+	badges := make(map[string]string, len(m.badges)+1)
+	for label, text := range m.badges {
+		badges[label] = text
+	}
+	if badge == "" {
+		delete(badges, entry)
+	} else {
+		badges[entry] = badge
+	}
+	m.badges = badges
 	return m
 }
 
@@ -188,10 +284,7 @@ func renderMenuWithHotkey(label string, hotkey rune, active bool) string {
 			// Render hotkey with special style (underlined)
 			if active {
 				// When menu is active, white on black background
-				hotkeyStyle := lipgloss.NewStyle().
-					Foreground(lipgloss.Color(theme.TP7White)).
-					Background(lipgloss.Color(theme.TP7Black))
-				result.WriteString(hotkeyStyle.Render(string(ch)))
+				result.WriteString(theme.MenuItemActiveStyle.Render(string(ch)))
 			} else {
 				// Normal: black text on light gray, underlined
 				hotkeyStyle := theme.MenuHotkeyStyle.Underline(true)
@@ -0,0 +1,55 @@
+package components
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Level is a notification's severity
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelSuccess
+	LevelWarning
+	LevelError
+)
+
+// Icon returns the marker shown before a notification in the status bar
+func (l Level) Icon() string {
+	switch l {
+	case LevelSuccess:
+		return "✓"
+	case LevelWarning:
+		return "!"
+	case LevelError:
+		return "✗"
+	}
+	return "·"
+}
+
+// Notification is a message shown briefly in the status bar and kept in the message log
+type Notification struct {
+	Level Level
+	Text  string
+	Time  time.Time
+}
+
+// String returns the notification as shown in the status bar
+func (n Notification) String() string {
+	return n.Level.Icon() + " " + n.Text
+}
+
+// NotifyMsg asks the application to show a notification
+type NotifyMsg struct {
+	Level Level
+	Text  string
+}
+
+// Notify returns a command that shows a notification, for views without access to the status bar
+func Notify(level Level, text string) tea.Cmd {
+	return func() tea.Msg {
+		return NotifyMsg{Level: level, Text: text}
+	}
+}
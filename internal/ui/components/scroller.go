@@ -0,0 +1,110 @@
+package components
+
+// Scroller is the cursor and scroll offset of a list that may be taller than its window
+// Views keep one per list and call Fit whenever the row count or window height
+// changes. The cursor always stays on a row and inside the window, and the window
+// moves no more than it has to, so resizing mid-scroll keeps the view steady.
+type Scroller struct {
+	cursor int // Selected row
+	offset int // First row shown
+	count  int // Rows in the list
+	height int // Rows that fit in the window, at least 1
+}
+
+// Fit sets the row count and window height and brings the cursor back into view
+func (s Scroller) Fit(count, height int) Scroller {
+	s.count = max(0, count)
+	s.height = height
+	return s.clamp()
+}
+
+// Cursor returns the selected row; 0 when the list is empty
+func (s Scroller) Cursor() int {
+	return s.cursor
+}
+
+// Offset returns the first row shown
+func (s Scroller) Offset() int {
+	return s.offset
+}
+
+// Window returns the rows shown, from start up to but not including end
+func (s Scroller) Window() (start, end int) {
+	return s.offset, min(s.count, s.offset+max(1, s.height))
+}
+
+// Select moves the cursor to a row, scrolling as little as needed to show it
+func (s Scroller) Select(row int) Scroller {
+	s.cursor = row
+	return s.clamp()
+}
+
+// SelectAtTop moves the cursor to a row and scrolls it to the top of the window,
+// as far as the rows below it allow
+func (s Scroller) SelectAtTop(row int) Scroller {
+	s.cursor = row
+	s.offset = row
+	return s.clamp()
+}
+
+// Up moves the cursor one row up
+func (s Scroller) Up() Scroller {
+	return s.Select(s.cursor - 1)
+}
+
+// Down moves the cursor one row down
+func (s Scroller) Down() Scroller {
+	return s.Select(s.cursor + 1)
+}
+
+// page returns how many rows a page moves: the page size, or the window's height
+// when it is 0
+func (s Scroller) page(size int) int {
+	if size > 0 {
+		return size
+	}
+	return max(1, s.height)
+}
+
+// PageUp moves the cursor and the window up by a page of size rows, 0 for the
+// window's height
+func (s Scroller) PageUp(size int) Scroller {
+	page := s.page(size)
+	s.cursor -= page
+	s.offset -= page
+	return s.clamp()
+}
+
+// PageDown moves the cursor and the window down by a page of size rows, 0 for the
+// window's height
+func (s Scroller) PageDown(size int) Scroller {
+	page := s.page(size)
+	s.cursor += page
+	s.offset += page
+	return s.clamp()
+}
+
+// Top moves the cursor to the first row
+func (s Scroller) Top() Scroller {
+	return s.Select(0)
+}
+
+// Bottom moves the cursor to the last row
+func (s Scroller) Bottom() Scroller {
+	return s.Select(s.count - 1)
+}
+
+// clamp keeps the cursor on a row and inside the window, and the window on the rows
+// The window never scrolls past the last row, leaving no blank lines below it.
+func (s Scroller) clamp() Scroller {
+	height := max(1, s.height)
+	s.cursor = max(0, min(s.cursor, s.count-1))
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+height {
+		s.offset = s.cursor - height + 1
+	}
+	s.offset = max(0, min(s.offset, s.count-height))
+	return s
+}
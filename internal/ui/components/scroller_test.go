@@ -0,0 +1,108 @@
+package components
+
+import "testing"
+
+func TestScrollerNavigation(t *testing.T) {
+	pageDown := func(s Scroller) Scroller { return s.PageDown(0) }
+	pageUp := func(s Scroller) Scroller { return s.PageUp(0) }
+	tests := []struct {
+		name   string
+		start  Scroller
+		move   func(Scroller) Scroller
+		cursor int
+		offset int
+	}{
+		{"down inside window", Scroller{}.Fit(10, 3), Scroller.Down, 1, 0},
+		{"down past window", Scroller{}.Fit(10, 3).Select(2), Scroller.Down, 3, 1},
+		{"down at last row", Scroller{}.Fit(10, 3).Bottom(), Scroller.Down, 9, 7},
+		{"up inside window", Scroller{}.Fit(10, 3).Select(2), Scroller.Up, 1, 0},
+		{"up past window", Scroller{}.Fit(10, 3).SelectAtTop(5), Scroller.Up, 4, 4},
+		{"up at first row", Scroller{}.Fit(10, 3), Scroller.Up, 0, 0},
+		{"page down", Scroller{}.Fit(10, 3).Select(1), pageDown, 4, 3},
+		{"page down near end", Scroller{}.Fit(10, 3).Select(8), pageDown, 9, 7},
+		{"page up", Scroller{}.Fit(10, 3).SelectAtTop(6), pageUp, 3, 3},
+		{"page up near start", Scroller{}.Fit(10, 3).Select(4), pageUp, 1, 0},
+		{"top", Scroller{}.Fit(10, 3).Bottom(), Scroller.Top, 0, 0},
+		{"bottom", Scroller{}.Fit(10, 3), Scroller.Bottom, 9, 7},
+		{"select below window", Scroller{}.Fit(10, 3), func(s Scroller) Scroller { return s.Select(6) }, 6, 4},
+		{"select out of range", Scroller{}.Fit(10, 3), func(s Scroller) Scroller { return s.Select(42) }, 9, 7},
+		{"select at top", Scroller{}.Fit(10, 3), func(s Scroller) Scroller { return s.SelectAtTop(4) }, 4, 4},
+		{"select at top near end", Scroller{}.Fit(10, 3), func(s Scroller) Scroller { return s.SelectAtTop(8) }, 8, 7},
+		{"empty list", Scroller{}.Fit(0, 3), Scroller.Bottom, 0, 0},
+		{"zero height", Scroller{}.Fit(5, 0), Scroller.Down, 1, 1},
+		{"page down with zero height", Scroller{}.Fit(5, 0), pageDown, 1, 1},
+	}
+	for _, tt := range tests {
+		got := tt.move(tt.start)
+		if got.Cursor() != tt.cursor || got.Offset() != tt.offset {
+			t.Errorf("%s: cursor %d offset %d, want %d and %d",
+				tt.name, got.Cursor(), got.Offset(), tt.cursor, tt.offset)
+		}
+	}
+}
+
+func TestScrollerPageSize(t *testing.T) {
+	// Pages move the page size, whatever the window's height
+	s := Scroller{}.Fit(20, 3).PageDown(5)
+	if s.Cursor() != 5 || s.Offset() != 5 {
+		t.Errorf("page down: cursor %d offset %d, want 5 and 5", s.Cursor(), s.Offset())
+	}
+	s = s.PageDown(5).PageUp(5)
+	if s.Cursor() != 5 || s.Offset() != 5 {
+		t.Errorf("page down and up: cursor %d offset %d, want 5 and 5", s.Cursor(), s.Offset())
+	}
+	if s = s.PageDown(5).PageDown(5).PageDown(5); s.Cursor() != 19 || s.Offset() != 17 {
+		t.Errorf("page down past the end: cursor %d offset %d, want 19 and 17", s.Cursor(), s.Offset())
+	}
+}
+
+func TestScrollerFit(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  Scroller
+		count  int
+		height int
+		cursor int
+		offset int
+	}{
+		// Growing the window keeps the offset until it would leave blank rows
+		{"taller window mid-scroll", Scroller{}.Fit(20, 5).SelectAtTop(10), 20, 8, 10, 10},
+		{"taller window near end", Scroller{}.Fit(20, 5).SelectAtTop(14), 20, 10, 14, 10},
+		{"taller than the list", Scroller{}.Fit(20, 5).Bottom(), 20, 30, 19, 0},
+		// Shrinking it keeps the cursor in view
+		{"shorter window", Scroller{}.Fit(20, 10).Select(9), 20, 4, 9, 6},
+		{"shorter window, cursor at top", Scroller{}.Fit(20, 10).SelectAtTop(5), 20, 2, 5, 5},
+		{"tiny window", Scroller{}.Fit(20, 10).Select(7), 20, -3, 7, 7},
+		// Fewer rows pull the cursor back onto the list
+		{"rows removed below cursor", Scroller{}.Fit(20, 5).Bottom(), 12, 5, 11, 7},
+		{"all rows removed", Scroller{}.Fit(20, 5).Select(3), 0, 5, 0, 0},
+		{"rows added", Scroller{}.Fit(5, 5).Bottom(), 20, 5, 4, 0},
+	}
+	for _, tt := range tests {
+		got := tt.start.Fit(tt.count, tt.height)
+		if got.Cursor() != tt.cursor || got.Offset() != tt.offset {
+			t.Errorf("%s: cursor %d offset %d, want %d and %d",
+				tt.name, got.Cursor(), got.Offset(), tt.cursor, tt.offset)
+		}
+	}
+}
+
+func TestScrollerWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		s          Scroller
+		start, end int
+	}{
+		{"first page", Scroller{}.Fit(10, 3), 0, 3},
+		{"scrolled", Scroller{}.Fit(10, 3).Select(5), 3, 6},
+		{"last page", Scroller{}.Fit(10, 3).Bottom(), 7, 10},
+		{"short list", Scroller{}.Fit(2, 5), 0, 2},
+		{"empty list", Scroller{}.Fit(0, 5), 0, 0},
+		{"zero height shows a row", Scroller{}.Fit(4, 0).Select(2), 2, 3},
+	}
+	for _, tt := range tests {
+		if start, end := tt.s.Window(); start != tt.start || end != tt.end {
+			t.Errorf("%s: window %d-%d, want %d-%d", tt.name, start, end, tt.start, tt.end)
+		}
+	}
+}
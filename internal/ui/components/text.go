@@ -0,0 +1,96 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Text helpers measure strings in terminal cells rather than bytes or runes,
+// so payees with CJK characters or emoji line up with everything else.
+// They also skip ANSI escape sequences, so styled text can be measured.
+
+// Width returns how many terminal cells text takes up
+func Width(text string) int {
+	return ansi.StringWidth(text)
+}
+
+// Truncate shortens text to width cells, ending it with tail when cut
+// A wide character that would straddle the edge is dropped, so the result
+// may be a cell short; pad it when columns must line up.
+func Truncate(text string, width int, tail string) string {
+	if width <= 0 {
+		return ""
+	}
+	if Width(text) <= width {
+		return text
+	}
+	if Width(tail) >= width {
+		tail = ""
+	}
+	return ansi.Truncate(text, width, tail)
+}
+
+// TruncateStart shortens text to width cells by cutting its beginning, starting it with prefix when cut
+// Account names keep their most specific component this way
+func TruncateStart(text string, width int, prefix string) string {
+	if width <= 0 {
+		return ""
+	}
+	total := Width(text)
+	if total <= width {
+		return text
+	}
+	if Width(prefix) >= width {
+		prefix = ""
+	}
+	rest, _ := dropLeft(text, total-width+Width(prefix))
+	return prefix + rest
+}
+
+// dropLeft removes the first n cells of text
+// A wide character straddling the cut is removed too; the extra cells removed are returned
+func dropLeft(text string, n int) (string, int) {
+	if n <= 0 {
+		return text, 0
+	}
+	total := Width(text)
+	if n >= total {
+		return "", 0
+	}
+	for cut := n; ; cut++ {
+		rest := ansi.TruncateLeft(text, cut, "")
+		if removed := total - Width(rest); removed >= n {
+			return rest, removed - n
+		}
+	}
+}
+
+// PadRight fills text with spaces on the right to width cells
+func PadRight(text string, width int) string {
+	if pad := width - Width(text); pad > 0 {
+		return text + strings.Repeat(" ", pad)
+	}
+	return text
+}
+
+// PadLeft fills text with spaces on the left to width cells, right-aligning it
+func PadLeft(text string, width int) string {
+	if pad := width - Width(text); pad > 0 {
+		return strings.Repeat(" ", pad) + text
+	}
+	return text
+}
+
+// Fit truncates or pads text to exactly width cells
+func Fit(text string, width int, tail string) string {
+	return PadRight(Truncate(text, width, tail), width)
+}
+
+// Cut returns the width cells of text starting at cell offset, padded to width
+// It is used to scroll wide tables horizontally; a wide character cut in half
+// at either edge is shown as spaces.
+func Cut(text string, offset, width int) string {
+	rest, extra := dropLeft(text, offset)
+	return Fit(strings.Repeat(" ", extra)+rest, width, "")
+}
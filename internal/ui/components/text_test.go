@@ -0,0 +1,111 @@
+package components
+
+import "testing"
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"Coffee", 6},
+		{"Café", 4},
+		{"東京ラーメン", 12},
+		{"☕ Coffee", 9},
+		{"🍣🍣", 4},
+		{"\x1b[31mred\x1b[0m", 3},
+	}
+	for _, tt := range tests {
+		if got := Width(tt.text); got != tt.want {
+			t.Errorf("Width(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		tail  string
+		want  string
+	}{
+		{"Coffee", 10, "...", "Coffee"},
+		{"Coffee Shop", 8, "...", "Coffe..."},
+		{"Café Crème", 6, "…", "Café …"},
+		{"東京ラーメン", 7, "…", "東京ラ…"},
+		{"東京ラーメン", 8, "…", "東京ラ…"},
+		{"🍣 Sushi Bar", 6, "…", "🍣 Su…"},
+		{"Coffee", 2, "...", "Co"},
+		{"Coffee", 0, "...", ""},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.text, tt.width, tt.tail)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+		if Width(got) > tt.width {
+			t.Errorf("Truncate(%q, %d) is %d cells wide", tt.text, tt.width, Width(got))
+		}
+	}
+}
+
+func TestTruncateStart(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  string
+	}{
+		{"Expenses:Food", 20, "Expenses:Food"},
+		{"Expenses:Food:Coffee", 12, "...od:Coffee"},
+		{"Expenses:外食:ラーメン", 14, "...食:ラーメン"},
+		{"Expenses:外食:ラーメン", 13, "...:ラーメン"},
+		{"Expenses:外食:ラーメン", 11, "...ラーメン"},
+	}
+	for _, tt := range tests {
+		got := TruncateStart(tt.text, tt.width, "...")
+		if got != tt.want {
+			t.Errorf("TruncateStart(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+		if Width(got) > tt.width {
+			t.Errorf("TruncateStart(%q, %d) is %d cells wide", tt.text, tt.width, Width(got))
+		}
+	}
+}
+
+func TestFitAndPad(t *testing.T) {
+	for _, text := range []string{"Coffee", "東京ラーメン屋さん", "🍣 Sushi", "Café"} {
+		if got := Width(Fit(text, 9, "…")); got != 9 {
+			t.Errorf("Fit(%q, 9) is %d cells wide", text, got)
+		}
+	}
+	if got := PadLeft("東京", 6); got != "  東京" {
+		t.Errorf("PadLeft = %q, want %q", got, "  東京")
+	}
+	if got := PadRight("東京", 6); got != "東京  " {
+		t.Errorf("PadRight = %q, want %q", got, "東京  ")
+	}
+}
+
+func TestCut(t *testing.T) {
+	tests := []struct {
+		text          string
+		offset, width int
+		want          string
+	}{
+		{"2024-03-01  Coffee", 12, 6, "Coffee"},
+		{"2024-03-01  東京", 12, 4, "東京"},
+		{"2024-03-01  東京", 12, 10, "東京      "},
+		{"short", 10, 3, "   "},
+	}
+	for _, tt := range tests {
+		got := Cut(tt.text, tt.offset, tt.width)
+		if got != tt.want {
+			t.Errorf("Cut(%q, %d, %d) = %q, want %q", tt.text, tt.offset, tt.width, got, tt.want)
+		}
+	}
+	// Cutting through a wide character never overflows the window
+	for offset := 0; offset < 8; offset++ {
+		if got := Width(Cut("東京ラーメン", offset, 5)); got != 5 {
+			t.Errorf("Cut at %d is %d cells wide", offset, got)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package components
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+// maxAlternatives is how many completions after the first a focused input lists
+const maxAlternatives = 3
+
+// TextInput is a single-line TP7-style text field
+// Tab accepts the first completion, from Complete when set, else from the
+// Suggestions that fuzzily match the value
+type TextInput struct {
+	Label       string
+	Placeholder string
+	Suggestions []string  // Completion candidates (e.g., account names), most used first
+	Complete    Completer // Completes the value itself, e.g. its last search term
+
+	value   []rune
+	cursor  int
+	focused bool
+	width   int
+}
+
+// NewTextInput creates an empty text input with a label
+func NewTextInput(label string) TextInput {
+	return TextInput{Label: label, width: 40}
+}
+
+// Value returns the current text
+func (t TextInput) Value() string {
+	return string(t.value)
+}
+
+// SetValue replaces the text and moves the cursor to the end
+func (t TextInput) SetValue(value string) TextInput {
+	t.value = []rune(value)
+	t.cursor = len(t.value)
+	return t
+}
+
+// SetWidth sets the display width of the field
+func (t TextInput) SetWidth(width int) TextInput {
+	t.width = width
+	return t
+}
+
+// Focus gives the input keyboard focus
+func (t TextInput) Focus() TextInput {
+	t.focused = true
+	return t
+}
+
+// Blur removes keyboard focus
+func (t TextInput) Blur() TextInput {
+	t.focused = false
+	return t
+}
+
+// Focused reports whether the input has focus
+func (t TextInput) Focused() bool {
+	return t.focused
+}
+
+// Completions returns the completions of the current text, best first
+func (t TextInput) Completions() []string {
+	if t.Complete != nil {
+		return t.Complete(t.Value())
+	}
+	return FuzzyMatch(t.Value(), t.Suggestions)
+}
+
+// Completion returns the best completion of the current text, or ""
+func (t TextInput) Completion() string {
+	if completions := t.Completions(); len(completions) > 0 {
+		return completions[0]
+	}
+	return ""
+}
+
+// Update handles editing keys; the bool reports whether the key was consumed
+func (t TextInput) Update(msg tea.KeyMsg) (TextInput, bool) {
+	if !t.focused {
+		return t, false
+	}
+
+	switch msg.Type {
+	case tea.KeyRunes, tea.KeySpace:
+		runes := msg.Runes
+		if msg.Type == tea.KeySpace {
+			runes = []rune{' '}
+		}
+		value := make([]rune, 0, len(t.value)+len(runes))
+		value = append(value, t.value[:t.cursor]...)
+		value = append(value, runes...)
+		value = append(value, t.value[t.cursor:]...)
+		t.value = value
+		t.cursor += len(runes)
+
+	case tea.KeyBackspace:
+		if t.cursor > 0 {
+			t.value = append(t.value[:t.cursor-1:t.cursor-1], t.value[t.cursor:]...)
+			t.cursor--
+		}
+
+	case tea.KeyDelete:
+		if t.cursor < len(t.value) {
+			t.value = append(t.value[:t.cursor:t.cursor], t.value[t.cursor+1:]...)
+		}
+
+	case tea.KeyLeft:
+		if t.cursor > 0 {
+			t.cursor--
+		}
+
+	case tea.KeyRight:
+		if t.cursor < len(t.value) {
+			t.cursor++
+		}
+
+	case tea.KeyHome, tea.KeyCtrlA:
+		t.cursor = 0
+
+	case tea.KeyEnd, tea.KeyCtrlE:
+		t.cursor = len(t.value)
+
+	case tea.KeyCtrlU:
+		t.value = t.value[t.cursor:]
+		t.cursor = 0
+
+	case tea.KeyTab:
+		completion := t.Completion()
+		if completion == "" {
+			return t, false
+		}
+		t = t.SetValue(completion)
+
+	default:
+		return t, false
+	}
+
+	return t, true
+}
+
+// View renders the label and field; the cursor is shown when focused
+func (t TextInput) View() string {
+	style := theme.AlternateItemStyle
+	runes := t.value
+	if len(runes) == 0 && !t.focused && t.Placeholder != "" {
+		runes = []rune(t.Placeholder)
+		style = theme.MutedTextStyle
+	}
+
+	text := string(runes)
+	if t.focused {
+		style = theme.InputStyle
+		if t.cursor >= len(runes) {
+			text += "_"
+		} else {
+			text = string(runes[:t.cursor]) + "▌" + string(runes[t.cursor:])
+		}
+	}
+	if pad := t.width - len([]rune(text)); pad > 0 {
+		text += strings.Repeat(" ", pad)
+	}
+
+	view := style.Render(text)
+	if t.focused {
+		if completions := t.Completions(); len(completions) > 0 {
+			hint := "  tab → " + completions[0]
+			if others := completions[1:min(len(completions), 1+maxAlternatives)]; len(others) > 0 {
+				hint += "   (" + strings.Join(others, ", ") + ")"
+			}
+			view += theme.MutedTextStyle.Render(hint)
+		}
+	}
+	if t.Label != "" {
+		view = theme.NormalTextStyle.Render(t.Label+": ") + view
+	}
+	return view
+}
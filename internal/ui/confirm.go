@@ -0,0 +1,55 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/confirm"
+)
+
+// requestWrite previews a request's edits for confirmation
+// Requests without edits finish at once, and single categorizations are written
+// without asking when the config turns their confirmation off.
+func (m Model) requestWrite(req confirm.RequestMsg) (tea.Model, tea.Cmd) {
+	if len(req.Edits) == 0 {
+		return m.finishWrite(req, nil)
+	}
+	if m.writer.ReadOnly() {
+		return m.finishWrite(req, beancount.ErrReadOnly)
+	}
+	if req.Single && !m.config.UI.ConfirmCategorize {
+		return m.finishWrite(req, m.writer.ApplyAll(req.Edits))
+	}
+	dialog := confirm.New(req, m.file.Store()).SetSideBySide(m.config.UI.DiffStyle == "side_by_side").SetSize(m.width, m.height-2)
+	m.confirm = &dialog
+	return m, nil
+}
+
+// updateConfirm handles keys while the write confirmation is shown
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	req := m.confirm.Request()
+	switch {
+	case m.confirm.Confirmed(msg):
+		m.confirm = nil
+		return m.finishWrite(req, m.writer.ApplyAll(req.Edits))
+
+	case m.confirm.Cancelled(msg):
+		m.confirm = nil
+		return m.notify(components.LevelInfo, "Cancelled: "+req.Title), nil
+	}
+
+	dialog := m.confirm.Update(msg)
+	m.confirm = &dialog
+	return m, nil
+}
+
+// finishWrite reports a request's outcome to whoever made it
+func (m Model) finishWrite(req confirm.RequestMsg, err error) (tea.Model, tea.Cmd) {
+	if req.Done == nil {
+		if err != nil {
+			return m.notifyf(components.LevelError, "%s failed: %v", req.Title, err), nil
+		}
+		return m, nil
+	}
+	return m.update(req.Done(err))
+}
@@ -0,0 +1,189 @@
+// Package confirm implements the write confirmation dialog: a diff preview of
+// ledger edits that must be accepted before they are applied
+package confirm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+// RequestMsg asks the root model to confirm and apply ledger edits
+// Views build edits without applying them and send this instead.
+type RequestMsg struct {
+	// Title says what the edits do, e.g. "Categorize as Expenses:Food"
+	Title string
+	Edits []beancount.Edit
+
+	// Single marks one categorization or flag change, which config may apply without asking
+	Single bool
+
+	// Done reports the outcome once the edits were applied (nil) or failed;
+	// it is not called when the write is cancelled
+	Done func(err error) tea.Msg
+}
+
+// Request returns a command that sends req
+func Request(req RequestMsg) tea.Cmd {
+	return func() tea.Msg { return req }
+}
+
+// keyMap defines key bindings for the dialog
+type keyMap struct {
+	Confirm key.Binding
+	Cancel  key.Binding
+	Up      key.Binding
+	Down    key.Binding
+	Layout  key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Confirm: key.NewBinding(
+			key.WithKeys("y", "Y", "enter"),
+			key.WithHelp("y/enter", "write"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("n", "N", "esc"),
+			key.WithHelp("n/esc", "cancel"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "scroll up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "scroll down"),
+		),
+		Layout: key.NewBinding(
+			key.WithKeys("tab", "s"),
+			key.WithHelp("tab", "side by side"),
+		),
+	}
+}
+
+// Model is the confirmation dialog for one request
+type Model struct {
+	request RequestMsg
+	keys    keyMap
+
+	// hunks are the edits split into context and changes; lines is their rendering
+	// and offset the first visible line
+	hunks  []hunk
+	lines  []string
+	offset int
+
+	// sideBySide shows old and new text in two columns instead of a unified diff
+	sideBySide bool
+
+	width  int
+	height int
+}
+
+// New creates the dialog previewing req's edits as a unified diff
+func New(req RequestMsg, store *beancount.Store) Model {
+	m := Model{request: req, keys: newKeyMap(), hunks: buildHunks(req.Edits, store)}
+	return m.render()
+}
+
+// SetSideBySide picks the two-column layout over the unified diff
+func (m Model) SetSideBySide(sideBySide bool) Model {
+	m.sideBySide = sideBySide
+	return m.render()
+}
+
+// render lays out the diff for the current layout and width
+func (m Model) render() Model {
+	if m.sideBySide {
+		m.lines = renderSideBySide(m.hunks, m.width)
+	} else {
+		m.lines = renderUnified(m.hunks)
+	}
+	return m.clamp()
+}
+
+// Request returns the request being confirmed
+func (m Model) Request() RequestMsg {
+	return m.request
+}
+
+// Confirmed reports whether a key accepts the write
+func (m Model) Confirmed(msg tea.KeyMsg) bool {
+	return key.Matches(msg, m.keys.Confirm)
+}
+
+// Cancelled reports whether a key cancels the write
+func (m Model) Cancelled(msg tea.KeyMsg) bool {
+	return key.Matches(msg, m.keys.Cancel)
+}
+
+// ShortHelp returns the dialog's key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	return []key.Binding{m.keys.Confirm, m.keys.Cancel, m.keys.Up, m.keys.Down, m.keys.Layout}
+}
+
+// Update scrolls the diff; confirming and cancelling are left to the caller
+func (m Model) Update(msg tea.KeyMsg) Model {
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		m.offset--
+	case key.Matches(msg, m.keys.Down):
+		m.offset++
+	case key.Matches(msg, m.keys.Layout):
+		return m.SetSideBySide(!m.sideBySide)
+	}
+	return m.clamp()
+}
+
+// bodyHeight returns how many diff lines fit between the title and the prompt
+func (m Model) bodyHeight() int {
+	return max(1, m.height-4)
+}
+
+// clamp keeps the offset within the scrollable range
+func (m Model) clamp() Model {
+	m.offset = max(0, min(m.offset, len(m.lines)-m.bodyHeight()))
+	return m
+}
+
+// View renders the dialog
+func (m Model) View() string {
+	titleText := "Confirm: " + m.request.Title
+	titlePadded := components.PadRight(titleText, m.width)
+	lines := []string{theme.TitleStyle.Width(m.width).Render(titlePadded)}
+
+	end := min(len(m.lines), m.offset+m.bodyHeight())
+	lines = append(lines, m.lines[m.offset:end]...)
+	if len(m.lines) > m.bodyHeight() {
+		lines = append(lines, theme.MutedTextStyle.Render(
+			fmt.Sprintf("  lines %d-%d of %d (↑/↓ to scroll)", m.offset+1, end, len(m.lines))))
+	}
+
+	layout := "side by side"
+	if m.sideBySide {
+		layout = "unified"
+	}
+	lines = append(lines, "", theme.HighlightStyle.Render(
+		fmt.Sprintf("  Write %s? It can be undone with u.   y:write   n/esc:cancel   tab:%s", plural(len(m.request.Edits), "change"), layout)))
+	return strings.Join(lines, "\n")
+}
+
+// SetSize updates the dialog size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m.render()
+}
+
+// plural formats a count with a noun, e.g. "1 change" or "3 changes"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
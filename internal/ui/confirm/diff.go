@@ -0,0 +1,220 @@
+package confirm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+// maxContext bounds the lines of the enclosing entry shown on each side of an edit
+const maxContext = 12
+
+// hunk is one edit laid out for display
+type hunk struct {
+	location string
+
+	// lead and trail are unchanged lines: the rest of the entry around the edit and
+	// the lines the old and new text have in common at their start and end
+	lead  []string
+	trail []string
+
+	removed []string
+	added   []string
+}
+
+// change is a removed or added line with the span that differs from its counterpart
+// from and to are rune offsets; a line without counterpart changes as a whole
+type change struct {
+	text     string
+	from, to int
+}
+
+// buildHunks splits edits into context and changed lines
+// The enclosing entry is read from the ledger, which still holds the old lines.
+func buildHunks(edits []beancount.Edit, store *beancount.Store) []hunk {
+	files := make(map[string][]string)
+	hunks := make([]hunk, 0, len(edits))
+	for _, edit := range edits {
+		lines, ok := files[edit.FilePath]
+		if !ok {
+			lines = readLines(store, edit.FilePath)
+			files[edit.FilePath] = lines
+		}
+
+		oldLines, newLines := edit.OldLines, edit.NewLines
+		prefix := 0
+		for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+			prefix++
+		}
+		suffix := 0
+		for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+			oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+			suffix++
+		}
+
+		before, after := entryContext(lines, edit.StartLine, len(oldLines))
+		h := hunk{location: fmt.Sprintf("%s:%d", filepath.Base(edit.FilePath), edit.StartLine)}
+		h.lead = append(before, oldLines[:prefix]...)
+		h.removed = oldLines[prefix : len(oldLines)-suffix]
+		h.added = newLines[prefix : len(newLines)-suffix]
+		h.trail = append(append([]string(nil), oldLines[len(oldLines)-suffix:]...), after...)
+		hunks = append(hunks, h)
+	}
+	return hunks
+}
+
+// readLines returns a file's lines, or nil when it can't be read
+// The preview then shows the edit without its surroundings.
+func readLines(store *beancount.Store, path string) []string {
+	data, err := store.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// entryContext returns the lines of the entry around count lines at the 1-based start:
+// the non-blank lines directly above and below them
+func entryContext(lines []string, start, count int) (before, after []string) {
+	first := start - 1
+	if first < 0 || first > len(lines) {
+		return nil, nil
+	}
+	from := first
+	for from > 0 && first-from < maxContext && strings.TrimSpace(lines[from-1]) != "" {
+		from--
+	}
+	before = append(before, lines[from:first]...)
+
+	last := min(first+count, len(lines))
+	to := last
+	for to < len(lines) && to-last < maxContext && strings.TrimSpace(lines[to]) != "" {
+		to++
+	}
+	after = append(after, lines[last:to]...)
+	return before, after
+}
+
+// pairChanges marks the differing span of removed and added lines changed in place
+// The nth removed line is paired with the nth added line; the rest change as a whole.
+func pairChanges(removed, added []string) ([]change, []change) {
+	left := make([]change, len(removed))
+	for i, line := range removed {
+		left[i] = change{text: line, from: 0, to: len([]rune(line))}
+	}
+	right := make([]change, len(added))
+	for i, line := range added {
+		right[i] = change{text: line, from: 0, to: len([]rune(line))}
+	}
+
+	for i := 0; i < min(len(removed), len(added)); i++ {
+		before, after := []rune(removed[i]), []rune(added[i])
+		prefix := 0
+		for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+			prefix++
+		}
+		suffix := 0
+		for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+			before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+			suffix++
+		}
+		left[i].from, left[i].to = prefix, len(before)-suffix
+		right[i].from, right[i].to = prefix, len(after)-suffix
+	}
+	return left, right
+}
+
+// renderChange styles a changed line, marking its differing span
+// Lines longer than width are cut, keeping the start; width < 0 keeps the whole line.
+func renderChange(c change, style lipgloss.Style, width int) string {
+	runes := []rune(c.text)
+	from, to := c.from, c.to
+	cut := false
+	if width >= 0 && components.Width(c.text) > width {
+		runes = []rune(components.Truncate(c.text, max(0, width-1), ""))
+		from, to = min(from, len(runes)), min(to, len(runes))
+		cut = true
+	}
+
+	var b strings.Builder
+	b.WriteString(style.Render(string(runes[:from])))
+	b.WriteString(style.Reverse(true).Render(string(runes[from:to])))
+	b.WriteString(style.Render(string(runes[to:])))
+	if cut {
+		b.WriteString(style.Render("…"))
+	}
+	return b.String()
+}
+
+// renderUnified lays out hunks as unified diffs: context lines, then the removed (-)
+// and added (+) lines with the changed part of each highlighted
+func renderUnified(hunks []hunk) []string {
+	var lines []string
+	for i, h := range hunks {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, theme.HighlightStyle.Render("  "+h.location))
+
+		removed, added := pairChanges(h.removed, h.added)
+		for _, line := range h.lead {
+			lines = append(lines, theme.MutedTextStyle.Render("    "+line))
+		}
+		for _, c := range removed {
+			lines = append(lines, theme.ErrorStyle.Render("  - ")+renderChange(c, theme.ErrorStyle, -1))
+		}
+		for _, c := range added {
+			lines = append(lines, theme.SuccessStyle.Render("  + ")+renderChange(c, theme.SuccessStyle, -1))
+		}
+		for _, line := range h.trail {
+			lines = append(lines, theme.MutedTextStyle.Render("    "+line))
+		}
+	}
+	return lines
+}
+
+// renderSideBySide lays out hunks in two columns, the old text on the left and the
+// new on the right, with changed lines facing each other
+func renderSideBySide(hunks []hunk, width int) []string {
+	column := max(10, (width-7)/2) // Margins, markers and the divider
+	side := func(marker, text string, style lipgloss.Style) string {
+		return style.Render(marker) + components.PadRight(text, column)
+	}
+	divider := theme.MutedTextStyle.Render(" │ ")
+	context := func(line string) string {
+		text := theme.MutedTextStyle.Render(components.Truncate(line, column, "…"))
+		return side(" ", text, theme.MutedTextStyle) + divider + side(" ", text, theme.MutedTextStyle)
+	}
+
+	var lines []string
+	for i, h := range hunks {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, theme.HighlightStyle.Render("  "+h.location))
+
+		removed, added := pairChanges(h.removed, h.added)
+		for _, line := range h.lead {
+			lines = append(lines, " "+context(line))
+		}
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			left, right := side(" ", "", theme.ErrorStyle), side(" ", "", theme.SuccessStyle)
+			if j < len(removed) {
+				left = side("-", renderChange(removed[j], theme.ErrorStyle, column), theme.ErrorStyle)
+			}
+			if j < len(added) {
+				right = side("+", renderChange(added[j], theme.SuccessStyle, column), theme.SuccessStyle)
+			}
+			lines = append(lines, " "+left+divider+right)
+		}
+		for _, line := range h.trail {
+			lines = append(lines, " "+context(line))
+		}
+	}
+	return lines
+}
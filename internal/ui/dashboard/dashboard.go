@@ -1,192 +1,479 @@
 package dashboard
 
 import (
-	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mmichie/lima/internal/anomaly"
 	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/goals"
+	"github.com/mmichie/lima/internal/recurring"
+	"github.com/mmichie/lima/internal/ui/components"
 	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
 )
 
-// Model represents the dashboard view model
+const (
+	// upcomingHorizon is how far ahead expected charges are listed
+	upcomingHorizon = 30 * 24 * time.Hour
+
+	// upcomingCount is how many expected charges the widget lists
+	upcomingCount = 6
+
+	// rankCount is how many payees or categories the top merchants and top categories
+	// widgets list
+	rankCount = 5
+
+	// averageMonths is how many months before this one the income and expenses average spans
+	averageMonths = 12
+
+	// anomalyHorizon is how far back unusual transactions are listed
+	anomalyHorizon = 30 * 24 * time.Hour
+
+	// anomalyCount is how many unusual transactions the widget lists
+	anomalyCount = 5
+)
+
+// rankedTotal is a payee's or category's spending in the current month
+type rankedTotal struct {
+	label string
+	total decimal.Decimal
+}
+
+// monthFlow is the income and expenses of a month, both positive when money comes
+// in and goes out
+type monthFlow struct {
+	income   decimal.Decimal
+	expenses decimal.Decimal
+}
+
+// net returns what the month saved, negative when it spent more than it earned
+func (f monthFlow) net() decimal.Decimal {
+	return f.income.Sub(f.expenses)
+}
+
+// add returns the sum of two months' flows
+func (f monthFlow) add(other monthFlow) monthFlow {
+	return monthFlow{income: f.income.Add(other.income), expenses: f.expenses.Add(other.expenses)}
+}
+
+// Model represents the dashboard view model: a grid of widgets laid out by config
 type Model struct {
-	file   *beancount.File
-	width  int
-	height int
+	file    *beancount.File
+	width   int
+	height  int
+	display components.Display
+
+	// layout holds the widget names of each grid row
+	layout [][]string
+
+	// currency is the commodity totals are converted into with the ledger's prices;
+	// empty shows native commodities side by side
+	currency string
 
 	// Cached statistics
 	totalTransactions int
 	totalAccounts     int
 	totalCommodities  int
 	recentCount       int
+	err               string
+
+	// month is the current calendar month, lastMonth the one before it
+	month     beancount.Period
+	lastMonth beancount.Period
+	today     time.Time
+
+	// Net worth (assets and liabilities) by commodity, and its change this month
+	netWorth       map[string]decimal.Decimal
+	netWorthChange map[string]decimal.Decimal
+
+	// Expenses in the main commodity this month, last month up to the same day, and all last month
+	commodity      string
+	spentMonth     decimal.Decimal
+	spentLastToDay decimal.Decimal
+	spentLast      decimal.Decimal
+
+	uncategorized int
+
+	// The latest transactions by date, newest first
+	recent []*beancount.Transaction
+
+	// Expected recurring charges, missing ones first
+	upcoming []recurring.Expected
+
+	// Payees and expense categories with the most spending this month, largest first
+	merchants  []rankedTotal
+	categories []rankedTotal
+
+	// Income and expenses in the main commodity this month, last month and on average
+	// over the averaged months before this one, at most averageMonths since the ledger starts
+	flowMonth    monthFlow
+	flowLast     monthFlow
+	flowAverage  monthFlow
+	flowAveraged int
+
+	// Unusual transactions of the last anomalyHorizon, newest first, flagged with thresholds
+	anomalyThresholds config.AnomaliesConfig
+	anomalies         []anomaly.Alert
+
+	// Configured goals and where each stands today
+	goals    []config.GoalConfig
+	progress []goals.Progress
 }
 
-// New creates a new dashboard model
-func New(file *beancount.File) Model {
-	return Model{
+// New creates a new dashboard model showing the widgets of layout, with totals in
+// currency ("" for native commodities)
+func New(file *beancount.File, layout [][]string, currency string) Model {
+	m := Model{
 		file:              file,
-		totalTransactions: file.TransactionCount(),
-		totalAccounts:     len(file.GetAccounts()),
-		totalCommodities:  len(file.GetCommodities()),
+		layout:            layout,
+		currency:          currency,
 		recentCount:       5,
+		anomalyThresholds: config.DefaultConfig().Anomalies,
 	}
+	return m.Reload()
 }
 
-// Init initializes the dashboard
-func (m Model) Init() tea.Cmd {
-	return nil
+// SetAnomalies changes the thresholds unusual transactions are flagged with and flags
+// them again
+func (m Model) SetAnomalies(thresholds config.AnomaliesConfig) Model {
+	if thresholds == m.anomalyThresholds {
+		return m
+	}
+	m.anomalyThresholds = thresholds
+	return m.Reload()
 }
 
-// Update handles messages
-func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	return m, nil
+// SetGoals changes the goals tracked and evaluates them again
+func (m Model) SetGoals(tracked []config.GoalConfig) Model {
+	if reflect.DeepEqual(tracked, m.goals) {
+		return m
+	}
+	m.goals = tracked
+	return m.Reload()
 }
 
-// View renders the dashboard with TP7 styling
-func (m Model) View() string {
-	if m.width == 0 {
-		return theme.NormalTextStyle.Render("Loading dashboard...")
+// SetCurrency changes the commodity totals are shown in and recomputes them
+// "" shows native commodities side by side.
+func (m Model) SetCurrency(currency string) Model {
+	if currency == m.currency {
+		return m
 	}
+	m.currency = currency
+	return m.Reload()
+}
 
-	// Title - fill full width
-	titleText := "Dashboard"
-	titlePadded := titleText
-	if m.width > len(titleText) {
-		titlePadded = titleText + strings.Repeat(" ", m.width-len(titleText))
+// Reload recomputes every widget's figures from the ledger
+func (m Model) Reload() Model {
+	m.totalTransactions = m.file.TransactionCount()
+	m.totalAccounts = len(m.file.GetAccounts())
+	m.totalCommodities = len(m.file.GetCommodities())
+
+	now := time.Now()
+	m.today = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	m.month = beancount.PeriodContaining(beancount.PeriodMonth, m.today)
+	m.lastMonth = m.month.Prev()
+
+	m.err = ""
+	transactions, err := m.file.AllTransactions()
+	if err != nil {
+		m.err = err.Error()
+	}
+	if m.recent, err = m.file.LatestTransactions(m.recentCount); err != nil {
+		m.err = err.Error()
+	}
+
+	m.upcoming = recurring.Upcoming(recurring.Detect(transactions), m.today, upcomingHorizon)
+
+	m.uncategorized = 0
+	for _, tx := range transactions {
+		if categorizer.IsUncategorized(tx) {
+			m.uncategorized++
+		}
 	}
-	title := theme.TitleStyle.Width(m.width).Render(titlePadded)
 
-	// Statistics boxes
-	stats := m.renderStats()
+	m.netWorth = make(map[string]decimal.Decimal)
+	m.netWorthChange = make(map[string]decimal.Decimal)
+	for account, balance := range beancount.AccountBalances(transactions) {
+		if !isBalanceSheet(account) {
+			continue
+		}
+		for commodity, number := range balance {
+			m.netWorth[commodity] = m.netWorth[commodity].Add(number)
+		}
+	}
 
-	// Recent transactions
-	recent := m.renderRecentTransactions()
+	var thisMonth []*beancount.Transaction
+	for _, tx := range transactions {
+		if m.month.Contains(tx.Date) {
+			thisMonth = append(thisMonth, tx)
+		}
+	}
+	for account, balance := range beancount.AccountBalances(thisMonth) {
+		if !isBalanceSheet(account) {
+			continue
+		}
+		for commodity, number := range balance {
+			m.netWorthChange[commodity] = m.netWorthChange[commodity].Add(number)
+		}
+	}
 
-	// Combine all sections
-	content := lipgloss.JoinVertical(lipgloss.Left,
-		title,
-		stats,
-		"",
-		recent,
-	)
+	// Holdings are valued at today's prices, flows at the prices of their own dates
+	if m.currency != "" {
+		prices := beancount.NewPriceDB(m.file.GetPriceDirectives())
+		m.netWorth = prices.ConvertBalance(m.netWorth, m.currency, m.today)
+		m.netWorthChange = prices.ConvertBalance(m.netWorthChange, m.currency, m.today)
+		transactions = prices.ConvertTransactions(transactions, m.currency)
+	}
 
-	return content
-}
+	m.anomalies = anomaly.Since(anomaly.Detect(transactions, m.anomalyThresholds), m.today.Add(-anomalyHorizon))
 
-// SetSize updates the dashboard size
-func (m Model) SetSize(width, height int) Model {
-	m.width = width
-	m.height = height
-	return m
+	m = m.refreshSpending(transactions)
+	m.progress = nil
+	for _, goal := range m.goals {
+		m.progress = append(m.progress, goals.Evaluate(transactions, goal, m.commodity, m.today))
+	}
+	return m.refreshFlows(transactions)
 }
 
-// renderStats renders the statistics boxes with TP7 styling
-func (m Model) renderStats() string {
-	// Use TP7 double-line box drawing characters
-	boxStyle := lipgloss.NewStyle().
-		Border(lipgloss.DoubleBorder()).
-		BorderForeground(lipgloss.Color(theme.TP7Cyan)).
-		BorderBackground(lipgloss.Color(theme.TP7Blue)).
-		Background(lipgloss.Color(theme.TP7Blue)).
-		Padding(1, 2).
-		Width(30)
-
-	// Transaction stats box
-	transactionsBox := boxStyle.Render(fmt.Sprintf(
-		"%s\n%s",
-		theme.MutedTextStyle.Render("Total Transactions"),
-		theme.HighlightStyle.Render(fmt.Sprintf("%d", m.totalTransactions)),
-	))
-
-	// Accounts box
-	accountsBox := boxStyle.Render(fmt.Sprintf(
-		"%s\n%s",
-		theme.MutedTextStyle.Render("Accounts"),
-		theme.HighlightStyle.Render(fmt.Sprintf("%d", m.totalAccounts)),
-	))
-
-	// Commodities box
-	commoditiesBox := boxStyle.Render(fmt.Sprintf(
-		"%s\n%s",
-		theme.MutedTextStyle.Render("Commodities"),
-		theme.HighlightStyle.Render(fmt.Sprintf("%d", m.totalCommodities)),
-	))
-
-	return lipgloss.JoinHorizontal(lipgloss.Top,
-		transactionsBox,
-		"  ",
-		accountsBox,
-		"  ",
-		commoditiesBox,
-	)
-}
-
-// renderRecentTransactions renders the most recent transactions with TP7 styling
-func (m Model) renderRecentTransactions() string {
-	var lines []string
-	lines = append(lines, "")
+// refreshSpending totals expenses this month and last, and by payee and category this month
+// Only the operating currency, or else the commodity most expense postings use, is counted
+func (m Model) refreshSpending(transactions []*beancount.Transaction) Model {
+	counts := make(map[string]int)
+	for _, tx := range transactions {
+		for _, posting := range tx.ResolvedPostings() {
+			if strings.HasPrefix(posting.Account, "Expenses") && posting.Amount != nil {
+				counts[posting.Amount.Commodity]++
+			}
+		}
+	}
+	m.commodity = ""
+	for commodity, count := range counts {
+		if m.commodity == "" || count > counts[m.commodity] || (count == counts[m.commodity] && commodity < m.commodity) {
+			m.commodity = commodity
+		}
+	}
 
-	// Title - fill full width
-	titleText := "Recent Transactions"
-	titlePadded := titleText
-	if m.width > len(titleText) {
-		titlePadded = titleText + strings.Repeat(" ", m.width-len(titleText))
+	if m.currency != "" {
+		m.commodity = m.currency
 	}
-	lines = append(lines, theme.TitleStyle.Width(m.width).Render(titlePadded))
-	lines = append(lines, "")
 
-	count := m.recentCount
-	if count > m.totalTransactions {
-		count = m.totalTransactions
+	// Last month up to the same day of the month as today, so a partial month compares fairly
+	lastToDay := m.lastMonth.Start.AddDate(0, 0, m.today.Day())
+
+	m.spentMonth, m.spentLastToDay, m.spentLast = decimal.Zero, decimal.Zero, decimal.Zero
+	byPayee := make(map[string]decimal.Decimal)
+	byCategory := make(map[string]decimal.Decimal)
+	for _, tx := range transactions {
+		inMonth, inLast := m.month.Contains(tx.Date), m.lastMonth.Contains(tx.Date)
+		if !inMonth && !inLast {
+			continue
+		}
+		spent := decimal.Zero
+		for _, posting := range tx.ResolvedPostings() {
+			if strings.HasPrefix(posting.Account, "Expenses") && posting.Amount != nil && posting.Amount.Commodity == m.commodity {
+				spent = spent.Add(posting.Amount.Number)
+				if inMonth {
+					byCategory[posting.Account] = byCategory[posting.Account].Add(posting.Amount.Number)
+				}
+			}
+		}
+		switch {
+		case inMonth:
+			m.spentMonth = m.spentMonth.Add(spent)
+			payee := tx.Payee
+			if payee == "" {
+				payee = tx.Narration
+			}
+			byPayee[payee] = byPayee[payee].Add(spent)
+		case tx.Date.Before(lastToDay):
+			m.spentLastToDay = m.spentLastToDay.Add(spent)
+			m.spentLast = m.spentLast.Add(spent)
+		default:
+			m.spentLast = m.spentLast.Add(spent)
+		}
 	}
 
-	for i := 0; i < count; i++ {
-		tx, err := m.file.GetTransaction(i)
-		if err != nil {
+	m.merchants = topTotals(byPayee)
+	m.categories = topTotals(byCategory)
+	return m
+}
+
+// refreshFlows totals income and expenses in the main commodity this month, last
+// month and per month over the year before this one
+// The average only spans the months since the first transaction, so a young ledger
+// isn't diluted by empty months.
+func (m Model) refreshFlows(transactions []*beancount.Transaction) Model {
+	m.flowMonth, m.flowLast, m.flowAverage = monthFlow{}, monthFlow{}, monthFlow{}
+	m.flowAveraged = 0
+
+	averageStart := m.month.Start.AddDate(0, -averageMonths, 0)
+	var first time.Time
+	var total monthFlow
+	for _, tx := range transactions {
+		if first.IsZero() || tx.Date.Before(first) {
+			first = tx.Date
+		}
+		if tx.Date.Before(averageStart) || !tx.Date.Before(m.month.End()) {
 			continue
 		}
+		var flow monthFlow
+		for _, posting := range tx.ResolvedPostings() {
+			if posting.Amount == nil || posting.Amount.Commodity != m.commodity {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(posting.Account, "Income"):
+				flow.income = flow.income.Sub(posting.Amount.Number)
+			case strings.HasPrefix(posting.Account, "Expenses"):
+				flow.expenses = flow.expenses.Add(posting.Amount.Number)
+			}
+		}
+		switch {
+		case m.month.Contains(tx.Date):
+			m.flowMonth = m.flowMonth.add(flow)
+		default:
+			total = total.add(flow)
+			if m.lastMonth.Contains(tx.Date) {
+				m.flowLast = m.flowLast.add(flow)
+			}
+		}
+	}
 
-		// Format date
-		dateStr := tx.Date.Format("2006-01-02")
+	if first.IsZero() {
+		return m
+	}
+	firstMonth := beancount.PeriodContaining(beancount.PeriodMonth, first).Start
+	for start := m.month.Start; m.flowAveraged < averageMonths; m.flowAveraged++ {
+		start = start.AddDate(0, -1, 0)
+		if start.Before(firstMonth) {
+			break
+		}
+	}
+	if m.flowAveraged > 0 {
+		months := decimal.NewFromInt(int64(m.flowAveraged))
+		m.flowAverage = monthFlow{income: total.income.Div(months), expenses: total.expenses.Div(months)}
+	}
+	return m
+}
 
-		// Format payee/narration
-		description := tx.Narration
-		if tx.Payee != "" {
-			description = tx.Payee + " - " + tx.Narration
+// topTotals returns the rankCount largest positive totals, largest first
+func topTotals(totals map[string]decimal.Decimal) []rankedTotal {
+	var ranked []rankedTotal
+	for label, total := range totals {
+		if total.IsPositive() {
+			ranked = append(ranked, rankedTotal{label: label, total: total})
 		}
-		if len(description) > 50 {
-			description = description[:47] + "..."
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if !ranked[i].total.Equal(ranked[j].total) {
+			return ranked[i].total.GreaterThan(ranked[j].total)
 		}
+		return ranked[i].label < ranked[j].label
+	})
+	if len(ranked) > rankCount {
+		ranked = ranked[:rankCount]
+	}
+	return ranked
+}
+
+// isBalanceSheet reports whether an account counts towards net worth
+func isBalanceSheet(account string) bool {
+	return strings.HasPrefix(account, "Assets") || strings.HasPrefix(account, "Liabilities")
+}
 
-		// Format flag with TP7 colors
-		flagStr := tx.Flag
-		if tx.Flag == "*" {
-			flagStr = theme.SuccessStyle.Render("*")
-		} else {
-			flagStr = theme.WarningStyle.Render("!")
+// Init initializes the dashboard
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return m, nil
+}
+
+// View renders the dashboard's widget rows with TP7 styling
+func (m Model) View() string {
+	if m.width == 0 {
+		return theme.NormalTextStyle.Render("Loading dashboard...")
+	}
+
+	// Title - fill full width
+	titleText := "Dashboard - " + m.display.Date(m.today)
+	if m.currency != "" {
+		titleText += " - in " + m.currency
+	}
+	titlePadded := components.PadRight(titleText, m.width)
+	sections := []string{theme.TitleStyle.Width(m.width).Render(titlePadded)}
+
+	if m.err != "" {
+		sections = append(sections, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+
+	for _, row := range m.layout {
+		if rendered := m.renderRow(row); rendered != "" {
+			sections = append(sections, rendered)
 		}
+	}
+	if len(sections) == 1 {
+		sections = append(sections, theme.MutedTextStyle.Render("  No widgets configured (see dashboard.layout in the config file)"))
+	}
 
-		// Format the line - simple text on blue background, fill full width
-		line := fmt.Sprintf("  %s  %-50s  %s",
-			theme.DateStyle.Render(dateStr),
-			theme.NormalTextStyle.Render(description),
-			flagStr,
-		)
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
 
-		// Pad to full width
-		lineLen := lipgloss.Width(line)
-		if m.width > lineLen {
-			line = line + theme.ListItemStyle.Render(strings.Repeat(" ", m.width-lineLen))
+// renderRow renders a row of widgets side by side in equal-width, equal-height boxes
+func (m Model) renderRow(names []string) string {
+	var row []widget
+	for _, name := range names {
+		if w, ok := widgets[name]; ok {
+			row = append(row, w)
 		}
+	}
+	if len(row) == 0 {
+		return ""
+	}
 
-		lines = append(lines, theme.ListItemStyle.Width(m.width).Render(line))
+	// Each box has a border and one column of padding on each side
+	boxWidth := m.width / len(row)
+	bodies := make([][]string, len(row))
+	height := 0
+	for i, w := range row {
+		width := boxWidth
+		if i == len(row)-1 {
+			width = m.width - boxWidth*(len(row)-1)
+		}
+		body := w.render(m, max(1, width-4))
+		bodies[i] = append([]string{theme.HighlightStyle.Render(w.title(m))}, body...)
+		height = max(height, len(bodies[i]))
 	}
 
-	if m.totalTransactions == 0 {
-		lines = append(lines, theme.MutedTextStyle.Render("  No transactions found"))
+	boxes := make([]string, len(row))
+	for i := range row {
+		width := boxWidth
+		if i == len(row)-1 {
+			width = m.width - boxWidth*(len(row)-1)
+		}
+		boxes[i] = theme.PanelStyle.Padding(0, 1).Width(max(1, width-2)).Height(height).Render(strings.Join(bodies[i], "\n"))
 	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, boxes...)
+}
 
-	return strings.Join(lines, "\n")
+// SetSize updates the dashboard size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m
+}
+
+// SetDisplay updates the dashboard display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m
 }
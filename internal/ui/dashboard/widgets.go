@@ -0,0 +1,447 @@
+package dashboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mmichie/lima/internal/anomaly"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/shopspring/decimal"
+)
+
+// widget is a dashboard panel; render returns its body lines fitted to width
+type widget struct {
+	title  func(m Model) string
+	render func(m Model, width int) []string
+}
+
+// widgets maps the config's widget names to their panels
+var widgets = map[string]widget{
+	"net_worth": {
+		title:  func(Model) string { return "Net Worth" },
+		render: Model.renderNetWorth,
+	},
+	"spending": {
+		title:  func(m Model) string { return "Spending - " + m.month.String() },
+		render: Model.renderSpending,
+	},
+	"income_expenses": {
+		title:  func(m Model) string { return "Income vs Expenses - " + m.month.String() },
+		render: Model.renderIncomeExpenses,
+	},
+	"uncategorized": {
+		title:  func(Model) string { return "Uncategorized" },
+		render: Model.renderUncategorized,
+	},
+	"recent_transactions": {
+		title:  func(Model) string { return "Recent Transactions" },
+		render: Model.renderRecentTransactions,
+	},
+	"upcoming_bills": {
+		title:  func(Model) string { return "Upcoming Bills" },
+		render: Model.renderUpcoming,
+	},
+	"top_merchants": {
+		title:  func(m Model) string { return "Top Merchants - " + m.month.String() },
+		render: Model.renderTopMerchants,
+	},
+	"top_categories": {
+		title:  func(m Model) string { return "Top Categories - " + m.month.String() },
+		render: Model.renderTopCategories,
+	},
+	"stats": {
+		title:  func(Model) string { return "Ledger" },
+		render: Model.renderStats,
+	},
+	"anomalies": {
+		title:  func(Model) string { return "Anomalies" },
+		render: Model.renderAnomalies,
+	},
+	"goals": {
+		title:  func(Model) string { return "Goals" },
+		render: Model.renderGoals,
+	},
+}
+
+// truncate shortens text to width cells, marking the cut with an ellipsis
+func truncate(text string, width int) string {
+	return components.Truncate(text, width, "…")
+}
+
+// renderNetWorth renders asset and liability totals per commodity with this month's change
+func (m Model) renderNetWorth(width int) []string {
+	commodities := make([]string, 0, len(m.netWorth))
+	for commodity, number := range m.netWorth {
+		if !number.IsZero() {
+			commodities = append(commodities, commodity)
+		}
+	}
+	sort.Strings(commodities)
+	if len(commodities) == 0 {
+		return []string{theme.MutedTextStyle.Render(truncate("No asset or liability balances", width))}
+	}
+
+	var lines []string
+	for _, commodity := range commodities {
+		total := m.display.Amount(m.netWorth[commodity], commodity)
+		lines = append(lines, theme.NormalTextStyle.Render(truncate(total, width)))
+
+		change := m.netWorthChange[commodity]
+		text := fmt.Sprintf("  %s this month", m.display.Signed(change, commodity))
+		style := theme.MutedTextStyle
+		switch {
+		case change.IsPositive():
+			style = theme.SuccessStyle
+		case change.IsNegative():
+			style = theme.ErrorStyle
+		}
+		lines = append(lines, style.Render(truncate(text, width)))
+	}
+	return lines
+}
+
+// renderSpending compares this month's expenses with last month's at the same point
+func (m Model) renderSpending(width int) []string {
+	if m.commodity == "" {
+		return []string{theme.MutedTextStyle.Render(truncate("No expenses yet", width))}
+	}
+
+	row := func(label string, amount decimal.Decimal) string {
+		value := m.display.Amount(amount, m.commodity)
+		return truncate(components.PadRight(label, width-components.Width(value))+value, width)
+	}
+	lines := []string{
+		theme.NormalTextStyle.Render(row("This month", m.spentMonth)),
+		theme.NormalTextStyle.Render(row("Last month to date", m.spentLastToDay)),
+		theme.MutedTextStyle.Render(row("All last month", m.spentLast)),
+	}
+
+	if m.spentLastToDay.IsPositive() {
+		change := m.spentMonth.Sub(m.spentLastToDay).Div(m.spentLastToDay).Mul(decimal.NewFromInt(100))
+		switch {
+		case change.IsPositive():
+			lines = append(lines, theme.ErrorStyle.Render(truncate(fmt.Sprintf("▲ %s%% vs last month", change.StringFixed(0)), width)))
+		case change.IsNegative():
+			lines = append(lines, theme.SuccessStyle.Render(truncate(fmt.Sprintf("▼ %s%% vs last month", change.Abs().StringFixed(0)), width)))
+		default:
+			lines = append(lines, theme.MutedTextStyle.Render(truncate("Same as last month", width)))
+		}
+	}
+	return lines
+}
+
+// renderIncomeExpenses renders this month's income, expenses and net, each compared
+// with last month and the monthly average; changes for the better are green
+func (m Model) renderIncomeExpenses(width int) []string {
+	if m.commodity == "" {
+		return []string{theme.MutedTextStyle.Render(truncate("No income or expenses yet", width))}
+	}
+
+	averageLabel := fmt.Sprintf("%d-mo avg", m.flowAveraged)
+	metric := func(label string, pick func(monthFlow) decimal.Decimal, upIsGood bool) []string {
+		current := pick(m.flowMonth)
+		value := m.display.Amount(current, m.commodity)
+		style := theme.NormalTextStyle
+		if label == "Net" {
+			switch {
+			case current.IsPositive():
+				style = theme.SuccessStyle
+			case current.IsNegative():
+				style = theme.ErrorStyle
+			}
+		}
+		lines := []string{style.Render(truncate(components.PadRight(label, width-components.Width(value))+value, width))}
+
+		comparisons := []string{renderChange(current, pick(m.flowLast), "last", upIsGood)}
+		if m.flowAveraged > 0 {
+			comparisons = append(comparisons, renderChange(current, pick(m.flowAverage), averageLabel, upIsGood))
+		}
+		separator := theme.NormalTextStyle.Render("  ")
+		return append(lines, truncate(separator+strings.Join(comparisons, separator), width))
+	}
+
+	lines := metric("Income", func(f monthFlow) decimal.Decimal { return f.income }, true)
+	lines = append(lines, metric("Expenses", func(f monthFlow) decimal.Decimal { return f.expenses }, false)...)
+	lines = append(lines, metric("Net", monthFlow.net, true)...)
+	return lines
+}
+
+// renderChange renders how current differs from base, e.g. "▲12% vs last", green
+// when the change is for the better
+func renderChange(current, base decimal.Decimal, label string, upIsGood bool) string {
+	change := current.Cmp(base)
+	if change == 0 {
+		return theme.MutedTextStyle.Render("= " + label)
+	}
+
+	text := "▲"
+	if change < 0 {
+		text = "▼"
+	}
+	if !base.IsZero() {
+		percent := current.Sub(base).Div(base.Abs()).Mul(decimal.NewFromInt(100)).Abs()
+		text += percent.StringFixed(0) + "%"
+	}
+	text += " vs " + label
+
+	if (change > 0) == upIsGood {
+		return theme.SuccessStyle.Render(text)
+	}
+	return theme.ErrorStyle.Render(text)
+}
+
+// renderUncategorized renders how many transactions still need a category
+func (m Model) renderUncategorized(width int) []string {
+	if m.uncategorized == 0 {
+		return []string{theme.SuccessStyle.Render(truncate("All transactions categorized", width))}
+	}
+	return []string{
+		theme.WarningStyle.Render(fmt.Sprintf("%d", m.uncategorized)),
+		theme.NormalTextStyle.Render(truncate("transactions need a category", width)),
+		theme.MutedTextStyle.Render(truncate("F7 to review them", width)),
+	}
+}
+
+// renderRecentTransactions renders the latest transactions by date with their amounts
+func (m Model) renderRecentTransactions(width int) []string {
+	if len(m.recent) == 0 {
+		return []string{theme.MutedTextStyle.Render("No transactions found")}
+	}
+
+	var lines []string
+	for _, tx := range m.recent {
+		// Amount of the first posting, as in the transactions list
+		amount := ""
+		amountStyle := theme.AmountStyle
+		if len(tx.Postings) > 0 && tx.Postings[0].Amount != nil {
+			number := tx.Postings[0].Amount.Number
+			amount = m.display.Amount(number, tx.Postings[0].Amount.Commodity)
+			if number.IsNegative() {
+				amountStyle = theme.AmountNegativeStyle
+			} else {
+				amountStyle = theme.AmountPositiveStyle
+			}
+		}
+
+		// Format payee/narration
+		description := tx.Narration
+		if tx.Payee != "" {
+			description = tx.Payee + " - " + tx.Narration
+		}
+		descriptionWidth := max(0, width-4-m.display.DateWidth()-components.Width(amount)-1)
+		description = components.Fit(description, descriptionWidth, "…")
+
+		// Format flag with TP7 colors
+		flagStr := theme.SuccessStyle.Render("*")
+		if tx.Flag != "*" {
+			flagStr = theme.WarningStyle.Render("!")
+		}
+
+		lines = append(lines, theme.DateStyle.Render(m.display.Date(tx.Date))+
+			theme.NormalTextStyle.Render(" ")+flagStr+
+			theme.NormalTextStyle.Render(" "+description+" ")+
+			amountStyle.Render(amount))
+	}
+	return lines
+}
+
+// renderUpcoming renders expected recurring charges, flagging missing ones
+func (m Model) renderUpcoming(width int) []string {
+	if len(m.upcoming) == 0 {
+		text := fmt.Sprintf("No recurring charges expected in the next %d days", int(upcomingHorizon.Hours()/24))
+		return []string{theme.MutedTextStyle.Render(truncate(text, width))}
+	}
+
+	var lines []string
+	missing := 0
+	for _, expected := range m.upcoming {
+		if expected.Missing {
+			missing++
+		}
+	}
+	if missing > 0 {
+		lines = append(lines, theme.ErrorStyle.Render(truncate(fmt.Sprintf("! %d expected charge(s) missing", missing), width)))
+	}
+
+	count := min(len(m.upcoming), upcomingCount)
+	for _, expected := range m.upcoming[:count] {
+		amount := m.display.Amount(expected.Amount, expected.Commodity)
+		note := string(expected.Cadence)
+		if expected.Missing {
+			note = "missing (" + note + ")"
+		}
+		payeeWidth := max(4, width-m.display.DateWidth()-components.Width(amount)-len(note)-4)
+		payee := components.Fit(expected.Payee, payeeWidth, "…")
+		line := fmt.Sprintf("%s %s %s %s", m.display.Date(expected.Date), payee, amount, note)
+
+		if expected.Missing {
+			lines = append(lines, theme.ErrorStyle.Render(truncate(line, width)))
+			continue
+		}
+		lines = append(lines, theme.DateStyle.Render(m.display.Date(expected.Date))+
+			theme.NormalTextStyle.Render(" "+payee+" "+amount+" ")+
+			theme.MutedTextStyle.Render(note))
+	}
+	if len(m.upcoming) > count {
+		lines = append(lines, theme.MutedTextStyle.Render(fmt.Sprintf("... and %d more", len(m.upcoming)-count)))
+	}
+	return lines
+}
+
+// renderTopMerchants charts the payees with the most spending this month
+func (m Model) renderTopMerchants(width int) []string {
+	return m.renderRanking(m.merchants, width)
+}
+
+// renderTopCategories charts the expense accounts with the most spending this month
+func (m Model) renderTopCategories(width int) []string {
+	categories := make([]rankedTotal, len(m.categories))
+	for i, category := range m.categories {
+		categories[i] = rankedTotal{label: strings.TrimPrefix(category.label, "Expenses:"), total: category.total}
+	}
+	return m.renderRanking(categories, width)
+}
+
+// renderRanking charts spending totals, largest first, with each one's amount and
+// share of this month's spending
+func (m Model) renderRanking(ranked []rankedTotal, width int) []string {
+	if len(ranked) == 0 {
+		return []string{theme.MutedTextStyle.Render(truncate("No spending this month", width))}
+	}
+
+	labelWidth := 0
+	for _, item := range ranked {
+		labelWidth = max(labelWidth, components.Width(item.label))
+	}
+	labelWidth = min(labelWidth, max(6, width/3))
+
+	largest := ranked[0].total
+	var lines []string
+	for _, item := range ranked {
+		amount := m.display.Number(item.total, m.commodity)
+		share := ""
+		if m.spentMonth.IsPositive() {
+			share = " " + components.PadLeft(item.total.Div(m.spentMonth).Mul(decimal.NewFromInt(100)).StringFixed(0)+"%", 4)
+		}
+		barWidth := max(1, width-labelWidth-components.Width(amount)-components.Width(share)-2)
+		fraction, _ := item.total.Div(largest).Float64()
+		bar := components.RenderBar(fraction, barWidth)
+		padding := strings.Repeat(" ", max(0, barWidth-components.Width(bar)))
+
+		lines = append(lines, theme.NormalTextStyle.Render(components.Fit(item.label, labelWidth, "…")+" ")+
+			theme.BarStyle.Render(bar)+
+			theme.NormalTextStyle.Render(padding+" "+amount)+
+			theme.MutedTextStyle.Render(share))
+	}
+	return lines
+}
+
+// renderStats renders ledger totals
+func (m Model) renderStats(width int) []string {
+	row := func(label string, count int) string {
+		value := fmt.Sprintf("%d", count)
+		return theme.NormalTextStyle.Render(truncate(components.PadRight(label, width-len(value))+value, width))
+	}
+	return []string{
+		row("Transactions", m.totalTransactions),
+		row("Accounts", m.totalAccounts),
+		row("Commodities", m.totalCommodities),
+	}
+}
+
+// renderAnomalies renders the latest unusual transactions with what makes each unusual
+func (m Model) renderAnomalies(width int) []string {
+	if len(m.anomalies) == 0 {
+		text := fmt.Sprintf("Nothing unusual in the last %d days", int(anomalyHorizon.Hours()/24))
+		return []string{theme.MutedTextStyle.Render(truncate(text, width))}
+	}
+
+	var lines []string
+	count := min(len(m.anomalies), anomalyCount)
+	for _, alert := range m.anomalies[:count] {
+		amount := m.display.Amount(alert.Amount.Number, alert.Amount.Commodity)
+		note := m.anomalyNote(alert)
+		payeeWidth := max(4, width-m.display.DateWidth()-components.Width(amount)-components.Width(note)-3)
+		payee := components.Fit(alert.Transaction.Payee, payeeWidth, "…")
+		lines = append(lines, theme.DateStyle.Render(m.display.Date(alert.Transaction.Date))+
+			theme.NormalTextStyle.Render(" "+payee+" "+amount+" ")+
+			theme.WarningStyle.Render(note))
+	}
+	if len(m.anomalies) > count {
+		lines = append(lines, theme.MutedTextStyle.Render(fmt.Sprintf("... and %d more", len(m.anomalies)-count)))
+	}
+	return lines
+}
+
+// anomalyNote says briefly what makes a transaction unusual
+func (m Model) anomalyNote(alert anomaly.Alert) string {
+	switch alert.Kind {
+	case anomaly.Unusual:
+		return fmt.Sprintf("%s× usual", alert.Ratio().StringFixed(1))
+	case anomaly.Duplicate:
+		return "repeats " + m.display.Date(alert.Original.Date)
+	}
+	return string(alert.Kind)
+}
+
+// renderGoals renders each goal's progress bar, marked where the pace puts it today,
+// with its status and how often it was met before
+func (m Model) renderGoals(width int) []string {
+	if len(m.progress) == 0 {
+		return []string{theme.MutedTextStyle.Render(truncate("No goals: add them under goals in the config", width))}
+	}
+
+	labelWidth := 0
+	for _, p := range m.progress {
+		labelWidth = max(labelWidth, components.Width(p.Goal.Name))
+	}
+	labelWidth = min(labelWidth, max(6, width/4))
+
+	var lines []string
+	for _, p := range m.progress {
+		amounts := m.display.Number(p.Current, p.Commodity) + " / " + m.display.Amount(p.Amount, p.Commodity)
+		barWidth := max(1, width-labelWidth-components.Width(amounts)-2)
+		bar := components.RenderBar(p.Fraction(), barWidth)
+		track := strings.Repeat(" ", max(0, barWidth-components.Width(bar)))
+		pace, _ := p.Expected.Div(p.Amount).Float64()
+		if cell := int(pace*float64(barWidth)) - components.Width(bar); cell >= 0 && cell < len(track) {
+			track = track[:cell] + "│" + track[cell+1:]
+		}
+
+		var style lipgloss.Style
+		switch {
+		case p.Status() == "over limit":
+			style = theme.ErrorStyle
+		case p.OnPace():
+			style = theme.SuccessStyle
+		default:
+			style = theme.WarningStyle
+		}
+		lines = append(lines, theme.NormalTextStyle.Render(components.PadRight(components.Fit(p.Goal.Name, labelWidth, "…"), labelWidth)+" ")+
+			style.Render(bar)+theme.MutedTextStyle.Render(track)+theme.NormalTextStyle.Render(" "+amounts))
+
+		gone := fmt.Sprintf("%.0f%% of %s gone", p.Elapsed*100, p.Period)
+		history := "months under"
+		if !p.IsLimit() {
+			gone = fmt.Sprintf("%.0f%% of the time to %s gone", p.Elapsed*100, p.Goal.By)
+			history = "month ends on pace"
+		}
+		note := "  " + gone
+		if len(p.History) > 0 {
+			var marks strings.Builder
+			for _, result := range p.History {
+				if result.Met {
+					marks.WriteString("✓")
+				} else {
+					marks.WriteString("✗")
+				}
+			}
+			note += fmt.Sprintf(" · %d of %d %s %s", p.Met(), len(p.History), history, marks.String())
+		}
+		lines = append(lines, style.Render("  "+p.Status())+theme.MutedTextStyle.Render(truncate(note, max(1, width-components.Width(p.Status())-2))))
+	}
+	return lines
+}
@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/confirm"
+	"github.com/mmichie/lima/internal/ui/entry"
+)
+
+// openEntry opens the new transaction form
+// The date defaults to the last added transaction's date, so a batch of receipts keeps its day
+func (m Model) openEntry() Model {
+	date := m.lastEntryDate
+	if date.IsZero() {
+		date = time.Now()
+	}
+	form := entry.New(m.file, date).SetDisplay(m.display).SetSize(m.width, m.height-2)
+	m.entry = &form
+	return m
+}
+
+// editEntry opens the entry form on an existing transaction
+func (m Model) editEntry(tx *beancount.Transaction) Model {
+	form := entry.Edit(m.file, tx).SetDisplay(m.display).SetSize(m.width, m.height-2)
+	m.entry = &form
+	return m
+}
+
+// entrySavedMsg reports the outcome of writing the entry form's transaction
+type entrySavedMsg struct {
+	edit     beancount.Edit
+	tx       *beancount.Transaction
+	original *beancount.Transaction
+	err      error
+}
+
+// updateEntry handles keys while the entry form is open
+func (m Model) updateEntry(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, cancelKey):
+		m.entry = nil
+		return m, nil
+
+	case key.Matches(msg, saveKey):
+		return m.saveEntry()
+	}
+
+	form := m.entry.Update(msg)
+	m.entry = &form
+	return m, nil
+}
+
+// saveEntry asks to write the form's transaction to the ledger
+// New transactions are appended; edited ones are rewritten in place
+func (m Model) saveEntry() (tea.Model, tea.Cmd) {
+	original := m.entry.Original()
+	tx, err := m.entry.Transaction()
+	var edit beancount.Edit
+	if err == nil {
+		if original != nil {
+			edit, err = m.writer.UpdateTransaction(original, tx)
+		} else {
+			edit, err = m.writer.AppendTransaction(tx)
+		}
+	}
+	if err != nil {
+		form := m.entry.SetError(err)
+		m.entry = &form
+		return m, nil
+	}
+
+	title := "Add " + m.display.Date(tx.Date) + " " + entryLabel(tx)
+	if original != nil {
+		title = "Update " + m.display.Date(tx.Date) + " " + entryLabel(tx)
+	}
+	return m.requestWrite(confirm.RequestMsg{
+		Title: title,
+		Edits: []beancount.Edit{edit},
+		Done: func(err error) tea.Msg {
+			return entrySavedMsg{edit: edit, tx: tx, original: original, err: err}
+		},
+	})
+}
+
+// entrySaved closes the form once its transaction is written, or shows why it wasn't
+func (m Model) entrySaved(msg entrySavedMsg) Model {
+	if msg.err != nil {
+		if m.entry != nil {
+			form := m.entry.SetError(msg.err)
+			m.entry = &form
+		}
+		return m
+	}
+
+	action, description := "Added", "new transaction"
+	if msg.original != nil {
+		action, description = "Updated", "transaction edit"
+	}
+	m.journal.record(editBatch{
+		edits:       []beancount.Edit{msg.edit},
+		description: description,
+	})
+
+	m.entry = nil
+	if msg.original == nil {
+		m.lastEntryDate = msg.tx.Date
+	}
+	m.transactions = m.transactions.Reload()
+	m = m.recheck()
+	return m.notifyf(components.LevelSuccess, "%s %s %s (u: undo)", action, m.display.Date(msg.tx.Date), entryLabel(msg.tx))
+}
+
+// entryLabel names a transaction by its payee, or its narration without one
+func entryLabel(tx *beancount.Transaction) string {
+	if tx.Payee == "" {
+		return tx.Narration
+	}
+	return tx.Payee
+}
+
+// handleMenu runs the action for a chosen menu entry
+func (m Model) handleMenu(msg components.MenuSelectedMsg) (tea.Model, tea.Cmd) {
+	switch msg.Item {
+	case "New Transaction":
+		return m.openEntry(), nil
+	case "Open":
+		return m.openFileDialog(), nil
+	case "Import":
+		return m.openImportDialog(), nil
+	case "Sync":
+		return m.startSync()
+	case "Merge Payees":
+		return m.openMergeDialog(), nil
+	case "Export":
+		// Reports > Export exports the active report from any view
+		if msg.Menu == "Reports" && m.currentView != ReportsView {
+			m = m.showReports()
+		}
+		return m.openExportDialog(), nil
+	case "Exit":
+		return m, tea.Quit
+	case "Dashboard":
+		return m.showDashboard(), nil
+	case "Transactions":
+		m.currentView = TransactionsView
+	case "Accounts":
+		return m.showAccounts(), nil
+	case "Reports":
+		return m.showReports(), nil
+	case "Patterns":
+		return m.showPatterns(), nil
+	case "Review":
+		return m.startReview(), nil
+	case "Budgets":
+		return m.showBudgets(), nil
+	case "Tags":
+		return m.showTags(), nil
+	case "Problems":
+		return m.showProblems(), nil
+	case "Imports":
+		m.currentView = ImportsView
+	case "Theme":
+		return m.nextTheme(), nil
+	case "Currency":
+		return m.nextCurrency(), nil
+	case "Compact Mode":
+		return m.toggleCompact(), nil
+	case "Keyboard Shortcuts":
+		return m.openHelp(), nil
+	default:
+		m = m.notifyf(components.LevelInfo, "%s > %s is not available yet", msg.Menu, msg.Item)
+	}
+	return m, nil
+}
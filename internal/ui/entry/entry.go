@@ -0,0 +1,607 @@
+package entry
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/shopspring/decimal"
+)
+
+// Header field indexes; posting fields follow as account/amount pairs
+const (
+	fieldDate = iota
+	fieldPayee
+	fieldNarration
+	headerFields
+)
+
+// minPostings is the number of posting rows a new form starts with
+const minPostings = 2
+
+// defaultCommodity is used when the ledger has no amounts to learn from
+const defaultCommodity = "USD"
+
+// postingInputs is one editable posting row
+type postingInputs struct {
+	account components.TextInput
+	amount  components.TextInput
+
+	// Set for postings loaded from the ledger
+	line   int               // Source line, so the writer can rewrite it in place
+	price  *beancount.Amount // Price, used to weigh the amount when balancing
+	elided bool              // The ledger left the amount for beancount to balance
+}
+
+// Model is the new or edit transaction form
+// The caller handles enter (save) and esc (cancel) and writes the result
+type Model struct {
+	width   int
+	height  int
+	display components.Display
+
+	date      components.TextInput
+	payee     components.TextInput
+	narration components.TextInput
+	postings  []postingInputs
+	focus     int
+
+	accounts  []string
+	commodity string
+
+	// history maps a lower-cased payee to its most recent transaction
+	history map[string]*beancount.Transaction
+
+	// today anchors relative dates (injected for tests)
+	today time.Time
+
+	// original is the transaction being edited (nil for a new transaction)
+	original *beancount.Transaction
+	// baseResidual is the original's imbalance as the form sees it (e.g., from costs it
+	// does not show); saving an unchanged imbalance is allowed
+	baseResidual string
+
+	err     error
+	message string
+}
+
+// New creates an empty form with the date set to date
+// Payee and account completions and the default commodity come from the ledger's history
+func New(file *beancount.File, date time.Time) Model {
+	m := newModel(file)
+	m.date = m.date.SetValue(date.Format("2006-01-02"))
+	for i := 0; i < minPostings; i++ {
+		m.addPosting()
+	}
+	m.date = m.date.Focus()
+	return m
+}
+
+// Edit creates a form loaded with an existing transaction
+// Tags, links, metadata and comments are not shown and are kept as they are
+func Edit(file *beancount.File, tx *beancount.Transaction) Model {
+	m := newModel(file)
+	m.original = tx
+	m.date = m.date.SetValue(tx.Date.Format("2006-01-02"))
+	m.payee = m.payee.SetValue(tx.Payee)
+	m.narration = m.narration.SetValue(tx.Narration)
+	for _, posting := range tx.Postings {
+		m.addPosting()
+		row := &m.postings[len(m.postings)-1]
+		row.account = row.account.SetValue(posting.Account)
+		row.line = posting.LineNumber
+		row.price = posting.Price
+		row.elided = posting.Amount == nil
+		if posting.Amount != nil {
+			row.amount = row.amount.SetValue(formatAmount(posting.Amount.Number, posting.Amount.Commodity, m.commodity))
+		}
+	}
+	for len(m.postings) < minPostings {
+		m.addPosting()
+	}
+	if blank, residual, err := m.balance(); err == nil && blank < 0 && len(residual) > 0 {
+		m.baseResidual = formatResidual(residual)
+	}
+	m.updateBalancePlaceholder()
+	m.focus = fieldPayee
+	m.payee = m.payee.Focus()
+	return m
+}
+
+// Original returns the transaction being edited, or nil for a new transaction
+func (m Model) Original() *beancount.Transaction {
+	return m.original
+}
+
+// newModel creates a form without postings, learning completions from the ledger
+func newModel(file *beancount.File) Model {
+	m := Model{
+		date:      components.NewTextInput(fmt.Sprintf("%-10s", "Date")).SetWidth(12),
+		payee:     components.NewTextInput(fmt.Sprintf("%-10s", "Payee")).SetWidth(50),
+		narration: components.NewTextInput(fmt.Sprintf("%-10s", "Narration")).SetWidth(50),
+		commodity: defaultCommodity,
+		history:   make(map[string]*beancount.Transaction),
+		today:     time.Now(),
+	}
+	m.date.Placeholder = "today"
+
+	transactions, _ := file.AllTransactions()
+	m.learn(transactions, file.GetAccounts())
+	return m
+}
+
+// learn collects payees and accounts ranked by use, the payees' latest transactions
+// and the most used commodity
+func (m *Model) learn(transactions []*beancount.Transaction, accounts []string) {
+	commodities := make(map[string]int)
+
+	for _, tx := range transactions {
+		for _, posting := range tx.Postings {
+			if posting.Amount != nil {
+				commodities[posting.Amount.Commodity]++
+			}
+		}
+		if tx.Payee == "" {
+			continue
+		}
+		key := strings.ToLower(tx.Payee)
+		if latest, ok := m.history[key]; !ok || !tx.Date.Before(latest.Date) {
+			m.history[key] = tx
+		}
+	}
+
+	// Payees and accounts used often and lately complete first
+	m.payee.Suggestions = beancount.RankPayees(transactions, m.today)
+	m.accounts = beancount.RankAccounts(transactions, accounts, m.today)
+
+	best := 0
+	for commodity, n := range commodities {
+		if n > best || (n == best && commodity < m.commodity) {
+			m.commodity, best = commodity, n
+		}
+	}
+}
+
+// addPosting appends an empty posting row
+func (m *Model) addPosting() {
+	n := len(m.postings) + 1
+	row := postingInputs{
+		account: components.NewTextInput(fmt.Sprintf("%-10s", fmt.Sprintf("Posting %d", n))).SetWidth(40),
+		amount:  components.NewTextInput("").SetWidth(16),
+	}
+	row.account.Suggestions = m.accounts
+	m.postings = append(m.postings, row)
+}
+
+// removePosting deletes posting row i, keeping at least minPostings rows
+func (m *Model) removePosting(i int) {
+	if len(m.postings) <= minPostings || i < 0 || i >= len(m.postings) {
+		return
+	}
+	m.postings = append(m.postings[:i], m.postings[i+1:]...)
+	for j := range m.postings {
+		m.postings[j].account.Label = fmt.Sprintf("%-10s", fmt.Sprintf("Posting %d", j+1))
+	}
+}
+
+// fieldCount is the number of focusable fields
+func (m Model) fieldCount() int {
+	return headerFields + 2*len(m.postings)
+}
+
+// input returns a pointer to focusable field i
+func (m *Model) input(i int) *components.TextInput {
+	switch i {
+	case fieldDate:
+		return &m.date
+	case fieldPayee:
+		return &m.payee
+	case fieldNarration:
+		return &m.narration
+	}
+	row := &m.postings[(i-headerFields)/2]
+	if (i-headerFields)%2 == 0 {
+		return &row.account
+	}
+	return &row.amount
+}
+
+// postingIndex returns the posting row of the focused field, or -1 in the header
+func (m Model) postingIndex() int {
+	if m.focus < headerFields {
+		return -1
+	}
+	return (m.focus - headerFields) / 2
+}
+
+// setFocus moves keyboard focus to field i, wrapping around
+func (m *Model) setFocus(i int) {
+	if m.focus == fieldPayee && i != fieldPayee {
+		m.fillFromHistory()
+	}
+	if i >= m.fieldCount() {
+		i = 0
+	}
+	if i < 0 {
+		i = m.fieldCount() - 1
+	}
+	*m.input(m.focus) = m.input(m.focus).Blur()
+	m.focus = i
+	*m.input(m.focus) = m.input(m.focus).Focus()
+}
+
+// fillFromHistory copies the postings of the payee's latest transaction
+// It only fills an untouched form so typed values are never overwritten
+func (m *Model) fillFromHistory() {
+	if m.original != nil {
+		return
+	}
+	tx, ok := m.history[strings.ToLower(strings.TrimSpace(m.payee.Value()))]
+	if !ok {
+		return
+	}
+	for _, row := range m.postings {
+		if row.account.Value() != "" || row.amount.Value() != "" {
+			return
+		}
+	}
+
+	m.payee = m.payee.SetValue(tx.Payee)
+	if m.narration.Value() == "" {
+		m.narration = m.narration.SetValue(tx.Narration)
+	}
+	m.postings = nil
+	for _, posting := range tx.Postings {
+		m.addPosting()
+		row := &m.postings[len(m.postings)-1]
+		row.account = row.account.SetValue(posting.Account)
+		if posting.Amount != nil {
+			row.amount = row.amount.SetValue(formatAmount(posting.Amount.Number, posting.Amount.Commodity, m.commodity))
+		}
+	}
+	for len(m.postings) < minPostings {
+		m.addPosting()
+	}
+	m.message = fmt.Sprintf("Filled from %s %s", m.display.Date(tx.Date), tx.Payee)
+}
+
+// keyMap defines key bindings for the form; saving and cancelling are left to the caller
+type keyMap struct {
+	Next          key.Binding
+	Prev          key.Binding
+	AddPosting    key.Binding
+	RemovePosting key.Binding
+}
+
+var keys = keyMap{
+	Next: key.NewBinding(
+		key.WithKeys("tab", "down"),
+		key.WithHelp("tab/↓", "next field"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("shift+tab", "up"),
+		key.WithHelp("shift+tab/↑", "previous field"),
+	),
+	AddPosting: key.NewBinding(
+		key.WithKeys("ctrl+n"),
+		key.WithHelp("ctrl+n", "add posting"),
+	),
+	RemovePosting: key.NewBinding(
+		key.WithKeys("ctrl+d"),
+		key.WithHelp("ctrl+d", "remove posting"),
+	),
+}
+
+// ShortHelp returns the form's key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	return []key.Binding{keys.Next, keys.AddPosting, keys.RemovePosting}
+}
+
+// Update handles a key for the focused field
+// ctrl+n adds a posting and ctrl+d removes the focused one
+func (m Model) Update(msg tea.KeyMsg) Model {
+	m.err = nil
+	switch {
+	case key.Matches(msg, keys.Next) && msg.Type != tea.KeyTab:
+		// Tab completes first, see below
+		m.setFocus(m.focus + 1)
+		return m
+	case key.Matches(msg, keys.Prev):
+		m.setFocus(m.focus - 1)
+		return m
+	case key.Matches(msg, keys.AddPosting):
+		m.addPosting()
+		m.setFocus(m.fieldCount() - 2)
+		return m
+	case key.Matches(msg, keys.RemovePosting):
+		if i := m.postingIndex(); i >= 0 && len(m.postings) > minPostings {
+			m.removePosting(i)
+			focus := m.focus
+			if focus >= m.fieldCount() {
+				focus = m.fieldCount() - 2
+			}
+			// The focused input was removed, so focus without blurring it
+			m.focus = focus
+			*m.input(m.focus) = m.input(m.focus).Focus()
+		}
+		return m
+	}
+
+	input, handled := m.input(m.focus).Update(msg)
+	*m.input(m.focus) = input
+	if !handled && msg.Type == tea.KeyTab {
+		// Tab without a completion moves to the next field
+		m.setFocus(m.focus + 1)
+	}
+	m.updateBalancePlaceholder()
+	return m
+}
+
+// updateBalancePlaceholder shows the auto-balancing amount in the one blank amount field
+func (m *Model) updateBalancePlaceholder() {
+	for i := range m.postings {
+		m.postings[i].amount.Placeholder = ""
+	}
+	blank, residual, err := m.balance()
+	if err != nil || blank < 0 || len(residual) != 1 {
+		return
+	}
+	for commodity, number := range residual {
+		m.postings[blank].amount.Placeholder = formatAmount(number.Neg(), commodity, "")
+	}
+}
+
+// balance sums the entered amounts per commodity
+// blank is the only posting with an account but no amount (-1 if none)
+func (m Model) balance() (int, map[string]decimal.Decimal, error) {
+	blank := -1
+	residual := make(map[string]decimal.Decimal)
+	for i, row := range m.postings {
+		account := strings.TrimSpace(row.account.Value())
+		text := strings.TrimSpace(row.amount.Value())
+		if text == "" {
+			if account != "" {
+				if blank >= 0 {
+					return -1, nil, fmt.Errorf("only one posting may be left blank for auto-balancing")
+				}
+				blank = i
+			}
+			continue
+		}
+		amount, err := parseAmount(text, m.commodity)
+		if err != nil {
+			return -1, nil, fmt.Errorf("posting %d: %w", i+1, err)
+		}
+		if row.price != nil {
+			amount = &beancount.Amount{Number: amount.Number.Mul(row.price.Number), Commodity: row.price.Commodity}
+		}
+		residual[amount.Commodity] = residual[amount.Commodity].Add(amount.Number)
+	}
+	for commodity, number := range residual {
+		if number.IsZero() {
+			delete(residual, commodity)
+		}
+	}
+	return blank, residual, nil
+}
+
+// Transaction builds the transaction from the form
+// A single blank amount is filled with the balancing amount
+func (m Model) Transaction() (*beancount.Transaction, error) {
+	date, err := parseDate(m.date.Value(), m.today)
+	if err != nil {
+		return nil, err
+	}
+	payee := strings.TrimSpace(m.payee.Value())
+	narration := strings.TrimSpace(m.narration.Value())
+	if payee == "" && narration == "" {
+		return nil, fmt.Errorf("enter a payee or narration")
+	}
+
+	blank, residual, err := m.balance()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &beancount.Transaction{
+		Date:      date,
+		Flag:      "*",
+		Payee:     payee,
+		Narration: narration,
+	}
+	if m.original != nil {
+		tx.Flag = m.original.Flag
+		tx.Tags = m.original.Tags
+		tx.Links = m.original.Links
+		tx.Metadata = m.original.Metadata
+		tx.FilePath = m.original.FilePath
+		tx.FilePosition = m.original.FilePosition
+		tx.LineNumber = m.original.LineNumber
+	}
+	for i, row := range m.postings {
+		account := strings.TrimSpace(row.account.Value())
+		text := strings.TrimSpace(row.amount.Value())
+		if account == "" {
+			if text != "" {
+				return nil, fmt.Errorf("posting %d: account is required", i+1)
+			}
+			continue
+		}
+
+		posting := beancount.Posting{Account: account, LineNumber: row.line, Price: row.price}
+		switch {
+		case text != "":
+			posting.Amount, _ = parseAmount(text, m.commodity)
+		case row.elided:
+			// Left for beancount to balance, as in the ledger
+		case i == blank && len(residual) == 1:
+			for commodity, number := range residual {
+				posting.Amount = &beancount.Amount{Number: number.Neg(), Commodity: commodity}
+			}
+		}
+		tx.Postings = append(tx.Postings, posting)
+	}
+
+	if len(tx.Postings) < 2 {
+		return nil, fmt.Errorf("enter at least two postings")
+	}
+	if blank < 0 && len(residual) > 0 && formatResidual(residual) != m.baseResidual {
+		return nil, fmt.Errorf("transaction does not balance: off by %s", formatResidual(residual))
+	}
+	if blank >= 0 && len(residual) == 0 {
+		return nil, fmt.Errorf("posting %d has nothing to balance", blank+1)
+	}
+	return tx, nil
+}
+
+// parseAmount parses "12.50", "-3" or "12.50 EUR"; the commodity defaults to commodity
+func parseAmount(s, commodity string) (*beancount.Amount, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid amount: %s", s)
+	}
+	number, err := decimal.NewFromString(strings.ReplaceAll(fields[0], ",", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %s", s)
+	}
+	if len(fields) == 2 {
+		commodity = strings.ToUpper(fields[1])
+	}
+	return &beancount.Amount{Number: number, Commodity: commodity}, nil
+}
+
+// formatAmount renders an amount for an input, omitting the default commodity
+func formatAmount(number decimal.Decimal, commodity, defaultCommodity string) string {
+	text := number.String()
+	if number.Exponent() >= -2 {
+		text = number.StringFixed(2)
+	}
+	if commodity != defaultCommodity {
+		text += " " + commodity
+	}
+	return text
+}
+
+// formatResidual renders per-commodity imbalances in a stable order
+func formatResidual(residual map[string]decimal.Decimal) string {
+	commodities := make([]string, 0, len(residual))
+	for commodity := range residual {
+		commodities = append(commodities, commodity)
+	}
+	sort.Strings(commodities)
+
+	parts := make([]string, 0, len(commodities))
+	for _, commodity := range commodities {
+		parts = append(parts, formatAmount(residual[commodity], commodity, ""))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseDate accepts YYYY-MM-DD, MM-DD (this year), DD (this month), "today"/"t",
+// "yesterday"/"y" and -N for N days ago; empty means today
+func parseDate(s string, today time.Time) (time.Time, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	year, month, day := today.Date()
+	base := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	switch s {
+	case "", "t", "today":
+		return base, nil
+	case "y", "yesterday":
+		return base.AddDate(0, 0, -1), nil
+	}
+	if strings.HasPrefix(s, "-") {
+		if n, err := strconv.Atoi(s[1:]); err == nil && n >= 0 {
+			return base.AddDate(0, 0, -n), nil
+		}
+	}
+	if date, err := time.Parse("2006-01-02", s); err == nil {
+		return date, nil
+	}
+	if date, err := time.Parse("01-02", s); err == nil {
+		return time.Date(year, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	if d, err := strconv.Atoi(s); err == nil && d >= 1 && d <= 31 {
+		date := time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+		if date.Month() == month {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s (use YYYY-MM-DD, MM-DD, DD, today, yesterday or -N)", s)
+}
+
+// SetError shows an error, e.g. when writing the transaction failed
+func (m Model) SetError(err error) Model {
+	m.err = err
+	return m
+}
+
+// View renders the form
+func (m Model) View() string {
+	title := "New Transaction"
+	if m.original != nil {
+		title = fmt.Sprintf("Edit Transaction (%s:%d)", filepath.Base(m.original.FilePath), m.original.LineNumber)
+	}
+
+	var lines []string
+	lines = append(lines, theme.TitleStyle.Render(title), "")
+	lines = append(lines, "  "+m.date.View())
+	lines = append(lines, "  "+m.payee.View())
+	lines = append(lines, "  "+m.narration.View())
+	lines = append(lines, "")
+	for _, row := range m.postings {
+		lines = append(lines, "  "+row.account.View()+"  "+row.amount.View())
+	}
+	lines = append(lines, "")
+
+	blank, residual, balanceErr := m.balance()
+	switch {
+	case m.err != nil:
+		lines = append(lines, theme.ErrorStyle.Render("  "+m.err.Error()))
+	case balanceErr != nil:
+		lines = append(lines, theme.ErrorStyle.Render("  "+balanceErr.Error()))
+	case len(residual) == 0 && blank < 0:
+		lines = append(lines, theme.SuccessStyle.Render("  Balanced"))
+	case len(residual) == 0:
+		lines = append(lines, theme.ErrorStyle.Render(fmt.Sprintf("  Posting %d has nothing to balance", blank+1)))
+	case blank >= 0 && m.postings[blank].elided:
+		lines = append(lines, theme.HighlightStyle.Render(fmt.Sprintf("  Posting %d is left for beancount to balance", blank+1)))
+	case blank >= 0 && len(residual) == 1:
+		lines = append(lines, theme.HighlightStyle.Render(fmt.Sprintf("  Posting %d balances with %s", blank+1, m.postings[blank].amount.Placeholder)))
+	case blank >= 0:
+		lines = append(lines, theme.HighlightStyle.Render(fmt.Sprintf("  Posting %d is left for beancount to balance", blank+1)))
+	case formatResidual(residual) == m.baseResidual:
+		lines = append(lines, theme.MutedTextStyle.Render("  Off by "+m.baseResidual+" before costs, as in the ledger"))
+	default:
+		lines = append(lines, theme.ErrorStyle.Render("  Off by "+formatResidual(residual)))
+	}
+	if m.message != "" {
+		lines = append(lines, theme.MutedTextStyle.Render("  "+m.message))
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, theme.MutedTextStyle.Render("  tab/↑↓:field   tab:complete   ctrl+n:add posting   ctrl+d:remove posting   enter:save   esc:cancel"))
+	lines = append(lines, theme.MutedTextStyle.Render(fmt.Sprintf("  Dates: YYYY-MM-DD, MM-DD, DD, today, yesterday, -N   Amounts default to %s; leave one blank to balance", m.commodity)))
+	return strings.Join(lines, "\n")
+}
+
+// SetSize updates the form size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m
+}
+
+// SetDisplay updates the form display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m
+}
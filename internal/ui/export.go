@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/export"
+)
+
+// openExportDialog opens the Export dialog for the transaction list or the active report,
+// with the path in the current ledger's directory
+func (m Model) openExportDialog() Model {
+	var table export.Table
+	switch m.currentView {
+	case TransactionsView:
+		var err error
+		if table, err = m.transactions.Export(); err != nil {
+			return m.notifyf(components.LevelError, "Export failed: %v", err)
+		}
+	case ReportsView:
+		table = m.reports.Export()
+	default:
+		return m.notify(components.LevelInfo, "Export works in the transactions and reports views")
+	}
+
+	dialog := export.New(filepath.Dir(m.file.Path()), table).SetSize(m.width, m.height-2)
+	m.export = &dialog
+	return m
+}
+
+// updateExport handles keys while the export dialog is shown
+func (m Model) updateExport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, cancelKey):
+		m.export = nil
+		return m, nil
+
+	case key.Matches(msg, exportKey):
+		return m.writeExport(), nil
+	}
+
+	dialog := m.export.Update(msg)
+	m.export = &dialog
+	return m, nil
+}
+
+// writeExport saves the table at the typed path and closes the dialog
+// On failure the dialog stays open with the error.
+func (m Model) writeExport() Model {
+	path, table := m.export.Path(), m.export.Table()
+	fail := func(err error) Model {
+		dialog := m.export.SetError(err)
+		m.export = &dialog
+		return m
+	}
+	if path == "" {
+		return fail(fmt.Errorf("enter the path of a .csv or .json file"))
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fail(fmt.Errorf("%s is a directory", path))
+	}
+	if err := export.Write(path, table); err != nil {
+		return fail(err)
+	}
+
+	m.export = nil
+	return m.notifyf(components.LevelSuccess, "Exported %s (%d rows) to %s", table.Name, len(table.Rows), path)
+}
@@ -0,0 +1,127 @@
+// Package export implements the Export dialog, which saves the transaction list or
+// a report as CSV or JSON at a path typed with tab completion
+package export
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/fileopen"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// maxMatches is how many completion candidates are listed after an ambiguous tab
+const maxMatches = 8
+
+// Model is the export dialog
+type Model struct {
+	table Table
+	input components.TextInput
+
+	// matches are the candidates of the last ambiguous completion
+	matches []string
+
+	err    error
+	width  int
+	height int
+}
+
+// New creates the dialog for a table, with the path prefilled with a CSV file
+// named after the table in dir
+func New(dir string, table Table) Model {
+	name := strings.ReplaceAll(table.Name, " ", "-") + ".csv"
+	input := components.NewTextInput("Path").SetValue(filepath.Join(dir, name)).Focus()
+	input.Placeholder = "~/finances/" + name
+	return Model{table: table, input: input}
+}
+
+// Table returns the data being exported
+func (m Model) Table() Table {
+	return m.table
+}
+
+// Path returns the file to write, with ~ expanded
+func (m Model) Path() string {
+	return config.ExpandHome(strings.TrimSpace(m.input.Value()))
+}
+
+// SetError shows why the table could not be written
+func (m Model) SetError(err error) Model {
+	m.err = err
+	return m
+}
+
+// keyMap defines key bindings for the dialog; saving and cancelling are left to the caller
+type keyMap struct {
+	Complete key.Binding
+}
+
+var keys = keyMap{
+	Complete: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "complete"),
+	),
+}
+
+// ShortHelp returns the dialog's key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	return []key.Binding{keys.Complete}
+}
+
+// Update handles keys; enter and esc are left to the caller
+func (m Model) Update(msg tea.KeyMsg) Model {
+	m.err = nil
+	if key.Matches(msg, keys.Complete) {
+		var value string
+		value, m.matches = fileopen.CompletePath(m.input.Value())
+		m.input = m.input.SetValue(value)
+		return m
+	}
+	m.matches = nil
+	m.input, _ = m.input.Update(msg)
+	return m
+}
+
+// View renders the dialog
+func (m Model) View() string {
+	var lines []string
+	rows := "rows"
+	if len(m.table.Rows) == 1 {
+		rows = "row"
+	}
+	lines = append(lines, theme.TitleStyle.Render("Export"), "")
+	lines = append(lines, theme.NormalTextStyle.Render(fmt.Sprintf("  %d %s of %s", len(m.table.Rows), rows, m.table.Name)), "")
+	lines = append(lines, "  "+m.input.SetWidth(max(20, m.width-12)).View())
+
+	if len(m.matches) > 0 {
+		shown := m.matches
+		if len(shown) > maxMatches {
+			shown = shown[:maxMatches]
+		}
+		lines = append(lines, theme.MutedTextStyle.Render("  "+strings.Join(shown, "  ")))
+		if len(m.matches) > maxMatches {
+			lines = append(lines, theme.MutedTextStyle.Render(fmt.Sprintf("  ... and %d more", len(m.matches)-maxMatches)))
+		}
+	}
+
+	if m.err != nil {
+		lines = append(lines, "", theme.ErrorStyle.Render("  "+m.err.Error()))
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, theme.MutedTextStyle.Render("  The extension picks the format: "+strings.Join(Formats, " or ")))
+	lines = append(lines, theme.MutedTextStyle.Render("  tab:complete   enter:export   esc:cancel"))
+	return strings.Join(lines, "\n")
+}
+
+// SetSize updates the dialog size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m
+}
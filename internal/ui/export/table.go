@@ -0,0 +1,179 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Table is the data of a view in export form: named columns and rows of text cells
+type Table struct {
+	// Name describes the rows in messages and default file names, e.g. "transactions"
+	Name    string
+	Columns []string
+	Rows    [][]string
+}
+
+// Formats lists the supported file extensions
+var Formats = []string{".csv", ".json"}
+
+// Encodings lists the names Encode accepts
+var Encodings = []string{"table", "csv", "json"}
+
+// Write saves a table to path, as CSV or JSON depending on its extension
+// CSV has a header row; JSON is an array of objects keyed by column name.
+func Write(path string, table Table) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if !slices.Contains(Formats, ext) {
+		return fmt.Errorf("unsupported format %q: use a %s file", filepath.Ext(path), strings.Join(Formats, " or "))
+	}
+	data, err := Encode(table, strings.TrimPrefix(ext, "."))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Encode renders a table as "csv", "json" or "table", text with aligned columns for
+// reading in a terminal
+func Encode(table Table, encoding string) ([]byte, error) {
+	var data []byte
+	var err error
+	switch encoding {
+	case "table":
+		data = encodeText(table)
+	case "csv":
+		data, err = encodeCSV(table)
+	case "json":
+		data, err = encodeJSON(table)
+	default:
+		return nil, fmt.Errorf("unsupported format %q: use %s", encoding, strings.Join(Encodings, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", table.Name, err)
+	}
+	return data, nil
+}
+
+// encodeText renders the header, a rule and the rows in columns padded to their
+// widest cell; columns of numbers and amounts are aligned right
+func encodeText(table Table) []byte {
+	widths := make([]int, len(table.Columns))
+	numeric := make([]bool, len(table.Columns))
+	for j, column := range table.Columns {
+		widths[j] = utf8.RuneCountInString(column)
+		numeric[j] = len(table.Rows) > 0
+	}
+	for _, row := range table.Rows {
+		for j := range table.Columns {
+			var cell string
+			if j < len(row) {
+				cell = row[j]
+			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(cell))
+			if cell != "" && !isNumeric(cell) {
+				numeric[j] = false
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	line := func(cells []string) {
+		var b strings.Builder
+		for j := range table.Columns {
+			var cell string
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			if j > 0 {
+				b.WriteString("  ")
+			}
+			pad := strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+			if numeric[j] {
+				b.WriteString(pad + cell)
+			} else {
+				b.WriteString(cell + pad)
+			}
+		}
+		buf.WriteString(strings.TrimRight(b.String(), " "))
+		buf.WriteString("\n")
+	}
+	line(table.Columns)
+	rule := make([]string, len(widths))
+	for j, width := range widths {
+		rule[j] = strings.Repeat("-", width)
+	}
+	line(rule)
+	for _, row := range table.Rows {
+		line(row)
+	}
+	return buf.Bytes()
+}
+
+// isNumeric reports whether a cell starts with a number, as amounts like
+// "-12.50 USD" do
+func isNumeric(cell string) bool {
+	cell = strings.TrimPrefix(cell, "-")
+	return cell != "" && cell[0] >= '0' && cell[0] <= '9'
+}
+
+// encodeCSV renders the header and rows as CSV
+func encodeCSV(table Table) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(table.Columns); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(table.Rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeJSON renders the rows as an array of objects, keeping the column order
+// of the table in each object
+func encodeJSON(table Table) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, row := range table.Rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  {")
+		for j, column := range table.Columns {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			var cell string
+			if j < len(row) {
+				cell = row[j]
+			}
+			name, err := json.Marshal(column)
+			if err != nil {
+				return nil, err
+			}
+			value, err := json.Marshal(cell)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(name)
+			buf.WriteString(": ")
+			buf.Write(value)
+		}
+		buf.WriteString("}")
+	}
+	if len(table.Rows) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/fileopen"
+)
+
+// openFileDialog opens the File > Open dialog in the current ledger's directory
+func (m Model) openFileDialog() Model {
+	recent := fileopen.LoadRecent(m.config.Files.RecentFiles)
+	dialog := fileopen.New(filepath.Dir(m.file.Path()), recent).SetSize(m.width, m.height-2)
+	m.fileOpen = &dialog
+	return m
+}
+
+// updateFileOpen handles keys while the file open dialog is shown
+func (m Model) updateFileOpen(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, cancelKey):
+		m.fileOpen = nil
+		return m, nil
+
+	case key.Matches(msg, openKey):
+		return m.openLedger(m.fileOpen.Path())
+	}
+
+	dialog := m.fileOpen.Update(msg)
+	m.fileOpen = &dialog
+	return m, nil
+}
+
+// openLedger swaps in another ledger and rebuilds every view for it
+// The session's undo history belongs to the old ledger and is dropped.
+// On failure the dialog stays open with the error.
+func (m Model) openLedger(path string) (tea.Model, tea.Cmd) {
+	fail := func(err error) (tea.Model, tea.Cmd) {
+		dialog := m.fileOpen.SetError(err)
+		m.fileOpen = &dialog
+		return m, nil
+	}
+	if path == "" {
+		return fail(fmt.Errorf("enter the path of a ledger to open"))
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fail(fmt.Errorf("%s is a directory", path))
+	}
+
+	// The store and the mirror belong to the ledger shown until what it wrote is saved
+	if m.savePending() {
+		return fail(fmt.Errorf("changes to %s are still being saved; try again in a moment", m.file.Path()))
+	}
+	file, err := m.file.Store().Open(path)
+	if err != nil {
+		return fail(fmt.Errorf("failed to open %s: %w", path, err))
+	}
+	// The old ledger's session is kept for when it is opened again
+	saveErr := m.SaveSession()
+	m.file.Close()
+
+	opened := New(file, m.global).RestoreSession()
+	opened.showHelpRow = m.showHelpRow
+	resized, _ := opened.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+	opened = resized.(Model)
+	opened.ready = m.ready
+
+	// Keep the message log, showing the new ledger's warnings after the confirmation
+	warnings := opened.notices.active
+	opened.notices = m.notices
+	opened = opened.notify(components.LevelSuccess, "Opened "+path)
+	for _, warning := range warnings {
+		opened = opened.notify(warning.Level, warning.Text)
+	}
+	if saveErr != nil {
+		opened = opened.notifyf(components.LevelWarning, "Session not saved: %v", saveErr)
+	}
+	if err := fileopen.Remember(m.config.Files.RecentFiles, path); err != nil {
+		opened = opened.notifyf(components.LevelWarning, "Recent files not saved: %v", err)
+	}
+	// The config check already running carries on for the new ledger
+	cmd := opened.Init()
+	if m.source.path != "" {
+		opened = opened.WatchConfig(m.source.path, m.source.profile)
+	}
+	return opened, cmd
+}
@@ -0,0 +1,255 @@
+// Package fileopen implements the File > Open dialog: a path input with tab
+// completion and a list of recently opened ledgers
+package fileopen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// maxMatches is how many completion candidates are listed after an ambiguous tab
+const maxMatches = 8
+
+// Model is the file open dialog
+type Model struct {
+	input  components.TextInput
+	recent []string
+
+	// cursor selects a recent file; -1 while the path input has focus
+	cursor int
+
+	// matches are the candidates of the last ambiguous completion
+	matches []string
+
+	// title names the dialog and action what enter does; "" for opening a ledger
+	title  string
+	action string
+
+	err    error
+	width  int
+	height int
+}
+
+// New creates the dialog with the path input prefilled with dir
+func New(dir string, recent []string) Model {
+	if dir != "" && !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir += string(filepath.Separator)
+	}
+	input := components.NewTextInput("Path").SetValue(dir).Focus()
+	input.Placeholder = "~/finances/main.beancount"
+	return Model{input: input, recent: recent, cursor: -1}
+}
+
+// Path returns the file to open: the selected recent file, or the typed path
+// with ~ expanded
+func (m Model) Path() string {
+	if m.cursor >= 0 && m.cursor < len(m.recent) {
+		return m.recent[m.cursor]
+	}
+	return config.ExpandHome(strings.TrimSpace(m.input.Value()))
+}
+
+// SetTitle names the dialog and what enter does, e.g. "Import Statement" and "import",
+// for choosing a file other than a ledger
+func (m Model) SetTitle(title, action string) Model {
+	m.title = title
+	m.action = action
+	return m
+}
+
+// SetPlaceholder sets the example path shown while the input is empty
+func (m Model) SetPlaceholder(placeholder string) Model {
+	m.input.Placeholder = placeholder
+	return m
+}
+
+// SetError shows why the chosen file could not be opened
+func (m Model) SetError(err error) Model {
+	m.err = err
+	return m
+}
+
+// keyMap defines key bindings for the dialog; opening and cancelling are left to the caller
+type keyMap struct {
+	Complete key.Binding
+	Up       key.Binding
+	Down     key.Binding
+}
+
+var keys = keyMap{
+	Complete: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "complete"),
+	),
+	Up: key.NewBinding(
+		key.WithKeys("up", "shift+tab"),
+		key.WithHelp("↑", "previous recent"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down"),
+		key.WithHelp("↓", "next recent"),
+	),
+}
+
+// ShortHelp returns the dialog's key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	return []key.Binding{keys.Complete, keys.Up, keys.Down}
+}
+
+// Update handles keys; enter and esc are left to the caller
+func (m Model) Update(msg tea.KeyMsg) Model {
+	m.err = nil
+	switch {
+	case key.Matches(msg, keys.Up):
+		if m.cursor >= 0 {
+			m.cursor--
+		}
+		m.input = m.focusInput()
+		return m
+
+	case key.Matches(msg, keys.Down):
+		if m.cursor < len(m.recent)-1 {
+			m.cursor++
+		}
+		m.input = m.focusInput()
+		return m
+
+	case key.Matches(msg, keys.Complete):
+		if m.cursor >= 0 {
+			// Edit the selected recent file from the input
+			m.input = m.input.SetValue(m.recent[m.cursor])
+			m.cursor = -1
+			m.input = m.focusInput()
+			return m
+		}
+		var value string
+		value, m.matches = CompletePath(m.input.Value())
+		m.input = m.input.SetValue(value)
+		return m
+	}
+
+	// Typing goes to the path input
+	if m.cursor >= 0 {
+		m.cursor = -1
+		m.input = m.focusInput()
+	}
+	m.matches = nil
+	m.input, _ = m.input.Update(msg)
+	return m
+}
+
+// focusInput focuses the path input unless a recent file is selected
+func (m Model) focusInput() components.TextInput {
+	if m.cursor >= 0 {
+		return m.input.Blur()
+	}
+	return m.input.Focus()
+}
+
+// View renders the dialog
+func (m Model) View() string {
+	var lines []string
+	title, action := "Open Ledger", "open"
+	if m.title != "" {
+		title, action = m.title, m.action
+	}
+	lines = append(lines, theme.TitleStyle.Render(title), "")
+	lines = append(lines, "  "+m.input.SetWidth(max(20, m.width-12)).View())
+
+	if len(m.matches) > 0 {
+		shown := m.matches
+		if len(shown) > maxMatches {
+			shown = shown[:maxMatches]
+		}
+		lines = append(lines, theme.MutedTextStyle.Render("  "+strings.Join(shown, "  ")))
+		if len(m.matches) > maxMatches {
+			lines = append(lines, theme.MutedTextStyle.Render(fmt.Sprintf("  ... and %d more", len(m.matches)-maxMatches)))
+		}
+	}
+
+	if m.err != nil {
+		lines = append(lines, "", theme.ErrorStyle.Render("  "+m.err.Error()))
+	}
+
+	lines = append(lines, "", theme.HighlightStyle.Render("  Recent files"))
+	if len(m.recent) == 0 {
+		lines = append(lines, theme.MutedTextStyle.Render("  (none yet)"))
+	}
+	for i, path := range m.recent {
+		line := "  " + path
+		if i == m.cursor {
+			lines = append(lines, theme.SelectedItemStyle.Render(line+"  "))
+		} else {
+			lines = append(lines, theme.ListItemStyle.Render(line))
+		}
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, theme.MutedTextStyle.Render("  tab:complete   ↑↓:recent files   enter:"+action+"   esc:cancel"))
+	return strings.Join(lines, "\n")
+}
+
+// SetSize updates the dialog size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m
+}
+
+// CompletePath extends a path to the longest prefix shared by the entries it matches
+// Directories complete with a trailing separator. When several entries match, they
+// are returned (sorted) so the caller can list them. Hidden entries only match a
+// prefix that starts with a dot.
+func CompletePath(value string) (string, []string) {
+	dirPart, prefix := filepath.Split(value)
+	dir := config.ExpandHome(dirPart)
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return value, nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return value, nil
+	}
+	sort.Strings(names)
+
+	common := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	// Don't split a multi-byte character
+	for !utf8.ValidString(common) {
+		common = common[:len(common)-1]
+	}
+	if len(names) == 1 {
+		return dirPart + common, nil
+	}
+	return dirPart + common, names
+}
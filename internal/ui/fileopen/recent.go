@@ -0,0 +1,60 @@
+package fileopen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// maxRecent is how many ledgers the history keeps
+const maxRecent = 10
+
+// LoadRecent reads the recent files history, most recent first
+// A missing or unreadable history is empty
+func LoadRecent(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(config.ExpandHome(path))
+	if err != nil {
+		return nil
+	}
+
+	var recent []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			recent = append(recent, line)
+		}
+	}
+	return recent
+}
+
+// Remember moves a ledger to the top of the history stored at path
+func Remember(path, file string) error {
+	if path == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", file, err)
+	}
+
+	recent := []string{abs}
+	for _, existing := range LoadRecent(path) {
+		if existing != abs && len(recent) < maxRecent {
+			recent = append(recent, existing)
+		}
+	}
+
+	path = config.ExpandHome(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(recent, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write recent files: %w", err)
+	}
+	return nil
+}
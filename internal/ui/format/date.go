@@ -0,0 +1,22 @@
+package format
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// Date renders a date in the configured format
+func (f Formatter) Date(t time.Time) string {
+	if f.dateLayout == "" {
+		return t.Format(config.DateLayout(""))
+	}
+	return t.Format(f.dateLayout)
+}
+
+// DateWidth returns the most cells a date takes in the configured format, for
+// sizing date columns; it measures a date with the longest month and weekday names
+func (f Formatter) DateWidth() int {
+	return utf8.RuneCountInString(f.Date(time.Date(2024, time.September, 25, 0, 0, 0, 0, time.UTC)))
+}
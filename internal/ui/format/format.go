@@ -0,0 +1,132 @@
+// Package format renders amounts and dates for display: decimal mark, thousands
+// grouping, commodity symbols, per-commodity precision and the date format
+//
+// Views render with the Formatter the root model builds from the config and hands
+// them; amounts and dates written to the ledger never go through this package.
+package format
+
+import (
+	"strings"
+
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// defaultPrecision is the number of decimal places of commodities without a configured precision
+const defaultPrecision = 2
+
+// locale is how a locale writes numbers
+// The zero locale is the ledger style: a period for the decimal mark, no grouping.
+type locale struct {
+	decimal     string // Empty for a period
+	thousands   string
+	symbolAfter bool
+}
+
+// locales maps config.AmountLocales to their conventions
+var locales = map[string]locale{
+	"":   {},
+	"en": {thousands: ","},
+	"de": {decimal: ",", thousands: ".", symbolAfter: true},
+	"fr": {decimal: ",", thousands: " ", symbolAfter: true},
+	"ch": {thousands: "'"},
+}
+
+// Formatter renders amounts following an amounts configuration, and dates in the
+// configured date format
+// The zero Formatter renders amounts in the ledger style and dates as ISO dates.
+type Formatter struct {
+	locale     locale
+	symbols    map[string]string
+	precision  map[string]int
+	dateLayout string // Go time layout; empty for the default
+}
+
+// New creates a formatter from the amounts configuration and the date format, a
+// preset name or a Go time layout
+// Unknown locales fall back to the ledger style; the config is validated on load.
+func New(amounts config.AmountsConfig, dateFormat string) Formatter {
+	l := locales[amounts.Locale]
+	if amounts.DecimalMark != "" {
+		l.decimal = amounts.DecimalMark
+	}
+	if amounts.ThousandsSeparator != "" {
+		l.thousands = amounts.ThousandsSeparator
+	}
+	switch amounts.SymbolPosition {
+	case "before":
+		l.symbolAfter = false
+	case "after":
+		l.symbolAfter = true
+	}
+	return Formatter{
+		locale:     l,
+		symbols:    amounts.Symbols,
+		precision:  amounts.Precision,
+		dateLayout: config.DateLayout(dateFormat),
+	}
+}
+
+// Precision returns the decimal places amounts of a commodity are shown with
+func (f Formatter) Precision(commodity string) int {
+	if places, ok := f.precision[commodity]; ok {
+		return places
+	}
+	return defaultPrecision
+}
+
+// Number renders a number in the commodity's precision, without the commodity
+// It suits columns whose commodity is shown elsewhere, e.g. in a header.
+func (f Formatter) Number(number decimal.Decimal, commodity string) string {
+	digits := number.Abs().StringFixed(int32(f.Precision(commodity)))
+	whole, fraction, _ := strings.Cut(digits, ".")
+
+	var b strings.Builder
+	if number.Round(int32(f.Precision(commodity))).IsNegative() {
+		b.WriteString("-")
+	}
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(f.locale.thousands)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		mark := f.locale.decimal
+		if mark == "" {
+			mark = "."
+		}
+		b.WriteString(mark + fraction)
+	}
+	return b.String()
+}
+
+// Signed renders a number like Number, with a plus sign on positive numbers
+// It is used for changes, such as a price rise
+func (f Formatter) Signed(number decimal.Decimal, commodity string) string {
+	text := f.Number(number, commodity)
+	if number.Round(int32(f.Precision(commodity))).IsPositive() {
+		return "+" + text
+	}
+	return text
+}
+
+// Amount renders a number with its commodity
+// A commodity with a symbol is shown as the symbol, placed as the locale does
+// ($1,234.56, 1.234,56 €); otherwise the code follows the number (1,234.56 USD).
+func (f Formatter) Amount(number decimal.Decimal, commodity string) string {
+	text := f.Number(number, commodity)
+	symbol, ok := f.symbols[commodity]
+	switch {
+	case commodity == "":
+		return text
+	case !ok:
+		return text + " " + commodity
+	case f.locale.symbolAfter:
+		return text + " " + symbol
+	}
+	if rest, negative := strings.CutPrefix(text, "-"); negative {
+		return "-" + symbol + rest
+	}
+	return symbol + text
+}
@@ -0,0 +1,96 @@
+package format
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+func TestAmount(t *testing.T) {
+	symbols := map[string]string{"USD": "$", "EUR": "€"}
+	precision := map[string]int{"JPY": 0, "BTC": 8}
+
+	tests := []struct {
+		name      string
+		cfg       config.AmountsConfig
+		number    string
+		commodity string
+		want      string
+	}{
+		{"ledger style", config.AmountsConfig{}, "1234567.891", "USD", "1234567.89 USD"},
+		{"ledger style negative", config.AmountsConfig{}, "-5", "USD", "-5.00 USD"},
+		{"en grouping", config.AmountsConfig{Locale: "en"}, "1234567.891", "USD", "1,234,567.89 USD"},
+		{"en symbol", config.AmountsConfig{Locale: "en", Symbols: symbols}, "-1234.5", "USD", "-$1,234.50"},
+		{"de symbol after", config.AmountsConfig{Locale: "de", Symbols: symbols}, "1234.5", "EUR", "1.234,50 €"},
+		{"fr", config.AmountsConfig{Locale: "fr"}, "-987654.3", "EUR", "-987 654,30 EUR"},
+		{"ch", config.AmountsConfig{Locale: "ch"}, "1000", "CHF", "1'000.00 CHF"},
+		{"no symbol for commodity", config.AmountsConfig{Locale: "en", Symbols: symbols}, "12", "GBP", "12.00 GBP"},
+		{"symbol position override", config.AmountsConfig{Locale: "de", Symbols: symbols, SymbolPosition: "before"}, "3", "EUR", "€3,00"},
+		{"custom marks", config.AmountsConfig{Locale: "en", DecimalMark: ",", ThousandsSeparator: " "}, "1234.5", "USD", "1 234,50 USD"},
+		{"zero precision", config.AmountsConfig{Locale: "en", Precision: precision}, "1234567.6", "JPY", "1,234,568 JPY"},
+		{"high precision", config.AmountsConfig{Precision: precision}, "0.00012345", "BTC", "0.00012345 BTC"},
+		{"rounds to zero without sign", config.AmountsConfig{}, "-0.001", "USD", "0.00 USD"},
+		{"short numbers", config.AmountsConfig{Locale: "en"}, "999.99", "USD", "999.99 USD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.cfg, "").Amount(decimal.RequireFromString(tt.number), tt.commodity)
+			if got != tt.want {
+				t.Errorf("Amount(%s, %s) = %q, want %q", tt.number, tt.commodity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumberAndSigned(t *testing.T) {
+	f := New(config.AmountsConfig{Locale: "de", Symbols: map[string]string{"EUR": "€"}}, "")
+	if got := f.Number(decimal.RequireFromString("-1234.5"), "EUR"); got != "-1.234,50" {
+		t.Errorf("Number = %q, want %q", got, "-1.234,50")
+	}
+	if got := f.Signed(decimal.RequireFromString("2"), "EUR"); got != "+2,00" {
+		t.Errorf("Signed = %q, want %q", got, "+2,00")
+	}
+	if got := f.Signed(decimal.RequireFromString("-2"), "EUR"); got != "-2,00" {
+		t.Errorf("Signed = %q, want %q", got, "-2,00")
+	}
+	if got := f.Signed(decimal.Zero, "EUR"); got != "0,00" {
+		t.Errorf("Signed = %q, want %q", got, "0,00")
+	}
+}
+
+func TestZeroFormatter(t *testing.T) {
+	var f Formatter
+	if got, want := f.Amount(decimal.NewFromInt(1500), "USD"), New(config.AmountsConfig{}, "").Amount(decimal.NewFromInt(1500), "USD"); got != want {
+		t.Errorf("expected the ledger style from the zero formatter, got %q, want %q", got, want)
+	}
+	if got := f.Date(time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)); got != "2024-03-05" {
+		t.Errorf("expected ISO dates from the zero formatter, got %q", got)
+	}
+}
+
+func TestDate(t *testing.T) {
+	date := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		format string
+		want   string
+		width  int
+	}{
+		{"", "2024-03-05", 10},
+		{"iso", "2024-03-05", 10},
+		{"us", "03/05/2024", 10},
+		{"eu", "05.03.2024", 10},
+		{"Mon 2 Jan 2006", "Tue 5 Mar 2024", 15},
+		{"2 January 2006", "5 March 2024", 17},
+	}
+	for _, tt := range tests {
+		f := New(config.AmountsConfig{}, tt.format)
+		if got := f.Date(date); got != tt.want {
+			t.Errorf("Date with %q = %q, want %q", tt.format, got, tt.want)
+		}
+		if got := f.DateWidth(); got != tt.width {
+			t.Errorf("DateWidth with %q = %d, want %d", tt.format, got, tt.width)
+		}
+	}
+}
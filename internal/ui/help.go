@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"slices"
+
+	keyhelp "github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/help"
+)
+
+// helpUsage is the basic usage shown below the key bindings
+var helpUsage = []string{
+	"Lima reads and edits a beancount ledger in place; every edit can be undone this session.",
+	"Search (/) in transactions combines text with terms like account:food tag:trip amount>50 date:2024-03.",
+	"Review steps through uncategorized transactions: pick a suggestion with 1-9 or accept the first.",
+	"Reports and budgets drill down into the matching transactions with enter.",
+	"The menu bar opens with F10 or alt+letter; key bindings are set in config.yaml.",
+}
+
+// bindings returns the global key bindings in the order the help overlay lists them
+func (k keyMap) bindings() []key.Binding {
+	return []key.Binding{
+		k.Dashboard, k.Transactions, k.Accounts, k.Reports, k.Patterns, k.Review, k.Budgets, k.Tags, k.Problems, k.Imports,
+		k.NewTransaction, k.OpenFile, k.Export, k.Messages, k.Theme, k.Currency, k.Compact, k.LargerPage, k.SmallerPage, k.HelpRow, k.Undo, k.Redo, k.Help, k.Quit,
+	}
+}
+
+// functionKeys lists the TP7-style F-key shortcuts handled in Update
+var functionKeys = []key.Binding{
+	key.NewBinding(key.WithKeys("f1"), key.WithHelp("F1", "help")),
+	key.NewBinding(key.WithKeys("f2"), key.WithHelp("F2", "dashboard")),
+	key.NewBinding(key.WithKeys("f3"), key.WithHelp("F3", "transactions")),
+	key.NewBinding(key.WithKeys("f4"), key.WithHelp("F4", "accounts")),
+	key.NewBinding(key.WithKeys("f5"), key.WithHelp("F5", "reports")),
+	key.NewBinding(key.WithKeys("f6"), key.WithHelp("F6", "patterns")),
+	key.NewBinding(key.WithKeys("f7"), key.WithHelp("F7", "review")),
+	key.NewBinding(key.WithKeys("f8"), key.WithHelp("F8", "budgets")),
+	key.NewBinding(key.WithKeys("f9"), key.WithHelp("F9", "tags")),
+	key.NewBinding(key.WithKeys("f10"), key.WithHelp("F10", "menu")),
+}
+
+// Keys handled by the root model while a dialog is open
+var (
+	saveKey   = key.NewBinding(key.WithKeys("enter", "ctrl+s"), key.WithHelp("enter", "save"))
+	openKey   = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "open"))
+	exportKey = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "export"))
+	importKey = key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "import"))
+	cancelKey = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel"))
+	closeKey  = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "close"))
+)
+
+// helpRowHeight is the number of lines of the help row; FullHelp columns are at most this long
+const helpRowHeight = 4
+
+// viewKeyMap returns the key bindings of the current view; the dashboard has none
+func (m Model) viewKeyMap() keyhelp.KeyMap {
+	switch m.currentView {
+	case TransactionsView:
+		return m.transactions
+	case AccountsView:
+		return m.accounts
+	case ReportsView:
+		return m.reports
+	case PatternsView:
+		return m.patterns
+	case ReviewView:
+		return m.review
+	case BudgetsView:
+		return m.budgets
+	case TagsView:
+		return m.tags
+	case ProblemsView:
+		return m.problems
+	case ImportsView:
+		return m.imports
+	}
+	return nil
+}
+
+// footerBindings returns the key hints for the status bar: the open dialog's,
+// else the current view's between the help and menu function keys
+func (m Model) footerBindings() []key.Binding {
+	switch {
+	case m.confirm != nil:
+		return m.confirm.ShortHelp()
+	case m.entry != nil:
+		return append(m.entry.ShortHelp(), saveKey, cancelKey)
+	case m.fileOpen != nil:
+		return append(m.fileOpen.ShortHelp(), openKey, cancelKey)
+	case m.importFile != nil:
+		return append(m.importFile.ShortHelp(), importKey, cancelKey)
+	case m.export != nil:
+		return append(m.export.ShortHelp(), exportKey, cancelKey)
+	case m.merge != nil:
+		return append(m.merge.ShortHelp(), closeKey)
+	case m.help != nil:
+		return append(m.help.ShortHelp(), closeKey)
+	case m.showLog:
+		return []key.Binding{closeKey}
+	}
+
+	last := len(functionKeys) - 1
+	view := m.viewKeyMap()
+	if view == nil {
+		return slices.Concat(functionKeys[:last], []key.Binding{m.keys.HelpRow, functionKeys[last]})
+	}
+	return slices.Concat(functionKeys[:1], view.ShortHelp(), []key.Binding{m.keys.HelpRow, functionKeys[last]})
+}
+
+// helpRowGroups returns the columns of the help row: the current view's bindings,
+// then the global ones
+func (m Model) helpRowGroups() [][]key.Binding {
+	k := m.keys
+	global := [][]key.Binding{
+		{k.Dashboard, k.Transactions, k.Accounts, k.Reports},
+		{k.Patterns, k.Review, k.Budgets, k.Tags},
+		{k.NewTransaction, k.Undo, k.Redo},
+		{k.Problems, k.Imports, k.Help, k.Quit},
+	}
+	if view := m.viewKeyMap(); view != nil {
+		return append(view.FullHelp(), global...)
+	}
+	return append([][]key.Binding{functionKeys[:5], functionKeys[5:9]}, global...)
+}
+
+// helpSections lists the key bindings active in the current view, then the global ones
+func (m Model) helpSections() []help.Section {
+	var view help.Section
+	switch m.currentView {
+	case TransactionsView:
+		view = help.Section{Title: "Transactions", Bindings: m.transactions.KeyBindings()}
+	case AccountsView:
+		view = help.Section{Title: "Accounts", Bindings: m.accounts.KeyBindings()}
+	case ReportsView:
+		view = help.Section{Title: "Reports", Bindings: m.reports.KeyBindings()}
+	case PatternsView:
+		view = help.Section{Title: "Patterns", Bindings: m.patterns.KeyBindings()}
+	case ReviewView:
+		view = help.Section{Title: "Review", Bindings: m.review.KeyBindings()}
+	case BudgetsView:
+		view = help.Section{Title: "Budgets", Bindings: m.budgets.KeyBindings()}
+	case TagsView:
+		view = help.Section{Title: "Tags", Bindings: m.tags.KeyBindings()}
+	case ProblemsView:
+		view = help.Section{Title: "Problems", Bindings: m.problems.KeyBindings()}
+	case ImportsView:
+		view = help.Section{Title: "Imports", Bindings: m.imports.KeyBindings()}
+	}
+
+	return []help.Section{
+		view,
+		{Title: "Global", Bindings: m.keys.bindings()},
+		{Title: "Function Keys", Bindings: functionKeys},
+	}
+}
+
+// openHelp opens the help overlay for the current view
+func (m Model) openHelp() Model {
+	overlay := help.New(m.helpSections(), helpUsage).SetSize(m.width, m.height-2)
+	m.help = &overlay
+	return m
+}
+
+// updateHelp handles keys while the help overlay is shown
+func (m Model) updateHelp(msg tea.KeyMsg) Model {
+	if key.Matches(msg, closeKey, m.keys.Help) || msg.String() == "f1" ||
+		(key.Matches(msg, m.keys.Quit) && msg.String() != "ctrl+c") {
+		m.help = nil
+		return m
+	}
+	overlay := m.help.Update(msg)
+	m.help = &overlay
+	return m
+}
@@ -0,0 +1,172 @@
+// Package help implements the F1 help overlay: a scrollable list of the active
+// key bindings, grouped by where they apply, followed by basic usage notes
+package help
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+// keyColumn is the width of the key column
+const keyColumn = 16
+
+// Section is a titled group of key bindings
+type Section struct {
+	Title    string
+	Bindings []key.Binding
+}
+
+// keyMap defines key bindings for scrolling the overlay
+type keyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "scroll up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "scroll down"),
+		),
+		PageUp: key.NewBinding(
+			key.WithKeys("pgup"),
+			key.WithHelp("pgup", "page up"),
+		),
+		PageDown: key.NewBinding(
+			key.WithKeys("pgdown", " "),
+			key.WithHelp("pgdn", "page down"),
+		),
+		Top: key.NewBinding(
+			key.WithKeys("home", "g"),
+			key.WithHelp("g/home", "top"),
+		),
+		Bottom: key.NewBinding(
+			key.WithKeys("end", "G"),
+			key.WithHelp("G/end", "bottom"),
+		),
+	}
+}
+
+// Model is the help overlay
+type Model struct {
+	sections []Section
+	usage    []string
+	keys     keyMap
+
+	// lines is the rendered body; offset is the first visible line
+	lines  []string
+	offset int
+
+	width  int
+	height int
+}
+
+// New creates the overlay listing sections, followed by the usage notes
+func New(sections []Section, usage []string) Model {
+	m := Model{sections: sections, usage: usage, keys: newKeyMap()}
+	m.lines = m.render()
+	return m
+}
+
+// render lays out every section, skipping disabled bindings and empty sections
+func (m Model) render() []string {
+	var lines []string
+	for _, section := range m.sections {
+		var rows []string
+		for _, binding := range section.Bindings {
+			if !binding.Enabled() || binding.Help().Key == "" {
+				continue
+			}
+			rows = append(rows, theme.HighlightStyle.Render(fmt.Sprintf("  %-*s", keyColumn, binding.Help().Key))+
+				theme.NormalTextStyle.Render(binding.Help().Desc))
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, theme.HighlightStyle.Render(section.Title))
+		lines = append(lines, rows...)
+	}
+
+	if len(m.usage) > 0 {
+		lines = append(lines, "", theme.HighlightStyle.Render("Usage"))
+		for _, line := range m.usage {
+			lines = append(lines, theme.NormalTextStyle.Render("  "+line))
+		}
+	}
+	return lines
+}
+
+// ShortHelp returns the overlay's key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	k := m.keys
+	return []key.Binding{k.Down, k.Up, k.PageDown, k.Top, k.Bottom}
+}
+
+// Update scrolls the overlay; closing it is left to the caller
+func (m Model) Update(msg tea.KeyMsg) Model {
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		m.offset--
+	case key.Matches(msg, m.keys.Down):
+		m.offset++
+	case key.Matches(msg, m.keys.PageUp):
+		m.offset -= m.bodyHeight()
+	case key.Matches(msg, m.keys.PageDown):
+		m.offset += m.bodyHeight()
+	case key.Matches(msg, m.keys.Top):
+		m.offset = 0
+	case key.Matches(msg, m.keys.Bottom):
+		m.offset = len(m.lines)
+	}
+	return m.clamp()
+}
+
+// bodyHeight returns how many lines fit below the title and above the position line
+func (m Model) bodyHeight() int {
+	return max(1, m.height-2)
+}
+
+// clamp keeps the offset within the scrollable range
+func (m Model) clamp() Model {
+	m.offset = max(0, min(m.offset, len(m.lines)-m.bodyHeight()))
+	return m
+}
+
+// View renders the visible part of the overlay
+func (m Model) View() string {
+	titleText := "Help - Keyboard Shortcuts"
+	titlePadded := components.PadRight(titleText, m.width)
+	lines := []string{theme.TitleStyle.Width(m.width).Render(titlePadded)}
+
+	end := min(len(m.lines), m.offset+m.bodyHeight())
+	lines = append(lines, m.lines[m.offset:end]...)
+
+	if len(m.lines) > m.bodyHeight() {
+		lines = append(lines, theme.MutedTextStyle.Render(
+			fmt.Sprintf("  lines %d-%d of %d", m.offset+1, end, len(m.lines))))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetSize updates the overlay size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m.clamp()
+}
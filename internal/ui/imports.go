@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/banksync"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/fileopen"
+	"github.com/mmichie/lima/internal/ui/imports"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// openImportDialog opens the File > Import dialog for choosing a statement, in the
+// directory of the last one imported or else the ledger's
+func (m Model) openImportDialog() Model {
+	dir := filepath.Dir(m.file.Path())
+	if statement := m.imports.Statement(); statement != "" {
+		dir = filepath.Dir(statement)
+	}
+	dialog := fileopen.New(dir, m.importedFiles).
+		SetTitle("Import Statement", "import").
+		SetPlaceholder("statement.csv, .qif or .ledger").
+		SetSize(m.width, m.height-2)
+	m.importFile = &dialog
+	return m
+}
+
+// updateImportFile handles keys while the import dialog is shown
+func (m Model) updateImportFile(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, cancelKey):
+		m.importFile = nil
+		return m, nil
+
+	case key.Matches(msg, importKey):
+		return m.importStatement(m.importFile.Path()), nil
+	}
+
+	dialog := m.importFile.Update(msg)
+	m.importFile = &dialog
+	return m, nil
+}
+
+// importStatement stages a statement's transactions in the imports view
+// On failure the dialog stays open with the error.
+func (m Model) importStatement(path string) Model {
+	fail := func(err error) Model {
+		dialog := m.importFile.SetError(err)
+		m.importFile = &dialog
+		return m
+	}
+	if path == "" {
+		return fail(fmt.Errorf("enter the path of a statement to import"))
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fail(fmt.Errorf("%s is a directory", path))
+	}
+
+	staged, err := m.imports.Load(path, imports.Settings{
+		ImportersFile: m.config.Files.ImportersFile,
+		Currency:      m.config.Amounts.Currency,
+		MinConfidence: m.config.Categorization.AutoThreshold,
+	})
+	if err != nil {
+		return fail(fmt.Errorf("failed to import %s: %w", filepath.Base(path), err))
+	}
+	m.imports = staged
+	m.importFile = nil
+	m.importedFiles = append([]string{path}, slices.DeleteFunc(m.importedFiles, func(p string) bool { return p == path })...)
+	m.currentView = ImportsView
+	m.menuBar = m.menuBar.SetBadge("Imports", countBadge(m.imports.Pending()))
+	return m.notifyf(components.LevelSuccess, "Staged %d new transactions from %s", m.imports.Pending(), filepath.Base(path))
+}
+
+// importsAppended journals staged transactions appended to the ledger and shows
+// them in the other views
+func (m Model) importsAppended(msg imports.AppendedMsg) (tea.Model, tea.Cmd) {
+	newImports, cmd := m.imports.Update(msg)
+	m.imports = newImports.(imports.Model)
+	if msg.Err != nil {
+		return m.notifyf(components.LevelError, "Import failed: %v", msg.Err), cmd
+	}
+	m.journal.record(editBatch{edits: msg.Edits, description: msg.Description})
+	m.transactions = m.transactions.Reload()
+	m.menuBar = m.menuBar.SetBadge("Imports", countBadge(m.imports.Pending()))
+	m = m.recheck()
+	return m.notifyf(components.LevelSuccess, "Appended %d imported transactions (u: undo)", msg.Count), cmd
+}
+
+// syncedMsg carries the result of a bank sync run in the background
+type syncedMsg struct {
+	result banksync.Result
+	err    error
+}
+
+// startSync pulls transactions from the configured aggregator in the background
+// Sync is opt-in, so without sync.enabled it only says how to turn it on.
+func (m Model) startSync() (tea.Model, tea.Cmd) {
+	provider, err := banksync.New(m.config.Sync)
+	if err != nil {
+		return m.notifyf(components.LevelWarning, "Sync: %v", err), nil
+	}
+	m = m.notify(components.LevelInfo, "Syncing with "+m.config.Sync.Provider+"...")
+	return m, syncCmd(m.file, provider, m.config.Sync)
+}
+
+// syncCmd runs a bank sync against the ledger's transactions
+// They are read before the command starts, on the UI goroutine, which alone may
+// touch the file.
+func syncCmd(file *beancount.File, provider banksync.Provider, cfg config.SyncConfig) tea.Cmd {
+	transactions, err := file.AllTransactions()
+	return func() tea.Msg {
+		if err != nil {
+			return syncedMsg{err: fmt.Errorf("failed to read the ledger: %w", err)}
+		}
+		result, err := banksync.Sync(context.Background(), provider, cfg, transactions, time.Now())
+		return syncedMsg{result: result, err: err}
+	}
+}
+
+// synced stages the transactions a bank sync found in the imports view
+func (m Model) synced(msg syncedMsg) Model {
+	if msg.err != nil {
+		return m.notifyf(components.LevelError, "Sync failed: %v", msg.err)
+	}
+	staged, err := m.imports.Stage(m.config.Sync.Provider+" sync", msg.result.Entries, m.config.Categorization.AutoThreshold)
+	if err != nil {
+		return m.notifyf(components.LevelError, "Sync failed: %v", err)
+	}
+	m.imports = staged
+	m.currentView = ImportsView
+	m.menuBar = m.menuBar.SetBadge("Imports", countBadge(m.imports.Pending()))
+	if len(msg.result.Unmapped) > 0 {
+		var ids []string
+		for _, account := range msg.result.Unmapped {
+			ids = append(ids, account.ID)
+		}
+		m = m.notifyf(components.LevelWarning, "Sync skipped accounts not in sync.accounts: %s", strings.Join(ids, ", "))
+	}
+	return m.notifyf(components.LevelSuccess, "Staged %d new transactions (%d synced before, %d pending)",
+		m.imports.Pending(), msg.result.Synced, msg.result.Pending)
+}
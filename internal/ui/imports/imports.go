@@ -0,0 +1,651 @@
+// Package imports implements the view staging the transactions of an imported bank
+// statement: each is shown with whether it repeats a ledger transaction and the
+// category it was given, and is appended to the ledger only once accepted
+package imports
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/importer"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/confirm"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// detailHeight is how many lines the selected transaction's details take below the list
+const detailHeight = 8
+
+// keyMap defines key bindings for the imports view
+type keyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	PageUp    key.Binding
+	PageDown  key.Binding
+	Top       key.Binding
+	Bottom    key.Binding
+	Mark      key.Binding
+	Clear     key.Binding
+	Accept    key.Binding
+	AcceptAll key.Binding
+	Discard   key.Binding
+	Import    key.Binding
+	Sync      key.Binding
+}
+
+// newKeyMap creates the key bindings, taking navigation keys from the config
+func newKeyMap(cfg config.KeybindingsConfig) keyMap {
+	return keyMap{
+		Up:       components.Binding(cfg.Up, "up"),
+		Down:     components.Binding(cfg.Down, "down"),
+		PageUp:   components.Binding(cfg.PageUp, "page up"),
+		PageDown: components.Binding(cfg.PageDown, "page down"),
+		Top:      components.Binding(cfg.Top, "top"),
+		Bottom:   components.Binding(cfg.Bottom, "bottom"),
+		Mark: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "mark"),
+		),
+		Clear: components.Binding(cfg.Back, "clear marks"),
+		Accept: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "append marked"),
+		),
+		AcceptAll: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "append all new"),
+		),
+		Discard: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "discard"),
+		),
+		Import: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "import statement"),
+		),
+		Sync: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sync bank"),
+		),
+	}
+}
+
+// status is what has become of a staged transaction
+type status int
+
+const (
+	pending status = iota
+	appended
+	discarded
+)
+
+// row is a staged transaction
+type row struct {
+	entry  importer.Entry
+	status status
+	marked bool
+}
+
+// Settings are how statements are read and categorized, from the config
+type Settings struct {
+	// ImportersFile holds the column mappings of CSV statements
+	ImportersFile string
+
+	// Currency is the commodity of QIF amounts and of $ in ledger journals
+	Currency string
+
+	// MinConfidence is the lowest confidence a category suggestion is applied at
+	MinConfidence float64
+}
+
+// ImportRequestedMsg asks the root model to choose a statement to import
+type ImportRequestedMsg struct{}
+
+// SyncRequestedMsg asks the root model to pull transactions from the bank aggregator
+type SyncRequestedMsg struct{}
+
+// AppendedMsg reports staged transactions appended to the ledger
+// Edits are the changes made, so the root model can journal them for undo; the
+// root passes the message back so the view marks them appended
+type AppendedMsg struct {
+	Edits       []beancount.Edit
+	Description string
+	Count       int
+	Err         error
+
+	rows []int
+}
+
+// Model represents the imports view: the staged transactions of the last statement
+// imported, in statement order, with the selected one's details below the list
+type Model struct {
+	file        *beancount.File
+	categorizer *categorizer.Categorizer
+	writer      *beancount.Writer
+	width       int
+	height      int
+	display     components.Display
+	keys        keyMap
+
+	// source names where the rows came from; path is the statement they were read
+	// from, "" for a sync
+	source string
+	path   string
+	rows   []row
+	list   components.Scroller
+
+	// message reports the result of the last action
+	message string
+}
+
+// New creates an imports view with nothing staged
+func New(file *beancount.File, cat *categorizer.Categorizer, writer *beancount.Writer, keys config.KeybindingsConfig) Model {
+	return Model{file: file, categorizer: cat, writer: writer, keys: newKeyMap(keys)}
+}
+
+// SetKeys rebinds the view's keys, e.g. after the config is reloaded
+func (m Model) SetKeys(keys config.KeybindingsConfig) Model {
+	m.keys = newKeyMap(keys)
+	return m
+}
+
+// Statement returns the statement last imported, "" before any or after a sync
+func (m Model) Statement() string {
+	return m.path
+}
+
+// Pending counts the staged transactions not yet appended or discarded that aren't
+// duplicates
+func (m Model) Pending() int {
+	n := 0
+	for _, r := range m.rows {
+		if r.status == pending && r.entry.Duplicate == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Load reads a statement into the staging area in place of the one staged, checking
+// it against the ledger and categorizing it
+// Registered and external importers read the statements they detect; CSV statements
+// are otherwise read with the only column mapping, or else the first by name that
+// can read them.
+func (m Model) Load(path string, settings Settings) (Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	entries, err := read(path, data, settings)
+	if err != nil {
+		return m, err
+	}
+	staged, err := m.Stage(filepath.Base(path), entries, settings.MinConfidence)
+	if err != nil {
+		return m, err
+	}
+	staged.path = path
+	return staged, nil
+}
+
+// Stage puts entries, e.g. from a bank sync, in the staging area in place of those
+// staged, checking them against the ledger and categorizing them; source names where
+// they came from
+func (m Model) Stage(source string, entries []importer.Entry, minConfidence float64) (Model, error) {
+	transactions, err := m.file.AllTransactions()
+	if err != nil {
+		return m, fmt.Errorf("failed to read the ledger: %w", err)
+	}
+	entries, err = importer.Process(entries, transactions, m.categorizer, importer.Options{MinConfidence: minConfidence})
+	if err != nil {
+		return m, err
+	}
+
+	m.source = source
+	m.path = ""
+	m.rows = make([]row, len(entries))
+	duplicates := 0
+	for i, entry := range entries {
+		m.rows[i] = row{entry: entry}
+		if entry.Duplicate != nil {
+			duplicates++
+		}
+	}
+	m.list = components.Scroller{}
+	m.message = fmt.Sprintf("Read %d transactions from %s: %d new, %d already in the ledger",
+		len(entries), source, len(entries)-duplicates, duplicates)
+	return m.scroll(), nil
+}
+
+// read reads a statement's entries with the first plugin that detects it, else by
+// its format
+func read(path string, data []byte, settings Settings) ([]importer.Entry, error) {
+	file, fileErr := importer.ReadFile(settings.ImportersFile)
+	if fileErr != nil && !errors.Is(fileErr, fs.ErrNotExist) {
+		return nil, fileErr
+	}
+	plugins, err := file.Plugins()
+	if err != nil {
+		return nil, err
+	}
+	if plugin := importer.Detect(plugins, path, data); plugin != nil {
+		entries, err := importer.ReaderOf(plugin).Read(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("importer %s: %w", plugin.Name(), err)
+		}
+		return entries, nil
+	}
+
+	switch importer.FormatOf(path) {
+	case "qif":
+		if settings.Currency == "" {
+			return nil, fmt.Errorf("set amounts.currency to the commodity of QIF amounts")
+		}
+		return importer.QIFConfig{Currency: settings.Currency}.Read(bytes.NewReader(data))
+	case "ledger":
+		return importer.LedgerConfig{Currency: settings.Currency}.Read(bytes.NewReader(data))
+	}
+
+	if fileErr != nil {
+		return nil, fileErr
+	}
+	importers := file.Importers
+	names := importer.Names(importers)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no importers in %s", settings.ImportersFile)
+	}
+	var failures []string
+	for _, name := range names {
+		entries, err := importers[name].Read(bytes.NewReader(data))
+		if err == nil {
+			return entries, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+	}
+	if len(failures) == 1 {
+		return nil, fmt.Errorf("importer %s", failures[0])
+	}
+	return nil, fmt.Errorf("no importer reads %s (%s)", filepath.Base(path), strings.Join(failures, "; "))
+}
+
+// listHeight returns how many rows fit between the header and the details
+func (m Model) listHeight() int {
+	return max(1, m.height-5-detailHeight+m.display.Spared(2))
+}
+
+// scroll keeps the cursor on a row and inside the visible window
+func (m Model) scroll() Model {
+	m.list = m.list.Fit(len(m.rows), m.listHeight())
+	return m
+}
+
+// Captures reports whether the view handles this key itself
+// The root model routes captured keys here instead of treating them as global
+// shortcuts, such as a for a new transaction
+func (m Model) Captures(msg tea.KeyMsg) bool {
+	if key.Matches(msg, m.keys.Import, m.keys.Sync) {
+		return true
+	}
+	return len(m.rows) > 0 && key.Matches(msg, m.keys.Mark, m.keys.Accept, m.keys.AcceptAll, m.keys.Discard)
+}
+
+// Init initializes the imports view
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if written, ok := msg.(AppendedMsg); ok {
+		return m.appended(written), nil
+	}
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if key.Matches(keyMsg, m.keys.Import) {
+		return m, func() tea.Msg { return ImportRequestedMsg{} }
+	}
+	if key.Matches(keyMsg, m.keys.Sync) {
+		return m, func() tea.Msg { return SyncRequestedMsg{} }
+	}
+	if len(m.rows) == 0 {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		m.list = m.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		m.list = m.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		m.list = m.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		m.list = m.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		m.list = m.list.Top()
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		m.list = m.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.Mark):
+		// Only transactions still staged can be marked
+		if r := &m.rows[m.list.Cursor()]; r.status == pending {
+			r.marked = !r.marked
+		}
+		m.list = m.list.Down()
+
+	case key.Matches(keyMsg, m.keys.Clear):
+		m = m.clearMarks()
+
+	case key.Matches(keyMsg, m.keys.Discard):
+		for _, i := range m.chosen() {
+			if m.rows[i].status == discarded {
+				m.rows[i].status = pending
+			} else if m.rows[i].status == pending {
+				m.rows[i].status = discarded
+			}
+		}
+		m = m.clearMarks()
+
+	case key.Matches(keyMsg, m.keys.Accept):
+		var rows []int
+		for _, i := range m.chosen() {
+			if m.rows[i].status == pending {
+				rows = append(rows, i)
+			}
+		}
+		if len(rows) == 0 {
+			m.message = "Nothing to append: the transaction was appended or discarded (d restores it)"
+			return m, nil
+		}
+		return m.append(rows)
+
+	case key.Matches(keyMsg, m.keys.AcceptAll):
+		// Duplicates are only appended one by one, when the verdict is wrong
+		var rows []int
+		for i, r := range m.rows {
+			if r.status == pending && r.entry.Duplicate == nil {
+				rows = append(rows, i)
+			}
+		}
+		if len(rows) == 0 {
+			m.message = "Nothing to append: no new transactions are staged"
+			return m, nil
+		}
+		return m.append(rows)
+	}
+
+	return m.scroll(), nil
+}
+
+// chosen returns the marked rows, or the selected one when none are marked
+func (m Model) chosen() []int {
+	var rows []int
+	for i, r := range m.rows {
+		if r.marked {
+			rows = append(rows, i)
+		}
+	}
+	if len(rows) == 0 {
+		rows = []int{m.list.Cursor()}
+	}
+	return rows
+}
+
+// clearMarks unmarks every row
+func (m Model) clearMarks() Model {
+	rows := slices.Clone(m.rows)
+	for i := range rows {
+		rows[i].marked = false
+	}
+	m.rows = rows
+	return m
+}
+
+// append asks to append rows' transactions to the ledger
+// The root model confirms and applies the edit, then reports it back as an AppendedMsg.
+func (m Model) append(rows []int) (tea.Model, tea.Cmd) {
+	transactions := make([]*beancount.Transaction, len(rows))
+	for i, r := range rows {
+		transactions[i] = m.rows[r].entry.Transaction
+	}
+	edits, err := importer.Append(m.writer, transactions)
+	if err != nil {
+		m.message = fmt.Sprintf("Append failed: %v", err)
+		return m, nil
+	}
+
+	title := fmt.Sprintf("Append %d imported transactions", len(rows))
+	description := fmt.Sprintf("import of %d transactions", len(rows))
+	if len(rows) == 1 {
+		tx := transactions[0]
+		title = fmt.Sprintf("Append %s %s", m.display.Date(tx.Date), label(tx))
+		description = fmt.Sprintf("import of %s %s", m.display.Date(tx.Date), label(tx))
+	}
+	result := AppendedMsg{Edits: edits, Description: description, Count: len(rows), rows: rows}
+	return m, confirm.Request(confirm.RequestMsg{
+		Title: title,
+		Edits: edits,
+		Done: func(err error) tea.Msg {
+			result.Err = err
+			return result
+		},
+	})
+}
+
+// appended marks the rows written to the ledger
+func (m Model) appended(msg AppendedMsg) Model {
+	if msg.Err != nil {
+		m.message = fmt.Sprintf("Append failed: %v", msg.Err)
+		return m
+	}
+	m.rows = slices.Clone(m.rows)
+	for _, i := range msg.rows {
+		if i < len(m.rows) {
+			m.rows[i].status = appended
+			m.rows[i].marked = false
+		}
+	}
+	m.message = fmt.Sprintf("Appended %d transactions; %d new ones still staged", len(msg.rows), m.Pending())
+	return m
+}
+
+// label names a transaction by its payee, or its narration without one
+func label(tx *beancount.Transaction) string {
+	if tx.Payee == "" {
+		return tx.Narration
+	}
+	return tx.Payee
+}
+
+// category returns the account a staged transaction was categorized to
+func category(tx *beancount.Transaction) string {
+	if posting := categorizer.CategoryPosting(tx); posting >= 0 {
+		return tx.Postings[posting].Account
+	}
+	if len(tx.Postings) > 1 {
+		return tx.Postings[1].Account
+	}
+	return ""
+}
+
+// verdict describes a row's state in a few words
+func (r row) verdict() string {
+	switch {
+	case r.status == appended:
+		return "appended"
+	case r.status == discarded:
+		return "discarded"
+	case r.entry.Duplicate != nil:
+		return "duplicate"
+	case r.entry.Transaction.Flag == "!":
+		return "review"
+	}
+	return "new"
+}
+
+// View renders the staged transactions and the selected one's details
+func (m Model) View() string {
+	if m.width == 0 {
+		return theme.NormalTextStyle.Render("Loading imports...")
+	}
+
+	var lines []string
+	title := "Imports"
+	if m.source != "" {
+		title = fmt.Sprintf("Imports: %s (%d staged)", m.source, m.Pending())
+	}
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(components.PadRight(title, m.width)))
+
+	if len(m.rows) == 0 {
+		lines = append(lines, "")
+		if m.source == "" {
+			lines = append(lines, theme.NormalTextStyle.Render(" Nothing staged: press i to import a CSV statement, QIF file or ledger journal, or s to sync"))
+		} else {
+			lines = append(lines, theme.NormalTextStyle.Render(" No transactions to stage"))
+		}
+		if m.message != "" {
+			lines = append(lines, "", theme.HighlightStyle.Render(" "+m.message))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(m.formatRow("  ", "Status", "Date", "Payee", "Category", "Amount")))
+	lines = append(lines, m.display.Rule(m.width)...)
+
+	start, end := m.list.Window()
+	for i := start; i < end; i++ {
+		r := m.rows[i]
+		tx := r.entry.Transaction
+		mark := "  "
+		if r.marked {
+			mark = "✓ "
+		}
+		amount := ""
+		if tx.Postings[0].Amount != nil {
+			amount = m.display.Amount(tx.Postings[0].Amount.Number, tx.Postings[0].Amount.Commodity)
+		}
+		line := m.formatRow(mark, r.verdict(), m.display.Date(tx.Date), label(tx), category(tx), amount)
+
+		switch {
+		case i == m.list.Cursor():
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line))
+		case r.status != pending || r.entry.Duplicate != nil:
+			lines = append(lines, theme.MutedTextStyle.Width(m.width).Render(line))
+		default:
+			lines = append(lines, theme.ListItemStyle.Width(m.width).Render(line))
+		}
+	}
+
+	lines = append(lines, m.display.Rule(m.width)...)
+	lines = append(lines, m.details()...)
+	if m.message != "" {
+		lines = append(lines, theme.HighlightStyle.Render(" "+m.message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// details describes the selected transaction: why it has its status and category,
+// then the transaction as it would be appended
+func (m Model) details() []string {
+	r := m.rows[m.list.Cursor()]
+	entry := r.entry
+	var lines []string
+	switch {
+	case entry.Duplicate != nil:
+		dup := entry.Duplicate
+		where := ""
+		if dup.FilePath != "" {
+			where = fmt.Sprintf(" (%s:%d)", filepath.Base(dup.FilePath), dup.LineNumber)
+		}
+		lines = append(lines, theme.WarningStyle.Render(fmt.Sprintf(" Already in the ledger as %s %s%s; a appends it anyway", m.display.Date(dup.Date), label(dup), where)))
+	case entry.Suggestion != nil:
+		lines = append(lines, theme.SuccessStyle.Render(fmt.Sprintf(" Categorized as %s (%.0f%%, %s)", entry.Suggestion.Category, entry.Suggestion.Confidence*100, entry.Suggestion.Reason)))
+	case categorizer.PlaceholderPosting(entry.Transaction) >= 0:
+		lines = append(lines, theme.MutedTextStyle.Render(" No confident category: flagged ! for the review queue"))
+	default:
+		lines = append(lines, theme.MutedTextStyle.Render(" Category from the statement"))
+	}
+
+	text, err := beancount.FormatTransaction(entry.Transaction)
+	if err != nil {
+		return append(lines, theme.ErrorStyle.Render(" "+err.Error()))
+	}
+	for i, line := range text {
+		if i == detailHeight-2 {
+			lines = append(lines, theme.MutedTextStyle.Render(fmt.Sprintf("   ... %d more lines", len(text)-i)))
+			break
+		}
+		lines = append(lines, theme.NormalTextStyle.Render("   "+line))
+	}
+	return lines
+}
+
+// formatRow lays out a mark and the status, date, payee, category and right-aligned
+// amount columns, the payee and category sharing the room left
+func (m Model) formatRow(mark, status, date, payee, category, amount string) string {
+	fixed := fmt.Sprintf("%-9s  %-*s  ", status, m.display.DateWidth(), date)
+	amountColumn := fmt.Sprintf("  %16s ", amount)
+	room := max(0, m.width-components.Width(fixed)-components.Width(amountColumn)-5)
+	payeeWidth := room / 2
+	categoryWidth := room - payeeWidth - 2
+	return " " + mark + fixed +
+		components.PadRight(components.Fit(payee, payeeWidth, "…"), payeeWidth) + "  " +
+		components.PadRight(components.Fit(category, max(0, categoryWidth), "…"), max(0, categoryWidth)) +
+		amountColumn
+}
+
+// SetSize updates the imports view size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m.scroll()
+}
+
+// SetDisplay updates the imports view display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m.SetSize(m.width, m.height)
+}
+
+// ShortHelp returns the view's main key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	k := m.keys
+	if len(m.rows) == 0 {
+		return []key.Binding{k.Import, k.Sync}
+	}
+	return []key.Binding{k.Accept, k.AcceptAll, k.Mark, k.Discard, k.Import}
+}
+
+// FullHelp returns all of the view's key bindings in columns, for the help row
+func (m Model) FullHelp() [][]key.Binding {
+	k := m.keys
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Top, k.Bottom, k.Mark, k.Clear},
+		{k.Accept, k.AcceptAll, k.Discard},
+		{k.Import, k.Sync},
+	}
+}
+
+// KeyBindings returns the view's key bindings, for the help overlay
+func (m Model) KeyBindings() []key.Binding {
+	return slices.Concat(m.FullHelp()...)
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/ui/components"
+)
+
+// editBatch is the set of ledger edits made by one action (a categorization, an added,
+// edited or deleted transaction, or an auto-categorize run)
+type editBatch struct {
+	edits       []beancount.Edit
+	entries     []categorizer.AuditEntry // Audit entries of auto-categorized postings
+	description string                   // What was done, for status messages
+}
+
+// journal records every ledger edit made this session so it can be undone and redone
+// Batches are undone newest first, so each inverse applies to the text its batch produced
+type journal struct {
+	done   []editBatch // Applied batches, most recent last
+	undone []editBatch // Undone batches available to redo, most recent last
+}
+
+// record adds a batch that has just been applied
+// A new edit discards the redo history, as the undone batches no longer apply
+func (j *journal) record(batch editBatch) {
+	j.done = append(j.done, batch)
+	j.undone = nil
+}
+
+// canUndo reports whether there is a batch to undo
+func (j journal) canUndo() bool {
+	return len(j.done) > 0
+}
+
+// canRedo reports whether there is an undone batch to redo
+func (j journal) canRedo() bool {
+	return len(j.undone) > 0
+}
+
+// undoLast reverts the most recent batch of ledger edits
+func (m Model) undoLast() Model {
+	if !m.journal.canUndo() {
+		return m.notify(components.LevelInfo, "Nothing to undo")
+	}
+	batch := m.journal.done[len(m.journal.done)-1]
+
+	inverses := make([]beancount.Edit, len(batch.edits))
+	for i, edit := range batch.edits {
+		inverses[i] = edit.Inverse()
+	}
+	if err := m.writer.ApplyAll(inverses); err != nil {
+		return m.notifyf(components.LevelError, "Undo failed: %v", err)
+	}
+	m.recordBatchAudit(batch, categorizer.AuditUndone)
+
+	m.journal.done = m.journal.done[:len(m.journal.done)-1]
+	m.journal.undone = append(m.journal.undone, batch)
+	m.transactions = m.transactions.Reload()
+	m = m.recheck()
+	return m.notify(components.LevelSuccess, "Undid "+batch.description+m.journalHint())
+}
+
+// redoLast re-applies the most recently undone batch of ledger edits
+func (m Model) redoLast() Model {
+	if !m.journal.canRedo() {
+		return m.notify(components.LevelInfo, "Nothing to redo")
+	}
+	batch := m.journal.undone[len(m.journal.undone)-1]
+
+	if err := m.writer.ApplyAll(batch.edits); err != nil {
+		return m.notifyf(components.LevelError, "Redo failed: %v", err)
+	}
+	m.recordBatchAudit(batch, categorizer.AuditApplied)
+
+	m.journal.undone = m.journal.undone[:len(m.journal.undone)-1]
+	m.journal.done = append(m.journal.done, batch)
+	m.transactions = m.transactions.Reload()
+	m = m.recheck()
+	return m.notify(components.LevelSuccess, "Redid "+batch.description+m.journalHint())
+}
+
+// journalHint summarizes what is left to undo and redo, e.g. " (u: 2 more, ctrl+r: 1)"
+func (m Model) journalHint() string {
+	undo, redo := len(m.journal.done), len(m.journal.undone)
+	switch {
+	case undo > 0 && redo > 0:
+		return fmt.Sprintf(" (%s: %d more, %s: %d)", m.keys.Undo.Help().Key, undo, m.keys.Redo.Help().Key, redo)
+	case undo > 0:
+		return fmt.Sprintf(" (%s: %d more)", m.keys.Undo.Help().Key, undo)
+	case redo > 0:
+		return fmt.Sprintf(" (%s: redo)", m.keys.Redo.Help().Key)
+	}
+	return ""
+}
+
+// recordBatchAudit logs a batch's audit entries again under a new action
+func (m Model) recordBatchAudit(batch editBatch, action string) {
+	entries := make([]categorizer.AuditEntry, len(batch.entries))
+	for i, entry := range batch.entries {
+		entry.Action = action
+		entries[i] = entry
+	}
+	m.recordAuditEntries(entries)
+}
@@ -0,0 +1,188 @@
+// Package loading implements the startup screen shown while a ledger's index is
+// built, with live counts streamed from the parser
+package loading
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+// tickInterval is how often the elapsed time is refreshed between progress reports
+const tickInterval = 100 * time.Millisecond
+
+// progressMsg carries a progress report from the parser
+type progressMsg beancount.Progress
+
+// loadedMsg reports the ledger opened, or why it could not be
+type loadedMsg struct {
+	file *beancount.File
+	err  error
+}
+
+// tickMsg refreshes the elapsed time
+type tickMsg time.Time
+
+// Model is the loading screen; once the ledger is open it hands over to the model
+// next builds for it
+type Model struct {
+	path  string
+	store *beancount.Store
+	next  func(*beancount.File) tea.Model
+
+	// events streams progress from the parser; it is closed once the ledger is open
+	events chan progressMsg
+
+	progress beancount.Progress
+	started  time.Time
+	now      time.Time
+	err      error
+
+	width  int
+	height int
+	sized  bool
+}
+
+// New creates the loading screen for the ledger at path, read through store
+func New(path string, store *beancount.Store, next func(*beancount.File) tea.Model) Model {
+	now := time.Now()
+	return Model{
+		path:    path,
+		store:   store,
+		next:    next,
+		events:  make(chan progressMsg, 1),
+		started: now,
+		now:     now,
+	}
+}
+
+// Err returns why the ledger could not be opened
+func (m Model) Err() error {
+	return m.err
+}
+
+// Init starts opening the ledger
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.open(), m.wait(), tick())
+}
+
+// open builds the index, streaming progress to events
+// Reports are dropped while the screen is behind; the next one catches up.
+func (m Model) open() tea.Cmd {
+	path, store, events := m.path, m.store, m.events
+	return func() tea.Msg {
+		defer close(events)
+		file, err := store.OpenWithProgress(path, func(p beancount.Progress) {
+			select {
+			case events <- progressMsg(p):
+			default:
+			}
+		})
+		return loadedMsg{file: file, err: err}
+	}
+}
+
+// wait delivers the next progress report, or nothing once the ledger is open
+func (m Model) wait() tea.Cmd {
+	events := m.events
+	return func() tea.Msg {
+		msg, ok := <-events
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// tick schedules the next elapsed time refresh
+func tick() tea.Cmd {
+	return tea.Tick(tickInterval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// Update handles messages; once the ledger is open the next model takes over,
+// sized to the screen
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height, m.sized = msg.Width, msg.Height, true
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" || msg.String() == "q" {
+			return m, tea.Quit
+		}
+
+	case progressMsg:
+		m.progress = beancount.Progress(msg)
+		m.now = time.Now()
+		return m, m.wait()
+
+	case tickMsg:
+		m.now = time.Time(msg)
+		return m, tick()
+
+	case loadedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		next := m.next(msg.file)
+		cmds := []tea.Cmd{next.Init()}
+		if m.sized {
+			var cmd tea.Cmd
+			next, cmd = next.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+			cmds = append(cmds, cmd)
+		}
+		return next, tea.Batch(cmds...)
+	}
+	return m, nil
+}
+
+// View renders the ledger being opened and the counts so far, centered on the screen
+func (m Model) View() string {
+	row := func(label, value string) string {
+		return theme.MutedTextStyle.Render(fmt.Sprintf("  %-14s", label)) +
+			theme.NormalTextStyle.Render(components.PadLeft(value, 12))
+	}
+
+	lines := []string{
+		theme.TitleStyle.Render(" Lima "),
+		"",
+		theme.NormalTextStyle.Render("Opening " + filepath.Base(m.path)),
+		"",
+		row("Files", fmt.Sprint(m.progress.Files)),
+		row("Lines", fmt.Sprint(m.progress.Lines)),
+		row("Transactions", fmt.Sprint(m.progress.Transactions)),
+		row("Read", formatBytes(m.progress.Bytes)),
+		row("Elapsed", m.now.Sub(m.started).Round(100*time.Millisecond).String()),
+		"",
+	}
+	if m.progress.File != "" {
+		lines = append(lines, theme.MutedTextStyle.Render("Scanning "+components.TruncateStart(filepath.Base(m.progress.File), 30, "...")))
+	} else {
+		lines = append(lines, theme.MutedTextStyle.Render("Starting..."))
+	}
+
+	panel := theme.PanelStyle.Padding(1, 3).Render(strings.Join(lines, "\n"))
+	if !m.sized {
+		return panel
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, panel)
+}
+
+// formatBytes formats a size in bytes, KB or MB
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,77 @@
+package loading
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mmichie/lima/internal/beancount"
+)
+
+// sizedModel records the screen size handed over by the loading screen
+type sizedModel struct {
+	file   *beancount.File
+	width  int
+	height int
+}
+
+func (m sizedModel) Init() tea.Cmd { return nil }
+func (m sizedModel) View() string  { return "" }
+
+func (m sizedModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width, m.height = size.Width, size.Height
+	}
+	return m, nil
+}
+
+func TestLoadingScreen(t *testing.T) {
+	ledger := filepath.Join(t.TempDir(), "main.beancount")
+	content := "2024-03-02 * \"Blue Bottle\" \"Latte\"\n  Assets:Checking  -5.00 USD\n  Expenses:Food\n"
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	m := New(ledger, beancount.NewStore(nil), func(file *beancount.File) tea.Model { return sizedModel{file: file} })
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = updated.(Model)
+
+	// Progress reports show as they arrive
+	updated, cmd := m.Update(progressMsg{File: ledger, Files: 1, Lines: 5000, Bytes: 3 << 20, Transactions: 1200})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Error("expected to wait for the next report")
+	}
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"Opening main.beancount", "5000", "1200", "3.0 MB", "Scanning main.beancount"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q on the loading screen:\n%s", want, view)
+		}
+	}
+
+	// Once open, the next model takes over at the screen size
+	msg := m.open()()
+	loaded, ok := msg.(loadedMsg)
+	if !ok || loaded.err != nil {
+		t.Fatalf("expected the ledger opened, got %#v", msg)
+	}
+	next, _ := m.Update(loaded)
+	sized, ok := next.(sizedModel)
+	if !ok {
+		t.Fatalf("expected the next model, got %T", next)
+	}
+	defer sized.file.Close()
+	if sized.width != 80 || sized.height != 24 || sized.file.TransactionCount() != 1 {
+		t.Errorf("unexpected handover: %dx%d, %d transactions", sized.width, sized.height, sized.file.TransactionCount())
+	}
+
+	// A ledger that can't be opened quits with the error
+	m = New(filepath.Join(t.TempDir(), "missing.beancount"), beancount.NewStore(nil), nil)
+	updated, cmd = m.Update(m.open()())
+	if updated.(Model).Err() == nil || cmd == nil {
+		t.Error("expected the open error kept and the program quit")
+	}
+}
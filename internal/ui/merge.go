@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/merge"
+)
+
+// openMergeDialog opens the Merge Payees dialog over the ledger's payees
+func (m Model) openMergeDialog() Model {
+	dialog := merge.New(m.file, m.writer).SetSize(m.width, m.height-2)
+	m.merge = &dialog
+	return m
+}
+
+// updateMerge handles keys while the Merge Payees dialog is shown
+func (m Model) updateMerge(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.merge.Renaming() && key.Matches(msg, closeKey) {
+		m.merge = nil
+		return m, nil
+	}
+	dialog, cmd := m.merge.Update(msg)
+	m.merge = &dialog
+	return m, cmd
+}
+
+// payeesMerged records a merge of payees for undo, or reports why it failed
+// The dialog stays open on the clusters left.
+func (m Model) payeesMerged(msg merge.MergedMsg) Model {
+	if msg.Err != nil {
+		return m.notifyf(components.LevelError, "Merge failed: %v", msg.Err)
+	}
+	m.journal.record(editBatch{
+		edits:       msg.Edits,
+		description: "payee merge as " + msg.Name,
+	})
+	if m.merge != nil {
+		dialog := m.merge.Reload()
+		m.merge = &dialog
+	}
+	m.transactions = m.transactions.Reload()
+	return m.notifyf(components.LevelSuccess, "Merged %d payees into %s (u: undo)", msg.Payees, msg.Name)
+}
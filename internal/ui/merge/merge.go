@@ -0,0 +1,311 @@
+// Package merge implements the Merge Payees dialog: payees that name the same merchant
+// spelled differently, grouped with a proposed name, each group merged under one name
+// once the rewrite is previewed
+package merge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/payees"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/confirm"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+// MergedMsg reports a merge once its edits were written, or why they weren't
+type MergedMsg struct {
+	Name   string
+	Payees int // Spellings renamed
+	Edits  []beancount.Edit
+	Err    error
+}
+
+// keyMap defines key bindings for the dialog; closing is left to the caller
+type keyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Toggle key.Binding
+	Use    key.Binding
+	Rename key.Binding
+	Merge  key.Binding
+}
+
+var keys = keyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "down"),
+	),
+	Toggle: key.NewBinding(
+		key.WithKeys(" ", "x"),
+		key.WithHelp("space", "include/skip"),
+	),
+	Use: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "use this name"),
+	),
+	Rename: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "type a name"),
+	),
+	Merge: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "merge"),
+	),
+}
+
+// row is a line of the list: a cluster's name, or one of its spellings
+type row struct {
+	cluster int
+	variant int // -1 for the cluster's own line
+}
+
+// Model is the Merge Payees dialog
+type Model struct {
+	file   *beancount.File
+	writer *beancount.Writer
+
+	clusters []payees.Cluster
+	// skipped are the spellings left out of their cluster's merge, by name
+	skipped map[string]bool
+	rows    []row
+	list    components.Scroller
+
+	// input is the name being typed for the selected cluster, when renaming
+	input    components.TextInput
+	renaming bool
+
+	err    string
+	width  int
+	height int
+}
+
+// New creates the dialog, clustering the ledger's payees
+func New(file *beancount.File, writer *beancount.Writer) Model {
+	m := Model{file: file, writer: writer, skipped: make(map[string]bool)}
+	return m.Reload()
+}
+
+// Reload clusters the payees again, e.g. after a merge, keeping the cursor on the
+// cluster it was on where there still is one
+func (m Model) Reload() Model {
+	var selected string
+	if r, ok := m.selected(); ok {
+		selected = m.clusters[r.cluster].Variants[0].Name
+	}
+
+	m.err = ""
+	transactions, err := m.file.AllTransactions()
+	if err != nil {
+		m.err = err.Error()
+	}
+	m.clusters = payees.Find(transactions)
+	m.rows = nil
+	for i, c := range m.clusters {
+		m.rows = append(m.rows, row{cluster: i, variant: -1})
+		for j := range c.Variants {
+			m.rows = append(m.rows, row{cluster: i, variant: j})
+		}
+	}
+	m = m.scroll()
+	for i, r := range m.rows {
+		if r.variant == -1 && m.clusters[r.cluster].Variants[0].Name == selected {
+			m.list = m.list.Select(i)
+		}
+	}
+	return m
+}
+
+// Clusters returns the clusters listed
+func (m Model) Clusters() []payees.Cluster {
+	return m.clusters
+}
+
+// Renaming reports whether a name is being typed, which takes enter and esc
+func (m Model) Renaming() bool {
+	return m.renaming
+}
+
+// selected returns the row under the cursor
+func (m Model) selected() (row, bool) {
+	if m.list.Cursor() >= len(m.rows) {
+		return row{}, false
+	}
+	return m.rows[m.list.Cursor()], true
+}
+
+// included returns a cluster's spellings not skipped
+func (m Model) included(c payees.Cluster) []payees.Variant {
+	var variants []payees.Variant
+	for _, v := range c.Variants {
+		if !m.skipped[v.Name] {
+			variants = append(variants, v)
+		}
+	}
+	return variants
+}
+
+// listHeight returns how many rows fit between the header and the help lines
+func (m Model) listHeight() int {
+	return max(1, m.height-7)
+}
+
+// scroll keeps the cursor on a row and inside the visible window
+func (m Model) scroll() Model {
+	m.list = m.list.Fit(len(m.rows), m.listHeight())
+	return m
+}
+
+// ShortHelp returns the dialog's key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	if m.renaming {
+		return nil
+	}
+	return []key.Binding{keys.Merge, keys.Toggle, keys.Use, keys.Rename}
+}
+
+// Update handles keys; esc outside of renaming is left to the caller
+func (m Model) Update(msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.err = ""
+	r, ok := m.selected()
+	if m.renaming {
+		switch msg.String() {
+		case "esc":
+			m.renaming = false
+		case "enter":
+			name := strings.TrimSpace(m.input.Value())
+			if name == "" || strings.Contains(name, `"`) {
+				m.err = "A payee can't be empty or contain quotes"
+				return m, nil
+			}
+			m.clusters[r.cluster].Name = name
+			m.renaming = false
+		default:
+			m.input, _ = m.input.Update(msg)
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, keys.Up):
+		m.list = m.list.Up()
+	case key.Matches(msg, keys.Down):
+		m.list = m.list.Down()
+	case !ok:
+	case key.Matches(msg, keys.Toggle):
+		if r.variant >= 0 {
+			name := m.clusters[r.cluster].Variants[r.variant].Name
+			m.skipped[name] = !m.skipped[name]
+		}
+		m.list = m.list.Down()
+	case key.Matches(msg, keys.Use):
+		if r.variant >= 0 {
+			m.clusters[r.cluster].Name = m.clusters[r.cluster].Variants[r.variant].Name
+		}
+	case key.Matches(msg, keys.Rename):
+		m.input = components.NewTextInput("Name").SetValue(m.clusters[r.cluster].Name).Focus()
+		m.renaming = true
+	case key.Matches(msg, keys.Merge):
+		return m, m.merge(m.clusters[r.cluster])
+	}
+	return m.scroll(), nil
+}
+
+// merge asks to rename the included spellings of a cluster to its name
+func (m Model) merge(c payees.Cluster) tea.Cmd {
+	variants := m.included(c)
+	renamed := 0
+	for _, v := range variants {
+		if v.Name != c.Name {
+			renamed++
+		}
+	}
+	if renamed == 0 {
+		return func() tea.Msg {
+			return components.NotifyMsg{Level: components.LevelInfo, Text: "Nothing to merge into " + c.Name}
+		}
+	}
+	edits, err := payees.Edits(m.writer, variants, c.Name)
+	if err != nil {
+		return func() tea.Msg {
+			return MergedMsg{Name: c.Name, Err: err}
+		}
+	}
+	return confirm.Request(confirm.RequestMsg{
+		Title: fmt.Sprintf("Merge %s as %s", plural(renamed, "payee"), c.Name),
+		Edits: edits,
+		Done: func(err error) tea.Msg {
+			return MergedMsg{Name: c.Name, Payees: renamed, Edits: edits, Err: err}
+		},
+	})
+}
+
+// View renders the clusters, each with its spellings and their transaction counts
+func (m Model) View() string {
+	var lines []string
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(components.PadRight(
+		fmt.Sprintf("Merge Payees (%d)", len(m.clusters)), m.width)), "")
+
+	if len(m.clusters) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("  No payees look like the same merchant spelled differently"))
+	}
+	start, end := m.list.Window()
+	for i := start; i < end; i++ {
+		r := m.rows[i]
+		c := m.clusters[r.cluster]
+		var line string
+		style := theme.ListItemStyle
+		if r.variant == -1 {
+			line = fmt.Sprintf(" → %s  (%d transactions)", c.Name, c.Count())
+			style = theme.HighlightStyle
+		} else {
+			v := c.Variants[r.variant]
+			mark := "[x]"
+			if m.skipped[v.Name] {
+				mark = "[ ]"
+				style = theme.MutedTextStyle
+			}
+			line = fmt.Sprintf("     %s %s  %d", mark, v.Name, len(v.Transactions))
+		}
+		if i == m.list.Cursor() {
+			style = theme.SelectedItemStyle
+		}
+		lines = append(lines, style.Width(m.width).Render(components.Fit(line, m.width, "…")))
+	}
+
+	lines = append(lines, "")
+	if m.renaming {
+		lines = append(lines, "  "+m.input.SetWidth(max(20, m.width-12)).View())
+	}
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("  "+m.err))
+	}
+	if !m.renaming {
+		lines = append(lines, theme.MutedTextStyle.Render("  enter:merge (previewed first)   space:include/skip   c:use this name   r:type a name   esc:close"))
+	} else {
+		lines = append(lines, theme.MutedTextStyle.Render("  enter:use the name   esc:cancel"))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetSize updates the dialog size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m.scroll()
+}
+
+// plural formats a count with a noun, e.g. "1 payee" or "3 payees"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
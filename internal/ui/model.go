@@ -1,13 +1,32 @@
 package ui
 
 import (
+	"fmt"
+	"slices"
+	"time"
+
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/backup"
 	"github.com/mmichie/lima/internal/beancount"
 	"github.com/mmichie/lima/internal/categorizer"
 	"github.com/mmichie/lima/internal/ui/accounts"
+	"github.com/mmichie/lima/internal/ui/budgets"
 	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/confirm"
 	"github.com/mmichie/lima/internal/ui/dashboard"
+	"github.com/mmichie/lima/internal/ui/entry"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/fileopen"
+	"github.com/mmichie/lima/internal/ui/help"
+	"github.com/mmichie/lima/internal/ui/imports"
+	"github.com/mmichie/lima/internal/ui/merge"
+	"github.com/mmichie/lima/internal/ui/patterns"
+	"github.com/mmichie/lima/internal/ui/problems"
+	"github.com/mmichie/lima/internal/ui/reports"
+	"github.com/mmichie/lima/internal/ui/review"
+	"github.com/mmichie/lima/internal/ui/tags"
+	"github.com/mmichie/lima/internal/ui/theme"
 	"github.com/mmichie/lima/internal/ui/transactions"
 	"github.com/mmichie/lima/pkg/config"
 )
@@ -20,8 +39,30 @@ const (
 	TransactionsView
 	AccountsView
 	ReportsView
+	PatternsView
+	ReviewView
+	BudgetsView
+	TagsView
+	ProblemsView
+	ImportsView
 )
 
+// pageSizeStep is how many rows the larger and smaller page keys change the page size by
+const pageSizeStep = 5
+
+// viewNames are the names of the views in the config and session state, by ViewType
+var viewNames = []string{"dashboard", "transactions", "accounts", "reports", "patterns", "review", "budgets", "tags", "problems", "imports"}
+
+// viewNamed returns the view with a name from viewNames, or the dashboard
+func viewNamed(name string) ViewType {
+	for view, viewName := range viewNames {
+		if viewName == name {
+			return ViewType(view)
+		}
+	}
+	return DashboardView
+}
+
 // Model is the main application model
 type Model struct {
 	// Current view
@@ -30,8 +71,13 @@ type Model struct {
 	// Beancount file
 	file *beancount.File
 
-	// Configuration
+	// Configuration: the global config overridden by the ledger's project file, and the
+	// global config other ledgers are opened with
 	config *config.Config
+	global *config.Config
+
+	// source is watched for config changes, which are applied live
+	source configSource
 
 	// Categorizer
 	categorizer *categorizer.Categorizer
@@ -40,6 +86,13 @@ type Model struct {
 	dashboard    dashboard.Model
 	transactions transactions.Model
 	accounts     accounts.Model
+	reports      reports.Model
+	patterns     patterns.Model
+	review       review.Model
+	tags         tags.Model
+	budgets      budgets.Model
+	problems     problems.Model
+	imports      imports.Model
 
 	// TP7-style UI components
 	menuBar   components.MenuBar
@@ -49,6 +102,50 @@ type Model struct {
 	width  int
 	height int
 	ready  bool
+	// showHelpRow shows every key binding of the current view above the status bar
+	showHelpRow bool
+	// currency is the commodity report and dashboard totals are shown in ("" for native)
+	currency string
+	// display is how the views render amounts, dates and their layout
+	display components.Display
+
+	// notices are the status bar notifications and the message log
+	notices notices
+	// showLog is true while the message log is open
+	showLog bool
+
+	// Ledger writing and auto-categorization state
+	writer  *beancount.Writer
+	audit   *categorizer.AuditLog
+	staged  []categorizer.AutoCandidate // Confident suggestions awaiting one-key apply
+	journal journal                     // Ledger edits made this session, for undo/redo
+	// writeBack finishes saving rewritten files, putting them back where they came from
+	writeBack *writeBack
+
+	// entry is the open add/edit transaction form (nil when closed)
+	entry *entry.Model
+	// lastEntryDate is the date of the last added transaction, the next form's default
+	lastEntryDate time.Time
+
+	// fileOpen is the open File > Open dialog (nil when closed)
+	fileOpen *fileopen.Model
+
+	// importFile is the open File > Import dialog (nil when closed), importedFiles the
+	// statements imported this session, latest first
+	importFile    *fileopen.Model
+	importedFiles []string
+
+	// export is the open Export dialog (nil when closed)
+	export *export.Model
+
+	// merge is the open Merge Payees dialog (nil when closed)
+	merge *merge.Model
+
+	// confirm previews ledger edits awaiting confirmation (nil when none)
+	confirm *confirm.Model
+
+	// help is the open help overlay (nil when closed)
+	help *help.Model
 
 	// Key bindings
 	keys keyMap
@@ -56,58 +153,93 @@ type Model struct {
 
 // keyMap defines the key bindings for navigation
 type keyMap struct {
-	Dashboard    key.Binding
-	Transactions key.Binding
-	Accounts     key.Binding
-	Reports      key.Binding
-	Quit         key.Binding
-	Help         key.Binding
+	Dashboard      key.Binding
+	Transactions   key.Binding
+	Accounts       key.Binding
+	Reports        key.Binding
+	Patterns       key.Binding
+	Review         key.Binding
+	Budgets        key.Binding
+	Tags           key.Binding
+	Problems       key.Binding
+	Imports        key.Binding
+	NewTransaction key.Binding
+	OpenFile       key.Binding
+	Export         key.Binding
+	Messages       key.Binding
+	Theme          key.Binding
+	Currency       key.Binding
+	Compact        key.Binding
+	LargerPage     key.Binding
+	SmallerPage    key.Binding
+	HelpRow        key.Binding
+	Undo           key.Binding
+	Redo           key.Binding
+	Quit           key.Binding
+	Help           key.Binding
+}
+
+// Global bindings take keys before the view shown, so config validation refuses those
+// the views handle themselves; review and imports take their own keys first and need not
+// reserve them
+func init() {
+	views := []struct {
+		name string
+		keys map[string]string
+	}{
+		{"transactions", transactions.FixedKeys()},
+		{"accounts", accounts.FixedKeys()},
+		{"reports", reports.FixedKeys()},
+		{"patterns", patterns.FixedKeys()},
+		{"budgets", budgets.FixedKeys()},
+		{"tags", tags.FixedKeys()},
+		{"problems", problems.FixedKeys()},
+	}
+	for _, view := range views {
+		for k, use := range view.keys {
+			if _, ok := config.ViewKeys[k]; !ok {
+				config.ViewKeys[k] = use + " in " + view.name
+			}
+		}
+	}
 }
 
 // keyMapFromConfig creates key bindings from config
 func keyMapFromConfig(cfg *config.Config) keyMap {
 	return keyMap{
-		Dashboard: key.NewBinding(
-			key.WithKeys(cfg.Keybindings.Dashboard...),
-			key.WithHelp(cfg.Keybindings.Dashboard[0], "dashboard"),
-		),
-		Transactions: key.NewBinding(
-			key.WithKeys(cfg.Keybindings.Transactions...),
-			key.WithHelp(cfg.Keybindings.Transactions[0], "transactions"),
-		),
-		Accounts: key.NewBinding(
-			key.WithKeys(cfg.Keybindings.Accounts...),
-			key.WithHelp(cfg.Keybindings.Accounts[0], "accounts"),
-		),
-		Reports: key.NewBinding(
-			key.WithKeys(cfg.Keybindings.Reports...),
-			key.WithHelp(cfg.Keybindings.Reports[0], "reports"),
-		),
-		Quit: key.NewBinding(
-			key.WithKeys(cfg.Keybindings.Quit...),
-			key.WithHelp(cfg.Keybindings.Quit[0], "quit"),
-		),
-		Help: key.NewBinding(
-			key.WithKeys(cfg.Keybindings.Help...),
-			key.WithHelp(cfg.Keybindings.Help[0], "help"),
-		),
+		Dashboard:      components.Binding(cfg.Keybindings.Dashboard, "dashboard"),
+		Transactions:   components.Binding(cfg.Keybindings.Transactions, "transactions"),
+		Accounts:       components.Binding(cfg.Keybindings.Accounts, "accounts"),
+		Reports:        components.Binding(cfg.Keybindings.Reports, "reports"),
+		Patterns:       components.Binding(cfg.Keybindings.Patterns, "patterns"),
+		Review:         components.Binding(cfg.Keybindings.Review, "review"),
+		Budgets:        components.Binding(cfg.Keybindings.Budgets, "budgets"),
+		Tags:           components.Binding(cfg.Keybindings.Tags, "tags"),
+		Problems:       components.Binding(cfg.Keybindings.Problems, "problems"),
+		Imports:        components.Binding(cfg.Keybindings.Imports, "imports"),
+		NewTransaction: components.Binding(cfg.Keybindings.NewTransaction, "new transaction"),
+		OpenFile:       components.Binding(cfg.Keybindings.OpenFile, "open file"),
+		Export:         components.Binding(cfg.Keybindings.Export, "export"),
+		Messages:       components.Binding(cfg.Keybindings.Messages, "message log"),
+		Theme:          components.Binding(cfg.Keybindings.Theme, "next theme"),
+		Currency:       components.Binding(cfg.Keybindings.Currency, "next currency"),
+		Compact:        components.Binding(cfg.Keybindings.Compact, "compact layout"),
+		LargerPage:     components.Binding(cfg.Keybindings.LargerPage, "larger pages"),
+		SmallerPage:    components.Binding(cfg.Keybindings.SmallerPage, "smaller pages"),
+		HelpRow:        components.Binding(cfg.Keybindings.HelpRow, "help row"),
+		Undo:           components.Binding(cfg.Keybindings.Undo, "undo"),
+		Redo:           components.Binding(cfg.Keybindings.Redo, "redo"),
+		Quit:           components.Binding(cfg.Keybindings.Quit, "quit"),
+		Help:           components.Binding(cfg.Keybindings.Help, "help"),
 	}
 }
 
 // New creates a new main application model
+// cfg is the global configuration; the ledger's .lima.yaml, if any, overrides it.
 func New(file *beancount.File, cfg *config.Config) Model {
-	// Parse initial view from config
-	var initialView ViewType
-	switch cfg.UI.DefaultView {
-	case "transactions":
-		initialView = TransactionsView
-	case "accounts":
-		initialView = AccountsView
-	case "reports":
-		initialView = ReportsView
-	default:
-		initialView = DashboardView
-	}
+	global := cfg
+	cfg, project, projectErr := global.ForLedger(file.Path())
+	initialView := viewNamed(cfg.UI.DefaultView)
 
 	// Create categorizer
 	cat, err := categorizer.New(cfg)
@@ -116,27 +248,120 @@ func New(file *beancount.File, cfg *config.Config) Model {
 		cat = nil
 	}
 
-	return Model{
+	var audit *categorizer.AuditLog
+	if cfg.Categorization.AuditLog != "" {
+		audit = categorizer.NewAuditLog(cfg.Categorization.AuditLog)
+	}
+
+	// Views style text with the theme's package-level styles
+	theme.Configure(cfg.Theme)
+
+	writer := beancount.NewWriter(file)
+	writer.SetReadOnly(cfg.UI.ReadOnly)
+	writer.SetBackup(backup.FromConfig(cfg.Backup).Hook())
+	// Encrypting a file can take a while, so saves finish in a command
+	file.Store().DeferEncryption(true)
+	model := Model{
+		writer:       writer,
+		writeBack:    newWriteBack(file.Store(), writer),
+		audit:        audit,
 		currentView:  initialView,
 		file:         file,
 		config:       cfg,
+		global:       global,
 		categorizer:  cat,
 		keys:         keyMapFromConfig(cfg),
-		dashboard:    dashboard.New(file),
-		transactions: transactions.New(file, cat),
-		accounts:     accounts.New(file),
+		showHelpRow:  cfg.UI.ShowHelpRow,
+		currency:     cfg.Amounts.Currency,
+		dashboard:    dashboard.New(file, cfg.Dashboard.Layout, cfg.Amounts.Currency).SetAnomalies(cfg.Anomalies).SetGoals(cfg.Goals),
+		transactions: transactions.New(file, cat, writer, cfg.Keybindings),
+		accounts:     accounts.New(file, cfg.Keybindings),
+		reports:      reports.New(file, cfg.Keybindings, cfg.Amounts.Currency).SetAnomalies(cfg.Anomalies),
+		patterns:     patterns.New(file, cat, cfg.Keybindings),
+		review:       review.New(file, cat, writer),
+		budgets:      budgets.New(file, cfg.Files.BudgetsFile, cfg.Keybindings),
+		tags:         tags.New(file, cfg.Keybindings),
+		problems:     problems.New(file, cfg.Keybindings),
+		imports:      imports.New(file, cat, writer, cfg.Keybindings),
 		menuBar:      components.NewMenuBar(),
 		statusBar:    components.NewStatusBar(),
 	}
+	model = model.setDisplay(components.NewDisplay(cfg))
+	if initialView == ReviewView {
+		model.review = model.review.Reload()
+	}
+	model.menuBar = model.menuBar.SetBadge("Problems", countBadge(model.problems.Count()))
+
+	if cfg.UI.ReadOnly {
+		model = model.notify(components.LevelInfo, "Read-only: changes will not be written to the ledger")
+	}
+	switch {
+	case projectErr != nil:
+		model = model.notifyf(components.LevelWarning, "Project settings ignored: %v", projectErr)
+	case project != "":
+		model = model.notify(components.LevelInfo, "Using project settings from "+project)
+	}
+
+	// Cross-check pattern categories against the ledger's accounts
+	// Skipped patterns matter more than unknown categories, so they are shown last
+	if cat != nil {
+		if warnings := cat.SetKnownAccounts(file.DeclaredAccounts()); len(warnings) > 0 {
+			model = model.notifyf(components.LevelWarning, "%d pattern categories not in ledger (first: %s); lima check lists them", len(warnings), warnings[0].Category)
+		}
+		if warnings := cat.LoadWarnings(); len(warnings) > 0 {
+			model = model.notifyf(components.LevelWarning, "%d invalid patterns skipped (first: %s)", len(warnings), warnings[0])
+		}
+	}
+	return model
 }
 
+// similarityTrainedMsg is sent when the payee similarity index has been built
+type similarityTrainedMsg struct{}
+
 // Init initializes the model
+// The auto-categorize scan runs after similarity training so it can use that backend.
 func (m Model) Init() tea.Cmd {
-	return nil
+	var watch tea.Cmd
+	if m.source.path != "" {
+		watch = checkConfigCmd()
+	}
+	if m.categorizer == nil {
+		return watch
+	}
+	if m.config.Categorization.SimilarityEnabled {
+		return tea.Batch(watch, trainSimilarityCmd(m.file, m.categorizer))
+	}
+	// Auto-categorization writes to the ledger, so a read-only one is left alone
+	if m.config.Categorization.AutoCategorize && !m.config.UI.ReadOnly {
+		return tea.Batch(watch, findAutoCandidatesCmd(m.file, m.categorizer))
+	}
+	return watch
+}
+
+// trainSimilarityCmd builds the similarity index in the background
+// The transactions are read before the command starts: only the UI goroutine may
+// touch the file, whose index and cache Update changes.
+func trainSimilarityCmd(file *beancount.File, cat *categorizer.Categorizer) tea.Cmd {
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		return nil
+	}
+	return func() tea.Msg {
+		cat.TrainSimilarity(transactions)
+		return similarityTrainedMsg{}
+	}
 }
 
 // Update handles messages and updates the model
+// Notifications raised while handling a message are scheduled to expire
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	m, cmd = updated.(Model).scheduleWriteBack(cmd)
+	return m.scheduleExpiry(cmd)
+}
+
+// update handles a message for Update
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -144,20 +369,147 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.ready = true
+		return m.resize(), nil
+
+	case noticeExpiredMsg:
+		m.notices.ticking = false
+		return m.expireNotices(time.Now()), nil
+
+	case configCheckMsg:
+		return m.checkConfig()
+
+	case confirm.RequestMsg:
+		return m.requestWrite(msg)
+
+	case entrySavedMsg:
+		return m.entrySaved(msg), nil
 
-		// Update menu bar and status bar sizes
-		m.menuBar = m.menuBar.SetWidth(msg.Width)
-		m.statusBar = m.statusBar.SetWidth(msg.Width)
+	case autoAppliedMsg:
+		return m.autoApplied(msg), nil
 
-		// Update all view sizes (-2 for menu bar and status bar)
-		contentHeight := msg.Height - 2
-		m.dashboard = m.dashboard.SetSize(msg.Width, contentHeight)
-		m.transactions = m.transactions.SetSize(msg.Width, contentHeight)
-		m.accounts = m.accounts.SetSize(msg.Width, contentHeight)
+	case writeBackMsg:
+		return m.writeBackDone(msg), nil
 
+	case components.NotifyMsg:
+		return m.notify(msg.Level, msg.Text), nil
+
+	case similarityTrainedMsg:
+		if m.config.Categorization.AutoCategorize && !m.config.UI.ReadOnly {
+			return m, findAutoCandidatesCmd(m.file, m.categorizer)
+		}
+		return m, nil
+
+	case autoCandidatesMsg:
+		return m.handleAutoCandidates(msg), nil
+
+	case transactions.CategoryAppliedMsg:
+		if msg.Err != nil {
+			return m.notifyf(components.LevelError, "Categorize failed: %v", msg.Err), nil
+		}
+		m.journal.record(editBatch{
+			edits:       []beancount.Edit{msg.Edit},
+			description: "categorization as " + msg.Category,
+		})
+		return m.notifyf(components.LevelSuccess, "Categorized as %s (u: undo)", msg.Category), nil
+
+	case review.CategorizedMsg:
+		if msg.Err == nil && len(msg.Edits) > 0 {
+			m.journal.record(editBatch{edits: msg.Edits, description: msg.Description})
+		}
+		newReview, cmd := m.review.Update(msg)
+		m.review = newReview.(review.Model)
+		return m, cmd
+
+	case merge.MergedMsg:
+		return m.payeesMerged(msg), nil
+
+	case imports.AppendedMsg:
+		return m.importsAppended(msg)
+
+	case imports.ImportRequestedMsg:
+		return m.openImportDialog(), nil
+
+	case imports.SyncRequestedMsg:
+		return m.startSync()
+
+	case syncedMsg:
+		return m.synced(msg), nil
+
+	case transactions.TransactionDeletedMsg:
+		if msg.Err != nil {
+			return m.notifyf(components.LevelError, "Delete failed: %v", msg.Err), nil
+		}
+		m.journal.record(editBatch{
+			edits:       []beancount.Edit{msg.Edit},
+			description: "deletion of " + msg.Description,
+		})
+		m.transactions = m.transactions.Reload()
+		return m.notifyf(components.LevelSuccess, "Deleted %s (u: undo)", msg.Description), nil
+
+	case transactions.FlagToggledMsg:
+		if msg.Err != nil {
+			return m.notifyf(components.LevelError, "Flag change failed: %v", msg.Err), nil
+		}
+		m.journal.record(editBatch{
+			edits:       []beancount.Edit{msg.Edit},
+			description: "flag change of " + msg.Description,
+		})
+		return m.notifyf(components.LevelSuccess, "Flagged %s as %s (u: undo)", msg.Description, msg.Flag), nil
+
+	case transactions.CopyRequestedMsg:
+		return m.handleCopyRequest(msg)
+
+	case clipboardCopiedMsg:
+		return m.handleClipboardCopied(msg), nil
+
+	case components.MenuSelectedMsg:
+		return m.handleMenu(msg)
+
+	case transactions.EditRequestedMsg:
+		return m.editEntry(msg.Transaction), nil
+
+	case reports.DrillDownMsg:
+		return m.drillDown(msg.Query(), msg.Period), nil
+
+	case budgets.DrillDownMsg:
+		return m.drillDown("account:"+msg.Account, msg.Period), nil
+
+	case tags.DrillDownMsg:
+		return m.drillDown(msg.Query(), beancount.AllTime()), nil
+
+	case problems.JumpMsg:
+		m.currentView = TransactionsView
+		m.transactions = m.transactions.Reload().ShowTransaction(msg.Transaction)
 		return m, nil
 
 	case tea.KeyMsg:
+		// Dialogs are modal; only ctrl+c escapes them. A write confirmation
+		// can open over the entry form, so it comes first
+		if m.confirm != nil && msg.String() != "ctrl+c" {
+			return m.updateConfirm(msg)
+		}
+		if m.entry != nil && msg.String() != "ctrl+c" {
+			return m.updateEntry(msg)
+		}
+		if m.fileOpen != nil && msg.String() != "ctrl+c" {
+			return m.updateFileOpen(msg)
+		}
+		if m.importFile != nil && msg.String() != "ctrl+c" {
+			return m.updateImportFile(msg)
+		}
+		if m.export != nil && msg.String() != "ctrl+c" {
+			return m.updateExport(msg)
+		}
+		if m.merge != nil && msg.String() != "ctrl+c" {
+			return m.updateMerge(msg)
+		}
+		if m.help != nil && msg.String() != "ctrl+c" {
+			return m.updateHelp(msg), nil
+		}
+		if m.showLog && msg.String() != "ctrl+c" {
+			return m.updateMessageLog(msg), nil
+		}
+
 		// Let menu bar handle its keys first (F10, Alt+keys, etc.)
 		newMenuBar, menuCmd := m.menuBar.Update(msg)
 		m.menuBar = newMenuBar
@@ -170,44 +522,142 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Batch(cmds...)
 		}
 
+		// Search, pickers, forms and the review queue take keys before global shortcuts
+		if m.capturesKey(msg) {
+			return m.routeToView(msg)
+		}
+
 		// Global navigation keys
 		switch {
 		case key.Matches(msg, m.keys.Quit):
 			return m, tea.Quit
 
 		case key.Matches(msg, m.keys.Dashboard):
-			m.currentView = DashboardView
-			return m, nil
+			return m.showDashboard(), nil
 
 		case key.Matches(msg, m.keys.Transactions):
 			m.currentView = TransactionsView
 			return m, nil
 
 		case key.Matches(msg, m.keys.Accounts):
-			m.currentView = AccountsView
-			return m, nil
+			return m.showAccounts(), nil
 
 		case key.Matches(msg, m.keys.Reports):
-			m.currentView = ReportsView
+			return m.showReports(), nil
+
+		case key.Matches(msg, m.keys.Patterns):
+			return m.showPatterns(), nil
+
+		case key.Matches(msg, m.keys.Review):
+			return m.startReview(), nil
+
+		case key.Matches(msg, m.keys.Budgets):
+			return m.showBudgets(), nil
+
+		case key.Matches(msg, m.keys.Tags):
+			return m.showTags(), nil
+
+		case key.Matches(msg, m.keys.Problems):
+			return m.showProblems(), nil
+
+		case key.Matches(msg, m.keys.Imports):
+			m.currentView = ImportsView
 			return m, nil
 
+		case key.Matches(msg, m.keys.NewTransaction):
+			return m.openEntry(), nil
+
+		case key.Matches(msg, m.keys.OpenFile):
+			return m.openFileDialog(), nil
+
+		case key.Matches(msg, m.keys.Export):
+			return m.openExportDialog(), nil
+
+		case key.Matches(msg, m.keys.Help):
+			return m.openHelp(), nil
+
+		case key.Matches(msg, m.keys.Messages):
+			m.showLog = true
+			return m, nil
+
+		case key.Matches(msg, m.keys.Theme):
+			return m.nextTheme(), nil
+
+		case key.Matches(msg, m.keys.Currency):
+			return m.nextCurrency(), nil
+
+		case key.Matches(msg, m.keys.Compact):
+			return m.toggleCompact(), nil
+
+		case key.Matches(msg, m.keys.LargerPage):
+			return m.resizePages(pageSizeStep), nil
+
+		case key.Matches(msg, m.keys.SmallerPage):
+			return m.resizePages(-pageSizeStep), nil
+
+		case key.Matches(msg, m.keys.HelpRow):
+			m.showHelpRow = !m.showHelpRow
+			return m.resize(), nil
+
+		// Apply staged suggestions / undo and redo ledger edits
+		case msg.String() == "A" && len(m.staged) > 0:
+			return m.confirmAutoCandidates(m.staged)
+		case key.Matches(msg, m.keys.Undo):
+			return m.undoLast(), nil
+		case key.Matches(msg, m.keys.Redo):
+			return m.redoLast(), nil
+
 		// TP7-style F-key shortcuts
+		case msg.String() == "f1":
+			return m.openHelp(), nil
 		case msg.String() == "f2":
-			m.currentView = DashboardView
-			return m, nil
+			return m.showDashboard(), nil
 		case msg.String() == "f3":
 			m.currentView = TransactionsView
 			return m, nil
 		case msg.String() == "f4":
-			m.currentView = AccountsView
-			return m, nil
+			return m.showAccounts(), nil
 		case msg.String() == "f5":
-			m.currentView = ReportsView
-			return m, nil
+			return m.showReports(), nil
+		case msg.String() == "f6":
+			return m.showPatterns(), nil
+		case msg.String() == "f7":
+			return m.startReview(), nil
+		case msg.String() == "f8":
+			return m.showBudgets(), nil
+		case msg.String() == "f9":
+			return m.showTags(), nil
 		}
 	}
 
-	// Route to current view
+	return m.routeToView(msg, cmds...)
+}
+
+// capturesKey reports whether the current view consumes a key before global shortcuts
+// ctrl+c always reaches the global handler so the app can quit
+func (m Model) capturesKey(msg tea.KeyMsg) bool {
+	if msg.String() == "ctrl+c" {
+		return false
+	}
+	switch m.currentView {
+	case TransactionsView:
+		return m.transactions.Editing()
+	case AccountsView:
+		return m.accounts.Editing()
+	case PatternsView:
+		return m.patterns.Editing()
+	case ReviewView:
+		return m.review.Captures(msg)
+	case ProblemsView:
+		return m.problems.Editing()
+	case ImportsView:
+		return m.imports.Captures(msg)
+	}
+	return false
+}
+
+// routeToView passes a message to the current view
+func (m Model) routeToView(msg tea.Msg, cmds ...tea.Cmd) (tea.Model, tea.Cmd) {
 	switch m.currentView {
 	case DashboardView:
 		newDashboard, cmd := m.dashboard.Update(msg)
@@ -223,6 +673,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		newAccounts, cmd := m.accounts.Update(msg)
 		m.accounts = newAccounts.(accounts.Model)
 		cmds = append(cmds, cmd)
+
+	case ReportsView:
+		newReports, cmd := m.reports.Update(msg)
+		m.reports = newReports.(reports.Model)
+		cmds = append(cmds, cmd)
+
+	case PatternsView:
+		newPatterns, cmd := m.patterns.Update(msg)
+		m.patterns = newPatterns.(patterns.Model)
+		cmds = append(cmds, cmd)
+
+	case ReviewView:
+		newReview, cmd := m.review.Update(msg)
+		m.review = newReview.(review.Model)
+		cmds = append(cmds, cmd)
+
+	case BudgetsView:
+		newBudgets, cmd := m.budgets.Update(msg)
+		m.budgets = newBudgets.(budgets.Model)
+		cmds = append(cmds, cmd)
+
+	case TagsView:
+		newTags, cmd := m.tags.Update(msg)
+		m.tags = newTags.(tags.Model)
+		cmds = append(cmds, cmd)
+
+	case ProblemsView:
+		newProblems, cmd := m.problems.Update(msg)
+		m.problems = newProblems.(problems.Model)
+		cmds = append(cmds, cmd)
+
+	case ImportsView:
+		newImports, cmd := m.imports.Update(msg)
+		m.imports = newImports.(imports.Model)
+		cmds = append(cmds, cmd)
 	}
 
 	return m, tea.Batch(cmds...)
@@ -248,18 +733,269 @@ func (m Model) View() string {
 	case AccountsView:
 		content = m.accounts.View()
 	case ReportsView:
-		content = renderReportsPlaceholder()
+		content = m.reports.View()
+	case PatternsView:
+		content = m.patterns.View()
+	case ReviewView:
+		content = m.review.View()
+	case BudgetsView:
+		content = m.budgets.View()
+	case TagsView:
+		content = m.tags.View()
+	case ProblemsView:
+		content = m.problems.View()
+	case ImportsView:
+		content = m.imports.View()
 	}
 
 	// Fill the content area with TP7 blue background to full height
 	// (except for TransactionsView which manages its own background)
-	contentHeight := m.height - 2 // Minus menu bar and status bar
-	if m.currentView != TransactionsView {
-		content = renderFullScreenContent(content, m.width, contentHeight)
+	contentHeight := m.height - 2 // Minus menu bar and status bar; dialogs cover the help row
+	if m.confirm != nil {
+		content = renderFullScreenContent(m.confirm.View(), m.width, contentHeight)
+	} else if m.entry != nil {
+		content = renderFullScreenContent(m.entry.View(), m.width, contentHeight)
+	} else if m.fileOpen != nil {
+		content = renderFullScreenContent(m.fileOpen.View(), m.width, contentHeight)
+	} else if m.importFile != nil {
+		content = renderFullScreenContent(m.importFile.View(), m.width, contentHeight)
+	} else if m.export != nil {
+		content = renderFullScreenContent(m.export.View(), m.width, contentHeight)
+	} else if m.merge != nil {
+		content = renderFullScreenContent(m.merge.View(), m.width, contentHeight)
+	} else if m.help != nil {
+		content = renderFullScreenContent(m.help.View(), m.width, contentHeight)
+	} else if m.showLog {
+		content = renderFullScreenContent(m.viewMessageLog(), m.width, contentHeight)
+	} else if m.currentView != TransactionsView {
+		content = renderFullScreenContent(content, m.width, m.contentHeight())
 	}
+	content = overlayDropdown(content, m.menuBar)
 
-	// Render TP7-style status bar
-	footer := renderFooter(m.currentView, m.statusBar)
+	// Render TP7-style status bar, with the help row above it
+	footer := renderFooter(m.statusBar, m.footerBindings(), m.statusLine())
+	if m.showHelpRow && !m.dialogOpen() {
+		footer = components.FullHelpView(m.helpRowGroups(), m.width, helpRowHeight) + "\n" + footer
+	}
 
 	return header + "\n" + content + "\n" + footer
 }
+
+// showDashboard switches to the dashboard, refreshing statistics and expected bills
+func (m Model) showDashboard() Model {
+	m.currentView = DashboardView
+	m.dashboard = m.dashboard.Reload()
+	return m
+}
+
+// showAccounts switches to the accounts view, refreshing balances
+// The ledger may have changed since the view was last shown
+func (m Model) showAccounts() Model {
+	m.currentView = AccountsView
+	m.accounts = m.accounts.Reload()
+	return m
+}
+
+// showReports switches to the reports view, recomputing totals
+// The ledger may have changed since the view was last shown; reports of unchanged
+// content come from the view's cache.
+func (m Model) showReports() Model {
+	m.currentView = ReportsView
+	m.reports = m.reports.Reload()
+	return m
+}
+
+// showTags switches to the tags view, recounting tags
+func (m Model) showTags() Model {
+	m.currentView = TagsView
+	m.tags = m.tags.Reload()
+	return m
+}
+
+// showProblems switches to the problems view, checking the ledger again
+func (m Model) showProblems() Model {
+	m.currentView = ProblemsView
+	return m.recheck()
+}
+
+// recheck checks the ledger for problems and updates the count on the menu entry
+func (m Model) recheck() Model {
+	m.problems = m.problems.Reload()
+	m.menuBar = m.menuBar.SetBadge("Problems", countBadge(m.problems.Count()))
+	return m
+}
+
+// countBadge is the menu badge for a count, such as of problems; none shows nothing
+func countBadge(count int) string {
+	if count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("(%d)", count)
+}
+
+// showBudgets switches to the budgets view, recomputing spending
+func (m Model) showBudgets() Model {
+	m.currentView = BudgetsView
+	m.budgets = m.budgets.Reload()
+	return m
+}
+
+// contentHeight is the height of the current view: the screen less the menu bar,
+// the status bar and the help row when shown
+func (m Model) contentHeight() int {
+	height := m.height - 2
+	if m.showHelpRow {
+		height -= helpRowHeight
+	}
+	return max(height, 0)
+}
+
+// dialogOpen reports whether a dialog or overlay covers the current view
+func (m Model) dialogOpen() bool {
+	return m.confirm != nil || m.entry != nil || m.fileOpen != nil || m.importFile != nil || m.export != nil || m.merge != nil || m.help != nil || m.showLog
+}
+
+// resize lays out the bars, views and open dialogs for the screen size
+// Dialogs cover the help row, so they get the full height between the bars.
+func (m Model) resize() Model {
+	m.menuBar = m.menuBar.SetWidth(m.width)
+	m.statusBar = m.statusBar.SetWidth(m.width)
+
+	contentHeight := m.contentHeight()
+	m.dashboard = m.dashboard.SetSize(m.width, contentHeight)
+	m.transactions = m.transactions.SetSize(m.width, contentHeight)
+	m.accounts = m.accounts.SetSize(m.width, contentHeight)
+	m.reports = m.reports.SetSize(m.width, contentHeight)
+	m.patterns = m.patterns.SetSize(m.width, contentHeight)
+	m.review = m.review.SetSize(m.width, contentHeight)
+	m.budgets = m.budgets.SetSize(m.width, contentHeight)
+	m.tags = m.tags.SetSize(m.width, contentHeight)
+	m.problems = m.problems.SetSize(m.width, contentHeight)
+	m.imports = m.imports.SetSize(m.width, contentHeight)
+
+	dialogHeight := m.height - 2
+	if m.entry != nil {
+		resized := m.entry.SetSize(m.width, dialogHeight)
+		m.entry = &resized
+	}
+	if m.fileOpen != nil {
+		resized := m.fileOpen.SetSize(m.width, dialogHeight)
+		m.fileOpen = &resized
+	}
+	if m.importFile != nil {
+		resized := m.importFile.SetSize(m.width, dialogHeight)
+		m.importFile = &resized
+	}
+	if m.export != nil {
+		resized := m.export.SetSize(m.width, dialogHeight)
+		m.export = &resized
+	}
+	if m.merge != nil {
+		resized := m.merge.SetSize(m.width, dialogHeight)
+		m.merge = &resized
+	}
+	if m.help != nil {
+		resized := m.help.SetSize(m.width, dialogHeight)
+		m.help = &resized
+	}
+	if m.confirm != nil {
+		resized := m.confirm.SetSize(m.width, dialogHeight)
+		m.confirm = &resized
+	}
+	return m
+}
+
+// setDisplay hands every view, and the entry form if open, how to render amounts,
+// dates and their layout
+// Lists are refitted, as the compact layout fits more rows.
+func (m Model) setDisplay(display components.Display) Model {
+	m.display = display
+	m.dashboard = m.dashboard.SetDisplay(display)
+	m.transactions = m.transactions.SetDisplay(display)
+	m.accounts = m.accounts.SetDisplay(display)
+	m.reports = m.reports.SetDisplay(display)
+	m.patterns = m.patterns.SetDisplay(display)
+	m.review = m.review.SetDisplay(display)
+	m.budgets = m.budgets.SetDisplay(display)
+	m.tags = m.tags.SetDisplay(display)
+	m.problems = m.problems.SetDisplay(display)
+	m.imports = m.imports.SetDisplay(display)
+	if m.entry != nil {
+		form := m.entry.SetDisplay(display)
+		m.entry = &form
+	}
+	return m
+}
+
+// nextTheme switches to the next built-in theme
+// Views read the theme's styles when rendering, so the change shows on the next frame.
+func (m Model) nextTheme() Model {
+	theme.Apply(theme.Next())
+	return m.notifyf(components.LevelInfo, "Theme: %s", theme.Current().Name)
+}
+
+// toggleCompact switches every view between the standard and the compact layout
+func (m Model) toggleCompact() Model {
+	display := m.display
+	display.Compact = !display.Compact
+	m = m.setDisplay(display)
+	if m.display.Compact {
+		return m.notify(components.LevelInfo, "Compact layout")
+	}
+	return m.notify(components.LevelInfo, "Standard layout")
+}
+
+// resizePages changes how many rows page up and page down move by delta, within the
+// limits the config allows
+func (m Model) resizePages(delta int) Model {
+	size := max(1, min(config.MaxPageSize, m.display.PageSize+delta))
+	display := m.display
+	display.PageSize = size
+	m = m.setDisplay(display)
+	if size == 1 {
+		return m.notify(components.LevelInfo, "Page size: 1 row")
+	}
+	return m.notifyf(components.LevelInfo, "Page size: %d rows", size)
+}
+
+// nextCurrency shows report and dashboard totals in the next currency the ledger has
+// prices in, cycling back to native commodities
+func (m Model) nextCurrency() Model {
+	options := append([]string{""}, beancount.NewPriceDB(m.file.GetPriceDirectives()).Currencies()...)
+	if m.config.Amounts.Currency != "" && !slices.Contains(options, m.config.Amounts.Currency) {
+		options = append(options, m.config.Amounts.Currency)
+	}
+	if len(options) == 1 && m.currency == "" {
+		return m.notify(components.LevelWarning, "No price directives in the ledger to convert totals with")
+	}
+
+	m.currency = options[(slices.Index(options, m.currency)+1)%len(options)]
+	m.dashboard = m.dashboard.SetCurrency(m.currency)
+	m.reports = m.reports.SetCurrency(m.currency)
+	if m.currency == "" {
+		return m.notify(components.LevelInfo, "Totals in native commodities")
+	}
+	return m.notifyf(components.LevelInfo, "Totals in %s", m.currency)
+}
+
+// drillDown shows the transactions matching a filter in a period, e.g. from a report row
+func (m Model) drillDown(query string, period beancount.Period) Model {
+	m.currentView = TransactionsView
+	m.transactions = m.transactions.SetFilter(query).SetPeriod(period)
+	return m
+}
+
+// showPatterns switches to the patterns view, refreshing match counts
+// The ledger may have changed since the view was last shown
+func (m Model) showPatterns() Model {
+	m.currentView = PatternsView
+	m.patterns = m.patterns.Reload()
+	return m
+}
+
+// startReview switches to the review view with a fresh queue
+func (m Model) startReview() Model {
+	m.currentView = ReviewView
+	m.review = m.review.Reload()
+	return m
+}
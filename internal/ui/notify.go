@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+const (
+	// noticeTTL is how long info and success notifications stay in the status bar
+	noticeTTL = 5 * time.Second
+
+	// problemTTL is how long warnings and errors stay, so they can be read
+	problemTTL = 10 * time.Second
+
+	// noticeLogSize is how many notifications the message log keeps
+	noticeLogSize = 50
+)
+
+// notice is a notification shown in the status bar until it expires
+type notice struct {
+	components.Notification
+	expires time.Time
+}
+
+// notices queues the status bar notifications
+type notices struct {
+	// active are the unexpired notifications, newest last; the newest is shown
+	active []notice
+
+	// log keeps recent notifications, newest last, for the message log
+	log []components.Notification
+
+	// ticking is true while an expiry timer is pending
+	ticking bool
+}
+
+// noticeExpiredMsg is sent when the earliest active notification may have expired
+type noticeExpiredMsg struct{}
+
+// notify shows a notification in the status bar and records it in the message log
+func (m Model) notify(level components.Level, text string) Model {
+	n := components.Notification{Level: level, Text: text, Time: time.Now()}
+	ttl := noticeTTL
+	if level >= components.LevelWarning {
+		ttl = problemTTL
+	}
+
+	// Clip so copies of the model don't share the appended notifications
+	m.notices.active = append(slices.Clip(m.notices.active), notice{Notification: n, expires: n.Time.Add(ttl)})
+	m.notices.log = append(slices.Clip(m.notices.log), n)
+	if len(m.notices.log) > noticeLogSize {
+		m.notices.log = m.notices.log[len(m.notices.log)-noticeLogSize:]
+	}
+	return m
+}
+
+// notifyf formats and shows a notification
+func (m Model) notifyf(level components.Level, format string, args ...any) Model {
+	return m.notify(level, fmt.Sprintf(format, args...))
+}
+
+// status returns the text of the notification shown in the status bar, if any
+func (m Model) status() string {
+	if len(m.notices.active) == 0 {
+		return ""
+	}
+	return m.notices.active[len(m.notices.active)-1].Text
+}
+
+// statusLine returns the status bar message: the newest notification with its level marker
+func (m Model) statusLine() string {
+	if len(m.notices.active) == 0 {
+		return ""
+	}
+	return m.notices.active[len(m.notices.active)-1].String()
+}
+
+// expireNotices drops the notifications whose time is up
+func (m Model) expireNotices(now time.Time) Model {
+	var active []notice
+	for _, n := range m.notices.active {
+		if n.expires.After(now) {
+			active = append(active, n)
+		}
+	}
+	m.notices.active = active
+	return m
+}
+
+// scheduleExpiry starts a timer for the earliest active notification unless one is pending
+func (m Model) scheduleExpiry(cmd tea.Cmd) (Model, tea.Cmd) {
+	if m.notices.ticking || len(m.notices.active) == 0 {
+		return m, cmd
+	}
+	earliest := m.notices.active[0].expires
+	for _, n := range m.notices.active[1:] {
+		if n.expires.Before(earliest) {
+			earliest = n.expires
+		}
+	}
+	m.notices.ticking = true
+	tick := tea.Tick(max(time.Until(earliest), 10*time.Millisecond), func(time.Time) tea.Msg {
+		return noticeExpiredMsg{}
+	})
+	return m, tea.Batch(cmd, tick)
+}
+
+// updateMessageLog handles keys while the message log is shown
+func (m Model) updateMessageLog(msg tea.KeyMsg) Model {
+	if key.Matches(msg, closeKey, m.keys.Messages) ||
+		(key.Matches(msg, m.keys.Quit) && msg.String() != "ctrl+c") {
+		m.showLog = false
+	}
+	return m
+}
+
+// viewMessageLog renders recent notifications, newest first
+func (m Model) viewMessageLog() string {
+	titleText := fmt.Sprintf("Messages - %d recent", len(m.notices.log))
+	titlePadded := components.PadRight(titleText, m.width)
+	lines := []string{theme.TitleStyle.Width(m.width).Render(titlePadded)}
+
+	if len(m.notices.log) == 0 {
+		lines = append(lines, theme.MutedTextStyle.Render("  No messages yet"))
+	}
+	visible := max(1, m.height-3)
+	for i := len(m.notices.log) - 1; i >= 0 && len(lines) <= visible; i-- {
+		n := m.notices.log[i]
+		style := theme.NormalTextStyle
+		switch n.Level {
+		case components.LevelSuccess:
+			style = theme.SuccessStyle
+		case components.LevelWarning:
+			style = theme.WarningStyle
+		case components.LevelError:
+			style = theme.ErrorStyle
+		}
+		lines = append(lines, theme.DateStyle.Render("  "+n.Time.Format("15:04:05")+" ")+style.Render(n.String()))
+	}
+	return strings.Join(lines, "\n")
+}
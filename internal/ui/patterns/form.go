@@ -0,0 +1,233 @@
+package patterns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+// Form field indexes
+const (
+	fieldID = iota
+	fieldName
+	fieldPattern
+	fieldCategory
+	fieldMin
+	fieldMax
+	fieldPriority
+	fieldCount
+)
+
+// previewLimit is how many matching transactions the form lists
+const previewLimit = 8
+
+// form is the create/edit dialog for a single pattern
+type form struct {
+	// editing is the ID of the pattern being edited ("" when creating)
+	editing string
+
+	// base keeps fields the form does not show (fields, tags, conditions, ...)
+	base categorizer.PatternYAML
+
+	inputs []components.TextInput
+	focus  int
+
+	// Live preview of the draft against the ledger
+	compiled     *categorizer.Pattern
+	err          error
+	preview      []*beancount.Transaction
+	previewCount int
+}
+
+// newForm creates a form, pre-filled from base when editing
+func newForm(base categorizer.PatternYAML, editing string, accounts []string) *form {
+	f := &form{editing: editing, base: base}
+
+	labels := []string{"ID", "Name", "Regex", "Category", "Min amount", "Max amount", "Priority"}
+	f.inputs = make([]components.TextInput, fieldCount)
+	for i, label := range labels {
+		f.inputs[i] = components.NewTextInput(fmt.Sprintf("%-10s", label)).SetWidth(50)
+	}
+	f.inputs[fieldCategory].Suggestions = accounts
+	f.inputs[fieldPattern].Placeholder = "e.g. (?i)starbucks|peets"
+	f.inputs[fieldMin].Placeholder = "none"
+	f.inputs[fieldMax].Placeholder = "none"
+
+	f.inputs[fieldID] = f.inputs[fieldID].SetValue(base.ID)
+	f.inputs[fieldName] = f.inputs[fieldName].SetValue(base.Name)
+	f.inputs[fieldPattern] = f.inputs[fieldPattern].SetValue(base.Pattern)
+	f.inputs[fieldCategory] = f.inputs[fieldCategory].SetValue(base.Category)
+	if base.MinAmount != nil {
+		f.inputs[fieldMin] = f.inputs[fieldMin].SetValue(strconv.FormatFloat(*base.MinAmount, 'f', -1, 64))
+	}
+	if base.MaxAmount != nil {
+		f.inputs[fieldMax] = f.inputs[fieldMax].SetValue(strconv.FormatFloat(*base.MaxAmount, 'f', -1, 64))
+	}
+	if base.Priority != 0 {
+		f.inputs[fieldPriority] = f.inputs[fieldPriority].SetValue(strconv.Itoa(base.Priority))
+	}
+
+	// The ID of an existing pattern identifies it and cannot change
+	if editing != "" {
+		f.focus = fieldName
+	}
+	f.inputs[f.focus] = f.inputs[f.focus].Focus()
+	return f
+}
+
+// yaml builds the pattern definition from the current field values
+func (f *form) yaml() (categorizer.PatternYAML, error) {
+	y := f.base
+	y.ID = strings.TrimSpace(f.inputs[fieldID].Value())
+	y.Name = strings.TrimSpace(f.inputs[fieldName].Value())
+	y.Pattern = f.inputs[fieldPattern].Value()
+	y.Category = strings.TrimSpace(f.inputs[fieldCategory].Value())
+	if y.Name == "" {
+		y.Name = y.ID
+	}
+
+	var err error
+	if y.MinAmount, err = parseAmount(f.inputs[fieldMin].Value()); err != nil {
+		return y, fmt.Errorf("min amount: %w", err)
+	}
+	if y.MaxAmount, err = parseAmount(f.inputs[fieldMax].Value()); err != nil {
+		return y, fmt.Errorf("max amount: %w", err)
+	}
+
+	y.Priority = 0
+	if priority := strings.TrimSpace(f.inputs[fieldPriority].Value()); priority != "" {
+		if y.Priority, err = strconv.Atoi(priority); err != nil {
+			return y, fmt.Errorf("priority must be a whole number")
+		}
+	}
+	return y, nil
+}
+
+// parseAmount parses an optional amount bound; empty means unbounded
+func parseAmount(s string) (*float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("not a number: %s", s)
+	}
+	return &v, nil
+}
+
+// refresh recompiles the draft and recomputes the preview
+func (f *form) refresh(cat *categorizer.Categorizer, transactions []*beancount.Transaction) {
+	f.compiled = nil
+	f.preview = nil
+	f.previewCount = 0
+
+	y, err := f.yaml()
+	if err == nil {
+		// Allow previewing before an ID is chosen
+		if y.ID == "" {
+			y.ID, y.Name = "draft", "draft"
+		}
+		f.compiled, err = cat.CompilePattern(y)
+	}
+	f.err = err
+	if f.compiled == nil {
+		return
+	}
+
+	for _, tx := range transactions {
+		if f.compiled.Matches(tx) {
+			if len(f.preview) < previewLimit {
+				f.preview = append(f.preview, tx)
+			}
+			f.previewCount++
+		}
+	}
+}
+
+// setFocus moves keyboard focus to field i, wrapping around
+func (f *form) setFocus(i int) {
+	first := fieldID
+	if f.editing != "" {
+		first = fieldName
+	}
+	if i >= fieldCount {
+		i = first
+	}
+	if i < first {
+		i = fieldCount - 1
+	}
+	f.inputs[f.focus] = f.inputs[f.focus].Blur()
+	f.focus = i
+	f.inputs[f.focus] = f.inputs[f.focus].Focus()
+}
+
+// update routes a key to the focused field; it reports whether the text changed
+func (f *form) update(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "down":
+		f.setFocus(f.focus + 1)
+		return false
+	case "shift+tab", "up":
+		f.setFocus(f.focus - 1)
+		return false
+	}
+
+	before := f.inputs[f.focus].Value()
+	input, handled := f.inputs[f.focus].Update(msg)
+	f.inputs[f.focus] = input
+	if !handled && msg.Type == tea.KeyTab {
+		// Tab without a completion moves to the next field
+		f.setFocus(f.focus + 1)
+	}
+	return input.Value() != before
+}
+
+// view renders the form with its live preview, dates as the display shows them
+func (f *form) view(width int, display components.Display) string {
+	title := "New Pattern"
+	if f.editing != "" {
+		title = "Edit Pattern: " + f.editing
+	}
+
+	var lines []string
+	lines = append(lines, theme.TitleStyle.Render(title), "")
+	for _, input := range f.inputs {
+		lines = append(lines, "  "+input.View())
+	}
+	if f.base.When != nil || len(f.base.Exclude) > 0 || len(f.base.Splits) > 0 {
+		lines = append(lines, theme.MutedTextStyle.Render("  (conditions, exclusions and splits are kept as in the patterns file)"))
+	}
+	lines = append(lines, "")
+
+	switch {
+	case f.err != nil:
+		lines = append(lines, theme.ErrorStyle.Render("  "+f.err.Error()))
+	case f.compiled != nil:
+		lines = append(lines, theme.HighlightStyle.Render(fmt.Sprintf("  Preview: %d matching transactions", f.previewCount)))
+		for _, tx := range f.preview {
+			description := tx.Payee
+			if description == "" {
+				description = tx.Narration
+			}
+			line := fmt.Sprintf("    %s  %s", display.Date(tx.Date), description)
+			if len(line) > width-2 && width > 5 {
+				line = line[:width-5] + "..."
+			}
+			lines = append(lines, theme.NormalTextStyle.Render(line))
+		}
+		if f.previewCount > len(f.preview) {
+			lines = append(lines, theme.MutedTextStyle.Render(fmt.Sprintf("    ... and %d more", f.previewCount-len(f.preview))))
+		}
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, theme.MutedTextStyle.Render("  tab/↑↓:field   tab:complete category   enter:save   esc:cancel"))
+	return strings.Join(lines, "\n")
+}
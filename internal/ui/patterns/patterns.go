@@ -0,0 +1,434 @@
+package patterns
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// keyMap defines key bindings for the patterns view
+type keyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	New      key.Binding
+	Edit     key.Binding
+	Delete   key.Binding
+	Toggle   key.Binding
+}
+
+// newKeyMap creates the key bindings, taking navigation keys from the config
+func newKeyMap(cfg config.KeybindingsConfig) keyMap {
+	return keyMap{
+		Up:       components.Binding(cfg.Up, "up"),
+		Down:     components.Binding(cfg.Down, "down"),
+		PageUp:   components.Binding(cfg.PageUp, "page up"),
+		PageDown: components.Binding(cfg.PageDown, "page down"),
+		Top:      components.Binding(cfg.Top, "top"),
+		Bottom:   components.Binding(cfg.Bottom, "bottom"),
+		New: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "new"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("enter", "e"),
+			key.WithHelp("enter/e", "edit"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("d", "delete"),
+			key.WithHelp("d", "delete"),
+		),
+		Toggle: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "enable/disable"),
+		),
+	}
+}
+
+// FixedKeys returns the keys the view handles whatever the config binds, by what they do
+func FixedKeys() map[string]string {
+	return components.FixedKeys(newKeyMap(config.KeybindingsConfig{}))
+}
+
+// patternRow is a pattern with the number of ledger transactions it matches
+type patternRow struct {
+	pattern *categorizer.Pattern
+	matches int
+}
+
+// Model represents the patterns view model
+type Model struct {
+	file        *beancount.File
+	categorizer *categorizer.Categorizer
+	width       int
+	height      int
+	display     components.Display
+
+	// List state
+	list components.Scroller
+	rows []patternRow
+	keys keyMap
+
+	// transactions is the ledger snapshot used for match counts and previews
+	transactions []*beancount.Transaction
+	accounts     []string
+
+	// form is the open create/edit dialog (nil when closed)
+	form *form
+
+	// confirmDelete is the ID awaiting y/n confirmation ("" when none)
+	confirmDelete string
+
+	// message reports the result of the last action
+	message string
+}
+
+// New creates a new patterns model
+func New(file *beancount.File, cat *categorizer.Categorizer, keys config.KeybindingsConfig) Model {
+	m := Model{
+		file:        file,
+		categorizer: cat,
+		keys:        newKeyMap(keys),
+		accounts:    file.GetAccounts(),
+	}
+	m.transactions, _ = file.AllTransactions()
+	m.refresh()
+	return m
+}
+
+// Editing reports whether the view is capturing text input
+// The root model suspends global shortcuts while this is true
+func (m Model) Editing() bool {
+	return m.form != nil || m.confirmDelete != ""
+}
+
+// SetKeys rebinds the view's keys, e.g. after the config is reloaded
+func (m Model) SetKeys(keys config.KeybindingsConfig) Model {
+	m.keys = newKeyMap(keys)
+	return m
+}
+
+// Reload re-reads transactions after the ledger changed on disk
+func (m Model) Reload() Model {
+	m.transactions, _ = m.file.AllTransactions()
+	m.accounts = m.file.GetAccounts()
+	m.refresh()
+	return m
+}
+
+// refresh rebuilds the rows from the categorizer's current patterns
+func (m *Model) refresh() {
+	m.rows = nil
+	if m.categorizer == nil {
+		return
+	}
+
+	patterns := m.categorizer.GetPatterns()
+	sort.SliceStable(patterns, func(i, j int) bool {
+		if patterns[i].EffectivePriority() != patterns[j].EffectivePriority() {
+			return patterns[i].EffectivePriority() > patterns[j].EffectivePriority()
+		}
+		return patterns[i].ID < patterns[j].ID
+	})
+
+	for _, pattern := range patterns {
+		row := patternRow{pattern: pattern}
+		for _, tx := range m.transactions {
+			if pattern.Matches(tx) {
+				row.matches++
+			}
+		}
+		m.rows = append(m.rows, row)
+	}
+
+	m.scroll()
+}
+
+// selected returns the pattern under the cursor, or nil
+func (m Model) selected() *categorizer.Pattern {
+	if len(m.rows) == 0 {
+		return nil
+	}
+	return m.rows[m.list.Cursor()].pattern
+}
+
+// selectID moves the cursor to the pattern with the given ID
+func (m *Model) selectID(id string) {
+	for i, row := range m.rows {
+		if row.pattern.ID == id {
+			m.list = m.list.Select(i)
+			return
+		}
+	}
+}
+
+// visibleRows is the number of list rows that fit on screen
+func (m Model) visibleRows() int {
+	rows := m.height - 6 // Title, header, separator, message line and padding
+	if rows < 1 {
+		rows = 1
+	}
+	return rows
+}
+
+// scroll keeps the cursor on a pattern and inside the visible window
+func (m *Model) scroll() {
+	m.list = m.list.Fit(len(m.rows), m.visibleRows())
+}
+
+// save persists patterns to the configured file and reports the outcome
+func (m *Model) save(action string) {
+	if err := m.categorizer.WritePatterns(); err != nil {
+		m.message = fmt.Sprintf("%s (not saved: %v)", action, err)
+		return
+	}
+	m.message = action
+}
+
+// Init initializes the patterns view
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || m.categorizer == nil {
+		return m, nil
+	}
+
+	if m.form != nil {
+		return m.updateForm(keyMsg), nil
+	}
+
+	if m.confirmDelete != "" {
+		id := m.confirmDelete
+		m.confirmDelete = ""
+		if keyMsg.String() != "y" {
+			m.message = "Delete cancelled"
+			return m, nil
+		}
+		if err := m.categorizer.RemovePattern(id); err != nil {
+			m.message = fmt.Sprintf("Delete failed: %v", err)
+			return m, nil
+		}
+		m.refresh()
+		m.save(fmt.Sprintf("Deleted pattern %s", id))
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		m.list = m.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		m.list = m.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		m.list = m.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		m.list = m.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		m.list = m.list.Top()
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		m.list = m.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.New):
+		m.form = newForm(categorizer.PatternYAML{}, "", m.accounts)
+		m.form.refresh(m.categorizer, m.transactions)
+
+	case key.Matches(keyMsg, m.keys.Edit):
+		if pattern := m.selected(); pattern != nil {
+			m.form = newForm(categorizer.PatternToYAML(pattern), pattern.ID, m.accounts)
+			m.form.refresh(m.categorizer, m.transactions)
+		}
+
+	case key.Matches(keyMsg, m.keys.Delete):
+		if pattern := m.selected(); pattern != nil {
+			m.confirmDelete = pattern.ID
+			m.message = fmt.Sprintf("Delete pattern %s? (y/n)", pattern.ID)
+		}
+
+	case key.Matches(keyMsg, m.keys.Toggle):
+		if pattern := m.selected(); pattern != nil {
+			enabled, err := m.categorizer.TogglePattern(pattern.ID)
+			if err != nil {
+				m.message = fmt.Sprintf("Toggle failed: %v", err)
+				break
+			}
+			state := "Disabled"
+			if enabled {
+				state = "Enabled"
+			}
+			m.save(fmt.Sprintf("%s pattern %s", state, pattern.ID))
+		}
+	}
+
+	return m, nil
+}
+
+// updateForm handles keys while the create/edit form is open
+func (m Model) updateForm(msg tea.KeyMsg) Model {
+	switch msg.String() {
+	case "esc":
+		m.form = nil
+		m.message = ""
+		return m
+
+	case "enter", "ctrl+s":
+		return m.submitForm()
+	}
+
+	if m.form.update(msg) {
+		m.form.refresh(m.categorizer, m.transactions)
+	}
+	return m
+}
+
+// submitForm validates the draft and adds or replaces the pattern
+func (m Model) submitForm() Model {
+	y, err := m.form.yaml()
+	if err == nil {
+		var pattern *categorizer.Pattern
+		pattern, err = m.categorizer.CompilePattern(y)
+		if err == nil {
+			if m.form.editing != "" {
+				err = m.categorizer.UpdatePattern(pattern)
+			} else {
+				err = m.categorizer.AddPattern(pattern)
+			}
+		}
+	}
+	if err != nil {
+		m.form.err = err
+		return m
+	}
+
+	action := "Added"
+	if m.form.editing != "" {
+		action = "Updated"
+	}
+	m.form = nil
+	m.refresh()
+	m.selectID(y.ID)
+	m.save(fmt.Sprintf("%s pattern %s", action, y.ID))
+	return m
+}
+
+// View renders the patterns view
+func (m Model) View() string {
+	if m.form != nil {
+		return m.form.view(m.width, m.display)
+	}
+
+	var lines []string
+
+	titleText := fmt.Sprintf("Patterns (%d total)", len(m.rows))
+	lines = append(lines, theme.TitleStyle.Render(titleText), "")
+
+	if m.categorizer == nil {
+		lines = append(lines, theme.NormalTextStyle.Render("Categorization is disabled"))
+		return strings.Join(lines, "\n")
+	}
+	if len(m.rows) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("No patterns defined. Press n to create one."))
+	} else {
+		header := fmt.Sprintf("  %-3s %-24s %-36s %8s %9s %8s", "On", "ID", "Category", "Priority", "Matches", "Accuracy")
+		lines = append(lines, theme.TitleStyle.Render(header))
+		lines = append(lines, theme.MutedTextStyle.Render(strings.Repeat("─", len(header))))
+
+		start, end := m.list.Window()
+		for i := start; i < end; i++ {
+			lines = append(lines, m.renderRow(i))
+		}
+	}
+
+	if m.message != "" {
+		lines = append(lines, "", theme.HighlightStyle.Render(m.message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderRow renders one pattern line
+func (m Model) renderRow(i int) string {
+	row := m.rows[i]
+	p := row.pattern
+
+	enabled := "✓"
+	if !p.IsActive() {
+		enabled = " "
+	}
+	accuracy := "-"
+	if p.Statistics.AcceptCount+p.Statistics.RejectCount > 0 {
+		accuracy = fmt.Sprintf("%.0f%%", p.Statistics.Accuracy*100)
+	}
+
+	line := fmt.Sprintf("  %-3s %-24s %-36s %8d %9d %8s",
+		enabled, truncate(p.ID, 24), truncate(p.Category, 36), p.EffectivePriority(), row.matches, accuracy)
+
+	if i == m.list.Cursor() {
+		return theme.SelectedItemStyle.Render(line)
+	}
+	if !p.IsActive() {
+		return theme.MutedTextStyle.Render(line)
+	}
+	return theme.ListItemStyle.Render(line)
+}
+
+// truncate shortens s to at most n characters with a trailing ellipsis
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+// SetSize updates the patterns view size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	m.scroll()
+	return m
+}
+
+// SetDisplay updates the patterns view display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m.SetSize(m.width, m.height)
+}
+
+// ShortHelp returns the view's main key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	k := m.keys
+	return []key.Binding{k.New, k.Edit, k.Delete, k.Toggle}
+}
+
+// FullHelp returns all of the view's key bindings in columns, for the help row
+func (m Model) FullHelp() [][]key.Binding {
+	k := m.keys
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Top, k.Bottom},
+		{k.New, k.Edit, k.Delete, k.Toggle},
+	}
+}
+
+// KeyBindings returns the view's key bindings, for the help overlay
+func (m Model) KeyBindings() []key.Binding {
+	return slices.Concat(m.FullHelp()...)
+}
@@ -0,0 +1,337 @@
+// Package problems implements the view listing what is wrong with the ledger: lines
+// that can't be read, entries that break ledger rules and unbalanced transactions
+package problems
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// sourceContext is how many lines are shown on each side of a problem's line
+const sourceContext = 5
+
+// keyMap defines key bindings for the problems view
+type keyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	Open     key.Binding
+	Back     key.Binding
+}
+
+// newKeyMap creates the key bindings, taking navigation keys from the config
+func newKeyMap(cfg config.KeybindingsConfig) keyMap {
+	return keyMap{
+		Up:       components.Binding(cfg.Up, "up"),
+		Down:     components.Binding(cfg.Down, "down"),
+		PageUp:   components.Binding(cfg.PageUp, "page up"),
+		PageDown: components.Binding(cfg.PageDown, "page down"),
+		Top:      components.Binding(cfg.Top, "top"),
+		Bottom:   components.Binding(cfg.Bottom, "bottom"),
+		Open:     components.Binding(cfg.Select, "go to"),
+		Back:     components.Binding(cfg.Back, "close source"),
+	}
+}
+
+// FixedKeys returns the keys the view handles whatever the config binds, by what they do
+func FixedKeys() map[string]string {
+	return components.FixedKeys(newKeyMap(config.KeybindingsConfig{}))
+}
+
+// JumpMsg asks the root model to show a transaction in the transactions view
+type JumpMsg struct {
+	Transaction int // File index of the transaction
+}
+
+// Model represents the problems view: the ledger's problems in file order, with the
+// selected one's line below the list
+type Model struct {
+	file    *beancount.File
+	width   int
+	height  int
+	display components.Display
+	keys    keyMap
+
+	problems []beancount.Problem
+	err      string
+
+	// source is the selected problem's line with the lines around it, shown in place
+	// of the list for problems outside any transaction; nil when closed
+	source []string
+	// sourceStart is the 1-based line number of source[0]
+	sourceStart int
+
+	list components.Scroller
+}
+
+// New creates a new problems model, checking the ledger
+func New(file *beancount.File, keys config.KeybindingsConfig) Model {
+	m := Model{file: file, keys: newKeyMap(keys)}
+	return m.Reload()
+}
+
+// SetKeys rebinds the view's keys, e.g. after the config is reloaded
+func (m Model) SetKeys(keys config.KeybindingsConfig) Model {
+	m.keys = newKeyMap(keys)
+	return m
+}
+
+// Reload checks the ledger again, keeping the cursor on the same line if it is
+// still a problem
+func (m Model) Reload() Model {
+	var selected beancount.Problem
+	if m.list.Cursor() < len(m.problems) {
+		selected = m.problems[m.list.Cursor()]
+	}
+
+	m.err = ""
+	m.source = nil
+	problems, err := m.file.Check()
+	if err != nil {
+		m.err = err.Error()
+	}
+	m.problems = problems
+	m = m.scroll()
+	for i, problem := range m.problems {
+		if problem.FilePath == selected.FilePath && problem.LineNumber == selected.LineNumber {
+			m.list = m.list.Select(i)
+			break
+		}
+	}
+	return m
+}
+
+// Count returns how many problems the ledger has
+func (m Model) Count() int {
+	return len(m.problems)
+}
+
+// Editing reports whether the source of a problem is shown; it takes Esc and q
+func (m Model) Editing() bool {
+	return m.source != nil
+}
+
+// listHeight returns how many rows fit between the header and the detail lines
+func (m Model) listHeight() int {
+	return max(1, m.height-8+m.display.Spared(2))
+}
+
+// scroll keeps the cursor on a row and inside the visible window
+func (m Model) scroll() Model {
+	m.list = m.list.Fit(len(m.problems), m.listHeight())
+	return m
+}
+
+// Init initializes the problems view
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+// Enter on a transaction's problem jumps to the transaction; on any other it shows
+// the line among its neighbours, until Esc
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.source != nil {
+		if key.Matches(keyMsg, m.keys.Back, m.keys.Open) || keyMsg.String() == "q" {
+			m.source = nil
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		m.list = m.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		m.list = m.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		m.list = m.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		m.list = m.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		m.list = m.list.Top()
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		m.list = m.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.Open):
+		if m.list.Cursor() >= len(m.problems) {
+			break
+		}
+		problem := m.problems[m.list.Cursor()]
+		if problem.Transaction >= 0 {
+			return m, func() tea.Msg { return JumpMsg{Transaction: problem.Transaction} }
+		}
+		m = m.openSource(problem)
+	}
+	return m, nil
+}
+
+// openSource reads the lines around a problem for display
+// When the file can't be read the problem's own line is shown alone.
+func (m Model) openSource(problem beancount.Problem) Model {
+	m.source = []string{problem.Line}
+	m.sourceStart = problem.LineNumber
+
+	data, err := m.file.Store().ReadFile(problem.FilePath)
+	if err != nil {
+		return m
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if problem.LineNumber < 1 || problem.LineNumber > len(lines) {
+		return m
+	}
+	from := max(0, problem.LineNumber-1-sourceContext)
+	to := min(len(lines), problem.LineNumber+sourceContext)
+	m.source = lines[from:to]
+	m.sourceStart = from + 1
+	return m
+}
+
+// View renders the problems, or the source of the selected one
+func (m Model) View() string {
+	if m.width == 0 {
+		return theme.NormalTextStyle.Render("Checking ledger...")
+	}
+	if m.source != nil {
+		return m.renderSource()
+	}
+
+	var lines []string
+
+	// Title - fill full width
+	titleText := fmt.Sprintf("Problems (%d)", len(m.problems))
+	titlePadded := components.PadRight(titleText, m.width)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(titlePadded))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to check the ledger: "+m.err))
+	}
+	if len(m.problems) == 0 && m.err == "" {
+		lines = append(lines, "")
+		lines = append(lines, theme.SuccessStyle.Render(" No problems found: every line reads and every transaction balances"))
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(m.formatRow("Kind", "Location", "Problem")))
+	lines = append(lines, m.display.Rule(m.width)...)
+
+	start, end := m.list.Window()
+	for i := start; i < end; i++ {
+		problem := m.problems[i]
+		line := m.formatRow(string(problem.Kind), location(problem), problem.Message)
+		switch {
+		case i == m.list.Cursor():
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line))
+		case problem.Kind == beancount.ProblemSyntax:
+			lines = append(lines, theme.ErrorStyle.Width(m.width).Render(line))
+		default:
+			lines = append(lines, theme.ListItemStyle.Width(m.width).Render(line))
+		}
+	}
+
+	// The selected problem's line as written
+	lines = append(lines, m.display.Rule(m.width)...)
+	if m.list.Cursor() < len(m.problems) {
+		problem := m.problems[m.list.Cursor()]
+		lines = append(lines, theme.HighlightStyle.Render(" "+components.Fit(location(problem)+": "+problem.Message, m.width-2, "…")))
+		lines = append(lines, theme.NormalTextStyle.Render(" "+components.Fit(strings.TrimRight(problem.Line, " \t"), m.width-2, "…")))
+		if problem.Transaction >= 0 {
+			lines = append(lines, theme.MutedTextStyle.Render(" enter shows the transaction"))
+		} else {
+			lines = append(lines, theme.MutedTextStyle.Render(" enter shows the lines around it"))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// renderSource renders the selected problem's line among its neighbours
+func (m Model) renderSource() string {
+	problem := m.problems[m.list.Cursor()]
+
+	var lines []string
+	titlePadded := components.PadRight(location(problem)+": "+problem.Message, m.width)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(components.Fit(titlePadded, m.width, "…")))
+	lines = append(lines, "")
+	for i, text := range m.source {
+		number := m.sourceStart + i
+		line := components.Fit(fmt.Sprintf(" %5d  %s", number, text), m.width, "…")
+		if number == problem.LineNumber {
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line))
+		} else {
+			lines = append(lines, theme.NormalTextStyle.Render(line))
+		}
+	}
+	lines = append(lines, "")
+	lines = append(lines, theme.MutedTextStyle.Render(" esc:back to problems"))
+	return strings.Join(lines, "\n")
+}
+
+// location names a problem's file and line
+func location(problem beancount.Problem) string {
+	return fmt.Sprintf("%s:%d", filepath.Base(problem.FilePath), problem.LineNumber)
+}
+
+// formatRow lays out the kind and location columns and the message in the rest
+func (m Model) formatRow(kind, where, message string) string {
+	columns := fmt.Sprintf(" %-11s %-24s ", kind, components.Fit(where, 24, "…"))
+	room := max(0, m.width-components.Width(columns)-1)
+	return columns + components.Fit(message, room, "…")
+}
+
+// SetSize updates the problems view size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m.scroll()
+}
+
+// SetDisplay updates the problems view display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m.SetSize(m.width, m.height)
+}
+
+// ShortHelp returns the view's main key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	if m.source != nil {
+		return []key.Binding{m.keys.Back}
+	}
+	return []key.Binding{m.keys.Open}
+}
+
+// FullHelp returns all of the view's key bindings in columns, for the help row
+func (m Model) FullHelp() [][]key.Binding {
+	k := m.keys
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Top, k.Bottom},
+		{k.Open, k.Back},
+	}
+}
+
+// KeyBindings returns the view's key bindings, for the help overlay
+func (m Model) KeyBindings() []key.Binding {
+	return slices.Concat(m.FullHelp()...)
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"maps"
+	"os"
+	"reflect"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/backup"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/format"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// configCheckInterval is how often the config files are checked for changes
+const configCheckInterval = time.Second
+
+// configSource is where the configuration was read from, to reload it when it changes
+type configSource struct {
+	// path is the global config file, or "" when the config is not watched
+	path    string
+	profile string
+
+	// stamps are the modification times of the config files last read, by path;
+	// missing files have the zero time
+	stamps map[string]time.Time
+}
+
+// configCheckMsg is sent when the config files are due to be checked for changes
+type configCheckMsg struct{}
+
+// WatchConfig reloads the configuration while the TUI runs whenever the global config
+// file at path or the ledger's project file changes, applying profile as at startup
+func (m Model) WatchConfig(path, profile string) Model {
+	m.source = configSource{path: path, profile: profile}
+	m.source.stamps = m.configStamps()
+	return m
+}
+
+// checkConfigCmd schedules the next check for config changes
+func checkConfigCmd() tea.Cmd {
+	return tea.Tick(configCheckInterval, func(time.Time) tea.Msg { return configCheckMsg{} })
+}
+
+// configStamps returns the modification times of the config files in effect
+func (m Model) configStamps() map[string]time.Time {
+	stamps := make(map[string]time.Time)
+	for _, path := range []string{m.source.path, config.FindProject(m.file.Path())} {
+		if path == "" {
+			continue
+		}
+		var stamp time.Time
+		if info, err := os.Stat(path); err == nil {
+			stamp = info.ModTime()
+		}
+		stamps[path] = stamp
+	}
+	return stamps
+}
+
+// checkConfig reloads the configuration if a config file changed since it was read
+func (m Model) checkConfig() (Model, tea.Cmd) {
+	if m.source.path == "" {
+		return m, nil
+	}
+	if stamps := m.configStamps(); !maps.Equal(stamps, m.source.stamps) {
+		m.source.stamps = stamps
+		m = m.reloadConfig()
+	}
+	return m, checkConfigCmd()
+}
+
+// reloadConfig reads the configuration again and applies it; an invalid one is
+// reported and the current one kept
+func (m Model) reloadConfig() Model {
+	global, err := config.Load(m.source.path)
+	if err == nil {
+		global, err = global.Profile(m.source.profile)
+	}
+	if err == nil {
+		global, err = global.Override(m.global.Overrides())
+	}
+	var cfg *config.Config
+	if err == nil {
+		cfg, _, err = global.ForLedger(m.file.Path())
+	}
+	if err != nil {
+		return m.notifyf(components.LevelError, "Config not reloaded: %v", err)
+	}
+	return m.applyConfig(global, cfg).notify(components.LevelSuccess, "Config reloaded")
+}
+
+// applyConfig switches to a reloaded configuration
+// Only the settings that changed are applied, so the theme, layout and page size
+// chosen at runtime survive edits to unrelated settings.
+func (m Model) applyConfig(global, cfg *config.Config) Model {
+	old := m.config
+	m.global, m.config = global, cfg
+
+	display := m.display
+	if !reflect.DeepEqual(old.Amounts, cfg.Amounts) || old.UI.DateFormat != cfg.UI.DateFormat {
+		display.Formatter = format.New(cfg.Amounts, cfg.UI.DateFormat)
+	}
+	if old.UI.CompactMode != cfg.UI.CompactMode {
+		display.Compact = cfg.UI.CompactMode
+	}
+	if old.UI.PageSize != cfg.UI.PageSize {
+		display.PageSize = max(0, cfg.UI.PageSize)
+	}
+	m = m.setDisplay(display)
+	if old.Theme != cfg.Theme {
+		theme.Configure(cfg.Theme)
+	}
+	if old.UI.ShowHelpRow != cfg.UI.ShowHelpRow {
+		m.showHelpRow = cfg.UI.ShowHelpRow
+	}
+	if old.UI.ReadOnly != cfg.UI.ReadOnly {
+		m.writer.SetReadOnly(cfg.UI.ReadOnly)
+	}
+	if old.Backup != cfg.Backup {
+		m.writer.SetBackup(backup.FromConfig(cfg.Backup).Hook())
+	}
+
+	if !reflect.DeepEqual(old.Keybindings, cfg.Keybindings) {
+		m.keys = keyMapFromConfig(cfg)
+		m.transactions = m.transactions.SetKeys(cfg.Keybindings)
+		m.accounts = m.accounts.SetKeys(cfg.Keybindings)
+		m.reports = m.reports.SetKeys(cfg.Keybindings)
+		m.patterns = m.patterns.SetKeys(cfg.Keybindings)
+		m.budgets = m.budgets.SetKeys(cfg.Keybindings)
+		m.tags = m.tags.SetKeys(cfg.Keybindings)
+		m.problems = m.problems.SetKeys(cfg.Keybindings)
+		m.imports = m.imports.SetKeys(cfg.Keybindings)
+	}
+
+	if !reflect.DeepEqual(old.Goals, cfg.Goals) {
+		m.dashboard = m.dashboard.SetGoals(cfg.Goals)
+	}
+	if old.Anomalies != cfg.Anomalies {
+		m.dashboard = m.dashboard.SetAnomalies(cfg.Anomalies)
+		m.reports = m.reports.SetAnomalies(cfg.Anomalies)
+	}
+
+	if m.categorizer != nil {
+		m.categorizer.SetThresholds(cfg.Categorization.ConfidenceThreshold, cfg.Categorization.AutoThreshold)
+	}
+	return m.resize()
+}
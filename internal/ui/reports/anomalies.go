@@ -0,0 +1,182 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/anomaly"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// unusual is the state of the anomalies report
+type unusual struct {
+	thresholds config.AnomaliesConfig
+	// alerts are the unusual transactions, the most recent first
+	alerts []anomaly.Alert
+	list   components.Scroller
+}
+
+// SetAnomalies changes the thresholds unusual transactions are flagged with and flags
+// them again
+func (m Model) SetAnomalies(thresholds config.AnomaliesConfig) Model {
+	m.anomalies.thresholds = thresholds
+	return m.refreshAnomalies()
+}
+
+// anomalyListHeight returns how many alerts are listed
+func (m Model) anomalyListHeight() int {
+	return max(1, m.height-6)
+}
+
+// refreshAnomalies flags the unusual transactions again, keeping the selected one
+// where possible
+func (m Model) refreshAnomalies() Model {
+	a := &m.anomalies
+	if a.thresholds == (config.AnomaliesConfig{}) {
+		a.thresholds = config.DefaultConfig().Anomalies
+	}
+	var selected anomaly.Alert
+	if len(a.alerts) > 0 {
+		selected = a.alerts[a.list.Cursor()]
+	}
+
+	a.alerts = cached(m.cache, cacheKey{report: "anomalies", currency: m.currency, params: a.thresholds}, func() []anomaly.Alert {
+		return anomaly.Detect(m.transactions, a.thresholds)
+	})
+	a.list = a.list.Fit(len(a.alerts), m.anomalyListHeight())
+	for i, alert := range a.alerts {
+		if alert.Kind == selected.Kind && selected.Transaction != nil && alert.Transaction.LineNumber == selected.Transaction.LineNumber &&
+			alert.Transaction.FilePath == selected.Transaction.FilePath {
+			a.list = a.list.Select(i)
+		}
+	}
+	return m
+}
+
+// scrollAnomalies keeps the cursor on an alert and inside the visible window
+func (m Model) scrollAnomalies() Model {
+	m.anomalies.list = m.anomalies.list.Fit(len(m.anomalies.alerts), m.anomalyListHeight())
+	return m
+}
+
+// updateAnomalies handles keys for the anomalies report
+func (m Model) updateAnomalies(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	a := &m.anomalies
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		a.list = a.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		a.list = a.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		a.list = a.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		a.list = a.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		a.list = a.list.Top()
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		a.list = a.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		// The payee's charges to compare with, or just the repeated pair
+		if len(a.alerts) > 0 {
+			alert := a.alerts[a.list.Cursor()]
+			msg := DrillDownMsg{Payee: alert.Transaction.Payee, Period: beancount.AllTime()}
+			if alert.Kind == anomaly.Duplicate {
+				msg.Filter = "date:" + alert.Original.Date.Format("2006-01-02") + ".." + alert.Transaction.Date.Format("2006-01-02")
+			}
+			return m, func() tea.Msg { return msg }
+		}
+	}
+	return m.scrollAnomalies(), nil
+}
+
+// anomalyDetail says what makes a transaction unusual
+func (m Model) anomalyDetail(alert anomaly.Alert) string {
+	switch alert.Kind {
+	case anomaly.Unusual:
+		return fmt.Sprintf("%s× the usual %s", alert.Ratio().StringFixed(1), m.display.Amount(alert.Mean, alert.Amount.Commodity))
+	case anomaly.Duplicate:
+		return "same charge on " + m.display.Date(alert.Original.Date)
+	}
+	return "first charge from this payee"
+}
+
+// viewAnomalies lists the unusual transactions, the most recent first
+func (m Model) viewAnomalies() string {
+	a := m.anomalies
+
+	var lines []string
+	titleText := fmt.Sprintf("Anomalies - %d unusual transactions", len(a.alerts))
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(components.PadRight(titleText, m.width)))
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+
+	t := a.thresholds
+	thresholds := fmt.Sprintf(" Flagged: over %g× a payee's mean after %d charges", t.MeanFactor, t.MinHistory)
+	if t.NewMerchantAmount > 0 {
+		thresholds += fmt.Sprintf(", new payees from %s", m.display.Number(decimal.NewFromFloat(t.NewMerchantAmount), m.spending.commodity))
+	}
+	if t.DuplicateDays > 0 {
+		thresholds += fmt.Sprintf(", repeats within %d days", t.DuplicateDays)
+	}
+	if len(a.alerts) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("Nothing unusual"))
+		lines = append(lines, theme.MutedTextStyle.Render(components.Fit(thresholds, m.width, "…")))
+		return strings.Join(lines, "\n")
+	}
+
+	dateWidth := m.display.DateWidth()
+	payeeWidth := max(10, min(30, m.width-dateWidth-80))
+	header := fmt.Sprintf(" %-*s  %-*s  %16s  %-18s  %s", dateWidth, "Date", payeeWidth, "Payee", "Amount", "Kind", "Why")
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(header))
+	start, end := a.list.Window()
+	for i := start; i < end; i++ {
+		alert := a.alerts[i]
+		line := fmt.Sprintf(" %-*s  %s  %16s  %-18s  %s", dateWidth, m.display.Date(alert.Transaction.Date),
+			components.PadRight(components.Fit(alert.Transaction.Payee, payeeWidth, "…"), payeeWidth),
+			m.display.Amount(alert.Amount.Number, alert.Amount.Commodity), alert.Kind, m.anomalyDetail(alert))
+		line = components.Fit(line, m.width, "…")
+		if i == a.list.Cursor() {
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line))
+		} else {
+			lines = append(lines, theme.ListItemStyle.Render(line))
+		}
+	}
+	lines = append(lines, "")
+	lines = append(lines, theme.MutedTextStyle.Render(components.Fit(thresholds, m.width, "…")))
+	return strings.Join(lines, "\n")
+}
+
+// exportAnomalies returns each unusual transaction with what makes it unusual
+func (m Model) exportAnomalies() export.Table {
+	table := export.Table{
+		Name:    "anomalies",
+		Columns: []string{"date", "payee", "narration", "amount", "commodity", "kind", "usual", "repeats"},
+	}
+	for _, alert := range m.anomalies.alerts {
+		tx := alert.Transaction
+		var usual, repeats string
+		if alert.Kind == anomaly.Unusual {
+			usual = alert.Mean.Round(2).String()
+		}
+		if alert.Kind == anomaly.Duplicate {
+			repeats = alert.Original.Date.Format("2006-01-02")
+		}
+		table.Rows = append(table.Rows, []string{tx.Date.Format("2006-01-02"), tx.Payee, tx.Narration,
+			alert.Amount.Number.String(), alert.Amount.Commodity, string(alert.Kind), usual, repeats})
+	}
+	return table
+}
@@ -0,0 +1,57 @@
+package reports
+
+import "maps"
+
+// maxCacheEntries bounds the cache; past it, the entries for other contents of the
+// ledger are dropped, then if need be all of them, and computed again as they are
+// asked for
+const maxCacheEntries = 256
+
+// cacheKey names one computation: the content of the ledger it was made from, told by
+// its hash, the report it is for, the currency the transactions were converted into and
+// whatever else the report takes, e.g. its period or thresholds
+// params must be comparable.
+type cacheKey struct {
+	ledger   string
+	report   string
+	currency string
+	params   any
+}
+
+// cache holds what the reports computed from the ledger so that showing the view again,
+// or going back to a period or currency seen before, doesn't compute it again
+// Entries are kept by the content of the ledger they were made from, so an edit misses
+// every report but undoing it finds them again, while a change of currency, period or
+// thresholds only misses the reports that take it. Copies of the model share the cache.
+type cache struct {
+	ledger  string
+	entries map[cacheKey]any
+}
+
+// newCache creates an empty cache
+func newCache() *cache {
+	return &cache{entries: make(map[cacheKey]any)}
+}
+
+// use makes the cache look up and store entries for the ledger with the hash
+func (c *cache) use(ledger string) {
+	c.ledger = ledger
+}
+
+// cached returns the value stored under key for the ledger in use, computing and
+// storing it when missing
+func cached[T any](c *cache, key cacheKey, compute func() T) T {
+	key.ledger = c.ledger
+	if value, ok := c.entries[key]; ok {
+		return value.(T)
+	}
+	if len(c.entries) >= maxCacheEntries {
+		maps.DeleteFunc(c.entries, func(k cacheKey, _ any) bool { return k.ledger != c.ledger })
+	}
+	if len(c.entries) >= maxCacheEntries {
+		clear(c.entries)
+	}
+	value := compute()
+	c.entries[key] = value
+	return value
+}
@@ -0,0 +1,35 @@
+package reports
+
+import "testing"
+
+func TestCacheByContent(t *testing.T) {
+	c := newCache()
+	computed := 0
+	report := func(c *cache, ledger string) string {
+		c.use(ledger)
+		return cached(c, cacheKey{report: "income"}, func() string {
+			computed++
+			return "income of " + ledger
+		})
+	}
+
+	// An edit misses, undoing it finds the report computed before
+	for _, ledger := range []string{"a", "a", "b", "a", "b"} {
+		if got := report(c, ledger); got != "income of "+ledger {
+			t.Errorf("expected the report of %s, got %q", ledger, got)
+		}
+	}
+	if computed != 2 {
+		t.Errorf("expected each content computed once, got %d computations", computed)
+	}
+
+	// Full, the cache drops the entries for other contents first
+	for i := range maxCacheEntries - 2 {
+		cached(c, cacheKey{report: "period", params: i}, func() int { return i })
+	}
+	c.use("c")
+	report(c, "c")
+	if len(c.entries) != 1 {
+		t.Errorf("expected only the new content's entry left, got %d", len(c.entries))
+	}
+}
@@ -0,0 +1,263 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/shopspring/decimal"
+)
+
+// shades are the heatmap cells from no spending to the most in the period
+var shades = []string{"·", "░", "▒", "▓", "█"}
+
+// weekdays label the calendar columns, Monday first
+var weekdays = []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"}
+
+// calendar is the state of the daily spending heatmap
+type calendar struct {
+	// unit is PeriodMonth for a month grid or PeriodYear for a year of weeks
+	unit beancount.PeriodUnit
+	// day is the selected day; the shown period contains it
+	day time.Time
+
+	// daily holds expenses in the spending report's commodity per day, and how many
+	// transactions made them
+	daily map[time.Time]decimal.Decimal
+	count map[time.Time]int
+}
+
+// period returns the shown month or year
+func (c calendar) period() beancount.Period {
+	return beancount.PeriodContaining(c.unit, c.day)
+}
+
+// refreshCalendar totals expenses per day in the commodity the spending report charts
+func (m Model) refreshCalendar() Model {
+	c := &m.calendar
+	if c.unit == beancount.PeriodAll {
+		c.unit = beancount.PeriodMonth
+	}
+	if c.day.IsZero() {
+		latest := m.latestIn(beancount.AllTime())
+		c.day = time.Date(latest.Year(), latest.Month(), latest.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	computed := cached(m.cache, cacheKey{report: "calendar", currency: m.currency, params: m.spending.commodity}, func() calendar {
+		computed := calendar{daily: make(map[time.Time]decimal.Decimal), count: make(map[time.Time]int)}
+		for _, tx := range m.transactions {
+			spent := decimal.Zero
+			for _, posting := range tx.ResolvedPostings() {
+				if _, ok := spendingCategory(posting.Account); ok && posting.Amount != nil && posting.Amount.Commodity == m.spending.commodity {
+					spent = spent.Add(posting.Amount.Number)
+				}
+			}
+			if !spent.IsZero() {
+				computed.daily[tx.Date] = computed.daily[tx.Date].Add(spent)
+				computed.count[tx.Date]++
+			}
+		}
+		return computed
+	})
+	c.daily, c.count = computed.daily, computed.count
+	return m
+}
+
+// updateCalendar handles keys for the heatmap
+// Arrows move a day along the grid's rows and a week across them; in the year
+// layout weeks are columns, so up and down move a day.
+func (m Model) updateCalendar(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := &m.calendar
+	across, along := 1, 7
+	if c.unit == beancount.PeriodYear {
+		across, along = 7, 1
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Collapse):
+		c.day = c.day.AddDate(0, 0, -across)
+
+	case key.Matches(keyMsg, m.keys.Expand):
+		c.day = c.day.AddDate(0, 0, across)
+
+	case key.Matches(keyMsg, m.keys.Up):
+		c.day = c.day.AddDate(0, 0, -along)
+
+	case key.Matches(keyMsg, m.keys.Down):
+		c.day = c.day.AddDate(0, 0, along)
+
+	case key.Matches(keyMsg, m.keys.PrevPer):
+		c.day = shiftDay(c.day, c.unit, -1)
+
+	case key.Matches(keyMsg, m.keys.NextPer):
+		c.day = shiftDay(c.day, c.unit, 1)
+
+	case key.Matches(keyMsg, m.keys.Month):
+		c.unit = beancount.PeriodMonth
+
+	case key.Matches(keyMsg, m.keys.Year):
+		c.unit = beancount.PeriodYear
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		msg := DrillDownMsg{Date: c.day, Period: beancount.PeriodContaining(beancount.PeriodMonth, c.day)}
+		return m, func() tea.Msg { return msg }
+	}
+	return m, nil
+}
+
+// shiftDay moves a day by months or years, keeping it within the target month
+func shiftDay(day time.Time, unit beancount.PeriodUnit, n int) time.Time {
+	target := beancount.PeriodContaining(beancount.PeriodMonth, day).Start
+	if unit == beancount.PeriodYear {
+		target = target.AddDate(n, 0, 0)
+	} else {
+		target = target.AddDate(0, n, 0)
+	}
+	last := target.AddDate(0, 1, -1).Day()
+	return target.AddDate(0, 0, min(day.Day(), last)-1)
+}
+
+// shade returns the heatmap cell for a day's spending, scaled against the period's peak
+func shade(spent, peak decimal.Decimal) string {
+	if !spent.IsPositive() || !peak.IsPositive() {
+		return shades[0]
+	}
+	level := spent.Div(peak).Mul(decimal.NewFromInt(int64(len(shades) - 1))).Ceil().IntPart()
+	return shades[max(1, min(int(level), len(shades)-1))]
+}
+
+// weekday returns a date's column, Monday first
+func weekday(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
+
+// viewCalendar renders the period's days shaded by spending, with the selected day's total
+func (m Model) viewCalendar() string {
+	c := m.calendar
+	period := c.period()
+
+	var lines []string
+	titleText := fmt.Sprintf("Daily Spending - %s ([ ])", period)
+	titlePadded := components.PadRight(titleText, m.width)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(titlePadded))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+
+	total, peak := decimal.Zero, decimal.Zero
+	for day := period.Start; day.Before(period.End()); day = day.AddDate(0, 0, 1) {
+		total = total.Add(c.daily[day])
+		peak = decimal.Max(peak, c.daily[day])
+	}
+
+	lines = append(lines, "")
+	if c.unit == beancount.PeriodYear {
+		lines = append(lines, m.yearGrid(period, peak)...)
+	} else {
+		lines = append(lines, m.monthGrid(period, peak)...)
+	}
+
+	// Selected day, then the legend
+	lines = append(lines, "")
+	day := fmt.Sprintf(" %s  %s in %d transactions", c.day.Format("Mon ")+m.display.Date(c.day),
+		m.display.Amount(c.daily[c.day], m.spending.commodity), c.count[c.day])
+	lines = append(lines, theme.HighlightStyle.Render(day))
+	lines = append(lines, theme.NormalTextStyle.Render(fmt.Sprintf(" %s total %s", period, m.display.Amount(total, m.spending.commodity))))
+	legend := " none " + strings.Join(shades[1:], "") + " up to " + m.display.Amount(peak, m.spending.commodity)
+	lines = append(lines, theme.MutedTextStyle.Render(legend))
+	lines = append(lines, theme.MutedTextStyle.Render(" arrows:move  [ ]:previous/next  m/y:month/year  enter:transactions"))
+
+	return strings.Join(lines, "\n")
+}
+
+// monthGrid lays out a month as weeks of seven days, each day with its date and shade
+func (m Model) monthGrid(period beancount.Period, peak decimal.Decimal) []string {
+	c := m.calendar
+	const cellWidth = 8
+
+	header := " "
+	for _, name := range weekdays {
+		header += components.PadRight(" "+name, cellWidth)
+	}
+	lines := []string{theme.HighlightStyle.Render(header)}
+
+	row := theme.NormalTextStyle.Render(" " + strings.Repeat(" ", cellWidth*weekday(period.Start)))
+	for day := period.Start; day.Before(period.End()); day = day.AddDate(0, 0, 1) {
+		spent := c.daily[day]
+		cell := fmt.Sprintf(" %2d %s ", day.Day(), strings.Repeat(shade(spent, peak), 3))
+		switch {
+		case day.Equal(c.day):
+			row += theme.SelectedItemStyle.Render(cell)
+		case spent.IsPositive():
+			row += theme.NormalTextStyle.Render(fmt.Sprintf(" %2d ", day.Day())) +
+				theme.BarStyle.Render(strings.Repeat(shade(spent, peak), 3)) + theme.NormalTextStyle.Render(" ")
+		default:
+			row += theme.MutedTextStyle.Render(cell)
+		}
+		if weekday(day) == 6 || day.AddDate(0, 0, 1).Equal(period.End()) {
+			lines = append(lines, row, "")
+			row = theme.NormalTextStyle.Render(" ")
+		}
+	}
+	return lines[:len(lines)-1]
+}
+
+// yearGrid lays out a year as columns of weeks, one cell per day with weekdays as rows
+func (m Model) yearGrid(period beancount.Period, peak decimal.Decimal) []string {
+	c := m.calendar
+	first := period.Start.AddDate(0, 0, -weekday(period.Start)) // Monday of the first week
+	weeks := int(period.End().Sub(first).Hours()/24+6) / 7
+
+	// Month names above the week they start in
+	labels := []rune(strings.Repeat(" ", weeks+6))
+	for month := period.Start; month.Before(period.End()); month = month.AddDate(0, 1, 0) {
+		column := int(month.Sub(first).Hours()/24) / 7
+		copy(labels[4+column:], []rune(month.Format("Jan")))
+	}
+	lines := []string{theme.HighlightStyle.Render(strings.TrimRight(string(labels), " "))}
+
+	for weekdayRow, name := range weekdays {
+		var row strings.Builder
+		row.WriteString(theme.HighlightStyle.Render(" " + name + " "))
+		for week := range weeks {
+			day := first.AddDate(0, 0, week*7+weekdayRow)
+			spent := c.daily[day]
+			cell := shade(spent, peak)
+			switch {
+			case !period.Contains(day):
+				row.WriteString(theme.NormalTextStyle.Render(" "))
+			case day.Equal(c.day):
+				row.WriteString(theme.SelectedItemStyle.Render(cell))
+			case spent.IsPositive():
+				row.WriteString(theme.BarStyle.Render(cell))
+			default:
+				row.WriteString(theme.MutedTextStyle.Render(cell))
+			}
+		}
+		lines = append(lines, row.String())
+	}
+	return lines
+}
+
+// exportCalendar returns the shown period's daily spending
+func (m Model) exportCalendar() export.Table {
+	c := m.calendar
+	period := c.period()
+	table := export.Table{
+		Name:    "daily spending",
+		Columns: []string{"date", "amount", "commodity", "transactions"},
+	}
+	for day := period.Start; day.Before(period.End()); day = day.AddDate(0, 0, 1) {
+		table.Rows = append(table.Rows, []string{
+			day.Format("2006-01-02"), c.daily[day].String(), m.spending.commodity, fmt.Sprint(c.count[day]),
+		})
+	}
+	return table
+}
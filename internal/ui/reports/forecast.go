@@ -0,0 +1,216 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/forecast"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/shopspring/decimal"
+)
+
+// maxForecastMonths is the longest projection [ and ] reach
+const maxForecastMonths = 24
+
+// cashFlow is the state of the cash-flow forecast report
+type cashFlow struct {
+	options     forecast.Options
+	projections []forecast.Projection
+	err         string
+	// cursor is the projection whose months are charted
+	cursor int
+}
+
+// refreshForecast projects the balances again, keeping the selected account
+func (m Model) refreshForecast() Model {
+	f := &m.forecast
+	if f.options.Months == 0 {
+		f.options = forecast.DefaultOptions
+	}
+	var selected string
+	if f.cursor < len(f.projections) {
+		selected = f.projections[f.cursor].Account
+	}
+
+	// Projections start from today, so a new day computes them again
+	type params struct {
+		today   string
+		options forecast.Options
+	}
+	computed := cached(m.cache, cacheKey{report: "forecast", currency: m.currency, params: params{time.Now().Format("2006-01-02"), f.options}}, func() cashFlow {
+		var computed cashFlow
+		projections, err := forecast.Project(m.transactions, time.Now(), f.options)
+		if err != nil {
+			computed.err = err.Error()
+		}
+		computed.projections = projections
+		return computed
+	})
+	f.projections, f.err = computed.projections, computed.err
+	f.cursor = 0
+	for i, p := range f.projections {
+		if p.Account == selected {
+			f.cursor = i
+			break
+		}
+	}
+	return m
+}
+
+// updateForecast handles keys for the forecast: accounts, the horizon and the model
+func (m Model) updateForecast(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := &m.forecast
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		f.cursor = max(0, f.cursor-1)
+
+	case key.Matches(keyMsg, m.keys.Down):
+		f.cursor = max(0, min(len(f.projections)-1, f.cursor+1))
+
+	case key.Matches(keyMsg, m.keys.PrevPer):
+		if f.options.Months > 1 {
+			f.options.Months--
+			m = m.refreshForecast()
+		}
+
+	case key.Matches(keyMsg, m.keys.NextPer):
+		if f.options.Months < maxForecastMonths {
+			f.options.Months++
+			m = m.refreshForecast()
+		}
+
+	case key.Matches(keyMsg, m.keys.Toggle):
+		if f.options.Model == forecast.Linear {
+			f.options.Model = forecast.Average
+		} else {
+			f.options.Model = forecast.Linear
+		}
+		m = m.refreshForecast()
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		if f.cursor < len(f.projections) {
+			msg := DrillDownMsg{Account: f.projections[f.cursor].Account, Period: beancount.AllTime()}
+			return m, func() tea.Msg { return msg }
+		}
+	}
+	return m, nil
+}
+
+// riskText describes whether a month's balance may go negative
+func riskText(month forecast.Month) string {
+	switch {
+	case month.Negative():
+		return "negative"
+	case month.AtRisk():
+		return "may go negative"
+	}
+	return ""
+}
+
+// viewForecast lists the projected accounts, then charts the selected one's months
+func (m Model) viewForecast() string {
+	f := m.forecast
+
+	var lines []string
+	titleText := fmt.Sprintf("Cash Flow Forecast - %d months ([ ]), %s of the %d before (space)",
+		f.options.Months, f.options.Model, f.options.History)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(components.PadRight(titleText, m.width)))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+	if f.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render(f.err))
+	}
+	if len(f.projections) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("No "+f.options.Account+" accounts to project"))
+		return strings.Join(lines, "\n")
+	}
+
+	accountWidth := 0
+	for _, p := range f.projections {
+		accountWidth = max(accountWidth, components.Width(p.Account))
+	}
+	accountWidth = min(accountWidth, max(12, m.width-60))
+	lines = append(lines, "")
+	for i, p := range f.projections {
+		last := p.Months[len(p.Months)-1]
+		line := fmt.Sprintf(" %s  %s → %s", components.PadRight(components.Fit(p.Account, accountWidth, "…"), accountWidth),
+			components.PadLeft(m.display.Amount(p.Balance, p.Commodity), 16), components.PadLeft(m.display.Amount(last.Balance, p.Commodity), 16))
+		var warning string
+		if risky := p.AtRisk(); len(risky) > 0 {
+			warning = "  ⚠ " + riskText(risky[0]) + " in " + beancount.PeriodContaining(beancount.PeriodMonth, risky[0].Start).String()
+		}
+		switch {
+		case i == f.cursor:
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line+warning))
+		default:
+			lines = append(lines, theme.ListItemStyle.Render(line)+theme.ErrorStyle.Render(warning))
+		}
+	}
+
+	// The selected account month by month, bars scaled to its largest balance
+	p := f.projections[f.cursor]
+	lines = append(lines, "")
+	header := fmt.Sprintf(" %-8s  %12s %12s %12s  %s", "Month", "Recurring", "Other", "Balance", p.Account)
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(header))
+	peak := decimal.Zero
+	for _, month := range p.Months {
+		peak = decimal.Max(peak, month.Balance.Abs())
+	}
+	for _, month := range p.Months {
+		line := fmt.Sprintf(" %-8s  %s %s %s  ", beancount.PeriodContaining(beancount.PeriodMonth, month.Start),
+			components.PadLeft(m.display.Number(month.Recurring, p.Commodity), 12),
+			components.PadLeft(m.display.Number(month.Trend, p.Commodity), 12),
+			components.PadLeft(m.display.Number(month.Balance, p.Commodity), 12))
+		barWidth := max(4, m.width-components.Width(line)-18)
+		fraction := 0.0
+		if peak.IsPositive() {
+			fraction, _ = month.Balance.Abs().Div(peak).Float64()
+		}
+		bar := components.RenderBar(fraction, barWidth)
+		risk := riskText(month)
+		barStyle := theme.BarStyle
+		switch {
+		case month.Negative():
+			barStyle = theme.ErrorStyle
+		case month.AtRisk():
+			barStyle = theme.WarningStyle
+		}
+		lines = append(lines, theme.NormalTextStyle.Render(line)+barStyle.Render(bar)+theme.ErrorStyle.Render(" "+risk))
+	}
+	lines = append(lines, "")
+	lines = append(lines, theme.MutedTextStyle.Render(components.Fit(
+		" Recurring: charges due on their dates   Other: the trend of the months before", m.width, "…")))
+	return strings.Join(lines, "\n")
+}
+
+// exportForecast returns every projected month of every account
+func (m Model) exportForecast() export.Table {
+	return m.forecastTable(m.forecast.projections)
+}
+
+// forecastTable lays out projections one month to a row
+func (m Model) forecastTable(projections []forecast.Projection) export.Table {
+	table := export.Table{
+		Name:    "forecast",
+		Columns: []string{"account", "commodity", "month", "recurring", "other", "balance", "low", "risk"},
+	}
+	for _, p := range projections {
+		for _, month := range p.Months {
+			table.Rows = append(table.Rows, []string{
+				p.Account, p.Commodity, month.Start.Format("2006-01"),
+				m.exportNumber(month.Recurring, p.Commodity), m.exportNumber(month.Trend, p.Commodity),
+				m.exportNumber(month.Balance, p.Commodity), m.exportNumber(month.Low, p.Commodity),
+				riskText(month),
+			})
+		}
+	}
+	return table
+}
@@ -0,0 +1,820 @@
+package reports
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// keyMap defines key bindings for the reports view
+type keyMap struct {
+	Up          key.Binding
+	Down        key.Binding
+	PageUp      key.Binding
+	PageDown    key.Binding
+	Top         key.Binding
+	Bottom      key.Binding
+	Toggle      key.Binding
+	Expand      key.Binding
+	Collapse    key.Binding
+	ExpandAll   key.Binding
+	CollapseAll key.Binding
+	Month       key.Binding
+	Quarter     key.Binding
+	Year        key.Binding
+	PrevPer     key.Binding
+	NextPer     key.Binding
+	DrillDown   key.Binding
+	NextReport  key.Binding
+	PrevReport  key.Binding
+}
+
+// newKeyMap creates the key bindings, taking navigation keys from the config
+func newKeyMap(cfg config.KeybindingsConfig) keyMap {
+	return keyMap{
+		Up:       components.Binding(cfg.Up, "up"),
+		Down:     components.Binding(cfg.Down, "down"),
+		PageUp:   components.Binding(cfg.PageUp, "page up"),
+		PageDown: components.Binding(cfg.PageDown, "page down"),
+		Top:      components.Binding(cfg.Top, "top"),
+		Bottom:   components.Binding(cfg.Bottom, "bottom"),
+		Toggle: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "expand/collapse"),
+		),
+		Expand: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "expand"),
+		),
+		Collapse: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "collapse"),
+		),
+		ExpandAll: key.NewBinding(
+			key.WithKeys("+"),
+			key.WithHelp("+", "expand all"),
+		),
+		CollapseAll: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "collapse all"),
+		),
+		Month: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "month"),
+		),
+		Quarter: key.NewBinding(
+			key.WithKeys("Q"),
+			key.WithHelp("Q", "quarter"),
+		),
+		Year: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "year"),
+		),
+		PrevPer: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "previous period"),
+		),
+		NextPer: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next period"),
+		),
+		DrillDown: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "transactions"),
+		),
+		NextReport: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "next report"),
+		),
+		PrevReport: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "previous report"),
+		),
+	}
+}
+
+// FixedKeys returns the keys the view handles whatever the config binds, by what they do
+func FixedKeys() map[string]string {
+	return components.FixedKeys(newKeyMap(config.KeybindingsConfig{}))
+}
+
+// reportKind identifies one of the reports shown in the view
+type reportKind int
+
+const (
+	incomeReport reportKind = iota
+	spendingReport
+	subscriptionsReport
+	calendarReport
+	forecastReport
+	savingsReport
+	tripsReport
+	anomaliesReport
+	reportCount
+)
+
+// reportNames are the tab labels, indexed by reportKind
+var reportNames = []string{"Income Statement", "Spending", "Subscriptions", "Calendar", "Forecast", "Savings", "Trips", "Anomalies"}
+
+// DrillDownMsg asks the root model to show an account's transactions for a period
+// Payee, when set, narrows them to one payee; Date, when set, to one day; Filter, when
+// set, by more terms of the filter language, e.g. tag:trip-japan
+type DrillDownMsg struct {
+	Account string
+	Payee   string
+	Date    time.Time
+	Filter  string
+	Period  beancount.Period
+}
+
+// Query returns the transaction filter for the drill-down
+func (d DrillDownMsg) Query() string {
+	var terms []string
+	if d.Account != "" {
+		terms = append(terms, "account:"+d.Account)
+	}
+	if !d.Date.IsZero() {
+		terms = append(terms, "date:"+d.Date.Format("2006-01-02"))
+	}
+	if d.Filter != "" {
+		terms = append(terms, d.Filter)
+	}
+	query := strings.Join(terms, " ")
+	if d.Payee != "" {
+		query += ` "` + strings.ReplaceAll(d.Payee, `"`, "") + `"`
+	}
+	return query
+}
+
+// Model represents the reports view: an income statement, a spending breakdown, a
+// subscription tracker, a calendar of daily spending, a cash-flow forecast, the
+// savings rate, spending by trip and unusual transactions
+type Model struct {
+	file    *beancount.File
+	width   int
+	height  int
+	display components.Display
+	keys    keyMap
+	report  reportKind
+
+	// currency is the commodity amounts are converted into with the ledger's prices;
+	// empty reports native commodities side by side
+	currency string
+
+	transactions []*beancount.Transaction
+	err          string
+
+	// cache holds the reports computed from the ledger, shared by copies of the model
+	cache *cache
+
+	// period is the reported period; it is compared with the one before it
+	period beancount.Period
+
+	// Income and Expenses trees with per-period totals by account and ancestors
+	roots    []*beancount.AccountNode
+	current  map[string]map[string]decimal.Decimal
+	previous map[string]map[string]decimal.Decimal
+	expanded map[string]bool
+
+	// List state
+	rows []*beancount.AccountNode
+	list components.Scroller
+
+	// Spending report state
+	spending spending
+
+	// Subscription tracker state
+	subscriptions subscriptions
+
+	// Daily spending calendar state
+	calendar calendar
+
+	// Cash-flow forecast state
+	forecast cashFlow
+
+	// Savings rate state
+	savings savingsRate
+
+	// Trips and events state
+	trips travel
+
+	// Unusual transactions state
+	anomalies unusual
+}
+
+// New creates a new reports model, reporting in currency ("" for native commodities)
+func New(file *beancount.File, keys config.KeybindingsConfig, currency string) Model {
+	m := Model{
+		file:     file,
+		keys:     newKeyMap(keys),
+		currency: currency,
+		expanded: map[string]bool{"Income": true, "Expenses": true},
+		cache:    newCache(),
+	}
+	m = m.load()
+
+	// Open on the month of the latest transaction so the report is never empty at first
+	month := beancount.PeriodContaining(beancount.PeriodMonth, m.latestIn(beancount.AllTime()))
+	m.spending.month = month
+	return m.SetPeriod(month).refreshSpending().refreshSubscriptions().refreshCalendar().refreshForecast().refreshSavings().refreshTrips().refreshAnomalies()
+}
+
+// latestIn returns the date of the latest transaction within a period,
+// or the period start (today for all time) when it has none
+func (m Model) latestIn(period beancount.Period) time.Time {
+	var latest time.Time
+	for _, tx := range m.transactions {
+		if period.Contains(tx.Date) && tx.Date.After(latest) {
+			latest = tx.Date
+		}
+	}
+	if latest.IsZero() {
+		if period.IsAll() {
+			return time.Now()
+		}
+		return period.Start
+	}
+	return latest
+}
+
+// SetKeys rebinds the view's keys, e.g. after the config is reloaded
+func (m Model) SetKeys(keys config.KeybindingsConfig) Model {
+	m.keys = newKeyMap(keys)
+	return m
+}
+
+// Reload re-reads the ledger, keeping the periods, expansion and selection
+// Reports already computed for the ledger's content are taken from the cache.
+func (m Model) Reload() Model {
+	return m.load().refresh().refreshSpending().refreshSubscriptions().refreshCalendar().refreshForecast().refreshSavings().refreshTrips().refreshAnomalies()
+}
+
+// load reads every transaction from the ledger, converted into the currency if one is set
+// Each posting is converted at the price of its transaction's date.
+func (m Model) load() Model {
+	type loaded struct {
+		transactions []*beancount.Transaction
+		err          string
+	}
+	m.cache.use(m.file.Hash())
+	l := cached(m.cache, cacheKey{report: "transactions", currency: m.currency}, func() loaded {
+		var l loaded
+		transactions, err := m.file.AllTransactions()
+		if err != nil {
+			l.err = err.Error()
+		}
+		if m.currency != "" {
+			transactions = beancount.NewPriceDB(m.file.GetPriceDirectives()).ConvertTransactions(transactions, m.currency)
+		}
+		l.transactions = transactions
+		return l
+	})
+	m.transactions, m.err = l.transactions, l.err
+	return m
+}
+
+// SetCurrency changes the commodity amounts are reported in, keeping periods and selection
+// "" reports native commodities side by side.
+func (m Model) SetCurrency(currency string) Model {
+	if currency == m.currency {
+		return m
+	}
+	m.currency = currency
+	return m.Reload()
+}
+
+// SetPeriod changes the reported period and recomputes the statement
+func (m Model) SetPeriod(period beancount.Period) Model {
+	m.period = period
+	return m.refresh()
+}
+
+// Period returns the reported period
+func (m Model) Period() beancount.Period {
+	return m.period
+}
+
+// selectPeriod switches to a period unit, or to all time if already active
+// The new period holds the latest activity of the current one, so going from a year to
+// a month lands on the year's last active month
+func (m Model) selectPeriod(unit beancount.PeriodUnit) Model {
+	if m.period.Unit == unit {
+		return m.SetPeriod(beancount.AllTime())
+	}
+	return m.SetPeriod(beancount.PeriodContaining(unit, m.latestIn(m.period)))
+}
+
+// refresh totals the period and the one before it, then rebuilds the tree
+func (m Model) refresh() Model {
+	var selected string
+	if node := m.selected(); node != nil {
+		selected = node.Account
+	}
+
+	type statement struct {
+		current, previous map[string]map[string]decimal.Decimal
+		roots             []*beancount.AccountNode
+	}
+	st := cached(m.cache, cacheKey{report: "income", currency: m.currency, params: m.period}, func() statement {
+		var current, previous []*beancount.Transaction
+		prev := m.period.Prev()
+		for _, tx := range m.transactions {
+			switch {
+			case m.period.Contains(tx.Date):
+				current = append(current, tx)
+			case !m.period.IsAll() && prev.Contains(tx.Date):
+				previous = append(previous, tx)
+			}
+		}
+		st := statement{
+			current:  beancount.RollUpBalances(incomeStatement(beancount.AccountBalances(current))),
+			previous: beancount.RollUpBalances(incomeStatement(beancount.AccountBalances(previous))),
+		}
+
+		// Accounts active in either period appear so a drop to zero is visible
+		var accounts []string
+		for _, totals := range []map[string]map[string]decimal.Decimal{st.current, st.previous} {
+			for account := range totals {
+				accounts = append(accounts, account)
+			}
+		}
+		st.roots = beancount.BuildAccountTree(accounts)
+		return st
+	})
+	m.current, m.previous, m.roots = st.current, st.previous, st.roots
+
+	m = m.refreshRows()
+	for i, node := range m.rows {
+		if node.Account == selected {
+			m.list = m.list.Select(i)
+		}
+	}
+	return m.scroll()
+}
+
+// incomeStatement keeps the Income and Expenses balances, with income made positive
+func incomeStatement(balances map[string]map[string]decimal.Decimal) map[string]map[string]decimal.Decimal {
+	kept := make(map[string]map[string]decimal.Decimal)
+	for account, balance := range balances {
+		switch {
+		case strings.HasPrefix(account, "Income:") || account == "Income":
+			negated := make(map[string]decimal.Decimal, len(balance))
+			for commodity, number := range balance {
+				negated[commodity] = number.Neg()
+			}
+			kept[account] = negated
+		case strings.HasPrefix(account, "Expenses:") || account == "Expenses":
+			kept[account] = balance
+		}
+	}
+	return kept
+}
+
+// refreshRows flattens the expanded part of the tree
+func (m Model) refreshRows() Model {
+	m.rows = nil
+	var walk func(node *beancount.AccountNode)
+	walk = func(node *beancount.AccountNode) {
+		m.rows = append(m.rows, node)
+		if m.expanded[node.Account] {
+			for _, child := range node.Children {
+				walk(child)
+			}
+		}
+	}
+	for _, root := range m.roots {
+		walk(root)
+	}
+	return m.scroll()
+}
+
+// selected returns the account under the cursor
+func (m Model) selected() *beancount.AccountNode {
+	if len(m.rows) == 0 {
+		return nil
+	}
+	return m.rows[m.list.Cursor()]
+}
+
+// listHeight returns how many account rows fit between the header and the net income line
+func (m Model) listHeight() int {
+	return max(1, m.height-7+m.display.Spared(2))
+}
+
+// scroll keeps the cursor on an account and inside the visible window
+func (m Model) scroll() Model {
+	m.list = m.list.Fit(len(m.rows), m.listHeight())
+	return m
+}
+
+// setExpanded opens or closes every account in the tree
+func (m Model) setExpanded(open bool) Model {
+	var walk func(node *beancount.AccountNode)
+	walk = func(node *beancount.AccountNode) {
+		if len(node.Children) > 0 {
+			m.expanded[node.Account] = open
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	for _, root := range m.roots {
+		walk(root)
+	}
+	return m
+}
+
+// Init initializes the reports view
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.NextReport):
+		m.report = (m.report + 1) % reportCount
+		return m, nil
+	case key.Matches(keyMsg, m.keys.PrevReport):
+		m.report = (m.report + reportCount - 1) % reportCount
+		return m, nil
+	}
+
+	switch m.report {
+	case spendingReport:
+		return m.updateSpending(keyMsg)
+	case subscriptionsReport:
+		return m.updateSubscriptions(keyMsg)
+	case calendarReport:
+		return m.updateCalendar(keyMsg)
+	case forecastReport:
+		return m.updateForecast(keyMsg)
+	case savingsReport:
+		return m.updateSavings(keyMsg)
+	case tripsReport:
+		return m.updateTrips(keyMsg)
+	case anomaliesReport:
+		return m.updateAnomalies(keyMsg)
+	}
+	return m.updateIncome(keyMsg)
+}
+
+// updateIncome handles keys for the income statement
+func (m Model) updateIncome(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	selected := m.selected()
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		m.list = m.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		m.list = m.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		m.list = m.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		m.list = m.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		m.list = m.list.Top()
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		m.list = m.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.Toggle):
+		if selected != nil && len(selected.Children) > 0 {
+			m.expanded[selected.Account] = !m.expanded[selected.Account]
+			m = m.refreshRows()
+		}
+
+	case key.Matches(keyMsg, m.keys.Expand):
+		if selected != nil && len(selected.Children) > 0 {
+			m.expanded[selected.Account] = true
+			m = m.refreshRows()
+		}
+
+	case key.Matches(keyMsg, m.keys.Collapse):
+		// Close an open account, otherwise jump to its parent
+		if selected == nil {
+			break
+		}
+		if m.expanded[selected.Account] && len(selected.Children) > 0 {
+			m.expanded[selected.Account] = false
+			m = m.refreshRows()
+		} else if i := strings.LastIndex(selected.Account, ":"); i >= 0 {
+			for pos, node := range m.rows {
+				if node.Account == selected.Account[:i] {
+					m.list = m.list.Select(pos)
+				}
+			}
+		}
+
+	case key.Matches(keyMsg, m.keys.ExpandAll):
+		m = m.setExpanded(true).refresh()
+
+	case key.Matches(keyMsg, m.keys.CollapseAll):
+		m = m.setExpanded(false).refresh()
+
+	case key.Matches(keyMsg, m.keys.Month):
+		m = m.selectPeriod(beancount.PeriodMonth)
+
+	case key.Matches(keyMsg, m.keys.Quarter):
+		m = m.selectPeriod(beancount.PeriodQuarter)
+
+	case key.Matches(keyMsg, m.keys.Year):
+		m = m.selectPeriod(beancount.PeriodYear)
+
+	case key.Matches(keyMsg, m.keys.PrevPer):
+		if !m.period.IsAll() {
+			m = m.SetPeriod(m.period.Prev())
+		}
+
+	case key.Matches(keyMsg, m.keys.NextPer):
+		if !m.period.IsAll() {
+			m = m.SetPeriod(m.period.Next())
+		}
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		if selected != nil {
+			msg := DrillDownMsg{Account: selected.Account, Period: m.period}
+			return m, func() tea.Msg { return msg }
+		}
+	}
+
+	return m.scroll(), nil
+}
+
+// View renders the selected report with TP7 styling
+func (m Model) View() string {
+	if m.width == 0 {
+		return theme.NormalTextStyle.Render("Loading reports...")
+	}
+
+	// Report tabs
+	tabs := []string{" "}
+	for kind, name := range reportNames {
+		if reportKind(kind) == m.report {
+			tabs = append(tabs, theme.SelectedItemStyle.Render(" "+name+" "))
+		} else {
+			tabs = append(tabs, theme.NormalTextStyle.Render(" "+name+" "))
+		}
+	}
+	if m.currency != "" {
+		tabs = append(tabs, theme.MutedTextStyle.Render("  in "+m.currency))
+	}
+	tabs = append(tabs, theme.MutedTextStyle.Render("  (tab: switch report)"))
+	tabLine := theme.NormalTextStyle.Width(m.width).Render(strings.Join(tabs, ""))
+
+	switch m.report {
+	case spendingReport:
+		return tabLine + "\n" + m.viewSpending()
+	case subscriptionsReport:
+		return tabLine + "\n" + m.viewSubscriptions()
+	case calendarReport:
+		return tabLine + "\n" + m.viewCalendar()
+	case forecastReport:
+		return tabLine + "\n" + m.viewForecast()
+	case savingsReport:
+		return tabLine + "\n" + m.viewSavings()
+	case tripsReport:
+		return tabLine + "\n" + m.viewTrips()
+	case anomaliesReport:
+		return tabLine + "\n" + m.viewAnomalies()
+	}
+	return tabLine + "\n" + m.viewIncome()
+}
+
+// viewIncome renders the income statement
+func (m Model) viewIncome() string {
+	var lines []string
+
+	// Title - fill full width
+	titleText := "Income Statement - " + m.period.String()
+	if !m.period.IsAll() {
+		titleText += " vs " + m.period.Prev().String() + " ([ ])"
+	}
+	titlePadded := components.PadRight(titleText, m.width)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(titlePadded))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+
+	if len(m.rows) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("No income or expenses in "+m.period.String()))
+		return strings.Join(lines, "\n")
+	}
+
+	// Column header
+	header := m.formatRow("Account", m.period.String(), "", "")
+	if !m.period.IsAll() {
+		header = m.formatRow("Account", m.period.String(), m.period.Prev().String(), "Change")
+	}
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(header))
+	lines = append(lines, m.display.Rule(m.width)...)
+
+	start, end := m.list.Window()
+	for i := start; i < end; i++ {
+		node := m.rows[i]
+		marker := "  "
+		if len(node.Children) > 0 {
+			marker = "▸ "
+			if m.expanded[node.Account] {
+				marker = "▾ "
+			}
+		}
+		label := m.display.Indent(node.Depth) + marker + node.Name
+		line := m.formatAmounts(label, m.current[node.Account], m.previous[node.Account])
+
+		switch {
+		case i == m.list.Cursor():
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line))
+		case node.Depth == 0:
+			lines = append(lines, theme.HighlightStyle.Width(m.width).Render(line))
+		default:
+			lines = append(lines, theme.ListItemStyle.Width(m.width).Render(line))
+		}
+	}
+
+	// Net income: income less expenses
+	lines = append(lines, m.display.Rule(m.width)...)
+	net := m.formatAmounts("Net Income", netIncome(m.current), netIncome(m.previous))
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(net))
+
+	return strings.Join(lines, "\n")
+}
+
+// netIncome subtracts expenses from income per commodity
+func netIncome(totals map[string]map[string]decimal.Decimal) map[string]decimal.Decimal {
+	net := make(map[string]decimal.Decimal)
+	for commodity, number := range totals["Income"] {
+		net[commodity] = net[commodity].Add(number)
+	}
+	for commodity, number := range totals["Expenses"] {
+		net[commodity] = net[commodity].Sub(number)
+	}
+	return net
+}
+
+// formatAmounts lays out a row with the current and previous totals and their difference
+func (m Model) formatAmounts(label string, current, previous map[string]decimal.Decimal) string {
+	if m.period.IsAll() {
+		return m.formatRow(label, m.formatBalance(current, false), "", "")
+	}
+	change := make(map[string]decimal.Decimal)
+	for commodity, number := range current {
+		change[commodity] = number
+	}
+	for commodity, number := range previous {
+		change[commodity] = change[commodity].Sub(number)
+	}
+	return m.formatRow(label, m.formatBalance(current, false), m.formatBalance(previous, false), m.formatBalance(change, true))
+}
+
+// formatRow lays out an account label and right-aligned amount columns
+func (m Model) formatRow(label, current, previous, change string) string {
+	columns := []string{current}
+	if !m.period.IsAll() {
+		columns = append(columns, previous, change)
+	}
+	columnWidth := max(14, min(22, (m.width-30)/len(columns)))
+
+	var amounts string
+	for _, column := range columns {
+		amounts += fmt.Sprintf("%*s", columnWidth, column)
+	}
+
+	label = " " + label
+	room := max(0, m.width-len(amounts)-2)
+	return components.Fit(label, room, "") + amounts + "  "
+}
+
+// formatBalance renders per-commodity amounts sorted by commodity; signed adds a + to increases
+func (m Model) formatBalance(balance map[string]decimal.Decimal, signed bool) string {
+	commodities := make([]string, 0, len(balance))
+	for commodity, number := range balance {
+		if !number.IsZero() {
+			commodities = append(commodities, commodity)
+		}
+	}
+	sort.Strings(commodities)
+
+	parts := make([]string, len(commodities))
+	for i, commodity := range commodities {
+		parts[i] = m.display.Amount(balance[commodity], commodity)
+		if signed && balance[commodity].IsPositive() {
+			parts[i] = "+" + parts[i]
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Export returns the active report with plain numbers, as the export dialog writes it
+// The income statement lists every account, expanded or not, with one row per commodity.
+func (m Model) Export() export.Table {
+	switch m.report {
+	case spendingReport:
+		return m.exportSpending()
+	case subscriptionsReport:
+		return m.exportSubscriptions()
+	case calendarReport:
+		return m.exportCalendar()
+	case forecastReport:
+		return m.exportForecast()
+	case savingsReport:
+		return m.exportSavings()
+	case tripsReport:
+		return m.exportTrips()
+	case anomaliesReport:
+		return m.exportAnomalies()
+	}
+
+	table := export.Table{
+		Name:    "income statement",
+		Columns: []string{"account", "commodity", m.period.String()},
+	}
+	if !m.period.IsAll() {
+		table.Columns = append(table.Columns, m.period.Prev().String())
+	}
+	var walk func(node *beancount.AccountNode)
+	walk = func(node *beancount.AccountNode) {
+		current, previous := m.current[node.Account], m.previous[node.Account]
+		var commodities []string
+		for _, totals := range []map[string]decimal.Decimal{current, previous} {
+			for commodity := range totals {
+				if !slices.Contains(commodities, commodity) {
+					commodities = append(commodities, commodity)
+				}
+			}
+		}
+		sort.Strings(commodities)
+		for _, commodity := range commodities {
+			row := []string{node.Account, commodity, m.exportNumber(current[commodity], commodity)}
+			if !m.period.IsAll() {
+				row = append(row, m.exportNumber(previous[commodity], commodity))
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	for _, root := range m.roots {
+		walk(root)
+	}
+	return table
+}
+
+// SetSize updates the reports view size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m.scroll().scrollSubscriptions().scrollTrips().scrollAnomalies()
+}
+
+// SetDisplay updates the reports view display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m.SetSize(m.width, m.height)
+}
+
+// ShortHelp returns the view's main key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	k := m.keys
+	return []key.Binding{k.NextReport, k.Toggle, k.Month, k.PrevPer, k.NextPer, k.DrillDown}
+}
+
+// FullHelp returns all of the view's key bindings in columns, for the help row
+func (m Model) FullHelp() [][]key.Binding {
+	k := m.keys
+	return [][]key.Binding{
+		{k.NextReport, k.PrevReport, k.DrillDown},
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Top, k.Bottom},
+		{k.Toggle, k.Expand, k.Collapse},
+		{k.ExpandAll, k.CollapseAll},
+		{k.Month, k.Quarter, k.Year},
+		{k.PrevPer, k.NextPer},
+	}
+}
+
+// KeyBindings returns the view's key bindings, for the help overlay
+func (m Model) KeyBindings() []key.Binding {
+	return slices.Concat(m.FullHelp()...)
+}
@@ -0,0 +1,252 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/forecast"
+	"github.com/mmichie/lima/internal/trips"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
+)
+
+// Kinds lists the reports Run computes, by the names lima report takes
+var Kinds = []string{"income", "balance", "spending", "networth", "savings"}
+
+// Run computes a report over a period without the view, for lima report to print
+// Amounts are in currency at the ledger's prices, or native commodities for "". The
+// income statement, spending and savings are the tables the view exports; the balance
+// sheet and net worth value holdings at the prices of the period's last day.
+func Run(file *beancount.File, kind string, period beancount.Period, currency string) (export.Table, error) {
+	if !slices.Contains(Kinds, kind) {
+		return export.Table{}, fmt.Errorf("unknown report %q: use %s", kind, strings.Join(Kinds, ", "))
+	}
+	m := New(file, config.KeybindingsConfig{}, currency)
+	if m.err != "" {
+		return export.Table{}, errors.New(m.err)
+	}
+	switch kind {
+	case "income":
+		return m.SetPeriod(period).Export(), nil
+	case "spending":
+		return m.exportSpendingIn(period), nil
+	case "savings":
+		return m.exportSavingsIn(period), nil
+	}
+
+	// Holdings are valued at one date, so they are summed before converting
+	transactions, err := file.AllTransactions()
+	if err != nil {
+		return export.Table{}, err
+	}
+	prices := beancount.NewPriceDB(file.GetPriceDirectives())
+	if kind == "balance" {
+		return m.balanceSheet(transactions, period, prices, currency), nil
+	}
+	return m.netWorthByMonth(transactions, period, prices, currency), nil
+}
+
+// Forecast projects the balances of the accounts options choose from today, one month
+// to a row, as the forecast report exports them; amounts are in currency at the
+// ledger's prices, or native commodities for ""
+func Forecast(file *beancount.File, currency string, options forecast.Options) (export.Table, error) {
+	m := New(file, config.KeybindingsConfig{}, currency)
+	if m.err != "" {
+		return export.Table{}, errors.New(m.err)
+	}
+	m.forecast.options = options
+	m = m.refreshForecast()
+	if m.forecast.err != "" {
+		return export.Table{}, errors.New(m.forecast.err)
+	}
+	return m.exportForecast(), nil
+}
+
+// Trips totals the spending of the trips options choose, the most recent first, as
+// the trips report exports them; amounts are in currency at the ledger's prices, or
+// native commodities for ""
+func Trips(file *beancount.File, currency string, options trips.Options) (export.Table, error) {
+	m := New(file, config.KeybindingsConfig{}, currency)
+	if m.err != "" {
+		return export.Table{}, errors.New(m.err)
+	}
+	m.trips.options = options
+	m = m.refreshTrips()
+	if m.trips.err != "" {
+		return export.Table{}, errors.New(m.trips.err)
+	}
+	return m.exportTrips(), nil
+}
+
+// exportSpendingIn totals expenses by category over a period, largest first, in the
+// commodity the spending report charts
+func (m Model) exportSpendingIn(period beancount.Period) export.Table {
+	totals := make(map[string]decimal.Decimal)
+	for month, categories := range m.spending.totals {
+		if !period.Contains(month) {
+			continue
+		}
+		for category, total := range categories {
+			totals[category] = totals[category].Add(total)
+		}
+	}
+	var categories []string
+	for category, total := range totals {
+		if !total.IsZero() {
+			categories = append(categories, category)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := totals[categories[i]], totals[categories[j]]
+		if !a.Equal(b) {
+			return a.GreaterThan(b)
+		}
+		return categories[i] < categories[j]
+	})
+
+	table := export.Table{
+		Name:    "spending",
+		Columns: []string{"period", "category", "amount", "commodity"},
+	}
+	for _, category := range categories {
+		table.Rows = append(table.Rows, []string{period.String(), category, m.exportNumber(totals[category], m.spending.commodity), m.spending.commodity})
+	}
+	return table
+}
+
+// exportNumber writes a number for an export in the decimal places its commodity is
+// shown with, or more if it was written with more, in the ledger style: 311.50, not 311.5
+func (m Model) exportNumber(number decimal.Decimal, commodity string) string {
+	return number.StringFixed(max(int32(m.display.Precision(commodity)), -number.Exponent()))
+}
+
+// isBalanceSheet reports whether an account is an asset, liability or equity account
+func isBalanceSheet(account string) bool {
+	root, _, _ := strings.Cut(account, ":")
+	return root == "Assets" || root == "Liabilities" || root == "Equity"
+}
+
+// valuationDate is the day holdings are valued for a period: its last day, or today
+// for all time and periods not over yet
+func valuationDate(period beancount.Period) time.Time {
+	today := time.Now()
+	if period.IsAll() || period.End().After(today) {
+		return today
+	}
+	return period.End().AddDate(0, 0, -1)
+}
+
+// balanceSheet lists the balance of every asset, liability and equity account and
+// their parents at the end of a period, one row per commodity, with the ledger's signs
+func (m Model) balanceSheet(transactions []*beancount.Transaction, period beancount.Period, prices *beancount.PriceDB, currency string) export.Table {
+	var held []*beancount.Transaction
+	for _, tx := range transactions {
+		if period.IsAll() || tx.Date.Before(period.End()) {
+			held = append(held, tx)
+		}
+	}
+	balances := make(map[string]map[string]decimal.Decimal)
+	for account, balance := range beancount.AccountBalances(held) {
+		if !isBalanceSheet(account) {
+			continue
+		}
+		if currency != "" {
+			balance = prices.ConvertBalance(balance, currency, valuationDate(period))
+		}
+		balances[account] = balance
+	}
+	rolled := beancount.RollUpBalances(balances)
+
+	table := export.Table{
+		Name:    "balance sheet",
+		Columns: []string{"account", "commodity", period.String()},
+	}
+	accounts := make([]string, 0, len(rolled))
+	for account := range rolled {
+		accounts = append(accounts, account)
+	}
+	var walk func(node *beancount.AccountNode)
+	walk = func(node *beancount.AccountNode) {
+		balance := rolled[node.Account]
+		commodities := make([]string, 0, len(balance))
+		for commodity, number := range balance {
+			if !number.IsZero() {
+				commodities = append(commodities, commodity)
+			}
+		}
+		sort.Strings(commodities)
+		for _, commodity := range commodities {
+			table.Rows = append(table.Rows, []string{node.Account, commodity, m.exportNumber(balance[commodity], commodity)})
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	for _, root := range beancount.BuildAccountTree(accounts) {
+		walk(root)
+	}
+	return table
+}
+
+// netWorthByMonth lists assets less liabilities at the end of each month of a period,
+// one row per commodity; all time runs from the first month with transactions to the last
+func (m Model) netWorthByMonth(transactions []*beancount.Transaction, period beancount.Period, prices *beancount.PriceDB, currency string) export.Table {
+	table := export.Table{
+		Name:    "net worth",
+		Columns: []string{"date", "commodity", "net worth"},
+	}
+	sorted := make([]*beancount.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+	if len(sorted) == 0 {
+		return table
+	}
+
+	month := beancount.PeriodContaining(beancount.PeriodMonth, sorted[0].Date)
+	last := beancount.PeriodContaining(beancount.PeriodMonth, sorted[len(sorted)-1].Date)
+	if !period.IsAll() {
+		month = beancount.PeriodContaining(beancount.PeriodMonth, period.Start)
+		last = beancount.PeriodContaining(beancount.PeriodMonth, period.End().AddDate(0, 0, -1))
+	}
+
+	worth := make(map[string]decimal.Decimal)
+	next := 0
+	for ; !month.Start.After(last.Start); month = month.Next() {
+		for ; next < len(sorted) && sorted[next].Date.Before(month.End()); next++ {
+			for _, posting := range sorted[next].ResolvedPostings() {
+				root, _, _ := strings.Cut(posting.Account, ":")
+				if posting.Amount == nil || root != "Assets" && root != "Liabilities" {
+					continue
+				}
+				worth[posting.Amount.Commodity] = worth[posting.Amount.Commodity].Add(posting.Amount.Number)
+			}
+		}
+		// Months yet to come have no net worth
+		if month.Start.After(time.Now()) {
+			break
+		}
+
+		valued := worth
+		date := valuationDate(month)
+		if currency != "" {
+			valued = prices.ConvertBalance(worth, currency, date)
+		}
+		commodities := make([]string, 0, len(valued))
+		for commodity, number := range valued {
+			if !number.IsZero() {
+				commodities = append(commodities, commodity)
+			}
+		}
+		sort.Strings(commodities)
+		for _, commodity := range commodities {
+			table.Rows = append(table.Rows, []string{date.Format("2006-01-02"), commodity, m.exportNumber(valued[commodity], commodity)})
+		}
+	}
+	return table
+}
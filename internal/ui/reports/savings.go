@@ -0,0 +1,216 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/savings"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+const (
+	// deviantMonths is how many of the months straying furthest from the overall rate are listed
+	deviantMonths = 8
+
+	// trendMonths is the most months the sparkline spans
+	trendMonths = 24
+)
+
+// savingsRate is the state of the savings rate report
+type savingsRate struct {
+	// months hold income and expenses in the spending report's commodity, oldest first
+	months []savings.Month
+	// month is the index of the month reported in full
+	month int
+
+	// deviant indexes the months furthest from the overall rate, furthest first
+	deviant []int
+	cursor  int
+}
+
+// refreshSavings totals income and expenses by month, keeping the reported month
+func (m Model) refreshSavings() Model {
+	s := &m.savings
+	var selected beancount.Period
+	if s.month < len(s.months) {
+		selected = beancount.PeriodContaining(beancount.PeriodMonth, s.months[s.month].Start)
+	}
+
+	// The month under way is counted as it stands today, so a new day computes it again
+	type params struct {
+		today     string
+		commodity string
+	}
+	today := time.Now().Format("2006-01-02")
+	computed := cached(m.cache, cacheKey{report: "savings", currency: m.currency, params: params{today, m.spending.commodity}}, func() savingsRate {
+		months := savings.Months(m.transactions, m.spending.commodity)
+		return savingsRate{months: months, deviant: savings.Deviations(months, deviantMonths)}
+	})
+	s.months, s.deviant = computed.months, computed.deviant
+	s.cursor = max(0, min(s.cursor, len(s.deviant)-1))
+	s.month = max(0, len(s.months)-1)
+	for i, month := range s.months {
+		if month.Start.Equal(selected.Start) {
+			s.month = i
+		}
+	}
+	return m
+}
+
+// updateSavings handles keys for the savings rate: the reported month and the deviant months
+func (m Model) updateSavings(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := &m.savings
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		s.cursor = max(0, s.cursor-1)
+
+	case key.Matches(keyMsg, m.keys.Down):
+		s.cursor = max(0, min(len(s.deviant)-1, s.cursor+1))
+
+	case key.Matches(keyMsg, m.keys.Top):
+		s.cursor = 0
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		s.cursor = max(0, len(s.deviant)-1)
+
+	case key.Matches(keyMsg, m.keys.PrevPer):
+		s.month = max(0, s.month-1)
+
+	case key.Matches(keyMsg, m.keys.NextPer):
+		s.month = max(0, min(len(s.months)-1, s.month+1))
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		if s.cursor < len(s.deviant) {
+			month := s.months[s.deviant[s.cursor]]
+			msg := DrillDownMsg{Period: beancount.PeriodContaining(beancount.PeriodMonth, month.Start)}
+			return m, func() tea.Msg { return msg }
+		}
+	}
+	return m, nil
+}
+
+// percent renders a savings rate, or a dash for a month without income
+func percent(rate float64, ok bool) string {
+	if !ok {
+		return "—"
+	}
+	return fmt.Sprintf("%.1f%%", rate)
+}
+
+// viewSavings renders the reported month with its rolling rates and trend, then the
+// months that stray furthest from the overall rate
+func (m Model) viewSavings() string {
+	s := m.savings
+	commodity := m.spending.commodity
+
+	var lines []string
+	titleText := "Savings Rate"
+	if len(s.months) > 0 {
+		titleText += " - " + beancount.PeriodContaining(beancount.PeriodMonth, s.months[s.month].Start).String() + " ([ ])"
+	}
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(components.PadRight(titleText, m.width)))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+	if len(s.months) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("No income or expenses in "+commodity))
+		return strings.Join(lines, "\n")
+	}
+
+	month := s.months[s.month]
+	lines = append(lines, "")
+	lines = append(lines, theme.NormalTextStyle.Render(fmt.Sprintf(" %-9s %s   Expenses %s   Saved %s   Rate %s",
+		"Income", m.display.Amount(month.Income, commodity), m.display.Amount(month.Expenses, commodity),
+		m.display.Amount(month.Saved(), commodity), percent(month.Rate()))))
+	var rolling []string
+	for _, window := range savings.Windows {
+		rolling = append(rolling, fmt.Sprintf("%d months %s", window, percent(savings.Rolling(s.months, s.month, window))))
+	}
+	lines = append(lines, theme.NormalTextStyle.Render(fmt.Sprintf(" %-9s %s", "Rolling", strings.Join(rolling, "   "))))
+
+	// The months up to the reported one, as many as fit
+	first := max(0, s.month+1-min(trendMonths, max(1, m.width-50)))
+	rates := make([]float64, 0, s.month+1-first)
+	for _, month := range s.months[first : s.month+1] {
+		rate, ok := month.Rate()
+		if !ok {
+			rate = math.NaN()
+		}
+		rates = append(rates, rate)
+	}
+	overall, ok := savings.Overall(s.months)
+	trend := fmt.Sprintf("  %s to %s, %s overall",
+		beancount.PeriodContaining(beancount.PeriodMonth, s.months[first].Start),
+		beancount.PeriodContaining(beancount.PeriodMonth, month.Start), percent(overall, ok))
+	lines = append(lines, theme.NormalTextStyle.Render(fmt.Sprintf(" %-9s ", "Trend"))+
+		theme.BarStyle.Render(components.RenderSparkline(rates))+theme.MutedTextStyle.Render(trend))
+
+	if len(s.deviant) == 0 {
+		return strings.Join(lines, "\n")
+	}
+	lines = append(lines, "")
+	header := fmt.Sprintf(" %-8s  %12s %12s %12s %8s  %s", "Month", "Income", "Expenses", "Saved", "Rate", "Furthest from overall")
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(header))
+	for i, index := range s.deviant {
+		month := s.months[index]
+		rate, _ := month.Rate()
+		line := fmt.Sprintf(" %-8s  %s %s %s %8s  %+.1f pts", beancount.PeriodContaining(beancount.PeriodMonth, month.Start),
+			components.PadLeft(m.display.Number(month.Income, commodity), 12),
+			components.PadLeft(m.display.Number(month.Expenses, commodity), 12),
+			components.PadLeft(m.display.Number(month.Saved(), commodity), 12),
+			percent(rate, true), rate-overall)
+		if i == s.cursor {
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line))
+		} else {
+			lines = append(lines, theme.ListItemStyle.Render(line))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exportSavings returns every month's income, expenses and rates
+func (m Model) exportSavings() export.Table {
+	return m.exportSavingsIn(beancount.AllTime())
+}
+
+// exportSavingsIn returns the months of a period, one to a row; their rolling rates
+// take in the months before it
+func (m Model) exportSavingsIn(period beancount.Period) export.Table {
+	table := export.Table{
+		Name:    "savings",
+		Columns: []string{"month", "income", "expenses", "saved", "rate"},
+	}
+	for _, window := range savings.Windows {
+		table.Columns = append(table.Columns, fmt.Sprintf("rate %dm", window))
+	}
+	table.Columns = append(table.Columns, "commodity")
+
+	// Rates are percentages to one decimal, empty without income
+	rate := func(r float64, ok bool) string {
+		if !ok {
+			return ""
+		}
+		return fmt.Sprintf("%.1f", r)
+	}
+	for i, month := range m.savings.months {
+		if !period.Contains(month.Start) {
+			continue
+		}
+		commodity := m.spending.commodity
+		row := []string{month.Start.Format("2006-01"), m.exportNumber(month.Income, commodity), m.exportNumber(month.Expenses, commodity),
+			m.exportNumber(month.Saved(), commodity), rate(month.Rate())}
+		for _, window := range savings.Windows {
+			row = append(row, rate(savings.Rolling(m.savings.months, i, window)))
+		}
+		table.Rows = append(table.Rows, append(row, commodity))
+	}
+	return table
+}
@@ -0,0 +1,288 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/shopspring/decimal"
+)
+
+// historyMonths is how many months of the selected category's history are charted
+const historyMonths = 6
+
+// spending is the state of the monthly spending-by-category report
+type spending struct {
+	// month is the charted month
+	month beancount.Period
+
+	// commodity is the most used expense commodity; amounts in others are left out
+	commodity string
+	skipped   bool
+
+	// totals holds expenses per month start and top-level category (e.g. Expenses:Food)
+	totals map[time.Time]map[string]decimal.Decimal
+
+	// categories of the charted month, largest first
+	categories []string
+	cursor     int
+}
+
+// spendingCategory returns the top-level expense category of an account,
+// e.g. Expenses:Food for Expenses:Food:Coffee
+func spendingCategory(account string) (string, bool) {
+	parts := strings.SplitN(account, ":", 3)
+	if parts[0] != "Expenses" {
+		return "", false
+	}
+	if len(parts) == 1 {
+		return account, true
+	}
+	return parts[0] + ":" + parts[1], true
+}
+
+// refreshSpending totals expenses by month and category, then lists the charted month
+func (m Model) refreshSpending() Model {
+	s := &m.spending
+	computed := cached(m.cache, cacheKey{report: "spending", currency: m.currency}, func() spending {
+		var computed spending
+
+		// Chart the operating currency, or else the commodity most expense postings use
+		counts := make(map[string]int)
+		for _, tx := range m.transactions {
+			for _, posting := range tx.ResolvedPostings() {
+				if _, ok := spendingCategory(posting.Account); ok && posting.Amount != nil {
+					counts[posting.Amount.Commodity]++
+				}
+			}
+		}
+		for commodity, count := range counts {
+			if computed.commodity == "" || count > counts[computed.commodity] || (count == counts[computed.commodity] && commodity < computed.commodity) {
+				computed.commodity = commodity
+			}
+		}
+
+		if m.currency != "" {
+			computed.commodity = m.currency
+		}
+
+		computed.totals = make(map[time.Time]map[string]decimal.Decimal)
+		for _, tx := range m.transactions {
+			month := beancount.PeriodContaining(beancount.PeriodMonth, tx.Date).Start
+			for _, posting := range tx.ResolvedPostings() {
+				category, ok := spendingCategory(posting.Account)
+				if !ok || posting.Amount == nil {
+					continue
+				}
+				if posting.Amount.Commodity != computed.commodity {
+					computed.skipped = true
+					continue
+				}
+				totals, ok := computed.totals[month]
+				if !ok {
+					totals = make(map[string]decimal.Decimal)
+					computed.totals[month] = totals
+				}
+				totals[category] = totals[category].Add(posting.Amount.Number)
+			}
+		}
+		return computed
+	})
+	s.commodity, s.skipped, s.totals = computed.commodity, computed.skipped, computed.totals
+
+	return m.setSpendingMonth(s.month)
+}
+
+// setSpendingMonth charts a month, keeping the selected category where possible
+func (m Model) setSpendingMonth(month beancount.Period) Model {
+	s := &m.spending
+	var selected string
+	if s.cursor < len(s.categories) {
+		selected = s.categories[s.cursor]
+	}
+
+	s.month = month
+	totals := s.totals[month.Start]
+	s.categories = s.categories[:0:0]
+	for category, total := range totals {
+		if !total.IsZero() {
+			s.categories = append(s.categories, category)
+		}
+	}
+	sort.Slice(s.categories, func(i, j int) bool {
+		a, b := totals[s.categories[i]], totals[s.categories[j]]
+		if !a.Equal(b) {
+			return a.GreaterThan(b)
+		}
+		return s.categories[i] < s.categories[j]
+	})
+
+	s.cursor = max(0, min(s.cursor, len(s.categories)-1))
+	for i, category := range s.categories {
+		if category == selected {
+			s.cursor = i
+		}
+	}
+	return m
+}
+
+// updateSpending handles keys for the spending report
+func (m Model) updateSpending(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := &m.spending
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		if s.cursor > 0 {
+			s.cursor--
+		}
+
+	case key.Matches(keyMsg, m.keys.Down):
+		if s.cursor < len(s.categories)-1 {
+			s.cursor++
+		}
+
+	case key.Matches(keyMsg, m.keys.Top):
+		s.cursor = 0
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		s.cursor = max(0, len(s.categories)-1)
+
+	case key.Matches(keyMsg, m.keys.PrevPer):
+		m = m.setSpendingMonth(s.month.Prev())
+
+	case key.Matches(keyMsg, m.keys.NextPer):
+		m = m.setSpendingMonth(s.month.Next())
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		if s.cursor < len(s.categories) {
+			msg := DrillDownMsg{Account: s.categories[s.cursor], Period: s.month}
+			return m, func() tea.Msg { return msg }
+		}
+	}
+	return m, nil
+}
+
+// viewSpending renders the month's categories as bars, then the selected category's history
+func (m Model) viewSpending() string {
+	s := m.spending
+	totals := s.totals[s.month.Start]
+
+	total := decimal.Zero
+	for _, category := range s.categories {
+		total = total.Add(totals[category])
+	}
+
+	var lines []string
+	titleText := fmt.Sprintf("Spending by Category - %s ([ ])", s.month)
+	if len(s.categories) > 0 {
+		titleText += "  Total " + m.display.Amount(total, s.commodity)
+	}
+	titlePadded := components.PadRight(titleText, m.width)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(titlePadded))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+
+	if len(s.categories) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("No expenses in "+s.month.String()))
+		return strings.Join(lines, "\n")
+	}
+
+	// Label column fits the longest category name
+	labelWidth := 0
+	for _, category := range s.categories {
+		labelWidth = max(labelWidth, components.Width(categoryLabel(category)))
+	}
+	labelWidth = min(labelWidth, 24)
+
+	largest := totals[s.categories[0]]
+	lines = append(lines, "")
+	for i, category := range s.categories {
+		amount := totals[category]
+		share := "    "
+		if total.IsPositive() {
+			share = fmt.Sprintf("%4s%%", amount.Div(total).Mul(decimal.NewFromInt(100)).StringFixed(0))
+		}
+		lines = append(lines, m.barRow(categoryLabel(category), labelWidth, amount, largest, share, i == s.cursor))
+	}
+
+	// History of the selected category over the preceding months
+	category := s.categories[s.cursor]
+	lines = append(lines, "")
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(
+		fmt.Sprintf(" %s, last %d months", categoryLabel(category), historyMonths)))
+
+	months := make([]beancount.Period, historyMonths)
+	month := s.month
+	for i := historyMonths - 1; i >= 0; i-- {
+		months[i] = month
+		month = month.Prev()
+	}
+	peak := decimal.Zero
+	for _, month := range months {
+		peak = decimal.Max(peak, s.totals[month.Start][category])
+	}
+	for _, month := range months {
+		lines = append(lines, m.barRow(month.String(), max(8, labelWidth), s.totals[month.Start][category], peak, "", false))
+	}
+
+	if s.skipped {
+		lines = append(lines, "")
+		lines = append(lines, theme.MutedTextStyle.Render(" Expenses in commodities other than "+s.commodity+" are not shown"))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// barRow lays out a label, a bar scaled against largest, the amount and a suffix
+func (m Model) barRow(label string, labelWidth int, amount, largest decimal.Decimal, suffix string, selected bool) string {
+	label = components.Fit(label, labelWidth, "…")
+	amountText := components.PadLeft(m.display.Number(amount, m.spending.commodity), 12)
+	barWidth := max(4, m.width-labelWidth-components.Width(amountText)-components.Width(suffix)-6)
+
+	fraction := 0.0
+	if largest.IsPositive() && amount.IsPositive() {
+		fraction, _ = amount.Div(largest).Float64()
+	}
+	bar := components.RenderBar(fraction, barWidth)
+	padding := strings.Repeat(" ", barWidth-components.Width(bar))
+
+	if selected {
+		line := fmt.Sprintf(" %s %s%s %s %s ", label, bar, padding, amountText, suffix)
+		return theme.SelectedItemStyle.Width(m.width).Render(line)
+	}
+	return theme.NormalTextStyle.Render(" "+label+" ") +
+		theme.BarStyle.Render(bar) +
+		theme.NormalTextStyle.Render(padding+" "+amountText+" "+suffix+" ")
+}
+
+// categoryLabel drops the Expenses: prefix for display
+func categoryLabel(category string) string {
+	if label, ok := strings.CutPrefix(category, "Expenses:"); ok {
+		return label
+	}
+	return category
+}
+
+// exportSpending returns the charted month's categories, largest first
+func (m Model) exportSpending() export.Table {
+	s := m.spending
+	table := export.Table{
+		Name:    "spending",
+		Columns: []string{"month", "category", "amount", "commodity"},
+	}
+	totals := s.totals[s.month.Start]
+	for _, category := range s.categories {
+		table.Rows = append(table.Rows, []string{
+			s.month.Start.Format("2006-01"), category, totals[category].String(), s.commodity,
+		})
+	}
+	return table
+}
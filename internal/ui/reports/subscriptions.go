@@ -0,0 +1,190 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/recurring"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/shopspring/decimal"
+)
+
+// subscriptions is the state of the subscription tracker report
+type subscriptions struct {
+	// series are the detected recurring charges, most expensive per year first
+	series []recurring.Series
+	list   components.Scroller
+}
+
+// refreshSubscriptions detects recurring charges, keeping the selected one where possible
+func (m Model) refreshSubscriptions() Model {
+	s := &m.subscriptions
+	var selected string
+	if len(s.series) > 0 {
+		selected = s.series[s.list.Cursor()].Payee
+	}
+
+	// Like the forecast, the report is of the ledger as of today, so a new day computes it again
+	today := time.Now().Format("2006-01-02")
+	s.series = cached(m.cache, cacheKey{report: "subscriptions", currency: m.currency, params: today}, func() []recurring.Series {
+		series := recurring.Detect(m.transactions)
+		sort.SliceStable(series, func(i, j int) bool {
+			a, b := series[i], series[j]
+			if a.Commodity != b.Commodity {
+				return a.Commodity < b.Commodity
+			}
+			return a.Annual().GreaterThan(b.Annual())
+		})
+		return series
+	})
+
+	s.list = s.list.Fit(len(s.series), m.listHeight())
+	for i, series := range s.series {
+		if series.Payee == selected {
+			s.list = s.list.Select(i)
+		}
+	}
+	return m
+}
+
+// scrollSubscriptions keeps the cursor on a subscription and inside the visible window
+func (m Model) scrollSubscriptions() Model {
+	m.subscriptions.list = m.subscriptions.list.Fit(len(m.subscriptions.series), m.listHeight())
+	return m
+}
+
+// updateSubscriptions handles keys for the subscription tracker
+func (m Model) updateSubscriptions(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := &m.subscriptions
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		s.list = s.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		s.list = s.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		s.list = s.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		s.list = s.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		s.list = s.list.Top()
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		s.list = s.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		if len(s.series) > 0 {
+			series := s.series[s.list.Cursor()]
+			msg := DrillDownMsg{Account: series.Account, Payee: series.Payee, Period: beancount.AllTime()}
+			return m, func() tea.Msg { return msg }
+		}
+	}
+	return m.scrollSubscriptions(), nil
+}
+
+// viewSubscriptions renders detected subscriptions with their cost and price changes
+func (m Model) viewSubscriptions() string {
+	s := m.subscriptions
+
+	var lines []string
+	titleText := fmt.Sprintf("Subscriptions - %d detected", len(s.series))
+	titlePadded := components.PadRight(titleText, m.width)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(titlePadded))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+
+	if len(s.series) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render(
+			fmt.Sprintf("No recurring charges found (a payee needs %d regular, similar charges)", recurring.MinOccurrences)))
+		return strings.Join(lines, "\n")
+	}
+
+	payeeWidth := max(10, min(28, m.width-72-m.display.DateWidth()))
+	header := fmt.Sprintf(" %-*s  %-9s %12s %12s %12s  %-*s  %s",
+		payeeWidth, "Payee", "Every", "Price", "Monthly", "Annual", m.display.DateWidth(), "Last", "Change")
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(header))
+	lines = append(lines, m.display.Rule(m.width)...)
+
+	start, end := s.list.Window()
+	for i := start; i < end; i++ {
+		lines = append(lines, m.subscriptionRow(s.series[i], payeeWidth, i == s.list.Cursor()))
+	}
+
+	// Totals per commodity
+	monthly, annual := make(map[string]decimal.Decimal), make(map[string]decimal.Decimal)
+	for _, series := range s.series {
+		monthly[series.Commodity] = monthly[series.Commodity].Add(series.Monthly())
+		annual[series.Commodity] = annual[series.Commodity].Add(series.Annual())
+	}
+	lines = append(lines, m.display.Rule(m.width)...)
+	commodities := make([]string, 0, len(monthly))
+	for commodity := range monthly {
+		commodities = append(commodities, commodity)
+	}
+	sort.Strings(commodities)
+	for _, commodity := range commodities {
+		total := fmt.Sprintf(" %s  %-9s %12s %s %s", components.PadRight("Total "+commodity, payeeWidth), "", "",
+			components.PadLeft(m.display.Number(monthly[commodity], commodity), 12),
+			components.PadLeft(m.display.Number(annual[commodity], commodity), 12))
+		lines = append(lines, theme.HighlightStyle.Width(m.width).Render(total))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// subscriptionRow lays out one recurring charge; price rises are red and drops green
+func (m Model) subscriptionRow(series recurring.Series, payeeWidth int, selected bool) string {
+	payee := components.Fit(series.Payee, payeeWidth, "…")
+	line := fmt.Sprintf(" %s  %-9s %s %s %s  %-*s  ",
+		payee, series.Cadence,
+		components.PadLeft(m.display.Amount(series.LastAmount, series.Commodity), 12),
+		components.PadLeft(m.display.Number(series.Monthly(), series.Commodity), 12),
+		components.PadLeft(m.display.Number(series.Annual(), series.Commodity), 12),
+		m.display.DateWidth(), m.display.Date(series.Last))
+
+	change := series.PriceChange()
+	var flag string
+	switch {
+	case change.IsPositive():
+		flag = "▲ " + m.display.Signed(change, series.Commodity)
+	case change.IsNegative():
+		flag = "▼ " + m.display.Signed(change, series.Commodity)
+	}
+
+	if selected {
+		return theme.SelectedItemStyle.Width(m.width).Render(line + flag)
+	}
+	flagStyle := theme.SuccessStyle
+	if change.IsPositive() {
+		flagStyle = theme.ErrorStyle
+	}
+	return theme.ListItemStyle.Render(line) + flagStyle.Render(flag)
+}
+
+// exportSubscriptions returns the detected recurring charges in the order shown
+func (m Model) exportSubscriptions() export.Table {
+	table := export.Table{
+		Name:    "subscriptions",
+		Columns: []string{"payee", "account", "cadence", "amount", "monthly", "annual", "commodity", "last", "next"},
+	}
+	for _, series := range m.subscriptions.series {
+		table.Rows = append(table.Rows, []string{
+			series.Payee, series.Account, string(series.Cadence),
+			series.LastAmount.String(), series.Monthly().String(), series.Annual().String(),
+			series.Commodity, series.Last.Format("2006-01-02"), series.Next.Format("2006-01-02"),
+		})
+	}
+	return table
+}
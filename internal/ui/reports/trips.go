@@ -0,0 +1,215 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/trips"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/shopspring/decimal"
+)
+
+// travel is the state of the trips and events report
+type travel struct {
+	options trips.Options
+	// trips are the tagged trips and event spans, the most recent first
+	trips []trips.Trip
+	err   string
+	list  components.Scroller
+}
+
+// tripListHeight returns how many trips are listed, leaving room for the selected
+// trip's categories below
+func (m Model) tripListHeight() int {
+	return max(1, (m.height-8)/2)
+}
+
+// refreshTrips finds the trips again, keeping the selected one where possible
+func (m Model) refreshTrips() Model {
+	t := &m.trips
+	if t.options == (trips.Options{}) {
+		t.options = trips.DefaultOptions
+	}
+	var selected trips.Trip
+	if len(t.trips) > 0 {
+		selected = t.trips[t.list.Cursor()]
+	}
+
+	computed := cached(m.cache, cacheKey{report: "trips", currency: m.currency, params: t.options}, func() travel {
+		var computed travel
+		events, err := m.file.Events()
+		if err != nil {
+			computed.err = err.Error()
+		}
+		computed.trips = trips.Find(m.transactions, events, t.options)
+		return computed
+	})
+	t.trips, t.err = computed.trips, computed.err
+	t.list = t.list.Fit(len(t.trips), m.tripListHeight())
+	for i, trip := range t.trips {
+		if trip.Name == selected.Name && trip.Start.Equal(selected.Start) {
+			t.list = t.list.Select(i)
+		}
+	}
+	return m
+}
+
+// scrollTrips keeps the cursor on a trip and inside the visible window
+func (m Model) scrollTrips() Model {
+	m.trips.list = m.trips.list.Fit(len(m.trips.trips), m.tripListHeight())
+	return m
+}
+
+// updateTrips handles keys for the trips report
+func (m Model) updateTrips(keyMsg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := &m.trips
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		t.list = t.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		t.list = t.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		t.list = t.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		t.list = t.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		t.list = t.list.Top()
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		t.list = t.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		if len(t.trips) > 0 {
+			msg := DrillDownMsg{Filter: t.trips[t.list.Cursor()].Query(), Period: beancount.AllTime()}
+			return m, func() tea.Msg { return msg }
+		}
+	}
+	return m.scrollTrips(), nil
+}
+
+// formatTotals renders a trip's totals side by side, or their daily averages
+func (m Model) formatTotals(trip trips.Trip, perDay bool) string {
+	var parts []string
+	for _, total := range trip.Totals() {
+		number := total.Number
+		if perDay {
+			number = trip.PerDay(number)
+		}
+		parts = append(parts, m.display.Amount(number, total.Commodity))
+	}
+	return strings.Join(parts, " ")
+}
+
+// viewTrips lists the trips with their spans and spending, then the selected trip's
+// spending by category
+func (m Model) viewTrips() string {
+	t := m.trips
+
+	var lines []string
+	var marks []string
+	if t.options.TagPrefix != "" {
+		marks = append(marks, "#"+t.options.TagPrefix+" tags")
+	}
+	if t.options.Event != "" {
+		marks = append(marks, t.options.Event+" events")
+	}
+	titleText := fmt.Sprintf("Trips & Events - %d from %s", len(t.trips), strings.Join(marks, " and "))
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(components.PadRight(titleText, m.width)))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+	if t.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read events: "+t.err))
+	}
+	if len(t.trips) == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render(fmt.Sprintf(
+			"No trips: tag their transactions #%sNAME or add event %q \"PLACE\" directives", t.options.TagPrefix, t.options.Event)))
+		return strings.Join(lines, "\n")
+	}
+
+	nameWidth := max(10, min(28, m.width-80))
+	dateWidth := m.display.DateWidth()
+	header := fmt.Sprintf(" %-*s  %-*s  %-*s %5s  %16s  %14s", nameWidth, "Trip", dateWidth, "From", dateWidth, "To", "Days", "Spent", "Per day")
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(header))
+	start, end := t.list.Window()
+	for i := start; i < end; i++ {
+		trip := t.trips[i]
+		line := fmt.Sprintf(" %s  %-*s  %-*s %5d  %16s  %14s", components.PadRight(components.Fit(trip.Name, nameWidth, "…"), nameWidth),
+			dateWidth, m.display.Date(trip.Start), dateWidth, m.display.Date(trip.End), trip.Days(),
+			m.formatTotals(trip, false), m.formatTotals(trip, true))
+		line = components.Fit(line, m.width, "…")
+		if i == t.list.Cursor() {
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line))
+		} else {
+			lines = append(lines, theme.ListItemStyle.Render(line))
+		}
+	}
+
+	// The selected trip by category, bars scaled to its largest in each commodity
+	trip := t.trips[t.list.Cursor()]
+	lines = append(lines, "")
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(fmt.Sprintf(" %s by category", trip.Name)))
+	largest := make(map[string]decimal.Decimal)
+	labelWidth := 0
+	for _, category := range trip.Categories {
+		largest[category.Amount.Commodity] = decimal.Max(largest[category.Amount.Commodity], category.Amount.Number)
+		labelWidth = max(labelWidth, components.Width(categoryLabel(category.Account)))
+	}
+	labelWidth = min(labelWidth, 24)
+	for _, category := range trip.Categories {
+		amount := components.PadLeft(m.display.Amount(category.Amount.Number, category.Amount.Commodity), 16)
+		perDay := components.PadLeft(m.display.Amount(trip.PerDay(category.Amount.Number), category.Amount.Commodity), 14) + " a day"
+		barWidth := max(4, m.width-labelWidth-components.Width(amount)-components.Width(perDay)-6)
+		fraction := 0.0
+		if peak := largest[category.Amount.Commodity]; peak.IsPositive() && category.Amount.Number.IsPositive() {
+			fraction, _ = category.Amount.Number.Div(peak).Float64()
+		}
+		bar := components.RenderBar(fraction, barWidth)
+		padding := strings.Repeat(" ", barWidth-components.Width(bar))
+		label := components.PadRight(components.Fit(categoryLabel(category.Account), labelWidth, "…"), labelWidth)
+		lines = append(lines, theme.NormalTextStyle.Render(" "+label+" ")+theme.BarStyle.Render(bar)+
+			theme.NormalTextStyle.Render(padding+" "+amount+perDay))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exportTrips returns each trip's total, then its categories, with their daily averages
+func (m Model) exportTrips() export.Table {
+	return m.tripsTable(m.trips.trips)
+}
+
+// tripsTable lays out trips one total or category to a row; a trip's totals come first,
+// with the category "total"
+func (m Model) tripsTable(found []trips.Trip) export.Table {
+	table := export.Table{
+		Name:    "trips",
+		Columns: []string{"trip", "marked by", "from", "to", "days", "category", "amount", "per day", "commodity"},
+	}
+	for _, trip := range found {
+		marked := "tag"
+		if trip.Event {
+			marked = "event"
+		}
+		row := func(category string, amount beancount.Amount) []string {
+			return []string{trip.Name, marked, trip.Start.Format("2006-01-02"), trip.End.Format("2006-01-02"), fmt.Sprint(trip.Days()),
+				category, m.exportNumber(amount.Number, amount.Commodity), m.exportNumber(trip.PerDay(amount.Number), amount.Commodity), amount.Commodity}
+		}
+		for _, total := range trip.Totals() {
+			table.Rows = append(table.Rows, row("total", total))
+		}
+		for _, category := range trip.Categories {
+			table.Rows = append(table.Rows, row(category.Account, category.Amount))
+		}
+	}
+	return table
+}
@@ -0,0 +1,458 @@
+package review
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/confirm"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+// maxChoices is the number of suggestions selectable with the digit keys
+const maxChoices = 9
+
+// keyMap defines key bindings for the review view
+type keyMap struct {
+	Accept key.Binding
+	Pick   key.Binding
+	Edit   key.Binding
+	Skip   key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Accept: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "accept"),
+		),
+		Pick: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("1-9", "pick suggestion"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit"),
+		),
+		Skip: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "skip"),
+		),
+	}
+}
+
+// Model steps through uncategorized and flagged transactions one at a time
+type Model struct {
+	file        *beancount.File
+	categorizer *categorizer.Categorizer
+	writer      *beancount.Writer
+	width       int
+	height      int
+	display     components.Display
+	keys        keyMap
+
+	// queue holds the transactions awaiting review; index is the current one
+	queue []*beancount.Transaction
+	index int
+
+	// suggestions for the current transaction, best first
+	suggestions []*categorizer.Suggestion
+
+	// editing is true while the account input is open
+	editing  bool
+	input    components.TextInput
+	accounts []string
+
+	// Session counters
+	categorized int
+	skipped     int
+
+	// message reports the result of the last action
+	message string
+}
+
+// CategorizedMsg reports a reviewed transaction written to the ledger
+// Edits are the changes made, so the root model can journal them for undo;
+// the root passes the message back so the review moves on
+type CategorizedMsg struct {
+	Edits       []beancount.Edit
+	Description string
+	Err         error
+
+	account string
+	chosen  int // Index of the chosen suggestion, -1 for a typed category
+}
+
+// New creates a new review model; the queue is built by Reload
+func New(file *beancount.File, cat *categorizer.Categorizer, writer *beancount.Writer) Model {
+	return Model{
+		file:        file,
+		categorizer: cat,
+		writer:      writer,
+		keys:        newKeyMap(),
+		input:       components.NewTextInput("Account").SetWidth(50),
+	}
+}
+
+// NeedsReview reports whether a transaction belongs in the review queue
+func NeedsReview(tx *beancount.Transaction) bool {
+	return tx.Flag == "!" || categorizer.PlaceholderPosting(tx) >= 0
+}
+
+// Reload rebuilds the queue from the ledger and starts a new session
+func (m Model) Reload() Model {
+	m.queue = nil
+	m.index = 0
+	m.categorized = 0
+	m.skipped = 0
+	m.editing = false
+	m.message = ""
+	m.accounts = m.file.GetAccounts()
+	m.input.Suggestions = m.accounts
+
+	transactions, err := m.file.AllTransactions()
+	if err != nil {
+		m.message = fmt.Sprintf("Failed to read transactions: %v", err)
+		return m
+	}
+	for _, tx := range transactions {
+		if NeedsReview(tx) {
+			m.queue = append(m.queue, tx)
+		}
+	}
+	m.loadSuggestions()
+	return m
+}
+
+// Remaining returns the number of transactions not yet reviewed
+func (m Model) Remaining() int {
+	return len(m.queue) - m.index
+}
+
+// current returns the transaction under review, or nil when the queue is done
+func (m Model) current() *beancount.Transaction {
+	if m.index >= len(m.queue) {
+		return nil
+	}
+	return m.queue[m.index]
+}
+
+// loadSuggestions fetches suggestions for the current transaction
+func (m *Model) loadSuggestions() {
+	m.suggestions = nil
+	tx := m.current()
+	if tx == nil || m.categorizer == nil {
+		return
+	}
+	suggestions, err := m.categorizer.SuggestAll(tx)
+	if err != nil {
+		m.message = fmt.Sprintf("Suggestion failed: %v", err)
+		return
+	}
+	if len(suggestions) > maxChoices {
+		suggestions = suggestions[:maxChoices]
+	}
+	m.suggestions = suggestions
+}
+
+// advance moves to the next transaction in the queue
+func (m *Model) advance() {
+	m.index++
+	m.loadSuggestions()
+}
+
+// Captures reports whether the view handles this key itself
+// The root model routes captured keys here instead of treating them as global shortcuts
+func (m Model) Captures(msg tea.KeyMsg) bool {
+	if m.editing {
+		return msg.String() != "ctrl+c"
+	}
+	if m.current() == nil {
+		return false
+	}
+	return key.Matches(msg, m.keys.Accept, m.keys.Pick, m.keys.Edit, m.keys.Skip)
+}
+
+// Init initializes the review view
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if written, ok := msg.(CategorizedMsg); ok {
+		return m.written(written), nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok || m.current() == nil {
+		return m, nil
+	}
+
+	if m.editing {
+		return m.updateEditing(keyMsg)
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Accept):
+		if len(m.suggestions) > 0 {
+			return m.choose(0)
+		}
+		m.message = "No suggestion to accept (e: edit, s: skip)"
+
+	case key.Matches(keyMsg, m.keys.Edit):
+		m.editing = true
+		m.input = m.input.SetValue("").Focus()
+		if len(m.suggestions) > 0 {
+			m.input = m.input.SetValue(m.suggestions[0].Category)
+		}
+
+	case key.Matches(keyMsg, m.keys.Skip):
+		m.skipped++
+		m.message = ""
+		m.advance()
+
+	case key.Matches(keyMsg, m.keys.Pick):
+		choice := int(keyMsg.String()[0] - '1')
+		if choice < len(m.suggestions) {
+			return m.choose(choice)
+		}
+	}
+
+	return m, nil
+}
+
+// updateEditing handles keys while the account input is open
+func (m Model) updateEditing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.editing = false
+		m.input = m.input.Blur()
+		return m, nil
+
+	case tea.KeyEnter:
+		account := strings.TrimSpace(m.input.Value())
+		if account == "" {
+			return m, nil
+		}
+		m.editing = false
+		m.input = m.input.Blur()
+		// Typing a suggested category counts as choosing that suggestion
+		for i, suggestion := range m.suggestions {
+			if suggestion.Category == account {
+				return m.choose(i)
+			}
+		}
+		return m.write(account, -1)
+	}
+
+	m.input, _ = m.input.Update(msg)
+	return m, nil
+}
+
+// choose asks to write the chosen suggestion
+func (m Model) choose(i int) (tea.Model, tea.Cmd) {
+	return m.write(m.suggestions[i].Category, i)
+}
+
+// write asks to set the target posting's account and clear the review flag
+// The root model confirms and applies the edits, then reports them back as a
+// CategorizedMsg; chosen is the suggestion picked, or -1 for a typed category
+func (m Model) write(account string, chosen int) (tea.Model, tea.Cmd) {
+	edits, err := m.edits(account)
+	if err != nil {
+		m.message = fmt.Sprintf("Write failed: %v", err)
+		return m, nil
+	}
+
+	tx := m.current()
+	label := tx.Payee
+	if label == "" {
+		label = tx.Narration
+	}
+	result := CategorizedMsg{
+		Edits:       edits,
+		Description: fmt.Sprintf("review of %s %s", m.display.Date(tx.Date), label),
+		account:     account,
+		chosen:      chosen,
+	}
+	return m, confirm.Request(confirm.RequestMsg{
+		Title:  fmt.Sprintf("Categorize %s as %s", label, account),
+		Edits:  edits,
+		Single: true,
+		Done: func(err error) tea.Msg {
+			result.Err = err
+			return result
+		},
+	})
+}
+
+// edits builds the edits that set the target posting's account and clear the review flag
+func (m Model) edits(account string) ([]beancount.Edit, error) {
+	tx := m.current()
+	posting := categorizer.CategoryPosting(tx)
+	if posting < 0 {
+		return nil, fmt.Errorf("no expense, income or placeholder posting to categorize")
+	}
+
+	var edits []beancount.Edit
+	if tx.Postings[posting].Account != account {
+		edit, err := m.writer.SetPostingAccount(tx, posting, account)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+	if tx.Flag == "!" {
+		edit, err := m.writer.SetTransactionFlag(tx, "*")
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+	return edits, nil
+}
+
+// written records feedback for a written category and moves to the next transaction
+func (m Model) written(msg CategorizedMsg) Model {
+	if msg.Err != nil {
+		m.message = fmt.Sprintf("Write failed: %v", msg.Err)
+		return m
+	}
+
+	switch {
+	case msg.chosen >= 0 && msg.chosen < len(m.suggestions):
+		m.recordFeedback(m.suggestions[msg.chosen], true)
+		if msg.chosen > 0 {
+			// Picking an alternative rejects the top suggestion
+			m.recordFeedback(m.suggestions[0], false)
+		}
+	case msg.chosen < 0 && len(m.suggestions) > 0:
+		// A manual category means the top suggestion was wrong
+		m.recordFeedback(m.suggestions[0], false)
+	}
+
+	m.categorized++
+	m.message = fmt.Sprintf("Categorized as %s", msg.account)
+	m.advance()
+	return m
+}
+
+// recordFeedback reports a suggestion outcome to the categorizer
+// Feedback failures only affect learning, so they are reported but not fatal
+func (m *Model) recordFeedback(suggestion *categorizer.Suggestion, accepted bool) {
+	if m.categorizer == nil {
+		return
+	}
+	if err := m.categorizer.Feedback(suggestion, accepted); err != nil {
+		m.message = fmt.Sprintf("Feedback not saved: %v", err)
+	}
+}
+
+// View renders the review view
+func (m Model) View() string {
+	var lines []string
+
+	tx := m.current()
+	if tx == nil {
+		lines = append(lines, theme.TitleStyle.Render("Review"), "")
+		if len(m.queue) == 0 {
+			lines = append(lines, theme.NormalTextStyle.Render("Nothing to review: no uncategorized or flagged transactions"))
+		} else {
+			lines = append(lines, theme.SuccessStyle.Render(fmt.Sprintf("Review complete: %d categorized, %d skipped", m.categorized, m.skipped)))
+		}
+		if m.message != "" {
+			lines = append(lines, "", theme.HighlightStyle.Render(m.message))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines, theme.TitleStyle.Render(fmt.Sprintf("Review (%d of %d)", m.index+1, len(m.queue))), "")
+
+	// Transaction details
+	header := fmt.Sprintf("%s %s", m.display.Date(tx.Date), tx.Flag)
+	if tx.Payee != "" {
+		header += fmt.Sprintf(" %q", tx.Payee)
+	}
+	header += fmt.Sprintf(" %q", tx.Narration)
+	lines = append(lines, theme.HighlightStyle.Render("  "+header))
+
+	target := categorizer.CategoryPosting(tx)
+	for i, posting := range tx.Postings {
+		amount := ""
+		if posting.Amount != nil {
+			amount = m.display.Amount(posting.Amount.Number, posting.Amount.Commodity)
+		}
+		marker := "  "
+		if i == target {
+			marker = "→ "
+		}
+		lines = append(lines, theme.NormalTextStyle.Render(fmt.Sprintf("  %s%s %15s", marker, components.PadRight(posting.Account, 50), amount)))
+	}
+	lines = append(lines, "")
+
+	// Suggestions
+	if len(m.suggestions) == 0 {
+		lines = append(lines, theme.MutedTextStyle.Render("  No suggestions (e: enter an account)"))
+	} else {
+		lines = append(lines, theme.TitleStyle.Render("  Suggestions"))
+		for i, suggestion := range m.suggestions {
+			line := fmt.Sprintf("  %d  %s %4.0f%%  %s", i+1, components.PadRight(suggestion.Category, 45), suggestion.Confidence*100, suggestion.Reason)
+			if i == 0 {
+				lines = append(lines, theme.SuccessStyle.Render(line))
+			} else {
+				lines = append(lines, theme.ListItemStyle.Render(line))
+			}
+		}
+	}
+	lines = append(lines, "")
+
+	if m.editing {
+		lines = append(lines, "  "+m.input.View())
+		lines = append(lines, theme.MutedTextStyle.Render("  tab:complete   enter:save   esc:cancel"))
+	} else {
+		lines = append(lines, theme.MutedTextStyle.Render("  a:accept   1-9:pick suggestion   e:edit   s:skip"))
+	}
+
+	if m.message != "" {
+		lines = append(lines, "", theme.HighlightStyle.Render("  "+m.message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetSize updates the review view size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m
+}
+
+// SetDisplay updates the review view display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m
+}
+
+// ShortHelp returns the view's main key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	k := m.keys
+	return []key.Binding{k.Accept, k.Pick, k.Edit, k.Skip}
+}
+
+// FullHelp returns all of the view's key bindings in columns, for the help row
+func (m Model) FullHelp() [][]key.Binding {
+	k := m.keys
+	return [][]key.Binding{
+		{k.Accept, k.Pick, k.Edit, k.Skip},
+	}
+}
+
+// KeyBindings returns the view's key bindings, for the help overlay
+func (m Model) KeyBindings() []key.Binding {
+	return slices.Concat(m.FullHelp()...)
+}
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/session"
+	"github.com/mmichie/lima/internal/ui/transactions"
+)
+
+// RestoreSession resumes where the last session on this ledger left off: the view,
+// and the transactions view's filter, period, sort order and cursor
+// Without a saved session the default view stays.
+func (m Model) RestoreSession() Model {
+	state, ok, err := session.Load(m.config.Files.StateFile, m.file.Path())
+	if err != nil {
+		return m.notifyf(components.LevelWarning, "Session not restored: %v", err)
+	}
+	if !ok {
+		return m
+	}
+
+	period, err := session.ParsePeriod(state.Period)
+	if err != nil {
+		m = m.notifyf(components.LevelWarning, "Session period not restored: %v", err)
+	}
+	m.transactions = m.transactions.Restore(transactions.State{
+		Filter:     state.Filter,
+		Period:     period,
+		Sort:       state.Sort,
+		Descending: state.Descending,
+		Cursor:     state.Cursor,
+	})
+	// A view chosen on the command line wins over the one left off in
+	if m.config.Overrides().View != "" {
+		return m
+	}
+	return m.showView(viewNamed(state.View))
+}
+
+// SaveSession records the view and the transactions view's state for the ledger,
+// to be restored on the next launch
+func (m Model) SaveSession() error {
+	txState := m.transactions.State()
+	return session.Save(m.config.Files.StateFile, m.file.Path(), session.State{
+		View:       viewNames[m.currentView],
+		Filter:     txState.Filter,
+		Period:     session.FormatPeriod(txState.Period),
+		Sort:       txState.Sort,
+		Descending: txState.Descending,
+		Cursor:     txState.Cursor,
+	})
+}
+
+// showView switches to a view, refreshing it as its key would
+func (m Model) showView(view ViewType) Model {
+	switch view {
+	case DashboardView:
+		return m.showDashboard()
+	case AccountsView:
+		return m.showAccounts()
+	case ReportsView:
+		return m.showReports()
+	case PatternsView:
+		return m.showPatterns()
+	case ReviewView:
+		return m.startReview()
+	case BudgetsView:
+		return m.showBudgets()
+	case TagsView:
+		return m.showTags()
+	case ProblemsView:
+		return m.showProblems()
+	}
+	m.currentView = view
+	return m
+}
@@ -0,0 +1,154 @@
+// Package session keeps the UI state of each ledger between runs: the view shown
+// and the transactions view's filter, period, sort order and cursor
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// maxLedgers is how many ledgers the state file remembers
+const maxLedgers = 20
+
+// State is where a session on one ledger left off
+type State struct {
+	View string `yaml:"view"`
+
+	// The transactions view; Period is "" for all time, else as FormatPeriod writes it
+	Filter     string `yaml:"filter,omitempty"`
+	Period     string `yaml:"period,omitempty"`
+	Sort       string `yaml:"sort,omitempty"`
+	Descending bool   `yaml:"descending,omitempty"`
+	Cursor     int    `yaml:"cursor,omitempty"`
+
+	Saved time.Time `yaml:"saved"`
+}
+
+// stateFile is the layout of the state file: states keyed by absolute ledger path
+type stateFile struct {
+	Ledgers map[string]State `yaml:"ledgers"`
+}
+
+// load reads the state file; a missing one is empty
+func load(path string) (stateFile, error) {
+	var file stateFile
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return file, fmt.Errorf("failed to read session state: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("failed to parse session state %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Load returns the state saved for a ledger in the state file at path
+// ok is false when there is none; a missing state file is not an error.
+func Load(path, ledger string) (state State, ok bool, err error) {
+	if path == "" {
+		return State{}, false, nil
+	}
+	abs, err := filepath.Abs(ledger)
+	if err != nil {
+		return State{}, false, fmt.Errorf("failed to resolve %s: %w", ledger, err)
+	}
+	file, err := load(config.ExpandHome(path))
+	if err != nil {
+		return State{}, false, err
+	}
+	state, ok = file.Ledgers[abs]
+	return state, ok, nil
+}
+
+// Save records a ledger's state in the state file at path, stamped with the time
+// The least recently saved ledgers are forgotten beyond maxLedgers.
+func Save(path, ledger string, state State) error {
+	if path == "" {
+		return nil
+	}
+	abs, err := filepath.Abs(ledger)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", ledger, err)
+	}
+	path = config.ExpandHome(path)
+	file, err := load(path)
+	if err != nil {
+		return err
+	}
+	if file.Ledgers == nil {
+		file.Ledgers = make(map[string]State)
+	}
+	state.Saved = time.Now()
+	file.Ledgers[abs] = state
+
+	if len(file.Ledgers) > maxLedgers {
+		ledgers := make([]string, 0, len(file.Ledgers))
+		for ledger := range file.Ledgers {
+			ledgers = append(ledgers, ledger)
+		}
+		sort.Slice(ledgers, func(i, j int) bool {
+			return file.Ledgers[ledgers[i]].Saved.After(file.Ledgers[ledgers[j]].Saved)
+		})
+		for _, ledger := range ledgers[maxLedgers:] {
+			delete(file.Ledgers, ledger)
+		}
+	}
+
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode session state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create session state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session state: %w", err)
+	}
+	return nil
+}
+
+// Period units as written in the state file
+var periodUnits = map[beancount.PeriodUnit]string{
+	beancount.PeriodMonth:   "month",
+	beancount.PeriodQuarter: "quarter",
+	beancount.PeriodYear:    "year",
+}
+
+// FormatPeriod writes a period as its unit and first day, e.g. "month 2024-03-01"
+// All time is "".
+func FormatPeriod(period beancount.Period) string {
+	unit, ok := periodUnits[period.Unit]
+	if !ok {
+		return ""
+	}
+	return unit + " " + period.Start.Format("2006-01-02")
+}
+
+// ParsePeriod reads a period written by FormatPeriod
+func ParsePeriod(s string) (beancount.Period, error) {
+	if s == "" {
+		return beancount.AllTime(), nil
+	}
+	name, date, _ := strings.Cut(s, " ")
+	start, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return beancount.AllTime(), fmt.Errorf("invalid period %q: %w", s, err)
+	}
+	for unit, unitName := range periodUnits {
+		if unitName == name {
+			return beancount.PeriodContaining(unit, start), nil
+		}
+	}
+	return beancount.AllTime(), fmt.Errorf("invalid period %q", s)
+}
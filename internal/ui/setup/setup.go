@@ -0,0 +1,393 @@
+// Package setup implements the first-run wizard, which asks for the ledger, the
+// operating currency and the categorization settings, then writes the initial config
+// and a patterns skeleton
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/fileopen"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// step is a page of the wizard
+type step int
+
+const (
+	stepLedger step = iota
+	stepCurrency
+	stepCategorize
+	stepLearn
+	stepConfirm
+)
+
+// commodityRegex matches a beancount commodity name, e.g. USD or VTI
+var commodityRegex = regexp.MustCompile(`^[A-Z]([A-Z0-9'._-]*[A-Z0-9])?$`)
+
+// PatternsSkeleton is written as the patterns file when there is none yet
+const PatternsSkeleton = `# Categorization patterns: each matches transactions by payee or narration and
+# suggests the account to post them to. Lima adds patterns as you categorize.
+version: "2"
+patterns: []
+# Example:
+#   - id: coffee
+#     name: Coffee shops
+#     pattern: "STARBUCKS|PEET'S"
+#     category: Expenses:Food:Coffee
+#     confidence: 0.9
+`
+
+// Model is the setup wizard; once the config is written it hands over to the model
+// next builds for it
+type Model struct {
+	path string
+	next func(*config.Config) tea.Model
+
+	step       step
+	ledger     components.TextInput
+	currency   components.TextInput
+	categorize bool
+	learn      bool
+
+	// matches are the candidates of the last ambiguous completion
+	matches []string
+
+	err    error
+	width  int
+	height int
+	sized  bool
+}
+
+// New creates the wizard writing the config to path, with the ledger prefilled
+func New(path, ledger string, next func(*config.Config) tea.Model) Model {
+	input := components.NewTextInput("Ledger").SetValue(ledger).Focus()
+	input.Placeholder = "~/finances/main.beancount"
+	currency := components.NewTextInput("Currency")
+	currency.Placeholder = "USD"
+	return Model{
+		path:       path,
+		next:       next,
+		ledger:     input,
+		currency:   currency,
+		categorize: true,
+		learn:      true,
+	}
+}
+
+// keyMap defines key bindings for the wizard
+type keyMap struct {
+	Next     key.Binding
+	Back     key.Binding
+	Complete key.Binding
+	Yes      key.Binding
+	No       key.Binding
+	Toggle   key.Binding
+	Quit     key.Binding
+}
+
+var keys = keyMap{
+	Next:     key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "next")),
+	Back:     key.NewBinding(key.WithKeys("esc", "shift+tab"), key.WithHelp("esc", "back")),
+	Complete: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "complete")),
+	Yes:      key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "yes")),
+	No:       key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "no")),
+	Toggle:   key.NewBinding(key.WithKeys(" ", "left", "right"), key.WithHelp("space", "toggle")),
+	Quit:     key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+}
+
+// Init does nothing; the wizard waits for input
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages; once the config is written the next model takes over,
+// sized to the screen
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height, m.sized = msg.Width, msg.Height, true
+
+	case tea.KeyMsg:
+		return m.updateKey(msg)
+	}
+	return m, nil
+}
+
+// updateKey handles a key on the current step
+func (m Model) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if key.Matches(msg, keys.Quit) {
+		return m, tea.Quit
+	}
+	m.err = nil
+
+	switch {
+	case key.Matches(msg, keys.Back):
+		if m.step == stepLedger {
+			return m, tea.Quit
+		}
+		return m.back(), nil
+
+	case key.Matches(msg, keys.Next):
+		return m.forward()
+	}
+
+	switch m.step {
+	case stepLedger:
+		if key.Matches(msg, keys.Complete) {
+			var value string
+			value, m.matches = fileopen.CompletePath(m.ledger.Value())
+			m.ledger = m.ledger.SetValue(value)
+			return m, nil
+		}
+		m.matches = nil
+		m.ledger, _ = m.ledger.Update(msg)
+
+	case stepCurrency:
+		m.currency, _ = m.currency.Update(msg)
+		if upper := strings.ToUpper(m.currency.Value()); upper != m.currency.Value() {
+			m.currency = m.currency.SetValue(upper)
+		}
+
+	case stepCategorize, stepLearn:
+		answer := &m.categorize
+		if m.step == stepLearn {
+			answer = &m.learn
+		}
+		switch {
+		case key.Matches(msg, keys.Yes):
+			*answer = true
+			return m.forward()
+		case key.Matches(msg, keys.No):
+			*answer = false
+			return m.forward()
+		case key.Matches(msg, keys.Toggle):
+			*answer = !*answer
+		}
+	}
+	return m, nil
+}
+
+// forward checks the current step's answer and moves to the next step, or writes
+// the config on the last one
+func (m Model) forward() (tea.Model, tea.Cmd) {
+	switch m.step {
+	case stepLedger:
+		if err := checkLedger(m.Ledger()); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.ledger = m.ledger.Blur()
+		m.currency = m.currency.Focus()
+
+	case stepCurrency:
+		if currency := m.Currency(); currency != "" && !commodityRegex.MatchString(currency) {
+			m.err = fmt.Errorf("%s is not a commodity name like USD or EUR", currency)
+			return m, nil
+		}
+		m.currency = m.currency.Blur()
+
+	case stepCategorize:
+		if !m.categorize {
+			m.step = stepConfirm
+			return m, nil
+		}
+
+	case stepConfirm:
+		return m.finish()
+	}
+	m.step++
+	return m, nil
+}
+
+// back returns to the previous step
+func (m Model) back() Model {
+	m.step--
+	if m.step == stepLearn && !m.categorize {
+		m.step = stepCategorize
+	}
+	switch m.step {
+	case stepLedger:
+		m.currency = m.currency.Blur()
+		m.ledger = m.ledger.Focus()
+	case stepCurrency:
+		m.currency = m.currency.Focus()
+	}
+	return m
+}
+
+// checkLedger reports why a path is not a ledger that can be opened
+func checkLedger(path string) error {
+	if path == "" {
+		return errors.New("enter the path of your beancount ledger")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no file at %s", path)
+		}
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
+
+// Ledger returns the chosen ledger, with ~ expanded and made absolute
+func (m Model) Ledger() string {
+	path := config.ExpandHome(strings.TrimSpace(m.ledger.Value()))
+	if abs, err := filepath.Abs(path); err == nil && path != "" {
+		return abs
+	}
+	return path
+}
+
+// Currency returns the chosen operating currency, or "" for none
+func (m Model) Currency() string {
+	return strings.TrimSpace(m.currency.Value())
+}
+
+// Config returns the configuration the answers make
+func (m Model) Config() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Files.DefaultLedger = m.Ledger()
+	cfg.Amounts.Currency = m.Currency()
+	cfg.Categorization.Enabled = m.categorize
+	cfg.Categorization.LearnFromEdits = m.categorize && m.learn
+	return cfg
+}
+
+// finish writes the config and, when missing, the patterns skeleton, then hands over
+func (m Model) finish() (tea.Model, tea.Cmd) {
+	cfg := m.Config()
+	if err := cfg.Save(m.path); err != nil {
+		m.err = err
+		return m, nil
+	}
+	if err := writeSkeleton(cfg.Files.PatternsFile); err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	next := m.next(cfg)
+	cmds := []tea.Cmd{next.Init()}
+	if m.sized {
+		var cmd tea.Cmd
+		next, cmd = next.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+		cmds = append(cmds, cmd)
+	}
+	return next, tea.Batch(cmds...)
+}
+
+// writeSkeleton writes an empty, commented patterns file unless one exists
+func writeSkeleton(path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create patterns directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(PatternsSkeleton), 0644); err != nil {
+		return fmt.Errorf("failed to write patterns file: %w", err)
+	}
+	return nil
+}
+
+// View renders the current step
+func (m Model) View() string {
+	width := max(40, m.width)
+	lines := []string{
+		theme.TitleStyle.Width(width).Render(components.PadRight(fmt.Sprintf("Welcome to Lima - setup %d/4", m.page()), width)),
+		"",
+	}
+	normal := func(text string) { lines = append(lines, theme.NormalTextStyle.Render("  "+text)) }
+	muted := func(text string) { lines = append(lines, theme.MutedTextStyle.Render("  "+text)) }
+
+	switch m.step {
+	case stepLedger:
+		normal("Which beancount ledger should Lima open?")
+		lines = append(lines, "", "  "+m.ledger.SetWidth(max(20, width-12)).View())
+		if len(m.matches) > 0 {
+			muted(strings.Join(m.matches, "  "))
+		}
+
+	case stepCurrency:
+		normal("Operating currency report and dashboard totals are converted into,")
+		normal("using the ledger's price directives. Leave it empty to show each commodity.")
+		lines = append(lines, "", "  "+m.currency.SetWidth(max(20, width-12)).View())
+
+	case stepCategorize:
+		normal("Suggest accounts for uncategorized transactions?")
+		lines = append(lines, "", "  "+toggle(m.categorize))
+
+	case stepLearn:
+		normal("Learn new patterns from the categories you pick?")
+		lines = append(lines, "", "  "+toggle(m.learn))
+
+	case stepConfirm:
+		cfg := m.Config()
+		currency := cfg.Amounts.Currency
+		if currency == "" {
+			currency = "(each commodity)"
+		}
+		learn := "no"
+		if cfg.Categorization.LearnFromEdits {
+			learn = "yes"
+		}
+		categorize := "no"
+		if cfg.Categorization.Enabled {
+			categorize = "yes"
+		}
+		normal("Ledger:          " + cfg.Files.DefaultLedger)
+		normal("Currency:        " + currency)
+		normal("Categorization:  " + categorize)
+		normal("Learning:        " + learn)
+		lines = append(lines, "")
+		muted("Config:          " + m.path)
+		muted("Patterns:        " + cfg.Files.PatternsFile)
+	}
+
+	if m.err != nil {
+		lines = append(lines, "", theme.ErrorStyle.Render("  "+m.err.Error()))
+	}
+
+	lines = append(lines, "")
+	switch m.step {
+	case stepLedger:
+		muted("tab:complete   enter:next   esc:quit")
+	case stepCategorize, stepLearn:
+		muted("y/n:answer   space:toggle   enter:next   esc:back")
+	case stepConfirm:
+		muted("enter:write config and open the ledger   esc:back")
+	default:
+		muted("enter:next   esc:back")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// page returns the step's number as shown, counting the learning question with
+// categorization
+func (m Model) page() int {
+	return min(int(m.step), int(stepLearn)) + 1
+}
+
+// toggle renders a yes/no answer with the chosen one highlighted
+func toggle(on bool) string {
+	yes, no := theme.NormalTextStyle.Render(" yes "), theme.SelectedItemStyle.Render(" no ")
+	if on {
+		yes, no = theme.SelectedItemStyle.Render(" yes "), theme.NormalTextStyle.Render(" no ")
+	}
+	return yes + " " + no
+}
@@ -0,0 +1,146 @@
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// nextModel records the config and screen size handed over by the wizard
+type nextModel struct {
+	cfg    *config.Config
+	width  int
+	height int
+}
+
+func (m nextModel) Init() tea.Cmd { return nil }
+func (m nextModel) View() string  { return "" }
+
+func (m nextModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width, m.height = size.Width, size.Height
+	}
+	return m, nil
+}
+
+// press sends keys to the wizard, returning whichever model is current afterwards
+func press(t *testing.T, m tea.Model, keys ...tea.KeyMsg) tea.Model {
+	t.Helper()
+	for _, k := range keys {
+		m, _ = m.Update(k)
+	}
+	return m
+}
+
+func typed(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestSetupWizard(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	ledger := filepath.Join(dir, "main.beancount")
+	if err := os.WriteFile(ledger, []byte("2024-01-01 open Assets:Checking\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config", "config.yaml")
+
+	var m tea.Model = New(path, "", func(cfg *config.Config) tea.Model { return nextModel{cfg: cfg} })
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	// The ledger must exist and be a file
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	m = press(t, m, typed(filepath.Join(dir, "missing.beancount")), enter)
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "no file at") {
+		t.Errorf("expected a missing ledger to be refused:\n%s", view)
+	}
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyCtrlU}, typed(dir), enter)
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "is a directory") {
+		t.Errorf("expected a directory to be refused:\n%s", view)
+	}
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyCtrlU}, typed(ledger), enter)
+	if m.(Model).step != stepCurrency {
+		t.Fatalf("expected the currency step, got %v", m.(Model).step)
+	}
+
+	// Currencies are upper-cased and must be commodity names
+	m = press(t, m, typed("eu-"), enter)
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "EU- is not a commodity name") {
+		t.Errorf("expected an invalid currency to be refused:\n%s", view)
+	}
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyBackspace}, typed("r"), enter)
+
+	// Declining categorization skips the learning question; going back returns to it
+	m = press(t, m, typed("n"))
+	if m.(Model).step != stepConfirm {
+		t.Fatalf("expected the summary after declining categorization, got %v", m.(Model).step)
+	}
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = press(t, m, typed("y"), typed("n"))
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"Ledger:          " + ledger, "Currency:        EUR", "Categorization:  yes", "Learning:        no", path} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the summary:\n%s", want, view)
+		}
+	}
+
+	// Writing the config hands over to the next model at the screen size
+	m = press(t, m, enter)
+	next, ok := m.(nextModel)
+	if !ok {
+		t.Fatalf("expected the next model to take over, got %T:\n%s", m, ansi.Strip(m.View()))
+	}
+	if next.width != 100 || next.height != 30 {
+		t.Errorf("expected the screen size handed over, got %dx%d", next.width, next.height)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load the written config: %v", err)
+	}
+	if cfg.Files.DefaultLedger != ledger || cfg.Amounts.Currency != "EUR" || !cfg.Categorization.Enabled || cfg.Categorization.LearnFromEdits {
+		t.Errorf("unexpected config written: %+v %+v", cfg.Files, cfg.Categorization)
+	}
+	if next.cfg.Files.PatternsFile != cfg.Files.PatternsFile {
+		t.Error("expected the written config handed over")
+	}
+
+	// The patterns skeleton loads as an empty set of patterns
+	cat, err := categorizer.New(cfg)
+	if err != nil {
+		t.Fatalf("failed to load the patterns skeleton: %v", err)
+	}
+	if cat.PatternCount() != 0 {
+		t.Errorf("expected no patterns, got %d", cat.PatternCount())
+	}
+}
+
+func TestSetupWizardKeepsPatterns(t *testing.T) {
+	dir := t.TempDir()
+	patterns := filepath.Join(dir, "patterns.yaml")
+	if err := os.WriteFile(patterns, []byte("patterns: []\n# mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSkeleton(patterns); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(patterns); !strings.Contains(string(data), "# mine") {
+		t.Error("expected an existing patterns file to be kept")
+	}
+
+	// esc on the first step leaves without writing anything
+	path := filepath.Join(dir, "config.yaml")
+	m := New(path, "", func(cfg *config.Config) tea.Model { return nextModel{cfg: cfg} })
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc}); cmd == nil {
+		t.Error("expected esc to quit")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected no config written when setup is cancelled")
+	}
+}
@@ -0,0 +1,372 @@
+package tags
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+)
+
+// keyMap defines key bindings for the tags view
+type keyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	PageUp    key.Binding
+	PageDown  key.Binding
+	Top       key.Binding
+	Bottom    key.Binding
+	Mark      key.Binding
+	Clear     key.Binding
+	DrillDown key.Binding
+}
+
+// newKeyMap creates the key bindings, taking navigation keys from the config
+func newKeyMap(cfg config.KeybindingsConfig) keyMap {
+	return keyMap{
+		Up:       components.Binding(cfg.Up, "up"),
+		Down:     components.Binding(cfg.Down, "down"),
+		PageUp:   components.Binding(cfg.PageUp, "page up"),
+		PageDown: components.Binding(cfg.PageDown, "page down"),
+		Top:      components.Binding(cfg.Top, "top"),
+		Bottom:   components.Binding(cfg.Bottom, "bottom"),
+		Mark: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "mark tag"),
+		),
+		Clear:     components.Binding(cfg.Back, "clear marks"),
+		DrillDown: components.Binding(cfg.Select, "transactions"),
+	}
+}
+
+// FixedKeys returns the keys the view handles whatever the config binds, by what they do
+func FixedKeys() map[string]string {
+	return components.FixedKeys(newKeyMap(config.KeybindingsConfig{}))
+}
+
+// DrillDownMsg asks the root model to show the transactions carrying every one of
+// Tags, or those with no tags at all when Untagged is set
+type DrillDownMsg struct {
+	Tags     []string
+	Untagged bool
+}
+
+// Query returns the transaction filter for the drill-down
+func (d DrillDownMsg) Query() string {
+	if d.Untagged {
+		return "-tag:*"
+	}
+	terms := make([]string, len(d.Tags))
+	for i, tag := range d.Tags {
+		terms[i] = "tag:" + tag
+	}
+	return strings.Join(terms, " ")
+}
+
+// tagRow is a tag and how it is used
+type tagRow struct {
+	tag   string
+	count int
+	last  time.Time
+}
+
+// Model represents the tags view: every tag with its transaction count, and a
+// last row for untagged transactions
+type Model struct {
+	file    *beancount.File
+	width   int
+	height  int
+	display components.Display
+	keys    keyMap
+
+	transactions []*beancount.Transaction
+	err          string
+
+	// rows are the tags, most used first; the untagged row follows them
+	rows     []tagRow
+	untagged int
+
+	// marked tags are drilled into together, showing the transactions carrying all of them
+	marked []string
+
+	// List state, over the tag rows and the untagged row
+	list components.Scroller
+}
+
+// New creates a new tags model
+func New(file *beancount.File, keys config.KeybindingsConfig) Model {
+	m := Model{file: file, keys: newKeyMap(keys)}
+	return m.Reload()
+}
+
+// SetKeys rebinds the view's keys, e.g. after the config is reloaded
+func (m Model) SetKeys(keys config.KeybindingsConfig) Model {
+	m.keys = newKeyMap(keys)
+	return m
+}
+
+// Reload re-reads the ledger, keeping the marks that still exist and the selection
+func (m Model) Reload() Model {
+	var selected string
+	if m.list.Cursor() < len(m.rows) {
+		selected = m.rows[m.list.Cursor()].tag
+	}
+
+	m.err = ""
+	transactions, err := m.file.AllTransactions()
+	if err != nil {
+		m.err = err.Error()
+	}
+	m.transactions = transactions
+	m = m.count()
+
+	m.marked = slices.DeleteFunc(m.marked, func(tag string) bool {
+		return !slices.ContainsFunc(m.rows, func(row tagRow) bool { return row.tag == tag })
+	})
+	m = m.scroll()
+	for i, row := range m.rows {
+		if row.tag == selected {
+			m.list = m.list.Select(i)
+		}
+	}
+	return m
+}
+
+// count tallies the transactions carrying each tag and those carrying none
+func (m Model) count() Model {
+	byTag := make(map[string]*tagRow)
+	m.untagged = 0
+	for _, tx := range m.transactions {
+		if len(tx.Tags) == 0 {
+			m.untagged++
+			continue
+		}
+		for _, tag := range uniqueTags(tx) {
+			row, ok := byTag[tag]
+			if !ok {
+				row = &tagRow{tag: tag}
+				byTag[tag] = row
+			}
+			row.count++
+			if tx.Date.After(row.last) {
+				row.last = tx.Date
+			}
+		}
+	}
+
+	m.rows = m.rows[:0:0]
+	for _, row := range byTag {
+		m.rows = append(m.rows, *row)
+	}
+	sort.Slice(m.rows, func(i, j int) bool {
+		if m.rows[i].count != m.rows[j].count {
+			return m.rows[i].count > m.rows[j].count
+		}
+		return m.rows[i].tag < m.rows[j].tag
+	})
+	return m
+}
+
+// uniqueTags returns a transaction's tags without repeats
+func uniqueTags(tx *beancount.Transaction) []string {
+	var tags []string
+	for _, tag := range tx.Tags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// intersection counts the transactions carrying every marked tag
+func (m Model) intersection() int {
+	n := 0
+	for _, tx := range m.transactions {
+		if !slices.ContainsFunc(m.marked, func(tag string) bool { return !slices.Contains(tx.Tags, tag) }) {
+			n++
+		}
+	}
+	return n
+}
+
+// onUntagged reports whether the cursor is on the untagged row
+func (m Model) onUntagged() bool {
+	return m.list.Cursor() == len(m.rows)
+}
+
+// listHeight returns how many rows fit between the header and the marks line
+func (m Model) listHeight() int {
+	return max(1, m.height-6+m.display.Spared(2))
+}
+
+// scroll keeps the cursor on a row and inside the visible window
+func (m Model) scroll() Model {
+	m.list = m.list.Fit(len(m.rows)+1, m.listHeight())
+	return m
+}
+
+// Init initializes the tags view
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles messages
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		m.list = m.list.Up()
+
+	case key.Matches(keyMsg, m.keys.Down):
+		m.list = m.list.Down()
+
+	case key.Matches(keyMsg, m.keys.PageUp):
+		m.list = m.list.PageUp(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.PageDown):
+		m.list = m.list.PageDown(m.display.PageSize)
+
+	case key.Matches(keyMsg, m.keys.Top):
+		m.list = m.list.Top()
+
+	case key.Matches(keyMsg, m.keys.Bottom):
+		m.list = m.list.Bottom()
+
+	case key.Matches(keyMsg, m.keys.Mark):
+		// Untagged transactions share no tag with others, so that row can't be marked
+		if m.onUntagged() {
+			break
+		}
+		tag := m.rows[m.list.Cursor()].tag
+		if i := slices.Index(m.marked, tag); i >= 0 {
+			m.marked = slices.Delete(m.marked, i, i+1)
+		} else {
+			m.marked = append(m.marked, tag)
+		}
+		m.list = m.list.Down()
+
+	case key.Matches(keyMsg, m.keys.Clear):
+		m.marked = nil
+
+	case key.Matches(keyMsg, m.keys.DrillDown):
+		msg := DrillDownMsg{Tags: m.marked}
+		switch {
+		case len(m.marked) > 0:
+		case m.onUntagged():
+			msg.Untagged = true
+		default:
+			msg.Tags = []string{m.rows[m.list.Cursor()].tag}
+		}
+		return m, func() tea.Msg { return msg }
+	}
+
+	return m.scroll(), nil
+}
+
+// View renders the tags with their counts
+func (m Model) View() string {
+	if m.width == 0 {
+		return theme.NormalTextStyle.Render("Loading tags...")
+	}
+
+	var lines []string
+
+	// Title - fill full width
+	titleText := fmt.Sprintf("Tags (%d)", len(m.rows))
+	titlePadded := components.PadRight(titleText, m.width)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(titlePadded))
+
+	if m.err != "" {
+		lines = append(lines, theme.ErrorStyle.Render("Failed to read transactions: "+m.err))
+	}
+
+	lines = append(lines, theme.HighlightStyle.Width(m.width).Render(m.formatRow("  ", "Tag", "Transactions", "Last used")))
+	lines = append(lines, m.display.Rule(m.width)...)
+
+	start, end := m.list.Window()
+	for i := start; i < end; i++ {
+		var line string
+		if i == len(m.rows) {
+			line = m.formatRow("  ", "(untagged)", fmt.Sprint(m.untagged), "")
+		} else {
+			row := m.rows[i]
+			mark := "  "
+			if slices.Contains(m.marked, row.tag) {
+				mark = "✓ "
+			}
+			line = m.formatRow(mark, "#"+row.tag, fmt.Sprint(row.count), m.display.Date(row.last))
+		}
+
+		switch {
+		case i == m.list.Cursor():
+			lines = append(lines, theme.SelectedItemStyle.Width(m.width).Render(line))
+		case i == len(m.rows):
+			lines = append(lines, theme.MutedTextStyle.Width(m.width).Render(line))
+		default:
+			lines = append(lines, theme.ListItemStyle.Width(m.width).Render(line))
+		}
+	}
+
+	// The transactions the marked tags select
+	lines = append(lines, m.display.Rule(m.width)...)
+	if len(m.marked) == 0 {
+		lines = append(lines, theme.MutedTextStyle.Render(" Mark tags with x to show the transactions carrying all of them"))
+	} else {
+		marked := "#" + strings.Join(m.marked, " #")
+		lines = append(lines, theme.HighlightStyle.Render(fmt.Sprintf(" %s: %d transactions (enter shows them)", marked, m.intersection())))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// formatRow lays out a mark, a tag and right-aligned count and date columns
+func (m Model) formatRow(mark, tag, count, last string) string {
+	columns := fmt.Sprintf("%14s  %-*s  ", count, m.display.DateWidth(), last)
+	room := max(0, m.width-components.Width(columns)-3)
+	return " " + mark + components.Fit(tag, room, "…") + columns
+}
+
+// SetSize updates the tags view size
+func (m Model) SetSize(width, height int) Model {
+	m.width = width
+	m.height = height
+	return m.scroll()
+}
+
+// SetDisplay updates the tags view display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m.SetSize(m.width, m.height)
+}
+
+// ShortHelp returns the view's main key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	k := m.keys
+	return []key.Binding{k.DrillDown, k.Mark, k.Clear}
+}
+
+// FullHelp returns all of the view's key bindings in columns, for the help row
+func (m Model) FullHelp() [][]key.Binding {
+	k := m.keys
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Top, k.Bottom},
+		{k.DrillDown, k.Mark, k.Clear},
+	}
+}
+
+// KeyBindings returns the view's key bindings, for the help overlay
+func (m Model) KeyBindings() []key.Binding {
+	return slices.Concat(m.FullHelp()...)
+}
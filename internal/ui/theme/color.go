@@ -0,0 +1,104 @@
+package theme
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// rgb is a color's red, green and blue components, 0-255 each
+type rgb struct {
+	r, g, b int
+}
+
+// ansi16 are the 16 basic terminal colors as VGA text mode drew them
+// The TP7 palette is made of them, so it keeps its look on 16-color terminals.
+var ansi16 = []rgb{
+	{0x00, 0x00, 0x00}, {0xAA, 0x00, 0x00}, {0x00, 0xAA, 0x00}, {0xAA, 0x55, 0x00},
+	{0x00, 0x00, 0xAA}, {0xAA, 0x00, 0xAA}, {0x00, 0xAA, 0xAA}, {0xAA, 0xAA, 0xAA},
+	{0x55, 0x55, 0x55}, {0xFF, 0x55, 0x55}, {0x55, 0xFF, 0x55}, {0xFF, 0xFF, 0x55},
+	{0x55, 0x55, 0xFF}, {0xFF, 0x55, 0xFF}, {0x55, 0xFF, 0xFF}, {0xFF, 0xFF, 0xFF},
+}
+
+// ansi256 are the xterm colors 16-255: a 6x6x6 color cube and 24 grays
+var ansi256 = func() []rgb {
+	levels := []int{0x00, 0x5F, 0x87, 0xAF, 0xD7, 0xFF}
+	var colors []rgb
+	for _, r := range levels {
+		for _, g := range levels {
+			for _, b := range levels {
+				colors = append(colors, rgb{r, g, b})
+			}
+		}
+	}
+	for i := 0; i < 24; i++ {
+		gray := 8 + 10*i
+		colors = append(colors, rgb{gray, gray, gray})
+	}
+	return colors
+}()
+
+// parseHex reads a #RRGGBB color
+func parseHex(hex string) (rgb, bool) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return rgb{}, false
+	}
+	n, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return rgb{}, false
+	}
+	return rgb{int(n >> 16 & 0xFF), int(n >> 8 & 0xFF), int(n & 0xFF)}, true
+}
+
+// nearest returns the index of the color in colors closest to c
+// Ties go to the later color, so pure hues map to the bright variants.
+func nearest(c rgb, colors []rgb) int {
+	best, bestDistance := 0, -1
+	for i, o := range colors {
+		dr, dg, db := c.r-o.r, c.g-o.g, c.b-o.b
+		distance := dr*dr + dg*dg + db*db
+		if bestDistance < 0 || distance <= bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best
+}
+
+// color returns a hex color with its 256- and 16-color equivalents
+// lipgloss picks the one matching the terminal's color profile when rendering.
+func color(hex string) lipgloss.TerminalColor {
+	c, ok := parseHex(hex)
+	if !ok {
+		return lipgloss.Color(hex)
+	}
+	return lipgloss.CompleteColor{
+		TrueColor: hex,
+		ANSI256:   strconv.Itoa(16 + nearest(c, ansi256)),
+		ANSI:      strconv.Itoa(nearest(c, ansi16)),
+	}
+}
+
+// colorProfiles maps config.ColorModes to lipgloss color profiles
+var colorProfiles = map[string]termenv.Profile{
+	"truecolor": termenv.TrueColor,
+	"256":       termenv.ANSI256,
+	"16":        termenv.ANSI,
+	"none":      termenv.Ascii,
+}
+
+// renderer renders the styles in the configured color mode
+// It is the theme's own, so the mode leaves lipgloss's default renderer, and
+// whatever else renders with it, alone.
+var renderer = colorRenderer("auto")
+
+// colorRenderer returns a renderer for a color mode
+// "auto" and unknown modes detect what the terminal supports.
+func colorRenderer(mode string) *lipgloss.Renderer {
+	r := lipgloss.NewRenderer(os.Stdout)
+	if profile, ok := colorProfiles[mode]; ok {
+		r.SetColorProfile(profile)
+	}
+	return r
+}
@@ -0,0 +1,63 @@
+package theme
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/muesli/termenv"
+)
+
+func TestColorEquivalents(t *testing.T) {
+	tests := []struct {
+		hex     string
+		ansi    string
+		ansi256 string
+	}{
+		{TP7Blue, "4", "19"},
+		{TP7Cyan, "14", "51"},
+		{TP7White, "15", "231"},
+		{TP7DarkGray, "8", "240"},
+		{TP7Red, "1", "196"},
+		{"#1a1a1a", "0", "234"},
+		{"#56B4E9", "14", "74"},
+	}
+	for _, tt := range tests {
+		c, ok := color(tt.hex).(lipgloss.CompleteColor)
+		if !ok {
+			t.Fatalf("color(%s) is not a complete color", tt.hex)
+		}
+		if c.ANSI != tt.ansi || c.ANSI256 != tt.ansi256 || c.TrueColor != tt.hex {
+			t.Errorf("color(%s) = %+v, want ANSI %s and ANSI256 %s", tt.hex, c, tt.ansi, tt.ansi256)
+		}
+	}
+
+	if _, ok := color("#abc").(lipgloss.Color); !ok {
+		t.Error("expected colors that are not #RRGGBB left to lipgloss")
+	}
+}
+
+func TestColorMode(t *testing.T) {
+	defer Configure(config.ThemeConfig{})
+
+	Configure(config.ThemeConfig{ColorMode: "16"})
+	if got := ErrorStyle.Render("x"); !strings.Contains(got, "31") {
+		t.Errorf("expected red from the 16 basic colors, got %q", got)
+	}
+	if lipgloss.ColorProfile() == termenv.ANSI {
+		t.Error("expected the default renderer left alone")
+	}
+
+	// Cycling palettes keeps the mode
+	Apply(HighContrastPalette)
+	Apply(TP7Palette)
+	if got := ErrorStyle.Render("x"); !strings.Contains(got, "31") {
+		t.Errorf("expected red from the 16 basic colors after cycling, got %q", got)
+	}
+
+	Configure(config.ThemeConfig{ColorMode: "256"})
+	if got := ErrorStyle.Render("x"); !strings.Contains(got, "38;5;196") {
+		t.Errorf("expected a 256-color red, got %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package theme
+
+import "github.com/mmichie/lima/pkg/config"
+
+// LightPalette suits terminals with a light background
+var LightPalette = Palette{
+	Name:          "light",
+	Background:    "#FFFFFF",
+	AltBackground: "#EEEEEE",
+	Text:          "#1C1C1C",
+	Muted:         "#6C6C6C",
+	Accent:        "#005F87",
+	AccentText:    "#FFFFFF",
+	Highlight:     "#5F00AF",
+	Success:       "#007A00",
+	Warning:       "#AF5F00",
+	Error:         "#C00000",
+	Date:          "#00707A",
+
+	MenuBackground:       "#D0D0D0",
+	MenuText:             "#000000",
+	MenuActiveBackground: "#005F87",
+	MenuActiveText:       "#FFFFFF",
+}
+
+// HighContrastPalette is white on black, with colors from the Okabe-Ito set
+// that stay distinct under the common color vision deficiencies: positive and
+// negative amounts are blue and orange rather than green and red.
+var HighContrastPalette = Palette{
+	Name:          "high_contrast",
+	Background:    "#000000",
+	AltBackground: "#262626",
+	Text:          "#FFFFFF",
+	Muted:         "#BCBCBC",
+	Accent:        "#F0E442",
+	AccentText:    "#000000",
+	Highlight:     "#F0E442",
+	Success:       "#56B4E9",
+	Warning:       "#F0E442",
+	Error:         "#E69F00",
+	Date:          "#CC79A7",
+
+	MenuBackground:       "#FFFFFF",
+	MenuText:             "#000000",
+	MenuActiveBackground: "#F0E442",
+	MenuActiveText:       "#000000",
+}
+
+// Palettes are the built-in themes, in the order the theme key cycles through them
+var Palettes = []Palette{TP7Palette, LightPalette, HighContrastPalette}
+
+// ByName returns the built-in palette called name
+func ByName(name string) (Palette, bool) {
+	for _, p := range Palettes {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Palette{}, false
+}
+
+// configured is the palette chosen in the config, with its color overrides
+var configured = TP7Palette
+
+// Configure applies the theme from the config: the named built-in palette with
+// the configured colors laid over it, in the configured color mode. Unknown
+// names fall back to TP7.
+func Configure(cfg config.ThemeConfig) {
+	renderer = colorRenderer(cfg.ColorMode)
+	p, ok := ByName(cfg.Name)
+	if !ok {
+		p = TP7Palette
+	}
+	overrides := []struct {
+		color *string
+		value string
+	}{
+		{&p.Accent, cfg.Primary},
+		{&p.Highlight, cfg.Secondary},
+		{&p.Success, cfg.Success},
+		{&p.Warning, cfg.Warning},
+		{&p.Error, cfg.Error},
+		{&p.Muted, cfg.Muted},
+		{&p.Text, cfg.Text},
+		{&p.Background, cfg.Background},
+	}
+	for _, o := range overrides {
+		if o.value != "" {
+			*o.color = o.value
+		}
+	}
+	configured = p
+	Apply(p)
+}
+
+// Next returns the built-in palette after the current one
+// The configured palette keeps its color overrides when cycled back to.
+func Next() Palette {
+	next := Palettes[0]
+	for i, p := range Palettes {
+		if p.Name == current.Name {
+			next = Palettes[(i+1)%len(Palettes)]
+		}
+	}
+	if next.Name == configured.Name {
+		return configured
+	}
+	return next
+}
@@ -0,0 +1,118 @@
+package theme
+
+import "github.com/charmbracelet/lipgloss"
+
+// Palette assigns colors to the roles the styles are built from
+// Colors are hex strings, e.g. "#0000AA"; terminals without truecolor get
+// their nearest 256- or 16-color equivalents.
+type Palette struct {
+	Name string
+
+	Background    string // Screen and panel background
+	AltBackground string // Alternating rows and inactive inputs
+	Text          string // Primary text
+	Muted         string // Secondary text and borders
+	Accent        string // Titles, selection, status bar and bars
+	AccentText    string // Text drawn on the accent color
+	Highlight     string // Emphasized text
+	Success       string // Positive amounts and confirmations
+	Warning       string // Warnings
+	Error         string // Errors and negative amounts
+	Date          string // Dates
+
+	MenuBackground       string // Menu bar and buttons
+	MenuText             string
+	MenuActiveBackground string // Open menu entry
+	MenuActiveText       string
+}
+
+// current is the palette the styles were last built from
+var current Palette
+
+// Styles, rebuilt by Apply whenever the palette changes
+// Views reference them at render time, so a new theme shows on the next frame.
+var (
+	ScreenStyle           lipgloss.Style // Screen background
+	MenuBarStyle          lipgloss.Style // Menu bar (top of screen)
+	MenuItemActiveStyle   lipgloss.Style // Active menu item (selected/hovered)
+	MenuItemInactiveStyle lipgloss.Style // Inactive menu item
+	MenuHotkeyStyle       lipgloss.Style // Hotkey letter in a menu
+	MenuDropdownStyle     lipgloss.Style // Frame of an open menu
+	StatusBarStyle        lipgloss.Style // Status bar (bottom of screen)
+	BorderStyle           lipgloss.Style // Border for dialogs and panels
+	PanelStyle            lipgloss.Style // Double-line frame for widgets and overlays
+	TitleStyle            lipgloss.Style // Titles of dialogs and sections
+	NormalTextStyle       lipgloss.Style
+	MutedTextStyle        lipgloss.Style
+	SelectedItemStyle     lipgloss.Style // Selected item in a list (inverted colors)
+	ListItemStyle         lipgloss.Style
+	AlternateItemStyle    lipgloss.Style // Alternate list item (striping)
+	HighlightStyle        lipgloss.Style // Highlighted/focused element
+	SuccessStyle          lipgloss.Style
+	WarningStyle          lipgloss.Style
+	ErrorStyle            lipgloss.Style
+	DateStyle             lipgloss.Style
+	AmountStyle           lipgloss.Style // Neutral amount
+	AmountPositiveStyle   lipgloss.Style
+	AmountNegativeStyle   lipgloss.Style
+	InputStyle            lipgloss.Style // Focused input field
+	ButtonStyle           lipgloss.Style
+	ButtonFocusedStyle    lipgloss.Style
+	BarStyle              lipgloss.Style // Bar chart bars
+)
+
+func init() {
+	Apply(TP7Palette)
+}
+
+// Current returns the palette in use
+func Current() Palette {
+	return current
+}
+
+// Apply rebuilds every style from a palette, in the configured color mode
+func Apply(p Palette) {
+	current = p
+	bg := color(p.Background)
+	on := func(fg string) lipgloss.Style {
+		return renderer.NewStyle().Foreground(color(fg)).Background(bg)
+	}
+
+	ScreenStyle = on(p.Text)
+	MenuBarStyle = renderer.NewStyle().Background(color(p.MenuBackground)).Foreground(color(p.MenuText))
+	MenuItemActiveStyle = renderer.NewStyle().Background(color(p.MenuActiveBackground)).Foreground(color(p.MenuActiveText))
+	MenuItemInactiveStyle = MenuBarStyle
+	MenuHotkeyStyle = MenuBarStyle
+	MenuDropdownStyle = renderer.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(color(p.MenuText)).
+		BorderBackground(color(p.MenuBackground))
+	StatusBarStyle = renderer.NewStyle().Background(color(p.Accent)).Foreground(color(p.AccentText))
+	BorderStyle = renderer.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(color(p.Muted)).
+		Background(bg)
+	PanelStyle = renderer.NewStyle().
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(color(p.Accent)).
+		BorderBackground(bg).
+		Background(bg)
+	TitleStyle = on(p.Accent).Bold(true)
+	NormalTextStyle = on(p.Text)
+	MutedTextStyle = on(p.Muted)
+	SelectedItemStyle = StatusBarStyle
+	ListItemStyle = on(p.Text)
+	AlternateItemStyle = renderer.NewStyle().Foreground(color(p.Text)).Background(color(p.AltBackground))
+	HighlightStyle = on(p.Highlight).Bold(true)
+	SuccessStyle = on(p.Success)
+	WarningStyle = on(p.Warning)
+	ErrorStyle = on(p.Error)
+	DateStyle = on(p.Date)
+	AmountStyle = on(p.Text)
+	AmountPositiveStyle = on(p.Success)
+	AmountNegativeStyle = on(p.Error)
+	InputStyle = renderer.NewStyle().Foreground(color(p.AccentText)).Background(color(p.Accent))
+	ButtonStyle = MenuBarStyle.Padding(0, 2)
+	ButtonFocusedStyle = InputStyle.Padding(0, 2).Bold(true)
+	BarStyle = on(p.Accent)
+}
@@ -7,17 +7,17 @@ import "github.com/charmbracelet/lipgloss"
 
 // Color Constants - Classic TP7 Palette
 const (
-	TP7Blue       = "#0000AA" // Classic TP7 background blue
-	TP7DarkBlue   = "#000088" // Slightly darker blue for alternating rows
-	TP7Cyan       = "#00FFFF" // Menu bar and highlights
-	TP7Black      = "#000000" // Selection background (inverted)
-	TP7White      = "#FFFFFF" // Primary text
-	TP7LightGray  = "#AAAAAA" // Secondary text and borders
-	TP7DarkGray   = "#555555" // Muted text
-	TP7Yellow     = "#FFFF00" // Warnings and highlights
-	TP7Green      = "#00FF00" // Success/positive
-	TP7Red        = "#FF0000" // Errors/negative
-	TP7DarkCyan   = "#008888" // Subtle highlights
+	TP7Blue      = "#0000AA" // Classic TP7 background blue
+	TP7DarkBlue  = "#000088" // Slightly darker blue for alternating rows
+	TP7Cyan      = "#00FFFF" // Menu bar and highlights
+	TP7Black     = "#000000" // Selection background (inverted)
+	TP7White     = "#FFFFFF" // Primary text
+	TP7LightGray = "#AAAAAA" // Secondary text and borders
+	TP7DarkGray  = "#555555" // Muted text
+	TP7Yellow    = "#FFFF00" // Warnings and highlights
+	TP7Green     = "#00FF00" // Success/positive
+	TP7Red       = "#FF0000" // Errors/negative
+	TP7DarkCyan  = "#008888" // Subtle highlights
 )
 
 // Box Drawing Characters - TP7 style double-line borders
@@ -35,140 +35,26 @@ const (
 	BoxCross       = "╬"
 )
 
-// Base Styles
-var (
-	// Screen background
-	ScreenStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color(TP7Blue)).
-			Foreground(lipgloss.Color(TP7White))
-
-	// Menu bar style (top of screen) - TP7 style: light gray background, black text
-	MenuBarStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color(TP7LightGray)).
-			Foreground(lipgloss.Color(TP7Black)).
-			Bold(false)
-
-	// Active menu item (when selected/hovered) - inverted: black background, white text
-	MenuItemActiveStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color(TP7Black)).
-				Foreground(lipgloss.Color(TP7White)).
-				Bold(false)
-
-	// Inactive menu item - same as menu bar
-	MenuItemInactiveStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color(TP7LightGray)).
-				Foreground(lipgloss.Color(TP7Black)).
-				Bold(false)
-
-	// Hotkey letter in menu (underlined) - normal text, just underlined
-	MenuHotkeyStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7Black)).
-			Background(lipgloss.Color(TP7LightGray)).
-			Bold(false)
-
-	// Status bar style (bottom of screen)
-	StatusBarStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color(TP7Cyan)).
-			Foreground(lipgloss.Color(TP7Black)).
-			Bold(false)
-
-	// Border style for dialogs and panels
-	BorderStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color(TP7LightGray)).
-			Background(lipgloss.Color(TP7Blue))
-
-	// Title style for dialogs and sections
-	TitleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7Cyan)).
-			Background(lipgloss.Color(TP7Blue)).
-			Bold(true)
-
-	// Normal text
-	NormalTextStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7White)).
-			Background(lipgloss.Color(TP7Blue))
-
-	// Muted/secondary text
-	MutedTextStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7LightGray)).
-			Background(lipgloss.Color(TP7Blue))
-
-	// Selected item in a list (inverted colors)
-	SelectedItemStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color(TP7Cyan)).
-				Foreground(lipgloss.Color(TP7Black)).
-				Bold(false)
-
-	// Normal list item
-	ListItemStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7White)).
-			Background(lipgloss.Color(TP7Blue))
-
-	// Alternate list item (for striping/alternating rows)
-	AlternateItemStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color(TP7White)).
-				Background(lipgloss.Color(TP7DarkBlue))
-
-	// Highlighted/focused element
-	HighlightStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7Yellow)).
-			Background(lipgloss.Color(TP7Blue)).
-			Bold(true)
-
-	// Success/positive indicators
-	SuccessStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7Green)).
-			Background(lipgloss.Color(TP7Blue))
-
-	// Warning indicators
-	WarningStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7Yellow)).
-			Background(lipgloss.Color(TP7Blue))
-
-	// Error/negative indicators
-	ErrorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7Red)).
-			Background(lipgloss.Color(TP7Blue))
-
-	// Date/timestamp style
-	DateStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7DarkCyan)).
-			Background(lipgloss.Color(TP7Blue))
-
-	// Amount style (neutral)
-	AmountStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7White)).
-			Background(lipgloss.Color(TP7Blue))
-
-	// Positive amount
-	AmountPositiveStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color(TP7Green)).
-				Background(lipgloss.Color(TP7Blue))
-
-	// Negative amount
-	AmountNegativeStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color(TP7Red)).
-				Background(lipgloss.Color(TP7Blue))
-
-	// Input field style
-	InputStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7Black)).
-			Background(lipgloss.Color(TP7Cyan))
-
-	// Button style (normal)
-	ButtonStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color(TP7Black)).
-			Background(lipgloss.Color(TP7LightGray)).
-			Padding(0, 2)
-
-	// Button style (focused)
-	ButtonFocusedStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color(TP7Black)).
-				Background(lipgloss.Color(TP7Cyan)).
-				Padding(0, 2).
-				Bold(true)
-)
+// TP7Palette is the classic Turbo Pascal 7 look and the default theme
+var TP7Palette = Palette{
+	Name:          "tp7",
+	Background:    TP7Blue,
+	AltBackground: TP7DarkBlue,
+	Text:          TP7White,
+	Muted:         TP7LightGray,
+	Accent:        TP7Cyan,
+	AccentText:    TP7Black,
+	Highlight:     TP7Yellow,
+	Success:       TP7Green,
+	Warning:       TP7Yellow,
+	Error:         TP7Red,
+	Date:          TP7DarkCyan,
+
+	MenuBackground:       TP7LightGray,
+	MenuText:             TP7Black,
+	MenuActiveBackground: TP7Black,
+	MenuActiveText:       TP7White,
+}
 
 // RenderBox renders a TP7-style double-line box around content
 func RenderBox(title string, content string, width int) string {
@@ -0,0 +1,131 @@
+package transactions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mmichie/lima/internal/ui/components"
+)
+
+// Fixed column widths; description and account share the remaining space
+const (
+	flagWidth   = 1
+	amountWidth = 15
+	columnGap   = 2
+
+	// The compact layout leaves one space between columns
+	compactColumnGap = 1
+
+	minDescriptionWidth = 20
+	maxDescriptionWidth = 80
+	minAccountWidth     = 20
+	maxAccountWidth     = 60
+
+	// scrollStep is how many columns one horizontal scroll moves
+	scrollStep = 8
+)
+
+// columnSet is a set of columns that can be hidden
+type columnSet int
+
+const (
+	columnFlag columnSet = 1 << iota
+	columnAccount
+	columnAmount
+)
+
+// columnLayouts are the hidden column sets the columns key cycles through
+var columnLayouts = []struct {
+	hidden columnSet
+	name   string
+}{
+	{0, "all columns"},
+	{columnAccount, "no account"},
+	{columnFlag | columnAccount, "no flag, account"},
+	{columnAmount, "no amount"},
+}
+
+// layout holds the column widths of the table; zero means the column is hidden
+type layout struct {
+	date, flag, description, account, amount int
+	gap                                      int // Spaces between columns
+}
+
+// layout computes column widths for the view width
+// The description gets 45% of the free space and the account the rest, within
+// their limits; on a narrow terminal the table gets wider than the view and
+// scrolls horizontally.
+func (m Model) layout() layout {
+	hidden := columnLayouts[m.columns].hidden
+	// The date column fits the date format with two spare cells, none when compact
+	l := layout{date: m.display.DateWidth() + 2, flag: flagWidth, account: 1, amount: amountWidth, gap: columnGap}
+	if m.display.Compact {
+		l.date, l.gap = m.display.DateWidth(), compactColumnGap
+	}
+	if hidden&columnFlag != 0 {
+		l.flag = 0
+	}
+	if hidden&columnAccount != 0 {
+		l.account = 0
+	}
+	if hidden&columnAmount != 0 {
+		l.amount = 0
+	}
+
+	// Space left for description and account once the fixed columns and gaps are laid out
+	free := m.width - l.width()
+	if l.account > 0 {
+		free += l.account
+	}
+
+	if l.account == 0 {
+		l.description = max(minDescriptionWidth, free)
+		return l
+	}
+	l.description = max(minDescriptionWidth, min(maxDescriptionWidth, free*45/100))
+	l.account = max(minAccountWidth, min(maxAccountWidth, free-l.description))
+	// Space the account can't use goes back to the description
+	l.description = max(l.description, min(maxDescriptionWidth, free-l.account))
+	return l
+}
+
+// width returns the total width of a row
+func (l layout) width() int {
+	width := l.date + l.gap + l.description
+	for _, column := range []int{l.flag, l.account, l.amount} {
+		if column > 0 {
+			width += l.gap + column
+		}
+	}
+	return width
+}
+
+// row lays out one line of the table; text too long for its column is shortened
+// Widths are in terminal cells, so wide characters keep the columns aligned
+func (l layout) row(date, flag, description, account, amount string) string {
+	var b strings.Builder
+	gap := strings.Repeat(" ", l.gap)
+	fmt.Fprintf(&b, "%-*s", l.date, date)
+	if l.flag > 0 {
+		fmt.Fprintf(&b, "%s%-*s", gap, l.flag, flag)
+	}
+	b.WriteString(gap + components.Fit(description, l.description, "..."))
+	if l.account > 0 {
+		b.WriteString(gap + components.PadRight(components.TruncateStart(account, l.account, "..."), l.account))
+	}
+	if l.amount > 0 {
+		b.WriteString(gap + components.PadLeft(amount, l.amount))
+	}
+	return b.String()
+}
+
+// maxScroll returns the furthest the table can scroll right
+func (m Model) maxScroll() int {
+	return max(0, m.layout().width()-m.width)
+}
+
+// scroll moves the table horizontally by delta columns
+func (m Model) scroll(delta int) Model {
+	m.xOffset = max(0, min(m.xOffset+delta, m.maxScroll()))
+	return m
+}
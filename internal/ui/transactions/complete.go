@@ -0,0 +1,72 @@
+package transactions
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+)
+
+// searchCompleter ranks the ledger's payees and accounts by use for the search bar
+func (m Model) searchCompleter() components.Completer {
+	transactions, _ := m.file.AllTransactions()
+	now := time.Now()
+	payees := beancount.RankPayees(transactions, now)
+	accounts := beancount.RankAccounts(transactions, m.file.GetAccounts(), now)
+	return filterCompleter(payees, accounts)
+}
+
+// filterCompleter completes the last term of a search query: account: terms with
+// accounts and payee: terms with payees, quoting payees that contain spaces
+func filterCompleter(payees, accounts []string) components.Completer {
+	return func(query string) []string {
+		start := lastTermStart(query)
+		head, term := query[:start], query[start:]
+		var negate string
+		if strings.HasPrefix(term, "-") {
+			negate, term = "-", term[1:]
+		}
+
+		field, value, found := strings.Cut(term, ":")
+		if !found {
+			return nil
+		}
+		var candidates []string
+		switch strings.ToLower(field) {
+		case "account":
+			candidates = accounts
+		case "payee":
+			candidates = payees
+		default:
+			return nil
+		}
+
+		matches := components.FuzzyMatch(strings.Trim(value, `"`), candidates)
+		completions := make([]string, len(matches))
+		for i, match := range matches {
+			if strings.ContainsFunc(match, unicode.IsSpace) {
+				match = `"` + strings.ReplaceAll(match, `"`, "") + `"`
+			}
+			completions[i] = head + negate + field + ":" + match
+		}
+		return completions
+	}
+}
+
+// lastTermStart returns where the last term of a query starts, after the last
+// whitespace outside quotes
+func lastTermStart(query string) int {
+	start := 0
+	inQuotes := false
+	for i, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			start = i + len(string(r))
+		}
+	}
+	return start
+}
@@ -0,0 +1,173 @@
+package transactions
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/theme"
+)
+
+// linkedTransaction is a transaction sharing a link with the selected one
+type linkedTransaction struct {
+	index int // File index, as in rows
+	tx    *beancount.Transaction
+}
+
+// openLinks lists the other transactions sharing a link with the selected one
+func (m Model) openLinks() Model {
+	if m.count() == 0 {
+		return m
+	}
+	tx, err := m.transactionAt(m.list.Cursor())
+	if err != nil {
+		m.filterErr = err.Error()
+		return m
+	}
+
+	m.showingLinks = true
+	m.linkCursor = 0
+	m.linkSource = tx
+	m.linked = nil
+	if len(tx.Links) == 0 {
+		return m
+	}
+
+	transactions, err := m.file.AllTransactions()
+	if err != nil {
+		m.filterErr = err.Error()
+		m.showingLinks = false
+		return m
+	}
+	for i, other := range transactions {
+		if i == m.rows[m.list.Cursor()] {
+			continue
+		}
+		if len(sharedLinks(tx, other)) > 0 {
+			m.linked = append(m.linked, linkedTransaction{index: i, tx: other})
+		}
+	}
+	sort.SliceStable(m.linked, func(i, j int) bool {
+		return m.linked[i].tx.Date.Before(m.linked[j].tx.Date)
+	})
+	return m
+}
+
+// sharedLinks returns the links of tx that other carries too
+func sharedLinks(tx, other *beancount.Transaction) []string {
+	var shared []string
+	for _, link := range tx.Links {
+		if slices.Contains(other.Links, link) {
+			shared = append(shared, link)
+		}
+	}
+	return shared
+}
+
+// closeLinks hides the linked transactions overlay
+func (m Model) closeLinks() Model {
+	m.showingLinks = false
+	m.linked = nil
+	m.linkSource = nil
+	m.linkCursor = 0
+	return m
+}
+
+// updateLinks handles keys while the linked transactions overlay is open
+// Enter jumps to the selected transaction and closes the overlay
+func (m Model) updateLinks(msg tea.KeyMsg) Model {
+	switch {
+	case key.Matches(msg, m.keys.Back), msg.String() == "q", key.Matches(msg, m.keys.Links):
+		return m.closeLinks()
+
+	case key.Matches(msg, m.keys.Up):
+		if m.linkCursor > 0 {
+			m.linkCursor--
+		}
+
+	case key.Matches(msg, m.keys.Down):
+		if m.linkCursor < len(m.linked)-1 {
+			m.linkCursor++
+		}
+
+	case key.Matches(msg, m.keys.Enter):
+		if m.linkCursor < len(m.linked) {
+			index := m.linked[m.linkCursor].index
+			return m.closeLinks().jumpToIndex(index)
+		}
+		return m.closeLinks()
+	}
+	return m
+}
+
+// jumpToIndex moves the cursor to the transaction at a file index
+// When the filter or period hides it they are cleared so it can be shown.
+func (m Model) jumpToIndex(index int) Model {
+	pos := slices.Index(m.rows, index)
+	if pos < 0 {
+		m.search = m.search.SetValue("")
+		m.filter = nil
+		m.filterErr = ""
+		m.period = beancount.AllTime()
+		m = m.refreshRows()
+		pos = slices.Index(m.rows, index)
+	}
+	if pos >= 0 {
+		m.list = m.list.Select(pos)
+	}
+	return m.fitList()
+}
+
+// renderLinks renders the linked transactions overlay with TP7 styling
+func (m Model) renderLinks() string {
+	panelStyle := theme.PanelStyle.
+		Padding(1, 2).
+		Width(m.width - 4)
+
+	var lines []string
+	title := "Linked Transactions"
+	if m.linkSource != nil && len(m.linkSource.Links) > 0 {
+		title += " ^" + strings.Join(m.linkSource.Links, " ^")
+	}
+	lines = append(lines, theme.TitleStyle.Render(title))
+	lines = append(lines, "")
+
+	switch {
+	case m.linkSource == nil || len(m.linkSource.Links) == 0:
+		lines = append(lines, theme.NormalTextStyle.Render("This transaction has no links (^invoice-42)"))
+	case len(m.linked) == 0:
+		lines = append(lines, theme.NormalTextStyle.Render("No other transaction carries these links"))
+	default:
+		room := max(10, m.width-42-m.display.DateWidth())
+		for i, linked := range m.linked {
+			tx := linked.tx
+			amount := ""
+			if len(tx.Postings) > 0 && tx.Postings[0].Amount != nil {
+				amount = m.display.Amount(tx.Postings[0].Amount.Number, tx.Postings[0].Amount.Commodity)
+			}
+			line := fmt.Sprintf("%s  %s  %14s  %s",
+				m.display.Date(tx.Date),
+				components.PadRight(components.Fit(description(tx), room, "…"), room),
+				amount,
+				"^"+strings.Join(sharedLinks(m.linkSource, tx), " ^"))
+
+			if i == m.linkCursor {
+				line = theme.SelectedItemStyle.Render(" > " + line)
+			} else {
+				line = theme.ListItemStyle.Render("   " + line)
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, theme.MutedTextStyle.Render("j/k:navigate   enter:jump   esc:close"))
+
+	content := strings.Join(lines, "\n")
+	return panelStyle.Render(content)
+}
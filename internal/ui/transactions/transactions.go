@@ -1,71 +1,162 @@
 package transactions
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/mmichie/lima/internal/beancount"
 	"github.com/mmichie/lima/internal/categorizer"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/confirm"
+	"github.com/mmichie/lima/internal/ui/export"
 	"github.com/mmichie/lima/internal/ui/theme"
+	"github.com/mmichie/lima/pkg/config"
+	"github.com/shopspring/decimal"
 )
 
 // keyMap defines key bindings for the transactions view
 type keyMap struct {
-	Up       key.Binding
-	Down     key.Binding
-	PageUp   key.Binding
-	PageDown key.Binding
-	Top      key.Binding
-	Bottom   key.Binding
-	Enter    key.Binding
+	Up         key.Binding
+	Down       key.Binding
+	PageUp     key.Binding
+	PageDown   key.Binding
+	Top        key.Binding
+	Bottom     key.Binding
+	Enter      key.Binding
+	Back       key.Binding
+	Search     key.Binding
+	Jump       key.Binding
+	Month      key.Binding
+	Quarter    key.Binding
+	Year       key.Binding
+	PrevPer    key.Binding
+	NextPer    key.Binding
+	Sort       key.Binding
+	Reverse    key.Binding
+	Edit       key.Binding
+	Delete     key.Binding
+	Flag       key.Binding
+	CopyEntry  key.Binding
+	CopyPayee  key.Binding
+	CopyAmount key.Binding
+	Left       key.Binding
+	Right      key.Binding
+	Columns    key.Binding
+	Links      key.Binding
 }
 
-func newKeyMap() keyMap {
+// newKeyMap creates the key bindings, taking navigation keys from the config
+func newKeyMap(cfg config.KeybindingsConfig) keyMap {
 	return keyMap{
-		Up: key.NewBinding(
-			key.WithKeys("up", "k"),
-			key.WithHelp("↑/k", "up"),
+		Up:       components.Binding(cfg.Up, "up"),
+		Down:     components.Binding(cfg.Down, "down"),
+		PageUp:   components.Binding(cfg.PageUp, "page up"),
+		PageDown: components.Binding(cfg.PageDown, "page down"),
+		Top:      components.Binding(cfg.Top, "top"),
+		Bottom:   components.Binding(cfg.Bottom, "bottom"),
+		Enter:    components.Binding(cfg.Select, "details"),
+		Back:     components.Binding(cfg.Back, "close picker"),
+		Search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search"),
 		),
-		Down: key.NewBinding(
-			key.WithKeys("down", "j"),
-			key.WithHelp("↓/j", "down"),
+		Jump: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "jump to date"),
 		),
-		PageUp: key.NewBinding(
-			key.WithKeys("pgup", "ctrl+b"),
-			key.WithHelp("pgup", "page up"),
+		Month: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "this month"),
 		),
-		PageDown: key.NewBinding(
-			key.WithKeys("pgdown", "ctrl+f"),
-			key.WithHelp("pgdn", "page down"),
+		Quarter: key.NewBinding(
+			key.WithKeys("Q"),
+			key.WithHelp("Q", "this quarter"),
 		),
-		Top: key.NewBinding(
-			key.WithKeys("home", "g"),
-			key.WithHelp("g/home", "top"),
+		Year: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "this year"),
 		),
-		Bottom: key.NewBinding(
-			key.WithKeys("end", "G"),
-			key.WithHelp("G/end", "bottom"),
+		PrevPer: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "previous period"),
 		),
-		Enter: key.NewBinding(
-			key.WithKeys("enter"),
-			key.WithHelp("enter", "details"),
+		NextPer: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next period"),
+		),
+		Sort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sort column"),
+		),
+		Reverse: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "reverse sort"),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "edit"),
+		),
+		Delete: key.NewBinding(
+			key.WithKeys("d", "delete"),
+			key.WithHelp("d", "delete"),
+		),
+		Flag: key.NewBinding(
+			key.WithKeys("*"),
+			key.WithHelp("*", "toggle flag"),
+		),
+		CopyEntry: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy entry"),
+		),
+		CopyPayee: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "copy payee"),
+		),
+		CopyAmount: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "copy amount"),
+		),
+		Left: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "scroll left"),
+		),
+		Right: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "scroll right"),
+		),
+		Columns: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "hide columns"),
+		),
+		Links: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "linked"),
 		),
 	}
 }
 
+// FixedKeys returns the keys the view handles whatever the config binds, by what they do
+func FixedKeys() map[string]string {
+	return components.FixedKeys(newKeyMap(config.KeybindingsConfig{}))
+}
+
 // Model represents the transactions view model
 type Model struct {
 	file        *beancount.File
 	categorizer *categorizer.Categorizer
+	writer      *beancount.Writer
 	width       int
 	height      int
+	display     components.Display
 
-	// List state for manual rendering
-	cursor int
-	offset int
+	// Cursor and scroll position over rows
+	list components.Scroller
 
 	keys keyMap
 
@@ -73,22 +164,523 @@ type Model struct {
 	showingPicker      bool
 	pickerCursor       int
 	currentSuggestions []*categorizer.Suggestion
+	llmPending         bool
+
+	// Linked transactions overlay state: the transactions sharing a link with linkSource
+	showingLinks bool
+	linkSource   *beancount.Transaction
+	linked       []linkedTransaction
+	linkCursor   int
+
+	// staged maps "file:line" of a transaction to its auto-categorize suggestion
+	staged map[string]*categorizer.Suggestion
+
+	// rows holds the file indexes of the transactions shown, in display order
+	// the list's cursor is a position in rows, not a file index
+	rows []int
+
+	// Search and filter state
+	filter    *beancount.Filter
+	searching bool
+	search    components.TextInput
+	filterErr string
+
+	// Jump-to-date prompt state
+	jumping bool
+	jump    components.TextInput
+	jumpErr string
+
+	// period restricts rows to a month, quarter or year
+	period beancount.Period
+
+	// totals summarizes the shown rows per commodity
+	totals []commodityTotals
+
+	// Sort order of rows (file order by default)
+	sortBy   sortColumn
+	sortDesc bool
+
+	// columns indexes columnLayouts, the columns hidden from the table
+	columns int
+
+	// xOffset is the horizontal scroll position of the table in columns
+	xOffset int
 
 	// Cached data
 	totalTransactions int
 }
 
 // New creates a new transactions model
-func New(file *beancount.File, cat *categorizer.Categorizer) Model {
-	return Model{
+func New(file *beancount.File, cat *categorizer.Categorizer, writer *beancount.Writer, keys config.KeybindingsConfig) Model {
+	m := Model{
 		file:              file,
 		categorizer:       cat,
-		cursor:            0,
-		offset:            0,
-		keys:              newKeyMap(),
+		writer:            writer,
+		keys:              newKeyMap(keys),
 		totalTransactions: file.TransactionCount(),
 		showingPicker:     false,
 		pickerCursor:      0,
+		search:            components.NewTextInput("/").SetWidth(60),
+		jump:              components.NewTextInput(":").SetWidth(30),
+		period:            beancount.AllTime(),
+	}
+	m.search.Placeholder = "text account:food tag:trip link:invoice amount>50 date:2024-03"
+	m.jump.Placeholder = "date 2024-06"
+	m = m.refreshRows()
+	return m
+}
+
+// sortColumn is the column rows are ordered by
+type sortColumn int
+
+const (
+	sortFile sortColumn = iota // Order of appearance in the ledger
+	sortDate
+	sortAmount
+	sortPayee
+	sortAccount
+	sortColumnCount
+)
+
+// String returns the column name shown in the title
+func (c sortColumn) String() string {
+	return [...]string{"file order", "date", "amount", "payee", "account"}[c]
+}
+
+// commodityTotals is the income and expense sum of the shown rows in one commodity
+type commodityTotals struct {
+	commodity string
+	income    decimal.Decimal // Positive for money earned
+	expenses  decimal.Decimal
+}
+
+// allRows returns the identity row mapping for an unfiltered ledger
+func allRows(n int) []int {
+	rows := make([]int, n)
+	for i := range rows {
+		rows[i] = i
+	}
+	return rows
+}
+
+// Editing reports whether the view is capturing keys (search bar, picker or overlay open)
+// The root model suspends global shortcuts while this is true
+func (m Model) Editing() bool {
+	return m.searching || m.jumping || m.showingPicker || m.showingLinks
+}
+
+// SetKeys rebinds the view's keys, e.g. after the config is reloaded
+func (m Model) SetKeys(keys config.KeybindingsConfig) Model {
+	m.keys = newKeyMap(keys)
+	return m
+}
+
+// Reload re-reads the ledger after transactions were added or removed
+// The filter, period and sort are kept and the cursor stays on the same row where possible
+func (m Model) Reload() Model {
+	list := m.list
+	m.totalTransactions = m.file.TransactionCount()
+	m = m.refreshRows()
+	m.list = list.Fit(m.count(), m.listHeight())
+	return m
+}
+
+// count returns the number of transactions shown
+func (m Model) count() int {
+	return len(m.rows)
+}
+
+// listHeight returns how many transactions fit between the table header and the totals
+// Until the view is sized it shows a page.
+func (m Model) listHeight() int {
+	if m.height == 0 {
+		return max(1, m.display.PageSize)
+	}
+	height := m.height - 7 + m.display.Spared(2) // Title, spacer, header, separator, totals, padding
+	if m.searching || m.filterErr != "" || m.jumping {
+		height -= 2 // Search bar or jump prompt
+	}
+	return max(1, height)
+}
+
+// fitList keeps the cursor on a row and inside the visible window
+func (m Model) fitList() Model {
+	m.list = m.list.Fit(m.count(), m.listHeight())
+	return m
+}
+
+// transactionAt returns the transaction shown at a display position
+func (m Model) transactionAt(pos int) (*beancount.Transaction, error) {
+	if pos < 0 || pos >= len(m.rows) {
+		return nil, fmt.Errorf("row out of range: %d", pos)
+	}
+	return m.file.GetTransaction(m.rows[pos])
+}
+
+// applyFilter parses a query and rebuilds rows from the matching transactions
+// On a parse error the previous rows are kept so typing stays smooth
+func (m Model) applyFilter(query string) Model {
+	filter, err := beancount.ParseFilter(query)
+	if err != nil {
+		m.filterErr = err.Error()
+		return m
+	}
+	m.filterErr = ""
+	m.filter = filter
+	return m.refreshRows()
+}
+
+// SetFilter replaces the search query and rebuilds rows, e.g. when drilling down from a report
+func (m Model) SetFilter(query string) Model {
+	m.search = m.search.SetValue(query)
+	return m.applyFilter(query)
+}
+
+// SetPeriod restricts the view to a period and rebuilds rows
+func (m Model) SetPeriod(period beancount.Period) Model {
+	m.period = period
+	return m.refreshRows()
+}
+
+// ShowTransaction moves the cursor to the transaction at a file index, clearing the
+// filter and period when they hide it
+func (m Model) ShowTransaction(index int) Model {
+	return m.jumpToIndex(index)
+}
+
+// Period returns the active date range
+func (m Model) Period() beancount.Period {
+	return m.period
+}
+
+// State is what the view shows: the filter, period, sort order and cursor row
+// The root model saves it between sessions.
+type State struct {
+	Filter     string
+	Period     beancount.Period
+	Sort       string // Column name, e.g. "date"; "file order" keeps ledger order
+	Descending bool
+	Cursor     int
+}
+
+// State returns the view's filter, period, sort order and cursor
+func (m Model) State() State {
+	var query string
+	if m.filter != nil {
+		query = m.filter.Query
+	}
+	return State{
+		Filter:     query,
+		Period:     m.period,
+		Sort:       m.sortBy.String(),
+		Descending: m.sortDesc,
+		Cursor:     m.list.Cursor(),
+	}
+}
+
+// Restore shows a saved state, with the cursor row at the top of the list
+// An unknown sort column keeps ledger order; an invalid filter is shown with its error.
+func (m Model) Restore(state State) Model {
+	m.sortBy = sortFile
+	for column := sortFile; column < sortColumnCount; column++ {
+		if column.String() == state.Sort {
+			m.sortBy = column
+		}
+	}
+	m.sortDesc = state.Descending
+	m.period = state.Period
+	m.search = m.search.SetValue(state.Filter)
+	m.filter, m.filterErr = nil, ""
+	if filter, err := beancount.ParseFilter(state.Filter); err != nil {
+		m.filterErr = err.Error()
+	} else {
+		m.filter = filter
+	}
+	m = m.refreshRows()
+	m.list = m.list.SelectAtTop(state.Cursor)
+	return m
+}
+
+// selectPeriod narrows to the current period of a unit, or clears it if already active
+func (m Model) selectPeriod(unit beancount.PeriodUnit) Model {
+	if m.period.Unit == unit {
+		return m.SetPeriod(beancount.AllTime())
+	}
+	return m.SetPeriod(beancount.PeriodContaining(unit, time.Now()))
+}
+
+// refreshRows rebuilds rows and totals from the filter and period
+// The unrestricted view keeps lazy loading: nothing is parsed and no totals are shown
+func (m Model) refreshRows() Model {
+	m.list = components.Scroller{}
+
+	if m.filter.Empty() && m.period.IsAll() && m.sortBy == sortFile {
+		m.rows = allRows(m.totalTransactions)
+		m.totals = nil
+		if m.sortDesc {
+			reverseRows(m.rows)
+		}
+		return m.fitList()
+	}
+
+	transactions, err := m.file.AllTransactions()
+	if err != nil {
+		m.filterErr = err.Error()
+		m.rows = allRows(m.totalTransactions)
+		return m.fitList()
+	}
+
+	m.rows = nil
+	var shown []*beancount.Transaction
+	for i, tx := range transactions {
+		if m.period.Contains(tx.Date) && m.filter.Match(tx) {
+			m.rows = append(m.rows, i)
+			shown = append(shown, tx)
+		}
+	}
+	if !m.filter.Empty() || !m.period.IsAll() {
+		m.totals = sumTotals(shown)
+	} else {
+		m.totals = nil
+	}
+	m.sortRows(transactions)
+	return m.fitList()
+}
+
+// sortRows orders rows by the sort column; ties keep file order
+func (m *Model) sortRows(transactions []*beancount.Transaction) {
+	less := func(a, b *beancount.Transaction) bool { return false }
+	switch m.sortBy {
+	case sortDate:
+		less = func(a, b *beancount.Transaction) bool { return a.Date.Before(b.Date) }
+	case sortAmount:
+		less = func(a, b *beancount.Transaction) bool { return displayAmount(a).LessThan(displayAmount(b)) }
+	case sortPayee:
+		less = func(a, b *beancount.Transaction) bool {
+			return strings.ToLower(description(a)) < strings.ToLower(description(b))
+		}
+	case sortAccount:
+		less = func(a, b *beancount.Transaction) bool { return displayAccount(a) < displayAccount(b) }
+	}
+
+	sort.SliceStable(m.rows, func(i, j int) bool {
+		return less(transactions[m.rows[i]], transactions[m.rows[j]])
+	})
+	if m.sortDesc {
+		reverseRows(m.rows)
+	}
+}
+
+// reverseRows reverses rows in place
+func reverseRows(rows []int) {
+	for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+		rows[i], rows[j] = rows[j], rows[i]
+	}
+}
+
+// description is the payee, or the narration when there is no payee
+func description(tx *beancount.Transaction) string {
+	if tx.Payee != "" {
+		return tx.Payee
+	}
+	return tx.Narration
+}
+
+// displayAccount is the account shown in the account column
+func displayAccount(tx *beancount.Transaction) string {
+	if len(tx.Postings) == 0 {
+		return ""
+	}
+	return tx.Postings[0].Account
+}
+
+// displayAmount is the amount shown in the amount column (zero if elided)
+func displayAmount(tx *beancount.Transaction) decimal.Decimal {
+	if len(tx.Postings) == 0 || tx.Postings[0].Amount == nil {
+		return decimal.Zero
+	}
+	return tx.Postings[0].Amount.Number
+}
+
+// sumTotals adds up income and expense postings per commodity
+func sumTotals(transactions []*beancount.Transaction) []commodityTotals {
+	byCommodity := make(map[string]*commodityTotals)
+	for _, tx := range transactions {
+		for _, posting := range tx.Postings {
+			if posting.Amount == nil {
+				continue
+			}
+			isIncome := strings.HasPrefix(posting.Account, "Income:")
+			isExpense := strings.HasPrefix(posting.Account, "Expenses:")
+			if !isIncome && !isExpense {
+				continue
+			}
+
+			commodity := posting.Amount.Commodity
+			totals, ok := byCommodity[commodity]
+			if !ok {
+				totals = &commodityTotals{commodity: commodity}
+				byCommodity[commodity] = totals
+			}
+			if isIncome {
+				totals.income = totals.income.Sub(posting.Amount.Number)
+			} else {
+				totals.expenses = totals.expenses.Add(posting.Amount.Number)
+			}
+		}
+	}
+
+	result := make([]commodityTotals, 0, len(byCommodity))
+	for _, totals := range byCommodity {
+		result = append(result, *totals)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].commodity < result[j].commodity
+	})
+	return result
+}
+
+// updateSearch handles keys while the search bar is open
+// Enter keeps the filter, Esc clears it; the list updates as the query is typed
+func (m Model) updateSearch(msg tea.KeyMsg) Model {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.searching = false
+		m.search = m.search.Blur()
+		return m
+	case tea.KeyEsc:
+		m.searching = false
+		m.search = m.search.SetValue("").Blur()
+		return m.applyFilter("")
+	}
+
+	before := m.search.Value()
+	m.search, _ = m.search.Update(msg)
+	if m.search.Value() != before {
+		m = m.applyFilter(m.search.Value())
+	}
+	return m
+}
+
+// updateJump handles keys while the jump-to-date prompt is open
+// Enter jumps and closes the prompt, or keeps it open to show why the date was not found
+func (m Model) updateJump(msg tea.KeyMsg) Model {
+	switch msg.Type {
+	case tea.KeyEnter:
+		var err error
+		if m, err = m.jumpTo(m.jump.Value()); err != nil {
+			m.jumpErr = err.Error()
+			return m
+		}
+		m.jumping = false
+		m.jump = m.jump.SetValue("").Blur()
+		return m
+	case tea.KeyEsc:
+		m.jumping = false
+		m.jumpErr = ""
+		m.jump = m.jump.SetValue("").Blur()
+		return m
+	}
+
+	m.jump, _ = m.jump.Update(msg)
+	m.jumpErr = ""
+	return m
+}
+
+// jumpTo moves the cursor to the first shown transaction on or after a date
+// The command is "date 2024-06"; a bare year, month or day works too.
+func (m Model) jumpTo(command string) (Model, error) {
+	arg := strings.TrimSpace(command)
+	if rest, ok := strings.CutPrefix(arg, "date "); ok {
+		arg = strings.TrimSpace(rest)
+	}
+	date, _, err := beancount.ParseDateRange(arg)
+	if err != nil {
+		return m, err
+	}
+
+	positions := make(map[int]int, len(m.rows))
+	for pos, index := range m.rows {
+		positions[index] = pos
+	}
+	for _, index := range m.file.TransactionsFrom(date) {
+		if pos, ok := positions[index]; ok {
+			m.list = m.list.SelectAtTop(pos)
+			return m, nil
+		}
+	}
+	return m, fmt.Errorf("no transactions shown on or after %s", m.display.Date(date))
+}
+
+// SetStaged marks transactions with pending auto-categorize suggestions
+func (m Model) SetStaged(candidates []categorizer.AutoCandidate) Model {
+	m.staged = make(map[string]*categorizer.Suggestion, len(candidates))
+	for _, candidate := range candidates {
+		m.staged[stagedKey(candidate.Transaction)] = candidate.Suggestion
+	}
+	return m
+}
+
+// stagedKey identifies a transaction by its source location
+func stagedKey(tx *beancount.Transaction) string {
+	return fmt.Sprintf("%s:%d", tx.FilePath, tx.LineNumber)
+}
+
+// CategoryAppliedMsg reports a category chosen in the picker and written to the ledger
+// Edit is the change made, so the root model can offer undo
+type CategoryAppliedMsg struct {
+	Edit     beancount.Edit
+	Category string
+	Err      error
+}
+
+// TransactionDeletedMsg reports a transaction removed from the ledger
+// Edit is the change made, so the root model can offer undo
+type TransactionDeletedMsg struct {
+	Edit        beancount.Edit
+	Description string
+	Err         error
+}
+
+// FlagToggledMsg reports a transaction's flag changed in the ledger
+// Edit is the change made, so the root model can offer undo
+type FlagToggledMsg struct {
+	Edit        beancount.Edit
+	Description string
+	Flag        string // The new flag
+	Err         error
+}
+
+// CopyRequestedMsg asks the root model to put part of the selected transaction on the clipboard
+// What names the part for the status bar, e.g. "payee"
+type CopyRequestedMsg struct {
+	What string
+	Text string
+	Err  error
+}
+
+// EditRequestedMsg asks the root model to open the selected transaction in the entry form
+type EditRequestedMsg struct {
+	Transaction *beancount.Transaction
+}
+
+// llmSuggestionMsg carries an asynchronous LLM suggestion for a transaction
+type llmSuggestionMsg struct {
+	index      int
+	suggestion *categorizer.Suggestion
+	err        error
+}
+
+// llmTimeout bounds how long the picker waits for an LLM answer
+const llmTimeout = 45 * time.Second
+
+// requestLLMSuggestion asks the LLM backend without blocking the UI
+func requestLLMSuggestion(cat *categorizer.Categorizer, index int, tx *beancount.Transaction) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+		defer cancel()
+		suggestion, err := cat.SuggestLLM(ctx, tx)
+		return llmSuggestionMsg{index: index, suggestion: suggestion, err: err}
 	}
 }
 
@@ -100,32 +692,55 @@ func (m Model) Init() tea.Cmd {
 // Update handles messages
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case llmSuggestionMsg:
+		// Ignore late answers for a transaction the user has moved away from
+		if !m.showingPicker || msg.index != m.list.Cursor() {
+			return m, nil
+		}
+		m.llmPending = false
+		if msg.err == nil && msg.suggestion != nil {
+			m.currentSuggestions = append(m.currentSuggestions, msg.suggestion)
+		}
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearch(msg).fitList(), nil
+		}
+		if m.jumping {
+			return m.updateJump(msg).fitList(), nil
+		}
+		if m.showingLinks {
+			return m.updateLinks(msg), nil
+		}
+
 		// If category picker is showing, handle picker navigation
 		if m.showingPicker {
-			switch msg.String() {
-			case "esc", "q":
+			switch {
+			case key.Matches(msg, m.keys.Back), msg.String() == "q":
 				m.showingPicker = false
 				m.pickerCursor = 0
+				m.llmPending = false
 				return m, nil
 
-			case "up", "k":
+			case key.Matches(msg, m.keys.Up):
 				if m.pickerCursor > 0 {
 					m.pickerCursor--
 				}
 				return m, nil
 
-			case "down", "j":
+			case key.Matches(msg, m.keys.Down):
 				if m.pickerCursor < len(m.currentSuggestions)-1 {
 					m.pickerCursor++
 				}
 				return m, nil
 
-			case "enter":
-				// TODO: Apply selected category to transaction
+			case key.Matches(msg, m.keys.Enter):
+				cmd := m.applySelected()
 				m.showingPicker = false
 				m.pickerCursor = 0
-				return m, nil
+				m.llmPending = false
+				return m, cmd
 			}
 			return m, nil
 		}
@@ -133,64 +748,295 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Handle navigation keys
 		switch {
 		case key.Matches(msg, m.keys.Up):
-			if m.cursor > 0 {
-				m.cursor--
-				// Adjust offset if cursor moves above visible area
-				if m.cursor < m.offset {
-					m.offset = m.cursor
-				}
-			}
+			m.list = m.list.Up()
 
 		case key.Matches(msg, m.keys.Down):
-			if m.cursor < m.totalTransactions-1 {
-				m.cursor++
-				// Adjust offset if cursor moves below visible area
-				visibleRows := m.height - 4 // Account for title and padding
-				if m.cursor >= m.offset+visibleRows {
-					m.offset = m.cursor - visibleRows + 1
-				}
-			}
+			m.list = m.list.Down()
+
+		case key.Matches(msg, m.keys.PageUp):
+			m.list = m.list.PageUp(m.display.PageSize)
+
+		case key.Matches(msg, m.keys.PageDown):
+			m.list = m.list.PageDown(m.display.PageSize)
 
 		case key.Matches(msg, m.keys.Top):
-			m.cursor = 0
-			m.offset = 0
+			m.list = m.list.Top()
 
 		case key.Matches(msg, m.keys.Bottom):
-			m.cursor = m.totalTransactions - 1
-			visibleRows := m.height - 4
-			m.offset = m.cursor - visibleRows + 1
-			if m.offset < 0 {
-				m.offset = 0
+			m.list = m.list.Bottom()
+
+		case key.Matches(msg, m.keys.Search):
+			m.searching = true
+			m.search = m.search.Focus()
+			m.search.Complete = m.searchCompleter()
+
+		case key.Matches(msg, m.keys.Jump):
+			m.jumping = true
+			m.jump = m.jump.Focus()
+
+		case key.Matches(msg, m.keys.Month):
+			m = m.selectPeriod(beancount.PeriodMonth)
+
+		case key.Matches(msg, m.keys.Quarter):
+			m = m.selectPeriod(beancount.PeriodQuarter)
+
+		case key.Matches(msg, m.keys.Year):
+			m = m.selectPeriod(beancount.PeriodYear)
+
+		case key.Matches(msg, m.keys.Sort):
+			m.sortBy = (m.sortBy + 1) % sortColumnCount
+			m = m.refreshRows()
+
+		case key.Matches(msg, m.keys.Left):
+			m = m.scroll(-scrollStep)
+
+		case key.Matches(msg, m.keys.Right):
+			m = m.scroll(scrollStep)
+
+		case key.Matches(msg, m.keys.Columns):
+			m.columns = (m.columns + 1) % len(columnLayouts)
+			m = m.scroll(0)
+
+		case key.Matches(msg, m.keys.Reverse):
+			m.sortDesc = !m.sortDesc
+			m = m.refreshRows()
+
+		case key.Matches(msg, m.keys.PrevPer):
+			if !m.period.IsAll() {
+				m = m.SetPeriod(m.period.Prev())
+			}
+
+		case key.Matches(msg, m.keys.NextPer):
+			if !m.period.IsAll() {
+				m = m.SetPeriod(m.period.Next())
 			}
 
 		case key.Matches(msg, m.keys.Enter):
 			// Get categorization suggestions for current transaction
-			if m.categorizer != nil && m.totalTransactions > 0 {
-				tx, err := m.file.GetTransaction(m.cursor)
+			if m.categorizer != nil && m.count() > 0 {
+				tx, err := m.transactionAt(m.list.Cursor())
 				if err == nil {
 					suggestions, err := m.categorizer.SuggestAll(tx)
-					if err == nil && len(suggestions) > 0 {
+					if err == nil && (len(suggestions) > 0 || m.categorizer.HasLLM()) {
 						m.currentSuggestions = suggestions
 						m.showingPicker = true
 						m.pickerCursor = 0
+						if m.categorizer.HasLLM() {
+							m.llmPending = true
+							return m, requestLLMSuggestion(m.categorizer, m.list.Cursor(), tx)
+						}
 						return m, nil
 					}
 				}
 			}
+
+		case key.Matches(msg, m.keys.Edit):
+			if m.count() > 0 {
+				tx, err := m.transactionAt(m.list.Cursor())
+				if err != nil {
+					m.filterErr = err.Error()
+					return m, nil
+				}
+				return m, func() tea.Msg { return EditRequestedMsg{Transaction: tx} }
+			}
+
+		case key.Matches(msg, m.keys.Delete):
+			if m.count() > 0 {
+				tx, err := m.transactionAt(m.list.Cursor())
+				if err != nil {
+					m.filterErr = err.Error()
+					return m, nil
+				}
+				return m, m.deleteRequest(tx)
+			}
+
+		case key.Matches(msg, m.keys.Flag):
+			if m.count() > 0 {
+				tx, err := m.transactionAt(m.list.Cursor())
+				if err != nil {
+					m.filterErr = err.Error()
+					return m, nil
+				}
+				return m, m.flagRequest(tx)
+			}
+
+		case key.Matches(msg, m.keys.CopyEntry):
+			return m, m.copyRequest(copyEntry)
+
+		case key.Matches(msg, m.keys.CopyPayee):
+			return m, m.copyRequest(copyPayee)
+
+		case key.Matches(msg, m.keys.CopyAmount):
+			return m, m.copyRequest(copyAmount)
+
+		case key.Matches(msg, m.keys.Links):
+			m = m.openLinks()
+		}
+	}
+
+	return m.fitList(), nil
+}
+
+// deleteRequest asks for confirmation to remove a transaction
+// The outcome is reported as a TransactionDeletedMsg
+func (m Model) deleteRequest(tx *beancount.Transaction) tea.Cmd {
+	result := TransactionDeletedMsg{
+		Description: fmt.Sprintf("%s %s", m.display.Date(tx.Date), description(tx)),
+	}
+	result.Edit, result.Err = m.writer.DeleteTransaction(tx)
+	if result.Err != nil {
+		return func() tea.Msg { return result }
+	}
+	return confirm.Request(confirm.RequestMsg{
+		Title: "Delete " + result.Description,
+		Edits: []beancount.Edit{result.Edit},
+		Done: func(err error) tea.Msg {
+			result.Err = err
+			return result
+		},
+	})
+}
+
+// flagRequest asks to toggle a transaction between cleared (*) and pending (!)
+// The outcome is reported as a FlagToggledMsg
+func (m Model) flagRequest(tx *beancount.Transaction) tea.Cmd {
+	result := FlagToggledMsg{
+		Description: fmt.Sprintf("%s %s", m.display.Date(tx.Date), description(tx)),
+		Flag:        "!",
+	}
+	if tx.Flag == "!" {
+		result.Flag = "*"
+	}
+	result.Edit, result.Err = m.writer.SetTransactionFlag(tx, result.Flag)
+	if result.Err != nil {
+		return func() tea.Msg { return result }
+	}
+	return confirm.Request(confirm.RequestMsg{
+		Title:  fmt.Sprintf("Flag %s as %s", result.Description, result.Flag),
+		Edits:  []beancount.Edit{result.Edit},
+		Single: true,
+		Done: func(err error) tea.Msg {
+			result.Err = err
+			return result
+		},
+	})
+}
+
+// Parts of a transaction that can be copied to the clipboard
+const (
+	copyEntry  = "transaction"
+	copyPayee  = "payee"
+	copyAmount = "amount"
+)
+
+// copyRequest asks the root model to copy part of the selected transaction
+// The entry is copied as written in the ledger and the amount as shown in the table.
+func (m Model) copyRequest(what string) tea.Cmd {
+	if m.count() == 0 {
+		return nil
+	}
+	result := CopyRequestedMsg{What: what}
+	report := func() tea.Msg { return result }
+	tx, err := m.transactionAt(m.list.Cursor())
+	if err != nil {
+		result.Err = err
+		return report
+	}
+
+	switch what {
+	case copyEntry:
+		lines, err := m.file.TransactionSource(tx)
+		if err != nil {
+			result.Err = err
+			return report
+		}
+		result.Text = strings.Join(lines, "\n") + "\n"
+	case copyPayee:
+		result.Text = description(tx)
+	case copyAmount:
+		if len(tx.Postings) == 0 || tx.Postings[0].Amount == nil {
+			result.Err = fmt.Errorf("the first posting has no amount")
+			return report
+		}
+		result.Text = m.display.Amount(tx.Postings[0].Amount.Number, tx.Postings[0].Amount.Commodity)
+	}
+	return report
+}
+
+// Export returns the shown transactions in display order, one row per transaction
+// with the account and amount of its first posting, as in the table. Amounts are
+// plain numbers so spreadsheets can sum them.
+func (m Model) Export() (export.Table, error) {
+	table := export.Table{
+		Name:    "transactions",
+		Columns: []string{"date", "flag", "payee", "narration", "account", "amount", "commodity", "tags", "links"},
+	}
+	for pos := range m.rows {
+		tx, err := m.transactionAt(pos)
+		if err != nil {
+			return export.Table{}, err
+		}
+		var amount, commodity string
+		if len(tx.Postings) > 0 && tx.Postings[0].Amount != nil {
+			amount = tx.Postings[0].Amount.Number.String()
+			commodity = tx.Postings[0].Amount.Commodity
 		}
+		table.Rows = append(table.Rows, []string{
+			tx.Date.Format("2006-01-02"), tx.Flag, tx.Payee, tx.Narration,
+			displayAccount(tx), amount, commodity,
+			strings.Join(tx.Tags, " "), strings.Join(tx.Links, " "),
+		})
+	}
+	return table, nil
+}
+
+// applySelected asks to write the picker's selected category to the current transaction,
+// recording the acceptance once written; the outcome is reported as a CategoryAppliedMsg
+func (m Model) applySelected() tea.Cmd {
+	if m.pickerCursor >= len(m.currentSuggestions) {
+		return nil
+	}
+	suggestion := m.currentSuggestions[m.pickerCursor]
+
+	result := CategoryAppliedMsg{Category: suggestion.Category}
+	report := func() tea.Msg { return result }
+
+	tx, err := m.transactionAt(m.list.Cursor())
+	if err != nil {
+		result.Err = err
+		return report
+	}
+	posting := categorizer.CategoryPosting(tx)
+	if posting < 0 {
+		result.Err = fmt.Errorf("no expense, income or placeholder posting to categorize")
+		return report
+	}
+
+	result.Edit, result.Err = m.writer.SetPostingAccount(tx, posting, suggestion.Category)
+	if result.Err != nil {
+		return report
 	}
 
-	return m, nil
+	cat := m.categorizer
+	return confirm.Request(confirm.RequestMsg{
+		Title:  fmt.Sprintf("Categorize %s as %s", description(tx), suggestion.Category),
+		Edits:  []beancount.Edit{result.Edit},
+		Single: true,
+		Done: func(err error) tea.Msg {
+			// Statistics failures don't undo the write; the ledger is already updated
+			if result.Err = err; err == nil {
+				_ = cat.Feedback(suggestion, true)
+			}
+			return result
+		},
+	})
 }
 
 // View renders the transactions view
 func (m Model) View() string {
 	if m.totalTransactions == 0 {
 		titleText := "Transactions (0 total)"
-		titlePadded := titleText
-		if m.width > len(titleText) {
-			titlePadded = titleText + strings.Repeat(" ", m.width-len(titleText))
-		}
+		titlePadded := components.PadRight(titleText, m.width)
 		title := theme.TitleStyle.Width(m.width).Render(titlePadded)
 		return title + "\n" + theme.NormalTextStyle.Render("No transactions found")
 	}
@@ -198,85 +1044,82 @@ func (m Model) View() string {
 	var lines []string
 
 	// Title with count and cursor position
-	titleText := fmt.Sprintf("Transactions (%d total) - Row %d/%d", m.totalTransactions, m.cursor+1, m.totalTransactions)
-	titlePadded := titleText
-	if m.width > len(titleText) {
-		titlePadded = titleText + strings.Repeat(" ", m.width-len(titleText))
+	titleText := fmt.Sprintf("Transactions (%d total) - Row %d/%d", m.totalTransactions, m.list.Cursor()+1, m.count())
+	if !m.filter.Empty() || !m.period.IsAll() {
+		titleText = fmt.Sprintf("Transactions (%d of %d) - Row %d/%d",
+			m.count(), m.totalTransactions, min(m.list.Cursor()+1, m.count()), m.count())
+	}
+	if !m.period.IsAll() {
+		titleText += " - " + m.period.String() + " ([ ])"
+	}
+	if m.sortBy != sortFile || m.sortDesc {
+		direction := "↑"
+		if m.sortDesc {
+			direction = "↓"
+		}
+		titleText += fmt.Sprintf(" - by %s %s", m.sortBy, direction)
+	}
+	if !m.filter.Empty() {
+		titleText += " - filter: " + m.filter.Query
 	}
+	if m.columns != 0 {
+		titleText += " - " + columnLayouts[m.columns].name
+	}
+	if m.maxScroll() > 0 {
+		titleText += fmt.Sprintf(" - col %d/%d (← →)", m.xOffset+1, m.maxScroll()+1)
+	}
+	titlePadded := components.PadRight(titleText, m.width)
 	title := theme.TitleStyle.Width(m.width).Render(titlePadded)
 	lines = append(lines, title)
-	lines = append(lines, "")
-
-	// Table header
-	headerLine := fmt.Sprintf("%-12s  %1s  %-40s  %-45s  %15s", "Date", "", "Description", "Account", "Amount")
-	if m.width > len(headerLine) {
-		headerLine = headerLine + strings.Repeat(" ", m.width-len(headerLine))
+	if !m.display.Compact {
+		lines = append(lines, "")
 	}
-	lines = append(lines, theme.TitleStyle.Width(m.width).Render(headerLine))
 
-	// Separator
-	separator := strings.Repeat("─", m.width)
-	lines = append(lines, theme.MutedTextStyle.Width(m.width).Render(separator))
+	// Table header, scrolled with the rows
+	columns := m.layout()
+	headerLine := components.Cut(columns.row("Date", "", "Description", "Account", "Amount"), m.xOffset, m.width)
+	lines = append(lines, theme.TitleStyle.Width(m.width).Render(headerLine))
 
-	// Calculate visible range
-	visibleRows := m.height - 6 // Account for title, header, separator, padding
-	if visibleRows < 1 {
-		visibleRows = 1
-	}
-	end := m.offset + visibleRows
-	if end > m.totalTransactions {
-		end = m.totalTransactions
-	}
+	lines = append(lines, m.display.Rule(m.width)...)
 
 	// Render visible transactions
-	for i := m.offset; i < end; i++ {
-		tx, err := m.file.GetTransaction(i)
+	start, end := m.list.Window()
+	for i := start; i < end; i++ {
+		tx, err := m.transactionAt(i)
 		if err != nil {
 			continue
 		}
 
 		// Format date
-		dateStr := tx.Date.Format("2006-01-02")
+		dateStr := m.display.Date(tx.Date)
 
 		// Format flag
 		flagStr := tx.Flag
 
-		// Format description
-		description := tx.Narration
-		if tx.Payee != "" {
-			description = tx.Payee
-		}
-		if len(description) > 40 {
-			description = description[:37] + "..."
-		}
+		// Long text is shortened to its column by the layout
+		description := description(tx)
 
-		// Format account
 		account := ""
 		if len(tx.Postings) > 0 {
-			account = tx.Postings[0].Account
-			if len(account) > 45 {
-				account = "..." + account[len(account)-42:]
-			}
+			account = m.display.ShortAccount(tx.Postings[0].Account)
+		}
+
+		// Staged suggestions replace the account column until applied
+		if suggestion, ok := m.staged[stagedKey(tx)]; ok {
+			account = "→ " + components.TruncateStart(m.display.ShortAccount(suggestion.Category), max(0, columns.account-2), "...")
 		}
 
 		// Format amount
 		amount := ""
 		if len(tx.Postings) > 0 && tx.Postings[0].Amount != nil {
-			amt := tx.Postings[0].Amount.Number.StringFixed(2)
-			commodity := tx.Postings[0].Amount.Commodity
-			amount = fmt.Sprintf("%s %s", amt, commodity)
+			amount = m.display.Amount(tx.Postings[0].Amount.Number, tx.Postings[0].Amount.Commodity)
 		}
 
-		// Build the row line
-		line := fmt.Sprintf("%-12s  %1s  %-40s  %-45s  %15s", dateStr, flagStr, description, account, amount)
-
-		// Pad to full width
-		if m.width > len(line) {
-			line = line + strings.Repeat(" ", m.width-len(line))
-		}
+		// Build the row line, cut to the scrolled window
+		line := components.Cut(columns.row(dateStr, flagStr, description, account, amount), m.xOffset, m.width)
 
 		// Apply highlighting for selected row
-		if i == m.cursor {
+		if i == m.list.Cursor() {
 			line = theme.SelectedItemStyle.Width(m.width).Render(line)
 		} else {
 			line = theme.ListItemStyle.Width(m.width).Render(line)
@@ -285,12 +1128,47 @@ func (m Model) View() string {
 		lines = append(lines, line)
 	}
 
+	if m.count() == 0 {
+		lines = append(lines, theme.NormalTextStyle.Render("No transactions match the filter"))
+	}
+
+	// Totals of the shown rows
+	if len(m.totals) > 0 {
+		var parts []string
+		for _, totals := range m.totals {
+			parts = append(parts, fmt.Sprintf("Income %s  Expenses %s  Net %s %s",
+				m.display.Number(totals.income, totals.commodity), m.display.Number(totals.expenses, totals.commodity),
+				m.display.Number(totals.income.Sub(totals.expenses), totals.commodity), totals.commodity))
+		}
+		lines = append(lines, theme.MutedTextStyle.Render(strings.Join(parts, "   |   ")))
+	}
+
+	// Search bar
+	if m.searching || m.filterErr != "" {
+		lines = append(lines, "")
+		bar := m.search.View()
+		if m.filterErr != "" {
+			bar += "  " + theme.ErrorStyle.Render(m.filterErr)
+		}
+		lines = append(lines, bar)
+	} else if m.jumping {
+		lines = append(lines, "")
+		bar := m.jump.View()
+		if m.jumpErr != "" {
+			bar += "  " + theme.ErrorStyle.Render(m.jumpErr)
+		}
+		lines = append(lines, bar)
+	}
+
 	view := strings.Join(lines, "\n")
 
 	// Show category picker overlay if active
 	if m.showingPicker {
 		return view + "\n\n" + m.renderCategoryPicker()
 	}
+	if m.showingLinks {
+		return view + "\n\n" + m.renderLinks()
+	}
 
 	return view
 }
@@ -299,17 +1177,19 @@ func (m Model) View() string {
 func (m Model) SetSize(width, height int) Model {
 	m.width = width
 	m.height = height
-	return m
+	return m.fitList().scroll(0)
+}
+
+// SetDisplay updates the transactions view display settings
+func (m Model) SetDisplay(display components.Display) Model {
+	m.display = display
+	return m.SetSize(m.width, m.height)
 }
 
 // renderCategoryPicker renders the category picker overlay with TP7 styling
 func (m Model) renderCategoryPicker() string {
 	// Use TP7 double-line box drawing characters
-	pickerStyle := lipgloss.NewStyle().
-		Border(lipgloss.DoubleBorder()).
-		BorderForeground(lipgloss.Color(theme.TP7Cyan)).
-		BorderBackground(lipgloss.Color(theme.TP7Blue)).
-		Background(lipgloss.Color(theme.TP7Blue)).
+	pickerStyle := theme.PanelStyle.
 		Padding(1, 2).
 		Width(m.width - 4)
 
@@ -317,7 +1197,7 @@ func (m Model) renderCategoryPicker() string {
 	lines = append(lines, theme.TitleStyle.Render("Category Suggestions"))
 	lines = append(lines, "")
 
-	if len(m.currentSuggestions) == 0 {
+	if len(m.currentSuggestions) == 0 && !m.llmPending {
 		lines = append(lines, theme.NormalTextStyle.Render("No categorization suggestions available"))
 	} else {
 		for i, suggestion := range m.currentSuggestions {
@@ -347,6 +1227,9 @@ func (m Model) renderCategoryPicker() string {
 
 			lines = append(lines, line)
 		}
+		if m.llmPending {
+			lines = append(lines, theme.MutedTextStyle.Render("   Asking LLM..."))
+		}
 	}
 
 	lines = append(lines, "")
@@ -355,3 +1238,29 @@ func (m Model) renderCategoryPicker() string {
 	content := strings.Join(lines, "\n")
 	return pickerStyle.Render(content)
 }
+
+// ShortHelp returns the view's main key bindings, for the status bar
+func (m Model) ShortHelp() []key.Binding {
+	k := m.keys
+	return []key.Binding{k.Enter, k.Edit, k.Delete, k.Flag, k.Search, k.Month, k.Sort, k.Columns}
+}
+
+// FullHelp returns all of the view's key bindings in columns, for the help row
+func (m Model) FullHelp() [][]key.Binding {
+	k := m.keys
+	return [][]key.Binding{
+		{k.Up, k.Down, k.PageUp, k.PageDown},
+		{k.Top, k.Bottom, k.Left, k.Right},
+		{k.Enter, k.Edit, k.Delete, k.Flag},
+		{k.CopyEntry, k.CopyPayee, k.CopyAmount},
+		{k.Search, k.Jump, k.Links},
+		{k.Month, k.Quarter, k.Year},
+		{k.PrevPer, k.NextPer},
+		{k.Sort, k.Reverse, k.Columns},
+	}
+}
+
+// KeyBindings returns the view's key bindings, for the help overlay
+func (m Model) KeyBindings() []key.Binding {
+	return slices.Concat(m.FullHelp()...)
+}
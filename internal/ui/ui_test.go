@@ -1,14 +1,46 @@
 package ui
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+	"github.com/mmichie/lima/internal/ui/confirm"
+	"github.com/mmichie/lima/internal/ui/export"
+	"github.com/mmichie/lima/internal/ui/imports"
+	"github.com/mmichie/lima/internal/ui/reports"
+	"github.com/mmichie/lima/internal/ui/theme"
 	"github.com/mmichie/lima/pkg/config"
 )
 
+// TestMain keeps what the default configuration writes outside the data directory,
+// such as ledger backups, inside a temporary one
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "lima-ui-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Setenv("XDG_DATA_HOME", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestUIModelCreation(t *testing.T) {
 	// Create a temporary test file
 	content := `2025-01-01 * "Test" "Transaction"
@@ -168,3 +200,4197 @@ func createTempFile(t *testing.T, content string) string {
 
 	return tmpFile
 }
+
+func TestAutoCategorizeWriteAndUndo(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Entertainment:Streaming
+2024-01-01 open Expenses:Uncategorized
+
+2025-01-01 * "NETFLIX.COM" "Subscription"
+  Assets:Checking  -15.49 USD
+  Expenses:Uncategorized  15.49 USD
+`
+	tmpDir := t.TempDir()
+	ledger := filepath.Join(tmpDir, "ledger.beancount")
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	cfg.Categorization.AutoCategorize = true
+	cfg.Categorization.AutoThreshold = 0.7
+	cfg.Categorization.AutoMode = "write"
+	cfg.Categorization.AuditLog = filepath.Join(tmpDir, "audit.log")
+	model := New(file, cfg)
+
+	msg := findAutoCandidatesCmd(file, model.categorizer)()
+	model = model.handleAutoCandidates(msg.(autoCandidatesMsg))
+
+	tx, err := file.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	if tx.Postings[1].Account != "Expenses:Entertainment:Streaming" {
+		t.Fatalf("expected posting to be categorized, got %s", tx.Postings[1].Account)
+	}
+	if len(model.journal.done) != 1 {
+		t.Fatal("expected an undoable batch")
+	}
+
+	model = model.undoLast()
+	data, _ := os.ReadFile(ledger)
+	if string(data) != content {
+		t.Errorf("expected ledger restored after undo, got:\n%s", data)
+	}
+
+	entries, err := model.audit.Entries()
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected applied and undone audit entries, got %d", len(entries))
+	}
+}
+
+func TestAutoCategorizeStage(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Entertainment:Streaming
+2024-01-01 open Expenses:Uncategorized
+
+2025-01-01 * "NETFLIX.COM" "Subscription"
+  Assets:Checking  -15.49 USD
+  Expenses:Uncategorized  15.49 USD
+`
+	tmpDir := t.TempDir()
+	ledger := filepath.Join(tmpDir, "ledger.beancount")
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	cfg.Categorization.AutoCategorize = true
+	cfg.Categorization.AutoThreshold = 0.7
+	cfg.Categorization.AuditLog = ""
+	model := New(file, cfg)
+
+	msg := findAutoCandidatesCmd(file, model.categorizer)()
+	model = model.handleAutoCandidates(msg.(autoCandidatesMsg))
+
+	if len(model.staged) != 1 {
+		t.Fatalf("expected 1 staged suggestion, got %d", len(model.staged))
+	}
+	data, _ := os.ReadFile(ledger)
+	if string(data) != content {
+		t.Error("expected ledger untouched while suggestions are staged")
+	}
+
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	model = confirmWrite(t, model)
+	if len(model.staged) != 0 {
+		t.Error("expected staged suggestions to be applied")
+	}
+	data, _ = os.ReadFile(ledger)
+	if !strings.Contains(string(data), "Expenses:Entertainment:Streaming") {
+		t.Errorf("expected category written to ledger, got:\n%s", data)
+	}
+}
+
+// typeKeys sends each rune of s to the model as a key press
+func typeKeys(model Model, s string) Model {
+	for _, r := range s {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		model = updated.(Model)
+	}
+	return model
+}
+
+// send delivers a message and the messages its command produces, as the runtime would
+func send(model Model, msg tea.Msg) Model {
+	updated, cmd := model.Update(msg)
+	model = updated.(Model)
+	for _, result := range runCmd(cmd) {
+		updated, _ = model.Update(result)
+		model = updated.(Model)
+	}
+	return model
+}
+
+// runCmd runs a command, expanding batches
+// Commands still running after a moment, such as notification timers, are dropped
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(100 * time.Millisecond):
+		return nil
+	}
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, runCmd(c)...)
+		}
+		return msgs
+	}
+	if msg == nil {
+		return nil
+	}
+	return []tea.Msg{msg}
+}
+
+// confirmWrite accepts the open write confirmation
+func confirmWrite(t *testing.T, model Model) Model {
+	t.Helper()
+	if model.confirm == nil {
+		t.Fatal("expected a write confirmation")
+	}
+	return send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+}
+
+// pressKey sends a single special key to the model
+func pressKey(model Model, keyType tea.KeyType) Model {
+	updated, _ := model.Update(tea.KeyMsg{Type: keyType})
+	return updated.(Model)
+}
+
+func TestPatternsViewCreatePattern(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food:Coffee
+
+2025-01-01 * "BLUE BOTTLE" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized  5.00 USD
+`
+	tmpDir := t.TempDir()
+	ledger := filepath.Join(tmpDir, "ledger.beancount")
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = filepath.Join(tmpDir, "patterns.yaml")
+	cfg.Categorization.Merchants.Enabled = false
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+
+	model = typeKeys(model, "5")
+	if model.currentView != PatternsView {
+		t.Fatalf("expected patterns view, got %d", model.currentView)
+	}
+
+	// Open the form; digits and q must reach the form rather than switch views
+	model = typeKeys(model, "n")
+	if !model.patterns.Editing() {
+		t.Fatal("expected pattern form to be open")
+	}
+	model = typeKeys(model, "coffee1")
+	model = pressKey(model, tea.KeyTab) // name
+	model = pressKey(model, tea.KeyTab) // regex
+	model = typeKeys(model, "BLUE BOTTLE")
+	model = pressKey(model, tea.KeyTab) // category
+	model = typeKeys(model, "expenses:food")
+	model = pressKey(model, tea.KeyTab) // accept completion
+
+	if !strings.Contains(model.View(), "Preview: 1 matching") {
+		t.Errorf("expected live preview to show the matching transaction, got:\n%s", model.View())
+	}
+
+	model = pressKey(model, tea.KeyEnter)
+	if model.patterns.Editing() {
+		t.Fatal("expected form to close after saving")
+	}
+
+	pattern, err := model.categorizer.GetPattern("coffee1")
+	if err != nil {
+		t.Fatalf("expected pattern to be added: %v", err)
+	}
+	if pattern.Category != "Expenses:Food:Coffee" {
+		t.Errorf("expected completed category, got %s", pattern.Category)
+	}
+
+	data, err := os.ReadFile(cfg.Files.PatternsFile)
+	if err != nil {
+		t.Fatalf("expected patterns file to be written: %v", err)
+	}
+	if !strings.Contains(string(data), "coffee1") {
+		t.Errorf("expected saved pattern, got:\n%s", data)
+	}
+}
+
+func TestReviewQueue(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food:Coffee
+2024-01-01 open Expenses:Food:DiningOut
+2024-01-01 open Expenses:Uncategorized
+
+2025-01-01 * "BLUE BOTTLE" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized  5.00 USD
+
+2025-01-02 * "CORNER CAFE" "Lunch"
+  Assets:Checking  -15.00 USD
+  Expenses:Uncategorized  15.00 USD
+
+2025-01-03 ! "MYSTERY" "Check this"
+  Assets:Checking  -9.00 USD
+  Expenses:Food:DiningOut  9.00 USD
+
+2025-01-04 * "GROCER" "Already categorized"
+  Assets:Checking  -20.00 USD
+  Expenses:Food:DiningOut  20.00 USD
+`
+	patternsYAML := `version: "1"
+patterns:
+  - id: blue-bottle
+    name: Blue Bottle
+    pattern: "BLUE BOTTLE"
+    category: Expenses:Food:Coffee
+    priority: 10
+    confidence: 0.9
+  - id: cafe
+    name: Cafe
+    pattern: "BLUE BOTTLE|CAFE"
+    category: Expenses:Food:DiningOut
+    priority: 5
+    confidence: 0.7
+`
+	tmpDir := t.TempDir()
+	ledger := filepath.Join(tmpDir, "ledger.beancount")
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+	patternsFile := filepath.Join(tmpDir, "patterns.yaml")
+	if err := os.WriteFile(patternsFile, []byte(patternsYAML), 0644); err != nil {
+		t.Fatalf("failed to write patterns: %v", err)
+	}
+
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = patternsFile
+	cfg.Categorization.Merchants.Enabled = false
+	cfg.Categorization.LearnFromEdits = false
+	cfg.UI.ConfirmCategorize = false
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+
+	model = typeKeys(model, "r")
+	if model.currentView != ReviewView {
+		t.Fatalf("expected review view, got %d", model.currentView)
+	}
+	if model.review.Remaining() != 3 {
+		t.Fatalf("expected 3 transactions to review, got %d", model.review.Remaining())
+	}
+
+	// Pick the alternative for the first transaction; "2" must not switch views
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	if model.currentView != ReviewView {
+		t.Fatal("expected digit keys to pick suggestions in review mode")
+	}
+	cafe, _ := model.categorizer.GetPattern("cafe")
+	blueBottle, _ := model.categorizer.GetPattern("blue-bottle")
+	if cafe.Statistics.AcceptCount != 1 || blueBottle.Statistics.RejectCount != 1 {
+		t.Errorf("expected feedback recorded, got accept=%d reject=%d",
+			cafe.Statistics.AcceptCount, blueBottle.Statistics.RejectCount)
+	}
+
+	// Accept the top suggestion for the second, then edit the flagged one
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	model = typeKeys(model, "e")
+	model = pressKey(model, tea.KeyCtrlU)
+	model = typeKeys(model, "Expenses:Food:Coffee")
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if model.review.Remaining() != 0 {
+		t.Errorf("expected queue to be finished, got %d remaining", model.review.Remaining())
+	}
+	if !strings.Contains(model.View(), "3 categorized, 0 skipped") {
+		t.Errorf("expected completion summary, got:\n%s", model.View())
+	}
+
+	data, _ := os.ReadFile(ledger)
+	expected := []string{
+		"2025-01-01 * \"BLUE BOTTLE\" \"Latte\"\n  Assets:Checking  -5.00 USD\n  Expenses:Food:DiningOut  5.00 USD",
+		"2025-01-02 * \"CORNER CAFE\" \"Lunch\"\n  Assets:Checking  -15.00 USD\n  Expenses:Food:DiningOut  15.00 USD",
+		"2025-01-03 * \"MYSTERY\" \"Check this\"\n  Assets:Checking  -9.00 USD\n  Expenses:Food:Coffee     9.00 USD",
+	}
+	for _, want := range expected {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected ledger to contain:\n%s\ngot:\n%s", want, data)
+		}
+	}
+}
+
+func TestPickerAppliesCategory(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food:Coffee
+
+2025-01-01 * "BLUE BOTTLE" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized  5.00 USD
+`
+	patternsYAML := `version: "1"
+patterns:
+  - id: blue-bottle
+    name: Blue Bottle
+    pattern: "BLUE BOTTLE"
+    category: Expenses:Food:Coffee
+`
+	tmpDir := t.TempDir()
+	ledger := filepath.Join(tmpDir, "ledger.beancount")
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+	patternsFile := filepath.Join(tmpDir, "patterns.yaml")
+	if err := os.WriteFile(patternsFile, []byte(patternsYAML), 0644); err != nil {
+		t.Fatalf("failed to write patterns: %v", err)
+	}
+
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = patternsFile
+	cfg.Categorization.Merchants.Enabled = false
+	cfg.Categorization.LearnFromEdits = false
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+
+	model = typeKeys(model, "2")
+	model = pressKey(model, tea.KeyEnter) // open picker
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if cmd == nil {
+		t.Fatal("expected picker selection to produce a command")
+	}
+	updated, _ = model.Update(cmd())
+	model = updated.(Model)
+	if view := model.View(); !strings.Contains(view, "- ") || !strings.Contains(view, "+ ") {
+		t.Errorf("expected a diff preview:\n%s", view)
+	}
+	model = confirmWrite(t, model)
+
+	tx, _ := file.GetTransaction(0)
+	if tx.Postings[1].Account != "Expenses:Food:Coffee" {
+		t.Errorf("expected category applied, got %s", tx.Postings[1].Account)
+	}
+	if !strings.Contains(model.status(), "u: undo") {
+		t.Errorf("expected confirmation with undo hint, got %q", model.status())
+	}
+	pattern, _ := model.categorizer.GetPattern("blue-bottle")
+	if pattern.Statistics.AcceptCount != 1 {
+		t.Errorf("expected acceptance recorded, got %d", pattern.Statistics.AcceptCount)
+	}
+
+	model = typeKeys(model, "u")
+	data, _ := os.ReadFile(ledger)
+	if string(data) != content {
+		t.Errorf("expected ledger restored after undo, got:\n%s", data)
+	}
+}
+
+func TestTransactionsSearch(t *testing.T) {
+	content := `2024-03-01 * "BLUE BOTTLE" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Food:Coffee  5.00 USD
+
+2024-03-05 * "WHOLE FOODS" "Groceries"
+  Assets:Checking  -80.00 USD
+  Expenses:Food:Groceries  80.00 USD
+
+2024-04-02 * "DELTA" "Flight"
+  Assets:Checking  -420.00 USD
+  Expenses:Travel  420.00 USD
+`
+	tmpFile := createTempFile(t, content)
+	defer os.Remove(tmpFile)
+
+	file, err := beancount.Open(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 160, 40, true
+	model.transactions = model.transactions.SetSize(160, 38)
+
+	model = typeKeys(model, "2/account:food date:2024-03 amount>10")
+	if model.currentView != TransactionsView {
+		t.Fatal("expected typed digits to go to the search bar")
+	}
+	view := model.View()
+	if !strings.Contains(view, "(1 of 3)") {
+		t.Errorf("expected filtered count in title, got:\n%s", view)
+	}
+	if !strings.Contains(view, "WHOLE FOODS") || strings.Contains(view, "BLUE BOTTLE") {
+		t.Errorf("expected only the matching transaction listed, got:\n%s", view)
+	}
+
+	// Enter keeps the filter; Esc in a new search clears it
+	model = pressKey(model, tea.KeyEnter)
+	if model.transactions.Editing() {
+		t.Error("expected search bar to close on enter")
+	}
+	model = typeKeys(model, "/")
+	model = pressKey(model, tea.KeyEsc)
+	if !strings.Contains(model.View(), "(3 total)") {
+		t.Errorf("expected filter cleared, got:\n%s", model.View())
+	}
+}
+
+func TestTransactionsPeriodNavigation(t *testing.T) {
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+
+	content := fmt.Sprintf(`%s * "EMPLOYER" "Salary"
+  Assets:Checking  1000.00 USD
+  Income:Salary  -1000.00 USD
+
+%s * "GROCER" "Food"
+  Assets:Checking  -40.00 USD
+  Expenses:Food  40.00 USD
+
+%s * "CAFE" "Coffee"
+  Assets:Checking  -5.00 USD
+  Expenses:Food  5.00 USD
+`, lastMonth.Format("2006-01-02"), lastMonth.Format("2006-01-02"), thisMonth.Format("2006-01-02"))
+
+	tmpFile := createTempFile(t, content)
+	defer os.Remove(tmpFile)
+
+	file, err := beancount.Open(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 160, 40, true
+	model.transactions = model.transactions.SetSize(160, 38)
+
+	model = typeKeys(model, "2m")
+	view := model.View()
+	if !strings.Contains(view, "(1 of 3)") || !strings.Contains(view, thisMonth.Format("Jan 2006")) {
+		t.Errorf("expected this month only with its label in the title, got:\n%s", view)
+	}
+
+	model = typeKeys(model, "[")
+	view = model.View()
+	if !strings.Contains(view, "(2 of 3)") {
+		t.Errorf("expected previous month, got:\n%s", view)
+	}
+	if !strings.Contains(view, "Income 1000.00  Expenses 40.00  Net 960.00 USD") {
+		t.Errorf("expected period totals, got:\n%s", view)
+	}
+
+	// Pressing the active period key again clears the range
+	model = typeKeys(model, "m")
+	if !strings.Contains(model.View(), "(3 total)") {
+		t.Errorf("expected all transactions, got:\n%s", model.View())
+	}
+}
+
+func TestTransactionsSort(t *testing.T) {
+	content := `2024-03-02 * "BRAVO" "Second"
+  Expenses:Food  30.00 USD
+  Assets:Checking
+
+2024-03-01 * "ALPHA" "First"
+  Expenses:Food  10.00 USD
+  Assets:Checking
+
+2024-03-03 * "CHARLIE" "Third"
+  Expenses:Food  20.00 USD
+  Assets:Checking
+`
+	tmpFile := createTempFile(t, content)
+	defer os.Remove(tmpFile)
+
+	file, err := beancount.Open(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 160, 40, true
+	model.transactions = model.transactions.SetSize(160, 38)
+
+	order := func(model Model) string {
+		view := model.View()
+		var names []string
+		for _, name := range []string{"ALPHA", "BRAVO", "CHARLIE"} {
+			names = append(names, fmt.Sprintf("%04d%s", strings.Index(view, name), name))
+		}
+		sort.Strings(names)
+		var result []string
+		for _, name := range names {
+			result = append(result, name[4:])
+		}
+		return strings.Join(result, ",")
+	}
+
+	model = typeKeys(model, "2")
+	if got := order(model); got != "BRAVO,ALPHA,CHARLIE" {
+		t.Errorf("expected file order, got %s", got)
+	}
+
+	model = typeKeys(model, "s") // date
+	if got := order(model); got != "ALPHA,BRAVO,CHARLIE" {
+		t.Errorf("expected date order, got %s", got)
+	}
+
+	model = typeKeys(model, "sS") // amount, descending
+	if got := order(model); got != "BRAVO,CHARLIE,ALPHA" {
+		t.Errorf("expected descending amount order, got %s", got)
+	}
+	if !strings.Contains(model.View(), "by amount ↓") {
+		t.Errorf("expected sort shown in title, got:\n%s", model.View())
+	}
+}
+
+func TestTransactionsColumnLayout(t *testing.T) {
+	content := `2024-03-01 * "Neighbourhood Coffee Roasters" "Flat white and a pastry to take away"
+  Expenses:Food:Restaurants:Coffee:Neighbourhood  4.50 USD
+  Assets:Checking
+`
+	tmpFile := createTempFile(t, content)
+	defer os.Remove(tmpFile)
+
+	file, err := beancount.Open(tmpFile)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 200, Height: 30})
+	model = typeKeys(model, "2")
+
+	// A wide terminal shows everything unshortened
+	view := model.View()
+	for _, want := range []string{"Neighbourhood Coffee Roasters", "Expenses:Food:Restaurants:Coffee:Neighbourhood", "4.50 USD"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q at width 200:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "(← →)") {
+		t.Errorf("expected no horizontal scrolling at width 200:\n%s", view)
+	}
+
+	// A narrow one shortens text to the minimum widths and scrolls the rest
+	model = send(model, tea.WindowSizeMsg{Width: 60, Height: 30})
+	view = model.View()
+	for _, want := range []string{"Neighbourhood Cof...", "...fee:Neighbourhood", "(← →)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q at width 60:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "4.50 USD") {
+		t.Errorf("expected the amount scrolled off screen at width 60:\n%s", view)
+	}
+	for _, line := range strings.Split(model.transactions.View(), "\n") {
+		if width := lipgloss.Width(line); width > 60 {
+			t.Errorf("expected lines to fit 60 columns, got %d: %q", width, line)
+		}
+	}
+
+	model = typeKeys(model, "llllllll")
+	if view = model.View(); !strings.Contains(view, "4.50 USD") {
+		t.Errorf("expected scrolling right to reveal the amount:\n%s", view)
+	}
+	model = typeKeys(model, "hhhhhhhh")
+	if view = model.View(); !strings.Contains(view, "2024-03-01") {
+		t.Errorf("expected scrolling left to return to the date:\n%s", view)
+	}
+
+	// Hiding the account column frees the space for the amount
+	model = typeKeys(model, "c")
+	view = model.View()
+	if strings.Contains(view, "Account") || !strings.Contains(view, "4.50 USD") || !strings.Contains(view, "no account") {
+		t.Errorf("expected the account column hidden:\n%s", view)
+	}
+}
+
+func TestNewTransactionEntry(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food:Coffee
+
+2025-01-01 * "Coffee Shop" "Latte"
+  Assets:Checking  -4.50 USD
+  Expenses:Food:Coffee
+`
+	ledger := createTempFile(t, content)
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+
+	// Open from the File menu: alt+f, then the first entry
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}, Alt: true})
+	model = updated.(Model)
+	if !strings.Contains(model.View(), "New Transaction") {
+		t.Error("expected File dropdown to list New Transaction")
+	}
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updated.(Model)
+	if cmd == nil {
+		t.Fatal("expected menu selection to produce a command")
+	}
+	updated, _ = model.Update(cmd())
+	model = updated.(Model)
+	if model.entry == nil {
+		t.Fatal("expected File > New Transaction to open the form")
+	}
+
+	// Payee completion fills the postings from the payee's last transaction
+	model = pressKey(model, tea.KeyCtrlU)
+	model = typeKeys(model, "2025-02-03")
+	model = pressKey(model, tea.KeyTab)
+	model = typeKeys(model, "coff")
+	model = pressKey(model, tea.KeyTab) // accept "Coffee Shop"
+	model = pressKey(model, tea.KeyTab) // next field copies the last Coffee Shop postings
+	model = pressKey(model, tea.KeyEnter)
+	model = confirmWrite(t, model)
+
+	if model.entry != nil {
+		t.Fatalf("expected form closed after save, error: %v", model.entry.View())
+	}
+	if file.TransactionCount() != 2 {
+		t.Fatalf("expected 2 transactions, got %d", file.TransactionCount())
+	}
+	tx, _ := file.GetTransaction(1)
+	if tx.Payee != "Coffee Shop" || tx.Narration != "Latte" || tx.Date.Format("2006-01-02") != "2025-02-03" {
+		t.Errorf("unexpected transaction header: %s %q %q", tx.Date.Format("2006-01-02"), tx.Payee, tx.Narration)
+	}
+	if len(tx.Postings) != 2 || tx.Postings[1].Amount == nil || tx.Postings[1].Amount.Number.String() != "4.5" {
+		t.Errorf("expected auto-balanced coffee posting of 4.50, got %+v", tx.Postings)
+	}
+	if !strings.Contains(model.status(), "u: undo") {
+		t.Errorf("expected confirmation with undo hint, got %q", model.status())
+	}
+
+	model = typeKeys(model, "u")
+	data, _ := os.ReadFile(ledger)
+	if string(data) != content {
+		t.Errorf("expected ledger restored after undo, got:\n%s", data)
+	}
+
+	// The keybinding reopens the form on the last used date
+	model = typeKeys(model, "a")
+	if model.entry == nil {
+		t.Fatal("expected a to open the form")
+	}
+	model = pressKey(model, tea.KeyTab)
+	model = typeKeys(model, "Bakery")
+	model = pressKey(model, tea.KeyTab)
+	model = pressKey(model, tea.KeyTab)
+	model = typeKeys(model, "Expenses:Food:Coffee")
+	model = pressKey(model, tea.KeyTab)
+	model = typeKeys(model, "3")
+	model = pressKey(model, tea.KeyEnter)
+	if model.entry == nil || !strings.Contains(model.entry.View(), "at least two postings") {
+		t.Fatal("expected a single posting to be rejected")
+	}
+	model = pressKey(model, tea.KeyTab)
+	model = typeKeys(model, "Assets:Checking")
+	model = pressKey(model, tea.KeyEnter)
+	model = confirmWrite(t, model)
+	if model.entry != nil {
+		t.Fatalf("expected form closed after save:\n%s", model.entry.View())
+	}
+
+	data, _ = os.ReadFile(ledger)
+	if !strings.Contains(string(data), `2025-02-03 * "Bakery" ""`) || !strings.Contains(string(data), "-3.00 USD") {
+		t.Errorf("expected balanced Bakery transaction on the last date, got:\n%s", data)
+	}
+}
+
+func TestEditTransaction(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food:Dining
+
+2025-01-05 * "Cafe" "Lunch" ; by card
+  receipt: "r-7"
+  Assets:Checking  -10.00 USD ; card
+  Expenses:Food:Dining
+`
+	ledger := createTempFile(t, content)
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+
+	edit := func(model Model) Model {
+		model = typeKeys(model, "2")
+		updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+		model = updated.(Model)
+		if cmd == nil {
+			t.Fatal("expected e to request the entry form")
+		}
+		updated, _ = model.Update(cmd())
+		model = updated.(Model)
+		if model.entry == nil || !strings.Contains(model.entry.View(), "Edit Transaction") {
+			t.Fatal("expected the edit form to open")
+		}
+		return model
+	}
+
+	// Change the narration and add a tip posting; the elided posting still balances
+	model = edit(model)
+	model = pressKey(model, tea.KeyTab)
+	model = pressKey(model, tea.KeyCtrlU)
+	model = typeKeys(model, "Dinner")
+	model = pressKey(model, tea.KeyCtrlN)
+	model = typeKeys(model, "Expenses:Tips")
+	model = pressKey(model, tea.KeyTab)
+	model = typeKeys(model, "2")
+	model = pressKey(model, tea.KeyEnter)
+	model = confirmWrite(t, model)
+	if model.entry != nil {
+		t.Fatalf("expected form closed after save:\n%s", model.entry.View())
+	}
+
+	expected := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food:Dining
+
+2025-01-05 * "Cafe" "Dinner" ; by card
+  receipt: "r-7"
+  Assets:Checking  -10.00 USD ; card
+  Expenses:Food:Dining
+  Expenses:Tips      2.00 USD
+`
+	data, _ := os.ReadFile(ledger)
+	if string(data) != expected {
+		t.Errorf("unexpected ledger after edit:\n%s", data)
+	}
+
+	// Remove the tip posting again (the last field belongs to it)
+	model = edit(model)
+	model = pressKey(model, tea.KeyUp)
+	model = pressKey(model, tea.KeyUp)
+	model = pressKey(model, tea.KeyCtrlD)
+	model = pressKey(model, tea.KeyEnter)
+	model = confirmWrite(t, model)
+	data, _ = os.ReadFile(ledger)
+	if strings.Contains(string(data), "Expenses:Tips") {
+		t.Errorf("expected tip posting removed, got:\n%s", data)
+	}
+
+	model = typeKeys(model, "u")
+	data, _ = os.ReadFile(ledger)
+	if string(data) != expected {
+		t.Errorf("expected undo to restore the tip posting, got:\n%s", data)
+	}
+}
+
+func TestDeleteTransactionWithUndo(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2025-01-01 * "First" "One"
+  Assets:Checking  -1.00 USD
+  Expenses:Misc
+
+2025-01-02 * "Second" "Two"
+  Assets:Checking  -2.00 USD
+  Expenses:Misc
+
+2025-01-03 * "Third" "Three"
+  Assets:Checking  -3.00 USD
+  Expenses:Misc
+`
+	ledger := createTempFile(t, content)
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+
+	deleteSelected := func(model Model) Model {
+		model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+		if !strings.Contains(model.View(), "Confirm: Delete") {
+			t.Fatal("expected a confirmation dialog")
+		}
+		return confirmWrite(t, model)
+	}
+
+	model = typeKeys(model, "2")
+	model = typeKeys(model, "j")
+
+	// Cancelling keeps the transaction; q is swallowed by the dialog
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	model = typeKeys(model, "qn")
+	if file.TransactionCount() != 3 {
+		t.Fatalf("expected cancel to keep the transaction, got %d", file.TransactionCount())
+	}
+
+	model = deleteSelected(model)
+	if file.TransactionCount() != 2 || !strings.Contains(model.status(), "Deleted 2025-01-02 Second") {
+		t.Fatalf("expected Second deleted, count %d, status %q", file.TransactionCount(), model.status())
+	}
+	model = deleteSelected(model)
+	if file.TransactionCount() != 1 {
+		t.Fatalf("expected two deletions, got %d transactions", file.TransactionCount())
+	}
+
+	// Undo restores both, newest first
+	model = typeKeys(model, "u")
+	if file.TransactionCount() != 2 {
+		t.Fatalf("expected one transaction restored, got %d", file.TransactionCount())
+	}
+	model = typeKeys(model, "u")
+	data, _ := os.ReadFile(ledger)
+	if string(data) != content {
+		t.Errorf("expected ledger restored after undo, got:\n%s", data)
+	}
+	if len(model.journal.done) != 0 {
+		t.Errorf("expected empty undo stack, got %d", len(model.journal.done))
+	}
+}
+
+func TestToggleTransactionFlag(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2025-01-01 * "First" "One"
+  Assets:Checking  -1.00 USD
+  Expenses:Misc
+`
+	ledger := createTempFile(t, content)
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = typeKeys(model, "2")
+
+	read := func() string {
+		data, _ := os.ReadFile(ledger)
+		return string(data)
+	}
+
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'*'}})
+	if !strings.Contains(model.View(), "Confirm: Flag 2025-01-01 First as !") {
+		t.Fatal("expected a confirmation dialog")
+	}
+	model = confirmWrite(t, model)
+	if !strings.Contains(read(), `2025-01-01 ! "First" "One"`) {
+		t.Fatalf("expected the transaction flagged pending, got:\n%s", read())
+	}
+	if !strings.Contains(model.status(), "Flagged 2025-01-01 First as !") {
+		t.Errorf("unexpected status %q", model.status())
+	}
+
+	// Toggling again clears it; without confirm_categorize it writes at once
+	model.config.UI.ConfirmCategorize = false
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'*'}})
+	if read() != content {
+		t.Fatalf("expected the flag cleared again, got:\n%s", read())
+	}
+
+	model = typeKeys(model, "u")
+	if !strings.Contains(read(), `2025-01-01 ! "First" "One"`) {
+		t.Errorf("expected undo to restore the pending flag, got:\n%s", read())
+	}
+}
+
+func TestCopyToClipboard(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2025-01-01 * "Coffee Shop" "Latte"
+  ; paid by card
+  Assets:Checking  -4.50 USD
+  Expenses:Food:Coffee
+
+2025-01-02 * "Bakery" ""
+  Assets:Checking
+  Expenses:Food  3.00 USD
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	var copied []string
+	defer func(original func(string) error) { copyToClipboard = original }(copyToClipboard)
+	copyToClipboard = func(text string) error {
+		copied = append(copied, text)
+		return nil
+	}
+
+	// press sends a copy key, then the view's request through to the finished copy
+	press := func(model Model, k rune) Model {
+		updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{k}})
+		model = updated.(Model)
+		for _, result := range runCmd(cmd) {
+			model = send(model, result)
+		}
+		return model
+	}
+
+	model := New(file, config.DefaultConfig())
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = typeKeys(model, "2")
+
+	for _, k := range "YPA" {
+		model = press(model, k)
+	}
+	want := []string{
+		"2025-01-01 * \"Coffee Shop\" \"Latte\"\n  ; paid by card\n  Assets:Checking  -4.50 USD\n  Expenses:Food:Coffee\n",
+		"Coffee Shop",
+		"-4.50 USD",
+	}
+	if fmt.Sprint(copied) != fmt.Sprint(want) {
+		t.Errorf("expected %q copied, got %q", want, copied)
+	}
+	if !strings.Contains(model.status(), "Copied amount to the clipboard") {
+		t.Errorf("unexpected status %q", model.status())
+	}
+
+	// An elided amount can't be copied
+	model = typeKeys(model, "j")
+	model = press(model, 'A')
+	if len(copied) != 3 || !strings.Contains(model.status(), "Copy failed: the first posting has no amount") {
+		t.Errorf("expected a failed copy, got %q and status %q", copied, model.status())
+	}
+}
+
+func TestJumpToDate(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2025-03-01 * "March" ""
+  Assets:Checking  -1.00 USD
+  Expenses:Misc
+
+2025-01-15 * "January" ""
+  Assets:Checking  -2.00 USD
+  Expenses:Misc
+
+2025-02-10 * "February" ""
+  Assets:Checking  -3.00 USD
+  Expenses:Misc
+
+2025-02-20 * "Late February" ""
+  Assets:Checking  -4.00 USD
+  Expenses:Misc
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = typeKeys(model, "2")
+
+	// The first transaction on or after the date, whatever the file order
+	model = typeKeys(model, ":date 2025-02")
+	model = pressKey(model, tea.KeyEnter)
+	if view := model.View(); !strings.Contains(view, "Row 3/4") {
+		t.Errorf("expected the cursor on February, got:\n%s", view)
+	}
+
+	model = typeKeys(model, ":2025-02-11")
+	model = pressKey(model, tea.KeyEnter)
+	if view := model.View(); !strings.Contains(view, "Row 4/4") {
+		t.Errorf("expected the cursor on Late February, got:\n%s", view)
+	}
+
+	// Invalid dates and dates past the last transaction keep the prompt open with an error;
+	// q is typed into the prompt rather than quitting
+	model = typeKeys(model, ":2026q")
+	model = pressKey(model, tea.KeyEnter)
+	if view := model.View(); !strings.Contains(view, "invalid date: 2026q") {
+		t.Errorf("expected an invalid date error, got:\n%s", view)
+	}
+	model = pressKey(model, tea.KeyEsc)
+	model = typeKeys(model, ":2026")
+	model = pressKey(model, tea.KeyEnter)
+	if view := model.View(); !strings.Contains(view, "no transactions shown on or after 2026-01-01") || !strings.Contains(view, "Row 4/4") {
+		t.Errorf("expected a not found error with the cursor kept, got:\n%s", view)
+	}
+}
+
+func TestJournalUndoRedo(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food:Coffee
+
+2025-01-01 ! "Blue Bottle" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized  5.00 USD
+
+2025-01-02 * "Bakery" "Bread"
+  Assets:Checking  -3.00 USD
+  Expenses:Misc
+`
+	ledger := createTempFile(t, content)
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+
+	keys := func(model Model, s string) Model {
+		for _, r := range s {
+			model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return model
+	}
+	read := func() string {
+		data, _ := os.ReadFile(ledger)
+		return string(data)
+	}
+
+	// Review: categorize the flagged transaction by hand
+	model = keys(model, "re")
+	model = send(model, tea.KeyMsg{Type: tea.KeyCtrlU})
+	model = keys(model, "Expenses:Food:Coffee")
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	model = keys(model, "y")
+	afterReview := read()
+
+	// Delete the bakery transaction
+	model = keys(model, "2j")
+	model = keys(model, "dy")
+	afterDelete := read()
+
+	// Add a transaction
+	model = keys(model, "a")
+	model = send(model, tea.KeyMsg{Type: tea.KeyTab})
+	model = keys(model, "Market")
+	model = send(model, tea.KeyMsg{Type: tea.KeyTab})
+	model = send(model, tea.KeyMsg{Type: tea.KeyTab})
+	model = keys(model, "Expenses:Food:Coffee")
+	model = send(model, tea.KeyMsg{Type: tea.KeyTab})
+	model = keys(model, "7")
+	model = send(model, tea.KeyMsg{Type: tea.KeyTab})
+	model = keys(model, "Assets:Checking")
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	model = keys(model, "y")
+	afterAdd := read()
+
+	if afterReview == content || afterDelete == afterReview || afterAdd == afterDelete {
+		t.Fatalf("expected three distinct edits, got:\n%s", afterAdd)
+	}
+	if len(model.journal.done) != 3 {
+		t.Fatalf("expected 3 journaled edits, got %d", len(model.journal.done))
+	}
+
+	// Undo everything, newest first
+	for _, expected := range []string{afterDelete, afterReview, content} {
+		model = keys(model, "u")
+		if read() != expected {
+			t.Fatalf("unexpected ledger after undo (%s):\n%s", model.status(), read())
+		}
+	}
+	model = keys(model, "u")
+	if model.status() != "Nothing to undo" {
+		t.Errorf("expected nothing to undo, got %q", model.status())
+	}
+
+	// Redo everything in the original order
+	for _, expected := range []string{afterReview, afterDelete, afterAdd} {
+		model = send(model, tea.KeyMsg{Type: tea.KeyCtrlR})
+		if read() != expected {
+			t.Fatalf("unexpected ledger after redo (%s):\n%s", model.status(), read())
+		}
+	}
+	if file.TransactionCount() != 2 {
+		t.Errorf("expected transaction count refreshed after redo, got %d", file.TransactionCount())
+	}
+
+	// A new edit after undo discards the redo history
+	model = keys(model, "u")
+	model = keys(model, "2")
+	model = keys(model, "gdy")
+	model = send(model, tea.KeyMsg{Type: tea.KeyCtrlR})
+	if model.status() != "Nothing to redo" {
+		t.Errorf("expected redo history cleared, got %q", model.status())
+	}
+}
+
+func TestAccountTree(t *testing.T) {
+	content := `2024-01-01 open Assets:Bank:Checking
+2024-01-01 open Assets:Bank:Savings
+2024-01-01 open Expenses:Food:Coffee
+2024-01-01 open Expenses:Food:Groceries
+
+2024-01-02 * "Employer" "Deposit"
+  Assets:Bank:Savings  1000.00 USD
+  Assets:Bank:Checking
+
+2024-01-03 * "Blue Bottle" "Latte"
+  Assets:Bank:Checking  -5.50 USD
+  Expenses:Food:Coffee  5.50 USD
+
+2024-01-04 * "Market" "Groceries"
+  Assets:Bank:Checking  -20.00 USD
+  Expenses:Food:Groceries
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+	model.accounts = model.accounts.SetSize(120, 36)
+	model = typeKeys(model, "3")
+	if model.currentView != AccountsView {
+		t.Fatalf("expected accounts view, got %d", model.currentView)
+	}
+
+	// Account types start open one level with aggregated balances
+	view := model.View()
+	for _, want := range []string{"▾ Assets", "▸ Bank", "-25.50 USD", "▸ Food", "25.50 USD"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in accounts view:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Checking") {
+		t.Errorf("expected Bank collapsed:\n%s", view)
+	}
+
+	// Expand Bank
+	model = typeKeys(model, "jl")
+	view = model.View()
+	if !strings.Contains(view, "▾ Bank") || !strings.Contains(view, "Checking") {
+		t.Errorf("expected Bank expanded:\n%s", view)
+	}
+	if !strings.Contains(view, "-1025.50 USD") || !strings.Contains(view, "1000.00 USD") {
+		t.Errorf("expected leaf balances with the elided amount filled in:\n%s", view)
+	}
+
+	// h on a leaf jumps to the parent, h again collapses it
+	model = typeKeys(model, "jhh")
+	if view = model.View(); strings.Contains(view, "Checking") {
+		t.Errorf("expected Bank collapsed again:\n%s", view)
+	}
+
+	// Filtering narrows the tree as the query is typed, keeping ancestors for context
+	model = typeKeys(model, "/groc")
+	view = model.View()
+	if !strings.Contains(view, "Groceries") || !strings.Contains(view, "▾ Food") {
+		t.Errorf("expected Groceries and its ancestors:\n%s", view)
+	}
+	if strings.Contains(view, "Coffee") || strings.Contains(view, "Bank") {
+		t.Errorf("expected non-matching accounts hidden:\n%s", view)
+	}
+	// Filter keys don't trigger global shortcuts
+	if model.currentView != AccountsView {
+		t.Errorf("expected to stay in accounts view while filtering")
+	}
+
+	model = pressKey(model, tea.KeyEsc)
+	if view = model.View(); !strings.Contains(view, "Bank") {
+		t.Errorf("expected filter cleared:\n%s", view)
+	}
+
+	// Expand all reveals every leaf
+	model = typeKeys(model, "+")
+	view = model.View()
+	for _, want := range []string{"Checking", "Savings", "Coffee", "Groceries"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q after expanding all:\n%s", want, view)
+		}
+	}
+}
+
+func TestIncomeStatement(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Income:Salary
+2024-01-01 open Expenses:Food:Coffee
+2024-01-01 open Expenses:Rent
+
+2024-02-01 * "Employer" "Salary"
+  Assets:Checking  3000.00 USD
+  Income:Salary
+
+2024-02-03 * "Landlord" "Rent"
+  Assets:Checking  -1200.00 USD
+  Expenses:Rent  1200.00 USD
+
+2024-03-01 * "Employer" "Salary"
+  Assets:Checking  3000.00 USD
+  Income:Salary
+
+2024-03-02 * "Blue Bottle" "Latte"
+  Assets:Checking  -5.50 USD
+  Expenses:Food:Coffee  5.50 USD
+
+2024-03-03 * "Landlord" "Rent"
+  Assets:Checking  -1250.00 USD
+  Expenses:Rent  1250.00 USD
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 140, 40, true
+	model.reports = model.reports.SetSize(140, 36)
+	model.transactions = model.transactions.SetSize(140, 36)
+	model = typeKeys(model, "4")
+	if model.currentView != ReportsView {
+		t.Fatalf("expected reports view, got %d", model.currentView)
+	}
+
+	// Opens on the latest month, compared with the month before
+	view := model.View()
+	for _, want := range []string{"Income Statement - Mar 2024 vs Feb 2024", "3000.00 USD", "+50.00 USD", "+5.50 USD", "1744.50 USD", "-55.50 USD"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in income statement:\n%s", want, view)
+		}
+	}
+
+	// Step forward a month: Food still shows, having dropped to nothing
+	model = typeKeys(model, "]")
+	view = model.View()
+	if !strings.Contains(view, "Apr 2024 vs Mar 2024") || !strings.Contains(view, "Food") || !strings.Contains(view, "-5.50 USD") {
+		t.Errorf("expected April with previous-period accounts:\n%s", view)
+	}
+
+	// Year view has no previous year activity, so the change equals the total
+	model = typeKeys(model, "y")
+	if view = model.View(); !strings.Contains(view, "Income Statement - 2024 vs 2023") || !strings.Contains(view, "+6000.00 USD") {
+		t.Errorf("expected yearly statement:\n%s", view)
+	}
+	// Back to months lands on the last active month of the year
+	model = typeKeys(model, "m")
+	if view = model.View(); !strings.Contains(view, "Mar 2024 vs Feb 2024") {
+		t.Errorf("expected March after switching back to months:\n%s", view)
+	}
+
+	// Drill down from Expenses:Rent into its transactions for March
+	// Rows: Income, Salary, Expenses, Food, Rent
+	model = typeKeys(model, "jjjj")
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected drill-down into transactions, got view %d", model.currentView)
+	}
+	view = model.View()
+	if !strings.Contains(view, "1250.00") || strings.Contains(view, "1200.00") || strings.Contains(view, "Blue Bottle") {
+		t.Errorf("expected only March rent:\n%s", view)
+	}
+}
+
+func TestReportRun(t *testing.T) {
+	content := `2024-01-01 * "Bank" "Opening"
+  Assets:Checking  1000.00 USD
+  Equity:Opening
+
+2024-02-01 * "Employer" "Salary"
+  Assets:Checking  3000.00 USD
+  Income:Salary
+
+2024-02-03 * "Card" "Groceries"
+  Liabilities:Card  -80.50 USD
+  Expenses:Food:Groceries
+
+2024-03-02 * "Landlord" "Rent"
+  Assets:Checking  -1200.00 USD
+  Expenses:Rent
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	rows := func(kind string, period beancount.Period) []string {
+		t.Helper()
+		table, err := reports.Run(file, kind, period, "")
+		if err != nil {
+			t.Fatalf("%s report failed: %v", kind, err)
+		}
+		var rows []string
+		for _, row := range table.Rows {
+			rows = append(rows, strings.Join(row, " "))
+		}
+		return rows
+	}
+	year := beancount.Period{Unit: beancount.PeriodYear, Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	february := beancount.Period{Unit: beancount.PeriodMonth, Start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		kind   string
+		period beancount.Period
+		want   []string
+	}{
+		{"income", february, []string{"Income USD 3000.00 0.00", "Income:Salary USD 3000.00 0.00", "Expenses USD 80.50 0.00", "Expenses:Food USD 80.50 0.00", "Expenses:Food:Groceries USD 80.50 0.00"}},
+		{"spending", year, []string{"2024 Expenses:Rent 1200.00 USD", "2024 Expenses:Food 80.50 USD"}},
+		{"balance", february, []string{"Assets USD 4000.00", "Assets:Checking USD 4000.00", "Liabilities USD -80.50", "Liabilities:Card USD -80.50", "Equity USD -1000.00", "Equity:Opening USD -1000.00"}},
+		{"networth", beancount.Period{Unit: beancount.PeriodQuarter, Start: year.Start}, []string{"2024-01-31 USD 1000.00", "2024-02-29 USD 3919.50", "2024-03-31 USD 2719.50"}},
+		{"savings", february, []string{"2024-02 3000.00 80.50 2919.50 97.3    USD"}},
+	}
+	for _, tt := range tests {
+		if got := rows(tt.kind, tt.period); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("unexpected %s report:\n%s\nwant:\n%s", tt.kind, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+
+	// Every encoding keeps the cents the ledger wrote
+	spending, err := reports.Run(file, "spending", year, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodings := map[string]string{
+		"table": "period  category        amount  commodity\n------  -------------  -------  ---------\n  2024  Expenses:Rent  1200.00  USD\n  2024  Expenses:Food    80.50  USD\n",
+		"csv":   "period,category,amount,commodity\n2024,Expenses:Rent,1200.00,USD\n2024,Expenses:Food,80.50,USD\n",
+		"json": "[\n  {\"period\": \"2024\", \"category\": \"Expenses:Rent\", \"amount\": \"1200.00\", \"commodity\": \"USD\"}," +
+			"\n  {\"period\": \"2024\", \"category\": \"Expenses:Food\", \"amount\": \"80.50\", \"commodity\": \"USD\"}\n]\n",
+	}
+	for encoding, want := range encodings {
+		data, err := export.Encode(spending, encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("unexpected %s encoding:\n%s\nwant:\n%s", encoding, data, want)
+		}
+	}
+
+	if _, err := reports.Run(file, "cashflow", year, ""); err == nil {
+		t.Error("expected an error for an unknown report")
+	}
+}
+
+func TestSpendingReport(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food:Coffee
+2024-01-01 open Expenses:Food:Groceries
+2024-01-01 open Expenses:Rent
+
+2024-02-03 * "Landlord" "Rent"
+  Assets:Checking  -1200.00 USD
+  Expenses:Rent  1200.00 USD
+
+2024-02-10 * "Market" "Groceries"
+  Assets:Checking  -80.00 USD
+  Expenses:Food:Groceries
+
+2024-03-02 * "Blue Bottle" "Latte"
+  Assets:Checking  -20.00 USD
+  Expenses:Food:Coffee  20.00 USD
+
+2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -180.00 USD
+  Expenses:Food:Groceries  180.00 USD
+
+2024-03-06 * "Landlord" "Rent"
+  Assets:Checking  -800.00 USD
+  Expenses:Rent  800.00 USD
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 100, 40, true
+	model.reports = model.reports.SetSize(100, 36)
+	model.transactions = model.transactions.SetSize(100, 36)
+	model = typeKeys(model, "4")
+	model = pressKey(model, tea.KeyTab)
+
+	// March: Rent first, then Food aggregated from its subcategories
+	view := model.View()
+	for _, want := range []string{"Spending by Category - Mar 2024", "Total 1000.00 USD", "800.00   80%", "200.00   20%", "█"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in spending report:\n%s", want, view)
+		}
+	}
+	if strings.Index(view, "Rent") > strings.Index(view, "Food") {
+		t.Errorf("expected categories largest first:\n%s", view)
+	}
+
+	// Selecting Food charts its history, including the elided February amount
+	model = typeKeys(model, "j")
+	view = model.View()
+	if !strings.Contains(view, "Food, last 6 months") || !strings.Contains(view, "80.00") {
+		t.Errorf("expected Food history:\n%s", view)
+	}
+
+	// Stepping back keeps Food selected
+	model = typeKeys(model, "[")
+	if view = model.View(); !strings.Contains(view, "Feb 2024") || !strings.Contains(view, "Total 1280.00 USD") {
+		t.Errorf("expected February spending:\n%s", view)
+	}
+	model = typeKeys(model, "]")
+
+	// Drill into Food's March transactions
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected drill-down into transactions, got view %d", model.currentView)
+	}
+	view = model.View()
+	if !strings.Contains(view, "Blue Bottle") || !strings.Contains(view, "180.00") || strings.Contains(view, "Landlord") {
+		t.Errorf("expected only March food transactions:\n%s", view)
+	}
+}
+
+func TestBudgetsView(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food:Groceries
+2024-01-01 open Expenses:Rent
+
+2024-01-01 custom "budget" Expenses:Food "monthly" 400.00 USD
+2024-01-01 custom "budget" Expenses:Rent "monthly" 1000.00 USD
+
+2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -450.00 USD
+  Expenses:Food:Groceries
+
+2024-03-06 * "Landlord" "Rent"
+  Assets:Checking  -950.00 USD
+  Expenses:Rent  950.00 USD
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+	model.budgets = model.budgets.SetSize(120, 36)
+	model.transactions = model.transactions.SetSize(120, 36)
+	model = typeKeys(model, "6")
+	if model.currentView != BudgetsView {
+		t.Fatalf("expected budgets view, got %d", model.currentView)
+	}
+
+	view := model.View()
+	for _, want := range []string{"Budgets - Mar 2024", "Food", "450.00", "-50.00", "113%", "Rent", "95%", "Total USD", "1400.00", "Budgets from ledger"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in budgets view:\n%s", want, view)
+		}
+	}
+
+	// A quarter holds three months of budget
+	model = typeKeys(model, "Q")
+	if view = model.View(); !strings.Contains(view, "2024 Q1") || !strings.Contains(view, "1200.00") {
+		t.Errorf("expected quarterly budget:\n%s", view)
+	}
+	model = typeKeys(model, "m")
+	if view = model.View(); !strings.Contains(view, "Budgets - Jan 2024") {
+		t.Errorf("expected first month of the quarter:\n%s", view)
+	}
+	model = typeKeys(model, "]]")
+
+	// Drill into Food's transactions
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected drill-down into transactions, got view %d", model.currentView)
+	}
+	if view = model.View(); !strings.Contains(view, "Market") || strings.Contains(view, "Landlord") {
+		t.Errorf("expected only food transactions:\n%s", view)
+	}
+}
+
+func TestBudgetsFromFile(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -50.00 USD
+  Expenses:Food  50.00 USD
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = filepath.Join(t.TempDir(), "budgets.yaml")
+	budgets := "budgets:\n  - account: Expenses:Food\n    amount: 200\n    commodity: USD\n"
+	if err := os.WriteFile(cfg.Files.BudgetsFile, []byte(budgets), 0644); err != nil {
+		t.Fatalf("failed to write budgets file: %v", err)
+	}
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+	model.budgets = model.budgets.SetSize(120, 36)
+	model = typeKeys(model, "6")
+
+	view := model.View()
+	if !strings.Contains(view, "200.00") || !strings.Contains(view, "25%") || !strings.Contains(view, "Budgets from "+cfg.Files.BudgetsFile) {
+		t.Errorf("expected budgets from the YAML file:\n%s", view)
+	}
+}
+
+func TestDashboardUpcomingBills(t *testing.T) {
+	// Monthly charges relative to today: one due soon, one overdue
+	today := time.Now()
+	var ledger strings.Builder
+	for i := 3; i >= 1; i-- {
+		due := today.AddDate(0, -i, 5).Format("2006-01-02")
+		fmt.Fprintf(&ledger, "%s * \"Streamy\" \"Subscription\"\n  Assets:Checking  -12.99 USD\n  Expenses:Subscriptions  12.99 USD\n\n", due)
+		late := today.AddDate(0, -i, -10).Format("2006-01-02")
+		fmt.Fprintf(&ledger, "%s * \"Power Co\" \"Electricity\"\n  Assets:Checking  -80.00 USD\n  Expenses:Utilities  80.00 USD\n\n", late)
+	}
+
+	file, err := beancount.Open(createTempFile(t, ledger.String()))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+	model.dashboard = model.dashboard.SetSize(120, 36)
+
+	view := model.View()
+	for _, want := range []string{"Upcoming Bills", "1 expected charge(s) missing", "Streamy", "12.99 USD", "Power Co", "missing (monthly)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q on dashboard:\n%s", want, view)
+		}
+	}
+	if strings.Index(view, "Power Co") > strings.Index(view, "Streamy") {
+		t.Error("expected missing charge listed before upcoming ones")
+	}
+}
+
+func TestSubscriptionsReport(t *testing.T) {
+	content := `2024-01-15 * "Streamy" "Subscription"
+  Assets:Checking  -9.99 USD
+  Expenses:Subscriptions  9.99 USD
+
+2024-02-15 * "Streamy" "Subscription"
+  Assets:Checking  -9.99 USD
+  Expenses:Subscriptions  9.99 USD
+
+2024-03-15 * "Streamy" "Subscription"
+  Assets:Checking  -11.99 USD
+  Expenses:Subscriptions  11.99 USD
+
+2024-01-03 * "Fit Club" "Membership"
+  Assets:Checking  -40.00 USD
+  Expenses:Health
+
+2024-02-03 * "Fit Club" "Membership"
+  Assets:Checking  -40.00 USD
+  Expenses:Health
+
+2024-03-04 * "Fit Club" "Membership"
+  Assets:Checking  -40.00 USD
+  Expenses:Health
+
+2024-03-04 * "Corner Cafe" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Food
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+	model.reports = model.reports.SetSize(120, 36)
+	model.transactions = model.transactions.SetSize(120, 36)
+	model = typeKeys(model, "4")
+	model = pressKey(model, tea.KeyTab)
+	model = pressKey(model, tea.KeyTab)
+
+	view := model.View()
+	for _, want := range []string{"Subscriptions - 2 detected", "Fit Club", "480.00", "143.88", "▲ +2.00", "Total USD", "51.99", "623.88"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in subscriptions report:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Corner Cafe") {
+		t.Error("one-off purchase listed as a subscription")
+	}
+	if strings.Index(view, "Fit Club") > strings.Index(view, "Streamy") {
+		t.Error("expected subscriptions ordered by annual cost")
+	}
+
+	// Drill into the Streamy charges
+	model = typeKeys(model, "j")
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected drill-down into transactions, got view %d", model.currentView)
+	}
+	if view = model.View(); !strings.Contains(view, "(3 of 7)") || strings.Contains(view, "Fit Club") {
+		t.Errorf("expected only the three Streamy charges:\n%s", view)
+	}
+}
+
+func TestCalendarReport(t *testing.T) {
+	content := `2024-03-04 * "Market" "Groceries"
+  Assets:Checking  -80.00 USD
+  Expenses:Food
+
+2024-03-04 * "Corner Cafe" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Food
+
+2024-03-12 * "Garage" "Repair"
+  Assets:Checking  -300.00 USD
+  Expenses:Car
+
+2024-03-15 * "Employer" "Salary"
+  Assets:Checking  1000.00 USD
+  Income:Salary
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+	model.reports = model.reports.SetSize(120, 36)
+	model.transactions = model.transactions.SetSize(120, 36)
+	model = typeKeys(model, "4")
+	for range 3 {
+		model = pressKey(model, tea.KeyTab)
+	}
+
+	// Opens on the latest transaction's day, which has no spending
+	view := model.View()
+	for _, want := range []string{"Daily Spending - Mar 2024", "Mo      Tu", "Fri 2024-03-15", "0.00 USD in 0 transactions", "total 385.00 USD", "up to 300.00", "███"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in calendar report:\n%s", want, view)
+		}
+	}
+
+	// Down a week and back, then left a few days to the 4th
+	model = pressKey(model, tea.KeyDown)
+	if view = model.View(); !strings.Contains(view, "Fri 2024-03-22") {
+		t.Errorf("expected down to move a week:\n%s", view)
+	}
+	model = pressKey(model, tea.KeyUp)
+	model = pressKey(model, tea.KeyUp)
+	for range 4 {
+		model = pressKey(model, tea.KeyLeft)
+	}
+	if view = model.View(); !strings.Contains(view, "Mon 2024-03-04  85.00 USD in 2 transactions") {
+		t.Errorf("expected the 4th selected:\n%s", view)
+	}
+
+	// The year layout keeps the day selected
+	model = typeKeys(model, "y")
+	if view = model.View(); !strings.Contains(view, "Daily Spending - 2024") || !strings.Contains(view, "Mon 2024-03-04") {
+		t.Errorf("expected the year calendar:\n%s", view)
+	}
+
+	// Enter shows the day's transactions
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected drill-down into transactions, got view %d", model.currentView)
+	}
+	if view = model.View(); !strings.Contains(view, "date:2024-03-04") || !strings.Contains(view, "Corner Cafe") || strings.Contains(view, "Garage") {
+		t.Errorf("expected only the 4th's transactions:\n%s", view)
+	}
+}
+
+func TestForecastReport(t *testing.T) {
+	// Half a year of pay, rent and groceries leaving 400 less each month, up to this month
+	this := time.Now()
+	month := func(k int) string {
+		return time.Date(this.Year(), this.Month()+time.Month(k), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+	}
+	var content strings.Builder
+	fmt.Fprintf(&content, "%s-01 * \"Opening\"\n  Assets:Checking  3000.00 USD\n  Equity:Opening-Balances\n\n", month(-7))
+	for k := -6; k <= 0; k++ {
+		fmt.Fprintf(&content, "%s-01 * \"Employer\" \"Pay\"\n  Assets:Checking  3000.00 USD\n  Income:Salary\n\n", month(k))
+		fmt.Fprintf(&content, "%s-01 * \"Landlord\" \"Rent\"\n  Expenses:Rent  2000.00 USD\n  Assets:Checking\n\n", month(k))
+		if k < 0 {
+			fmt.Fprintf(&content, "%s-20 * \"Store %d\" \"Groceries\"\n  Expenses:Food  1400.00 USD\n  Assets:Checking\n\n", month(k), -k)
+		}
+	}
+	file, err := beancount.Open(createTempFile(t, content.String()))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+	model.reports = model.reports.SetSize(120, 36)
+	model = typeKeys(model, "4")
+	for range 4 {
+		model = pressKey(model, tea.KeyTab)
+	}
+
+	// 1,600 left at the start of this month falls by 400 a month once rent is paid
+	view := model.View()
+	for _, want := range []string{"Cash Flow Forecast - 6 months ([ ]), average of the 6 before", "Assets:Checking", "1600.00 USD", "-2000.00", "1600.00"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in forecast report:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "⚠") {
+		t.Errorf("expected no warning within six months:\n%s", view)
+	}
+
+	model = typeKeys(model, "]]]]] ")
+	view = model.View()
+	for _, want := range []string{"11 months", "linear", "⚠ negative in"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the longer forecast:\n%s", want, view)
+		}
+	}
+}
+
+func TestSavingsReport(t *testing.T) {
+	// 4,000 earned and 3,000 spent each month of 2024 to April, but 5,000 in March
+	var content strings.Builder
+	for month := 1; month <= 4; month++ {
+		spent := "3000.00"
+		if month == 3 {
+			spent = "5000.00"
+		}
+		fmt.Fprintf(&content, "2024-%02d-01 * \"Employer\" \"Pay\"\n  Assets:Checking  4000.00 USD\n  Income:Salary\n\n", month)
+		fmt.Fprintf(&content, "2024-%02d-10 * \"Landlord %d\" \"Rent\"\n  Expenses:Rent  %s USD\n  Assets:Checking\n\n", month, month, spent)
+	}
+	file, err := beancount.Open(createTempFile(t, content.String()))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+	model.reports = model.reports.SetSize(120, 36)
+	model.transactions = model.transactions.SetSize(120, 36)
+	model = typeKeys(model, "4")
+	for range 5 {
+		model = pressKey(model, tea.KeyTab)
+	}
+
+	// April saved a quarter; February to April saved 1,000 of 12,000
+	view := model.View()
+	for _, want := range []string{"Savings Rate - Apr 2024 ([ ])", "Rate 25.0%", "3 months 8.3%", "12 months —", "12.5% overall",
+		"Mar 2024", "-25.0%", "-37.5 pts"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in savings report:\n%s", want, view)
+		}
+	}
+
+	model = typeKeys(model, "[")
+	if view = model.View(); !strings.Contains(view, "Savings Rate - Mar 2024") || !strings.Contains(view, "Rate -25.0%") {
+		t.Errorf("expected March reported:\n%s", view)
+	}
+
+	// Enter shows the transactions of the month furthest from the overall rate
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected drill-down into transactions, got view %d", model.currentView)
+	}
+	if view = model.View(); !strings.Contains(view, "Landlord 3") || strings.Contains(view, "Landlord 4") {
+		t.Errorf("expected only March's transactions:\n%s", view)
+	}
+}
+
+func TestTripsReport(t *testing.T) {
+	content := `2024-03-01 * "Airline" "Flight to Tokyo" #trip-japan
+  Expenses:Travel  900.00 USD
+  Assets:Checking
+
+2024-03-10 * "Hotel" "Five nights" #trip-japan
+  Expenses:Lodging  700.00 USD
+  Assets:Checking
+
+2024-06-01 event "location" "Lisbon"
+2024-06-08 event "location" "Boston"
+
+2024-06-03 * "Cafe" "Pastries"
+  Expenses:Food  20.00 USD
+  Assets:Checking
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+	model.reports = model.reports.SetSize(120, 36)
+	model.transactions = model.transactions.SetSize(120, 36)
+	model = typeKeys(model, "4")
+	for range 6 {
+		model = pressKey(model, tea.KeyTab)
+	}
+
+	// Boston has no spending; Lisbon runs to the day before it
+	view := model.View()
+	for _, want := range []string{"Trips & Events - 2 from #trip- tags and location events", "Lisbon", "2024-06-07",
+		"#trip-japan", "1600.00 USD", "160.00 USD", "Lisbon by category"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in trips report:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Boston") {
+		t.Errorf("expected no trip without spending:\n%s", view)
+	}
+
+	model = pressKey(model, tea.KeyDown)
+	if view = model.View(); !strings.Contains(view, "#trip-japan by category") || !strings.Contains(view, "Travel") {
+		t.Errorf("expected the Japan trip's categories:\n%s", view)
+	}
+
+	// Enter shows the trip's tagged transactions
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected drill-down into transactions, got view %d", model.currentView)
+	}
+	if view = model.View(); !strings.Contains(view, "Airline") || strings.Contains(view, "Cafe") {
+		t.Errorf("expected only the trip's transactions:\n%s", view)
+	}
+}
+
+func TestAnomalies(t *testing.T) {
+	// Dates are relative to today because the dashboard lists the last 30 days
+	today := time.Now()
+	day := func(daysAgo int) string { return today.AddDate(0, 0, -daysAgo).Format("2006-01-02") }
+	var content strings.Builder
+	for i, amount := range []string{"50.00", "40.00", "60.00", "200.00"} {
+		fmt.Fprintf(&content, "%s * \"Grocer\" \"Shop %d\"\n  Expenses:Food  %s USD\n  Assets:Checking\n\n", day(40-i*7), i+1, amount)
+	}
+	fmt.Fprintf(&content, "%s * \"Jeweler\" \"Ring\"\n  Expenses:Gifts  900.00 USD\n  Assets:Checking\n\n", day(50))
+	fmt.Fprintf(&content, "%s * \"Cafe\" \"Latte\"\n  Expenses:Food  4.50 USD\n  Assets:Checking\n\n", day(3))
+	fmt.Fprintf(&content, "%s * \"Cafe\" \"Latte again\"\n  Expenses:Food  4.50 USD\n  Assets:Checking\n", day(2))
+	file, err := beancount.Open(createTempFile(t, content.String()))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Dashboard.Layout = [][]string{{"anomalies"}}
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+	model.dashboard = model.dashboard.SetSize(120, 38)
+
+	// The jeweler's charge is older than the widget lists
+	view := model.View()
+	for _, want := range []string{"Anomalies", "Cafe", "repeats", "Grocer", "4.0× usual"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in anomalies widget:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Jeweler") {
+		t.Errorf("expected only the last 30 days:\n%s", view)
+	}
+
+	model.reports = model.reports.SetSize(120, 36)
+	model.transactions = model.transactions.SetSize(120, 36)
+	model = typeKeys(model, "4")
+	for range 7 {
+		model = pressKey(model, tea.KeyTab)
+	}
+	view = model.View()
+	for _, want := range []string{"Anomalies - 3 unusual transactions", "possible duplicate", "4.0× the usual 50.00 USD",
+		"new merchant", "Jeweler", "new payees from 250.00"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in anomalies report:\n%s", want, view)
+		}
+	}
+
+	// Enter shows the repeated pair
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected drill-down into transactions, got view %d", model.currentView)
+	}
+	if view = model.View(); !strings.Contains(view, "(2 of 7)") || strings.Contains(view, "Grocer") {
+		t.Errorf("expected only the cafe's repeated charges:\n%s", view)
+	}
+}
+
+func TestReportsCache(t *testing.T) {
+	ledger := func(salary string) string {
+		return `2024-03-01 * "Employer" "Salary"
+  Assets:Checking  ` + salary + ` USD
+  Income:Salary
+
+2024-03-03 * "Landlord" "Rent"
+  Assets:Checking  -1250.00 USD
+  Expenses:Rent  1250.00 USD
+`
+	}
+	path := createTempFile(t, ledger("3000.00"))
+	file, err := beancount.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 140, 40, true
+	model.reports = model.reports.SetSize(140, 36)
+	model = typeKeys(model, "4")
+	first := model.View()
+	if !strings.Contains(first, "3000.00 USD") {
+		t.Fatalf("expected the salary in the income statement:\n%s", first)
+	}
+
+	// Leaving and coming back, or stepping away a period and back, shows the same report
+	model = typeKeys(model, "14[]")
+	if view := model.View(); view != first {
+		t.Errorf("expected the same report after coming back:\n%s\nwant:\n%s", view, first)
+	}
+
+	// An edit to the ledger is reported once it is reloaded
+	if err := os.WriteFile(path, []byte(ledger("3500.00")), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+	if err := file.Reload(); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	model = typeKeys(model, "14")
+	if view := model.View(); !strings.Contains(view, "3500.00 USD") || strings.Contains(view, "3000.00 USD") {
+		t.Errorf("expected the edited salary:\n%s", view)
+	}
+}
+
+func TestGoalsWidget(t *testing.T) {
+	// Dates are relative to today because goals are tracked to this month
+	today := time.Now()
+	thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	content := fmt.Sprintf(`%[3]s * "Bistro" "Dinner"
+  Expenses:Dining  500.00 USD
+  Assets:Checking
+
+%[3]s * "Bank" "Savings"
+  Assets:Savings  5000.00 USD
+  Assets:Checking
+
+%[2]s * "Bistro" "Dinner"
+  Expenses:Dining  200.00 USD
+  Assets:Checking
+
+%[1]s * "Bistro" "Dinner"
+  Expenses:Dining  400.00 USD
+  Assets:Checking
+`, thisMonth.Format("2006-01-02"), thisMonth.AddDate(0, -1, 0).Format("2006-01-02"), thisMonth.AddDate(0, -2, 0).Format("2006-01-02"))
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Dashboard.Layout = [][]string{{"goals"}}
+	cfg.Goals = []config.GoalConfig{
+		{Name: "Dining", Accounts: []string{"Expenses:Dining"}, Limit: 300},
+		{Name: "Emergency fund", Accounts: []string{"Assets:Savings"}, Target: 1000, By: thisMonth.AddDate(1, 0, 0).Format("2006-01-02")},
+	}
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+	model.dashboard = model.dashboard.SetSize(120, 38)
+
+	view := model.View()
+	for _, want := range []string{"Goals", "Dining", "400.00 / 300.00 USD", "over limit", "1 of 2 months under ✗✓",
+		"Emergency fund", "5000.00 / 1000.00 USD", "reached"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in goals widget:\n%s", want, view)
+		}
+	}
+}
+
+func TestDashboardWidgets(t *testing.T) {
+	// Dates are relative to today because the dashboard reports on the current month
+	today := time.Now()
+	thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+	content := fmt.Sprintf(`%[1]s * "Employer" "Salary"
+  Assets:Checking  1000.00 USD
+  Income:Salary
+
+%[1]s * "Market" "Groceries"
+  Assets:Checking  -80.00 USD
+  Expenses:Food
+
+%[2]s * "Market" "Groceries"
+  Assets:Checking  -60.00 USD
+  Expenses:Food
+
+%[2]s * "Cafe" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Food
+
+%[2]s * "Mystery" "Unknown charge"
+  Assets:Checking  -15.00 USD
+  Expenses:Uncategorized
+`, lastMonth.Format("2006-01-02"), thisMonth.Format("2006-01-02"))
+
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model.width, model.height, model.ready = 120, 40, true
+	model.dashboard = model.dashboard.SetSize(120, 38)
+
+	view := model.View()
+	for _, want := range []string{"Net Worth", "840.00 USD", "Spending - ", "This month", "80.00 USD", "Uncategorized", "Top Merchants", "Mystery", "Recent Transactions", "Upcoming Bills"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q on default dashboard:\n%s", want, view)
+		}
+	}
+
+	// A custom layout shows only its widgets
+	cfg := config.DefaultConfig()
+	cfg.Dashboard.Layout = [][]string{{"uncategorized", "top_merchants"}}
+	model = New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+	model.dashboard = model.dashboard.SetSize(120, 38)
+
+	view = model.View()
+	if !strings.Contains(view, "transactions need a category") || !strings.Contains(view, "60.00") {
+		t.Errorf("expected configured widgets:\n%s", view)
+	}
+	for _, hidden := range []string{"Net Worth", "Recent Transactions", "Upcoming Bills"} {
+		if strings.Contains(view, hidden) {
+			t.Errorf("expected %q hidden by the layout:\n%s", hidden, view)
+		}
+	}
+	if strings.Index(view, "Uncategorized") > strings.Index(view, "Top Merchants") {
+		t.Error("expected widgets in layout order")
+	}
+}
+
+func TestCurrencySelector(t *testing.T) {
+	// Dates are relative to today because the dashboard reports on the current month
+	today := time.Now()
+	thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	content := fmt.Sprintf(`%[2]s price EUR 1.00 USD
+%[1]s price EUR 1.20 USD
+
+%[1]s * "Employer" "Salary"
+  Assets:Checking  1000.00 USD
+  Income:Salary
+
+%[1]s * "Boulangerie" "Bread"
+  Assets:Wallet  -50.00 EUR
+  Expenses:Food
+
+%[1]s * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`, thisMonth.Format("2006-01-02"), thisMonth.AddDate(-2, 0, 0).Format("2006-01-02"))
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Dashboard.Layout = [][]string{{"net_worth", "spending"}}
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+	model.dashboard = model.dashboard.SetSize(120, 38)
+	model.reports = model.reports.SetSize(120, 36)
+
+	// Native commodities side by side
+	view := ansi.Strip(model.View())
+	if !strings.Contains(view, "980.00 USD") || !strings.Contains(view, "-50.00 EUR") {
+		t.Errorf("expected native net worth per commodity:\n%s", view)
+	}
+
+	// Converted at this month's price
+	model = typeKeys(model, "C")
+	view = ansi.Strip(model.View())
+	for _, want := range []string{"Totals in USD", "- in USD", "920.00 USD", "80.00 USD"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q on the dashboard in USD:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "EUR") {
+		t.Errorf("expected no EUR totals once converted:\n%s", view)
+	}
+
+	model = typeKeys(model, "4")
+	if view = ansi.Strip(model.View()); !strings.Contains(view, "in USD") || !strings.Contains(view, "80.00") || strings.Contains(view, "EUR") {
+		t.Errorf("expected the income statement in USD:\n%s", view)
+	}
+
+	// Back to native commodities
+	model = typeKeys(model, "C1")
+	if view = ansi.Strip(model.View()); !strings.Contains(view, "Totals in native commodities") || !strings.Contains(view, "-50.00 EUR") {
+		t.Errorf("expected native totals again:\n%s", view)
+	}
+}
+
+func TestDashboardIncomeExpenses(t *testing.T) {
+	// Dates are relative to today because the widget reports on the current month
+	today := time.Now()
+	thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	month := func(offset int) string { return thisMonth.AddDate(0, offset, 0).Format("2006-01-02") }
+	var content strings.Builder
+	for _, m := range []struct {
+		offset   int
+		expenses string
+	}{{-2, "120.00"}, {-1, "100.00"}, {0, "80.00"}} {
+		fmt.Fprintf(&content, `%[1]s * "Employer" "Salary"
+  Assets:Checking  500.00 USD
+  Income:Salary
+
+%[1]s * "Market" "Groceries"
+  Assets:Checking  -%[2]s USD
+  Expenses:Food
+
+`, month(m.offset), m.expenses)
+	}
+
+	file, err := beancount.Open(createTempFile(t, content.String()))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	cfg := config.DefaultConfig()
+	cfg.Theme.ColorMode = "16"
+	cfg.Dashboard.Layout = [][]string{{"income_expenses"}}
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+	model.dashboard = model.dashboard.SetSize(120, 38)
+
+	// The average spans the two months the ledger has before this one
+	view := ansi.Strip(model.View())
+	for _, want := range []string{
+		"Income vs Expenses - ", "500.00 USD", "80.00 USD", "420.00 USD",
+		"= last  = 2-mo avg", "▼20% vs last  ▼27% vs 2-mo avg", "▲5% vs last  ▲8% vs 2-mo avg",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the widget:\n%s", want, view)
+		}
+	}
+
+	// Less spending and more saved are both changes for the better
+	view = model.View()
+	for _, better := range []string{"▼20% vs last", "▲5% vs last"} {
+		if !strings.Contains(view, theme.SuccessStyle.Render(better)) {
+			t.Errorf("expected %q styled as an improvement:\n%q", better, view)
+		}
+	}
+}
+
+func TestDashboardTopCategories(t *testing.T) {
+	// Dates are relative to today because the widgets report on the current month
+	today := time.Now()
+	thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	content := fmt.Sprintf(`%[1]s * "Landlord" "Rent"
+  Assets:Checking  -600.00 USD
+  Expenses:Housing:Rent
+
+%[1]s * "Market" "Groceries"
+  Assets:Checking  -300.00 USD
+  Expenses:Food:Groceries
+
+%[1]s * "Cafe" "Latte"
+  Assets:Checking  -100.00 USD
+  Expenses:Food:Coffee
+`, thisMonth.Format("2006-01-02"))
+
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Dashboard.Layout = [][]string{{"top_merchants"}, {"top_categories"}}
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+	model.dashboard = model.dashboard.SetSize(120, 38)
+
+	// Each entry shows its amount and share of the month's spending, largest first
+	view := ansi.Strip(model.View())
+	for _, want := range []string{"Top Categories - ", "Housing:Rent", "600.00  60%", "Food:Groceries", "300.00  30%", "Cafe", "100.00  10%"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q on the dashboard:\n%s", want, view)
+		}
+	}
+	categories := view[strings.Index(view, "Top Categories"):]
+	if strings.Index(categories, "Housing:Rent") > strings.Index(categories, "Food:Coffee") {
+		t.Errorf("expected the largest category first:\n%s", categories)
+	}
+	if strings.Contains(categories, "Expenses:") {
+		t.Errorf("expected categories without the Expenses: prefix:\n%s", categories)
+	}
+}
+
+func TestDashboardRecentTransactions(t *testing.T) {
+	// The ledger is not in date order; the newest entries sit at the top of the file
+	var ledger strings.Builder
+	for day := 20; day >= 1; day-- {
+		fmt.Fprintf(&ledger, "2024-03-%02d * \"Shop %d\" \"Purchase\"\n  Assets:Checking  -%d.00 USD\n  Expenses:Misc\n\n", day, day, day)
+	}
+	ledger.WriteString("2024-03-25 * \"Employer\" \"Salary\"\n  Assets:Checking  2500.00 USD\n  Income:Salary\n")
+
+	file, err := beancount.Open(createTempFile(t, ledger.String()))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Dashboard.Layout = [][]string{{"recent_transactions"}}
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 100, 40, true
+	model.dashboard = model.dashboard.SetSize(100, 38)
+
+	view := model.View()
+	for _, want := range []string{"2024-03-25", "2500.00 USD", "Shop 20", "-20.00 USD", "Shop 17"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q among recent transactions:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Shop 16 ") || strings.Contains(view, "Shop 1 ") {
+		t.Errorf("expected only the five latest transactions:\n%s", view)
+	}
+	if strings.Index(view, "Employer") > strings.Index(view, "Shop 20") {
+		t.Error("expected newest transaction first")
+	}
+}
+
+func TestAmountFormatting(t *testing.T) {
+	content := `2024-03-01 * "Landlord" "Rent"
+  Expenses:Housing:Rent  1234.50 EUR
+  Assets:Checking
+
+2024-03-02 * "Ramen" "Dinner"
+  Expenses:Food  1500 JPY
+  Assets:Cash
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.Amounts = config.AmountsConfig{
+		Locale:    "de",
+		Symbols:   map[string]string{"EUR": "€"},
+		Precision: map[string]int{"JPY": 0},
+	}
+	model := New(file, cfg)
+	defer New(file, config.DefaultConfig()) // Restore the default formatter
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	model = typeKeys(model, "2")
+	view := model.View()
+	for _, want := range []string{"1.234,50 €", "1.500 JPY"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the transactions view:\n%s", want, view)
+		}
+	}
+
+	model = typeKeys(model, "3+")
+	if view = model.View(); !strings.Contains(view, "-1.234,50 €") {
+		t.Errorf("expected the checking balance formatted:\n%s", view)
+	}
+}
+
+func TestWideCharacterLayout(t *testing.T) {
+	content := `2024-03-01 * "東京ラーメン屋さん本店の特製つけ麺セット" "Lunch"
+  Expenses:Food:外食  12.00 USD
+  Assets:Checking
+
+2024-03-02 * "☕🍰 Café Crème Pâtisserie" "Treats"
+  Expenses:Food:Coffee  8.00 USD
+  Assets:Checking
+
+2024-03-03 * "Plain Grocer" "Groceries"
+  Expenses:Food:Groceries  30.00 USD
+  Assets:Checking
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.Dashboard.Layout = [][]string{{"recent_transactions"}}
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 72, Height: 30})
+
+	// Every row of a table is exactly as wide as the view, whatever its characters
+	sameWidth := func(name, view string, rows []string) {
+		t.Helper()
+		for _, line := range strings.Split(view, "\n") {
+			for _, row := range rows {
+				if strings.Contains(line, row) {
+					if width := lipgloss.Width(line); width != 72 {
+						t.Errorf("%s: expected the %q row to be 72 cells wide, got %d: %q", name, row, width, line)
+					}
+				}
+			}
+		}
+	}
+
+	model = typeKeys(model, "2")
+	view := model.transactions.View()
+	if !strings.Contains(view, "東京ラーメン") || !strings.Contains(view, "☕🍰 Café") {
+		t.Errorf("expected the wide payees in the transactions view:\n%s", view)
+	}
+	sameWidth("transactions", view, []string{"2024-03-01", "2024-03-02", "2024-03-03"})
+	for _, line := range strings.Split(view, "\n") {
+		if strings.Contains(line, "2024-03-0") && !strings.Contains(line, "Expenses:Food") && !strings.Contains(line, "...") {
+			t.Errorf("expected the account column aligned after the description: %q", line)
+		}
+	}
+
+	model = typeKeys(model, "3")
+	model = typeKeys(model, "+")
+	sameWidth("accounts", model.accounts.View(), []string{"外食", "Coffee", "Groceries"})
+
+	view = model.dashboard.View()
+	if !strings.Contains(view, "東京") {
+		t.Errorf("expected the wide payee on the dashboard:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if width := lipgloss.Width(line); width > 72 {
+			t.Errorf("dashboard: expected lines within 72 cells, got %d: %q", width, line)
+		}
+	}
+}
+
+func TestFileOpenDialog(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	dir := t.TempDir()
+	other := filepath.Join(dir, "household.beancount")
+	if err := os.WriteFile(other, []byte(`2024-04-01 * "Landlord" "April rent"
+  Assets:Checking  -1200.00 USD
+  Expenses:Housing
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.Files.RecentFiles = filepath.Join(dir, "recent_files")
+	cfg.Files.StateFile = filepath.Join(dir, "state.yaml")
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 100, 30, true
+
+	model = send(model, tea.KeyMsg{Type: tea.KeyCtrlO})
+	if model.fileOpen == nil {
+		t.Fatal("expected ctrl+o to open the file dialog")
+	}
+
+	// A bad path keeps the dialog open with the error
+	model = pressKey(model, tea.KeyCtrlU)
+	model = typeKeys(model, filepath.Join(dir, "missing.beancount"))
+	model = pressKey(model, tea.KeyEnter)
+	if model.fileOpen == nil {
+		t.Fatal("expected the dialog to stay open after a failed open")
+	}
+	if view := model.View(); !strings.Contains(view, "failed to open") {
+		t.Errorf("expected an error in the dialog:\n%s", view)
+	}
+
+	// Tab completes the partial name
+	model = pressKey(model, tea.KeyCtrlU)
+	model = typeKeys(model, filepath.Join(dir, "house"))
+	model = pressKey(model, tea.KeyTab)
+	if got := model.fileOpen.Path(); got != other {
+		t.Fatalf("expected tab to complete to %s, got %s", other, got)
+	}
+
+	model = pressKey(model, tea.KeyEnter)
+	if model.fileOpen != nil {
+		t.Fatal("expected the dialog to close after opening")
+	}
+	defer model.file.Close()
+	if model.file.Path() != other {
+		t.Errorf("expected %s loaded, got %s", other, model.file.Path())
+	}
+	model = typeKeys(model, "2")
+	view := model.View()
+	if !strings.Contains(view, "Landlord") || strings.Contains(view, "Market") {
+		t.Errorf("expected the transactions of the opened ledger:\n%s", view)
+	}
+
+	// The opened ledger heads the recent files
+	model = send(model, tea.KeyMsg{Type: tea.KeyCtrlO})
+	if view := model.View(); !strings.Contains(view, other) {
+		t.Errorf("expected %s among the recent files:\n%s", other, view)
+	}
+}
+
+func TestProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	personal := filepath.Join(dir, "personal", "main.beancount")
+	business := filepath.Join(dir, "business", "books", "main.beancount")
+	for _, path := range []string{personal, business} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(`2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	project := filepath.Join(dir, "business", config.ProjectFile)
+	if err := os.WriteFile(project, []byte("ui:\n  default_view: transactions\namounts:\n  currency: EUR\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := beancount.Open(personal)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.Files.RecentFiles = filepath.Join(dir, "recent_files")
+	cfg.Files.StateFile = ""
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 100, 30, true
+	if model.currentView != DashboardView || model.currency != "" {
+		t.Fatalf("expected the global settings without a project file, got view %v, currency %q", model.currentView, model.currency)
+	}
+
+	// The business ledger's project file applies when it is opened
+	model = send(model, tea.KeyMsg{Type: tea.KeyCtrlO})
+	model = pressKey(model, tea.KeyCtrlU)
+	model = typeKeys(model, business)
+	model = pressKey(model, tea.KeyEnter)
+	if model.file.Path() != business {
+		t.Fatalf("expected %s loaded, got %s", business, model.file.Path())
+	}
+	if model.currentView != TransactionsView || model.currency != "EUR" {
+		t.Errorf("expected the project's settings, got view %v, currency %q", model.currentView, model.currency)
+	}
+	if view := model.View(); !strings.Contains(view, "Using project settings from "+project) {
+		t.Errorf("expected the project file to be reported:\n%s", view)
+	}
+
+	// and stops applying when another ledger is opened
+	model = send(model, tea.KeyMsg{Type: tea.KeyCtrlO})
+	model = pressKey(model, tea.KeyCtrlU)
+	model = typeKeys(model, personal)
+	model = pressKey(model, tea.KeyEnter)
+	defer model.file.Close()
+	if model.currentView != DashboardView || model.currency != "" || cfg.Amounts.Currency != "" {
+		t.Errorf("expected the global settings back, got view %v, currency %q", model.currentView, model.currency)
+	}
+}
+
+func TestHelpOverlay(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.Keybindings.Budgets = []string{"b", "6"}
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 100, 12, true
+	model = typeKeys(model, "2")
+
+	model = pressKey(model, tea.KeyF1)
+	if model.help == nil {
+		t.Fatal("expected F1 to open the help overlay")
+	}
+	view := model.View()
+	for _, want := range []string{"Help - Keyboard Shortcuts", "Transactions", "page down", "lines 1-8 of"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in help overlay:\n%s", want, view)
+		}
+	}
+
+	// Scrolling reaches the global bindings, including config overrides
+	model = typeKeys(model, "G")
+	view = model.View()
+	if !strings.Contains(view, "Usage") {
+		t.Errorf("expected G to scroll to the end of the help:\n%s", view)
+	}
+	model = typeKeys(model, "g")
+	for i := 0; i < 30 && !strings.Contains(model.View(), "b/6"); i++ {
+		model = typeKeys(model, "j")
+	}
+	if !strings.Contains(model.View(), "b/6") {
+		t.Errorf("expected the configured budgets keys in the help:\n%s", model.View())
+	}
+
+	// Keys scroll the overlay instead of reaching the view
+	if model.currentView != TransactionsView {
+		t.Errorf("expected keys to stay in the overlay, got view %d", model.currentView)
+	}
+
+	model = pressKey(model, tea.KeyEsc)
+	if model.help != nil {
+		t.Fatal("expected esc to close the help overlay")
+	}
+	model = typeKeys(model, "?")
+	if model.help == nil {
+		t.Fatal("expected ? to open the help overlay")
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 100, 20, true
+
+	// Components raise notifications with a command
+	updated, cmd := model.Update(components.Notify(components.LevelWarning, "Prices are stale")())
+	model = updated.(Model)
+	if model.status() != "Prices are stale" {
+		t.Fatalf("expected the notification in the status bar, got %q", model.status())
+	}
+	if cmd == nil || !model.notices.ticking {
+		t.Error("expected an expiry timer to be scheduled")
+	}
+	if view := model.View(); !strings.Contains(view, "! Prices are stale") {
+		t.Errorf("expected the warning in the status bar:\n%s", view)
+	}
+
+	// The newest notification is shown
+	model = typeKeys(model, "u")
+	if model.status() != "Nothing to undo" {
+		t.Errorf("expected the newest notification shown, got %q", model.status())
+	}
+
+	// Expired notifications leave the status bar but stay in the log
+	for i := range model.notices.active {
+		model.notices.active[i].expires = time.Now().Add(-time.Second)
+	}
+	model = send(model, noticeExpiredMsg{})
+	if model.status() != "" || model.notices.ticking {
+		t.Errorf("expected notifications expired, got %q (ticking %v)", model.status(), model.notices.ticking)
+	}
+
+	model = typeKeys(model, "M")
+	view := model.View()
+	for _, want := range []string{"Messages - 2 recent", "Prices are stale", "Nothing to undo"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the message log:\n%s", want, view)
+		}
+	}
+	if strings.Index(view, "Nothing to undo") > strings.Index(view, "Prices are stale") {
+		t.Error("expected the newest message first")
+	}
+	model = pressKey(model, tea.KeyEsc)
+	if model.showLog {
+		t.Error("expected esc to close the message log")
+	}
+}
+
+func TestWriteConfirmation(t *testing.T) {
+	content := `2025-01-01 * "BLUE BOTTLE" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized  5.00 USD
+
+2025-01-02 * "BLUE BOTTLE" "Mocha"
+  Assets:Checking  -6.00 USD
+  Expenses:Uncategorized  6.00 USD
+`
+	tmpDir := t.TempDir()
+	ledger := filepath.Join(tmpDir, "ledger.beancount")
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+	patternsFile := filepath.Join(tmpDir, "patterns.yaml")
+	if err := os.WriteFile(patternsFile, []byte(`version: "1"
+patterns:
+  - id: blue-bottle
+    name: Blue Bottle
+    pattern: "BLUE BOTTLE"
+    category: Expenses:Food:Coffee
+`), 0644); err != nil {
+		t.Fatalf("failed to write patterns: %v", err)
+	}
+
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = patternsFile
+	cfg.Categorization.Merchants.Enabled = false
+	cfg.Categorization.LearnFromEdits = false
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 120, 40, true
+	accept := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}
+	read := func() string {
+		data, _ := os.ReadFile(ledger)
+		return string(data)
+	}
+
+	// The preview shows the old and new posting lines
+	model = typeKeys(model, "r")
+	model = send(model, accept)
+	view := model.View()
+	for _, want := range []string{"Confirm: Categorize BLUE BOTTLE as Expenses:Food:Coffee", "ledger.beancount:3",
+		"-   Expenses:Uncategorized  5.00 USD", "+   Expenses:Food:Coffee    5.00 USD"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the preview:\n%s", want, view)
+		}
+	}
+
+	// Cancelling writes nothing and keeps the transaction under review
+	model = pressKey(model, tea.KeyEsc)
+	if model.confirm != nil || read() != content {
+		t.Fatal("expected cancel to close the preview without writing")
+	}
+	if model.review.Remaining() != 2 || !strings.HasPrefix(model.status(), "Cancelled") {
+		t.Errorf("expected the review unchanged, got %d remaining, status %q", model.review.Remaining(), model.status())
+	}
+
+	model = send(model, accept)
+	model = confirmWrite(t, model)
+	if !strings.Contains(read(), "Expenses:Food:Coffee    5.00 USD") || model.review.Remaining() != 1 {
+		t.Fatalf("expected the confirmed category written, got %d remaining:\n%s", model.review.Remaining(), read())
+	}
+
+	// Single categorizations can skip the preview; deletes are always confirmed
+	model.config.UI.ConfirmCategorize = false
+	model = send(model, accept)
+	if model.confirm != nil || strings.Contains(read(), "Uncategorized") {
+		t.Fatalf("expected the category written without a preview:\n%s", read())
+	}
+	model = typeKeys(model, "2")
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if model.confirm == nil || !strings.Contains(model.View(), "Confirm: Delete") {
+		t.Fatal("expected deletes to be confirmed")
+	}
+}
+
+func TestThemeSwitching(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+	defer theme.Configure(config.DefaultConfig().Theme)
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.Theme.Name = "high_contrast"
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 100, 20, true
+	if theme.Current().Name != "high_contrast" {
+		t.Fatalf("expected the configured theme, got %q", theme.Current().Name)
+	}
+
+	// The theme key cycles through the built-in themes and wraps around
+	model = typeKeys(model, "T")
+	if theme.Current().Name != "tp7" || model.status() != "Theme: tp7" {
+		t.Errorf("expected tp7 after high_contrast, got %q (status %q)", theme.Current().Name, model.status())
+	}
+	model = typeKeys(model, "T")
+	if theme.Current().Name != "light" {
+		t.Errorf("expected light after tp7, got %q", theme.Current().Name)
+	}
+	if !strings.Contains(model.View(), "F2 dashboard") {
+		t.Error("expected the view to render after switching theme")
+	}
+}
+
+func TestThemeFromConfig(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+	defer theme.Configure(config.DefaultConfig().Theme)
+
+	// Unset colors keep the built-in theme's
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	New(file, cfg)
+	if theme.Current() != theme.TP7Palette {
+		t.Errorf("expected the TP7 palette by default, got %+v", theme.Current())
+	}
+
+	cfg.Theme.Name = "light"
+	cfg.Theme.Primary = "#123456"
+	cfg.Theme.Error = "#ABCDEF"
+	model := New(file, cfg)
+	model.width, model.height, model.ready = 100, 20, true
+	current := theme.Current()
+	if current.Accent != "#123456" || current.Error != "#ABCDEF" {
+		t.Errorf("expected the configured colors, got %+v", current)
+	}
+	if current.Background != theme.LightPalette.Background {
+		t.Errorf("expected unset colors from the light theme, got %q", current.Background)
+	}
+
+	// Cycling back to the configured theme keeps its overrides
+	for range theme.Palettes {
+		model = typeKeys(model, "T")
+	}
+	if theme.Current() != current {
+		t.Errorf("expected the configured palette after a full cycle, got %+v", theme.Current())
+	}
+}
+
+func TestTransactionListScrolling(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("2024-01-01 open Assets:Checking\n")
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&content, "\n2025-01-%02d * \"Payee %02d\" \"\"\n  Assets:Checking  -1.00 USD\n  Expenses:Misc\n", i, i)
+	}
+	file, err := beancount.Open(createTempFile(t, content.String()))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model = send(model, tea.WindowSizeMsg{Width: 100, Height: 24})
+	model = typeKeys(model, "2")
+
+	// row returns the cursor's row from the title, checking the row is on screen
+	row := func(model Model) int {
+		t.Helper()
+		view := ansi.Strip(model.View())
+		var cursor, count int
+		if i := strings.Index(view, "Row "); i < 0 {
+			t.Fatalf("no row in the title:\n%s", view)
+		} else if _, err := fmt.Sscanf(view[i:], "Row %d/%d", &cursor, &count); err != nil {
+			t.Fatalf("unreadable title: %v", err)
+		}
+		if !strings.Contains(view, fmt.Sprintf("Payee %02d", cursor)) {
+			t.Errorf("row %d is off screen:\n%s", cursor, view)
+		}
+		return cursor
+	}
+
+	model = typeKeys(model, "G")
+	if got := row(model); got != 30 {
+		t.Errorf("expected the last row, got %d", got)
+	}
+
+	// Resizing mid-scroll keeps the cursor on screen, down to a single row
+	for _, height := range []int{40, 14, 9, 3, 24} {
+		model = send(model, tea.WindowSizeMsg{Width: 100, Height: height})
+		if got := row(model); got != 30 {
+			t.Errorf("height %d: expected the cursor kept on row 30, got %d", height, got)
+		}
+	}
+
+	// Page keys move a window at a time
+	model = typeKeys(model, "g")
+	model = send(model, tea.KeyMsg{Type: tea.KeyPgDown})
+	page := row(model) - 1
+	if page < 2 || strings.Contains(ansi.Strip(model.View()), "Payee 01") {
+		t.Errorf("expected page down to scroll a window, moved %d rows", page)
+	}
+	model = send(model, tea.KeyMsg{Type: tea.KeyPgUp})
+	if got := row(model); got != 1 {
+		t.Errorf("expected page up back to the first row, got %d", got)
+	}
+
+	// The search bar takes rows from the list without hiding the cursor
+	model = typeKeys(model, "G/")
+	if got := row(model); got != 30 {
+		t.Errorf("expected the cursor on screen with the search bar open, got %d", got)
+	}
+}
+
+func TestRemappedNavigationKeys(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food
+
+2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+
+2024-03-06 * "Bakery" "Bread"
+  Assets:Checking  -4.00 USD
+  Expenses:Food
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.Keybindings.Down = []string{"ctrl+n"}
+	cfg.Keybindings.Select = []string{"space"}
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 100, Height: 20})
+
+	// The transactions list moves with the configured key only
+	model.currentView = TransactionsView
+	view := model.transactions.View()
+	model = typeKeys(model, "j")
+	if model.transactions.View() != view {
+		t.Error("expected j unbound after remapping down")
+	}
+	model = pressKey(model, tea.KeyCtrlN)
+	if model.transactions.View() == view {
+		t.Error("expected ctrl+n to move down")
+	}
+
+	// The accounts tree toggles with the space bar configured as "space"
+	model.currentView = AccountsView
+	view = model.accounts.View()
+	model = pressKey(model, tea.KeySpace)
+	if model.accounts.View() == view {
+		t.Error("expected space to toggle the selected account")
+	}
+	if help := model.accounts.KeyBindings()[1].Help(); help.Key != "ctrl+n" {
+		t.Errorf("expected help to show the configured key, got %q", help.Key)
+	}
+}
+
+func TestCompactMode(t *testing.T) {
+	var content strings.Builder
+	for day := 1; day <= 28; day++ {
+		fmt.Fprintf(&content, "2024-03-%02d * \"Market\" \"Groceries\"\n  Expenses:Food:Groceries  20.00 USD\n  Assets:Checking\n\n", day)
+	}
+	file, err := beancount.Open(createTempFile(t, content.String()))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 24})
+	model.currentView = TransactionsView
+
+	view := ansi.Strip(model.View())
+	rows := strings.Count(view, "Groceries")
+	if !strings.Contains(view, "─────") || !strings.Contains(view, "Expenses:Food:Groceries") {
+		t.Errorf("expected a separator and full account names in the standard layout:\n%s", view)
+	}
+
+	// Compact: no separator or spacer, abbreviated accounts and two more rows
+	model = typeKeys(model, "D")
+	view = ansi.Strip(model.View())
+	if strings.Contains(view, "─────") || !strings.Contains(view, "Ex:Food:Groceries") || !strings.Contains(view, "2024-03-01 * Market") {
+		t.Errorf("expected the compact layout:\n%s", view)
+	}
+	if got := strings.Count(view, "Groceries"); got != rows+2 {
+		t.Errorf("expected %d rows in the compact layout, got %d:\n%s", rows+2, got, view)
+	}
+	model.currentView = AccountsView
+	if view = ansi.Strip(model.View()); strings.Contains(view, "─────") {
+		t.Errorf("expected no separator in the compact accounts view:\n%s", view)
+	}
+
+	// The layout belongs to the model; another one starts from the config
+	other := send(New(file, cfg), tea.WindowSizeMsg{Width: 120, Height: 24})
+	other.currentView = TransactionsView
+	if view := ansi.Strip(other.View()); !strings.Contains(view, "Expenses:Food:Groceries") {
+		t.Errorf("expected another model in the standard layout:\n%s", view)
+	}
+
+	model = typeKeys(model, "D")
+	model.currentView = TransactionsView
+	if view = ansi.Strip(model.View()); !strings.Contains(view, "Standard layout") || strings.Count(view, "Groceries") != rows {
+		t.Errorf("expected the standard layout again:\n%s", view)
+	}
+}
+
+func TestDateFormat(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-03-05 * "Market" "Groceries" #food
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.UI.DateFormat = "eu"
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	for _, view := range []ViewType{TransactionsView, TagsView, DashboardView} {
+		model.currentView = view
+		if got := ansi.Strip(model.View()); !strings.Contains(got, "05.03.2024") || strings.Contains(got, "2024-03-05") {
+			t.Errorf("expected dates in the eu format in view %d:\n%s", view, got)
+		}
+	}
+
+	// Filters still take ISO dates
+	model.currentView = TransactionsView
+	model.transactions = model.transactions.SetFilter("date:2024-03-05")
+	if got := ansi.Strip(model.View()); !strings.Contains(got, "(1 of 1)") {
+		t.Errorf("expected the ISO date filter to match:\n%s", got)
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	var content strings.Builder
+	for day := 0; day < 60; day++ {
+		date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, day)
+		fmt.Fprintf(&content, "%s * \"Market\" \"Groceries\"\n  Expenses:Food  20.00 USD\n  Assets:Checking\n\n", date.Format("2006-01-02"))
+	}
+	file, err := beancount.Open(createTempFile(t, content.String()))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.UI.PageSize = 10
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 40})
+	model.currentView = TransactionsView
+
+	// Page down moves the configured rows, not the window's height
+	model = pressKey(model, tea.KeyPgDown)
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "Row 11/60") {
+		t.Errorf("expected page down to move 10 rows:\n%s", view)
+	}
+
+	model = typeKeys(model, ">")
+	model = pressKey(model, tea.KeyPgDown)
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "Row 26/60") || !strings.Contains(view, "Page size: 15 rows") {
+		t.Errorf("expected larger pages of 15 rows:\n%s", view)
+	}
+
+	model = typeKeys(model, "<<<<")
+	model = pressKey(model, tea.KeyPgUp)
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "Row 25/60") || !strings.Contains(view, "Page size: 1 row") {
+		t.Errorf("expected pages to shrink to one row:\n%s", view)
+	}
+}
+
+func TestViewKeysReserved(t *testing.T) {
+	if err := config.DefaultConfig().Validate(); err != nil {
+		t.Fatalf("expected the default bindings valid, got: %v", err)
+	}
+
+	// Undo on d would take delete from the transactions view
+	cfg := config.DefaultConfig()
+	cfg.Keybindings.Undo = []string{"d"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"d" in 'undo' is reserved for delete in transactions`) {
+		t.Errorf("expected undo on d refused, got %v", err)
+	}
+
+	// Navigation is handled by the views, alongside their own keys
+	cfg = config.DefaultConfig()
+	cfg.Keybindings.Select = []string{"enter", "space", "tab"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected select on a view key allowed, got: %v", err)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+	defer theme.Configure(config.DefaultConfig().Theme)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	files := "files:\n  budgets_file: \"\"\n  patterns_file: \"\"\n  state_file: \"\"\n"
+	write := func(content string, stamp time.Time) {
+		if err := os.WriteFile(path, []byte(files+content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Distinct modification times, however coarse the filesystem's clock
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write("ui:\n  page_size: 10\n", start)
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	model := New(file, cfg).WatchConfig(path, "")
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+	if model.Init() == nil {
+		t.Fatal("expected Init to schedule the config check")
+	}
+
+	// Unchanged files are left alone
+	model = send(model, configCheckMsg{})
+	if strings.Contains(ansi.Strip(model.View()), "Config reloaded") {
+		t.Error("expected no reload while the config is unchanged")
+	}
+
+	write("ui:\n  page_size: 15\ntheme:\n  name: light\nkeybindings:\n  dashboard: [\"z\"]\ncategorization:\n  confidence_threshold: 0.7\n", start.Add(time.Minute))
+	model = send(model, configCheckMsg{})
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "Config reloaded") {
+		t.Errorf("expected a toast after reloading:\n%s", view)
+	}
+	if model.display.PageSize != 15 || theme.Current().Name != "light" {
+		t.Errorf("expected page size 15 and the light theme, got %d and %s", model.display.PageSize, theme.Current().Name)
+	}
+	if got := model.categorizer.GetConfig().ConfidenceThreshold; got != 0.7 {
+		t.Errorf("expected confidence threshold 0.7, got %f", got)
+	}
+	model = typeKeys(model, "2")
+	model = typeKeys(model, "z")
+	if model.currentView != DashboardView {
+		t.Errorf("expected the rebound key to show the dashboard, got %v", model.currentView)
+	}
+
+	// An invalid config is reported and the current one kept
+	write("ui:\n  page_size: 0\n", start.Add(2*time.Minute))
+	model = send(model, configCheckMsg{})
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "Config not reloaded") {
+		t.Errorf("expected the validation error:\n%s", view)
+	}
+	if model.display.PageSize != 15 || model.config.UI.PageSize != 15 {
+		t.Errorf("expected the previous config kept, got page size %d", model.display.PageSize)
+	}
+}
+
+func TestHelpRow(t *testing.T) {
+	file, err := beancount.Open(createTempFile(t, `2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -20.00 USD
+  Expenses:Food
+`))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.BudgetsFile = ""
+	cfg.Keybindings.Down = []string{"ctrl+n"}
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 160, Height: 30})
+	model.currentView = AccountsView
+
+	// The status bar hints come from the view's key map
+	view := ansi.Strip(model.View())
+	for _, want := range []string{"F1 help", "enter/space expand/collapse", "/ filter", "H help row", "F10 menu"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the status bar:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "ctrl+n") {
+		t.Error("expected navigation keys only in the help row")
+	}
+
+	// The help row lists every binding, with the keys as configured
+	height := strings.Count(view, "\n")
+	model = typeKeys(model, "H")
+	view = ansi.Strip(model.View())
+	columns := strings.Join(strings.Fields(view), " ") // Help columns are padded to their widest key
+	for _, want := range []string{"ctrl+n down", "pgdown/ctrl+f page down", "+ expand all", "esc/backspace clear filter"} {
+		if !strings.Contains(columns, want) {
+			t.Errorf("expected %q in the help row:\n%s", want, view)
+		}
+	}
+	if got := strings.Count(view, "\n"); got != height {
+		t.Errorf("expected the view to shrink for the help row, screen went from %d to %d lines", height, got)
+	}
+
+	// Dialogs cover the help row and show their own keys
+	model = typeKeys(model, "M")
+	view = ansi.Strip(model.View())
+	if strings.Contains(view, "clear filter") || !strings.Contains(view, "esc close") {
+		t.Errorf("expected only the message log's keys:\n%s", view)
+	}
+	model = pressKey(model, tea.KeyEsc)
+
+	model = typeKeys(model, "H")
+	if strings.Contains(ansi.Strip(model.View()), "clear filter") {
+		t.Error("expected H to hide the help row again")
+	}
+}
+
+func TestExportView(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2025-01-01 * "Coffee Shop" "Latte, large" #treat
+  Assets:Checking  -4.50 USD
+  Expenses:Food:Coffee
+
+2025-01-02 * "Bakery" ""
+  Assets:Checking  -3.00 USD
+  Expenses:Food
+
+2025-01-03 ! "Coffee Shop" "Beans"
+  Assets:Checking  -12.00 USD
+  Expenses:Food:Coffee
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	dir := t.TempDir()
+	exportTo := func(model Model, path string) Model {
+		model = typeKeys(model, "E")
+		if model.export == nil {
+			t.Fatalf("expected the export dialog to open, status %q", model.status())
+		}
+		model = pressKey(model, tea.KeyCtrlU)
+		model = typeKeys(model, path)
+		return pressKey(model, tea.KeyEnter)
+	}
+
+	model := New(file, config.DefaultConfig())
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = typeKeys(model, "2/coffee")
+	model = pressKey(model, tea.KeyEnter)
+
+	// The filtered list goes out as CSV
+	csvPath := filepath.Join(dir, "coffee.csv")
+	model = exportTo(model, csvPath)
+	if model.export != nil {
+		t.Fatalf("expected the dialog to close, got:\n%s", ansi.Strip(model.View()))
+	}
+	if !strings.Contains(model.status(), "Exported transactions (2 rows) to "+csvPath) {
+		t.Errorf("unexpected status %q", model.status())
+	}
+	data, err := os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := `date,flag,payee,narration,account,amount,commodity,tags,links
+2025-01-01,*,Coffee Shop,"Latte, large",Assets:Checking,-4.5,USD,treat,
+2025-01-03,!,Coffee Shop,Beans,Assets:Checking,-12,USD,,
+`
+	if string(data) != wantCSV {
+		t.Errorf("expected CSV:\n%s\ngot:\n%s", wantCSV, data)
+	}
+
+	// And as JSON, chosen by the extension
+	jsonPath := filepath.Join(dir, "coffee.json")
+	model = exportTo(model, jsonPath)
+	data, err = os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]string
+	if err := json.Unmarshal(data, &rows); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if len(rows) != 2 || rows[1]["narration"] != "Beans" || rows[0]["tags"] != "treat" {
+		t.Errorf("unexpected rows %v", rows)
+	}
+
+	// An unknown format keeps the dialog open with the error
+	model = exportTo(model, filepath.Join(dir, "coffee.txt"))
+	if model.export == nil || !strings.Contains(ansi.Strip(model.View()), `unsupported format ".txt"`) {
+		t.Errorf("expected a format error, got:\n%s", ansi.Strip(model.View()))
+	}
+	model = pressKey(model, tea.KeyEsc)
+
+	// Views without a table say where export works
+	model = typeKeys(model, "1E")
+	if model.export != nil || !strings.Contains(model.status(), "Export works in the transactions and reports views") {
+		t.Errorf("expected no export from the dashboard, status %q", model.status())
+	}
+
+	// Reports > Export writes the active report
+	model = send(model, components.MenuSelectedMsg{Menu: "Reports", Item: "Export"})
+	if model.currentView != ReportsView || model.export == nil {
+		t.Fatalf("expected the reports view and the export dialog, got view %v", model.currentView)
+	}
+	model = pressKey(model, tea.KeyCtrlU)
+	model = typeKeys(model, filepath.Join(dir, "income.csv"))
+	model = pressKey(model, tea.KeyEnter)
+	data, err = os.ReadFile(filepath.Join(dir, "income.csv"))
+	if err != nil {
+		t.Fatalf("report not exported, status %q: %v", model.status(), err)
+	}
+	if !strings.Contains(string(data), "Expenses:Food:Coffee,USD,16.5") {
+		t.Errorf("expected the coffee total in the report:\n%s", data)
+	}
+}
+
+func TestAccountBalances(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Liabilities:Card
+
+2024-01-02 * "Employer" "Deposit"
+  Assets:Checking  1000.00 USD
+  Income:Salary
+
+2024-01-03 * "Market" "Groceries"
+  Liabilities:Card  -40.00 USD
+  Expenses:Food
+
+2099-01-01 * "Landlord" "Rent"
+  Assets:Checking  -800.00 USD
+  Expenses:Rent
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	cfg := config.DefaultConfig()
+	cfg.Theme.ColorMode = "16"
+
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 100, Height: 30})
+	model = typeKeys(model, "3")
+
+	// Balances are as of today; the future rent is left out but its account is listed
+	view := ansi.Strip(model.View())
+	for _, want := range []string{"1000.00 USD", "-40.00 USD", "Rent", "1 future transactions left out"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in accounts view:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "200.00 USD") || strings.Contains(view, "800.00 USD") {
+		t.Errorf("expected the future rent left out of the balances:\n%s", view)
+	}
+
+	// Negative balances off the cursor are styled as such, positive ones are not
+	view = model.View()
+	if !strings.Contains(view, theme.AmountNegativeStyle.Bold(true).Render("-40.00 USD")) {
+		t.Errorf("expected the card balance styled as negative:\n%q", view)
+	}
+	if !strings.Contains(view, theme.AmountNegativeStyle.Render("-1000.00 USD")) {
+		t.Errorf("expected the salary balance styled as negative:\n%q", view)
+	}
+	if strings.Contains(view, theme.AmountNegativeStyle.Bold(true).Render("1000.00 USD")) {
+		t.Errorf("expected the assets balance not styled as negative:\n%q", view)
+	}
+}
+
+func TestAutocomplete(t *testing.T) {
+	content := `2015-01-01 open Assets:Checking
+2015-01-01 open Expenses:Food:Coffee
+2015-01-01 open Expenses:Food:Groceries
+
+2015-03-01 * "Coffee Shop" "Latte"
+  Assets:Checking  -4.50 USD
+  Expenses:Food:Coffee
+
+2015-03-02 * "Coffee Shop" "Latte"
+  Assets:Checking  -4.50 USD
+  Expenses:Food:Coffee
+
+2015-03-03 * "Coffee Shop" "Latte"
+  Assets:Checking  -4.50 USD
+  Expenses:Food:Coffee
+
+2025-05-01 * "Corner Cafe" "Espresso"
+  Assets:Checking  -3.00 USD
+  Expenses:Food:Coffee
+
+2025-05-02 * "Corner Market" "Groceries"
+  Assets:Checking  -30.00 USD
+  Expenses:Food:Groceries
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 160, Height: 40})
+
+	// Payees used lately rank above ones used more often long ago
+	model = typeKeys(model, "a")
+	model = pressKey(model, tea.KeyTab)
+	model = typeKeys(model, "co")
+	view := ansi.Strip(model.View())
+	if !strings.Contains(view, "tab → Corner Market   (Corner Cafe, Coffee Shop)") {
+		t.Errorf("expected ranked payee completions:\n%s", view)
+	}
+
+	// Matching is fuzzy: the letters only need to appear in order
+	model = pressKey(model, tea.KeyCtrlU)
+	model = typeKeys(model, "cfsh")
+	model = pressKey(model, tea.KeyTab)
+	if view = ansi.Strip(model.View()); !strings.Contains(view, "Coffee Shop_") {
+		t.Errorf("expected Coffee Shop completed:\n%s", view)
+	}
+	model = pressKey(model, tea.KeyEsc)
+
+	// The search bar completes account: and payee: terms, quoting payees with spaces
+	model = typeKeys(model, "2")
+	model = typeKeys(model, "/account:groc")
+	model = pressKey(model, tea.KeyTab)
+	model = typeKeys(model, " payee:mkt")
+	model = pressKey(model, tea.KeyTab)
+	model = pressKey(model, tea.KeyEnter)
+	view = ansi.Strip(model.View())
+	if !strings.Contains(view, `account:Expenses:Food:Groceries payee:"Corner Market"`) {
+		t.Errorf("expected completed search terms:\n%s", view)
+	}
+	if !strings.Contains(view, "Corner Market") || strings.Contains(view, "Coffee Shop") {
+		t.Errorf("expected the completed filter applied:\n%s", view)
+	}
+}
+
+func TestMergePayees(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2024-01-02 * "Amazon.com" "Books"
+  Assets:Checking  -30.00 USD
+  Expenses:Books
+
+2024-01-03 * "AMZN Mktp US*2K3" "Cable"
+  Assets:Checking  -10.00 USD
+  Expenses:Home
+
+2024-01-04 * "AMAZON.COM" "Lamp"
+  Assets:Checking  -40.00 USD
+  Expenses:Home
+
+2024-01-05 * "Market" "Groceries"
+  Assets:Checking  -40.00 USD
+  Expenses:Food
+`
+	ledger := filepath.Join(t.TempDir(), "ledger.beancount")
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+	read := func() string {
+		data, _ := os.ReadFile(ledger)
+		return string(data)
+	}
+
+	model := New(file, config.DefaultConfig())
+	model = send(model, tea.WindowSizeMsg{Width: 100, Height: 30})
+	model = send(model, components.MenuSelectedMsg{Menu: "File", Item: "Merge Payees"})
+	view := ansi.Strip(model.View())
+	for _, want := range []string{"Merge Payees (1)", "→ Amazon.com  (3 transactions)", "[x] AMZN Mktp US*2K3", "[x] AMAZON.COM"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the dialog:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Market") {
+		t.Errorf("expected payees spelled one way left out:\n%s", view)
+	}
+
+	// Skip one spelling, then merge the rest after the preview
+	model = typeKeys(model, "j ")
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	view = ansi.Strip(model.View())
+	if !strings.Contains(view, "Confirm: Merge 1 payee as Amazon.com") {
+		t.Fatalf("expected the merge previewed:\n%s", view)
+	}
+	model = confirmWrite(t, model)
+	if strings.Contains(read(), "AMZN") || !strings.Contains(read(), `"AMAZON.COM" "Lamp"`) {
+		t.Fatalf("expected only the included spelling renamed:\n%s", read())
+	}
+	if model.merge == nil || !strings.HasPrefix(model.status(), "Merged 1 payees into Amazon.com") {
+		t.Errorf("expected the dialog open and the merge reported, status %q", model.status())
+	}
+
+	// A typed name is used, and the merge can be undone
+	model = typeKeys(model, "kkkr")
+	for range len("Amazon.com") {
+		model = pressKey(model, tea.KeyBackspace)
+	}
+	model = typeKeys(model, "Amazon")
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	model = typeKeys(model, "jj ") // AMAZON.COM, skipped before
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	model = confirmWrite(t, model)
+	if strings.Count(read(), `"Amazon"`) != 3 {
+		t.Fatalf("expected every spelling renamed to the typed name:\n%s", read())
+	}
+	model = pressKey(model, tea.KeyEsc)
+	if model.merge != nil {
+		t.Fatal("expected esc to close the dialog")
+	}
+	model = typeKeys(model, "u")
+	if strings.Count(read(), `"Amazon"`) != 0 || !strings.Contains(read(), `"AMAZON.COM" "Lamp"`) {
+		t.Errorf("expected the merge undone:\n%s", read())
+	}
+}
+
+func TestTagsView(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2024-01-02 * "Airline" "Flight" #travel #work
+  Assets:Checking  -300.00 USD
+  Expenses:Travel
+
+2024-01-03 * "Hotel" "Stay" #travel
+  Assets:Checking  -200.00 USD
+  Expenses:Travel
+
+2024-01-04 * "Florist" "Flowers" #gift
+  Assets:Checking  -30.00 USD
+  Expenses:Gifts
+
+2024-01-05 * "Market" "Groceries"
+  Assets:Checking  -40.00 USD
+  Expenses:Food
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model = send(model, tea.WindowSizeMsg{Width: 100, Height: 30})
+	model = typeKeys(model, "7")
+	if model.currentView != TagsView {
+		t.Fatalf("expected tags view, got %v", model.currentView)
+	}
+
+	// Tags are listed most used first, with the untagged count last
+	view := ansi.Strip(model.View())
+	for _, want := range []string{"Tags (3)", "#travel", "#work", "#gift", "(untagged)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in tags view:\n%s", want, view)
+		}
+	}
+	if strings.Index(view, "#travel") > strings.Index(view, "#gift") {
+		t.Errorf("expected the most used tag first:\n%s", view)
+	}
+
+	// Enter shows the transactions of the tag under the cursor
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected transactions view, got %v", model.currentView)
+	}
+	view = ansi.Strip(model.View())
+	if !strings.Contains(view, "Airline") || !strings.Contains(view, "Hotel") || strings.Contains(view, "Florist") {
+		t.Errorf("expected the travel transactions:\n%s", view)
+	}
+
+	// Marked tags select the transactions carrying all of them
+	model = typeKeys(model, "7")
+	model = typeKeys(model, "x") // travel
+	model = typeKeys(model, "j") // gift, sorted before work
+	model = typeKeys(model, "x") // work
+	view = ansi.Strip(model.View())
+	if !strings.Contains(view, "#travel #work: 1 transactions") {
+		t.Errorf("expected the intersection count:\n%s", view)
+	}
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	view = ansi.Strip(model.View())
+	if !strings.Contains(view, "Airline") || strings.Contains(view, "Hotel") {
+		t.Errorf("expected only the transaction tagged travel and work:\n%s", view)
+	}
+
+	// The untagged row shows transactions without tags
+	model = typeKeys(model, "7")
+	model = pressKey(model, tea.KeyEsc)
+	model = typeKeys(model, "G")
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	view = ansi.Strip(model.View())
+	if !strings.Contains(view, "Market") || strings.Contains(view, "Airline") {
+		t.Errorf("expected only the untagged transaction:\n%s", view)
+	}
+}
+
+func TestLinkedTransactions(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2024-01-02 * "Client" "Invoice sent" ^invoice-42
+  Assets:Receivable  500.00 USD
+  Income:Consulting
+
+2024-01-10 * "Market" "Groceries"
+  Assets:Checking  -40.00 USD
+  Expenses:Food
+
+2024-02-01 * "Client" "Invoice paid" ^invoice-42
+  Assets:Checking  500.00 USD
+  Assets:Receivable
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+	model = typeKeys(model, "2")
+
+	// A transaction without links says so
+	model = typeKeys(model, "jL")
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "This transaction has no links") {
+		t.Errorf("expected the no links note:\n%s", view)
+	}
+	model = pressKey(model, tea.KeyEsc)
+
+	// The other transactions sharing the link are listed, even when filtered out
+	model = typeKeys(model, "/sent")
+	model = pressKey(model, tea.KeyEnter)
+	model = typeKeys(model, "L")
+	view := ansi.Strip(model.View())
+	if !strings.Contains(view, "Linked Transactions ^invoice-42") || !strings.Contains(view, "2024-02-01  Client") {
+		t.Errorf("expected the paid invoice listed:\n%s", view)
+	}
+
+	// Enter jumps to it, clearing the filter that hid it
+	model = pressKey(model, tea.KeyEnter)
+	view = ansi.Strip(model.View())
+	if strings.Contains(view, "Linked Transactions") || strings.Contains(view, "filter:") {
+		t.Errorf("expected the overlay closed and the filter cleared:\n%s", view)
+	}
+	if !strings.Contains(view, "Row 3/3") {
+		t.Errorf("expected the cursor on the paid invoice:\n%s", view)
+	}
+
+	// The link is a filter term too
+	model = typeKeys(model, "/^invoice-42")
+	model = pressKey(model, tea.KeyEnter)
+	view = ansi.Strip(model.View())
+	if !strings.Contains(view, "Transactions (2 of 3)") || strings.Contains(view, "Market") {
+		t.Errorf("expected the linked transactions filtered:\n%s", view)
+	}
+}
+
+func TestWritePreviewLayouts(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2024-03-02 * "Blue Bottle" "Latte" #coffee
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized  5.00 USD
+
+2024-03-03 * "Market" "Groceries"
+  Assets:Checking  -40.00 USD
+  Expenses:Food
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	cfg := config.DefaultConfig()
+	cfg.Theme.ColorMode = "16"
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	tx, err := file.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	edit, err := model.writer.SetPostingAccount(tx, 1, "Expenses:Food:Coffee")
+	if err != nil {
+		t.Fatalf("failed to build edit: %v", err)
+	}
+	model = send(model, confirm.RequestMsg{Title: "Categorize", Edits: []beancount.Edit{edit}})
+
+	// The unified diff shows the rest of the transaction around the changed posting,
+	// but not the next one
+	view := ansi.Strip(model.View())
+	for _, want := range []string{
+		`    2024-03-02 * "Blue Bottle" "Latte" #coffee`,
+		"    Assets:Checking  -5.00 USD",
+		"  -   Expenses:Uncategorized  5.00 USD",
+		"  +   Expenses:Food:Coffee    5.00 USD",
+		"tab:side by side",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the preview:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Market") {
+		t.Errorf("expected only the edited transaction as context:\n%s", view)
+	}
+
+	// Only the changed part of the line is highlighted
+	if !strings.Contains(model.View(), theme.SuccessStyle.Reverse(true).Render("Food:Coffee  ")) {
+		t.Errorf("expected the changed span highlighted:\n%q", model.View())
+	}
+
+	// Tab puts old and new lines side by side
+	model = pressKey(model, tea.KeyTab)
+	view = ansi.Strip(model.View())
+	var row string
+	for _, line := range strings.Split(view, "\n") {
+		if strings.Contains(line, "Uncategorized") {
+			row = line
+		}
+	}
+	if !strings.Contains(row, "-  Expenses:Uncategorized  5.00 USD") || !strings.Contains(row, "│ +  Expenses:Food:Coffee    5.00 USD") {
+		t.Errorf("expected the old and new posting on one row:\n%s", view)
+	}
+	if !strings.Contains(view, "tab:unified") {
+		t.Errorf("expected the unified layout offered:\n%s", view)
+	}
+}
+
+func TestSessionRestore(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+
+2024-03-02 * "Blue Bottle" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Food:Coffee
+
+2024-03-05 * "Market" "Groceries"
+  Assets:Checking  -40.00 USD
+  Expenses:Food:Groceries
+
+2024-03-09 * "Blue Bottle" "Beans"
+  Assets:Checking  -18.00 USD
+  Expenses:Food:Coffee
+
+2024-04-01 * "Landlord" "Rent"
+  Assets:Checking  -800.00 USD
+  Expenses:Rent
+`
+	ledger := createTempFile(t, content)
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.StateFile = filepath.Join(t.TempDir(), "state.yaml")
+
+	// Without a saved session the default view is shown
+	model := New(file, cfg).RestoreSession()
+	if model.currentView != DashboardView {
+		t.Fatalf("expected the default view, got %v", model.currentView)
+	}
+
+	// Filter to March, sort by amount and move the cursor
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+	model = typeKeys(model, "2")
+	model = typeKeys(model, "/account:food")
+	model = pressKey(model, tea.KeyEnter)
+	model.transactions = model.transactions.SetPeriod(beancount.PeriodContaining(beancount.PeriodMonth, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	model = typeKeys(model, "ssS") // date, then amount, descending
+	model = typeKeys(model, "j")
+	want := ansi.Strip(model.View())
+	if !strings.Contains(want, "Row 2/3") || !strings.Contains(want, "by amount") || !strings.Contains(want, "Mar 2024") {
+		t.Fatalf("unexpected transactions view before saving:\n%s", want)
+	}
+	if err := model.SaveSession(); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	// The next launch resumes in the same place
+	restored := New(file, cfg).RestoreSession()
+	restored = send(restored, tea.WindowSizeMsg{Width: 120, Height: 30})
+	if restored.currentView != TransactionsView {
+		t.Fatalf("expected the transactions view restored, got %v", restored.currentView)
+	}
+	if got := ansi.Strip(restored.View()); got != want {
+		t.Errorf("expected the session restored as saved:\n%s\ngot:\n%s", want, got)
+	}
+
+	// Sessions are kept per ledger
+	otherLedger := filepath.Join(t.TempDir(), "other.beancount")
+	if err := os.WriteFile(otherLedger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+	other, err := beancount.Open(otherLedger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer other.Close()
+	if model := New(other, cfg).RestoreSession(); model.currentView != DashboardView {
+		t.Errorf("expected another ledger to start afresh, got %v", model.currentView)
+	}
+
+	// A view given on the command line wins over the saved one
+	overridden, err := cfg.Override(config.Overrides{View: "problems"})
+	if err != nil {
+		t.Fatalf("Override: %v", err)
+	}
+	if model := New(file, overridden).RestoreSession(); model.currentView != ProblemsView {
+		t.Errorf("expected the view given to win over the session, got %v", model.currentView)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	content := `2024-03-02 * "Blue Bottle" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized
+`
+	ledger := createTempFile(t, content)
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg, err := config.DefaultConfig().Override(config.Overrides{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Override: %v", err)
+	}
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+	if !strings.Contains(model.status(), "Read-only") {
+		t.Errorf("expected a read-only notice, got %q", model.status())
+	}
+
+	tx, err := file.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	edit, err := model.writer.SetPostingAccount(tx, 1, "Expenses:Food:Coffee")
+	if err != nil {
+		t.Fatalf("failed to build edit: %v", err)
+	}
+	model = send(model, confirm.RequestMsg{Title: "Categorize", Edits: []beancount.Edit{edit}})
+	if model.confirm != nil {
+		t.Error("expected no write preview for a read-only ledger")
+	}
+	if !strings.Contains(model.status(), "read-only") {
+		t.Errorf("expected the write refused, got %q", model.status())
+	}
+	if data, _ := os.ReadFile(ledger); string(data) != content {
+		t.Errorf("expected the ledger untouched, got:\n%s", data)
+	}
+}
+
+func TestWriteBack(t *testing.T) {
+	content := `2024-03-02 * "Blue Bottle" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized
+`
+	ledger := createTempFile(t, content)
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.UI.ConfirmCategorize = false
+	var put []string
+	model := New(file, cfg).AfterWrite(func(path string) error {
+		put = append(put, path)
+		return errors.New("connection closed")
+	})
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	tx, err := file.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	edit, err := model.writer.SetPostingAccount(tx, 1, "Expenses:Food:Coffee")
+	if err != nil {
+		t.Fatalf("failed to build edit: %v", err)
+	}
+
+	// The file is written at once but put back by a command, off the UI thread
+	updated, cmd := model.Update(confirm.RequestMsg{Title: "Categorize", Edits: []beancount.Edit{edit}, Single: true})
+	model = updated.(Model)
+	if data, _ := os.ReadFile(ledger); !strings.Contains(string(data), "Expenses:Food:Coffee") {
+		t.Errorf("expected the ledger written, got:\n%s", data)
+	}
+	if len(put) != 0 {
+		t.Fatalf("expected nothing put back during Update, got %v", put)
+	}
+	for _, msg := range runCmd(cmd) {
+		updated, _ = model.Update(msg)
+		model = updated.(Model)
+	}
+	if len(put) != 1 || put[0] != ledger {
+		t.Errorf("expected the ledger put back once, got %v", put)
+	}
+	if !strings.Contains(model.status(), "connection closed") {
+		t.Errorf("expected the failure reported, got %q", model.status())
+	}
+
+	// Nothing is left for lima to put back on quitting
+	if err := model.FinishWriteBack(); err != nil || len(put) != 1 {
+		t.Errorf("expected nothing more to put back, got %v %v", err, put)
+	}
+}
+
+// prefixCipher "encrypts" files ending in .enc by prefixing them
+type prefixCipher struct{}
+
+func (prefixCipher) Encrypted(path string) bool { return strings.HasSuffix(path, ".enc") }
+
+func (prefixCipher) Decrypt(path string, ciphertext []byte) ([]byte, error) {
+	plaintext, ok := bytes.CutPrefix(ciphertext, []byte("ENC:"))
+	if !ok {
+		return nil, errors.New("not encrypted")
+	}
+	return plaintext, nil
+}
+
+func (prefixCipher) Encrypt(path string, plaintext []byte) ([]byte, error) {
+	return append([]byte("ENC:"), plaintext...), nil
+}
+
+func TestEncryptedSave(t *testing.T) {
+	ledger := filepath.Join(t.TempDir(), "main.beancount.enc")
+	content := `ENC:2024-03-02 * "Blue Bottle" "Latte"
+  Assets:Checking  -5.00 USD
+  Expenses:Uncategorized
+`
+	if err := os.WriteFile(ledger, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := beancount.NewStore(prefixCipher{}).Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.UI.ConfirmCategorize = false
+	model := New(file, cfg)
+	tx, err := file.GetTransaction(0)
+	if err != nil {
+		t.Fatalf("failed to get transaction: %v", err)
+	}
+	edit, err := model.writer.SetPostingAccount(tx, 1, "Expenses:Food:Coffee")
+	if err != nil {
+		t.Fatalf("failed to build edit: %v", err)
+	}
+
+	// The edit shows at once but is encrypted and written by a command
+	updated, cmd := model.Update(confirm.RequestMsg{Title: "Categorize", Edits: []beancount.Edit{edit}, Single: true})
+	model = updated.(Model)
+	if data, _ := os.ReadFile(ledger); string(data) != content {
+		t.Fatalf("expected the file untouched during Update, got:\n%s", data)
+	}
+	if data, _ := file.Store().ReadFile(ledger); !strings.Contains(string(data), "Expenses:Food:Coffee") {
+		t.Errorf("expected the edit read back, got:\n%s", data)
+	}
+	if !model.savePending() {
+		t.Error("expected the save pending")
+	}
+	for _, msg := range runCmd(cmd) {
+		updated, _ = model.Update(msg)
+		model = updated.(Model)
+	}
+	data, _ := os.ReadFile(ledger)
+	if !bytes.HasPrefix(data, []byte("ENC:")) || !strings.Contains(string(data), "Expenses:Food:Coffee") {
+		t.Errorf("expected the edit encrypted and written, got:\n%s", data)
+	}
+	if model.savePending() {
+		t.Error("expected nothing left to save")
+	}
+}
+
+func TestProblemsView(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food
+
+2024-01-02 * "Market" "Groceries"
+  Assets:Checking  -40.00 USD
+  Expenses:Food
+
+2024-01-03 * "Bakery" "Bread"
+  Assets:Checking  -5.00 USD
+  Expenses:Food  4.50 USD
+
+2024-01-04 * "Cafe" "Coffee"
+  Assets:Checking  -3.00 USD
+  Expenses:Food
+
+Stray text
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	model := New(file, config.DefaultConfig())
+	model = send(model, tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	// The View menu counts the problems
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}, Alt: true})
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "Problems (2)") {
+		t.Errorf("expected the problem count on the menu entry:\n%s", view)
+	}
+	model = pressKey(model, tea.KeyEsc)
+
+	model = typeKeys(model, "8")
+	if model.currentView != ProblemsView {
+		t.Fatalf("expected problems view, got %v", model.currentView)
+	}
+	view := ansi.Strip(model.View())
+	for _, want := range []string{"Problems (2)", "unbalanced", "-0.50 USD left over", "Unrecognized line", "2024-01-03 * \"Bakery\""} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in problems view:\n%s", want, view)
+		}
+	}
+
+	// Enter on a transaction's problem shows the transaction
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	if model.currentView != TransactionsView {
+		t.Fatalf("expected transactions view, got %v", model.currentView)
+	}
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "Row 2/3") {
+		t.Errorf("expected the cursor on the unbalanced transaction:\n%s", view)
+	}
+
+	// On any other it shows the line among its neighbours, until Esc
+	model = typeKeys(model, "8")
+	model = typeKeys(model, "j")
+	model = send(model, tea.KeyMsg{Type: tea.KeyEnter})
+	view = ansi.Strip(model.View())
+	if !strings.Contains(view, "   16  Stray text") || !strings.Contains(view, "   13  ") {
+		t.Errorf("expected the stray line with its neighbours:\n%s", view)
+	}
+	model = typeKeys(model, "q")
+	if model.currentView != ProblemsView || model.problems.Editing() {
+		t.Fatalf("expected q to close the source and stay in the problems view")
+	}
+}
+
+func TestImportsView(t *testing.T) {
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food
+
+2024-01-02 * "Market" "Groceries"
+  Assets:Checking  -40.00 USD
+  Expenses:Food  40.00 USD
+`
+	journal := `2024/01/02 Market
+    assets:checking   -40.00 USD
+    expenses:food
+
+2024/01/05 Bakery
+    assets:checking   -5.00 USD
+    expenses:food
+
+2024/01/06 Cafe
+    assets:checking   -3.00 USD
+    expenses:food
+`
+	tmpDir := t.TempDir()
+	ledger := filepath.Join(tmpDir, "ledger.beancount")
+	if err := os.WriteFile(ledger, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write ledger: %v", err)
+	}
+	statement := filepath.Join(tmpDir, "bank.journal")
+	if err := os.WriteFile(statement, []byte(journal), 0644); err != nil {
+		t.Fatalf("failed to write statement: %v", err)
+	}
+	file, err := beancount.Open(ledger)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	model = typeKeys(model, "9")
+	if model.currentView != ImportsView {
+		t.Fatalf("expected imports view, got %v", model.currentView)
+	}
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}})
+	if model.importFile == nil {
+		t.Fatal("expected i to open the import dialog")
+	}
+	if view := ansi.Strip(model.View()); !strings.Contains(view, "Import Statement") {
+		t.Errorf("expected the import dialog:\n%s", view)
+	}
+	// The dialog starts in the ledger's directory
+	model = typeKeys(model, "bank.journal")
+	model = pressKey(model, tea.KeyEnter)
+	if model.importFile != nil {
+		t.Fatalf("expected the statement imported, got %v", ansi.Strip(model.importFile.View()))
+	}
+
+	view := ansi.Strip(model.View())
+	for _, want := range []string{"bank.journal (2 staged)", "duplicate", "Market", "Bakery", "Cafe", "Already in the ledger as 2024-01-02 Market"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in imports view:\n%s", want, view)
+		}
+	}
+	if data, _ := os.ReadFile(ledger); string(data) != content {
+		t.Errorf("expected staged transactions not written yet, got:\n%s", data)
+	}
+
+	// Appending one transaction leaves the rest staged
+	model = typeKeys(model, "j")
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	model = confirmWrite(t, model)
+	data, _ := os.ReadFile(ledger)
+	if !strings.Contains(string(data), `"Bakery"`) || strings.Contains(string(data), `"Cafe"`) {
+		t.Errorf("expected only Bakery appended, got:\n%s", data)
+	}
+	if model.imports.Pending() != 1 {
+		t.Errorf("expected 1 transaction still staged, got %d", model.imports.Pending())
+	}
+
+	// Discarded transactions aren't appended with the rest
+	model = typeKeys(model, "jd")
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'A'}})
+	if model.confirm != nil {
+		t.Error("expected nothing left to append")
+	}
+
+	// The append is undone like any other edit
+	model = typeKeys(model, "u")
+	if data, _ := os.ReadFile(ledger); string(data) != content {
+		t.Errorf("expected the ledger restored after undo, got:\n%s", data)
+	}
+}
+
+func TestSyncStagesInImportsView(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"errors": [], "accounts": [{"id": "ACT-1", "name": "Checking", "currency": "USD", "transactions": [
+			{"id": "T1", "posted": 1704153600, "amount": "-40.00", "description": "Market"},
+			{"id": "T2", "posted": 1704412800, "amount": "-5.00", "description": "Bakery"}
+		]}]}`)
+	}))
+	defer server.Close()
+
+	content := `2024-01-01 open Assets:Checking
+2024-01-01 open Expenses:Food
+
+2024-01-02 * "Market" "Groceries"
+  Assets:Checking  -40.00 USD
+  Expenses:Food  40.00 USD
+`
+	file, err := beancount.Open(createTempFile(t, content))
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Files.PatternsFile = ""
+	cfg.Sync.AccessURLEnv = "LIMA_TEST_SIMPLEFIN_URL"
+	cfg.Sync.Accounts = map[string]string{"ACT-1": "Assets:Checking"}
+	t.Setenv(cfg.Sync.AccessURLEnv, server.URL)
+	model := New(file, cfg)
+	model = send(model, tea.WindowSizeMsg{Width: 120, Height: 30})
+
+	// Sync is off until enabled
+	model = typeKeys(model, "9")
+	model = send(model, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	if !strings.Contains(model.status(), "sync.enabled") {
+		t.Errorf("expected a notice that sync is off, got %q", model.status())
+	}
+
+	model.config.Sync.Enabled = true
+	model = send(model, imports.SyncRequestedMsg{})
+	if model.currentView != ImportsView || model.imports.Pending() != 1 {
+		t.Fatalf("expected the new transaction staged, got %d in view %v: %s", model.imports.Pending(), model.currentView, model.status())
+	}
+	view := ansi.Strip(model.View())
+	for _, want := range []string{"simplefin sync (1 staged)", "duplicate", "Bakery"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in imports view:\n%s", want, view)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"errors"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mmichie/lima/internal/beancount"
+	"github.com/mmichie/lima/internal/ui/components"
+)
+
+// writeBack finishes saving the ledger files the model rewrites, off the UI thread:
+// the writer only notes each file, and once Update has handled the message that wrote
+// them a command encrypts those held by the store and puts them back where they came
+// from, one batch at a time
+type writeBack struct {
+	store   *beancount.Store
+	put     func(path string) error // Nil when the files stay where they are
+	pending []string                // Written since the last batch started
+	running []string                // The batch being saved, if any
+}
+
+// writeBackMsg reports how saving a batch went
+type writeBackMsg struct {
+	failed string // The file that couldn't be put back; the rest of the batch wasn't tried
+	err    error
+}
+
+// newWriteBack notes the files the writer rewrites, for a batch to save them
+func newWriteBack(store *beancount.Store, writer *beancount.Writer) *writeBack {
+	wb := &writeBack{store: store}
+	writer.SetAfterWrite(func(path string) error {
+		if !slices.Contains(wb.pending, path) {
+			wb.pending = append(wb.pending, path)
+		}
+		return nil
+	})
+	return wb
+}
+
+// AfterWrite sets a function called with each ledger file the model rewrites, once
+// it is written, such as to put it back where it came from
+// It runs in a command, so a slow transfer doesn't hold up the view.
+func (m Model) AfterWrite(put func(path string) error) Model {
+	m.writeBack.put = put
+	return m
+}
+
+// scheduleWriteBack starts saving the files written since the last batch, unless a
+// batch is still running
+func (m Model) scheduleWriteBack(cmd tea.Cmd) (Model, tea.Cmd) {
+	wb := m.writeBack
+	if wb == nil || wb.running != nil || len(wb.pending) == 0 {
+		return m, cmd
+	}
+	paths, store, put := wb.pending, wb.store, wb.put
+	wb.pending, wb.running = nil, paths
+	return m, tea.Batch(cmd, func() tea.Msg {
+		if err := store.Flush(); err != nil {
+			return writeBackMsg{err: err}
+		}
+		if put == nil {
+			return writeBackMsg{}
+		}
+		for _, path := range paths {
+			if err := put(path); err != nil {
+				return writeBackMsg{failed: path, err: err}
+			}
+		}
+		return writeBackMsg{}
+	})
+}
+
+// writeBackDone reports a failed batch; the next one starts once Update returns
+// Plaintext the store failed to encrypt stays with it, for the next batch to try again.
+func (m Model) writeBackDone(msg writeBackMsg) Model {
+	m.writeBack.running = nil
+	switch {
+	case msg.err == nil:
+		return m
+	case msg.failed == "":
+		return m.notifyf(components.LevelError, "Failed to save: %v", msg.err)
+	default:
+		return m.notifyf(components.LevelError, "Failed to write %s back: %v", msg.failed, msg.err)
+	}
+}
+
+// savePending reports whether files written are still to be saved
+func (m Model) savePending() bool {
+	wb := m.writeBack
+	return wb.running != nil || len(wb.pending) > 0 || wb.store.Pending()
+}
+
+// FinishWriteBack saves what is still to be, waiting for it, for when lima quits
+// before the last batch is done; files of a batch already put back are put again,
+// which changes nothing on the server
+func (m Model) FinishWriteBack() error {
+	wb := m.writeBack
+	errs := []error{wb.store.Flush()}
+	if wb.put != nil {
+		for _, path := range append(wb.running, wb.pending...) {
+			errs = append(errs, wb.put(path))
+		}
+	}
+	wb.pending, wb.running = nil, nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,174 @@
+// Package watch polls a ledger's files and an inbox directory, for lima watch. It
+// reports each change to the ledger and each statement left in the inbox, once the
+// statement has stopped changing between polls so a half-copied file is never read.
+package watch
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kind is what an event reports
+type Kind int
+
+const (
+	LedgerChanged Kind = iota // One of the ledger's files was written, created or removed
+	NewStatement              // A file arrived in the inbox
+)
+
+// Event is a change the watcher noticed
+type Event struct {
+	Kind Kind
+	Path string // The statement, or the ledger file that changed first
+}
+
+// stamp is what tells one version of a file from the next
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher polls a ledger and an inbox for changes
+type Watcher struct {
+	files func() []string // The ledger and its includes, as currently read
+	inbox string          // "" watches the ledger only
+
+	ledger  map[string]stamp // Ledger files as last seen
+	pending map[string]stamp // Inbox files seen on the last poll, not yet settled
+	done    map[string]stamp // Inbox files already reported, as they were then
+}
+
+// New creates a watcher over the files files returns and the inbox directory, taking
+// the ledger as it is now; statements already in the inbox are reported by the first
+// polls, as new ones are
+func New(files func() []string, inbox string) *Watcher {
+	w := &Watcher{
+		files:   files,
+		inbox:   inbox,
+		pending: make(map[string]stamp),
+		done:    make(map[string]stamp),
+	}
+	w.Sync()
+	return w
+}
+
+// Sync takes the ledger's files as they are now, so changes lima made itself, such
+// as staging an import, are not reported back
+func (w *Watcher) Sync() {
+	w.ledger = w.ledgerStamps()
+}
+
+// Poll returns the events since the last poll: a ledger change first, then the
+// settled statements in name order
+func (w *Watcher) Poll() []Event {
+	var events []Event
+	if stamps := w.ledgerStamps(); !maps.Equal(stamps, w.ledger) {
+		events = append(events, Event{Kind: LedgerChanged, Path: firstChanged(w.ledger, stamps)})
+		w.ledger = stamps
+	}
+	if w.inbox == "" {
+		return events
+	}
+
+	current := inboxStamps(w.inbox)
+	var settled []string
+	for path, s := range current {
+		if done, ok := w.done[path]; ok && done == s {
+			continue
+		}
+		if pending, ok := w.pending[path]; ok && pending == s {
+			settled = append(settled, path)
+		}
+	}
+	sort.Strings(settled)
+	for _, path := range settled {
+		events = append(events, Event{Kind: NewStatement, Path: path})
+		w.done[path] = current[path]
+	}
+	// Files moved away, such as once imported, are forgotten, so one arriving again
+	// under the same name is a new statement
+	for path := range w.done {
+		if _, ok := current[path]; !ok {
+			delete(w.done, path)
+		}
+	}
+	w.pending = current
+	return events
+}
+
+// Run polls every interval until ctx is done, calling handle with each event
+func (w *Watcher) Run(ctx context.Context, interval time.Duration, handle func(Event)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, event := range w.Poll() {
+			handle(event)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ledgerStamps returns the ledger's files as they are now; a missing file has the
+// zero stamp
+func (w *Watcher) ledgerStamps() map[string]stamp {
+	stamps := make(map[string]stamp)
+	for _, path := range w.files() {
+		var s stamp
+		if info, err := os.Stat(path); err == nil {
+			s = stamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		stamps[path] = s
+	}
+	return stamps
+}
+
+// inboxStamps returns the regular files directly in dir, leaving out hidden ones
+// such as editors' and downloads' temporary files; an unreadable dir has none
+func inboxStamps(dir string) map[string]stamp {
+	stamps := make(map[string]stamp)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return stamps
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		stamps[filepath.Join(dir, entry.Name())] = stamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps
+}
+
+// firstChanged returns the first path, in name order, that differs between two sets
+// of stamps
+func firstChanged(before, after map[string]stamp) string {
+	var changed []string
+	for path, s := range after {
+		if old, ok := before[path]; !ok || old != s {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	if len(changed) == 0 {
+		return ""
+	}
+	return changed[0]
+}
@@ -0,0 +1,122 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPollLedger(t *testing.T) {
+	dir := t.TempDir()
+	ledger := filepath.Join(dir, "main.beancount")
+	include := filepath.Join(dir, "2025.beancount")
+	write(t, ledger, "include \"2025.beancount\"\n")
+	write(t, include, "")
+
+	w := New(func() []string { return []string{ledger, include} }, "")
+	if events := w.Poll(); len(events) != 0 {
+		t.Fatalf("expected no events before a change, got %+v", events)
+	}
+
+	write(t, include, "2025-01-01 open Assets:Cash\n")
+	events := w.Poll()
+	if len(events) != 1 || events[0].Kind != LedgerChanged || events[0].Path != include {
+		t.Fatalf("expected the include reported changed, got %+v", events)
+	}
+	if events := w.Poll(); len(events) != 0 {
+		t.Errorf("expected a change reported once, got %+v", events)
+	}
+
+	// Writes lima makes itself are taken in with Sync
+	write(t, ledger, "include \"2025.beancount\"\n; staged\n")
+	w.Sync()
+	if events := w.Poll(); len(events) != 0 {
+		t.Errorf("expected no events after Sync, got %+v", events)
+	}
+
+	if err := os.Remove(include); err != nil {
+		t.Fatal(err)
+	}
+	if events := w.Poll(); len(events) != 1 || events[0].Path != include {
+		t.Errorf("expected the removed include reported, got %+v", events)
+	}
+}
+
+func TestPollInbox(t *testing.T) {
+	dir := t.TempDir()
+	ledger := filepath.Join(dir, "main.beancount")
+	write(t, ledger, "")
+	inbox := filepath.Join(dir, "inbox")
+	if err := os.Mkdir(inbox, 0755); err != nil {
+		t.Fatal(err)
+	}
+	existing := filepath.Join(inbox, "a.csv")
+	write(t, existing, "date,amount\n")
+	write(t, filepath.Join(inbox, ".b.csv.part"), "date,am")
+	if err := os.Mkdir(filepath.Join(inbox, "imported"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	w := New(func() []string { return []string{ledger} }, inbox)
+	// A statement is reported once it is the same on two polls in a row
+	if events := w.Poll(); len(events) != 0 {
+		t.Fatalf("expected statements to settle first, got %+v", events)
+	}
+	events := w.Poll()
+	if len(events) != 1 || events[0].Kind != NewStatement || events[0].Path != existing {
+		t.Fatalf("expected the statement already there, got %+v", events)
+	}
+	if events := w.Poll(); len(events) != 0 {
+		t.Errorf("expected a statement reported once, got %+v", events)
+	}
+
+	// One still being written waits until it stops changing
+	arriving := filepath.Join(inbox, "c.qif")
+	write(t, arriving, "!Type:Bank\n")
+	w.Poll()
+	write(t, arriving, "!Type:Bank\nD01/02/2025\n")
+	if events := w.Poll(); len(events) != 0 {
+		t.Errorf("expected a changing statement held back, got %+v", events)
+	}
+	if events := w.Poll(); len(events) != 1 || events[0].Path != arriving {
+		t.Errorf("expected the statement once settled, got %+v", events)
+	}
+
+	// Moved away and dropped in again, a statement is new
+	if err := os.Remove(existing); err != nil {
+		t.Fatal(err)
+	}
+	w.Poll()
+	write(t, existing, "date,amount\n")
+	w.Poll()
+	if events := w.Poll(); len(events) != 1 || events[0].Path != existing {
+		t.Errorf("expected the statement reported again, got %+v", events)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	ledger := filepath.Join(dir, "main.beancount")
+	write(t, ledger, "")
+	w := New(func() []string { return []string{ledger} }, "")
+	write(t, ledger, "; changed\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []Event
+	w.Run(ctx, time.Millisecond, func(event Event) {
+		events = append(events, event)
+		cancel()
+	})
+	if len(events) != 1 || events[0].Kind != LedgerChanged {
+		t.Errorf("expected one ledger change, got %+v", events)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -19,30 +22,177 @@ type Config struct {
 	// Theme configuration
 	Theme ThemeConfig `yaml:"theme"`
 
+	// Amount formatting
+	Amounts AmountsConfig `yaml:"amounts"`
+
+	// Dashboard layout
+	Dashboard DashboardConfig `yaml:"dashboard"`
+
 	// Keybindings
 	Keybindings KeybindingsConfig `yaml:"keybindings"`
 
 	// Categorization settings
 	Categorization CategorizationConfig `yaml:"categorization"`
+
+	// Bank sync settings
+	Sync SyncConfig `yaml:"sync"`
+
+	// Price fetching settings
+	Prices PricesConfig `yaml:"prices"`
+
+	// API server settings
+	Serve ServeConfig `yaml:"serve"`
+
+	// Backups taken before writes
+	Backup BackupConfig `yaml:"backup"`
+
+	// Encrypted ledger files
+	Encryption EncryptionConfig `yaml:"encryption"`
+
+	// Ledgers on other machines
+	Remote RemoteConfig `yaml:"remote"`
+
+	// lima watch settings
+	Watch WatchConfig `yaml:"watch"`
+
+	// lima tax settings
+	Tax TaxConfig `yaml:"tax"`
+
+	// lima shared settings
+	Shared SharedConfig `yaml:"shared"`
+
+	// Unusual transactions flagged on the dashboard and in the Anomalies report
+	Anomalies AnomaliesConfig `yaml:"anomalies"`
+
+	// Spending limits and savings targets tracked by the goals dashboard widget
+	Goals []GoalConfig `yaml:"goals"`
+
+	// Profiles are named sets of overrides, chosen with --profile or LIMA_PROFILE; each
+	// takes the same settings as the rest of the file
+	Profiles map[string]yaml.Node `yaml:"profiles,omitempty"`
+
+	// set holds the dotted YAML paths the loaded file gave, e.g. "ui.compact_mode", so
+	// merging can tell a false or zero written out from one left unset
+	set map[string]bool
+
+	// overrides are the command-line settings applied over the files
+	overrides Overrides
 }
 
 // FilesConfig contains file path settings
 type FilesConfig struct {
 	DefaultLedger string `yaml:"default_ledger"`
 	PatternsFile  string `yaml:"patterns_file"`
+	BudgetsFile   string `yaml:"budgets_file"`   // Used when the ledger has no custom "budget" directives
+	ImportersFile string `yaml:"importers_file"` // Column mappings of bank statements for lima import
+	PricesFile    string `yaml:"prices_file"`    // Where lima prices fetch appends price directives
+	PriceCache    string `yaml:"price_cache"`    // Quotes fetched recently, reused within prices.cache_minutes
+	RecentFiles   string `yaml:"recent_files"`   // History of opened ledgers, most recent first
+	StateFile     string `yaml:"state_file"`     // Where each ledger's session left off; "" starts afresh
 }
 
 // UIConfig contains UI preferences
 type UIConfig struct {
-	DefaultView     string `yaml:"default_view"` // "dashboard", "transactions", "accounts", "reports"
-	PageSize        int    `yaml:"page_size"`    // Number of items per page
-	DateFormat      string `yaml:"date_format"`  // Date format string
+	DefaultView     string `yaml:"default_view"` // "dashboard", "transactions", "accounts", "reports", "patterns", "review", "budgets", "tags", "problems", "imports"
+	PageSize        int    `yaml:"page_size"`    // Rows page up and page down move, up to MaxPageSize
+	DateFormat      string `yaml:"date_format"`  // Preset (iso, us, eu) or Go time layout dates are shown with
 	ShowLineNumbers bool   `yaml:"show_line_numbers"`
-	CompactMode     bool   `yaml:"compact_mode"`
+	CompactMode     bool   `yaml:"compact_mode"` // Dense lists: no separators, abbreviated accounts, less padding
+
+	// ConfirmCategorize previews single categorizations and flag changes before writing them;
+	// deletes, edits and bulk changes are always confirmed
+	ConfirmCategorize bool `yaml:"confirm_categorize"`
+
+	// ShowHelpRow shows every key binding of the current view above the status bar
+	ShowHelpRow bool `yaml:"show_help_row"`
+
+	// DiffStyle lays out write previews: "unified" or "side_by_side"
+	DiffStyle string `yaml:"diff_style"`
+
+	// ReadOnly opens ledgers without ever writing to them; edits are refused
+	ReadOnly bool `yaml:"read_only"`
+}
+
+// MaxPageSize is the largest page size allowed
+const MaxPageSize = 1000
+
+// DatePresets are the named date formats, by the Go time layout they stand for
+var DatePresets = map[string]string{
+	"iso": "2006-01-02",
+	"us":  "01/02/2006",
+	"eu":  "02.01.2006",
 }
 
+// DateLayout returns the Go time layout of a date format: a preset's layout, the
+// format itself, or ISO when empty
+func DateLayout(format string) string {
+	if layout, ok := DatePresets[format]; ok {
+		return layout
+	}
+	if format == "" {
+		return DatePresets["iso"]
+	}
+	return format
+}
+
+// ReservedKeys are the keys the UI handles itself, by what they do; bindings may not
+// use them, nor alt+letter, which opens the menu bar
+var ReservedKeys = map[string]string{
+	"f1":  "help (F1)",
+	"f2":  "the dashboard (F2)",
+	"f3":  "transactions (F3)",
+	"f4":  "accounts (F4)",
+	"f5":  "reports (F5)",
+	"f6":  "patterns (F6)",
+	"f7":  "review (F7)",
+	"f8":  "budgets (F8)",
+	"f9":  "tags (F9)",
+	"f10": "the menu bar (F10)",
+	"A":   "applying staged suggestions",
+}
+
+// ViewKeys are the keys the views handle themselves, by what they do, e.g. "d": "delete
+// in transactions"; the UI adds its views' as it starts, from their key maps
+// Global bindings take a key before the view does, so they may not use these; the
+// navigation bindings are handled by the views themselves and may.
+var ViewKeys = map[string]string{}
+
+// navigationBindings are the bindings the views handle, rather than the root model
+var navigationBindings = []string{"up", "down", "page_up", "page_down", "top", "bottom", "select", "back"}
+
+// reservedKey returns what a fixed key does, or "" when the binding named may use it
+func reservedKey(binding, k string) string {
+	if use, ok := ReservedKeys[k]; ok {
+		return use
+	}
+	if strings.HasPrefix(k, "alt+") {
+		return "the menu bar (alt+letter)"
+	}
+	if use, ok := ViewKeys[k]; ok && !slices.Contains(navigationBindings, binding) {
+		return use
+	}
+	return ""
+}
+
+// DiffStyles are the layouts of the write preview
+var DiffStyles = []string{"unified", "side_by_side"}
+
+// ViewNames are the views lima can open in
+var ViewNames = []string{"dashboard", "transactions", "accounts", "reports", "patterns", "review", "budgets", "tags", "problems", "imports"}
+
+// ThemeNames are the built-in themes
+var ThemeNames = []string{"tp7", "light", "high_contrast"}
+
+// ColorModes are the color depths the UI may render with
+// auto detects what the terminal supports; none renders without colors.
+var ColorModes = []string{"auto", "truecolor", "256", "16", "none"}
+
 // ThemeConfig contains theme settings
 type ThemeConfig struct {
+	Name      string `yaml:"name"`       // Built-in theme: tp7, light or high_contrast
+	ColorMode string `yaml:"color_mode"` // auto, truecolor, 256, 16 or none
+
+	// Colors override the built-in theme's; empty colors keep the theme's own
 	Primary    string `yaml:"primary"`    // Primary accent color
 	Secondary  string `yaml:"secondary"`  // Secondary accent color
 	Success    string `yaml:"success"`    // Success/positive color
@@ -53,22 +203,80 @@ type ThemeConfig struct {
 	Background string `yaml:"background"` // Background color
 }
 
+// AmountLocales are the locales amounts may be formatted for
+// The empty locale writes amounts as the ledger does: 1234.56 USD
+var AmountLocales = []string{"", "en", "de", "fr", "ch"}
+
+// AmountsConfig controls how amounts are displayed; the ledger is never affected
+type AmountsConfig struct {
+	Locale             string            `yaml:"locale"`              // Decimal mark, grouping and symbol placement: en (1,234.56), de (1.234,56), fr (1 234,56), ch (1'234.56)
+	DecimalMark        string            `yaml:"decimal_mark"`        // Overrides the locale's decimal mark
+	ThousandsSeparator string            `yaml:"thousands_separator"` // Overrides the locale's grouping separator
+	Symbols            map[string]string `yaml:"symbols"`             // Commodity -> symbol shown instead of the code, e.g. USD: $
+	SymbolPosition     string            `yaml:"symbol_position"`     // "before" or "after" the number; defaults to the locale's
+	Precision          map[string]int    `yaml:"precision"`           // Commodity -> decimal places (default 2), e.g. JPY: 0
+
+	// Currency is the operating currency report and dashboard totals are converted into
+	// with the ledger's price directives; empty shows native commodities side by side
+	Currency string `yaml:"currency"`
+}
+
+// DashboardWidgets are the widget names a dashboard layout may use
+var DashboardWidgets = []string{
+	"net_worth",
+	"spending",
+	"income_expenses",
+	"uncategorized",
+	"recent_transactions",
+	"upcoming_bills",
+	"top_merchants",
+	"top_categories",
+	"stats",
+	"anomalies",
+	"goals",
+}
+
+// DashboardConfig contains dashboard settings
+type DashboardConfig struct {
+	// Layout lists the rows of the dashboard grid, each a list of widget names
+	// sharing the row's width; widgets left out are not shown
+	Layout [][]string `yaml:"layout"`
+}
+
 // KeybindingsConfig contains keybinding settings
 type KeybindingsConfig struct {
-	Quit         []string `yaml:"quit"`
-	Help         []string `yaml:"help"`
-	Dashboard    []string `yaml:"dashboard"`
-	Transactions []string `yaml:"transactions"`
-	Accounts     []string `yaml:"accounts"`
-	Reports      []string `yaml:"reports"`
-	Up           []string `yaml:"up"`
-	Down         []string `yaml:"down"`
-	PageUp       []string `yaml:"page_up"`
-	PageDown     []string `yaml:"page_down"`
-	Top          []string `yaml:"top"`
-	Bottom       []string `yaml:"bottom"`
-	Select       []string `yaml:"select"`
-	Back         []string `yaml:"back"`
+	Quit           []string `yaml:"quit"`
+	Help           []string `yaml:"help"`
+	Dashboard      []string `yaml:"dashboard"`
+	Transactions   []string `yaml:"transactions"`
+	Accounts       []string `yaml:"accounts"`
+	Reports        []string `yaml:"reports"`
+	Patterns       []string `yaml:"patterns"`
+	Review         []string `yaml:"review"`
+	Budgets        []string `yaml:"budgets"`
+	Tags           []string `yaml:"tags"`
+	Problems       []string `yaml:"problems"`
+	Imports        []string `yaml:"imports"`
+	NewTransaction []string `yaml:"new_transaction"`
+	OpenFile       []string `yaml:"open_file"`
+	Export         []string `yaml:"export"`
+	Messages       []string `yaml:"messages"`
+	Theme          []string `yaml:"theme"`
+	Currency       []string `yaml:"currency"`
+	Compact        []string `yaml:"compact"`
+	LargerPage     []string `yaml:"larger_page"`
+	SmallerPage    []string `yaml:"smaller_page"`
+	HelpRow        []string `yaml:"help_row"`
+	Undo           []string `yaml:"undo"`
+	Redo           []string `yaml:"redo"`
+	Up             []string `yaml:"up"`
+	Down           []string `yaml:"down"`
+	PageUp         []string `yaml:"page_up"`
+	PageDown       []string `yaml:"page_down"`
+	Top            []string `yaml:"top"`
+	Bottom         []string `yaml:"bottom"`
+	Select         []string `yaml:"select"`
+	Back           []string `yaml:"back"`
 }
 
 // CategorizationConfig contains categorization settings
@@ -77,6 +285,180 @@ type CategorizationConfig struct {
 	AutoCategorize      bool    `yaml:"auto_categorize"`
 	ConfidenceThreshold float64 `yaml:"confidence_threshold"`
 	LearnFromEdits      bool    `yaml:"learn_from_edits"`
+	StrictPatterns      bool    `yaml:"strict_patterns"` // Fail on any invalid pattern instead of skipping it
+
+	// Auto-categorization applies suggestions at or above AutoThreshold
+	AutoThreshold float64 `yaml:"auto_threshold"` // Minimum confidence to auto-apply (0.0-1.0)
+	AutoMode      string  `yaml:"auto_mode"`      // "stage" (review first) or "write" (edit the ledger)
+	AuditLog      string  `yaml:"audit_log"`      // JSON Lines log of automatic categorizations
+
+	// Similarity suggests categories for unseen payees from similar, already categorized payees
+	SimilarityEnabled   bool    `yaml:"similarity_enabled"`
+	SimilarityThreshold float64 `yaml:"similarity_threshold"` // Minimum payee similarity (0.0-1.0)
+
+	// LLM is the optional language-model categorization backend
+	LLM LLMConfig `yaml:"llm"`
+
+	// Merchants is the built-in merchant knowledge base
+	Merchants MerchantsConfig `yaml:"merchants"`
+}
+
+// MerchantsConfig contains settings for the merchant knowledge base
+type MerchantsConfig struct {
+	Enabled    bool              `yaml:"enabled"`
+	File       string            `yaml:"file"`       // Optional YAML file extending or overriding the built-in merchants
+	Archetypes map[string]string `yaml:"archetypes"` // Archetype name -> account override
+}
+
+// LLMConfig contains settings for the opt-in LLM categorization backend
+type LLMConfig struct {
+	Enabled           bool   `yaml:"enabled"`
+	Endpoint          string `yaml:"endpoint"`            // OpenAI-compatible base URL
+	Model             string `yaml:"model"`               // Chat model name
+	APIKeyEnv         string `yaml:"api_key_env"`         // Environment variable holding the API key
+	TimeoutSeconds    int    `yaml:"timeout_seconds"`     // Per-request timeout
+	RequestsPerMinute int    `yaml:"requests_per_minute"` // Rate limit
+}
+
+// SyncProviders are the aggregators lima sync pulls transactions from
+var SyncProviders = []string{"simplefin", "plaid"}
+
+// SyncConfig contains settings for the opt-in bank sync, which pulls transactions from
+// an aggregator; credentials are read from environment variables, never the file
+type SyncConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Provider string `yaml:"provider"` // "simplefin" or "plaid"
+
+	// AccessURLEnv names the environment variable holding the SimpleFIN access URL,
+	// credentials included, as lima sync --claim prints it
+	AccessURLEnv string `yaml:"access_url_env"`
+
+	// Plaid API base URL and the environment variables holding its credentials
+	Endpoint       string `yaml:"endpoint"`
+	ClientIDEnv    string `yaml:"client_id_env"`
+	SecretEnv      string `yaml:"secret_env"`
+	AccessTokenEnv string `yaml:"access_token_env"`
+
+	Accounts       map[string]string `yaml:"accounts"`        // Aggregator account ID -> beancount account
+	Days           int               `yaml:"days"`            // How many days back a sync fetches
+	TimeoutSeconds int               `yaml:"timeout_seconds"` // Per-request timeout
+}
+
+// ServeConfig contains settings for lima serve, the JSON API over HTTP, and lima grpc,
+// the categorizer's gRPC service; the token every request must bear is read from an
+// environment variable, never the file
+type ServeConfig struct {
+	Address     string `yaml:"address"`      // host:port lima serve listens on
+	GRPCAddress string `yaml:"grpc_address"` // host:port lima grpc listens on
+	TokenEnv    string `yaml:"token_env"`    // Environment variable holding the API token
+
+	// TLS certificate and key in PEM files; lima serve uses them when set, lima grpc
+	// requires them since it speaks HTTP/2
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// BackupConfig contains settings for the copies lima keeps of each ledger file before
+// writing to it, which lima restore puts back
+type BackupConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Dir     string `yaml:"dir"`  // Where backups are kept, in a directory per file
+	Keep    int    `yaml:"keep"` // How many backups of each file are kept; older ones are removed
+}
+
+// EncryptionConfig contains settings for ledger files encrypted with age (.age files)
+// or GPG (.gpg and .asc files), which are decrypted in memory and encrypted again on
+// every write, so their plaintext never reaches the disk
+type EncryptionConfig struct {
+	AgeIdentity   string   `yaml:"age_identity"`   // Identity file age decrypts with; files are also encrypted to it
+	AgeRecipients []string `yaml:"age_recipients"` // More recipients age encrypts files to, e.g. age1... or ssh-ed25519 keys
+
+	// GPGRecipients are the keys GPG encrypts files to, with gpg-agent unlocking the
+	// secret key; without any, files are encrypted with a passphrase
+	GPGRecipients []string `yaml:"gpg_recipients"`
+
+	// PassphraseEnv names the environment variable holding the passphrase; when it is
+	// unset lima asks for the passphrase on the terminal
+	PassphraseEnv string `yaml:"passphrase_env"`
+}
+
+// RemoteConfig contains settings for ledgers opened over SFTP as [user@]host:path
+type RemoteConfig struct {
+	// WriteBack puts each file lima rewrites back on the server; without it remote
+	// ledgers open read-only
+	WriteBack bool `yaml:"write_back"`
+}
+
+// WatchConfig contains settings for lima watch, which checks and categorizes the
+// ledger as it changes and imports the statements left in an inbox
+type WatchConfig struct {
+	Inbox           string `yaml:"inbox"`            // Directory statements are imported from; "" watches the ledger only
+	IntervalSeconds int    `yaml:"interval_seconds"` // How often the ledger and inbox are polled
+}
+
+// TaxConfig contains settings for lima tax, which totals the postings an accountant
+// asks for by tax year
+type TaxConfig struct {
+	// YearStart is the month and day each tax year starts, e.g. 04-06; a year starting
+	// after January 1st is named for both calendar years it spans, e.g. 2024-25
+	YearStart string `yaml:"year_start"`
+
+	Groups []TaxGroup `yaml:"groups"` // Reported in this order
+}
+
+// TaxGroup is one line of the tax summary
+type TaxGroup struct {
+	Name     string   `yaml:"name"`
+	Accounts []string `yaml:"accounts"` // Postings to these accounts and those under them count
+	Tags     []string `yaml:"tags"`     // So do the income and expense postings of transactions tagged so
+}
+
+// SharedConfig contains settings for lima shared, which works out who owes whom for
+// expenses split with other people
+type SharedConfig struct {
+	Me     string   `yaml:"me"`     // What you are called in balances
+	People []string `yaml:"people"` // Who shares a tagged transaction without shared_with metadata
+	Tag    string   `yaml:"tag"`    // Transactions with this tag are shared
+}
+
+// AnomaliesConfig contains the thresholds for flagging unusual transactions
+type AnomaliesConfig struct {
+	MeanFactor        float64 `yaml:"mean_factor"`         // Flag a charge this many times its payee's mean
+	MinHistory        int     `yaml:"min_history"`         // Earlier charges a payee needs before its mean counts
+	NewMerchantAmount float64 `yaml:"new_merchant_amount"` // Flag a payee's first charge of at least this; 0 turns it off
+	DuplicateDays     int     `yaml:"duplicate_days"`      // Flag the same charge to a payee within this many days; 0 turns it off
+}
+
+// GoalIntervals are the periods a spending limit may cover
+var GoalIntervals = []string{"monthly", "quarterly", "yearly"}
+
+// GoalConfig is a spending limit, such as dining under 300 a month, or a savings target,
+// such as 10000 saved by December
+type GoalConfig struct {
+	Name      string   `yaml:"name"`
+	Accounts  []string `yaml:"accounts"`  // Postings to these accounts and those under them count
+	Commodity string   `yaml:"commodity"` // By default amounts.currency, or else the one most used
+	Limit     float64  `yaml:"limit"`     // Spend at most this each interval
+	Interval  string   `yaml:"interval"`  // Of a limit: monthly (default), quarterly or yearly
+	Target    float64  `yaml:"target"`    // Hold at least this balance by the deadline
+	By        string   `yaml:"by"`        // Deadline of a target, YYYY-MM-DD
+	From      string   `yaml:"from"`      // Start of a target, YYYY-MM-DD; by default the first posting
+}
+
+// PriceSources are the services lima prices fetch quotes prices from
+var PriceSources = []string{"yahoo", "coingecko", "ecb"}
+
+// PricesConfig contains settings for fetching commodity prices
+type PricesConfig struct {
+	Currency string `yaml:"currency"` // Currency prices are quoted in where the source lets us choose
+
+	// Sources maps each commodity to a source, optionally with the symbol it has there,
+	// e.g. "yahoo:VTI", "coingecko:bitcoin" or "ecb"; commodities without one are skipped
+	Sources map[string]string `yaml:"sources"`
+
+	CacheMinutes      int `yaml:"cache_minutes"`       // How long a fetched quote is reused; 0 always fetches
+	RequestsPerMinute int `yaml:"requests_per_minute"` // Rate limit per source
+	TimeoutSeconds    int `yaml:"timeout_seconds"`     // Per-request timeout
 }
 
 // DefaultConfig returns the default configuration
@@ -86,46 +468,142 @@ func DefaultConfig() *Config {
 	return &Config{
 		Files: FilesConfig{
 			DefaultLedger: filepath.Join(homeDir, "finances", "main.beancount"),
-			PatternsFile:  filepath.Join(homeDir, ".config", "lima", "patterns.yaml"),
+			PatternsFile:  filepath.Join(DataDir(), "patterns.yaml"),
+			BudgetsFile:   filepath.Join(DataDir(), "budgets.yaml"),
+			ImportersFile: filepath.Join(DataDir(), "importers.yaml"),
+			PriceCache:    filepath.Join(CacheDir(), "prices.yaml"),
+			RecentFiles:   filepath.Join(CacheDir(), "recent_files"),
+			StateFile:     filepath.Join(CacheDir(), "state.yaml"),
 		},
 		UI: UIConfig{
 			DefaultView:     "dashboard",
 			PageSize:        20,
-			DateFormat:      "2006-01-02",
+			DateFormat:      "iso",
 			ShowLineNumbers: false,
 			CompactMode:     false,
+
+			ConfirmCategorize: true,
+			DiffStyle:         "unified",
 		},
 		Theme: ThemeConfig{
-			Primary:    "#00D9FF",
-			Secondary:  "#7D56F4",
-			Success:    "#00FF00",
-			Warning:    "#FFFF00",
-			Error:      "#FF0000",
-			Muted:      "#666666",
-			Text:       "#FFFFFF",
-			Background: "#1a1a1a",
+			Name:      "tp7",
+			ColorMode: "auto",
+		},
+		Dashboard: DashboardConfig{
+			Layout: [][]string{
+				{"net_worth", "spending", "uncategorized"},
+				{"upcoming_bills", "top_merchants"},
+				{"recent_transactions"},
+			},
 		},
 		Keybindings: KeybindingsConfig{
-			Quit:         []string{"q", "ctrl+c"},
-			Help:         []string{"?"},
-			Dashboard:    []string{"1"},
-			Transactions: []string{"2"},
-			Accounts:     []string{"3"},
-			Reports:      []string{"4"},
-			Up:           []string{"up", "k"},
-			Down:         []string{"down", "j"},
-			PageUp:       []string{"pgup", "ctrl+b"},
-			PageDown:     []string{"pgdown", "ctrl+f"},
-			Top:          []string{"home", "g"},
-			Bottom:       []string{"end", "G"},
-			Select:       []string{"enter", "space"},
-			Back:         []string{"esc", "backspace"},
+			Quit:           []string{"q", "ctrl+c"},
+			Help:           []string{"?"},
+			Dashboard:      []string{"1"},
+			Transactions:   []string{"2"},
+			Accounts:       []string{"3"},
+			Reports:        []string{"4"},
+			Patterns:       []string{"5"},
+			Review:         []string{"r"},
+			Budgets:        []string{"6"},
+			Tags:           []string{"7"},
+			Problems:       []string{"8"},
+			Imports:        []string{"9"},
+			NewTransaction: []string{"a"},
+			OpenFile:       []string{"ctrl+o"},
+			Export:         []string{"E"},
+			Messages:       []string{"M"},
+			Theme:          []string{"T"},
+			Currency:       []string{"C"},
+			Compact:        []string{"D"},
+			LargerPage:     []string{">"},
+			SmallerPage:    []string{"<"},
+			HelpRow:        []string{"H"},
+			Undo:           []string{"u"},
+			Redo:           []string{"ctrl+r"},
+			Up:             []string{"up", "k"},
+			Down:           []string{"down", "j"},
+			PageUp:         []string{"pgup", "ctrl+b"},
+			PageDown:       []string{"pgdown", "ctrl+f"},
+			Top:            []string{"home", "g"},
+			Bottom:         []string{"end", "G"},
+			Select:         []string{"enter", "space"},
+			Back:           []string{"esc", "backspace"},
 		},
 		Categorization: CategorizationConfig{
 			Enabled:             true,
 			AutoCategorize:      false,
 			ConfidenceThreshold: 0.8,
 			LearnFromEdits:      true,
+			StrictPatterns:      true,
+			AutoThreshold:       0.95,
+			AutoMode:            "stage",
+			AuditLog:            filepath.Join(DataDir(), "audit.log"),
+			SimilarityEnabled:   false,
+			SimilarityThreshold: 0.6,
+			LLM: LLMConfig{
+				Enabled:           false,
+				Endpoint:          "https://api.openai.com/v1",
+				Model:             "gpt-4o-mini",
+				APIKeyEnv:         "OPENAI_API_KEY",
+				TimeoutSeconds:    30,
+				RequestsPerMinute: 20,
+			},
+			Merchants: MerchantsConfig{
+				Enabled: true,
+			},
+		},
+		Sync: SyncConfig{
+			Enabled:        false,
+			Provider:       "simplefin",
+			AccessURLEnv:   "SIMPLEFIN_ACCESS_URL",
+			Endpoint:       "https://sandbox.plaid.com",
+			ClientIDEnv:    "PLAID_CLIENT_ID",
+			SecretEnv:      "PLAID_SECRET",
+			AccessTokenEnv: "PLAID_ACCESS_TOKEN",
+			Days:           30,
+			TimeoutSeconds: 60,
+		},
+		Prices: PricesConfig{
+			Currency:          "USD",
+			CacheMinutes:      60,
+			RequestsPerMinute: 30,
+			TimeoutSeconds:    30,
+		},
+		Serve: ServeConfig{
+			Address:     "127.0.0.1:8420",
+			GRPCAddress: "127.0.0.1:8421",
+			TokenEnv:    "LIMA_API_TOKEN",
+		},
+		Backup: BackupConfig{
+			Enabled: true,
+			Dir:     filepath.Join(DataDir(), "backups"),
+			Keep:    20,
+		},
+		Encryption: EncryptionConfig{
+			PassphraseEnv: "LIMA_PASSPHRASE",
+		},
+		Watch: WatchConfig{
+			IntervalSeconds: 5,
+		},
+		Tax: TaxConfig{
+			YearStart: "01-01",
+			Groups: []TaxGroup{
+				{Name: "Deductible", Tags: []string{"tax-deductible"}},
+				{Name: "Medical", Accounts: []string{"Expenses:Medical"}},
+				{Name: "Charitable", Accounts: []string{"Expenses:Charity"}},
+			},
+		},
+		Shared: SharedConfig{
+			Me:     "Me",
+			People: []string{"Partner"},
+			Tag:    "shared",
+		},
+		Anomalies: AnomaliesConfig{
+			MeanFactor:        3,
+			MinHistory:        3,
+			NewMerchantAmount: 250,
+			DuplicateDays:     3,
 		},
 	}
 }
@@ -139,7 +617,8 @@ func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			// File doesn't exist, return defaults
+			// File doesn't exist, return defaults; merged, it overrides nothing
+			config.set = map[string]bool{}
 			return config, nil
 		}
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -149,6 +628,10 @@ func Load(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err == nil {
+		config.set = setFields(&doc)
+	}
 
 	// Validate
 	if err := config.Validate(); err != nil {
@@ -166,8 +649,7 @@ func LoadDefault() (*Config, error) {
 
 // DefaultConfigPath returns the default configuration file path
 func DefaultConfigPath() string {
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".config", "lima", "config.yaml")
+	return filepath.Join(ConfigDir(), "config.yaml")
 }
 
 // Save saves the configuration to a file
@@ -199,19 +681,53 @@ func (c *Config) Save(path string) error {
 
 // Validate validates the configuration
 func (c *Config) Validate() error {
+	for _, name := range c.ProfileNames() {
+		if _, err := c.Profile(name); err != nil {
+			return err
+		}
+	}
+
 	// Validate UI settings
-	validViews := map[string]bool{
-		"dashboard":    true,
-		"transactions": true,
-		"accounts":     true,
-		"reports":      true,
+	validView := false
+	for _, name := range ViewNames {
+		validView = validView || name == c.UI.DefaultView
 	}
-	if !validViews[c.UI.DefaultView] {
+	if !validView {
 		return fmt.Errorf("invalid default view: %s", c.UI.DefaultView)
 	}
 
-	if c.UI.PageSize < 1 || c.UI.PageSize > 1000 {
-		return fmt.Errorf("page size must be between 1 and 1000")
+	// A date format must keep the year, month and day, so a date reads back the same
+	reference := time.Date(2024, time.September, 25, 0, 0, 0, 0, time.UTC)
+	layout := DateLayout(c.UI.DateFormat)
+	if parsed, err := time.Parse(layout, reference.Format(layout)); err != nil || !parsed.Equal(reference) {
+		return fmt.Errorf("invalid date format %q: use iso, us, eu or a Go layout with year, month and day", c.UI.DateFormat)
+	}
+
+	if c.UI.PageSize < 1 || c.UI.PageSize > MaxPageSize {
+		return fmt.Errorf("page size must be between 1 and %d", MaxPageSize)
+	}
+
+	validDiffStyle := false
+	for _, style := range DiffStyles {
+		validDiffStyle = validDiffStyle || style == c.UI.DiffStyle
+	}
+	if !validDiffStyle {
+		return fmt.Errorf("invalid diff style: %s", c.UI.DiffStyle)
+	}
+
+	validTheme := false
+	for _, name := range ThemeNames {
+		validTheme = validTheme || name == c.Theme.Name
+	}
+	if !validTheme {
+		return fmt.Errorf("invalid theme: %s", c.Theme.Name)
+	}
+	validColorMode := false
+	for _, mode := range ColorModes {
+		validColorMode = validColorMode || mode == c.Theme.ColorMode
+	}
+	if !validColorMode {
+		return fmt.Errorf("invalid color mode: %s", c.Theme.ColorMode)
 	}
 
 	// Validate theme colors (basic check - should be hex colors)
@@ -231,10 +747,168 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate amount formatting
+	validLocale := false
+	for _, locale := range AmountLocales {
+		validLocale = validLocale || locale == c.Amounts.Locale
+	}
+	if !validLocale {
+		return fmt.Errorf("invalid amount locale: %s", c.Amounts.Locale)
+	}
+	if c.Amounts.SymbolPosition != "" && c.Amounts.SymbolPosition != "before" && c.Amounts.SymbolPosition != "after" {
+		return fmt.Errorf("invalid symbol position: %s (must be before or after)", c.Amounts.SymbolPosition)
+	}
+	if c.Amounts.DecimalMark != "" && c.Amounts.DecimalMark == c.Amounts.ThousandsSeparator {
+		return fmt.Errorf("decimal mark and thousands separator must differ")
+	}
+	for commodity, places := range c.Amounts.Precision {
+		if places < 0 || places > 12 {
+			return fmt.Errorf("precision for %s must be between 0 and 12", commodity)
+		}
+	}
+
+	// Validate dashboard widgets
+	validWidgets := make(map[string]bool)
+	for _, widget := range DashboardWidgets {
+		validWidgets[widget] = true
+	}
+	for _, row := range c.Dashboard.Layout {
+		for _, widget := range row {
+			if !validWidgets[widget] {
+				return fmt.Errorf("invalid dashboard widget: %s", widget)
+			}
+		}
+	}
+
 	// Validate categorization settings
 	if c.Categorization.ConfidenceThreshold < 0 || c.Categorization.ConfidenceThreshold > 1 {
 		return fmt.Errorf("confidence threshold must be between 0 and 1")
 	}
+	if c.Categorization.AutoThreshold < 0 || c.Categorization.AutoThreshold > 1 {
+		return fmt.Errorf("auto threshold must be between 0 and 1")
+	}
+	if c.Categorization.AutoMode != "stage" && c.Categorization.AutoMode != "write" {
+		return fmt.Errorf("invalid auto mode: %s (must be stage or write)", c.Categorization.AutoMode)
+	}
+	if c.Categorization.SimilarityThreshold < 0 || c.Categorization.SimilarityThreshold > 1 {
+		return fmt.Errorf("similarity threshold must be between 0 and 1")
+	}
+	if c.Categorization.LLM.Enabled {
+		if c.Categorization.LLM.Endpoint == "" {
+			return fmt.Errorf("llm endpoint is required when llm categorization is enabled")
+		}
+		if c.Categorization.LLM.RequestsPerMinute < 1 {
+			return fmt.Errorf("llm requests per minute must be at least 1")
+		}
+	}
+	if c.Sync.Enabled {
+		if !slices.Contains(SyncProviders, c.Sync.Provider) {
+			return fmt.Errorf("invalid sync provider: %s (must be one of %s)", c.Sync.Provider, strings.Join(SyncProviders, ", "))
+		}
+		if c.Sync.Provider == "plaid" && c.Sync.Endpoint == "" {
+			return fmt.Errorf("sync endpoint is required for plaid")
+		}
+		if c.Sync.Days < 1 {
+			return fmt.Errorf("sync days must be at least 1")
+		}
+		if c.Sync.TimeoutSeconds < 1 {
+			return fmt.Errorf("sync timeout must be at least 1 second")
+		}
+	}
+
+	for commodity, source := range c.Prices.Sources {
+		name, _, _ := strings.Cut(source, ":")
+		if !slices.Contains(PriceSources, name) {
+			return fmt.Errorf("invalid price source for %s: %s (must be one of %s)", commodity, source, strings.Join(PriceSources, ", "))
+		}
+	}
+	if c.Prices.CacheMinutes < 0 {
+		return fmt.Errorf("price cache minutes must not be negative")
+	}
+	if c.Prices.RequestsPerMinute < 1 {
+		return fmt.Errorf("price requests per minute must be at least 1")
+	}
+	if c.Prices.TimeoutSeconds < 1 {
+		return fmt.Errorf("price timeout must be at least 1 second")
+	}
+	if c.Serve.Address == "" {
+		return fmt.Errorf("serve address is required")
+	}
+	if c.Serve.GRPCAddress == "" {
+		return fmt.Errorf("serve grpc_address is required")
+	}
+	if c.Serve.TokenEnv == "" {
+		return fmt.Errorf("serve token_env is required")
+	}
+	if (c.Serve.CertFile == "") != (c.Serve.KeyFile == "") {
+		return fmt.Errorf("serve cert_file and key_file must be set together")
+	}
+	if c.Backup.Enabled {
+		if c.Backup.Dir == "" {
+			return fmt.Errorf("backup dir is required when backups are enabled")
+		}
+		if c.Backup.Keep < 1 {
+			return fmt.Errorf("backup keep must be at least 1")
+		}
+	}
+	if slices.Contains(c.Encryption.AgeRecipients, "") || slices.Contains(c.Encryption.GPGRecipients, "") {
+		return fmt.Errorf("encryption recipients must not be empty")
+	}
+	if c.Watch.IntervalSeconds < 1 {
+		return fmt.Errorf("watch interval must be at least 1 second")
+	}
+	if _, err := time.Parse("01-02", c.Tax.YearStart); err != nil {
+		return fmt.Errorf("invalid tax year start: %s (must be MM-DD)", c.Tax.YearStart)
+	}
+	taxGroups := make(map[string]bool)
+	for _, group := range c.Tax.Groups {
+		if group.Name == "" || taxGroups[group.Name] {
+			return fmt.Errorf("tax groups need distinct names")
+		}
+		taxGroups[group.Name] = true
+		if len(group.Accounts) == 0 && len(group.Tags) == 0 {
+			return fmt.Errorf("tax group %s needs accounts or tags", group.Name)
+		}
+	}
+	if c.Shared.Me == "" || c.Shared.Tag == "" {
+		return fmt.Errorf("shared me and tag must not be empty")
+	}
+	if slices.Contains(c.Shared.People, "") || slices.Contains(c.Shared.People, c.Shared.Me) {
+		return fmt.Errorf("shared people must be named, and other than %s", c.Shared.Me)
+	}
+	if c.Anomalies.MeanFactor <= 1 {
+		return fmt.Errorf("anomalies mean factor must be greater than 1")
+	}
+	if c.Anomalies.MinHistory < 1 {
+		return fmt.Errorf("anomalies min history must be at least 1")
+	}
+	if c.Anomalies.NewMerchantAmount < 0 || c.Anomalies.DuplicateDays < 0 {
+		return fmt.Errorf("anomalies new merchant amount and duplicate days must not be negative")
+	}
+	goals := make(map[string]bool)
+	for _, goal := range c.Goals {
+		if goal.Name == "" || goals[goal.Name] {
+			return fmt.Errorf("goals need distinct names")
+		}
+		goals[goal.Name] = true
+		if len(goal.Accounts) == 0 {
+			return fmt.Errorf("goal %s needs accounts", goal.Name)
+		}
+		if (goal.Limit > 0) == (goal.Target > 0) {
+			return fmt.Errorf("goal %s needs either a limit or a target", goal.Name)
+		}
+		if goal.Limit > 0 && goal.Interval != "" && !slices.Contains(GoalIntervals, goal.Interval) {
+			return fmt.Errorf("invalid interval for goal %s: %s (must be one of %s)", goal.Name, goal.Interval, strings.Join(GoalIntervals, ", "))
+		}
+		if goal.Target > 0 {
+			if _, err := time.Parse("2006-01-02", goal.By); err != nil {
+				return fmt.Errorf("goal %s needs a deadline: by must be YYYY-MM-DD", goal.Name)
+			}
+			if _, err := time.Parse("2006-01-02", goal.From); goal.From != "" && err != nil {
+				return fmt.Errorf("invalid start for goal %s: %s (must be YYYY-MM-DD)", goal.Name, goal.From)
+			}
+		}
+	}
 
 	// Validate keybindings (at least one key per action)
 	keybindingFields := []struct {
@@ -242,9 +916,37 @@ func (c *Config) Validate() error {
 		keys []string
 	}{
 		{"quit", c.Keybindings.Quit},
+		{"help", c.Keybindings.Help},
 		{"dashboard", c.Keybindings.Dashboard},
 		{"transactions", c.Keybindings.Transactions},
 		{"accounts", c.Keybindings.Accounts},
+		{"reports", c.Keybindings.Reports},
+		{"patterns", c.Keybindings.Patterns},
+		{"review", c.Keybindings.Review},
+		{"budgets", c.Keybindings.Budgets},
+		{"tags", c.Keybindings.Tags},
+		{"problems", c.Keybindings.Problems},
+		{"imports", c.Keybindings.Imports},
+		{"new_transaction", c.Keybindings.NewTransaction},
+		{"open_file", c.Keybindings.OpenFile},
+		{"export", c.Keybindings.Export},
+		{"messages", c.Keybindings.Messages},
+		{"theme", c.Keybindings.Theme},
+		{"currency", c.Keybindings.Currency},
+		{"compact", c.Keybindings.Compact},
+		{"larger_page", c.Keybindings.LargerPage},
+		{"smaller_page", c.Keybindings.SmallerPage},
+		{"help_row", c.Keybindings.HelpRow},
+		{"undo", c.Keybindings.Undo},
+		{"redo", c.Keybindings.Redo},
+		{"up", c.Keybindings.Up},
+		{"down", c.Keybindings.Down},
+		{"page_up", c.Keybindings.PageUp},
+		{"page_down", c.Keybindings.PageDown},
+		{"top", c.Keybindings.Top},
+		{"bottom", c.Keybindings.Bottom},
+		{"select", c.Keybindings.Select},
+		{"back", c.Keybindings.Back},
 	}
 
 	for _, field := range keybindingFields {
@@ -253,49 +955,28 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	return nil
-}
-
-// Merge merges another config into this one (other takes precedence)
-func (c *Config) Merge(other *Config) {
-	// Merge files
-	if other.Files.DefaultLedger != "" {
-		c.Files.DefaultLedger = other.Files.DefaultLedger
-	}
-	if other.Files.PatternsFile != "" {
-		c.Files.PatternsFile = other.Files.PatternsFile
-	}
-
-	// Merge UI
-	if other.UI.DefaultView != "" {
-		c.UI.DefaultView = other.UI.DefaultView
-	}
-	if other.UI.PageSize > 0 {
-		c.UI.PageSize = other.UI.PageSize
-	}
-	if other.UI.DateFormat != "" {
-		c.UI.DateFormat = other.UI.DateFormat
-	}
-
-	// Theme colors
-	if other.Theme.Primary != "" {
-		c.Theme.Primary = other.Theme.Primary
+	// A key does one thing: it may not be bound to two actions or shadow a fixed key
+	var conflicts []string
+	owners := make(map[string]string)
+	for _, field := range keybindingFields {
+		for _, k := range field.keys {
+			if k == " " {
+				k = "space"
+			}
+			if use := reservedKey(field.name, k); use != "" {
+				conflicts = append(conflicts, fmt.Sprintf("%q in '%s' is reserved for %s", k, field.name, use))
+				continue
+			}
+			if owner, ok := owners[k]; ok && owner != field.name {
+				conflicts = append(conflicts, fmt.Sprintf("%q is bound to both '%s' and '%s'", k, owner, field.name))
+				continue
+			}
+			owners[k] = field.name
+		}
 	}
-	if other.Theme.Secondary != "" {
-		c.Theme.Secondary = other.Theme.Secondary
+	if len(conflicts) > 0 {
+		return fmt.Errorf("conflicting keybindings: %s", strings.Join(conflicts, "; "))
 	}
 
-	// Keybindings - merge arrays
-	if len(other.Keybindings.Quit) > 0 {
-		c.Keybindings.Quit = other.Keybindings.Quit
-	}
-	if len(other.Keybindings.Dashboard) > 0 {
-		c.Keybindings.Dashboard = other.Keybindings.Dashboard
-	}
-	if len(other.Keybindings.Transactions) > 0 {
-		c.Keybindings.Transactions = other.Keybindings.Transactions
-	}
-	if len(other.Keybindings.Accounts) > 0 {
-		c.Keybindings.Accounts = other.Keybindings.Accounts
-	}
+	return nil
 }
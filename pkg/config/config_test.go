@@ -3,7 +3,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -18,8 +22,8 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("expected page size 20, got %d", cfg.UI.PageSize)
 	}
 
-	if cfg.Theme.Primary != "#00D9FF" {
-		t.Errorf("expected primary color '#00D9FF', got '%s'", cfg.Theme.Primary)
+	if cfg.Theme.Name != "tp7" || cfg.Theme.Primary != "" {
+		t.Errorf("expected the tp7 theme without color overrides, got %+v", cfg.Theme)
 	}
 
 	if len(cfg.Keybindings.Quit) == 0 {
@@ -42,6 +46,72 @@ func TestConfigValidation(t *testing.T) {
 			mutate:    func(c *Config) {},
 			shouldErr: false,
 		},
+		{
+			name: "amount locale",
+			mutate: func(c *Config) {
+				c.Amounts.Locale = "de"
+				c.Amounts.Symbols = map[string]string{"EUR": "€"}
+				c.Amounts.Precision = map[string]int{"JPY": 0}
+			},
+			shouldErr: false,
+		},
+		{
+			name: "date format preset",
+			mutate: func(c *Config) {
+				c.UI.DateFormat = "eu"
+			},
+			shouldErr: false,
+		},
+		{
+			name: "custom date format",
+			mutate: func(c *Config) {
+				c.UI.DateFormat = "Jan 2, 2006"
+			},
+			shouldErr: false,
+		},
+		{
+			name: "date format without a day",
+			mutate: func(c *Config) {
+				c.UI.DateFormat = "2006-01"
+			},
+			shouldErr: true,
+		},
+		{
+			name: "date format that is not a layout",
+			mutate: func(c *Config) {
+				c.UI.DateFormat = "dd/mm/yyyy"
+			},
+			shouldErr: true,
+		},
+		{
+			name: "unknown amount locale",
+			mutate: func(c *Config) {
+				c.Amounts.Locale = "xx"
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid symbol position",
+			mutate: func(c *Config) {
+				c.Amounts.SymbolPosition = "middle"
+			},
+			shouldErr: true,
+		},
+		{
+			name: "same decimal mark and separator",
+			mutate: func(c *Config) {
+				c.Amounts.DecimalMark = ","
+				c.Amounts.ThousandsSeparator = ","
+			},
+			shouldErr: true,
+		},
+		{
+			name: "negative precision",
+			mutate: func(c *Config) {
+				c.Amounts.Precision = map[string]int{"USD": -1}
+			},
+			shouldErr: true,
+		},
 		{
 			name: "invalid default view",
 			mutate: func(c *Config) {
@@ -63,6 +133,13 @@ func TestConfigValidation(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			name: "unknown dashboard widget",
+			mutate: func(c *Config) {
+				c.Dashboard.Layout = [][]string{{"net_worth", "weather"}}
+			},
+			shouldErr: true,
+		},
 		{
 			name: "invalid color format",
 			mutate: func(c *Config) {
@@ -70,6 +147,41 @@ func TestConfigValidation(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			name: "unknown theme",
+			mutate: func(c *Config) {
+				c.Theme.Name = "dracula"
+			},
+			shouldErr: true,
+		},
+		{
+			name: "unknown color mode",
+			mutate: func(c *Config) {
+				c.Theme.ColorMode = "8"
+			},
+			shouldErr: true,
+		},
+		{
+			name: "unknown diff style",
+			mutate: func(c *Config) {
+				c.UI.DiffStyle = "split"
+			},
+			shouldErr: true,
+		},
+		{
+			name: "similarity threshold too high",
+			mutate: func(c *Config) {
+				c.Categorization.SimilarityThreshold = 1.5
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid auto mode",
+			mutate: func(c *Config) {
+				c.Categorization.AutoMode = "always"
+			},
+			shouldErr: true,
+		},
 		{
 			name: "confidence threshold too low",
 			mutate: func(c *Config) {
@@ -84,6 +196,128 @@ func TestConfigValidation(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			name: "price source with a symbol",
+			mutate: func(c *Config) {
+				c.Prices.Sources = map[string]string{"VOD": "yahoo:VOD.L", "EUR": "ecb"}
+			},
+			shouldErr: false,
+		},
+		{
+			name: "unknown price source",
+			mutate: func(c *Config) {
+				c.Prices.Sources = map[string]string{"VTI": "nasdaq:VTI"}
+			},
+			shouldErr: true,
+		},
+		{
+			name: "negative price cache minutes",
+			mutate: func(c *Config) {
+				c.Prices.CacheMinutes = -1
+			},
+			shouldErr: true,
+		},
+		{
+			name: "missing serve address",
+			mutate: func(c *Config) {
+				c.Serve.Address = ""
+			},
+			shouldErr: true,
+		},
+		{
+			name: "serve certificate without a key",
+			mutate: func(c *Config) {
+				c.Serve.CertFile = "/etc/lima/cert.pem"
+			},
+			shouldErr: true,
+		},
+		{
+			name: "backups kept too few",
+			mutate: func(c *Config) {
+				c.Backup.Keep = 0
+			},
+			shouldErr: true,
+		},
+		{
+			name: "backups disabled without a directory",
+			mutate: func(c *Config) {
+				c.Backup.Enabled = false
+				c.Backup.Dir = ""
+			},
+			shouldErr: false,
+		},
+		{
+			name: "empty encryption recipient",
+			mutate: func(c *Config) {
+				c.Encryption.GPGRecipients = []string{"me@example.com", ""}
+			},
+			shouldErr: true,
+		},
+		{
+			name: "watch interval too short",
+			mutate: func(c *Config) {
+				c.Watch.IntervalSeconds = 0
+			},
+			shouldErr: true,
+		},
+		{
+			name: "invalid tax year start",
+			mutate: func(c *Config) {
+				c.Tax.YearStart = "April 6"
+			},
+			shouldErr: true,
+		},
+		{
+			name: "tax group without accounts or tags",
+			mutate: func(c *Config) {
+				c.Tax.Groups = append(c.Tax.Groups, TaxGroup{Name: "Business"})
+			},
+			shouldErr: true,
+		},
+		{
+			name: "duplicate tax group",
+			mutate: func(c *Config) {
+				c.Tax.Groups = append(c.Tax.Groups, TaxGroup{Name: "Medical", Tags: []string{"medical"}})
+			},
+			shouldErr: true,
+		},
+		{
+			name: "anomalies mean factor too small",
+			mutate: func(c *Config) {
+				c.Anomalies.MeanFactor = 1
+			},
+			shouldErr: true,
+		},
+		{
+			name: "goal with a limit and a target",
+			mutate: func(c *Config) {
+				c.Goals = []GoalConfig{{Name: "Dining", Accounts: []string{"Expenses:Dining"}, Limit: 300, Target: 300, By: "2024-12-31"}}
+			},
+			shouldErr: true,
+		},
+		{
+			name: "savings goal without a deadline",
+			mutate: func(c *Config) {
+				c.Goals = []GoalConfig{{Name: "Emergency fund", Accounts: []string{"Assets:Savings"}, Target: 10000}}
+			},
+			shouldErr: true,
+		},
+		{
+			name: "valid goals",
+			mutate: func(c *Config) {
+				c.Goals = []GoalConfig{
+					{Name: "Dining", Accounts: []string{"Expenses:Dining"}, Limit: 300},
+					{Name: "Emergency fund", Accounts: []string{"Assets:Savings"}, Target: 10000, By: "2024-12-31"},
+				}
+			},
+		},
+		{
+			name: "sharing with yourself",
+			mutate: func(c *Config) {
+				c.Shared.People = append(c.Shared.People, c.Shared.Me)
+			},
+			shouldErr: true,
+		},
 		{
 			name: "missing quit keybinding",
 			mutate: func(c *Config) {
@@ -91,6 +325,48 @@ func TestConfigValidation(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			name: "missing navigation keybinding",
+			mutate: func(c *Config) {
+				c.Keybindings.Down = nil
+			},
+			shouldErr: true,
+		},
+		{
+			name: "key bound twice to one action",
+			mutate: func(c *Config) {
+				c.Keybindings.Budgets = []string{"6", "b", "6"}
+			},
+			shouldErr: false,
+		},
+		{
+			name: "key bound to two actions",
+			mutate: func(c *Config) {
+				c.Keybindings.Tags = []string{"7", "j"}
+			},
+			shouldErr: true,
+		},
+		{
+			name: "space bound to two actions",
+			mutate: func(c *Config) {
+				c.Keybindings.HelpRow = []string{" "}
+			},
+			shouldErr: true,
+		},
+		{
+			name: "reserved function key",
+			mutate: func(c *Config) {
+				c.Keybindings.Export = []string{"f2"}
+			},
+			shouldErr: true,
+		},
+		{
+			name: "reserved menu key",
+			mutate: func(c *Config) {
+				c.Keybindings.Theme = []string{"alt+t"}
+			},
+			shouldErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -109,6 +385,25 @@ func TestConfigValidation(t *testing.T) {
 	}
 }
 
+func TestKeybindingConflicts(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Keybindings.Export = []string{"k"}
+	cfg.Keybindings.Messages = []string{"f9", "m"}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected conflicting keybindings to be refused")
+	}
+	for _, want := range []string{
+		`"k" is bound to both 'export' and 'up'`,
+		`"f9" in 'messages' is reserved for tags (F9)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+}
+
 func TestConfigLoadSave(t *testing.T) {
 	// Create temporary directory
 	tmpDir := t.TempDir()
@@ -180,6 +475,7 @@ func TestConfigMerge(t *testing.T) {
 	base.UI.DefaultView = "dashboard"
 	base.UI.PageSize = 20
 	base.Theme.Primary = "#00D9FF"
+	base.Theme.Secondary = "#7D56F4"
 
 	override := &Config{
 		UI: UIConfig{
@@ -212,6 +508,136 @@ func TestConfigMerge(t *testing.T) {
 	}
 }
 
+// fillFields sets every field of a struct to a non-zero value, returning their dotted paths
+func fillFields(v reflect.Value, prefix string) []string {
+	var paths []string
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		path := prefix + yamlName(field)
+		value := v.Field(i)
+		switch value.Kind() {
+		case reflect.Struct:
+			paths = append(paths, fillFields(value, path+".")...)
+			continue
+		case reflect.String:
+			value.SetString("x-" + path)
+		case reflect.Int:
+			value.SetInt(7)
+		case reflect.Float64:
+			value.SetFloat(0.5)
+		case reflect.Bool:
+			value.SetBool(true)
+		case reflect.Slice:
+			switch field.Type {
+			case reflect.TypeOf([][]string{}):
+				value.Set(reflect.ValueOf([][]string{{"stats"}}))
+			case reflect.TypeOf([]TaxGroup{}):
+				value.Set(reflect.ValueOf([]TaxGroup{{Name: "x-" + path, Tags: []string{"x"}}}))
+			case reflect.TypeOf([]GoalConfig{}):
+				value.Set(reflect.ValueOf([]GoalConfig{{Name: "x-" + path, Accounts: []string{"x"}, Limit: 7}}))
+			default:
+				value.Set(reflect.ValueOf([]string{"x-" + path}))
+			}
+		case reflect.Map:
+			m := reflect.MakeMap(field.Type)
+			elem := reflect.New(field.Type.Elem()).Elem()
+			switch elem.Kind() {
+			case reflect.String:
+				elem.SetString("x-" + path)
+			case reflect.Int:
+				elem.SetInt(3)
+			default:
+				var node yaml.Node
+				if err := yaml.Unmarshal([]byte("ui: {page_size: 7}"), &node); err != nil {
+					panic(err)
+				}
+				elem.Set(reflect.ValueOf(*node.Content[0]))
+			}
+			m.SetMapIndex(reflect.ValueOf("XYZ"), elem)
+			value.Set(m)
+		default:
+			panic("fillFields: unsupported field " + path)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestConfigMergeEveryField(t *testing.T) {
+	override := &Config{}
+	paths := fillFields(reflect.ValueOf(override).Elem(), "")
+
+	base := DefaultConfig()
+	base.Amounts.Symbols = map[string]string{"USD": "$"}
+	base.Merge(override)
+
+	// Every field takes the override's value; maps keep the base's other keys
+	if base.Amounts.Symbols["USD"] != "$" {
+		t.Error("expected symbol maps to merge per commodity")
+	}
+	delete(base.Amounts.Symbols, "USD")
+	got, _ := yaml.Marshal(base)
+	want, _ := yaml.Marshal(override)
+	if string(got) != string(want) {
+		t.Errorf("merging did not carry every one of %d fields:\ngot:\n%s\nwant:\n%s", len(paths), got, want)
+	}
+}
+
+func TestConfigMergeExplicitZero(t *testing.T) {
+	// A file giving every field its zero value, booleans false included, overrides them all
+	data, err := yaml.Marshal(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	zero := &Config{set: setFields(&doc)}
+
+	base := DefaultConfig()
+	base.UI.CompactMode = true
+	base.Merge(zero)
+
+	got, _ := yaml.Marshal(base)
+	if string(got) != string(data) {
+		t.Errorf("explicit zero values were not merged:\n%s", got)
+	}
+
+	// Fields a file leaves out keep the base's value
+	path := filepath.Join(t.TempDir(), "override.yaml")
+	if err := os.WriteFile(path, []byte("ui:\n  compact_mode: false\ncategorization:\n  llm:\n    enabled: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	partial, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	base = DefaultConfig()
+	base.UI.CompactMode = true
+	base.UI.PageSize = 50
+	base.Categorization.Enabled = true
+	base.Merge(partial)
+	if base.UI.CompactMode || !base.Categorization.LLM.Enabled {
+		t.Errorf("expected the file's booleans to win, got compact %v, llm %v", base.UI.CompactMode, base.Categorization.LLM.Enabled)
+	}
+	if base.UI.PageSize != 50 || !base.Categorization.Enabled {
+		t.Error("fields left out of the file should keep the base's value")
+	}
+
+	missing, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	base.Merge(missing)
+	if base.UI.PageSize != 50 {
+		t.Error("a missing file should override nothing")
+	}
+}
+
 func TestConfigPartialLoad(t *testing.T) {
 	// Create temporary file with partial config
 	tmpDir := t.TempDir()
@@ -222,6 +648,9 @@ func TestConfigPartialLoad(t *testing.T) {
   page_size: 30
 theme:
   primary: "#FF0000"
+dashboard:
+  layout:
+    - [recent_transactions]
 `
 	if err := os.WriteFile(configPath, []byte(partialYAML), 0644); err != nil {
 		t.Fatalf("failed to write test file: %v", err)
@@ -246,8 +675,12 @@ theme:
 		t.Errorf("expected primary color '#FF0000', got '%s'", cfg.Theme.Primary)
 	}
 
+	if len(cfg.Dashboard.Layout) != 1 || len(cfg.Dashboard.Layout[0]) != 1 || cfg.Dashboard.Layout[0][0] != "recent_transactions" {
+		t.Errorf("expected dashboard layout to replace the default, got %v", cfg.Dashboard.Layout)
+	}
+
 	// Check defaults are preserved for non-specified values
-	if cfg.Theme.Secondary != "#7D56F4" {
+	if cfg.Theme.Name != "tp7" || cfg.Theme.Secondary != "" {
 		t.Error("non-specified value should use default")
 	}
 
@@ -268,3 +701,251 @@ func TestDefaultConfigPath(t *testing.T) {
 		t.Errorf("expected path to end with 'config.yaml', got '%s'", path)
 	}
 }
+
+func TestXDGDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "cfg"))
+	t.Setenv("XDG_DATA_HOME", "relative") // Ignored, as the specification requires
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+
+	if got, want := DefaultConfigPath(), filepath.Join(home, "cfg", "lima", "config.yaml"); got != want {
+		t.Errorf("config path = %s, want %s", got, want)
+	}
+	if got, want := CacheDir(), filepath.Join(home, "cache", "lima"); got != want {
+		t.Errorf("cache dir = %s, want %s", got, want)
+	}
+	cfg := DefaultConfig()
+	if got, want := cfg.Files.StateFile, filepath.Join(home, "cache", "lima", "state.yaml"); got != want {
+		t.Errorf("state file = %s, want %s", got, want)
+	}
+	if filepath.Dir(cfg.Files.PatternsFile) != DataDir() || !filepath.IsAbs(DataDir()) {
+		t.Errorf("expected patterns in the absolute data dir %s, got %s", DataDir(), cfg.Files.PatternsFile)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+
+	legacy := filepath.Join(home, ".config", "lima")
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The budgets path is configured explicitly, so that file stays put
+	budgets := filepath.Join(legacy, "budgets.yaml")
+	files := map[string]string{
+		"config.yaml":   "files:\n  budgets_file: " + budgets + "\n",
+		"patterns.yaml": "patterns: []\n",
+		"budgets.yaml":  "budgets: []\n",
+		"state.yaml":    "{}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(legacy, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An existing file at the destination is never overwritten
+	if err := os.MkdirAll(filepath.Join(home, "cache", "lima"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "cache", "lima", "state.yaml"), []byte("newer\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	moves, err := Migrate()
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(moves) != 2 {
+		t.Errorf("expected the config and patterns to move, got %+v", moves)
+	}
+	for _, path := range []string{
+		filepath.Join(home, "cfg", "lima", "config.yaml"),
+		filepath.Join(home, "data", "lima", "patterns.yaml"),
+		budgets,
+		filepath.Join(legacy, "state.yaml"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to exist: %v", path, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(home, "cache", "lima", "state.yaml")); string(data) != "newer\n" {
+		t.Errorf("existing state file was overwritten: %q", data)
+	}
+
+	cfg, err := LoadDefault()
+	if err != nil || cfg.Files.BudgetsFile != budgets {
+		t.Errorf("expected the migrated config to load, got %v, %+v", err, cfg)
+	}
+
+	// Nothing left to move
+	if moves, err := Migrate(); err != nil || len(moves) != 0 {
+		t.Errorf("second migrate = %+v, %v", moves, err)
+	}
+}
+
+func TestForLedger(t *testing.T) {
+	root := t.TempDir()
+	ledger := filepath.Join(root, "business", "2024", "main.beancount")
+	if err := os.MkdirAll(filepath.Dir(ledger), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	global := DefaultConfig()
+	global.Amounts.Symbols = map[string]string{"USD": "$"}
+	if cfg, project, err := global.ForLedger(ledger); err != nil || project != "" || cfg != global {
+		t.Fatalf("without a project file expected the global config, got %q, %v", project, err)
+	}
+
+	// Found by walking up from the ledger's directory
+	projectFile := filepath.Join(root, "business", ProjectFile)
+	content := "files:\n  patterns_file: patterns.yaml\n  budgets_file: ~/budgets.yaml\nencryption:\n  age_identity: keys/age.txt\nwatch:\n  inbox: inbox\nui:\n  default_view: transactions\n  compact_mode: false\namounts:\n  currency: EUR\n  symbols:\n    EUR: €\n"
+	if err := os.WriteFile(projectFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	global.UI.CompactMode = true
+	cfg, project, err := global.ForLedger(ledger)
+	if err != nil || project != projectFile {
+		t.Fatalf("ForLedger = %q, %v; want %q", project, err, projectFile)
+	}
+	if cfg.Files.PatternsFile != filepath.Join(root, "business", "patterns.yaml") {
+		t.Errorf("expected the patterns file next to the project file, got %s", cfg.Files.PatternsFile)
+	}
+	if cfg.Encryption.AgeIdentity != filepath.Join(root, "business", "keys", "age.txt") {
+		t.Errorf("expected the age identity next to the project file, got %s", cfg.Encryption.AgeIdentity)
+	}
+	if cfg.Watch.Inbox != filepath.Join(root, "business", "inbox") {
+		t.Errorf("expected the watch inbox next to the project file, got %s", cfg.Watch.Inbox)
+	}
+	if cfg.Files.BudgetsFile != filepath.Join(home, "budgets.yaml") {
+		t.Errorf("expected the budgets file in the home directory, got %s", cfg.Files.BudgetsFile)
+	}
+	if cfg.UI.DefaultView != "transactions" || cfg.Amounts.Currency != "EUR" || cfg.UI.CompactMode {
+		t.Errorf("project settings not applied: %+v %+v", cfg.UI, cfg.Amounts)
+	}
+	if cfg.Amounts.Symbols["USD"] != "$" || cfg.Amounts.Symbols["EUR"] != "€" || cfg.Files.ImportersFile != global.Files.ImportersFile {
+		t.Errorf("global settings not kept: %+v", cfg.Amounts.Symbols)
+	}
+	if _, ok := global.Amounts.Symbols["EUR"]; ok || global.UI.DefaultView != "dashboard" {
+		t.Error("the global config should be left unchanged")
+	}
+
+	// An invalid project file is reported and the global config used
+	if err := os.WriteFile(projectFile, []byte("ui:\n  default_view: nowhere\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _, err := global.ForLedger(ledger); err == nil || cfg != global {
+		t.Errorf("expected an error and the global config for an invalid project file, got %v", err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `files:
+  default_ledger: /books/personal.beancount
+ui:
+  compact_mode: true
+profiles:
+  business:
+    files:
+      default_ledger: /books/business.beancount
+      patterns_file: /books/business-patterns.yaml
+    theme:
+      name: light
+    ui:
+      compact_mode: false
+  personal: {}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if names := cfg.ProfileNames(); len(names) != 2 || names[0] != "business" || names[1] != "personal" {
+		t.Errorf("unexpected profile names: %v", names)
+	}
+
+	business, err := cfg.Profile("business")
+	if err != nil {
+		t.Fatalf("profile: %v", err)
+	}
+	if business.Files.DefaultLedger != "/books/business.beancount" || business.Files.PatternsFile != "/books/business-patterns.yaml" ||
+		business.Theme.Name != "light" || business.UI.CompactMode {
+		t.Errorf("business profile not applied: %+v %+v", business.Files, business.Theme)
+	}
+	if business.Files.BudgetsFile != cfg.Files.BudgetsFile || business.Theme.ColorMode != "auto" {
+		t.Error("settings the profile leaves out should keep the file's value")
+	}
+	if cfg.Files.DefaultLedger != "/books/personal.beancount" || cfg.Theme.Name != "tp7" {
+		t.Error("choosing a profile should leave the config unchanged")
+	}
+
+	if same, err := cfg.Profile(""); err != nil || same != cfg {
+		t.Errorf("no profile should return the config itself, got %v", err)
+	}
+	if _, err := cfg.Profile("work"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+
+	// A profile with invalid settings fails loading
+	if err := os.WriteFile(path, []byte("profiles:\n  broken:\n    ui:\n      page_size: 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected an invalid profile to fail validation")
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	root := t.TempDir()
+	ledger := filepath.Join(root, "main.beancount")
+	content := "files:\n  patterns_file: project.yaml\nui:\n  default_view: budgets\ntheme:\n  name: light\n"
+	if err := os.WriteFile(filepath.Join(root, ProjectFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	global := DefaultConfig()
+	overrides := Overrides{PatternsFile: "/tmp/flag.yaml", View: "problems", Theme: "high_contrast", ReadOnly: true, NoColor: true, WriteBack: true}
+	cfg, err := global.Override(overrides)
+	if err != nil {
+		t.Fatalf("Override: %v", err)
+	}
+	if cfg.Files.PatternsFile != "/tmp/flag.yaml" || cfg.UI.DefaultView != "problems" || cfg.Theme.Name != "high_contrast" || !cfg.UI.ReadOnly || cfg.Theme.ColorMode != "none" || !cfg.Remote.WriteBack {
+		t.Errorf("overrides not applied: %+v %+v %+v", cfg.Files, cfg.UI, cfg.Theme)
+	}
+	if cfg.Overrides() != overrides {
+		t.Errorf("expected the overrides kept, got %+v", cfg.Overrides())
+	}
+	if global.UI.DefaultView != "dashboard" || global.UI.ReadOnly {
+		t.Error("the overridden config should be left unchanged")
+	}
+
+	// They win over the project file too
+	project, _, err := cfg.ForLedger(ledger)
+	if err != nil {
+		t.Fatalf("ForLedger: %v", err)
+	}
+	if project.Files.PatternsFile != "/tmp/flag.yaml" || project.UI.DefaultView != "problems" || project.Theme.Name != "high_contrast" {
+		t.Errorf("project file won over the overrides: %+v %+v", project.Files, project.UI)
+	}
+
+	// Unset overrides keep the files' settings
+	project, _, err = global.ForLedger(ledger)
+	if err != nil || project.UI.DefaultView != "budgets" || project.Theme.Name != "light" || project.UI.ReadOnly {
+		t.Errorf("expected the project settings without overrides, got %+v, %v", project.UI, err)
+	}
+
+	for _, o := range []Overrides{{View: "nowhere"}, {Theme: "neon"}} {
+		if _, err := global.Override(o); err == nil {
+			t.Errorf("expected an error for %+v", o)
+		}
+	}
+}
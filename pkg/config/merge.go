@@ -0,0 +1,90 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Merge merges another config into this one (other takes precedence)
+// Every field is merged. A config read with Load overrides exactly the fields its file
+// gives, even as false, zero or empty; one built in code overrides the fields that are
+// set. Lists such as keybindings and the dashboard layout replace the whole list;
+// symbol, precision and archetype maps are merged per key.
+func (c *Config) Merge(other *Config) {
+	mergeValue(reflect.ValueOf(c).Elem(), reflect.ValueOf(other).Elem(), "", other.set)
+	for path := range other.set {
+		if c.set == nil {
+			c.set = make(map[string]bool)
+		}
+		c.set[path] = true
+	}
+}
+
+// mergeValue merges the exported fields of struct src into dst, recursing into
+// nested sections; prefix is the sections' dotted YAML path
+func mergeValue(dst, src reflect.Value, prefix string, set map[string]bool) {
+	for i := range src.NumField() {
+		field := src.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		path := prefix + yamlName(field)
+		from, to := src.Field(i), dst.Field(i)
+		given := !from.IsZero()
+		if set != nil {
+			given = set[path]
+		}
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			mergeValue(to, from, path+".", set)
+
+		case field.Type.Kind() == reflect.Map:
+			if !given || from.Len() == 0 {
+				continue
+			}
+			if to.IsNil() {
+				to.Set(reflect.MakeMapWithSize(field.Type, from.Len()))
+			}
+			iter := from.MapRange()
+			for iter.Next() {
+				to.SetMapIndex(iter.Key(), iter.Value())
+			}
+
+		case given:
+			to.Set(from)
+		}
+	}
+}
+
+// yamlName returns the key a field is written under
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// setFields returns the dotted paths of every key in a YAML document or mapping
+func setFields(node *yaml.Node) map[string]bool {
+	set := make(map[string]bool)
+	var walk func(node *yaml.Node, prefix string)
+	walk = func(node *yaml.Node, prefix string) {
+		if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+			node = node.Content[0]
+		}
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			path := prefix + node.Content[i].Value
+			set[path] = true
+			walk(node.Content[i+1], path+".")
+		}
+	}
+	walk(node, "")
+	return set
+}
@@ -0,0 +1,58 @@
+package config
+
+import "fmt"
+
+// Overrides are settings given on the command line; they win over the config file,
+// the profile and the ledger's project file alike
+type Overrides struct {
+	PatternsFile string // Patterns file used instead of files.patterns_file
+	View         string // View opened instead of ui.default_view and the last session's
+	Theme        string // Built-in theme used instead of theme.name
+	ReadOnly     bool   // Open ledgers read-only whatever ui.read_only says
+	NoColor      bool   // Render without colors whatever theme.color_mode says
+	WriteBack    bool   // Write remote ledgers back to the server whatever remote.write_back says
+}
+
+// Override returns this configuration with overrides applied; they are kept, so
+// ForLedger applies them again over a project file
+// This configuration is left unchanged.
+func (c *Config) Override(o Overrides) (*Config, error) {
+	overridden, err := c.clone()
+	if err != nil {
+		return nil, err
+	}
+	overridden.overrides = o
+	overridden.applyOverrides()
+	if err := overridden.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid option: %w", err)
+	}
+	return overridden, nil
+}
+
+// Overrides returns the command-line overrides applied to this configuration
+func (c *Config) Overrides() Overrides {
+	return c.overrides
+}
+
+// applyOverrides sets the settings the overrides give
+func (c *Config) applyOverrides() {
+	o := c.overrides
+	if o.PatternsFile != "" {
+		c.Files.PatternsFile = o.PatternsFile
+	}
+	if o.View != "" {
+		c.UI.DefaultView = o.View
+	}
+	if o.Theme != "" {
+		c.Theme.Name = o.Theme
+	}
+	if o.ReadOnly {
+		c.UI.ReadOnly = true
+	}
+	if o.NoColor {
+		c.Theme.ColorMode = "none"
+	}
+	if o.WriteBack {
+		c.Remote.WriteBack = true
+	}
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// appName names lima's directory inside each base directory
+const appName = "lima"
+
+// ConfigDir returns where the configuration file lives: $XDG_CONFIG_HOME/lima, else
+// ~/.config/lima, ~/Library/Application Support/lima on macOS or %AppData%\lima on Windows
+func ConfigDir() string {
+	return baseDir("XDG_CONFIG_HOME", os.UserConfigDir)
+}
+
+// DataDir returns where patterns, budgets, the audit log and backups live: $XDG_DATA_HOME/lima,
+// else ~/.local/share/lima, or the configuration directory on macOS and Windows
+func DataDir() string {
+	return baseDir("XDG_DATA_HOME", func() (string, error) {
+		if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+			return os.UserConfigDir()
+		}
+		home, err := os.UserHomeDir()
+		return filepath.Join(home, ".local", "share"), err
+	})
+}
+
+// CacheDir returns where the recent files and session state live: $XDG_CACHE_HOME/lima,
+// else ~/.cache/lima, ~/Library/Caches/lima on macOS or %LocalAppData%\lima on Windows
+func CacheDir() string {
+	return baseDir("XDG_CACHE_HOME", os.UserCacheDir)
+}
+
+// baseDir returns lima's directory under the base directory an XDG variable names, or
+// under the platform's default when it is unset; relative paths are ignored as the
+// specification requires
+func baseDir(env string, fallback func() (string, error)) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName)
+	}
+	dir, err := fallback()
+	if err != nil {
+		// No home directory; use the working directory rather than the filesystem root
+		return appName
+	}
+	return filepath.Join(dir, appName)
+}
+
+// ExpandHome replaces a leading ~ with the user's home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// LegacyDir returns ~/.config/lima, where every file lived before the XDG directories
+func LegacyDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", appName)
+}
+
+// Location is a file lima reads or writes, named for display
+type Location struct {
+	Name string
+	Path string
+}
+
+// Locations returns the resolved paths of lima's files, with the configuration read
+// from path; unset optional files are ""
+func (c *Config) Locations(path string) []Location {
+	return []Location{
+		{"Config file", path},
+		{"Default ledger", c.Files.DefaultLedger},
+		{"Patterns", c.Files.PatternsFile},
+		{"Budgets", c.Files.BudgetsFile},
+		{"Importers", c.Files.ImportersFile},
+		{"Prices", c.Files.PricesFile},
+		{"Price cache", c.Files.PriceCache},
+		{"Merchants", c.Categorization.Merchants.File},
+		{"Audit log", c.Categorization.AuditLog},
+		{"Recent files", c.Files.RecentFiles},
+		{"Session state", c.Files.StateFile},
+		{"Backups", c.Backup.Dir},
+		{"Age identity", c.Encryption.AgeIdentity},
+		{"Inbox", c.Watch.Inbox},
+	}
+}
+
+// Move records a file migrated out of the legacy directory
+type Move struct {
+	From string
+	To   string
+}
+
+// Migrate moves files left in the legacy ~/.config/lima to their XDG locations
+// The configuration file moves first; the other files only move while the configuration
+// leaves their path at its default, so explicitly configured paths keep working. Files
+// already present at the destination are never overwritten.
+func Migrate() ([]Move, error) {
+	legacy := LegacyDir()
+	if _, err := os.Stat(legacy); err != nil {
+		return nil, nil
+	}
+
+	var moves []Move
+	move := func(from, to string) error {
+		if from == to {
+			return nil
+		}
+		if _, err := os.Stat(from); err != nil {
+			return nil
+		}
+		if _, err := os.Stat(to); err == nil {
+			return nil
+		}
+		if err := moveFile(from, to); err != nil {
+			return err
+		}
+		moves = append(moves, Move{From: from, To: to})
+		return nil
+	}
+
+	if err := move(filepath.Join(legacy, "config.yaml"), DefaultConfigPath()); err != nil {
+		return moves, err
+	}
+
+	cfg, err := LoadDefault()
+	if err != nil {
+		return moves, err
+	}
+	defaults := DefaultConfig()
+	files := []struct {
+		name       string
+		configured string
+		def        string
+	}{
+		{"patterns.yaml", cfg.Files.PatternsFile, defaults.Files.PatternsFile},
+		{"budgets.yaml", cfg.Files.BudgetsFile, defaults.Files.BudgetsFile},
+		{"audit.log", cfg.Categorization.AuditLog, defaults.Categorization.AuditLog},
+		{"recent_files", cfg.Files.RecentFiles, defaults.Files.RecentFiles},
+		{"state.yaml", cfg.Files.StateFile, defaults.Files.StateFile},
+	}
+	for _, file := range files {
+		if file.configured != file.def {
+			continue
+		}
+		if err := move(filepath.Join(legacy, file.name), file.def); err != nil {
+			return moves, err
+		}
+	}
+
+	// Tidy up when nothing else was kept there; a non-empty directory stays
+	if len(moves) > 0 && legacy != ConfigDir() {
+		_ = os.Remove(legacy)
+	}
+	return moves, nil
+}
+
+// moveFile renames a file, creating the destination directory, and falls back to
+// copying when renaming fails, as it does across filesystems
+func moveFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(to)
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(to)
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	return os.Remove(from)
+}
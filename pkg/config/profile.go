@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileEnv names the environment variable choosing a profile when --profile is not given
+const ProfileEnv = "LIMA_PROFILE"
+
+// ProfileNames returns the names of the configured profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns this configuration with a named profile's settings applied over it;
+// the empty name applies none
+// This configuration is left unchanged.
+func (c *Config) Profile(name string) (*Config, error) {
+	if name == "" {
+		return c, nil
+	}
+	node, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 {
+			return nil, fmt.Errorf("unknown profile %q: no profiles are configured", name)
+		}
+		return nil, fmt.Errorf("unknown profile %q (have %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	profile := &Config{}
+	if err := node.Decode(profile); err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+	profile.set = setFields(&node)
+
+	merged, err := c.clone()
+	if err != nil {
+		return nil, err
+	}
+	merged.Profiles = nil
+	merged.Merge(profile)
+	merged.Profiles = nil // Profiles do not nest
+	if err := merged.Validate(); err != nil {
+		return nil, fmt.Errorf("profile %s: %w", name, err)
+	}
+	return merged, nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile names a ledger's own configuration, found next to the ledger or in a
+// directory above it
+const ProjectFile = ".lima.yaml"
+
+// FindProject returns the project file nearest to a ledger, walking up from the
+// ledger's directory, or "" when there is none
+func FindProject(ledger string) string {
+	dir, err := filepath.Abs(filepath.Dir(ledger))
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ForLedger returns the configuration a ledger is opened with: this one overridden by
+// the ledger's project file, and the project file's path, or "" without one
+// File paths in the project file starting with ~ are in the home directory, and other
+// relative ones are resolved against its directory. This configuration is left unchanged.
+func (c *Config) ForLedger(ledger string) (*Config, string, error) {
+	path := FindProject(ledger)
+	if path == "" {
+		return c, "", nil
+	}
+	project, err := Load(path)
+	if err != nil {
+		return c, path, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for _, file := range []*string{
+		&project.Files.DefaultLedger,
+		&project.Files.PatternsFile,
+		&project.Files.BudgetsFile,
+		&project.Files.ImportersFile,
+		&project.Files.PricesFile,
+		&project.Files.PriceCache,
+		&project.Files.RecentFiles,
+		&project.Files.StateFile,
+		&project.Categorization.AuditLog,
+		&project.Categorization.Merchants.File,
+		&project.Backup.Dir,
+		&project.Encryption.AgeIdentity,
+		&project.Watch.Inbox,
+	} {
+		*file = ExpandHome(*file)
+		if *file != "" && !filepath.IsAbs(*file) {
+			*file = filepath.Join(dir, *file)
+		}
+	}
+
+	merged, err := c.clone()
+	if err != nil {
+		return c, path, err
+	}
+	merged.Merge(project)
+	merged.applyOverrides()
+	if err := merged.Validate(); err != nil {
+		return c, path, fmt.Errorf("%s: invalid configuration: %w", path, err)
+	}
+	return merged, path, nil
+}
+
+// clone returns a deep copy, so merging into it leaves the original's maps alone
+func (c *Config) clone() (*Config, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	copied := &Config{}
+	if err := yaml.Unmarshal(data, copied); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	copied.set = maps.Clone(c.set)
+	copied.overrides = c.overrides
+	return copied, nil
+}